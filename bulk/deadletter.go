@@ -0,0 +1,204 @@
+package bulk
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// DeadLetter represents an item the Submitter gave up on.
+type DeadLetter struct {
+	Item       Item      `json:"item"`
+	Reason     string    `json:"reason"`
+	StatusCode int       `json:"status_code"`
+	FailedAt   time.Time `json:"failed_at"`
+}
+
+// DeadLetterStore keeps the items that permanently failed so they can be inspected and retried.
+type DeadLetterStore interface {
+	Put(deadLetter DeadLetter) error
+	List() ([]DeadLetter, error)
+	Remove(id string) error
+}
+
+// ErrDeadLetterNotFound is returned when retrying an unknown dead letter.
+var ErrDeadLetterNotFound = errors.New("bulk: dead letter not found")
+
+// MemoryDeadLetterStore is a DeadLetterStore kept in memory.
+type MemoryDeadLetterStore struct {
+	mutex       sync.Mutex
+	deadLetters []DeadLetter
+}
+
+// NewMemoryDeadLetterStore returns an empty MemoryDeadLetterStore.
+func NewMemoryDeadLetterStore() *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{}
+}
+
+// Put stores the dead letter, replacing any previous one of the same item.
+func (store *MemoryDeadLetterStore) Put(deadLetter DeadLetter) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	for index := range store.deadLetters {
+		if store.deadLetters[index].Item.ID == deadLetter.Item.ID {
+			store.deadLetters[index] = deadLetter
+			return nil
+		}
+	}
+	store.deadLetters = append(store.deadLetters, deadLetter)
+	return nil
+}
+
+// List returns every stored dead letter, oldest first.
+func (store *MemoryDeadLetterStore) List() ([]DeadLetter, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return append([]DeadLetter(nil), store.deadLetters...), nil
+}
+
+// Remove deletes the dead letter of the given item.
+func (store *MemoryDeadLetterStore) Remove(id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	for index := range store.deadLetters {
+		if store.deadLetters[index].Item.ID == id {
+			store.deadLetters = append(store.deadLetters[:index], store.deadLetters[index+1:]...)
+			return nil
+		}
+	}
+	return ErrDeadLetterNotFound
+}
+
+// FileDeadLetterStore is a DeadLetterStore persisted as a JSON file.
+type FileDeadLetterStore struct {
+	memoryStore *MemoryDeadLetterStore
+	mutex       sync.Mutex
+	path        string
+}
+
+// OpenFileDeadLetterStore opens (or creates) the dead letter file at path.
+func OpenFileDeadLetterStore(path string) (*FileDeadLetterStore, error) {
+	store := &FileDeadLetterStore{
+		memoryStore: NewMemoryDeadLetterStore(),
+		path:        path,
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+	if unmarshalError := json.Unmarshal(data, &store.memoryStore.deadLetters); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return store, nil
+}
+
+// save rewrites the whole file atomically.
+func (store *FileDeadLetterStore) save() error {
+	deadLetters, _ := store.memoryStore.List()
+	data, err := json.MarshalIndent(deadLetters, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporaryPath := store.path + ".tmp"
+	if err := ioutil.WriteFile(temporaryPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, store.path)
+}
+
+// Put stores the dead letter, replacing any previous one of the same item.
+func (store *FileDeadLetterStore) Put(deadLetter DeadLetter) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.memoryStore.Put(deadLetter)
+	return store.save()
+}
+
+// List returns every stored dead letter, oldest first.
+func (store *FileDeadLetterStore) List() ([]DeadLetter, error) {
+	return store.memoryStore.List()
+}
+
+// Remove deletes the dead letter of the given item.
+func (store *FileDeadLetterStore) Remove(id string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if err := store.memoryStore.Remove(id); err != nil {
+		return err
+	}
+	return store.save()
+}
+
+// isPermanentFailure reports whether retrying the submission can't succeed,
+// i.e. the server refused the request itself (validation errors, unsupported types...).
+func isPermanentFailure(err error) (bool, int) {
+	threatMatrixError := &gothreatmatrix.ThreatMatrixError{}
+	if !errors.As(err, &threatMatrixError) {
+		return false, 0
+	}
+	statusCode := threatMatrixError.StatusCode
+	switch statusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false, statusCode
+	}
+	return statusCode >= http.StatusBadRequest && statusCode < http.StatusInternalServerError, statusCode
+}
+
+// deadLetterFor returns the dead letter of a failed item, nil when it deserves another attempt.
+func (submitter *Submitter) deadLetterFor(item Item, err error) *DeadLetter {
+	if submitter.DeadLetters == nil {
+		return nil
+	}
+	permanent, statusCode := isPermanentFailure(err)
+	if !permanent && (submitter.MaxAttempts <= 0 || item.Attempts < submitter.MaxAttempts) {
+		return nil
+	}
+	return &DeadLetter{
+		Item:       item,
+		Reason:     err.Error(),
+		StatusCode: statusCode,
+		FailedAt:   time.Now().UTC(),
+	}
+}
+
+// RetryDeadLetter moves the dead letter of the given item back into the queue with its attempts reset.
+func (submitter *Submitter) RetryDeadLetter(id string) error {
+	deadLetters, err := submitter.DeadLetters.List()
+	if err != nil {
+		return err
+	}
+	for _, deadLetter := range deadLetters {
+		if deadLetter.Item.ID == id {
+			item := deadLetter.Item
+			item.Attempts = 0
+			if err := submitter.queue.Push(item); err != nil {
+				return err
+			}
+			return submitter.DeadLetters.Remove(id)
+		}
+	}
+	return ErrDeadLetterNotFound
+}
+
+// RetryAllDeadLetters moves every dead letter back into the queue.
+func (submitter *Submitter) RetryAllDeadLetters() error {
+	deadLetters, err := submitter.DeadLetters.List()
+	if err != nil {
+		return err
+	}
+	for _, deadLetter := range deadLetters {
+		if err := submitter.RetryDeadLetter(deadLetter.Item.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
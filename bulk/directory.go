@@ -0,0 +1,323 @@
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// ErrCorruptManifest is returned by SubmitDirectory when a line of its manifest, other than a torn last line, cannot be read.
+var ErrCorruptManifest = errors.New("bulk: the directory manifest is corrupt")
+
+// Values of the FileResult.Status field.
+const (
+	// FILE_SUBMITTED files were submitted, their job created.
+	FILE_SUBMITTED = "submitted"
+	// FILE_EXISTING files were not submitted, the instance already having an analysis of their hash.
+	FILE_EXISTING = "existing"
+	// FILE_RESUMED files were not submitted, the manifest of an earlier run recording them as unchanged and done.
+	FILE_RESUMED = "resumed"
+	// FILE_FAILED files could not be hashed or submitted, see FileResult.Err. A later run submits them again.
+	FILE_FAILED = "failed"
+)
+
+// DirectoryOptions represents the fields used to configure SubmitDirectory.
+type DirectoryOptions struct {
+	// Params are the params of every analysis. Its IdempotencyKey, when set, is suffixed with the md5 of every file.
+	Params gothreatmatrix.BasicAnalysisParams
+	// Concurrency is the number of files submitted at a time, values lower than 1 are treated as 1.
+	Concurrency int
+	// ManifestPath, when set, is the journal every outcome is appended to. A run reading an existing manifest skips
+	// the files it records as submitted or existing, unless their content changed since, so that an interrupted run
+	// continues where it left off.
+	ManifestPath string
+	// Include, when set, selects the files to submit by their path, relative to the directory. Subdirectories are
+	// always walked.
+	Include func(relativePath string) bool
+	// ResubmitExisting, when true, submits the files the instance already analyzed instead of skipping them.
+	ResubmitExisting bool
+	// ExistingWithinMinutes is how far back an existing analysis counts, 0 for any analysis.
+	ExistingWithinMinutes int
+	// OnFile, when set, is called with the outcome of every file, in the order they complete.
+	OnFile func(result FileResult)
+}
+
+// FileResult represents the outcome of a file of SubmitDirectory, also written as a line of its manifest.
+type FileResult struct {
+	// Path is the path of the file, relative to the directory, slash separated.
+	Path   string `json:"path"`
+	Md5    string `json:"md5"`
+	Status string `json:"status"`
+	// JobID is the job of the analysis submitted or found, 0 for a failed file.
+	JobID int `json:"job_id,omitempty"`
+	// Error is the message of Err, kept in the manifest.
+	Error string    `json:"error,omitempty"`
+	At    time.Time `json:"at"`
+	Err   error     `json:"-"`
+}
+
+// manifestWriter appends the outcomes of SubmitDirectory to its manifest.
+type manifestWriter struct {
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// write appends the result to the manifest and syncs it to disk.
+func (manifest *manifestWriter) write(result FileResult) error {
+	manifest.mutex.Lock()
+	defer manifest.mutex.Unlock()
+	resultJson, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if _, err := manifest.file.Write(append(resultJson, '\n')); err != nil {
+		return err
+	}
+	return manifest.file.Sync()
+}
+
+// readManifest returns the files an earlier run recorded as done, their md5 by path. A torn last line, what a crash
+// mid-write leaves behind, is truncated off the manifest so that the results appended next start on a line of
+// their own; any other unreadable line returns an error wrapping ErrCorruptManifest.
+func readManifest(manifestPath string) (map[string]string, error) {
+	done := map[string]string{}
+	file, err := os.OpenFile(manifestPath, os.O_RDWR, 0600)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	// * the length of the lines read whole
+	length := int64(0)
+	for lineNumber := 1; ; lineNumber++ {
+		line, readError := reader.ReadBytes('\n')
+		if readError != nil && readError != io.EOF {
+			return nil, readError
+		}
+		// * only the last line can lack its newline, it is torn when a crash happened mid-write
+		torn := readError == io.EOF
+		if torn && len(line) == 0 {
+			break
+		}
+		result := FileResult{}
+		if unmarshalError := json.Unmarshal(line, &result); unmarshalError != nil {
+			if torn {
+				if err := file.Truncate(length); err != nil {
+					return nil, err
+				}
+				break
+			}
+			return nil, fmt.Errorf("%w: %s line %d: %v", ErrCorruptManifest, manifestPath, lineNumber, unmarshalError)
+		}
+		switch result.Status {
+		case FILE_SUBMITTED, FILE_EXISTING, FILE_RESUMED:
+			done[result.Path] = result.Md5
+		default:
+			delete(done, result.Path)
+		}
+		if torn {
+			// * a whole result missing only its newline is kept, and ended
+			if _, err := file.WriteAt([]byte("\n"), length+int64(len(line))); err != nil {
+				return nil, err
+			}
+			break
+		}
+		length += int64(len(line))
+	}
+	return done, nil
+}
+
+// directoryFiles returns the files of the directory selected by include, relative and slash separated, in lexical order.
+func directoryFiles(root string, include func(relativePath string) bool) ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		if include == nil || include(relativePath) {
+			files = append(files, relativePath)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// fileMd5 returns the hex encoded md5 of the file.
+func fileMd5(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// SubmitDirectory submits every file of the directory, subdirectories included, in lexical order with bounded
+// concurrency, skipping the ones the instance already analyzed (see ThreatMatrixClient.AskAnalysisAvailability)
+// and, with a manifest, the ones an earlier run already did. It returns the outcome of every file in lexical
+// order: a failed file does not stop the run, only an unreadable directory or manifest, or ctx being done,
+// stop it, the outcomes so far being returned.
+func SubmitDirectory(ctx context.Context, client *gothreatmatrix.ThreatMatrixClient, root string, options *DirectoryOptions) ([]FileResult, error) {
+	if options == nil {
+		options = &DirectoryOptions{}
+	}
+	files, err := directoryFiles(root, options.Include)
+	if err != nil {
+		return nil, err
+	}
+	done := map[string]string{}
+	var manifest *manifestWriter
+	if options.ManifestPath != "" {
+		if done, err = readManifest(options.ManifestPath); err != nil {
+			return nil, err
+		}
+		manifestFile, err := os.OpenFile(options.ManifestPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		defer manifestFile.Close()
+		manifest = &manifestWriter{file: manifestFile}
+	}
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]FileResult, len(files))
+	completed := make([]bool, len(files))
+	indexes := make(chan int)
+	var waitGroup sync.WaitGroup
+	var mutex sync.Mutex
+	var runError error
+	for worker := 0; worker < concurrency; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for index := range indexes {
+				result := submitFile(ctx, client, root, files[index], done, options)
+				if manifest != nil {
+					if err := manifest.write(result); err != nil {
+						mutex.Lock()
+						runError = err
+						mutex.Unlock()
+					}
+				}
+				mutex.Lock()
+				results[index] = result
+				completed[index] = true
+				mutex.Unlock()
+				if options.OnFile != nil {
+					options.OnFile(result)
+				}
+			}
+		}()
+	}
+	for index := range files {
+		mutex.Lock()
+		stop := runError != nil
+		mutex.Unlock()
+		if stop || ctx.Err() != nil {
+			break
+		}
+		indexes <- index
+	}
+	close(indexes)
+	waitGroup.Wait()
+
+	submitted := []FileResult{}
+	for index, result := range results {
+		if completed[index] {
+			submitted = append(submitted, result)
+		}
+	}
+	if runError != nil {
+		return submitted, runError
+	}
+	return submitted, ctx.Err()
+}
+
+// submitFile hashes the file then submits it, unless it is done or the instance already analyzed it.
+func submitFile(ctx context.Context, client *gothreatmatrix.ThreatMatrixClient, root string, relativePath string, done map[string]string, options *DirectoryOptions) FileResult {
+	result := FileResult{Path: relativePath}
+	failed := func(err error) FileResult {
+		result.Status = FILE_FAILED
+		result.Err = err
+		result.Error = err.Error()
+		result.At = time.Now()
+		return result
+	}
+	filePath := filepath.Join(root, filepath.FromSlash(relativePath))
+	md5Hash, err := fileMd5(filePath)
+	if err != nil {
+		return failed(err)
+	}
+	result.Md5 = md5Hash
+	if done[relativePath] == md5Hash {
+		result.Status = FILE_RESUMED
+		result.At = time.Now()
+		return result
+	}
+	if !options.ResubmitExisting {
+		availability, err := client.AskAnalysisAvailability(ctx, &gothreatmatrix.AnalysisAvailabilityParams{
+			Md5:        md5Hash,
+			Analyzers:  options.Params.AnalyzersRequested,
+			MinutesAgo: options.ExistingWithinMinutes,
+		})
+		if err != nil {
+			return failed(err)
+		}
+		if availability.Exists() {
+			result.Status = FILE_EXISTING
+			result.JobID = availability.JobID
+			result.At = time.Now()
+			return result
+		}
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return failed(err)
+	}
+	defer file.Close()
+	params := options.Params
+	if params.IdempotencyKey != "" {
+		params.IdempotencyKey += "-" + md5Hash
+	}
+	analysisResponse, err := client.CreateFileAnalysis(ctx, &gothreatmatrix.FileAnalysisParams{
+		BasicAnalysisParams: params,
+		File:                file,
+	})
+	if err != nil {
+		return failed(err)
+	}
+	result.Status = FILE_SUBMITTED
+	result.JobID = analysisResponse.JobID
+	result.At = time.Now()
+	return result
+}
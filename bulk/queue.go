@@ -0,0 +1,358 @@
+// Package bulk provides a submitter that drains large amounts of observables into ThreatMatrix, and
+// SubmitDirectory, submitting every file of a directory.
+package bulk
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// Priority represents the lane of an Item: items of a higher lane are always handed out first.
+type Priority int
+
+// Values of the Priority enum.
+const (
+	// BATCH is the lane of backfills and other bulk work.
+	BATCH Priority = iota
+	// INTERACTIVE is the lane of lookups a person is waiting for.
+	INTERACTIVE
+)
+
+// priorityLanes is the number of Priority values.
+const priorityLanes = int(INTERACTIVE) + 1
+
+// Item represents an observable waiting to be submitted.
+type Item struct {
+	ID       string                                  `json:"id"`
+	Params   gothreatmatrix.ObservableAnalysisParams `json:"params"`
+	Attempts int                                     `json:"attempts"`
+	Priority Priority                                `json:"priority"`
+}
+
+// lane returns the index of the item's lane, unknown priorities are treated as BATCH.
+func (item *Item) lane() int {
+	if item.Priority < BATCH || int(item.Priority) >= priorityLanes {
+		return int(BATCH)
+	}
+	return int(item.Priority)
+}
+
+// Queue holds the items of a Submitter.
+//
+// Items handed out by Pop stay in-flight until they are acknowledged through Ack or put back through Nack.
+// Recover puts every in-flight item back so none is lost: delivery is at-least-once.
+type Queue interface {
+	Push(item Item) error
+	Pop() (Item, bool, error)
+	Ack(id string) error
+	Nack(item Item) error
+	Recover() error
+	Len() int
+	Close() error
+}
+
+// ErrQueueClosed is returned when using a closed Queue.
+var ErrQueueClosed = errors.New("bulk: queue is closed")
+
+// MemoryQueue is a Queue kept in memory, its items are lost when the process exits.
+// Items are handed out by Priority, then in the order they were pushed.
+type MemoryQueue struct {
+	mutex    sync.Mutex
+	lanes    [priorityLanes][]Item
+	inFlight map[string]Item
+	closed   bool
+}
+
+// NewMemoryQueue returns an empty MemoryQueue.
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		inFlight: map[string]Item{},
+	}
+}
+
+// Push adds the item at the end of the queue.
+func (queue *MemoryQueue) Push(item Item) error {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	if queue.closed {
+		return ErrQueueClosed
+	}
+	queue.append(item)
+	return nil
+}
+
+// append adds the item at the end of its lane.
+func (queue *MemoryQueue) append(item Item) {
+	lane := item.lane()
+	queue.lanes[lane] = append(queue.lanes[lane], item)
+}
+
+// Pop hands out the first pending item, the boolean is false when there's none.
+func (queue *MemoryQueue) Pop() (Item, bool, error) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	if queue.closed {
+		return Item{}, false, ErrQueueClosed
+	}
+	for lane := priorityLanes - 1; lane >= 0; lane-- {
+		if len(queue.lanes[lane]) == 0 {
+			continue
+		}
+		item := queue.lanes[lane][0]
+		queue.lanes[lane] = queue.lanes[lane][1:]
+		queue.inFlight[item.ID] = item
+		return item, true, nil
+	}
+	return Item{}, false, nil
+}
+
+// Ack removes the in-flight item for good.
+func (queue *MemoryQueue) Ack(id string) error {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	delete(queue.inFlight, id)
+	return nil
+}
+
+// Nack puts the in-flight item back at the end of the queue.
+func (queue *MemoryQueue) Nack(item Item) error {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	delete(queue.inFlight, item.ID)
+	queue.append(item)
+	return nil
+}
+
+// Recover puts every in-flight item back in the queue.
+func (queue *MemoryQueue) Recover() error {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	for id, item := range queue.inFlight {
+		queue.append(item)
+		delete(queue.inFlight, id)
+	}
+	return nil
+}
+
+// Len returns the number of pending and in-flight items.
+func (queue *MemoryQueue) Len() int {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	return queue.pendingLen() + len(queue.inFlight)
+}
+
+// pendingLen returns the number of items waiting in every lane.
+func (queue *MemoryQueue) pendingLen() int {
+	length := 0
+	for lane := range queue.lanes {
+		length += len(queue.lanes[lane])
+	}
+	return length
+}
+
+// LenByPriority returns the number of pending items of the given priority.
+func (queue *MemoryQueue) LenByPriority(priority Priority) int {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	item := Item{Priority: priority}
+	return len(queue.lanes[item.lane()])
+}
+
+// Close closes the queue.
+func (queue *MemoryQueue) Close() error {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	queue.closed = true
+	return nil
+}
+
+// journalEntry represents one operation written in a FileQueue journal.
+type journalEntry struct {
+	Operation string `json:"op"`
+	Item      *Item  `json:"item,omitempty"`
+	ID        string `json:"id,omitempty"`
+}
+
+// valid reports whether the entry is one the FileQueue writes.
+func (entry *journalEntry) valid() bool {
+	switch entry.Operation {
+	case "push":
+		return entry.Item != nil
+	case "ack":
+		return entry.ID != ""
+	}
+	return false
+}
+
+// ErrCorruptJournal is returned by OpenFileQueue when an entry of the journal, other than a torn last line, cannot be read.
+var ErrCorruptJournal = errors.New("bulk: the queue journal is corrupt")
+
+// FileQueue is a Queue persisted in an append-only journal file so submissions survive process restarts.
+// Every item that was not acknowledged before a crash is delivered again once the queue is reopened.
+type FileQueue struct {
+	*MemoryQueue
+	mutex sync.Mutex
+	path  string
+	file  *os.File
+}
+
+// OpenFileQueue opens (or creates) the journal at path and restores every unacknowledged item.
+// A torn last line, what a crash mid-write leaves behind, is dropped; any other unreadable entry returns
+// an error wrapping ErrCorruptJournal rather than silently losing the items it holds.
+func OpenFileQueue(path string) (*FileQueue, error) {
+	memoryQueue := NewMemoryQueue()
+	file, err := os.Open(path)
+	if err == nil {
+		reader := bufio.NewReader(file)
+		order := []string{}
+		items := map[string]Item{}
+		for lineNumber := 1; ; lineNumber++ {
+			line, readError := reader.ReadBytes('\n')
+			if readError != nil && readError != io.EOF {
+				file.Close()
+				return nil, readError
+			}
+			// * only the last line can lack its newline, it is torn when a crash happened mid-write
+			torn := readError == io.EOF
+			if torn && len(line) == 0 {
+				break
+			}
+			entry := journalEntry{}
+			unmarshalError := json.Unmarshal(line, &entry)
+			if unmarshalError == nil && !entry.valid() {
+				unmarshalError = errors.New("unknown entry")
+			}
+			if unmarshalError != nil {
+				if torn {
+					break
+				}
+				file.Close()
+				return nil, fmt.Errorf("%w: %s line %d: %v", ErrCorruptJournal, path, lineNumber, unmarshalError)
+			}
+			switch entry.Operation {
+			case "push":
+				if _, ok := items[entry.Item.ID]; !ok {
+					order = append(order, entry.Item.ID)
+				}
+				items[entry.Item.ID] = *entry.Item
+			case "ack":
+				delete(items, entry.ID)
+			}
+			if torn {
+				break
+			}
+		}
+		file.Close()
+		for _, id := range order {
+			if item, ok := items[id]; ok {
+				memoryQueue.append(item)
+				delete(items, id)
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	fileQueue := &FileQueue{
+		MemoryQueue: memoryQueue,
+		path:        path,
+	}
+	if err := fileQueue.compact(); err != nil {
+		return nil, err
+	}
+	return fileQueue, nil
+}
+
+// compact rewrites the journal with only the pending items.
+func (queue *FileQueue) compact() error {
+	temporaryPath := queue.path + ".tmp"
+	file, err := os.OpenFile(temporaryPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for lane := range queue.lanes {
+		for index := range queue.lanes[lane] {
+			if err := encoder.Encode(journalEntry{Operation: "push", Item: &queue.lanes[lane][index]}); err != nil {
+				file.Close()
+				return err
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	file.Close()
+	if err := os.Rename(temporaryPath, queue.path); err != nil {
+		return err
+	}
+	queue.file, err = os.OpenFile(queue.path, os.O_APPEND|os.O_WRONLY, 0600)
+	return err
+}
+
+// write appends the entry to the journal and syncs it to disk.
+func (queue *FileQueue) write(entry journalEntry) error {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	if queue.file == nil {
+		return ErrQueueClosed
+	}
+	entryJson, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := queue.file.Write(append(entryJson, '\n')); err != nil {
+		return err
+	}
+	return queue.file.Sync()
+}
+
+// Push persists the item then adds it at the end of the queue.
+func (queue *FileQueue) Push(item Item) error {
+	if err := queue.write(journalEntry{Operation: "push", Item: &item}); err != nil {
+		return err
+	}
+	return queue.MemoryQueue.Push(item)
+}
+
+// Ack removes the in-flight item for good, it won't be delivered again after a restart.
+func (queue *FileQueue) Ack(id string) error {
+	if err := queue.write(journalEntry{Operation: "ack", ID: id}); err != nil {
+		return err
+	}
+	return queue.MemoryQueue.Ack(id)
+}
+
+// Nack persists the item's new state then puts it back at the end of the queue.
+func (queue *FileQueue) Nack(item Item) error {
+	if err := queue.write(journalEntry{Operation: "push", Item: &item}); err != nil {
+		return err
+	}
+	return queue.MemoryQueue.Nack(item)
+}
+
+// Close closes the journal file.
+func (queue *FileQueue) Close() error {
+	queue.MemoryQueue.Close()
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	if queue.file == nil {
+		return nil
+	}
+	err := queue.file.Close()
+	queue.file = nil
+	return err
+}
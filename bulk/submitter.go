@@ -0,0 +1,149 @@
+package bulk
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// Result represents the outcome of submitting an Item.
+type Result struct {
+	Item     Item
+	Response *gothreatmatrix.AnalysisResponse
+	Err      error
+}
+
+// Submitter drains a Queue of observables into ThreatMatrix with bounded concurrency.
+type Submitter struct {
+	client      *gothreatmatrix.ThreatMatrixClient
+	queue       Queue
+	concurrency int
+	// OnResult, when set, is called after every submission attempt.
+	OnResult func(result Result)
+	// DeadLetters, when set, receives the items that permanently failed instead of keeping them in the queue.
+	DeadLetters DeadLetterStore
+	// MaxAttempts is the number of attempts after which a failing item becomes a dead letter, 0 means unlimited.
+	// Items refused by the server (4xx) become dead letters right away. The attempts interrupted by the cancellation
+	// of the run are not counted.
+	MaxAttempts int
+}
+
+// NewSubmitter returns a Submitter that sends the items of queue through client.
+// A concurrency lower than 1 is treated as 1.
+func NewSubmitter(client *gothreatmatrix.ThreatMatrixClient, queue Queue, concurrency int) *Submitter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Submitter{
+		client:      client,
+		queue:       queue,
+		concurrency: concurrency,
+	}
+}
+
+// newItemID returns a random identifier for an Item.
+func newItemID() (string, error) {
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(randomBytes), nil
+}
+
+// Enqueue adds the observable to the BATCH lane of the queue and returns the identifier of its Item.
+func (submitter *Submitter) Enqueue(params *gothreatmatrix.ObservableAnalysisParams) (string, error) {
+	return submitter.EnqueueWithPriority(params, BATCH)
+}
+
+// EnqueueWithPriority adds the observable to the given lane of the queue and returns the identifier of its Item.
+// An INTERACTIVE item is handed to the next free worker, ahead of every BATCH item already queued.
+func (submitter *Submitter) EnqueueWithPriority(params *gothreatmatrix.ObservableAnalysisParams, priority Priority) (string, error) {
+	id, err := newItemID()
+	if err != nil {
+		return "", err
+	}
+	if err := submitter.queue.Push(Item{ID: id, Params: *params, Priority: priority}); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Resume puts back every item left in-flight by an interrupted run, then runs the submitter.
+func (submitter *Submitter) Resume(ctx context.Context) error {
+	if err := submitter.queue.Recover(); err != nil {
+		return err
+	}
+	return submitter.Run(ctx)
+}
+
+// Run submits the queued items until the queue is empty or ctx is done.
+// Items are acknowledged once submitted: an item that failed is left in the queue for a later run,
+// unless it became a dead letter.
+func (submitter *Submitter) Run(ctx context.Context) error {
+	var waitGroup sync.WaitGroup
+	var mutex sync.Mutex
+	var runError error
+	failed := []Item{}
+
+	for worker := 0; worker < submitter.concurrency; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for ctx.Err() == nil {
+				item, ok, err := submitter.queue.Pop()
+				if err != nil {
+					mutex.Lock()
+					runError = err
+					mutex.Unlock()
+					return
+				}
+				if !ok {
+					return
+				}
+				response, err := submitter.client.CreateObservableAnalysis(ctx, &item.Params)
+				// * the run was stopped, the item did not fail: the attempt is not counted
+				canceled := err != nil && ctx.Err() != nil
+				if !canceled {
+					item.Attempts++
+				}
+				if err == nil {
+					err = submitter.queue.Ack(item.ID)
+				} else if canceled {
+					mutex.Lock()
+					failed = append(failed, item)
+					mutex.Unlock()
+				} else if deadLetter := submitter.deadLetterFor(item, err); deadLetter != nil {
+					if putError := submitter.DeadLetters.Put(*deadLetter); putError != nil {
+						mutex.Lock()
+						failed = append(failed, item)
+						mutex.Unlock()
+					} else if ackError := submitter.queue.Ack(item.ID); ackError != nil {
+						err = ackError
+					}
+				} else {
+					mutex.Lock()
+					failed = append(failed, item)
+					mutex.Unlock()
+				}
+				if submitter.OnResult != nil {
+					submitter.OnResult(Result{Item: item, Response: response, Err: err})
+				}
+			}
+		}()
+	}
+	waitGroup.Wait()
+
+	// failed items are put back at the end so they don't spin in the current run
+	for _, item := range failed {
+		if err := submitter.queue.Nack(item); err != nil && runError == nil {
+			runError = err
+		}
+	}
+	if runError != nil {
+		return runError
+	}
+	return ctx.Err()
+}
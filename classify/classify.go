@@ -0,0 +1,64 @@
+// Package classify tells the observable classification of a value the way the ThreatMatrix server does,
+// without a round trip to it.
+package classify
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Values of the observable classifications of ThreatMatrix.
+const (
+	IP      = "ip"
+	URL     = "url"
+	DOMAIN  = "domain"
+	HASH    = "hash"
+	GENERIC = "generic"
+)
+
+// Values of the hash types Hash tells apart.
+const (
+	MD5    = "md5"
+	SHA1   = "sha1"
+	SHA256 = "sha256"
+)
+
+// The patterns of the observable classification of the server, which lowercases the value first.
+var (
+	urlRegex    = regexp.MustCompile(`^(?:htt|ft|tc)ps?://[a-z\d-]{1,63}(?:\.[a-z\d-]{1,63})+(?:/?|/.+)$`)
+	domainRegex = regexp.MustCompile(`^\.?[a-z\d-]{1,63}(?:\.[a-z\d-]{1,63})+$`)
+	hashRegex   = regexp.MustCompile(`^(?:[a-f\d]{32}|[a-f\d]{40}|[a-f\d]{64})$`)
+)
+
+// Observable returns the classification the server gives the value: IP, URL, DOMAIN, HASH, or GENERIC
+// when it is none of them.
+func Observable(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case net.ParseIP(value) != nil:
+		return IP
+	case urlRegex.MatchString(value):
+		return URL
+	case domainRegex.MatchString(value):
+		return DOMAIN
+	case hashRegex.MatchString(value):
+		return HASH
+	}
+	return GENERIC
+}
+
+// Hash returns the type of the hash, MD5, SHA1 or SHA256 by its length, an empty string when the value is no hash.
+func Hash(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if !hashRegex.MatchString(value) {
+		return ""
+	}
+	switch len(value) {
+	case 32:
+		return MD5
+	case 40:
+		return SHA1
+	}
+	return SHA256
+}
@@ -0,0 +1,92 @@
+// apply makes the tags and playbooks of a ThreatMatrix instance match the ones declared in a state file.
+// With -dry-run it only prints what would change, and -plan writes the same plan as JSON for tooling.
+//
+// Usage:
+//
+//	THREATMATRIX_URL=https://threatmatrix.example.com THREATMATRIX_TOKEN=... go run ./cmd/apply \
+//		-f state.yaml [-dry-run] [-plan plan.json]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/sirupsen/logrus"
+)
+
+// writePlan writes the plan as indented JSON to the path, - for the standard output.
+func writePlan(path string, plan *gothreatmatrix.ApplyPlan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func main() {
+	statePath := flag.String("f", "", "state file declaring the tags and playbooks, as YAML or JSON")
+	dryRun := flag.Bool("dry-run", false, "print what would change without writing anything")
+	planPath := flag.String("plan", "", "file the plan is written to as JSON, - for the standard output")
+	flag.Parse()
+
+	baseUrl := os.Getenv("THREATMATRIX_URL")
+	token := os.Getenv("THREATMATRIX_TOKEN")
+	if baseUrl == "" || token == "" {
+		fmt.Fprintln(os.Stderr, "THREATMATRIX_URL and THREATMATRIX_TOKEN are required")
+		os.Exit(2)
+	}
+	if *statePath == "" {
+		fmt.Fprintln(os.Stderr, "-f is required")
+		os.Exit(2)
+	}
+	state, err := gothreatmatrix.LoadDesiredState(*statePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	client := gothreatmatrix.NewThreatMatrixClient(&gothreatmatrix.ThreatMatrixClientOptions{
+		Url:   baseUrl,
+		Token: token,
+	}, nil, &gothreatmatrix.LoggerParams{Level: logrus.InfoLevel})
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	plan, err := client.PlanApply(ctx, state)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *planPath != "" {
+		if err := writePlan(*planPath, plan); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	// * the diff goes to the standard error when the standard output carries the JSON plan
+	diffOutput := os.Stdout
+	if *planPath == "-" {
+		diffOutput = os.Stderr
+	}
+	if err := plan.WriteDiff(diffOutput); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *dryRun || len(plan.Changes) == 0 {
+		return
+	}
+	if err := client.Apply(ctx, plan); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(diffOutput, "applied %d changes\n", len(plan.Changes))
+}
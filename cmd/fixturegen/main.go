@@ -0,0 +1,168 @@
+// fixturegen captures responses of a live ThreatMatrix instance into sanitized, versioned fixture files.
+//
+// The fixtures are decoded by the golden tests in the tests directory so that a server upgrade
+// renaming or retyping a field is caught by go test instead of by users.
+//
+// Usage:
+//
+//	THREATMATRIX_URL=https://threatmatrix.example.com THREATMATRIX_TOKEN=... go run ./cmd/fixturegen -version v4.0.1
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// fixture represents an endpoint to capture and the Go type its response decodes into.
+type fixture struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// manifest describes the fixtures captured from one server version.
+type manifest struct {
+	Version    string    `json:"version"`
+	CapturedAt time.Time `json:"captured_at"`
+	Fixtures   []fixture `json:"fixtures"`
+}
+
+var fixtures = []fixture{
+	{Name: "analyzer_configs", Path: constants.ANALYZER_CONFIG_URL, Type: "map[string]AnalyzerConfig"},
+	{Name: "connector_configs", Path: constants.CONNECTOR_CONFIG_URL, Type: "map[string]ConnectorConfig"},
+	{Name: "jobs", Path: constants.BASE_JOB_URL, Type: "JobListResponse"},
+	{Name: "tags", Path: constants.BASE_TAG_URL, Type: "[]Tag"},
+	{Name: "me_access", Path: constants.USER_DETAILS_URL, Type: "User"},
+}
+
+// sensitiveKeys are the fields whose values are replaced during sanitization.
+var sensitiveKeys = map[string]string{
+	"username":   "analyst",
+	"first_name": "Jane",
+	"last_name":  "Doe",
+	"full_name":  "Jane Doe",
+	"email":      "analyst@example.com",
+	"token":      "REDACTED",
+	"key":        "REDACTED",
+	"api_key":    "REDACTED",
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+
+// sanitize replaces personal data and secrets in a decoded JSON document.
+func sanitize(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, fieldValue := range typedValue {
+			if replacement, ok := sensitiveKeys[strings.ToLower(key)]; ok {
+				if _, isString := fieldValue.(string); isString {
+					typedValue[key] = replacement
+					continue
+				}
+			}
+			typedValue[key] = sanitize(fieldValue)
+		}
+		return typedValue
+	case []interface{}:
+		for index, item := range typedValue {
+			typedValue[index] = sanitize(item)
+		}
+		return typedValue
+	case string:
+		return emailPattern.ReplaceAllString(typedValue, "analyst@example.com")
+	}
+	return value
+}
+
+func capture(ctx context.Context, httpClient *http.Client, baseUrl string, token string, path string) (interface{}, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", baseUrl+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", fmt.Sprintf("token %s", token))
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: status code %d", path, response.StatusCode)
+	}
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+	return sanitize(document), nil
+}
+
+func main() {
+	version := flag.String("version", "", "version of the ThreatMatrix instance, used as the fixture directory name")
+	outputDir := flag.String("out", filepath.Join("tests", "testFiles", "fixtures"), "directory the fixtures are written to")
+	flag.Parse()
+
+	baseUrl := os.Getenv("THREATMATRIX_URL")
+	token := os.Getenv("THREATMATRIX_TOKEN")
+	if baseUrl == "" || token == "" || *version == "" {
+		fmt.Fprintln(os.Stderr, "THREATMATRIX_URL, THREATMATRIX_TOKEN and -version are required")
+		os.Exit(2)
+	}
+
+	versionDir := filepath.Join(*outputDir, *version)
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	captured := manifest{
+		Version:    *version,
+		CapturedAt: time.Now().UTC(),
+	}
+	for _, endpoint := range fixtures {
+		document, err := capture(ctx, httpClient, strings.TrimRight(baseUrl, "/"), token, endpoint.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipping %s: %v\n", endpoint.Name, err)
+			continue
+		}
+		data, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if err := ioutil.WriteFile(filepath.Join(versionDir, endpoint.Name+".json"), append(data, '\n'), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		captured.Fixtures = append(captured.Fixtures, endpoint)
+		fmt.Printf("captured %s\n", endpoint.Name)
+	}
+	sort.Slice(captured.Fixtures, func(i, j int) bool {
+		return captured.Fixtures[i].Name < captured.Fixtures[j].Name
+	})
+	data, err := json.MarshalIndent(captured, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(filepath.Join(versionDir, "manifest.json"), append(data, '\n'), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
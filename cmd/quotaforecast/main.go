@@ -0,0 +1,91 @@
+// quotaforecast estimates, from the submissions recorded in an audit file, when the monthly quotas
+// of the external analyzers will be exhausted.
+//
+// Usage:
+//
+//	go run ./cmd/quotaforecast -audit audit.jsonl -catalog catalog.json \
+//		-quota VirusTotal_v3_Get_File=15500 -quota Shodan_Search=100 [-metrics]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// quotaFlags collects the repeated -quota analyzer=limit flags.
+type quotaFlags map[string]int
+
+func (quotas quotaFlags) String() string {
+	return fmt.Sprint(map[string]int(quotas))
+}
+
+func (quotas quotaFlags) Set(value string) error {
+	analyzer, limit, ok := strings.Cut(value, "=")
+	if !ok || analyzer == "" {
+		return fmt.Errorf("expected analyzer=limit, got %q", value)
+	}
+	parsedLimit, err := strconv.Atoi(limit)
+	if err != nil || parsedLimit <= 0 {
+		return fmt.Errorf("invalid limit %q", limit)
+	}
+	quotas[analyzer] = parsedLimit
+	return nil
+}
+
+func main() {
+	quotas := quotaFlags{}
+	auditPath := flag.String("audit", "", "audit file the submissions are read from")
+	catalogPath := flag.String("catalog", "", "catalog snapshot resolving the analyzers of the playbooks")
+	window := flag.Duration("window", gothreatmatrix.DEFAULT_QUOTA_FORECAST_WINDOW, "history the daily rate is computed on")
+	metrics := flag.Bool("metrics", false, "print the forecasts in the Prometheus text format")
+	flag.Var(quotas, "quota", "monthly quota of an external analyzer as analyzer=limit, repeatable")
+	flag.Parse()
+
+	if *auditPath == "" || len(quotas) == 0 {
+		fmt.Fprintln(os.Stderr, "-audit and at least one -quota are required")
+		os.Exit(2)
+	}
+	records, err := gothreatmatrix.ReadAuditFile(*auditPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	options := &gothreatmatrix.QuotaForecastOptions{
+		Quotas: quotas,
+		Window: *window,
+	}
+	if *catalogPath != "" {
+		snapshot, err := gothreatmatrix.LoadCatalogSnapshot(*catalogPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		options.Playbooks = snapshot.Playbooks
+	}
+	forecasts := gothreatmatrix.ForecastQuotas(records, options)
+
+	if *metrics {
+		if err := gothreatmatrix.WriteQuotaMetrics(os.Stdout, forecasts); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "ANALYZER\tUSED\tQUOTA\tPER DAY\tPROJECTED\tEXHAUSTED AT")
+	for _, forecast := range forecasts {
+		exhaustedAt := "-"
+		if forecast.ExhaustedAt != nil {
+			exhaustedAt = forecast.ExhaustedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%.1f\t%d\t%s\n", forecast.Analyzer, forecast.Used, forecast.MonthlyQuota, forecast.DailyRate, forecast.ProjectedUsage, exhaustedAt)
+	}
+	writer.Flush()
+}
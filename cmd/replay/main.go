@@ -0,0 +1,94 @@
+// replay re-emits the job completed events of the finished jobs received in a time range,
+// to bootstrap a new downstream consumer with the data already in ThreatMatrix.
+//
+// Usage:
+//
+//	THREATMATRIX_URL=https://threatmatrix.example.com THREATMATRIX_TOKEN=... go run ./cmd/replay \
+//		-from 2023-01-01T00:00:00Z -to 2023-02-01T00:00:00Z -webhook https://consumer.example.com/hook -out jobs.jsonl
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/sirupsen/logrus"
+)
+
+// parseTime parses an RFC3339 flag value, an empty value giving the zero time.
+func parseTime(name string, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "-%s: %v\n", name, err)
+		os.Exit(2)
+	}
+	return parsed
+}
+
+func main() {
+	from := flag.String("from", "", "replay the jobs received at or after this RFC3339 time")
+	to := flag.String("to", "", "replay the jobs received at or before this RFC3339 time")
+	webhook := flag.String("webhook", "", "webhook the job completed events are posted to")
+	webhookSecret := flag.String("webhook-secret", "", "secret signing the webhook events")
+	output := flag.String("out", "", "file the job completed events are written to as JSON lines, - for the standard output")
+	pageSize := flag.Int("page-size", gothreatmatrix.DEFAULT_REPLAY_PAGE_SIZE, "number of jobs fetched per page")
+	flag.Parse()
+
+	baseUrl := os.Getenv("THREATMATRIX_URL")
+	token := os.Getenv("THREATMATRIX_TOKEN")
+	if baseUrl == "" || token == "" {
+		fmt.Fprintln(os.Stderr, "THREATMATRIX_URL and THREATMATRIX_TOKEN are required")
+		os.Exit(2)
+	}
+	sinks := []gothreatmatrix.JobSink{}
+	if *webhook != "" {
+		sinks = append(sinks, &gothreatmatrix.WebhookSink{Url: *webhook, Secret: *webhookSecret})
+	}
+	switch *output {
+	case "":
+	case "-":
+		sinks = append(sinks, gothreatmatrix.NewJSONLinesSink(os.Stdout))
+	default:
+		file, err := os.OpenFile(*output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		sinks = append(sinks, gothreatmatrix.NewJSONLinesSink(file))
+	}
+	if len(sinks) == 0 {
+		fmt.Fprintln(os.Stderr, "at least one of -webhook and -out is required")
+		os.Exit(2)
+	}
+
+	client := gothreatmatrix.NewThreatMatrixClient(&gothreatmatrix.ThreatMatrixClientOptions{
+		Url:   baseUrl,
+		Token: token,
+	}, nil, &gothreatmatrix.LoggerParams{Level: logrus.InfoLevel})
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	result, err := client.JobService.Replay(ctx, &gothreatmatrix.ReplayOptions{
+		From:     parseTime("from", *from),
+		To:       parseTime("to", *to),
+		PageSize: *pageSize,
+	}, sinks...)
+	for _, failure := range result.Failures {
+		fmt.Fprintf(os.Stderr, "job %d: %v\n", failure.JobID, failure.Err)
+	}
+	fmt.Fprintf(os.Stderr, "replayed %d jobs, skipped %d running jobs, %d failures\n", result.Replayed, result.Skipped, len(result.Failures))
+	if err != nil {
+		if !result.LastReceived.IsZero() {
+			fmt.Fprintf(os.Stderr, "resume with -from %s\n", result.LastReceived.Format(time.RFC3339Nano))
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,550 @@
+// threatmatrix runs the major operations of the SDK from the command line: submitting an analysis, waiting for a job,
+// exporting it, listing the jobs and checking the health of the plugins. With -json every command writes exactly one
+// CLIResponse document (internal/cli) to the standard output, so tooling written in any language can shell out to it;
+// the schema command prints the JSON Schema of that contract, also published in docs/cli-contract.schema.json. With
+// -table the result is written as a table for humans instead. The event-schemas command writes the JSON Schemas of
+// the sink payloads to a directory, see gothreatmatrix.EventSchemas.
+//
+// The analyze, jobs, analyzers and connectors commands group subcommands taking their arguments positionally, flags
+// being accepted before or after them. The command of their CLIResponse is the group and subcommand, e.g. "jobs list".
+//
+// The exit code is 0 on success, 1 when the command failed and 2 on a usage error. The preflight and health commands
+// exit with 1 when one of their checks failed, their report still being written.
+//
+// Usage:
+//
+//	THREATMATRIX_URL=https://threatmatrix.example.com THREATMATRIX_TOKEN=... go run ./cmd/threatmatrix scan \
+//		-observable 8.8.8.8 -analyzers Classic_DNS,Shodan_Search -wait -json
+//	go run ./cmd/threatmatrix scan -file sample.exe -playbook FREE_TO_USE_ANALYZERS -json
+//	go run ./cmd/threatmatrix analyze observable 8.8.8.8 -wait -table
+//	go run ./cmd/threatmatrix analyze file sample.exe -playbook FREE_TO_USE_ANALYZERS
+//	go run ./cmd/threatmatrix jobs list -status running -table
+//	go run ./cmd/threatmatrix analyzers health VirusTotal_v3 Shodan_Search
+//	go run ./cmd/threatmatrix wait -job 42 -timeout 10m -json
+//	go run ./cmd/threatmatrix export -job 42 -format sigma
+//	go run ./cmd/threatmatrix preflight -json
+//	go run ./cmd/threatmatrix schema
+//	go run ./cmd/threatmatrix event-schemas docs/schemas
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/khulnasoft/go-threatmatrix/internal/cli"
+	"github.com/sirupsen/logrus"
+)
+
+// errUsage marks the errors of the command line itself.
+var errUsage = errors.New("usage")
+
+// usageError returns an error reported with the usage exit code.
+func usageError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// exportFormats render a job for the export command.
+var exportFormats = map[string]func(writer io.Writer, job *gothreatmatrix.Job) error{
+	"summary": func(writer io.Writer, job *gothreatmatrix.Job) error {
+		data, err := json.MarshalIndent(job.Summary(), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(writer, string(data))
+		return err
+	},
+	"html": gothreatmatrix.WriteHTMLReport,
+	"sigma": func(writer io.Writer, job *gothreatmatrix.Job) error {
+		return gothreatmatrix.WriteSigmaRules(writer, gothreatmatrix.SigmaRules(job, nil))
+	},
+}
+
+// newClient returns the client configured by the environment variables, THREATMATRIX_URL and THREATMATRIX_TOKEN
+// being required, see gothreatmatrix.NewThreatMatrixClientFromEnv for the others (THREATMATRIX_CERTIFICATE,
+// THREATMATRIX_PROXY_URL...). The logs go to the standard error, the standard output being the contract.
+func newClient() (*gothreatmatrix.ThreatMatrixClient, error) {
+	if os.Getenv("THREATMATRIX_URL") == "" || os.Getenv("THREATMATRIX_TOKEN") == "" {
+		return nil, usageError("THREATMATRIX_URL and THREATMATRIX_TOKEN are required")
+	}
+	client, err := gothreatmatrix.NewThreatMatrixClientFromEnv(nil, &gothreatmatrix.LoggerParams{Level: logrus.WarnLevel, File: os.Stderr})
+	if err != nil {
+		return nil, usageError("%v", err)
+	}
+	return client, nil
+}
+
+// splitList splits a comma separated flag value, an empty value giving no items.
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// wait waits for the job, up to the timeout when not 0.
+func wait(ctx context.Context, client *gothreatmatrix.ThreatMatrixClient, jobId uint64, timeout time.Duration) (*cli.CLIWaitResult, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	waitResult, err := client.JobService.WaitForCompletion(ctx, jobId, &gothreatmatrix.WaitOptions{
+		PollInterval:    5 * time.Second,
+		MaxPollInterval: time.Minute,
+		Jitter:          0.2,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cli.NewCLIWaitResult(waitResult), nil
+}
+
+// output represents how the result of a command is written, set by the -json and -table flags.
+type output struct {
+	json  bool
+	table bool
+}
+
+// register adds the output flags to the flags of a command.
+func (output *output) register(flags *flag.FlagSet) {
+	flags.BoolVar(&output.json, "json", false, "write the result as a JSON document")
+	flags.BoolVar(&output.table, "table", false, "write the result as a table")
+}
+
+// parseInterspersed parses the flags of the arguments wherever they are among the positional arguments, which it returns.
+func parseInterspersed(flags *flag.FlagSet, arguments []string) ([]string, error) {
+	positionals := []string{}
+	for {
+		if err := flags.Parse(arguments); err != nil {
+			return nil, usageError("%v", err)
+		}
+		if flags.NArg() == 0 {
+			return positionals, nil
+		}
+		positionals = append(positionals, flags.Arg(0))
+		arguments = flags.Args()[1:]
+	}
+}
+
+// analysisFlags are the flags of the commands submitting an analysis.
+type analysisFlags struct {
+	analyzers  *string
+	connectors *string
+	playbook   *string
+	tlp        *string
+	tags       *string
+	wait       *bool
+	timeout    *time.Duration
+}
+
+// newAnalysisFlags adds the analysis flags to the flags of a command.
+func newAnalysisFlags(flags *flag.FlagSet) *analysisFlags {
+	return &analysisFlags{
+		analyzers:  flags.String("analyzers", "", "comma separated analyzers to run"),
+		connectors: flags.String("connectors", "", "comma separated connectors to run"),
+		playbook:   flags.String("playbook", "", "playbook to run"),
+		tlp:        flags.String("tlp", "", "TLP of the analysis: CLEAR (or WHITE), GREEN, AMBER or RED"),
+		tags:       flags.String("tags", "", "comma separated tag labels of the job"),
+		wait:       flags.Bool("wait", false, "wait for the job to finish and include its summary"),
+		timeout:    flags.Duration("timeout", 0, "how long -wait waits, forever when 0"),
+	}
+}
+
+// basicAnalysisParams returns the parameters of the analysis the flags describe.
+func (analysisFlags *analysisFlags) basicAnalysisParams() (gothreatmatrix.BasicAnalysisParams, error) {
+	basicAnalysisParams := gothreatmatrix.BasicAnalysisParams{
+		AnalyzersRequested:  splitList(*analysisFlags.analyzers),
+		ConnectorsRequested: splitList(*analysisFlags.connectors),
+		TagsLabels:          splitList(*analysisFlags.tags),
+		PlaybookRequested:   *analysisFlags.playbook,
+		Tlp:                 gothreatmatrix.WHITE,
+	}
+	if tlp := strings.ToUpper(*analysisFlags.tlp); tlp != "" {
+		if _, ok := gothreatmatrix.TLPVALUES[tlp]; !ok {
+			return basicAnalysisParams, usageError("invalid -tlp %q", *analysisFlags.tlp)
+		}
+		basicAnalysisParams.Tlp = gothreatmatrix.ParseTLP(tlp)
+	}
+	return basicAnalysisParams, nil
+}
+
+// analyze submits the analysis of the observable, or of the file when the observable is empty,
+// and waits for its job when the flags say so.
+func analyze(ctx context.Context, analysisFlags *analysisFlags, observable string, classification string, filePath string) (*cli.CLIScanResult, error) {
+	basicAnalysisParams, err := analysisFlags.basicAnalysisParams()
+	if err != nil {
+		return nil, err
+	}
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	var analysisResponse *gothreatmatrix.AnalysisResponse
+	if observable != "" {
+		analysisResponse, err = client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+			BasicAnalysisParams:      basicAnalysisParams,
+			ObservableName:           observable,
+			ObservableClassification: classification,
+		})
+	} else {
+		file, openError := os.Open(filePath)
+		if openError != nil {
+			return nil, usageError("%v", openError)
+		}
+		defer file.Close()
+		analysisResponse, err = client.CreateFileAnalysis(ctx, &gothreatmatrix.FileAnalysisParams{
+			BasicAnalysisParams: basicAnalysisParams,
+			File:                file,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := &cli.CLIScanResult{Analysis: *analysisResponse}
+	if *analysisFlags.wait {
+		result.Wait, err = wait(ctx, client, uint64(analysisResponse.JobID), *analysisFlags.timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func scan(ctx context.Context, arguments []string, output *output) (interface{}, error) {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	output.register(flags)
+	observable := flags.String("observable", "", "observable to analyze")
+	classification := flags.String("classification", "", "classification of the observable, detected when empty")
+	filePath := flags.String("file", "", "file to analyze")
+	analysisFlags := newAnalysisFlags(flags)
+	if err := flags.Parse(arguments); err != nil {
+		return nil, usageError("%v", err)
+	}
+	if (*observable == "") == (*filePath == "") {
+		return nil, usageError("exactly one of -observable and -file is required")
+	}
+	return analyze(ctx, analysisFlags, *observable, *classification, *filePath)
+}
+
+func analyzeObservable(ctx context.Context, arguments []string, output *output) (interface{}, error) {
+	flags := flag.NewFlagSet("analyze observable", flag.ContinueOnError)
+	output.register(flags)
+	classification := flags.String("classification", "", "classification of the observable, detected when empty")
+	analysisFlags := newAnalysisFlags(flags)
+	positionals, err := parseInterspersed(flags, arguments)
+	if err != nil {
+		return nil, err
+	}
+	if len(positionals) != 1 {
+		return nil, usageError("exactly one observable is required")
+	}
+	return analyze(ctx, analysisFlags, positionals[0], *classification, "")
+}
+
+func analyzeFile(ctx context.Context, arguments []string, output *output) (interface{}, error) {
+	flags := flag.NewFlagSet("analyze file", flag.ContinueOnError)
+	output.register(flags)
+	analysisFlags := newAnalysisFlags(flags)
+	positionals, err := parseInterspersed(flags, arguments)
+	if err != nil {
+		return nil, err
+	}
+	if len(positionals) != 1 {
+		return nil, usageError("exactly one file is required")
+	}
+	return analyze(ctx, analysisFlags, "", "", positionals[0])
+}
+
+func waitCommand(ctx context.Context, arguments []string, output *output) (interface{}, error) {
+	flags := flag.NewFlagSet("wait", flag.ContinueOnError)
+	output.register(flags)
+	jobId := flags.Uint64("job", 0, "ID of the job to wait for")
+	timeout := flags.Duration("timeout", 0, "how long to wait, forever when 0")
+	if err := flags.Parse(arguments); err != nil {
+		return nil, usageError("%v", err)
+	}
+	if *jobId == 0 {
+		return nil, usageError("-job is required")
+	}
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	return wait(ctx, client, *jobId, *timeout)
+}
+
+func export(ctx context.Context, arguments []string, output *output) (interface{}, error) {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	output.register(flags)
+	jobId := flags.Uint64("job", 0, "ID of the job to export")
+	format := flags.String("format", "summary", "export format: summary, html or sigma")
+	if err := flags.Parse(arguments); err != nil {
+		return nil, usageError("%v", err)
+	}
+	render, ok := exportFormats[*format]
+	if *jobId == 0 || !ok {
+		return nil, usageError("-job and a -format among summary, html and sigma are required")
+	}
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	job, err := client.JobService.Get(ctx, *jobId)
+	if err != nil {
+		return nil, err
+	}
+	buffer := &bytes.Buffer{}
+	if err := render(buffer, job); err != nil {
+		return nil, err
+	}
+	return &cli.CLIExportResult{JobID: job.ID, Format: *format, Content: buffer.String()}, nil
+}
+
+func jobsList(ctx context.Context, arguments []string, output *output) (interface{}, error) {
+	flags := flag.NewFlagSet("jobs list", flag.ContinueOnError)
+	output.register(flags)
+	status := flags.String("status", "", "status of the jobs, e.g. running or reported_without_fails")
+	observable := flags.String("observable", "", "part of the observable name of the jobs")
+	user := flags.String("user", "", "user who submitted the jobs")
+	tags := flags.String("tags", "", "comma separated tag labels, one of which the jobs have")
+	positionals, err := parseInterspersed(flags, arguments)
+	if err != nil {
+		return nil, err
+	}
+	if len(positionals) > 0 {
+		return nil, usageError("unexpected argument %q", positionals[0])
+	}
+	jobFilter := gothreatmatrix.NewJobFilter()
+	if *status != "" {
+		jobStatus := gothreatmatrix.JobStatus(strings.ToLower(*status))
+		if !jobStatus.IsKnown() {
+			return nil, usageError("invalid -status %q", *status)
+		}
+		jobFilter.Status(jobStatus)
+	}
+	if *observable != "" {
+		jobFilter.ObservableName(*observable)
+	}
+	if *user != "" {
+		jobFilter.User(*user)
+	}
+	if labels := splitList(*tags); len(labels) > 0 {
+		jobFilter.TagIn(labels...)
+	}
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.JobService.ListWithFilter(ctx, jobFilter)
+}
+
+// healthCommand returns the health command of the plugins of the type, checked by healthCheck.
+func healthCommand(pluginType string, healthCheck func(ctx context.Context, client *gothreatmatrix.ThreatMatrixClient, name string) (bool, error)) command {
+	return func(ctx context.Context, arguments []string, output *output) (interface{}, error) {
+		flags := flag.NewFlagSet(pluginType+"s health", flag.ContinueOnError)
+		output.register(flags)
+		names, err := parseInterspersed(flags, arguments)
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return nil, usageError("at least one %s is required", pluginType)
+		}
+		client, err := newClient()
+		if err != nil {
+			return nil, err
+		}
+		result := &cli.CLIHealthResult{PluginType: pluginType, Plugins: []cli.CLIPluginHealth{}}
+		for _, name := range names {
+			healthy, err := healthCheck(ctx, client, name)
+			if err != nil {
+				return nil, err
+			}
+			result.Plugins = append(result.Plugins, cli.CLIPluginHealth{Name: name, Healthy: healthy})
+		}
+		return result, nil
+	}
+}
+
+func preflight(ctx context.Context, arguments []string, output *output) (interface{}, error) {
+	flags := flag.NewFlagSet("preflight", flag.ContinueOnError)
+	output.register(flags)
+	if err := flags.Parse(arguments); err != nil {
+		return nil, usageError("%v", err)
+	}
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.Preflight(ctx), nil
+}
+
+// command runs a command with its arguments and returns its result.
+type command func(ctx context.Context, arguments []string, output *output) (interface{}, error)
+
+var commands = map[string]command{
+	"scan":               scan,
+	"wait":               waitCommand,
+	"export":             export,
+	"preflight":          preflight,
+	"analyze observable": analyzeObservable,
+	"analyze file":       analyzeFile,
+	"jobs list":          jobsList,
+	"analyzers health": healthCommand("analyzer", func(ctx context.Context, client *gothreatmatrix.ThreatMatrixClient, name string) (bool, error) {
+		return client.AnalyzerService.HealthCheck(ctx, name)
+	}),
+	"connectors health": healthCommand("connector", func(ctx context.Context, client *gothreatmatrix.ThreatMatrixClient, name string) (bool, error) {
+		return client.ConnectorService.HealthCheck(ctx, name)
+	}),
+}
+
+// writeTable writes the result as a table, the results without a tabular form as indented JSON.
+func writeTable(writer io.Writer, result interface{}) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	row := func(cells ...interface{}) {
+		for index, cell := range cells {
+			if index > 0 {
+				fmt.Fprint(table, "\t")
+			}
+			fmt.Fprint(table, cell)
+		}
+		fmt.Fprintln(table)
+	}
+	switch result := result.(type) {
+	case *cli.CLIScanResult:
+		row("JOB", "STATUS", "ANALYZERS", "CONNECTORS")
+		status := result.Analysis.Status
+		if result.Wait != nil {
+			status = result.Wait.Summary.Status
+		}
+		row(result.Analysis.JobID, status, strings.Join(result.Analysis.AnalyzersRunning, ","), strings.Join(result.Analysis.ConnectorsRunning, ","))
+	case *cli.CLIWaitResult:
+		row("JOB", "STATUS", "COMPLETE", "SUCCEEDED", "FAILED", "PENDING")
+		row(result.Summary.JobID, result.Summary.Status, result.Complete, strings.Join(result.Summary.AnalyzersSucceeded, ","),
+			strings.Join(result.Summary.AnalyzersFailed, ","), strings.Join(result.PendingAnalyzers, ","))
+	case *gothreatmatrix.JobListResponse:
+		row("ID", "STATUS", "OBSERVABLE/FILE", "TLP", "USER", "RECEIVED")
+		for _, job := range result.Results {
+			analyzed := job.ObservableName
+			if job.IsSample {
+				analyzed = job.FileName
+			}
+			received := ""
+			if job.ReceivedRequestTime != nil {
+				received = job.ReceivedRequestTime.Format(time.RFC3339)
+			}
+			row(job.ID, job.Status, analyzed, job.Tlp, job.User.Username, received)
+		}
+	case *cli.CLIHealthResult:
+		row(strings.ToUpper(result.PluginType), "HEALTHY")
+		for _, plugin := range result.Plugins {
+			row(plugin.Name, plugin.Healthy)
+		}
+	case *gothreatmatrix.PreflightReport:
+		row("CHECK", "STATUS", "DETAIL")
+		for _, check := range result.Checks {
+			row(check.Name, check.Status, check.Detail)
+		}
+	default:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(writer, string(data))
+		return err
+	}
+	return table.Flush()
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		data, err := cli.MarshalCLIContractSchema()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "event-schemas" {
+		if err := gothreatmatrix.WriteEventSchemas(os.Args[2]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	name, arguments := "", []string{}
+	if len(os.Args) > 1 {
+		name, arguments = os.Args[1], os.Args[2:]
+	}
+	// * the grouped commands are named by their group and subcommand
+	if _, ok := commands[name]; !ok && len(arguments) > 0 {
+		name, arguments = name+" "+arguments[0], arguments[1:]
+	}
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "usage: threatmatrix scan|wait|export|preflight|schema|event-schemas [flags]\n"+
+			"       threatmatrix analyze observable|file <value> [flags]\n"+
+			"       threatmatrix jobs list [flags]\n"+
+			"       threatmatrix analyzers|connectors health <name>... [flags]\n"+
+			"see -h of each command")
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	commandOutput := &output{}
+	result, err := run(ctx, arguments, commandOutput)
+
+	exitCode := 0
+	response := cli.CLIResponse{ContractVersion: cli.CLI_CONTRACT_VERSION, Command: name, OK: err == nil, Result: result}
+	if err != nil {
+		exitCode = 1
+		response.Result = nil
+		response.Error = cli.NewCLIError(err)
+		if errors.Is(err, errUsage) {
+			exitCode = 2
+			response.Error.Kind = cli.CLI_ERROR_USAGE
+		}
+	}
+	if report, ok := result.(*gothreatmatrix.PreflightReport); ok && !report.OK() {
+		exitCode = 1
+	}
+	if healthResult, ok := result.(*cli.CLIHealthResult); ok && !healthResult.OK() {
+		exitCode = 1
+	}
+	switch {
+	case commandOutput.json:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(response)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+	case commandOutput.table:
+		if err := writeTable(os.Stdout, result); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+		}
+	default:
+		if exportResult, ok := result.(*cli.CLIExportResult); ok {
+			fmt.Print(exportResult.Content)
+		} else {
+			data, _ := json.MarshalIndent(result, "", "  ")
+			fmt.Println(string(data))
+		}
+	}
+	os.Exit(exitCode)
+}
@@ -8,26 +8,86 @@ const (
 
 // These represent job endpoints URL
 const (
-	BASE_JOB_URL            = "/api/jobs"
-	SPECIFIC_JOB_URL        = BASE_JOB_URL + "/%d"
-	DOWNLOAD_SAMPLE_JOB_URL = SPECIFIC_JOB_URL + "/download_sample"
-	KILL_JOB_URL            = SPECIFIC_JOB_URL + "/kill"
-	KILL_ANALYZER_JOB_URL   = SPECIFIC_JOB_URL + "/analyzer/%s/kill"
-	RETRY_ANALYZER_JOB_URL  = SPECIFIC_JOB_URL + "/analyzer/%s/retry"
-	KILL_CONNECTOR_JOB_URL  = SPECIFIC_JOB_URL + "/connector/%s/kill"
-	RETRY_CONNECTOR_JOB_URL = SPECIFIC_JOB_URL + "/connector/%s/retry"
+	BASE_JOB_URL             = "/api/jobs"
+	SPECIFIC_JOB_URL         = BASE_JOB_URL + "/%d"
+	DOWNLOAD_SAMPLE_JOB_URL  = SPECIFIC_JOB_URL + "/download_sample"
+	KILL_JOB_URL             = SPECIFIC_JOB_URL + "/kill"
+	KILL_ANALYZER_JOB_URL    = SPECIFIC_JOB_URL + "/analyzer/%s/kill"
+	RETRY_ANALYZER_JOB_URL   = SPECIFIC_JOB_URL + "/analyzer/%s/retry"
+	KILL_CONNECTOR_JOB_URL   = SPECIFIC_JOB_URL + "/connector/%s/kill"
+	RETRY_CONNECTOR_JOB_URL  = SPECIFIC_JOB_URL + "/connector/%s/retry"
+	JOB_COMMENTS_URL         = SPECIFIC_JOB_URL + "/comments"
+	SPECIFIC_JOB_COMMENT_URL = JOB_COMMENTS_URL + "/%d"
+)
+
+// These represent websocket endpoints URL
+const (
+	JOB_WEBSOCKET_URL = "/ws/jobs/%d"
+)
+
+// These represent job statistics endpoints URL
+const (
+	JOB_AGGREGATE_URL                           = BASE_JOB_URL + "/aggregate"
+	JOB_AGGREGATE_STATUS_URL                    = JOB_AGGREGATE_URL + "/status"
+	JOB_AGGREGATE_TYPE_URL                      = JOB_AGGREGATE_URL + "/type"
+	JOB_AGGREGATE_OBSERVABLE_CLASSIFICATION_URL = JOB_AGGREGATE_URL + "/observable_classification"
+	JOB_AGGREGATE_FILE_MIMETYPE_URL             = JOB_AGGREGATE_URL + "/file_mimetype"
+	JOB_AGGREGATE_TOP_PLAYBOOK_URL              = JOB_AGGREGATE_URL + "/top_playbook"
+	JOB_AGGREGATE_TOP_USER_URL                  = JOB_AGGREGATE_URL + "/top_user"
+	JOB_AGGREGATE_TOP_TLP_URL                   = JOB_AGGREGATE_URL + "/top_tlp"
 )
 
 // These represent analyzer endpoints URL
 const (
-	ANALYZER_CONFIG_URL      = "/api/get_analyzer_configs"
-	ANALYZER_HEALTHCHECK_URL = "/api/analyzer/%s/healthcheck"
+	ANALYZER_CONFIG_URL       = "/api/get_analyzer_configs"
+	ANALYZER_HEALTHCHECK_URL  = "/api/analyzer/%s/healthcheck"
+	ANALYZER_ORGANIZATION_URL = "/api/analyzer/%s/organization"
 )
 
 // These represent connector endpoints URL
 const (
-	CONNECTOR_CONFIG_URL      = "/api/get_connector_configs"
-	CONNECTOR_HEALTHCHECK_URL = "/api/connector/%s/healthcheck"
+	CONNECTOR_CONFIG_URL       = "/api/get_connector_configs"
+	CONNECTOR_HEALTHCHECK_URL  = "/api/connector/%s/healthcheck"
+	CONNECTOR_ORGANIZATION_URL = "/api/connector/%s/organization"
+)
+
+// These represent visualizer endpoints URL
+const (
+	VISUALIZER_CONFIG_URL      = "/api/get_visualizer_configs"
+	VISUALIZER_HEALTHCHECK_URL = "/api/visualizer/%s/healthcheck"
+)
+
+// These represent pivot endpoints URL
+const (
+	PIVOT_CONFIG_URL      = "/api/get_pivot_configs"
+	PIVOT_HEALTHCHECK_URL = "/api/pivot/%s/healthcheck"
+	PIVOT_MAP_URL         = "/api/pivot_map"
+)
+
+// These represent ingestor endpoints URL
+const (
+	INGESTOR_CONFIG_URL      = "/api/get_ingestor_configs"
+	INGESTOR_HEALTHCHECK_URL = "/api/ingestor/%s/healthcheck"
+	INGESTOR_PULL_URL        = "/api/ingestor/%s/pull"
+)
+
+// These represent data model endpoints URL
+const (
+	BASE_DATA_MODEL_URL            = "/api/data_model"
+	DOMAIN_DATA_MODEL_URL          = BASE_DATA_MODEL_URL + "/domain"
+	SPECIFIC_DOMAIN_DATA_MODEL_URL = DOMAIN_DATA_MODEL_URL + "/%d"
+	IP_DATA_MODEL_URL              = BASE_DATA_MODEL_URL + "/ip"
+	SPECIFIC_IP_DATA_MODEL_URL     = IP_DATA_MODEL_URL + "/%d"
+	FILE_DATA_MODEL_URL            = BASE_DATA_MODEL_URL + "/file"
+	SPECIFIC_FILE_DATA_MODEL_URL   = FILE_DATA_MODEL_URL + "/%d"
+)
+
+// These represent playbook endpoints URL
+const (
+	PLAYBOOK_CONFIG_URL       = "/api/get_playbook_configs"
+	BASE_PLAYBOOK_URL         = "/api/playbook"
+	SPECIFIC_PLAYBOOK_URL     = BASE_PLAYBOOK_URL + "/%s"
+	PLAYBOOK_ORGANIZATION_URL = SPECIFIC_PLAYBOOK_URL + "/organization"
 )
 
 // These represent analyze endpoints URL
@@ -36,6 +96,7 @@ const (
 	ANALYZE_MULTIPLE_OBSERVABLES_URL = "/api/analyze_multiple_observables"
 	ANALYZE_FILE_URL                 = "/api/analyze_file"
 	ANALYZE_MULTIPLE_FILES_URL       = "/api/analyze_multiple_files"
+	ASK_ANALYSIS_AVAILABILITY_URL    = "/api/ask_analysis_availability"
 )
 
 // These represent me endpoints URL
@@ -46,4 +107,50 @@ const (
 	ORGANIZATION_URL                    = BASE_ME_URL + "/organization"
 	INVITE_TO_ORGANIZATION_URL          = ORGANIZATION_URL + "/invite"
 	REMOVE_MEMBER_FROM_ORGANIZATION_URL = ORGANIZATION_URL + "/remove_member"
+	LEAVE_ORGANIZATION_URL              = ORGANIZATION_URL + "/leave"
+	INVITATIONS_URL                     = BASE_ME_URL + "/invitations"
+	ACCEPT_INVITATION_URL               = INVITATIONS_URL + "/%d/accept"
+	DECLINE_INVITATION_URL              = INVITATIONS_URL + "/%d/decline"
+)
+
+// These represent plugin config endpoints URL
+const (
+	PLUGIN_CONFIG_URL          = "/api/plugin_config"
+	SPECIFIC_PLUGIN_CONFIG_URL = PLUGIN_CONFIG_URL + "/%d"
+)
+
+// These represent auth endpoints URL
+const (
+	AUTH_LOGIN_URL            = "/api/auth/login"
+	AUTH_API_ACCESS_URL       = "/api/auth/apiaccess"
+	AUTH_SESSIONS_URL         = "/api/auth/sessions"
+	SPECIFIC_AUTH_SESSION_URL = AUTH_SESSIONS_URL + "/%d"
+)
+
+// These represent the legacy endpoints URL used by older ThreatMatrix/IntelOwl servers
+const (
+	LEGACY_ANALYSIS_REQUEST_URL = "/api/send_analysis_request"
+	LEGACY_ANALYSIS_RESULT_URL  = "/api/ask_analysis_result"
+)
+
+// These represent investigation endpoints URL
+const (
+	BASE_INVESTIGATION_URL             = "/api/investigation"
+	SPECIFIC_INVESTIGATION_URL         = BASE_INVESTIGATION_URL + "/%d"
+	ADD_JOB_TO_INVESTIGATION_URL       = SPECIFIC_INVESTIGATION_URL + "/add_job"
+	REMOVE_JOB_FROM_INVESTIGATION_URL  = SPECIFIC_INVESTIGATION_URL + "/remove_job"
+	INVESTIGATION_TREE_URL             = SPECIFIC_INVESTIGATION_URL + "/tree"
+	INVESTIGATION_COMMENTS_URL         = SPECIFIC_INVESTIGATION_URL + "/comments"
+	SPECIFIC_INVESTIGATION_COMMENT_URL = INVESTIGATION_COMMENTS_URL + "/%d"
+)
+
+// These represent notification endpoints URL
+const (
+	BASE_NOTIFICATION_URL         = "/api/notification"
+	MARK_NOTIFICATION_AS_READ_URL = BASE_NOTIFICATION_URL + "/%d/mark-as-read"
+)
+
+// These represent search endpoints URL
+const (
+	PLUGIN_REPORT_SEARCH_URL = "/api/plugin_report/search"
 )
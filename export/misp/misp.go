@@ -0,0 +1,266 @@
+// Package misp converts ThreatMatrix jobs into MISP events, so their results can be imported into the MISP
+// instances running alongside ThreatMatrix, e.g. through the /events/add endpoint or a feed.
+//
+// The UUIDs are deterministic, derived from the job and the values, so exporting the same job twice gives the
+// same event, which MISP then updates instead of duplicating.
+package misp
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/classify"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// Values of the threat_level_id field of a MISP event.
+const (
+	THREAT_LEVEL_HIGH      = 1
+	THREAT_LEVEL_MEDIUM    = 2
+	THREAT_LEVEL_LOW       = 3
+	THREAT_LEVEL_UNDEFINED = 4
+)
+
+// Values of the analysis field of a MISP event.
+const (
+	ANALYSIS_INITIAL   = 0
+	ANALYSIS_ONGOING   = 1
+	ANALYSIS_COMPLETED = 2
+)
+
+// Values of the distribution field of a MISP event.
+const (
+	DISTRIBUTION_ORGANISATION = 0
+	DISTRIBUTION_COMMUNITY    = 1
+	DISTRIBUTION_CONNECTED    = 2
+	DISTRIBUTION_ALL          = 3
+)
+
+// Categories of the MISP attributes.
+const (
+	CATEGORY_NETWORK_ACTIVITY = "Network activity"
+	CATEGORY_PAYLOAD_DELIVERY = "Payload delivery"
+)
+
+// UUID_NAMESPACE is the namespace of the UUIDv5 of the events and attributes.
+const UUID_NAMESPACE = "5c8b5a0e-0d6a-4f7e-9a51-3f2e6b0c9d41"
+
+// Attribute represents an attribute of a MISP event.
+type Attribute struct {
+	UUID     string `json:"uuid"`
+	Type     string `json:"type"`
+	Category string `json:"category"`
+	Value    string `json:"value"`
+	// ToIds tells MISP whether the attribute is meant to be fed to the detection systems.
+	ToIds     bool   `json:"to_ids"`
+	Comment   string `json:"comment,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+// Tag represents a MISP tag, e.g. "tlp:amber".
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Event represents a MISP event, its numeric fields encoded as strings the way MISP does.
+type Event struct {
+	UUID          string      `json:"uuid"`
+	Info          string      `json:"info"`
+	Date          string      `json:"date"`
+	Timestamp     string      `json:"timestamp"`
+	ThreatLevelId int         `json:"threat_level_id,string"`
+	Analysis      int         `json:"analysis,string"`
+	Distribution  int         `json:"distribution,string"`
+	Published     bool        `json:"published"`
+	Tags          []Tag       `json:"Tag"`
+	Attributes    []Attribute `json:"Attribute"`
+}
+
+// Document returns the JSON document of the event, {"Event": {...}}, as MISP imports it.
+func (event *Event) Document() ([]byte, error) {
+	return json.Marshal(map[string]*Event{"Event": event})
+}
+
+// Options represents the fields used to configure NewEvent.
+type Options struct {
+	// Verdict is the verdict of the job, e.g. the result of gothreatmatrix.ApplyVerdictRules, setting the threat
+	// level of the event. The attributes of the analyzed observable or file are flagged for the IDS when it is
+	// SUSPICIOUS or MALICIOUS.
+	Verdict gothreatmatrix.Verdict
+	// Distribution is the distribution of the event, DISTRIBUTION_ORGANISATION by default.
+	Distribution int
+	// Time is the date of the event, the finished_analysis_time of the job when zero.
+	Time time.Time
+}
+
+// threatLevels maps the verdicts to the threat levels of MISP.
+var threatLevels = map[gothreatmatrix.Verdict]int{
+	gothreatmatrix.UNKNOWN:    THREAT_LEVEL_UNDEFINED,
+	gothreatmatrix.BENIGN:     THREAT_LEVEL_LOW,
+	gothreatmatrix.SUSPICIOUS: THREAT_LEVEL_MEDIUM,
+	gothreatmatrix.MALICIOUS:  THREAT_LEVEL_HIGH,
+}
+
+// hashTypes maps the hash types of classify to the attribute types of MISP.
+var hashTypes = map[string]string{
+	classify.MD5:    "md5",
+	classify.SHA1:   "sha1",
+	classify.SHA256: "sha256",
+}
+
+// eventBuilder collects the attributes of an event, each value once.
+type eventBuilder struct {
+	event  *Event
+	values map[string]bool
+}
+
+// add appends the attribute, unless an attribute with its type and value already is, the hashes compared in
+// lowercase.
+func (builder *eventBuilder) add(attributeType string, category string, value string, toIds bool, comment string) {
+	if attributeType == "md5" || attributeType == "sha1" || attributeType == "sha256" {
+		value = strings.ToLower(value)
+	}
+	key := attributeType + "|" + value
+	if builder.values[key] {
+		return
+	}
+	builder.values[key] = true
+	builder.event.Attributes = append(builder.event.Attributes, Attribute{
+		UUID:      uuid5(builder.event.UUID, key),
+		Type:      attributeType,
+		Category:  category,
+		Value:     value,
+		ToIds:     toIds,
+		Comment:   comment,
+		Timestamp: builder.event.Timestamp,
+	})
+}
+
+// NewEvent converts the job into a MISP event holding:
+//   - the attributes of the analyzed observable, or of the name and hashes of the analyzed file
+//   - the attributes of the artifacts of its reports, commented with the analyzers they come from
+//   - the TLP and the tags of the job, and a tag for every malware family
+//   - the threat level of the verdict, the analysis being completed for a finished job
+//
+// options can be nil.
+func NewEvent(job *gothreatmatrix.Job, options *Options) *Event {
+	if options == nil {
+		options = &Options{}
+	}
+	date := options.Time
+	if date.IsZero() && job.FinishedAnalysisTime != nil {
+		date = *job.FinishedAnalysisTime
+	}
+	if date.IsZero() && job.ReceivedRequestTime != nil {
+		date = *job.ReceivedRequestTime
+	}
+	if date.IsZero() {
+		date = time.Now()
+	}
+	date = date.UTC()
+	threatLevel, ok := threatLevels[options.Verdict]
+	if !ok {
+		threatLevel = THREAT_LEVEL_UNDEFINED
+	}
+	analysis := ANALYSIS_ONGOING
+	if job.FinishedAnalysisTime != nil {
+		analysis = ANALYSIS_COMPLETED
+	}
+	builder := &eventBuilder{
+		event: &Event{
+			UUID:          uuid5(UUID_NAMESPACE, fmt.Sprintf("threatmatrix:job:%d", job.ID)),
+			Info:          fmt.Sprintf("ThreatMatrix job %d: %s", job.ID, subjectName(job)),
+			Date:          date.Format("2006-01-02"),
+			Timestamp:     strconv.FormatInt(date.Unix(), 10),
+			ThreatLevelId: threatLevel,
+			Analysis:      analysis,
+			Distribution:  options.Distribution,
+			Tags:          []Tag{},
+			Attributes:    []Attribute{},
+		},
+		values: map[string]bool{},
+	}
+	event := builder.event
+	if job.Tlp != "" {
+		event.Tags = append(event.Tags, Tag{Name: "tlp:" + strings.ToLower(job.Tlp)})
+	}
+	for _, tag := range job.Tags {
+		event.Tags = append(event.Tags, Tag{Name: tag.Label})
+	}
+	for _, family := range gothreatmatrix.ExtractMalwareFamilies(job) {
+		event.Tags = append(event.Tags, Tag{Name: fmt.Sprintf("malware_classification:malware-family=\"%s\"", family.Name)})
+	}
+
+	toIds := options.Verdict >= gothreatmatrix.SUSPICIOUS
+	if job.IsSample {
+		if job.FileName != "" {
+			builder.add("filename", CATEGORY_PAYLOAD_DELIVERY, job.FileName, false, "analyzed file")
+		}
+		if job.Md5 != "" {
+			builder.add("md5", CATEGORY_PAYLOAD_DELIVERY, job.Md5, toIds, "analyzed file")
+		}
+		for index := range job.AnalyzerReports {
+			report := &job.AnalyzerReports[index]
+			if report.Name != "File_Info" {
+				continue
+			}
+			for _, algorithm := range []string{classify.SHA1, classify.SHA256} {
+				if value, ok := report.Report[algorithm].(string); ok && value != "" {
+					builder.add(hashTypes[algorithm], CATEGORY_PAYLOAD_DELIVERY, value, toIds, "analyzed file")
+				}
+			}
+		}
+	} else if attributeType, category := attributeType(job.ObservableClassification, job.ObservableName); attributeType != "" {
+		builder.add(attributeType, category, job.ObservableName, toIds, "analyzed observable")
+	}
+	for _, artifact := range gothreatmatrix.ExtractArtifacts(job) {
+		if attributeType, category := attributeType(artifact.Classification, artifact.Value); attributeType != "" {
+			builder.add(attributeType, category, artifact.Value, false, "found by "+strings.Join(artifact.Sources, ", "))
+		}
+	}
+	return event
+}
+
+// subjectName returns the analyzed observable or the name of the analyzed file.
+func subjectName(job *gothreatmatrix.Job) string {
+	if job.IsSample || job.ObservableName == "" {
+		return job.FileName
+	}
+	return job.ObservableName
+}
+
+// attributeType returns the attribute type and category of MISP of a value of the observable classification,
+// empty for the classifications MISP has none for.
+func attributeType(classification string, value string) (string, string) {
+	switch classification {
+	case classify.IP:
+		return "ip-dst", CATEGORY_NETWORK_ACTIVITY
+	case classify.DOMAIN:
+		return "domain", CATEGORY_NETWORK_ACTIVITY
+	case classify.URL:
+		return "url", CATEGORY_NETWORK_ACTIVITY
+	case classify.HASH:
+		if hashType, ok := hashTypes[classify.Hash(value)]; ok {
+			return hashType, CATEGORY_PAYLOAD_DELIVERY
+		}
+	}
+	return "", ""
+}
+
+// uuid5 returns the UUIDv5 of the name in the namespace, a UUID in its textual form.
+func uuid5(namespace string, name string) string {
+	namespaceBytes, _ := hex.DecodeString(strings.ReplaceAll(namespace, "-", ""))
+	hash := sha1.New()
+	hash.Write(namespaceBytes)
+	hash.Write([]byte(name))
+	sum := hash.Sum(nil)[:16]
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	encoded := hex.EncodeToString(sum)
+	return encoded[:8] + "-" + encoded[8:12] + "-" + encoded[12:16] + "-" + encoded[16:20] + "-" + encoded[20:]
+}
@@ -0,0 +1,422 @@
+// Package stix converts ThreatMatrix jobs into STIX 2.1 bundles, so their results can be pushed to TAXII
+// servers and other threat intelligence platforms.
+//
+// The identifiers are deterministic: the cyber-observable objects get the UUIDv5 the STIX specification
+// defines from their contributing properties, and the other objects one derived from the job, so exporting
+// the same job twice gives the same objects, which TAXII servers then treat as the same.
+package stix
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/classify"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// SPEC_VERSION is the version of STIX of the exported objects.
+const SPEC_VERSION = "2.1"
+
+// TIMESTAMP_FORMAT is the format of the timestamps of STIX, in UTC with millisecond precision.
+const TIMESTAMP_FORMAT = "2006-01-02T15:04:05.000Z"
+
+// SCO_NAMESPACE is the namespace the STIX specification defines for the UUIDv5 of the cyber-observable objects.
+const SCO_NAMESPACE = "00abedb4-aa42-466c-9c01-fed23315a9b7"
+
+// tlpMarkings are the TLP marking definitions of STIX 2.1, by lowercase TLP.
+var tlpMarkings = map[string]Object{
+	"white": newTlpMarking("613f2e26-407d-48c7-9eca-b8e91df99dc9", "white"),
+	"green": newTlpMarking("34098fce-860f-48ae-8e50-ebd3cc5e41da", "green"),
+	"amber": newTlpMarking("f88d31f6-486f-44da-b317-01333bde0b82", "amber"),
+	"red":   newTlpMarking("5e57c739-391a-4eb3-b6be-7d15ca92d5ed", "red"),
+}
+
+// hashNames maps the hash types of classify to the hash algorithm names of STIX.
+var hashNames = map[string]string{
+	classify.MD5:    "MD5",
+	classify.SHA1:   "SHA-1",
+	classify.SHA256: "SHA-256",
+}
+
+// Object represents a STIX object, its properties keyed by their STIX names.
+type Object map[string]interface{}
+
+// ID returns the identifier of the object.
+func (object Object) ID() string {
+	id, _ := object["id"].(string)
+	return id
+}
+
+// Type returns the type of the object, e.g. "indicator".
+func (object Object) Type() string {
+	objectType, _ := object["type"].(string)
+	return objectType
+}
+
+// Bundle represents a STIX bundle.
+type Bundle struct {
+	Type    string   `json:"type"`
+	ID      string   `json:"id"`
+	Objects []Object `json:"objects"`
+}
+
+// ObjectsOfType returns the objects of the bundle of that type, in the order of the bundle.
+func (bundle *Bundle) ObjectsOfType(objectType string) []Object {
+	objects := []Object{}
+	for _, object := range bundle.Objects {
+		if object.Type() == objectType {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// Options represents the fields used to configure NewBundle.
+type Options struct {
+	// Verdict is the verdict of the job, e.g. the result of gothreatmatrix.ApplyVerdictRules.
+	// An indicator of the analyzed observable or file is created when it is SUSPICIOUS or MALICIOUS.
+	Verdict gothreatmatrix.Verdict
+	// AnalyzerVerdict returns the result of the malware-analysis of a successful analyzer report.
+	// When nil, the analyzers that named a malware family are MALICIOUS and the others UNKNOWN.
+	AnalyzerVerdict func(report *gothreatmatrix.Report) gothreatmatrix.Verdict
+	// Time is when the objects were created, the finished_analysis_time of the job when zero.
+	Time time.Time
+}
+
+// bundleBuilder collects the objects of a bundle, each one once.
+type bundleBuilder struct {
+	job       *gothreatmatrix.Job
+	timestamp string
+	common    Object
+	objects   []Object
+	ids       map[string]bool
+}
+
+// add appends the object to the bundle, unless an object with its ID already is, and returns its ID.
+func (builder *bundleBuilder) add(object Object) string {
+	id := object.ID()
+	if !builder.ids[id] {
+		builder.ids[id] = true
+		builder.objects = append(builder.objects, object)
+	}
+	return id
+}
+
+// newDomainObject returns a STIX domain or relationship object of the job, its ID derived from the key.
+func (builder *bundleBuilder) newDomainObject(objectType string, key string) Object {
+	object := Object{
+		"type":         objectType,
+		"spec_version": SPEC_VERSION,
+		"id":           objectType + "--" + uuid5(sdoNamespace, fmt.Sprintf("threatmatrix:job:%d:%s:%s", builder.job.ID, objectType, key)),
+		"created":      builder.timestamp,
+		"modified":     builder.timestamp,
+	}
+	for property, value := range builder.common {
+		object[property] = value
+	}
+	return object
+}
+
+// NewBundle converts the job into a STIX 2.1 bundle holding:
+//   - the identity of ThreatMatrix, creator of every object, and the TLP marking of the job
+//   - the cyber-observable objects of the analyzed observable or file and of the artifacts of its reports
+//   - a malware object for every malware family, and a malware-analysis for every successful analyzer report
+//   - an indicator of the observable or file, indicating the malware families, for suspicious and malicious jobs
+//   - a report referencing all of them, labelled with the tags of the job
+//
+// options can be nil.
+func NewBundle(job *gothreatmatrix.Job, options *Options) *Bundle {
+	if options == nil {
+		options = &Options{}
+	}
+	created := options.Time
+	if created.IsZero() && job.FinishedAnalysisTime != nil {
+		created = *job.FinishedAnalysisTime
+	}
+	if created.IsZero() && job.ReceivedRequestTime != nil {
+		created = *job.ReceivedRequestTime
+	}
+	if created.IsZero() {
+		created = time.Now()
+	}
+	builder := &bundleBuilder{
+		job:       job,
+		timestamp: created.UTC().Format(TIMESTAMP_FORMAT),
+		objects:   []Object{},
+		ids:       map[string]bool{},
+	}
+	identity := builder.newDomainObject("identity", "threatmatrix")
+	identity["id"] = "identity--" + uuid5(sdoNamespace, "threatmatrix")
+	identity["name"] = "ThreatMatrix"
+	identity["identity_class"] = "system"
+	builder.common = Object{"created_by_ref": builder.add(identity)}
+	if marking, ok := tlpMarkings[tlpName(job.Tlp)]; ok {
+		builder.common["object_marking_refs"] = []string{builder.add(marking)}
+	}
+
+	subject := subjectObservable(job)
+	subjectId := ""
+	subjectHashes := map[string]bool{}
+	if subject != nil {
+		subjectId = builder.add(subject)
+		hashes, _ := subject["hashes"].(map[string]string)
+		for _, value := range hashes {
+			subjectHashes[value] = true
+		}
+	}
+	for _, artifact := range gothreatmatrix.ExtractArtifacts(job) {
+		// * the hashes of the analyzed file are already in its observable
+		if subjectHashes[strings.ToLower(artifact.Value)] {
+			continue
+		}
+		if observable := newObservable(artifact.Classification, artifact.Value); observable != nil {
+			builder.add(observable)
+		}
+	}
+
+	families := gothreatmatrix.ExtractMalwareFamilies(job)
+	malwareIds := []string{}
+	familyAnalyzers := map[string]bool{}
+	for _, family := range families {
+		malware := builder.newDomainObject("malware", family.Name)
+		malware["name"] = family.Name
+		malware["is_family"] = true
+		if len(family.Aliases) > 0 {
+			malware["aliases"] = family.Aliases
+		}
+		malwareIds = append(malwareIds, builder.add(malware))
+		for _, source := range family.Sources {
+			familyAnalyzers[source] = true
+		}
+	}
+
+	for index := range job.AnalyzerReports {
+		report := &job.AnalyzerReports[index]
+		if strings.ToUpper(report.Status) != "SUCCESS" {
+			continue
+		}
+		verdict := gothreatmatrix.UNKNOWN
+		if options.AnalyzerVerdict != nil {
+			verdict = options.AnalyzerVerdict(report)
+		} else if familyAnalyzers[report.Name] {
+			verdict = gothreatmatrix.MALICIOUS
+		}
+		analysis := builder.newDomainObject("malware-analysis", report.Name)
+		analysis["product"] = report.Name
+		analysis["result"] = verdict.String()
+		if subject != nil && subject.Type() == "file" {
+			analysis["sample_ref"] = subjectId
+		}
+		builder.add(analysis)
+	}
+
+	if pattern := indicatorPattern(subject); pattern != "" && options.Verdict >= gothreatmatrix.SUSPICIOUS {
+		indicator := builder.newDomainObject("indicator", "subject")
+		indicator["name"] = subjectName(job)
+		indicator["pattern"] = pattern
+		indicator["pattern_type"] = "stix"
+		indicator["valid_from"] = builder.timestamp
+		indicator["indicator_types"] = []string{"anomalous-activity"}
+		if options.Verdict == gothreatmatrix.MALICIOUS {
+			indicator["indicator_types"] = []string{"malicious-activity"}
+		}
+		indicatorId := builder.add(indicator)
+		for index, malwareId := range malwareIds {
+			relationship := builder.newDomainObject("relationship", "indicates:"+families[index].Name)
+			relationship["relationship_type"] = "indicates"
+			relationship["source_ref"] = indicatorId
+			relationship["target_ref"] = malwareId
+			builder.add(relationship)
+		}
+	}
+
+	report := builder.newDomainObject("report", "job")
+	report["name"] = fmt.Sprintf("ThreatMatrix job %d: %s", job.ID, subjectName(job))
+	report["report_types"] = []string{"threat-report"}
+	report["published"] = builder.timestamp
+	objectRefs := []string{}
+	for _, object := range builder.objects {
+		if objectType := object.Type(); objectType != "identity" && objectType != "marking-definition" {
+			objectRefs = append(objectRefs, object.ID())
+		}
+	}
+	if len(objectRefs) == 0 {
+		objectRefs = append(objectRefs, builder.common["created_by_ref"].(string))
+	}
+	report["object_refs"] = objectRefs
+	if len(job.Tags) > 0 {
+		labels := []string{}
+		for _, tag := range job.Tags {
+			labels = append(labels, tag.Label)
+		}
+		report["labels"] = labels
+	}
+	builder.add(report)
+
+	return &Bundle{
+		Type:    "bundle",
+		ID:      "bundle--" + uuid5(sdoNamespace, fmt.Sprintf("threatmatrix:job:%d:bundle", job.ID)),
+		Objects: builder.objects,
+	}
+}
+
+// subjectName returns the analyzed observable or the name of the analyzed file.
+func subjectName(job *gothreatmatrix.Job) string {
+	if job.IsSample || job.ObservableName == "" {
+		return job.FileName
+	}
+	return job.ObservableName
+}
+
+// subjectObservable returns the cyber-observable object of what the job analyzed, nil when STIX has none for it.
+func subjectObservable(job *gothreatmatrix.Job) Object {
+	if !job.IsSample {
+		return newObservable(job.ObservableClassification, job.ObservableName)
+	}
+	hashes := map[string]string{}
+	if job.Md5 != "" {
+		hashes["MD5"] = strings.ToLower(job.Md5)
+	}
+	for index := range job.AnalyzerReports {
+		report := &job.AnalyzerReports[index]
+		if report.Name != "File_Info" {
+			continue
+		}
+		for _, algorithm := range []string{classify.SHA1, classify.SHA256} {
+			if value, ok := report.Report[algorithm].(string); ok && value != "" {
+				hashes[hashNames[algorithm]] = strings.ToLower(value)
+			}
+		}
+	}
+	properties := map[string]interface{}{}
+	if len(hashes) > 0 {
+		properties["hashes"] = hashes
+	}
+	if job.FileName != "" {
+		properties["name"] = job.FileName
+	}
+	if len(properties) == 0 {
+		return nil
+	}
+	file := newCyberObservable("file", properties)
+	if job.FileMimetype != "" {
+		file["mime_type"] = job.FileMimetype
+	}
+	return file
+}
+
+// newObservable returns the cyber-observable object of a value of the observable classification,
+// nil for the classifications STIX has none for.
+func newObservable(classification string, value string) Object {
+	if value == "" {
+		return nil
+	}
+	switch classification {
+	case classify.IP:
+		if strings.Contains(value, ":") {
+			return newCyberObservable("ipv6-addr", map[string]interface{}{"value": value})
+		}
+		return newCyberObservable("ipv4-addr", map[string]interface{}{"value": value})
+	case classify.DOMAIN:
+		return newCyberObservable("domain-name", map[string]interface{}{"value": strings.ToLower(value)})
+	case classify.URL:
+		return newCyberObservable("url", map[string]interface{}{"value": value})
+	case classify.HASH:
+		if hashName, ok := hashNames[classify.Hash(value)]; ok {
+			return newCyberObservable("file", map[string]interface{}{"hashes": map[string]string{hashName: strings.ToLower(value)}})
+		}
+	}
+	return nil
+}
+
+// newCyberObservable returns the cyber-observable object of the ID contributing properties, with the UUIDv5
+// of their canonical JSON as its ID.
+func newCyberObservable(objectType string, properties map[string]interface{}) Object {
+	canonical := &bytes.Buffer{}
+	encoder := json.NewEncoder(canonical)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(properties)
+	object := Object{
+		"type":         objectType,
+		"spec_version": SPEC_VERSION,
+		"id":           objectType + "--" + uuid5(SCO_NAMESPACE, strings.TrimSuffix(canonical.String(), "\n")),
+	}
+	for property, value := range properties {
+		object[property] = value
+	}
+	return object
+}
+
+// indicatorPattern returns the STIX pattern matching the cyber-observable object, empty when there is none.
+func indicatorPattern(observable Object) string {
+	if observable == nil {
+		return ""
+	}
+	objectType := observable.Type()
+	if value, ok := observable["value"].(string); ok {
+		return fmt.Sprintf("[%s:value = '%s']", objectType, escapePatternValue(value))
+	}
+	hashes, _ := observable["hashes"].(map[string]string)
+	hashNames := make([]string, 0, len(hashes))
+	for hashName := range hashes {
+		hashNames = append(hashNames, hashName)
+	}
+	sort.Strings(hashNames)
+	comparisons := []string{}
+	for _, hashName := range hashNames {
+		comparisons = append(comparisons, fmt.Sprintf("file:hashes.'%s' = '%s'", hashName, hashes[hashName]))
+	}
+	if len(comparisons) == 0 {
+		return ""
+	}
+	return "[" + strings.Join(comparisons, " OR ") + "]"
+}
+
+// escapePatternValue escapes the backslashes and quotes of a string literal of a STIX pattern.
+func escapePatternValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+}
+
+// tlpName returns the lowercase TLP of STIX 2.1, where TLP:CLEAR is still TLP:WHITE.
+func tlpName(tlp string) string {
+	tlp = strings.ToLower(tlp)
+	if tlp == "clear" {
+		return "white"
+	}
+	return tlp
+}
+
+// newTlpMarking returns the TLP marking definition of STIX 2.1 with that UUID.
+func newTlpMarking(uuid string, tlp string) Object {
+	return Object{
+		"type":            "marking-definition",
+		"spec_version":    SPEC_VERSION,
+		"id":              "marking-definition--" + uuid,
+		"created":         "2017-01-20T00:00:00.000Z",
+		"definition_type": "tlp",
+		"name":            "TLP:" + strings.ToUpper(tlp),
+		"definition":      map[string]string{"tlp": tlp},
+	}
+}
+
+// sdoNamespace is the namespace of the UUIDv5 of the objects that are not cyber-observables.
+var sdoNamespace = uuid5(SCO_NAMESPACE, "threatmatrix")
+
+// uuid5 returns the UUIDv5 of the name in the namespace, a UUID in its textual form.
+func uuid5(namespace string, name string) string {
+	namespaceBytes, _ := hex.DecodeString(strings.ReplaceAll(namespace, "-", ""))
+	hash := sha1.New()
+	hash.Write(namespaceBytes)
+	hash.Write([]byte(name))
+	sum := hash.Sum(nil)[:16]
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	encoded := hex.EncodeToString(sum)
+	return encoded[:8] + "-" + encoded[8:12] + "-" + encoded[12:16] + "-" + encoded[16:20] + "-" + encoded[20:]
+}
@@ -0,0 +1,198 @@
+package gothreatmatrix
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DEFAULT_ALERT_MAX_BYTES is the largest alert an AlertBridge accepts when AlertBridgeOptions leaves MaxBytes at 0.
+const DEFAULT_ALERT_MAX_BYTES = 1 << 20
+
+// SIGNATURE_HEADER is the header of the HMAC-SHA256 signature of a webhook body, the one WebhookSink sends.
+const SIGNATURE_HEADER = "X-ThreatMatrix-Signature"
+
+// TIMESTAMP_HEADER is the header of the Unix time, in seconds, a WebhookSink signed its event at. The signature
+// then covers the timestamp, a "." and the body, so that a receiver can reject the replayed events.
+const TIMESTAMP_HEADER = "X-ThreatMatrix-Timestamp"
+
+// AlertBridgeOptions represents the fields used to configure NewAlertBridge, in particular where the fields it
+// needs are in the JSON alerts of the SIEM: dotted paths into the alert, e.g. "rule.name" or "entities.ip".
+type AlertBridgeOptions struct {
+	// NameField is the field naming the investigation, appended to the name of the template.
+	NameField string
+	// IndicatorFields are the fields holding the indicators, strings or arrays of strings.
+	// When empty, every string of the alert that is an observable is an indicator, see ClassifyObservable.
+	IndicatorFields []string
+	// Secret, when set, is the key the alerts are signed with in the SIGNATURE_HEADER header (HMAC-SHA256,
+	// hex encoded), the unsigned ones being rejected.
+	Secret string
+	// MaxBytes is the largest alert accepted, DEFAULT_ALERT_MAX_BYTES when 0.
+	MaxBytes int64
+}
+
+// AlertBridgeResponse represents the JSON response of an AlertBridge to an alert.
+type AlertBridgeResponse struct {
+	InvestigationID  uint64   `json:"investigation_id"`
+	InvestigationUrl string   `json:"investigation_url"`
+	Indicators       []string `json:"indicators"`
+	Jobs             []uint64 `json:"jobs"`
+	// Skipped are the indicators with no playbook in the template.
+	Skipped []string `json:"skipped"`
+	// Error is set when a submission failed after the investigation was created.
+	Error string `json:"error,omitempty"`
+}
+
+// AlertBridge is an http.Handler turning the alert webhooks of a SIEM into investigations: it extracts the
+// indicators of the alert, creates an investigation from the template and runs its playbooks on them, see
+// CreateFromTemplate, then responds with the URL of the investigation.
+//
+// It answers 201 with an AlertBridgeResponse, 400 for alerts that are not JSON objects or have no indicator,
+// 401 for a missing or wrong signature and 502 when ThreatMatrix failed, with an AlertBridgeResponse when the
+// investigation was created before the failure.
+type AlertBridge struct {
+	client   *ThreatMatrixClient
+	template InvestigationTemplate
+	options  AlertBridgeOptions
+}
+
+// NewAlertBridge returns an AlertBridge creating the investigations through the client.
+func (client *ThreatMatrixClient) NewAlertBridge(template *InvestigationTemplate, options *AlertBridgeOptions) *AlertBridge {
+	alertBridge := &AlertBridge{
+		client:   client,
+		template: *template,
+	}
+	if options != nil {
+		alertBridge.options = *options
+	}
+	if alertBridge.options.MaxBytes <= 0 {
+		alertBridge.options.MaxBytes = DEFAULT_ALERT_MAX_BYTES
+	}
+	return alertBridge
+}
+
+// ServeHTTP implements http.Handler.
+func (alertBridge *AlertBridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, alertBridge.options.MaxBytes+1))
+	if err != nil {
+		http.Error(w, "could not read the alert", http.StatusBadRequest)
+		return
+	}
+	if int64(len(body)) > alertBridge.options.MaxBytes {
+		http.Error(w, "the alert is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if alertBridge.options.Secret != "" {
+		signature := hmac.New(sha256.New, []byte(alertBridge.options.Secret))
+		signature.Write(body)
+		received, err := hex.DecodeString(r.Header.Get(SIGNATURE_HEADER))
+		if err != nil || !hmac.Equal(received, signature.Sum(nil)) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+	}
+	alert, err := decodeUntrustedObject(body)
+	if err != nil || alert == nil {
+		http.Error(w, "the alert is not a JSON object", http.StatusBadRequest)
+		return
+	}
+	indicators := alertIndicators(alert, alertBridge.options.IndicatorFields)
+	if len(indicators) == 0 {
+		http.Error(w, "the alert has no indicator", http.StatusBadRequest)
+		return
+	}
+	name := ""
+	if alertBridge.options.NameField != "" {
+		if values := alertFieldValues(alert, alertBridge.options.NameField); len(values) > 0 {
+			name = values[0]
+		}
+	}
+	templated, err := alertBridge.client.InvestigationService.CreateFromTemplate(r.Context(), &alertBridge.template, &InvestigationSeed{
+		Name:        name,
+		Observables: indicators,
+	})
+	if templated == nil {
+		http.Error(w, "could not create the investigation: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	response := AlertBridgeResponse{
+		InvestigationID:  templated.Investigation.ID,
+		InvestigationUrl: alertBridge.client.InvestigationService.WebUrl(templated.Investigation.ID),
+		Indicators:       indicators,
+		Jobs:             templated.Jobs,
+		Skipped:          templated.Skipped,
+	}
+	statusCode := http.StatusCreated
+	if err != nil {
+		response.Error = err.Error()
+		statusCode = http.StatusBadGateway
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(&response)
+}
+
+// alertIndicators returns the sorted, deduplicated indicators of the fields of the alert, see IndicatorFields.
+func alertIndicators(alert map[string]interface{}, fields []string) []string {
+	values := []string{}
+	if len(fields) == 0 {
+		walkMalwareFields(alert, "", func(key string, value string) {
+			values = append(values, value)
+		})
+	}
+	for _, field := range fields {
+		values = append(values, alertFieldValues(alert, field)...)
+	}
+	unique := map[string]bool{}
+	indicators := []string{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if ClassifyObservable(value) == "" || unique[strings.ToLower(value)] {
+			continue
+		}
+		unique[strings.ToLower(value)] = true
+		indicators = append(indicators, value)
+	}
+	sort.Strings(indicators)
+	return indicators
+}
+
+// alertFieldValues returns the strings at the dotted path of the alert, walking the arrays along the way.
+func alertFieldValues(value interface{}, path string) []string {
+	if path == "" {
+		switch typedValue := value.(type) {
+		case string:
+			return []string{typedValue}
+		case []interface{}:
+			values := []string{}
+			for _, item := range typedValue {
+				values = append(values, alertFieldValues(item, "")...)
+			}
+			return values
+		}
+		return nil
+	}
+	key, rest, _ := strings.Cut(path, ".")
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		return alertFieldValues(typedValue[key], rest)
+	case []interface{}:
+		values := []string{}
+		for _, item := range typedValue {
+			values = append(values, alertFieldValues(item, path)...)
+		}
+		return values
+	}
+	return nil
+}
@@ -3,12 +3,24 @@ package gothreatmatrix
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/khulnasoft/go-threatmatrix/classify"
 	"github.com/khulnasoft/go-threatmatrix/constants"
 )
 
@@ -20,12 +32,26 @@ type BasicAnalysisParams struct {
 	AnalyzersRequested   []string               `json:"analyzers_requested"`
 	ConnectorsRequested  []string               `json:"connectors_requested"`
 	TagsLabels           []string               `json:"tags_labels"`
+	PlaybookRequested    string                 `json:"playbook_requested,omitempty"`
+	// ScanMode is SCAN_MODE_FORCE_NEW to always run a new analysis, or SCAN_MODE_CHECK_PREVIOUS to let ThreatMatrix
+	// answer with an analysis of the same observable or file made within ScanCheckTime. 0 leaves it to the instance.
+	ScanMode int `json:"scan_mode,omitempty"`
+	// ScanCheckTime is how far back SCAN_MODE_CHECK_PREVIOUS looks, e.g. FormatScanCheckTime(24 * time.Hour).
+	ScanCheckTime string `json:"scan_check_time,omitempty"`
+	// ForceFreshScan submits a new analysis even when the client reuses recent results.
+	ForceFreshScan bool `json:"-"`
+	// IdempotencyKey, when set, is sent as the Idempotency-Key header and lets the RetryOptions retry the submission.
+	// Use a new key for every distinct analysis.
+	IdempotencyKey string `json:"-"`
 }
 
 // ObservableAnalysisParams represents the fields needed to make an observable analysis.
 type ObservableAnalysisParams struct {
 	BasicAnalysisParams
-	ObservableName           string `json:"observable_name"`
+	ObservableName string `json:"observable_name"`
+	// ObservableClassification is ip, url, domain, hash, generic or the name of a custom classification of the client.
+	// When empty, the client fills it with the first custom classification matching the observable,
+	// or else with the classification the server would give it, see classify.Observable.
 	ObservableClassification string `json:"classification"`
 }
 
@@ -39,12 +65,20 @@ type MultipleObservableAnalysisParams struct {
 type FileAnalysisParams struct {
 	BasicAnalysisParams
 	File *os.File
+	// ExtraFields are additional form fields (e.g. original path, source system) sent along with the file.
+	// The fields of the analysis itself, e.g. tlp or file, are rejected with ErrReservedExtraField.
+	ExtraFields map[string]string
+	// OnProgress, when set, is called every time a chunk of the file is sent.
+	OnProgress func(UploadProgress)
 }
 
 // MultipleFileAnalysisParams represents the fields needed to analyze multiple files.
 type MultipleFileAnalysisParams struct {
 	BasicAnalysisParams
 	Files []*os.File
+	// ExtraFields are additional form fields (e.g. original path, source system) sent along with the files.
+	// The fields of the analysis itself, e.g. tlp or files, are rejected with ErrReservedExtraField.
+	ExtraFields map[string]string
 }
 
 // AnalysisResponse represents a response returned by the API when you analyze an observable or file.
@@ -54,6 +88,9 @@ type AnalysisResponse struct {
 	Warnings          []string `json:"warnings"`
 	AnalyzersRunning  []string `json:"analyzers_running"`
 	ConnectorsRunning []string `json:"connectors_running"`
+	// Deprecations are the notices of the deprecated or removed plugins the analysis requested,
+	// as known from the catalogs the client read, see ThreatMatrixClientOptions.OnDeprecation.
+	Deprecations []DeprecationNotice `json:"-"`
 }
 
 // MultipleAnalysisResponse represent a response returned by the API when you analyze multiple observables or files.
@@ -62,22 +99,102 @@ type MultipleAnalysisResponse struct {
 	Results []AnalysisResponse `json:"results"`
 }
 
+// annotateDeprecations sets the deprecation notices of the analysis on every result.
+func (multipleAnalysisResponse *MultipleAnalysisResponse) annotateDeprecations(deprecations []DeprecationNotice) {
+	for index := range multipleAnalysisResponse.Results {
+		multipleAnalysisResponse.Results[index].Deprecations = deprecations
+	}
+}
+
+// AnalysisAvailabilityParams represents the fields needed to check if an analysis already exists.
+type AnalysisAvailabilityParams struct {
+	// Md5 and Sha256 are the hashes of the sample, either one identifying it.
+	Md5         string   `json:"md5,omitempty"`
+	Sha256      string   `json:"sha256,omitempty"`
+	Analyzers   []string `json:"analyzers,omitempty"`
+	RunningOnly bool     `json:"running_only,omitempty"`
+	MinutesAgo  int      `json:"minutes_ago,omitempty"`
+}
+
+// AnalysisAvailability represents a response returned by the API when you check if an analysis already exists.
+type AnalysisAvailability struct {
+	Status             string   `json:"status"`
+	JobID              int      `json:"job_id"`
+	AnalyzersToExecute []string `json:"analyzers_to_execute"`
+}
+
+// ANALYSIS_NOT_AVAILABLE is the status returned when no previous analysis matches.
+const ANALYSIS_NOT_AVAILABLE = "not_available"
+
+// Exists reports whether a previous analysis matched.
+func (analysisAvailability *AnalysisAvailability) Exists() bool {
+	return analysisAvailability.Status != ANALYSIS_NOT_AVAILABLE
+}
+
 // CreateObservableAnalysis lets you analyze an observable.
 //
 //	Endpoint: POST /api/analyze_observable
 //
 // ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/analyze_observable
 func (client *ThreatMatrixClient) CreateObservableAnalysis(ctx context.Context, params *ObservableAnalysisParams) (*AnalysisResponse, error) {
+	if !client.options.DedupeInFlightSubmissions {
+		return client.submitObservableAnalysis(ctx, params)
+	}
+	key, err := observableSubmissionKey(params)
+	if err != nil {
+		return nil, err
+	}
+	return client.dedupeSubmission(ctx, key, func() (*AnalysisResponse, error) {
+		return client.submitObservableAnalysis(ctx, params)
+	})
+}
+
+// submitObservableAnalysis creates the analysis of the observable, see CreateObservableAnalysis.
+func (client *ThreatMatrixClient) submitObservableAnalysis(ctx context.Context, params *ObservableAnalysisParams) (*AnalysisResponse, error) {
 	requestUrl := client.options.Url + constants.ANALYZE_OBSERVABLE_URL
 	method := "POST"
 	contentType := "application/json"
-	jsonData, _ := json.Marshal(params)
+	observableParams := *params
+	if observableParams.ObservableClassification == "" {
+		observableParams.ObservableClassification = client.matchCustomClassification(observableParams.ObservableName)
+	}
+	if observableParams.ObservableClassification == "" {
+		observableParams.ObservableClassification = classify.Observable(observableParams.ObservableName)
+	}
+	observableParams.ObservableClassification = client.applyClassification(&observableParams.BasicAnalysisParams, observableParams.ObservableClassification)
+	client.applyDefaultPlaybook(&observableParams.BasicAnalysisParams, observableParams.ObservableClassification)
+	if err := client.guardPII(ctx, &observableParams.BasicAnalysisParams, []string{observableParams.ObservableName}); err != nil {
+		return nil, err
+	}
+	if err := client.enforceAnalyzerPolicy(ctx, &observableParams.BasicAnalysisParams); err != nil {
+		return nil, err
+	}
+	canonical := ""
+	if client.options.ReuseRecentResults && !observableParams.ForceFreshScan {
+		canonicalObservable, err := client.canonicalObservable(ctx, observableParams.ObservableClassification, observableParams.ObservableName)
+		if err != nil {
+			return nil, err
+		}
+		canonical = canonicalObservable
+	}
+	observableHash := md5.Sum([]byte(observableParams.ObservableName))
+	recentResponse, window, err := client.reuseRecentAnalysis(ctx, &observableParams.BasicAnalysisParams, hex.EncodeToString(observableHash[:]), canonical)
+	if recentResponse != nil || err != nil {
+		return recentResponse, err
+	}
+	if err := client.auditSubmission(ctx, constants.ANALYZE_OBSERVABLE_URL, &observableParams.BasicAnalysisParams, []string{observableParams.ObservableName}, nil); err != nil {
+		return nil, err
+	}
+	jsonData, _ := json.Marshal(&observableParams)
 	body := bytes.NewBuffer(jsonData)
 
 	request, err := client.buildRequest(ctx, method, contentType, body, requestUrl)
 	if err != nil {
 		return nil, err
 	}
+	if err := client.setIdempotencyKey(request, &observableParams.BasicAnalysisParams); err != nil {
+		return nil, err
+	}
 
 	analysisResponse := AnalysisResponse{}
 	successResp, err := client.newRequest(ctx, request)
@@ -87,6 +204,8 @@ func (client *ThreatMatrixClient) CreateObservableAnalysis(ctx context.Context,
 	if unmarshalError := json.Unmarshal(successResp.Data, &analysisResponse); unmarshalError != nil {
 		return nil, unmarshalError
 	}
+	analysisResponse.Deprecations = client.deprecatedReferences(&observableParams.BasicAnalysisParams)
+	client.rememberAnalysis(canonical, window, &observableParams.BasicAnalysisParams, &analysisResponse)
 	return &analysisResponse, nil
 
 }
@@ -100,13 +219,50 @@ func (client *ThreatMatrixClient) CreateMultipleObservableAnalysis(ctx context.C
 	requestUrl := client.options.Url + constants.ANALYZE_MULTIPLE_OBSERVABLES_URL
 	method := "POST"
 	contentType := "application/json"
-	jsonData, _ := json.Marshal(params)
+	observablesParams := *params
+	observableNames := make([]string, 0, len(observablesParams.Observables))
+	observablesParams.Observables = make([][]string, len(params.Observables))
+	sharedClassification := ""
+	for index, observable := range params.Observables {
+		observablesParams.Observables[index] = observable
+		// * observables are [classification, name] pairs
+		if len(observable) > 0 {
+			observableNames = append(observableNames, observable[len(observable)-1])
+		}
+		classification := ""
+		if len(observable) == 2 {
+			classification = observable[0]
+		}
+		if index == 0 {
+			sharedClassification = classification
+		} else if classification != sharedClassification {
+			sharedClassification = ""
+		}
+		if client.customClassification(classification) != nil {
+			observablesParams.Observables[index] = []string{GENERIC_CLASSIFICATION, observable[1]}
+		}
+	}
+	// * the defaults of a custom classification only apply when it is the classification of every observable
+	client.applyClassification(&observablesParams.BasicAnalysisParams, sharedClassification)
+	if err := client.guardPII(ctx, &observablesParams.BasicAnalysisParams, observableNames); err != nil {
+		return nil, err
+	}
+	if err := client.enforceAnalyzerPolicy(ctx, &observablesParams.BasicAnalysisParams); err != nil {
+		return nil, err
+	}
+	if err := client.auditSubmission(ctx, constants.ANALYZE_MULTIPLE_OBSERVABLES_URL, &observablesParams.BasicAnalysisParams, observableNames, nil); err != nil {
+		return nil, err
+	}
+	jsonData, _ := json.Marshal(&observablesParams)
 	body := bytes.NewBuffer(jsonData)
 
 	request, err := client.buildRequest(ctx, method, contentType, body, requestUrl)
 	if err != nil {
 		return nil, err
 	}
+	if err := client.setIdempotencyKey(request, &observablesParams.BasicAnalysisParams); err != nil {
+		return nil, err
+	}
 
 	multipleAnalysisResponse := MultipleAnalysisResponse{}
 	successResp, err := client.newRequest(ctx, request)
@@ -116,76 +272,341 @@ func (client *ThreatMatrixClient) CreateMultipleObservableAnalysis(ctx context.C
 	if unmarshalError := json.Unmarshal(successResp.Data, &multipleAnalysisResponse); unmarshalError != nil {
 		return nil, unmarshalError
 	}
+	multipleAnalysisResponse.annotateDeprecations(client.deprecatedReferences(&observablesParams.BasicAnalysisParams))
 	return &multipleAnalysisResponse, nil
 }
 
-// CreateFileAnalysis lets you analyze a file.
-//
-//	Endpoint: POST /api/analyze_file
-//
-// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/analyze_file
-func (client *ThreatMatrixClient) CreateFileAnalysis(ctx context.Context, fileAnalysisParams *FileAnalysisParams) (*AnalysisResponse, error) {
-	requestUrl := client.options.Url + constants.ANALYZE_FILE_URL
-	// * Making the multiform data
+// DEFAULT_FILE_PLAYBOOK_KEY is the ThreatMatrixClientOptions.DefaultPlaybooks key used for files
+// whose mime type has no playbook of its own.
+const DEFAULT_FILE_PLAYBOOK_KEY = "file"
+
+// applyDefaultPlaybook sets the default playbook configured for the key (an observable classification or a file mime type)
+// when the caller requested neither a playbook nor analyzers. It reports whether a playbook was set.
+func (client *ThreatMatrixClient) applyDefaultPlaybook(basicAnalysisParams *BasicAnalysisParams, key string) bool {
+	if basicAnalysisParams.PlaybookRequested != "" || len(basicAnalysisParams.AnalyzersRequested) > 0 {
+		return false
+	}
+	playbook, ok := client.options.DefaultPlaybooks[key]
+	if !ok || playbook == "" {
+		return false
+	}
+	basicAnalysisParams.PlaybookRequested = playbook
+	return true
+}
+
+// applyFilePlaybook routes the file, when the analysis requests neither a playbook nor analyzers, to the playbook
+// of its detected type in FileTypePlaybooks, or else to the default playbook of its mime type or of every file.
+func (client *ThreatMatrixClient) applyFilePlaybook(basicAnalysisParams *BasicAnalysisParams, file *os.File) error {
+	if basicAnalysisParams.PlaybookRequested != "" || len(basicAnalysisParams.AnalyzersRequested) > 0 {
+		return nil
+	}
+	if len(client.options.FileTypePlaybooks) > 0 {
+		fileType, err := DetectFileType(file)
+		if err != nil {
+			return err
+		}
+		if playbook := client.options.FileTypePlaybooks[fileType]; playbook != "" {
+			basicAnalysisParams.PlaybookRequested = playbook
+			return nil
+		}
+	}
+	if len(client.options.DefaultPlaybooks) == 0 {
+		return nil
+	}
+	mimeType, err := detectMimeType(file)
+	if err != nil {
+		return err
+	}
+	if !client.applyDefaultPlaybook(basicAnalysisParams, mimeType) {
+		client.applyDefaultPlaybook(basicAnalysisParams, DEFAULT_FILE_PLAYBOOK_KEY)
+	}
+	return nil
+}
+
+// detectMimeType sniffs the mime type of the file content from its current offset, the part of it an upload sends,
+// and seeks the file back to that offset.
+func detectMimeType(file *os.File) (string, error) {
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	header := make([]byte, 512)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	mimeType := http.DetectContentType(header[:n])
+	if index := strings.Index(mimeType, ";"); index >= 0 {
+		mimeType = mimeType[:index]
+	}
+	return mimeType, nil
+}
+
+// analysisFormBuilder builds the multipart form sent when analyzing files.
+// The files are not copied into the form but streamed when it is sent, see buildUploadRequest.
+type analysisFormBuilder struct {
+	body   *bytes.Buffer
+	writer *multipart.Writer
+	files  []formFile
+}
+
+// formFile is a file of the form, streamed after the bytes of the form written before it.
+type formFile struct {
+	head       []byte
+	reader     io.Reader
+	fileName   string
+	size       int64
+	onProgress func(UploadProgress)
+	seeker     io.Seeker
+	offset     int64
+}
+
+// newAnalysisFormBuilder returns an empty analysisFormBuilder.
+func newAnalysisFormBuilder() *analysisFormBuilder {
 	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+	return &analysisFormBuilder{
+		body:   body,
+		writer: multipart.NewWriter(body),
+	}
+}
 
+// writeBasicParams adds the fields shared by every file analysis.
+func (builder *analysisFormBuilder) writeBasicParams(basicAnalysisParams *BasicAnalysisParams) error {
 	// * Adding the TLP field
-	writeTlpError := writer.WriteField("tlp", fileAnalysisParams.Tlp.String())
-	if writeTlpError != nil {
-		return nil, writeTlpError
+	if err := builder.writer.WriteField("tlp", basicAnalysisParams.Tlp.String()); err != nil {
+		return err
 	}
 	// * Adding the runtimeconfiguration field
-	runTimeConfigurationJson, marshalError := json.Marshal(fileAnalysisParams.RuntimeConfiguration)
+	runTimeConfigurationJson, marshalError := json.Marshal(basicAnalysisParams.RuntimeConfiguration)
 	if marshalError != nil {
-		return nil, marshalError
+		return marshalError
 	}
-	runTimeConfigurationJsonString := string(runTimeConfigurationJson)
-	writeRuntimeError := writer.WriteField("runtime_configuration", runTimeConfigurationJsonString)
-	if writeRuntimeError != nil {
-		return nil, writeRuntimeError
+	if err := builder.writer.WriteField("runtime_configuration", string(runTimeConfigurationJson)); err != nil {
+		return err
 	}
-
 	// * Adding the requested analyzers
-	for _, analyzer := range fileAnalysisParams.AnalyzersRequested {
-		writeAnalyzerError := writer.WriteField("analyzers_requested", analyzer)
-		if writeAnalyzerError != nil {
-			return nil, writeAnalyzerError
+	if err := builder.writeField("analyzers_requested", basicAnalysisParams.AnalyzersRequested...); err != nil {
+		return err
+	}
+	// * Adding the requested connectors
+	if err := builder.writeField("connectors_requested", basicAnalysisParams.ConnectorsRequested...); err != nil {
+		return err
+	}
+	// * Adding the tag labels
+	if err := builder.writeField("tags_labels", basicAnalysisParams.TagsLabels...); err != nil {
+		return err
+	}
+	// * Adding the scan mode
+	if basicAnalysisParams.ScanMode != 0 {
+		if err := builder.writer.WriteField("scan_mode", strconv.Itoa(basicAnalysisParams.ScanMode)); err != nil {
+			return err
+		}
+	}
+	if basicAnalysisParams.ScanCheckTime != "" {
+		if err := builder.writer.WriteField("scan_check_time", basicAnalysisParams.ScanCheckTime); err != nil {
+			return err
 		}
 	}
+	// * Adding the requested playbook
+	if basicAnalysisParams.PlaybookRequested != "" {
+		return builder.writer.WriteField("playbook_requested", basicAnalysisParams.PlaybookRequested)
+	}
+	return nil
+}
 
-	// * Adding the requested connectors
-	for _, connector := range fileAnalysisParams.ConnectorsRequested {
-		writeConnectorError := writer.WriteField("connectors_requested", connector)
-		if writeConnectorError != nil {
-			return nil, writeConnectorError
+// writeField adds the field once for each of the given values.
+func (builder *analysisFormBuilder) writeField(fieldName string, values ...string) error {
+	for _, value := range values {
+		if err := builder.writer.WriteField(fieldName, value); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	// * Adding the tag labels
-	for _, tagLabel := range fileAnalysisParams.TagsLabels {
-		writeTagLabelError := writer.WriteField("tags_labels", tagLabel)
-		if writeTagLabelError != nil {
-			return nil, writeTagLabelError
+// ErrReservedExtraField is returned when an ExtraFields name is one of the fields the analysis itself sends.
+var ErrReservedExtraField = errors.New("gothreatmatrix: the extra field is a field of the analysis")
+
+// reservedFormFields are the fields of the analysis forms, which ExtraFields must not override nor repeat.
+var reservedFormFields = map[string]bool{
+	"tlp":                       true,
+	"runtime_configuration":     true,
+	"analyzers_requested":       true,
+	"connectors_requested":      true,
+	"tags_labels":               true,
+	"scan_mode":                 true,
+	"scan_check_time":           true,
+	"playbook_requested":        true,
+	"file":                      true,
+	"files":                     true,
+	"observable_name":           true,
+	"observable_classification": true,
+	"observables":               true,
+}
+
+// writeExtraFields adds the caller's additional fields, sorted by name to keep the form deterministic.
+// It returns an error wrapping ErrReservedExtraField, before writing any of them, when one is a reservedFormFields.
+func (builder *analysisFormBuilder) writeExtraFields(extraFields map[string]string) error {
+	fieldNames := make([]string, 0, len(extraFields))
+	for fieldName := range extraFields {
+		if reservedFormFields[fieldName] {
+			return fmt.Errorf("%w: %s", ErrReservedExtraField, fieldName)
+		}
+		fieldNames = append(fieldNames, fieldName)
+	}
+	sort.Strings(fieldNames)
+	for _, fieldName := range fieldNames {
+		if err := builder.writer.WriteField(fieldName, extraFields[fieldName]); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	// * Adding the file!
-	filePart, _ := writer.CreateFormFile("file", filepath.Base(fileAnalysisParams.File.Name()))
-	_, writeFileError := io.Copy(filePart, fileAnalysisParams.File)
-	if writeFileError != nil {
-		writer.Close()
-		return nil, writeFileError
+// writeFile adds the file, from its current offset, under the given field name.
+func (builder *analysisFormBuilder) writeFile(fieldName string, file *os.File, onProgress func(UploadProgress)) error {
+	size, err := remainingSize(file)
+	if err != nil {
+		return err
 	}
-	writer.Close()
+	return builder.writeReader(fieldName, filepath.Base(file.Name()), file, size, onProgress)
+}
 
-	//* building the request!
-	contentType := writer.FormDataContentType()
-	method := "POST"
-	request, err := client.buildRequest(ctx, method, contentType, body, requestUrl)
+// writeReader adds the content of the reader under the given field name, size being its length or 0 or less when
+// unknown. When the reader is an io.Seeker, it is rewound to its current offset when the form is sent again.
+func (builder *analysisFormBuilder) writeReader(fieldName string, fileName string, reader io.Reader, size int64, onProgress func(UploadProgress)) error {
+	if _, err := builder.writer.CreateFormFile(fieldName, fileName); err != nil {
+		return err
+	}
+	if size <= 0 {
+		size = -1
+	}
+	file := formFile{
+		head:       append([]byte(nil), builder.body.Bytes()...),
+		reader:     reader,
+		fileName:   fileName,
+		size:       size,
+		onProgress: onProgress,
+	}
+	if seeker, ok := reader.(io.Seeker); ok {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		file.seeker, file.offset = seeker, offset
+	}
+	builder.files = append(builder.files, file)
+	builder.body.Reset()
+	return nil
+}
+
+// close finishes the form and returns its content type.
+func (builder *analysisFormBuilder) close() (string, error) {
+	if err := builder.writer.Close(); err != nil {
+		return "", err
+	}
+	return builder.writer.FormDataContentType(), nil
+}
+
+// CreateFileAnalysis lets you analyze a file.
+// With VerifyUploads, the job is fetched once created and an *IntegrityError wrapping ErrUploadChecksumMismatch
+// is returned with the response when the server hashes differ from the file, e.g. a truncated upload.
+// With ResubmitCorruptUploads too, the file is then submitted once more, as a fresh scan.
+//
+//	Endpoint: POST /api/analyze_file
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/analyze_file
+func (client *ThreatMatrixClient) CreateFileAnalysis(ctx context.Context, fileAnalysisParams *FileAnalysisParams) (*AnalysisResponse, error) {
+	if !client.options.DedupeInFlightSubmissions {
+		return client.createFileAnalysis(ctx, fileAnalysisParams)
+	}
+	key, err := fileSubmissionKey(fileAnalysisParams)
+	if err != nil {
+		return nil, err
+	}
+	return client.dedupeSubmission(ctx, key, func() (*AnalysisResponse, error) {
+		return client.createFileAnalysis(ctx, fileAnalysisParams)
+	})
+}
+
+// createFileAnalysis submits the file, then verifies the upload, see CreateFileAnalysis.
+func (client *ThreatMatrixClient) createFileAnalysis(ctx context.Context, fileAnalysisParams *FileAnalysisParams) (*AnalysisResponse, error) {
+	if !client.options.VerifyUploads {
+		return client.submitFileAnalysis(ctx, fileAnalysisParams)
+	}
+	// * the upload sends the file from its current offset, the part of it the job hashes are compared with
+	offset, err := fileAnalysisParams.File.Seek(0, io.SeekCurrent)
 	if err != nil {
 		return nil, err
 	}
+	analysisResponse, err := client.submitFileAnalysis(ctx, fileAnalysisParams)
+	if err != nil {
+		return analysisResponse, err
+	}
+	err = client.verifyUpload(ctx, analysisResponse.JobID, fileAnalysisParams.File, offset)
+	if !errors.Is(err, ErrUploadChecksumMismatch) || !client.options.ResubmitCorruptUploads {
+		return analysisResponse, err
+	}
+	resubmitParams := *fileAnalysisParams
+	resubmitParams.ForceFreshScan = true
+	if resubmitParams.IdempotencyKey != "" {
+		resubmitParams.IdempotencyKey += "-resubmit"
+	}
+	if analysisResponse, err = client.submitFileAnalysis(ctx, &resubmitParams); err != nil {
+		return analysisResponse, err
+	}
+	return analysisResponse, client.verifyUpload(ctx, analysisResponse.JobID, fileAnalysisParams.File, offset)
+}
+
+// submitFileAnalysis uploads the file and creates its analysis, see CreateFileAnalysis.
+func (client *ThreatMatrixClient) submitFileAnalysis(ctx context.Context, fileAnalysisParams *FileAnalysisParams) (*AnalysisResponse, error) {
+	requestUrl := client.options.Url + constants.ANALYZE_FILE_URL
+	// * Making the multiform data
+	basicAnalysisParams := fileAnalysisParams.BasicAnalysisParams
+	if err := client.applyFilePlaybook(&basicAnalysisParams, fileAnalysisParams.File); err != nil {
+		return nil, err
+	}
+	fileName := filepath.Base(fileAnalysisParams.File.Name())
+	if err := client.guardPII(ctx, &basicAnalysisParams, []string{fileName}); err != nil {
+		return nil, err
+	}
+	if err := client.enforceAnalyzerPolicy(ctx, &basicAnalysisParams); err != nil {
+		return nil, err
+	}
+	if client.options.ReuseRecentResults && !basicAnalysisParams.ForceFreshScan {
+		fileHash, err := fileMd5(fileAnalysisParams.File)
+		if err != nil {
+			return nil, err
+		}
+		if recentResponse, _, err := client.reuseRecentAnalysis(ctx, &basicAnalysisParams, fileHash, ""); recentResponse != nil || err != nil {
+			return recentResponse, err
+		}
+	}
+	builder := newAnalysisFormBuilder()
+	if err := builder.writeBasicParams(&basicAnalysisParams); err != nil {
+		return nil, err
+	}
+	if err := builder.writeExtraFields(fileAnalysisParams.ExtraFields); err != nil {
+		return nil, err
+	}
+	if err := client.auditSubmission(ctx, constants.ANALYZE_FILE_URL, &basicAnalysisParams, nil, []string{fileName}); err != nil {
+		return nil, err
+	}
+	if err := builder.writeFile("file", fileAnalysisParams.File, fileAnalysisParams.OnProgress); err != nil {
+		return nil, err
+	}
+
+	//* building the request, the file is streamed!
+	request, err := client.buildUploadRequest(ctx, builder, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.setIdempotencyKey(request, &basicAnalysisParams); err != nil {
+		return nil, err
+	}
 	analysisResponse := AnalysisResponse{}
 	successResp, err := client.newRequest(ctx, request)
 	if err != nil {
@@ -194,6 +615,7 @@ func (client *ThreatMatrixClient) CreateFileAnalysis(ctx context.Context, fileAn
 	if unmarshalError := json.Unmarshal(successResp.Data, &analysisResponse); unmarshalError != nil {
 		return nil, unmarshalError
 	}
+	analysisResponse.Deprecations = client.deprecatedReferences(&basicAnalysisParams)
 	return &analysisResponse, nil
 }
 
@@ -205,67 +627,42 @@ func (client *ThreatMatrixClient) CreateFileAnalysis(ctx context.Context, fileAn
 func (client *ThreatMatrixClient) CreateMultipleFileAnalysis(ctx context.Context, fileAnalysisParams *MultipleFileAnalysisParams) (*MultipleAnalysisResponse, error) {
 	requestUrl := client.options.Url + constants.ANALYZE_MULTIPLE_FILES_URL
 	// * Making the multiform data
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	// * Adding the TLP field
-	writeTlpError := writer.WriteField("tlp", fileAnalysisParams.Tlp.String())
-	if writeTlpError != nil {
-		return nil, writeTlpError
-	}
-	// * Adding the runtimeconfiguration field
-	runTimeConfigurationJson, marshalError := json.Marshal(fileAnalysisParams.RuntimeConfiguration)
-	if marshalError != nil {
-		return nil, marshalError
+	basicAnalysisParams := fileAnalysisParams.BasicAnalysisParams
+	fileNames := make([]string, 0, len(fileAnalysisParams.Files))
+	for _, file := range fileAnalysisParams.Files {
+		fileNames = append(fileNames, filepath.Base(file.Name()))
 	}
-	runTimeConfigurationJsonString := string(runTimeConfigurationJson)
-	writeRuntimeError := writer.WriteField("runtime_configuration", runTimeConfigurationJsonString)
-	if writeRuntimeError != nil {
-		return nil, writeRuntimeError
+	if err := client.guardPII(ctx, &basicAnalysisParams, fileNames); err != nil {
+		return nil, err
 	}
-
-	// * Adding the requested analyzers
-	for _, analyzer := range fileAnalysisParams.AnalyzersRequested {
-		writeAnalyzerError := writer.WriteField("analyzers_requested", analyzer)
-		if writeAnalyzerError != nil {
-			return nil, writeAnalyzerError
-		}
+	if err := client.enforceAnalyzerPolicy(ctx, &basicAnalysisParams); err != nil {
+		return nil, err
 	}
-
-	// * Adding the requested connectors
-	for _, connector := range fileAnalysisParams.ConnectorsRequested {
-		writeConnectorError := writer.WriteField("connectors_requested", connector)
-		if writeConnectorError != nil {
-			return nil, writeConnectorError
-		}
+	builder := newAnalysisFormBuilder()
+	if err := builder.writeBasicParams(&basicAnalysisParams); err != nil {
+		return nil, err
 	}
-
-	// * Adding the tag labels
-	for _, tagLabel := range fileAnalysisParams.TagsLabels {
-		writeTagLabelError := writer.WriteField("tags_labels", tagLabel)
-		if writeTagLabelError != nil {
-			return nil, writeTagLabelError
-		}
+	if err := builder.writeExtraFields(fileAnalysisParams.ExtraFields); err != nil {
+		return nil, err
 	}
-
 	// * Adding the files!
 	for _, file := range fileAnalysisParams.Files {
-		filePart, _ := writer.CreateFormFile("files", filepath.Base(file.Name()))
-		_, writeFileError := io.Copy(filePart, file)
-		if writeFileError != nil {
-			writer.Close()
-			return nil, writeFileError
+		if err := builder.writeFile("files", file, nil); err != nil {
+			return nil, err
 		}
 	}
-	writer.Close()
+	if err := client.auditSubmission(ctx, constants.ANALYZE_MULTIPLE_FILES_URL, &basicAnalysisParams, nil, fileNames); err != nil {
+		return nil, err
+	}
 
-	//* building the request!
-	contentType := writer.FormDataContentType()
-	method := "POST"
-	request, err := client.buildRequest(ctx, method, contentType, body, requestUrl)
+	//* building the request, the files are streamed!
+	request, err := client.buildUploadRequest(ctx, builder, requestUrl)
 	if err != nil {
 		return nil, err
 	}
+	if err := client.setIdempotencyKey(request, &basicAnalysisParams); err != nil {
+		return nil, err
+	}
 
 	multipleAnalysisResponse := MultipleAnalysisResponse{}
 	successResp, err := client.newRequest(ctx, request)
@@ -275,5 +672,176 @@ func (client *ThreatMatrixClient) CreateMultipleFileAnalysis(ctx context.Context
 	if unmarshalError := json.Unmarshal(successResp.Data, &multipleAnalysisResponse); unmarshalError != nil {
 		return nil, unmarshalError
 	}
+	multipleAnalysisResponse.annotateDeprecations(client.deprecatedReferences(&basicAnalysisParams))
 	return &multipleAnalysisResponse, nil
 }
+
+// AskAnalysisAvailability checks if an analysis of the given md5 already exists in your ThreatMatrix instance.
+//
+//	Endpoint: POST /api/ask_analysis_availability
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/ask_analysis_availability
+func (client *ThreatMatrixClient) AskAnalysisAvailability(ctx context.Context, params *AnalysisAvailabilityParams) (*AnalysisAvailability, error) {
+	requestUrl := client.options.Url + constants.ASK_ANALYSIS_AVAILABILITY_URL
+	method := "POST"
+	contentType := "application/json"
+	jsonData, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	body := bytes.NewBuffer(jsonData)
+
+	request, err := client.buildRequest(ctx, method, contentType, body, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	analysisAvailability := AnalysisAvailability{}
+	successResp, err := client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalError := json.Unmarshal(successResp.Data, &analysisAvailability); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &analysisAvailability, nil
+}
+
+// CheckExisting checks if an analysis of the observable already exists in your ThreatMatrix instance, e.g. to
+// avoid submitting a duplicate. The params, nil for any analysis of it, narrow the check to some analyzers,
+// to the running analyses or to the last minutes; their Md5 is the one of the observable.
+//
+//	Endpoint: POST /api/ask_analysis_availability
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/ask_analysis_availability
+func (client *ThreatMatrixClient) CheckExisting(ctx context.Context, observableName string, params *AnalysisAvailabilityParams) (*AnalysisAvailability, error) {
+	availabilityParams := AnalysisAvailabilityParams{}
+	if params != nil {
+		availabilityParams = *params
+	}
+	observableHash := md5.Sum([]byte(observableName))
+	availabilityParams.Md5 = hex.EncodeToString(observableHash[:])
+	return client.AskAnalysisAvailability(ctx, &availabilityParams)
+}
+
+// SubmitByHash analyzes a file only when your ThreatMatrix instance doesn't already have an analysis of it.
+// The md5 and sha256 of the file from its current offset, the part of it an upload sends, are computed locally and
+// checked through AskAnalysisAvailability: when an analysis exists its job is returned and the file is never uploaded,
+// otherwise it is uploaded from that offset.
+func (client *ThreatMatrixClient) SubmitByHash(ctx context.Context, fileAnalysisParams *FileAnalysisParams) (*AnalysisResponse, error) {
+	md5Hash, sha256Hash := md5.New(), sha256.New()
+	if err := hashFile(fileAnalysisParams.File, md5Hash, sha256Hash); err != nil {
+		return nil, err
+	}
+	availability, err := client.AskAnalysisAvailability(ctx, &AnalysisAvailabilityParams{
+		Md5:       hex.EncodeToString(md5Hash.Sum(nil)),
+		Sha256:    hex.EncodeToString(sha256Hash.Sum(nil)),
+		Analyzers: fileAnalysisParams.AnalyzersRequested,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if availability.Exists() {
+		return &AnalysisResponse{
+			JobID:            availability.JobID,
+			Status:           availability.Status,
+			AnalyzersRunning: availability.AnalyzersToExecute,
+		}, nil
+	}
+	return client.CreateFileAnalysis(ctx, fileAnalysisParams)
+}
+
+// fileMd5 computes the md5 of the file content from its current offset, which it leaves the file at.
+func fileMd5(file *os.File) (string, error) {
+	hash := md5.New()
+	if err := hashFile(file, hash); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashFile writes the file content from its current offset, the part of it an upload sends, to the hashes and
+// seeks the file back to that offset.
+func hashFile(file *os.File, hashes ...hash.Hash) error {
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	writers := make([]io.Writer, 0, len(hashes))
+	for _, digest := range hashes {
+		writers = append(writers, digest)
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), file); err != nil {
+		return err
+	}
+	_, err = file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// dedupWindow returns how far back recent analyses are reused for the analysis, 0 to always submit.
+func (client *ThreatMatrixClient) dedupWindow(ctx context.Context, basicAnalysisParams *BasicAnalysisParams) (time.Duration, error) {
+	if !client.options.ReuseRecentResults || basicAnalysisParams.ForceFreshScan || basicAnalysisParams.ScanMode == SCAN_MODE_FORCE_NEW {
+		return 0, nil
+	}
+	if basicAnalysisParams.ScanCheckTime != "" {
+		return ParseScanCheckTime(basicAnalysisParams.ScanCheckTime)
+	}
+	if basicAnalysisParams.PlaybookRequested == "" {
+		return time.Duration(client.options.DedupWindowMinutes) * time.Minute, nil
+	}
+	playbookConfigs, err := client.playbookConfigs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	playbookConfig, ok := playbookConfigs[basicAnalysisParams.PlaybookRequested]
+	if !ok {
+		return time.Duration(client.options.DedupWindowMinutes) * time.Minute, nil
+	}
+	return playbookConfig.ScanCheckWindow()
+}
+
+// reuseRecentAnalysis returns the response of a recent analysis of the same md5 within the dedup window,
+// nil when a new analysis has to be submitted. When canonical is set, the analyses remembered by the client for
+// the same canonical observable are reused too, see IndicatorCanonicalizer.
+// The window is returned so the new analysis can be remembered, see rememberAnalysis.
+func (client *ThreatMatrixClient) reuseRecentAnalysis(ctx context.Context, basicAnalysisParams *BasicAnalysisParams, md5 string, canonical string) (*AnalysisResponse, time.Duration, error) {
+	window, err := client.dedupWindow(ctx, basicAnalysisParams)
+	if err != nil || window <= 0 {
+		return nil, 0, err
+	}
+	if canonical != "" && client.recent != nil {
+		if recentResponse, ok := client.recent.get(recentAnalysisKey(canonical, basicAnalysisParams), window); ok {
+			recentResponse.Warnings = append(append([]string{}, recentResponse.Warnings...), fmt.Sprintf("reused the analysis of job %d of the same canonical observable %s", recentResponse.JobID, canonical))
+			return recentResponse, window, nil
+		}
+	}
+	minutesAgo := int(window / time.Minute)
+	if minutesAgo < 1 {
+		minutesAgo = 1
+	}
+	availability, err := client.AskAnalysisAvailability(ctx, &AnalysisAvailabilityParams{
+		Md5:        md5,
+		Analyzers:  basicAnalysisParams.AnalyzersRequested,
+		MinutesAgo: minutesAgo,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if availability.Status == ANALYSIS_NOT_AVAILABLE {
+		return nil, window, nil
+	}
+	return &AnalysisResponse{
+		JobID:            availability.JobID,
+		Status:           availability.Status,
+		Warnings:         []string{fmt.Sprintf("reused the analysis of job %d from the last %d minutes", availability.JobID, minutesAgo)},
+		AnalyzersRunning: availability.AnalyzersToExecute,
+	}, window, nil
+}
+
+// rememberAnalysis remembers the analysis submitted for the canonical observable, so it is reused within the window.
+func (client *ThreatMatrixClient) rememberAnalysis(canonical string, window time.Duration, basicAnalysisParams *BasicAnalysisParams, analysisResponse *AnalysisResponse) {
+	if canonical == "" || window <= 0 || client.recent == nil {
+		return
+	}
+	client.recent.add(recentAnalysisKey(canonical, basicAnalysisParams), window, analysisResponse)
+}
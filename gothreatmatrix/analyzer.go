@@ -2,9 +2,7 @@ package gothreatmatrix
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"sort"
+	"sync"
 
 	"github.com/khulnasoft/go-threatmatrix/constants"
 )
@@ -38,36 +36,25 @@ type AnalyzerService struct {
 //
 // ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/get_analyzer_configs
 func (analyzerService *AnalyzerService) GetConfigs(ctx context.Context) (*[]AnalyzerConfig, error) {
-	requestUrl := analyzerService.client.options.Url + constants.ANALYZER_CONFIG_URL
-	contentType := "application/json"
-	method := "GET"
-	request, err := analyzerService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	snapshot, err := analyzerService.client.catalogSnapshot()
 	if err != nil {
 		return nil, err
 	}
-
-	successResp, err := analyzerService.client.newRequest(ctx, request)
-	if err != nil {
+	var analyzerConfigurationResponse map[string]AnalyzerConfig
+	if snapshot != nil {
+		analyzerConfigurationResponse = snapshot.Analyzers
+	} else if analyzerConfigurationResponse, err = analyzerService.fetchConfigs(ctx); err != nil {
 		return nil, err
 	}
-	analyzerConfigurationResponse := map[string]AnalyzerConfig{}
-	if unmarshalError := json.Unmarshal(successResp.Data, &analyzerConfigurationResponse); unmarshalError != nil {
-		return nil, unmarshalError
-	}
+	observeCatalog(analyzerService.client, ANALYZER_PLUGIN, analyzerConfigurationResponse, func(config AnalyzerConfig) bool {
+		return config.Deprecated
+	})
+	return sortPluginConfigs(analyzerConfigurationResponse), nil
+}
 
-	analyzerNames := make([]string, 0)
-	// *getting all the analyzer key names!
-	for analyzerName := range analyzerConfigurationResponse {
-		analyzerNames = append(analyzerNames, analyzerName)
-	}
-	// * sorting them alphabetically
-	sort.Strings(analyzerNames)
-	analyzerConfigurationList := []AnalyzerConfig{}
-	for _, analyzerName := range analyzerNames {
-		analyzerConfig := analyzerConfigurationResponse[analyzerName]
-		analyzerConfigurationList = append(analyzerConfigurationList, analyzerConfig)
-	}
-	return &analyzerConfigurationList, nil
+// fetchConfigs gets the analyzer configurations from the ThreatMatrix instance, keyed by analyzer name.
+func (analyzerService *AnalyzerService) fetchConfigs(ctx context.Context) (map[string]AnalyzerConfig, error) {
+	return fetchPluginConfigs[AnalyzerConfig](ctx, analyzerService.client, constants.ANALYZER_CONFIG_URL)
 }
 
 // HealthCheck checks if the specified analyzer is up and running
@@ -76,21 +63,180 @@ func (analyzerService *AnalyzerService) GetConfigs(ctx context.Context) (*[]Anal
 //
 // ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/analyzer/operation/analyzer_healthcheck_retrieve
 func (analyzerService *AnalyzerService) HealthCheck(ctx context.Context, analyzerName string) (bool, error) {
-	route := analyzerService.client.options.Url + constants.ANALYZER_HEALTHCHECK_URL
-	requestUrl := fmt.Sprintf(route, analyzerName)
-	contentType := "application/json"
-	method := "GET"
-	request, err := analyzerService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	return pluginHealthCheck(ctx, analyzerService.client, constants.ANALYZER_HEALTHCHECK_URL, analyzerName)
+}
+
+// DEFAULT_HEALTH_CHECK_CONCURRENCY is the number of health checks HealthCheckAll runs in parallel
+// when HealthCheckAllOptions leaves Concurrency at 0.
+const DEFAULT_HEALTH_CHECK_CONCURRENCY = 4
+
+// HealthCheckAllOptions represents the fields used to configure the HealthCheckAll of the analyzers and connectors.
+type HealthCheckAllOptions struct {
+	// Concurrency is the number of health checks in flight, DEFAULT_HEALTH_CHECK_CONCURRENCY when 0.
+	Concurrency int
+	// IncludeDisabled checks the disabled plugins too, they are skipped otherwise.
+	IncludeDisabled bool
+}
+
+// HealthCheckAll fetches the analyzer configurations and runs the health check of every analyzer in parallel,
+// keyed by analyzer name. A failed check is reported in the Err of its PluginHealth, the analyzer then not
+// being Healthy: the error is only set when the configurations could not be fetched or when ctx is done,
+// the health of the analyzers checked so far being still returned.
+//
+//	Endpoint: GET /api/get_analyzer_configs
+//	Endpoint: GET /api/analyzer/{NameOfAnalyzer}/healthcheck
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/analyzer/operation/analyzer_healthcheck_retrieve
+func (analyzerService *AnalyzerService) HealthCheckAll(ctx context.Context, options *HealthCheckAllOptions) (map[string]PluginHealth, error) {
+	if options == nil {
+		options = &HealthCheckAllOptions{}
+	}
+	analyzerConfigs, err := analyzerService.GetConfigs(ctx)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	analyzerNames := []string{}
+	for _, analyzerConfig := range *analyzerConfigs {
+		if !analyzerConfig.Disabled || options.IncludeDisabled {
+			analyzerNames = append(analyzerNames, analyzerConfig.Name)
+		}
+	}
+	return analyzerService.client.healthCheckAll(ctx, ANALYZER_PLUGIN, analyzerNames, options.Concurrency)
+}
+
+// healthCheckAll runs the health check of the plugins of the type in parallel, see HealthCheckAll.
+func (client *ThreatMatrixClient) healthCheckAll(ctx context.Context, pluginType string, names []string, concurrency int) (map[string]PluginHealth, error) {
+	if concurrency < 1 {
+		concurrency = DEFAULT_HEALTH_CHECK_CONCURRENCY
+	}
+	health := map[string]PluginHealth{}
+	var mutex sync.Mutex
+	var ctxErr error
+	semaphore := make(chan struct{}, concurrency)
+	var waitGroup sync.WaitGroup
+	for _, name := range names {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		waitGroup.Add(1)
+		go func(name string) {
+			defer waitGroup.Done()
+			defer func() { <-semaphore }()
+			pluginHealth, err := client.checkPlugin(ctx, [2]string{pluginType, name})
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				ctxErr = err
+				return
+			}
+			health[name] = pluginHealth
+		}(name)
 	}
-	status := StatusResponse{}
-	successResp, err := analyzerService.client.newRequest(ctx, request)
+	waitGroup.Wait()
+	if ctxErr == nil {
+		ctxErr = ctx.Err()
+	}
+	return health, ctxErr
+}
+
+// Enable enables the analyzer back for the organization of the user, after Disable.
+//
+//	Endpoint: DELETE /api/analyzer/{NameOfAnalyzer}/organization
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/analyzer/operation/analyzer_organization_destroy
+func (analyzerService *AnalyzerService) Enable(ctx context.Context, analyzerName string) error {
+	return setPluginOrganizationState(ctx, analyzerService.client, constants.ANALYZER_ORGANIZATION_URL, analyzerName, true)
+}
+
+// Disable disables the analyzer for the organization of the user: its members cannot run it anymore.
+// It needs the user to be an admin of the organization.
+//
+//	Endpoint: POST /api/analyzer/{NameOfAnalyzer}/organization
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/analyzer/operation/analyzer_organization_create
+func (analyzerService *AnalyzerService) Disable(ctx context.Context, analyzerName string) error {
+	return setPluginOrganizationState(ctx, analyzerService.client, constants.ANALYZER_ORGANIZATION_URL, analyzerName, false)
+}
+
+// FreeLocalOnly lists down the names of the analyzers that run entirely inside your ThreatMatrix instance at no cost,
+// ready to be used as AnalyzersRequested in air-gapped or budget-constrained deployments.
+//
+// An analyzer is kept when it is enabled, does not call an external service,
+// and does not need a required secret (usually a license or an API key).
+func (analyzerService *AnalyzerService) FreeLocalOnly(ctx context.Context) ([]string, error) {
+	analyzerConfigs, err := analyzerService.GetConfigs(ctx)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	analyzerNames := []string{}
+	for _, analyzerConfig := range *analyzerConfigs {
+		if analyzerConfig.isFreeLocal() {
+			analyzerNames = append(analyzerNames, analyzerConfig.Name)
+		}
 	}
-	if unmarshalError := json.Unmarshal(successResp.Data, &status); unmarshalError != nil {
-		return false, unmarshalError
+	return analyzerNames, nil
+}
+
+// isFreeLocal tells whether the analyzer can run without reaching out of the instance nor needing a paid secret.
+func (analyzerConfig *AnalyzerConfig) isFreeLocal() bool {
+	if analyzerConfig.Disabled || analyzerConfig.ExternalService {
+		return false
+	}
+	for _, secret := range analyzerConfig.Secrets {
+		if secret.Required {
+			return false
+		}
+	}
+	return true
+}
+
+// AnalyzerConfigs represents a set of analyzer configurations, e.g. the ones AnalyzerService.GetConfigs lists:
+//
+//	runnable := gothreatmatrix.AnalyzerConfigs(*analyzerConfigs).FilterFor("ip").Names()
+type AnalyzerConfigs []AnalyzerConfig
+
+// FilterFor returns the enabled analyzers ThreatMatrix runs on an observable of the classification (ip, domain,
+// url, hash or generic): the observable analyzers supporting it and, for hashes, the file analyzers with run_hash.
+func (analyzerConfigs AnalyzerConfigs) FilterFor(observableType string) AnalyzerConfigs {
+	if isFileType(observableType) {
+		return AnalyzerConfigs{}
+	}
+	return analyzerConfigs.filter(observableType)
+}
+
+// FilterForFile returns the enabled file analyzers ThreatMatrix runs on a file of the mime type, according to their
+// supported and not supported file types. An empty mime type returns every enabled file analyzer.
+func (analyzerConfigs AnalyzerConfigs) FilterForFile(mimeType string) AnalyzerConfigs {
+	if mimeType == "" {
+		mimeType = "file"
+	}
+	if !isFileType(mimeType) {
+		return AnalyzerConfigs{}
+	}
+	return analyzerConfigs.filter(mimeType)
+}
+
+// filter returns the enabled analyzers supporting the indicator type.
+func (analyzerConfigs AnalyzerConfigs) filter(indicatorType string) AnalyzerConfigs {
+	filtered := AnalyzerConfigs{}
+	for index := range analyzerConfigs {
+		analyzerConfig := &analyzerConfigs[index]
+		if !analyzerConfig.Disabled && analyzerConfig.supportsIndicator(indicatorType) {
+			filtered = append(filtered, *analyzerConfig)
+		}
+	}
+	return filtered
+}
+
+// Names returns the names of the analyzers, ready for BasicAnalysisParams.AnalyzersRequested.
+func (analyzerConfigs AnalyzerConfigs) Names() []string {
+	analyzerNames := make([]string, 0, len(analyzerConfigs))
+	for _, analyzerConfig := range analyzerConfigs {
+		analyzerNames = append(analyzerNames, analyzerConfig.Name)
 	}
-	return status.Status, nil
+	return analyzerNames
 }
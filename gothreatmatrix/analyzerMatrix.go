@@ -0,0 +1,152 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// MATRIX_OBSERVABLE_TYPES are the observable classifications BuildAnalyzerMatrix lists when it is given no indicator types.
+var MATRIX_OBSERVABLE_TYPES = []string{"ip", "url", "domain", "hash", GENERIC_CLASSIFICATION}
+
+// AnalyzerMatrix represents what the analyzers of an instance can analyze, see BuildAnalyzerMatrix.
+type AnalyzerMatrix struct {
+	// IndicatorTypes are the columns of the matrix: observable classifications, "file" and file mime types.
+	IndicatorTypes []string
+	// Rows are sorted by analyzer name.
+	Rows []AnalyzerMatrixRow
+}
+
+// AnalyzerMatrixRow represents the indicator types an analyzer runs for.
+type AnalyzerMatrixRow struct {
+	Analyzer string
+	// MaxTlp maps every indicator type the analyzer runs for to the highest TLP it runs under,
+	// an analyzer running for a TLP also running for the lower ones.
+	MaxTlp map[string]TLP
+}
+
+// BuildAnalyzerMatrix builds the analyzer × indicator type × TLP matrix of the live catalog, so teams can document
+// what their instance can actually analyze. Disabled analyzers, the ones the client's analyzer policy denies and
+// the ones running for none of the indicator types are left out.
+// When indicatorTypes is empty, the columns are MATRIX_OBSERVABLE_TYPES, "file" and the file types of the catalog.
+func (client *ThreatMatrixClient) BuildAnalyzerMatrix(ctx context.Context, indicatorTypes []string) (*AnalyzerMatrix, error) {
+	analyzerConfigs, err := client.AnalyzerService.GetConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(indicatorTypes) == 0 {
+		indicatorTypes = defaultMatrixIndicatorTypes(*analyzerConfigs)
+	}
+	matrix := &AnalyzerMatrix{
+		IndicatorTypes: indicatorTypes,
+		Rows:           []AnalyzerMatrixRow{},
+	}
+	for index := range *analyzerConfigs {
+		analyzerConfig := &(*analyzerConfigs)[index]
+		if analyzerConfig.Disabled || !isAnalyzerAllowed(analyzerConfig.Name, client.options.AnalyzersAllowed, client.options.AnalyzersDenied) {
+			continue
+		}
+		maxTlp := analyzerConfig.maxTlp()
+		row := AnalyzerMatrixRow{Analyzer: analyzerConfig.Name, MaxTlp: map[string]TLP{}}
+		for _, indicatorType := range indicatorTypes {
+			if analyzerConfig.supportsIndicator(indicatorType) {
+				row.MaxTlp[indicatorType] = maxTlp
+			}
+		}
+		if len(row.MaxTlp) > 0 {
+			matrix.Rows = append(matrix.Rows, row)
+		}
+	}
+	sort.Slice(matrix.Rows, func(i, j int) bool {
+		return matrix.Rows[i].Analyzer < matrix.Rows[j].Analyzer
+	})
+	return matrix, nil
+}
+
+// defaultMatrixIndicatorTypes returns MATRIX_OBSERVABLE_TYPES, "file" and the sorted file types the analyzers support.
+func defaultMatrixIndicatorTypes(analyzerConfigs []AnalyzerConfig) []string {
+	fileTypes := map[string]bool{}
+	for _, analyzerConfig := range analyzerConfigs {
+		for _, fileType := range analyzerConfig.SupportedFiletypes {
+			fileTypes[fileType] = true
+		}
+	}
+	sortedFileTypes := make([]string, 0, len(fileTypes))
+	for fileType := range fileTypes {
+		sortedFileTypes = append(sortedFileTypes, fileType)
+	}
+	sort.Strings(sortedFileTypes)
+	indicatorTypes := append([]string{}, MATRIX_OBSERVABLE_TYPES...)
+	indicatorTypes = append(indicatorTypes, "file")
+	return append(indicatorTypes, sortedFileTypes...)
+}
+
+// maxTlp returns the highest TLP the analyzer runs under, see CoverageConstraints.
+func (analyzerConfig *AnalyzerConfig) maxTlp() TLP {
+	for _, tlp := range []TLP{RED, AMBER, GREEN} {
+		if analyzerConfig.meetsConstraints(&CoverageConstraints{Tlp: tlp}) {
+			return tlp
+		}
+	}
+	return WHITE
+}
+
+// cells returns the row of the table of the matrix: the analyzer, then the highest TLP of every indicator type,
+// empty when the analyzer does not run for it.
+func (matrix *AnalyzerMatrix) cells(row *AnalyzerMatrixRow) []string {
+	cells := []string{row.Analyzer}
+	for _, indicatorType := range matrix.IndicatorTypes {
+		cell := ""
+		if tlp, ok := row.MaxTlp[indicatorType]; ok {
+			cell = tlp.String()
+		}
+		cells = append(cells, cell)
+	}
+	return cells
+}
+
+// WriteCSV writes the matrix as CSV, with a header row.
+func (matrix *AnalyzerMatrix) WriteCSV(writer io.Writer) error {
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write(append([]string{"analyzer"}, matrix.IndicatorTypes...)); err != nil {
+		return err
+	}
+	for index := range matrix.Rows {
+		if err := csvWriter.Write(matrix.cells(&matrix.Rows[index])); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// WriteMarkdown writes the matrix as a Markdown table.
+func (matrix *AnalyzerMatrix) WriteMarkdown(writer io.Writer) error {
+	writeRow := func(cells []string) error {
+		escaped := make([]string, len(cells))
+		for index, cell := range cells {
+			escaped[index] = strings.ReplaceAll(cell, "|", `\|`)
+		}
+		_, err := fmt.Fprintf(writer, "| %s |\n", strings.Join(escaped, " | "))
+		return err
+	}
+	if err := writeRow(append([]string{"Analyzer"}, matrix.IndicatorTypes...)); err != nil {
+		return err
+	}
+	separator := make([]string, len(matrix.IndicatorTypes)+1)
+	for index := range separator {
+		separator[index] = "---"
+	}
+	if err := writeRow(separator); err != nil {
+		return err
+	}
+	for index := range matrix.Rows {
+		if err := writeRow(matrix.cells(&matrix.Rows[index])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
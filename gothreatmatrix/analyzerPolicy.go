@@ -0,0 +1,108 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoAnalyzerAllowed is returned when an analyzer allowlist/denylist leaves nothing to run.
+var ErrNoAnalyzerAllowed = errors.New("every analyzer of the analysis is excluded by the client's analyzer policy")
+
+// isAnalyzerAllowed reports whether the allowlist/denylist lets the analyzer run, an empty allowlist allowing every analyzer.
+func isAnalyzerAllowed(analyzerName string, analyzersAllowed []string, analyzersDenied []string) bool {
+	for _, denied := range analyzersDenied {
+		if denied == analyzerName {
+			return false
+		}
+	}
+	if len(analyzersAllowed) == 0 {
+		return true
+	}
+	for _, allowed := range analyzersAllowed {
+		if allowed == analyzerName {
+			return true
+		}
+	}
+	return false
+}
+
+// enforceAnalyzerPolicy applies the client's analyzer allowlist/denylist to the analysis, see applyAnalyzerPolicy,
+// then its TLPEnforcement, see applyTLPEnforcement.
+func (client *ThreatMatrixClient) enforceAnalyzerPolicy(ctx context.Context, basicAnalysisParams *BasicAnalysisParams) error {
+	if err := client.applyAnalyzerPolicy(ctx, basicAnalysisParams, client.options.AnalyzersAllowed, client.options.AnalyzersDenied); err != nil {
+		return err
+	}
+	return client.applyTLPEnforcement(ctx, basicAnalysisParams)
+}
+
+// applyAnalyzerPolicy applies an analyzer allowlist/denylist to the analysis.
+//
+// Excluded analyzers are removed from AnalyzersRequested and from the RuntimeConfiguration.
+// When the analysis lets the server pick the analyzers (none requested, e.g. with a playbook),
+// the analyzer catalog is fetched and the analyzers allowed by the policy are requested explicitly instead,
+// so an excluded analyzer can never run through this client.
+func (client *ThreatMatrixClient) applyAnalyzerPolicy(ctx context.Context, basicAnalysisParams *BasicAnalysisParams, analyzersAllowed []string, analyzersDenied []string) error {
+	if len(analyzersAllowed) == 0 && len(analyzersDenied) == 0 {
+		return nil
+	}
+	requested, err := client.requestedAnalyzers(ctx, basicAnalysisParams)
+	if err != nil {
+		return err
+	}
+	allowedAnalyzers := []string{}
+	excludedAnalyzers := map[string]bool{}
+	for _, analyzerName := range analyzersDenied {
+		excludedAnalyzers[analyzerName] = true
+	}
+	for _, analyzerName := range requested {
+		if isAnalyzerAllowed(analyzerName, analyzersAllowed, analyzersDenied) {
+			allowedAnalyzers = append(allowedAnalyzers, analyzerName)
+		} else {
+			excludedAnalyzers[analyzerName] = true
+		}
+	}
+	if len(allowedAnalyzers) == 0 {
+		return ErrNoAnalyzerAllowed
+	}
+	basicAnalysisParams.AnalyzersRequested = allowedAnalyzers
+	basicAnalysisParams.RuntimeConfiguration = filterRuntimeConfiguration(basicAnalysisParams.RuntimeConfiguration, excludedAnalyzers)
+	return nil
+}
+
+// requestedAnalyzers returns the analyzers requested by the analysis, every enabled analyzer of the catalog
+// when it lets the server pick them.
+func (client *ThreatMatrixClient) requestedAnalyzers(ctx context.Context, basicAnalysisParams *BasicAnalysisParams) ([]string, error) {
+	if len(basicAnalysisParams.AnalyzersRequested) > 0 {
+		return basicAnalysisParams.AnalyzersRequested, nil
+	}
+	analyzerConfigs, err := client.AnalyzerService.GetConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	requested := []string{}
+	for _, analyzerConfig := range *analyzerConfigs {
+		if !analyzerConfig.Disabled {
+			requested = append(requested, analyzerConfig.Name)
+		}
+	}
+	return requested, nil
+}
+
+// filterRuntimeConfiguration returns a copy of the runtime configuration without the excluded analyzers,
+// whether they are configured at the top level or under the "analyzers" section.
+func filterRuntimeConfiguration(runtimeConfiguration map[string]interface{}, excludedAnalyzers map[string]bool) map[string]interface{} {
+	if runtimeConfiguration == nil {
+		return nil
+	}
+	filtered := make(map[string]interface{}, len(runtimeConfiguration))
+	for key, value := range runtimeConfiguration {
+		if excludedAnalyzers[key] {
+			continue
+		}
+		if section, ok := value.(map[string]interface{}); ok && key == "analyzers" {
+			value = filterRuntimeConfiguration(section, excludedAnalyzers)
+		}
+		filtered[key] = value
+	}
+	return filtered
+}
@@ -0,0 +1,114 @@
+package gothreatmatrix
+
+// Typed reports of popular analyzers, registered in the DefaultReportSchemaRegistry. Only the commonly used
+// fields are typed: decode the report with Report.DecodeInto into a schema of your own for the others.
+
+// VirusTotalAnalysisStats represents how many VirusTotal engines gave each verdict.
+type VirusTotalAnalysisStats struct {
+	Harmless   int `json:"harmless"`
+	Malicious  int `json:"malicious"`
+	Suspicious int `json:"suspicious"`
+	Undetected int `json:"undetected"`
+	Timeout    int `json:"timeout"`
+}
+
+// VirusTotalReport represents the report of the VirusTotal_v3 analyzers, the VirusTotal object of the observable or file.
+type VirusTotalReport struct {
+	Data struct {
+		ID         string `json:"id"`
+		Type       string `json:"type"`
+		Attributes struct {
+			LastAnalysisStats VirusTotalAnalysisStats `json:"last_analysis_stats"`
+			// LastAnalysisDate is a Unix timestamp.
+			LastAnalysisDate int64    `json:"last_analysis_date"`
+			Reputation       int      `json:"reputation"`
+			Tags             []string `json:"tags"`
+			// MeaningfulName, Md5 and Sha256 are only reported for files.
+			MeaningfulName string `json:"meaningful_name"`
+			Md5            string `json:"md5"`
+			Sha256         string `json:"sha256"`
+		} `json:"attributes"`
+	} `json:"data"`
+	Link string `json:"link"`
+}
+
+// AbuseIPDBReport represents the report of the AbuseIPDB analyzer.
+type AbuseIPDBReport struct {
+	Data struct {
+		IpAddress            string `json:"ipAddress"`
+		IsPublic             bool   `json:"isPublic"`
+		IsWhitelisted        bool   `json:"isWhitelisted"`
+		AbuseConfidenceScore int    `json:"abuseConfidenceScore"`
+		CountryCode          string `json:"countryCode"`
+		UsageType            string `json:"usageType"`
+		Isp                  string `json:"isp"`
+		Domain               string `json:"domain"`
+		TotalReports         int    `json:"totalReports"`
+		NumDistinctUsers     int    `json:"numDistinctUsers"`
+		LastReportedAt       string `json:"lastReportedAt"`
+	} `json:"data"`
+	Permalink string `json:"permalink"`
+}
+
+// ShodanReport represents the report of the Shodan_Search analyzer, the Shodan host of the IP.
+type ShodanReport struct {
+	IpStr       string   `json:"ip_str"`
+	Org         string   `json:"org"`
+	Isp         string   `json:"isp"`
+	Asn         string   `json:"asn"`
+	CountryCode string   `json:"country_code"`
+	Hostnames   []string `json:"hostnames"`
+	Ports       []int    `json:"ports"`
+	Tags        []string `json:"tags"`
+	Vulns       []string `json:"vulns"`
+	LastUpdate  string   `json:"last_update"`
+}
+
+// OTXPulse represents an AlienVault OTX pulse the observable is part of.
+type OTXPulse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Tags       []string `json:"tags"`
+	References []string `json:"references"`
+}
+
+// OTXReport represents the report of the OTXQuery analyzer.
+type OTXReport struct {
+	Pulses         []OTXPulse               `json:"pulses"`
+	MalwareSamples []string                 `json:"malware_samples"`
+	PassiveDns     []map[string]interface{} `json:"passive_dns"`
+	Geo            map[string]interface{}   `json:"geo"`
+}
+
+// GreyNoiseReport represents the report of the GreyNoise analyzers, see also ExtractNoiseClassification.
+type GreyNoiseReport struct {
+	Ip             string `json:"ip"`
+	Noise          bool   `json:"noise"`
+	Riot           bool   `json:"riot"`
+	Classification string `json:"classification"`
+	Name           string `json:"name"`
+	Link           string `json:"link"`
+	LastSeen       string `json:"last_seen"`
+	Message        string `json:"message"`
+}
+
+// URLhausReport represents the report of the URLhaus analyzer.
+type URLhausReport struct {
+	QueryStatus      string   `json:"query_status"`
+	UrlStatus        string   `json:"url_status"`
+	Threat           string   `json:"threat"`
+	Tags             []string `json:"tags"`
+	UrlhausReference string   `json:"urlhaus_reference"`
+}
+
+// builtinReportSchemas are the typed reports the DefaultReportSchemaRegistry starts with, by analyzer name.
+var builtinReportSchemas = map[string]interface{}{
+	"VirusTotal_v3_Get_Observable": VirusTotalReport{},
+	"VirusTotal_v3_Get_File":       VirusTotalReport{},
+	"AbuseIPDB":                    AbuseIPDBReport{},
+	"Shodan_Search":                ShodanReport{},
+	"OTXQuery":                     OTXReport{},
+	"GreyNoise":                    GreyNoiseReport{},
+	"GreyNoiseCommunity":           GreyNoiseReport{},
+	"URLhaus":                      URLhausReport{},
+}
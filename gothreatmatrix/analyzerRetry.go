@@ -0,0 +1,164 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// AnalyzerRetry represents the outcome of RetryAnalyzerOrRescan.
+type AnalyzerRetry struct {
+	JobID        uint64
+	AnalyzerName string
+	// Emulated is true when the server could not retry the analyzer, the analyzer then ran in RescanJobID.
+	Emulated    bool
+	RescanJobID uint64
+}
+
+// isRetryUnsupported reports whether the error means the server has no granular retry endpoint.
+func isRetryUnsupported(err error) bool {
+	var threatMatrixError *ThreatMatrixError
+	if !errors.As(err, &threatMatrixError) {
+		return false
+	}
+	switch threatMatrixError.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// RetryAnalyzerOrRescan re-runs a single analyzer of a job.
+// When the server supports it the analyzer is retried within the job, see RetryAnalyzer; otherwise the observable
+// or sample of the job is analyzed again with only this analyzer, with the TLP and tags of the original job,
+// and the new job is linked to the original one through the returned AnalyzerRetry.
+func (jobService *JobService) RetryAnalyzerOrRescan(ctx context.Context, jobId uint64, analyzerName string) (*AnalyzerRetry, error) {
+	retry := &AnalyzerRetry{
+		JobID:        jobId,
+		AnalyzerName: analyzerName,
+	}
+	_, err := jobService.RetryAnalyzer(ctx, jobId, analyzerName)
+	if err == nil {
+		return retry, nil
+	}
+	if !isRetryUnsupported(err) {
+		return nil, err
+	}
+	// * a 404 can also mean the job does not exist, fetching it tells the two apart
+	job, err := jobService.Get(ctx, jobId)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(job.Tags))
+	for _, tag := range job.Tags {
+		tags = append(tags, tag.Label)
+	}
+	basicAnalysisParams := BasicAnalysisParams{
+		Tlp:                ParseTLP(job.Tlp),
+		AnalyzersRequested: []string{analyzerName},
+		TagsLabels:         tags,
+		ForceFreshScan:     true,
+	}
+	var analysisResponse *AnalysisResponse
+	if job.IsSample {
+		analysisResponse, err = jobService.rescanSample(ctx, job, basicAnalysisParams)
+	} else {
+		analysisResponse, err = jobService.client.CreateObservableAnalysis(ctx, &ObservableAnalysisParams{
+			BasicAnalysisParams:      basicAnalysisParams,
+			ObservableName:           job.ObservableName,
+			ObservableClassification: job.ObservableClassification,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	retry.Emulated = true
+	retry.RescanJobID = uint64(analysisResponse.JobID)
+	return retry, nil
+}
+
+// rescanSample downloads the sample of the job and submits it again.
+func (jobService *JobService) rescanSample(ctx context.Context, job *Job, basicAnalysisParams BasicAnalysisParams) (*AnalysisResponse, error) {
+	sample, err := jobService.DownloadSample(ctx, uint64(job.ID))
+	if err != nil {
+		return nil, err
+	}
+	directory, err := os.MkdirTemp("", "threatmatrix-rescan")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(directory)
+	fileName := sanitizeFileName(filepath.Base(job.FileName))
+	if fileName == "" || fileName == "." {
+		fileName = "sample"
+	}
+	filePath := filepath.Join(directory, fileName)
+	if err := os.WriteFile(filePath, sample, 0600); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return jobService.client.CreateFileAnalysis(ctx, &FileAnalysisParams{
+		BasicAnalysisParams: basicAnalysisParams,
+		File:                file,
+	})
+}
+
+// JobRetry represents the plugins Retry asked to run again.
+type JobRetry struct {
+	JobID      uint64
+	Analyzers  []string
+	Connectors []string
+}
+
+// isReportRetryable reports whether the analyzer or connector report ended without a result.
+func isReportRetryable(report *Report) bool {
+	switch report.Status {
+	case "FAILED", "KILLED", "failed", "killed":
+		return true
+	}
+	return false
+}
+
+// Retry re-runs every analyzer and connector of the job that failed or was killed,
+// through RetryAnalyzer and RetryConnector. It stops at the first plugin that could not be retried,
+// the returned JobRetry then lists the plugins retried so far.
+//
+//	Endpoint: GET /api/jobs/{jobID}
+//	Endpoint: PATCH /api/jobs/{jobID}/analyzer/{nameOfAnalyzer}/retry
+//	Endpoint: PATCH /api/jobs/{jobID}/connector/{nameOfConnector}/retry
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs
+func (jobService *JobService) Retry(ctx context.Context, jobId uint64) (*JobRetry, error) {
+	job, err := jobService.Get(ctx, jobId)
+	if err != nil {
+		return nil, err
+	}
+	retry := &JobRetry{
+		JobID:      jobId,
+		Analyzers:  []string{},
+		Connectors: []string{},
+	}
+	for index := range job.AnalyzerReports {
+		if report := &job.AnalyzerReports[index]; isReportRetryable(report) {
+			if _, err := jobService.RetryAnalyzer(ctx, jobId, report.Name); err != nil {
+				return retry, err
+			}
+			retry.Analyzers = append(retry.Analyzers, report.Name)
+		}
+	}
+	for index := range job.ConnectorReports {
+		if report := &job.ConnectorReports[index]; isReportRetryable(report) {
+			if _, err := jobService.RetryConnector(ctx, jobId, report.Name); err != nil {
+				return retry, err
+			}
+			retry.Connectors = append(retry.Connectors, report.Name)
+		}
+	}
+	return retry, nil
+}
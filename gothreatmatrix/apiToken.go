@@ -0,0 +1,99 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// APIToken represents an API token of the user, one of the durin sessions of the ThreatMatrix instance.
+type APIToken struct {
+	ID int `json:"id"`
+	// Token is the key to authenticate with. It is only told when the token is created.
+	Token string `json:"token,omitempty"`
+	// Client is the name of the durin client the token was issued for.
+	Client     string     `json:"client"`
+	Created    *time.Time `json:"created"`
+	Expiry     *time.Time `json:"expiry"`
+	HasExpired bool       `json:"has_expired"`
+	// IsCurrent tells the token the request listing the tokens authenticated with.
+	IsCurrent bool `json:"is_current"`
+}
+
+// CreateAPIToken creates the API access token of the user, e.g. to rotate the credentials of a provisioning tool
+// after revoking the former token. The instance refuses to create a token while the user already has one.
+//
+//	Endpoint: POST /api/auth/apiaccess
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/auth/operation/auth_apiaccess_create
+func (userService *UserService) CreateAPIToken(ctx context.Context) (*APIToken, error) {
+	requestUrl := userService.client.options.Url + constants.AUTH_API_ACCESS_URL
+	contentType := "application/json"
+	method := "POST"
+	request, err := userService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	apiToken := APIToken{}
+	successResp, err := userService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalError := json.Unmarshal(successResp.Data, &apiToken); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &apiToken, nil
+}
+
+// ListTokens lists the tokens of the user, the API access token and the ones of the logged in sessions, without
+// their keys.
+//
+//	Endpoint: GET /api/auth/sessions
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/auth/operation/auth_sessions_list
+func (userService *UserService) ListTokens(ctx context.Context) ([]APIToken, error) {
+	requestUrl := userService.client.options.Url + constants.AUTH_SESSIONS_URL
+	contentType := "application/json"
+	method := "GET"
+	request, err := userService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	apiTokens := []APIToken{}
+	successResp, err := userService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalError := json.Unmarshal(successResp.Data, &apiTokens); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return apiTokens, nil
+}
+
+// RevokeToken revokes a token of the user through its ID, as told by ListTokens. Revoking the token the client
+// authenticates with makes its next requests fail.
+//
+//	Endpoint: DELETE /api/auth/sessions/{id}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/auth/operation/auth_sessions_destroy
+func (userService *UserService) RevokeToken(ctx context.Context, tokenId int) (bool, error) {
+	requestUrl := userService.client.options.Url + fmt.Sprintf(constants.SPECIFIC_AUTH_SESSION_URL, tokenId)
+	contentType := "application/json"
+	method := "DELETE"
+	request, err := userService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return false, err
+	}
+	successResp, err := userService.client.newRequest(ctx, request)
+	if err != nil {
+		return false, err
+	}
+	if successResp.StatusCode == http.StatusNoContent {
+		return true, nil
+	}
+	return false, nil
+}
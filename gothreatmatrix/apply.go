@@ -0,0 +1,257 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+)
+
+// Values of the PlannedChange.Action field.
+const (
+	APPLY_CREATE = "create"
+	APPLY_UPDATE = "update"
+)
+
+// Values of the PlannedChange.Resource field.
+const (
+	TAG_RESOURCE      = "tag"
+	PLAYBOOK_RESOURCE = "playbook"
+)
+
+// DesiredState represents the tags and playbooks a ThreatMatrix instance should have, see PlanApply.
+// Tags are matched by label and playbooks by name; what is on the instance but not in the state is left alone.
+//
+// States are stored as YAML, e.g.
+//
+//	tags:
+//	  - label: phishing
+//	    color: "#ff0000"
+//	playbooks:
+//	  - name: DNS
+//	    description: resolve the domains
+//	    analyzers: {Classic_DNS: {}}
+//	    connectors: {}
+//	    supports: [domain]
+type DesiredState struct {
+	Tags      []TagParams      `json:"tags,omitempty"`
+	Playbooks []PlaybookParams `json:"playbooks,omitempty"`
+}
+
+// LoadDesiredState reads the state stored at path, as YAML or JSON.
+func LoadDesiredState(path string) (*DesiredState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := DesiredState{}
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("{")) {
+		err = json.Unmarshal(data, &state)
+	} else {
+		err = unmarshalYaml(data, &state)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// FieldChange represents the change of one field of a resource, Before is nil for a created resource.
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after"`
+}
+
+// PlannedChange represents what applying a DesiredState does to one resource.
+type PlannedChange struct {
+	// Action is APPLY_CREATE or APPLY_UPDATE, Resource TAG_RESOURCE or PLAYBOOK_RESOURCE.
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	// ID is the ID of the updated tag.
+	ID     uint64        `json:"id,omitempty"`
+	Fields []FieldChange `json:"fields"`
+
+	tag      *TagParams
+	playbook *PlaybookParams
+}
+
+// ApplyPlan represents the changes PlanApply found, in the order Apply makes them.
+// It marshals to JSON as a machine-readable plan, and WriteDiff renders it for humans.
+type ApplyPlan struct {
+	Changes []PlannedChange `json:"changes"`
+}
+
+// PlanApply compares the state with the instance and returns the changes applying it would make.
+// Nothing is written to the instance, so it is the dry run of Apply.
+//
+//	Endpoint: GET /api/tags
+//	Endpoint: GET /api/get_playbook_configs
+func (client *ThreatMatrixClient) PlanApply(ctx context.Context, state *DesiredState) (*ApplyPlan, error) {
+	plan := &ApplyPlan{Changes: []PlannedChange{}}
+	if len(state.Tags) > 0 {
+		tags, err := client.TagService.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		existingTags := map[string]Tag{}
+		for _, tag := range *tags {
+			existingTags[tag.Label] = tag
+		}
+		for index := range state.Tags {
+			if change := planTag(&state.Tags[index], existingTags); change != nil {
+				plan.Changes = append(plan.Changes, *change)
+			}
+		}
+	}
+	if len(state.Playbooks) > 0 {
+		// * compared with the instance itself, never with a catalog snapshot
+		playbookConfigs, err := client.PlaybookService.fetchConfigs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for index := range state.Playbooks {
+			if change := planPlaybook(&state.Playbooks[index], playbookConfigs); change != nil {
+				plan.Changes = append(plan.Changes, *change)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// planTag returns the change making the tag as desired, nil when it already is.
+func planTag(desired *TagParams, existingTags map[string]Tag) *PlannedChange {
+	existing, ok := existingTags[desired.Label]
+	if !ok {
+		return &PlannedChange{
+			Action:   APPLY_CREATE,
+			Resource: TAG_RESOURCE,
+			Name:     desired.Label,
+			Fields:   []FieldChange{{Field: "color", After: desired.Color}},
+			tag:      desired,
+		}
+	}
+	if existing.Color == desired.Color {
+		return nil
+	}
+	return &PlannedChange{
+		Action:   APPLY_UPDATE,
+		Resource: TAG_RESOURCE,
+		Name:     desired.Label,
+		ID:       existing.ID,
+		Fields:   []FieldChange{{Field: "color", Before: existing.Color, After: desired.Color}},
+		tag:      desired,
+	}
+}
+
+// planPlaybook returns the change making the playbook as desired, nil when it already is.
+func planPlaybook(desired *PlaybookParams, playbookConfigs map[string]PlaybookConfig) *PlannedChange {
+	fields := []FieldChange{}
+	existing, ok := playbookConfigs[desired.Name]
+	compare := func(field string, before interface{}, after interface{}) {
+		if !ok {
+			fields = append(fields, FieldChange{Field: field, After: after})
+		} else if !sameValue(before, after) {
+			fields = append(fields, FieldChange{Field: field, Before: before, After: after})
+		}
+	}
+	compare("description", existing.Description, desired.Description)
+	compare("analyzers", existing.Analyzers, desired.Analyzers)
+	compare("connectors", existing.Connectors, desired.Connectors)
+	compare("supports", existing.Supports, desired.Supports)
+	if ok && len(fields) == 0 {
+		return nil
+	}
+	action := APPLY_UPDATE
+	if !ok {
+		action = APPLY_CREATE
+	}
+	return &PlannedChange{
+		Action:   action,
+		Resource: PLAYBOOK_RESOURCE,
+		Name:     desired.Name,
+		Fields:   fields,
+		playbook: desired,
+	}
+}
+
+// sameValue reports whether the two values are equal, empty maps and slices being equal to nil ones.
+func sameValue(before interface{}, after interface{}) bool {
+	beforeValue, afterValue := reflect.ValueOf(before), reflect.ValueOf(after)
+	if (beforeValue.Kind() == reflect.Map || beforeValue.Kind() == reflect.Slice) && beforeValue.Len() == 0 &&
+		(afterValue.Kind() == reflect.Map || afterValue.Kind() == reflect.Slice) && afterValue.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(before, after)
+}
+
+// Apply makes the changes of the plan, in order, and stops at the first failing one.
+// A plan is only meant to be applied right after PlanApply returned it.
+//
+//	Endpoint: POST /api/tags
+//	Endpoint: PUT /api/tags/{id}
+//	Endpoint: POST /api/playbook
+//	Endpoint: PATCH /api/playbook/{name}
+func (client *ThreatMatrixClient) Apply(ctx context.Context, plan *ApplyPlan) error {
+	for index := range plan.Changes {
+		change := &plan.Changes[index]
+		var err error
+		switch {
+		case change.tag != nil && change.Action == APPLY_CREATE:
+			_, err = client.TagService.Create(ctx, change.tag)
+		case change.tag != nil:
+			_, err = client.TagService.Update(ctx, change.ID, change.tag)
+		case change.playbook != nil && change.Action == APPLY_CREATE:
+			_, err = client.PlaybookService.CreatePlaybook(ctx, change.playbook)
+		case change.playbook != nil:
+			_, err = client.PlaybookService.UpdatePlaybook(ctx, change.Name, change.playbook)
+		default:
+			err = fmt.Errorf("the %s %s change was not planned by PlanApply", change.Resource, change.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("could not %s the %s %s: %w", change.Action, change.Resource, change.Name, err)
+		}
+	}
+	return nil
+}
+
+// WriteDiff writes a human-readable diff of the plan: "+" for what is created, "~" for what is updated.
+func (plan *ApplyPlan) WriteDiff(writer io.Writer) error {
+	if len(plan.Changes) == 0 {
+		_, err := fmt.Fprintln(writer, "No changes.")
+		return err
+	}
+	buffer := &bytes.Buffer{}
+	counts := map[string]int{}
+	for _, change := range plan.Changes {
+		counts[change.Action]++
+		if change.Action == APPLY_CREATE {
+			fmt.Fprintf(buffer, "+ %s %s\n", change.Resource, change.Name)
+		} else {
+			fmt.Fprintf(buffer, "~ %s %s\n", change.Resource, change.Name)
+		}
+		for _, field := range change.Fields {
+			if change.Action == APPLY_CREATE {
+				fmt.Fprintf(buffer, "    + %s: %s\n", field.Field, diffValue(field.After))
+			} else {
+				fmt.Fprintf(buffer, "    ~ %s: %s -> %s\n", field.Field, diffValue(field.Before), diffValue(field.After))
+			}
+		}
+	}
+	fmt.Fprintf(buffer, "\n%d to create, %d to update.\n", counts[APPLY_CREATE], counts[APPLY_UPDATE])
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
+
+// diffValue formats the value of a field for the diff, as compact JSON.
+func diffValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
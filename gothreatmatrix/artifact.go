@@ -0,0 +1,239 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Artifact represents an indicator found in the reports of a job.
+type Artifact struct {
+	Value string `json:"value"`
+	// Classification is the observable classification of the value: ip, url, domain or hash.
+	Classification string `json:"classification"`
+	// Sources are the names of the analyzers whose report contained the value.
+	Sources []string `json:"sources"`
+}
+
+var (
+	artifactUrlRegex    = regexp.MustCompile(`^(?i)(?:https?|ftp)://[^\s"'<>]+$`)
+	artifactDomainRegex = regexp.MustCompile(`^(?i)(?:[a-z0-9](?:[a-z0-9\-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+	artifactHashRegex   = regexp.MustCompile(`^(?i)(?:[a-f0-9]{32}|[a-f0-9]{40}|[a-f0-9]{64})$`)
+)
+
+// ClassifyObservable returns the observable classification (ip, url, domain or hash) of the value,
+// or an empty string when it is none of them.
+func ClassifyObservable(value string) string {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return ""
+	case net.ParseIP(value) != nil:
+		return "ip"
+	case artifactUrlRegex.MatchString(value):
+		return "url"
+	case artifactHashRegex.MatchString(value):
+		return "hash"
+	case artifactDomainRegex.MatchString(value):
+		return "domain"
+	}
+	return ""
+}
+
+// ExtractArtifacts walks the analyzer reports of the job and returns every indicator they contain,
+// sorted by value. The observable of the job itself is left out.
+func ExtractArtifacts(job *Job) []Artifact {
+	artifacts := map[string]*Artifact{}
+	for index := range job.AnalyzerReports {
+		report := &job.AnalyzerReports[index]
+		collectArtifacts(report.Report, report.Name, artifacts)
+	}
+	delete(artifacts, strings.ToLower(job.ObservableName))
+	values := make([]string, 0, len(artifacts))
+	for value := range artifacts {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	artifactList := make([]Artifact, 0, len(values))
+	for _, value := range values {
+		artifactList = append(artifactList, *artifacts[value])
+	}
+	return artifactList
+}
+
+func collectArtifacts(value interface{}, source string, artifacts map[string]*Artifact) {
+	switch typedValue := value.(type) {
+	case string:
+		classification := ClassifyObservable(typedValue)
+		if classification == "" {
+			return
+		}
+		trimmed := strings.TrimSpace(typedValue)
+		key := strings.ToLower(trimmed)
+		artifact, ok := artifacts[key]
+		if !ok {
+			artifact = &Artifact{Value: trimmed, Classification: classification}
+			artifacts[key] = artifact
+		}
+		for _, existingSource := range artifact.Sources {
+			if existingSource == source {
+				return
+			}
+		}
+		artifact.Sources = append(artifact.Sources, source)
+	case map[string]interface{}:
+		for _, fieldValue := range typedValue {
+			collectArtifacts(fieldValue, source, artifacts)
+		}
+	case []interface{}:
+		for _, item := range typedValue {
+			collectArtifacts(item, source, artifacts)
+		}
+	}
+}
+
+// Values of the Indicator.Type field.
+const (
+	INDICATOR_IP     = "ip"
+	INDICATOR_DOMAIN = "domain"
+	INDICATOR_URL    = "url"
+	INDICATOR_HASH   = "hash"
+	INDICATOR_CVE    = "cve"
+)
+
+// Indicator represents a normalized IOC found in the analyzer reports of a job, see ExtractIndicators.
+type Indicator struct {
+	// Value is normalized: IPs in their canonical form, domains, hashes and CVE IDs in one letter case.
+	Value string `json:"value"`
+	// Type is INDICATOR_IP, INDICATOR_DOMAIN, INDICATOR_URL, INDICATOR_HASH or INDICATOR_CVE.
+	Type string `json:"type"`
+	// Sources are the names of the analyzers whose report contained the indicator.
+	Sources []string `json:"sources"`
+}
+
+var (
+	indicatorCveRegex = regexp.MustCompile(`(?i)\bCVE-\d{4}-\d{4,}\b`)
+	// * the IOCs embedded in free text, e.g. a description or a log line; domains are only taken from whole values,
+	// file names and dotted identifiers looking like them
+	indicatorTextRegexes = []struct {
+		indicatorType string
+		regex         *regexp.Regexp
+	}{
+		{INDICATOR_URL, regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s"'<>]+`)},
+		{INDICATOR_IP, regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)},
+		{INDICATOR_HASH, regexp.MustCompile(`(?i)\b(?:[a-f0-9]{64}|[a-f0-9]{40}|[a-f0-9]{32})\b`)},
+		{INDICATOR_CVE, indicatorCveRegex},
+	}
+)
+
+// indicatorFileExtensions are the extensions of the file names the reports list, which are no top-level domains.
+var indicatorFileExtensions = map[string]bool{
+	"bat": true, "bin": true, "dat": true, "dll": true, "doc": true, "docx": true, "exe": true, "gif": true,
+	"htm": true, "html": true, "jpg": true, "js": true, "json": true, "log": true, "pdf": true, "php": true,
+	"png": true, "ps1": true, "py": true, "sh": true, "sys": true, "tmp": true, "txt": true, "vbs": true,
+	"xls": true, "xlsx": true,
+}
+
+// ExtractIndicators walks the analyzer reports of the job, whole values and free text alike, and returns the
+// IOCs they contain (IPs, domains, URLs, hashes and CVE IDs) normalized and deduplicated, sorted by type then value.
+// The observable of the job itself is left out.
+func ExtractIndicators(job *Job) []Indicator {
+	indicators := map[string]*Indicator{}
+	for index := range job.AnalyzerReports {
+		report := &job.AnalyzerReports[index]
+		collectIndicators(report.Report, report.Name, indicators)
+	}
+	if observableType, observable := normalizeIndicator(job.ObservableName); observableType != "" {
+		delete(indicators, observableType+" "+observable)
+	}
+	keys := make([]string, 0, len(indicators))
+	for key := range indicators {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	indicatorList := make([]Indicator, 0, len(keys))
+	for _, key := range keys {
+		indicatorList = append(indicatorList, *indicators[key])
+	}
+	return indicatorList
+}
+
+// ExtractIndicators fetches the job and returns the IOCs of its analyzer reports, see ExtractIndicators.
+//
+//	Endpoint: GET /api/jobs/{id}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_retrieve
+func (jobService *JobService) ExtractIndicators(ctx context.Context, jobId uint64) ([]Indicator, error) {
+	job, err := jobService.Get(ctx, jobId)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractIndicators(job), nil
+}
+
+func collectIndicators(value interface{}, source string, indicators map[string]*Indicator) {
+	switch typedValue := value.(type) {
+	case string:
+		if indicatorType, normalized := normalizeIndicator(typedValue); indicatorType != "" {
+			addIndicator(indicatorType, normalized, source, indicators)
+			return
+		}
+		for _, textRegex := range indicatorTextRegexes {
+			for _, match := range textRegex.regex.FindAllString(typedValue, -1) {
+				if indicatorType, normalized := normalizeIndicator(match); indicatorType == textRegex.indicatorType {
+					addIndicator(indicatorType, normalized, source, indicators)
+				}
+			}
+		}
+	case map[string]interface{}:
+		for _, fieldValue := range typedValue {
+			collectIndicators(fieldValue, source, indicators)
+		}
+	case []interface{}:
+		for _, item := range typedValue {
+			collectIndicators(item, source, indicators)
+		}
+	}
+}
+
+// addIndicator records that the report of the source contained the indicator.
+func addIndicator(indicatorType string, value string, source string, indicators map[string]*Indicator) {
+	key := indicatorType + " " + value
+	indicator, ok := indicators[key]
+	if !ok {
+		indicator = &Indicator{Value: value, Type: indicatorType}
+		indicators[key] = indicator
+	}
+	for _, existingSource := range indicator.Sources {
+		if existingSource == source {
+			return
+		}
+	}
+	indicator.Sources = append(indicator.Sources, source)
+}
+
+// normalizeIndicator returns the type and the normalized form of the value, an empty type when it is no indicator.
+// The punctuation of the surrounding text is trimmed from URLs.
+func normalizeIndicator(value string) (string, string) {
+	value = strings.TrimSpace(value)
+	if indicatorCveRegex.FindString(value) == value && value != "" {
+		return INDICATOR_CVE, strings.ToUpper(value)
+	}
+	switch ClassifyObservable(value) {
+	case "ip":
+		return INDICATOR_IP, net.ParseIP(value).String()
+	case "url":
+		return INDICATOR_URL, strings.TrimRight(value, ".,;:)]}")
+	case "hash":
+		return INDICATOR_HASH, strings.ToLower(value)
+	case "domain":
+		domain := strings.ToLower(value)
+		if indicatorFileExtensions[domain[strings.LastIndex(domain, ".")+1:]] {
+			return "", ""
+		}
+		return INDICATOR_DOMAIN, domain
+	}
+	return "", ""
+}
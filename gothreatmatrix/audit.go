@@ -0,0 +1,264 @@
+package gothreatmatrix
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrAuditChainBroken is returned when an audit trail was altered: a record was modified, removed, or reordered.
+var ErrAuditChainBroken = errors.New("audit trail hash chain is broken")
+
+// AuditRecord represents a submission made through the client.
+//
+// Every record carries the hash of the previous one, so altering any record of the trail breaks the chain,
+// see VerifyAuditRecords.
+type AuditRecord struct {
+	Sequence uint64    `json:"sequence"`
+	Time     time.Time `json:"time"`
+	// Actor is the name given to NewAuditTrail, TokenFingerprint identifies the API key without disclosing it.
+	Actor               string   `json:"actor"`
+	TokenFingerprint    string   `json:"token_fingerprint"`
+	Endpoint            string   `json:"endpoint"`
+	Observables         []string `json:"observables,omitempty"`
+	Files               []string `json:"files,omitempty"`
+	AnalyzersRequested  []string `json:"analyzers_requested"`
+	ConnectorsRequested []string `json:"connectors_requested"`
+	PlaybookRequested   string   `json:"playbook_requested,omitempty"`
+	Tlp                 TLP      `json:"tlp"`
+	// Metadata is the CallMetadata of the context of the submission, if any.
+	Metadata     *CallMetadata `json:"metadata,omitempty"`
+	PreviousHash string        `json:"previous_hash"`
+	Hash         string        `json:"hash"`
+}
+
+// computeHash returns the hash of the record, computed over every field but Hash itself.
+func (record AuditRecord) computeHash() (string, error) {
+	record.Hash = ""
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditSink stores the audit records, e.g. in a file, syslog, or a database.
+// Records are written one at a time, in order.
+//
+// A sink that can give back the last record it stored should also implement
+//
+//	Last() *AuditRecord
+//
+// so NewAuditTrail continues the existing chain instead of starting a new one.
+type AuditSink interface {
+	WriteAuditRecord(record *AuditRecord) error
+}
+
+// AuditTrail records every submission made by a client once set in ThreatMatrixClientOptions.AuditTrail.
+// A submission is only sent once its record is written: if the sink fails, the submission fails too.
+type AuditTrail struct {
+	mutex    sync.Mutex
+	sink     AuditSink
+	actor    string
+	sequence uint64
+	lastHash string
+}
+
+// NewAuditTrail returns an AuditTrail writing to the sink, recording actor as the author of the submissions.
+func NewAuditTrail(sink AuditSink, actor string) *AuditTrail {
+	trail := &AuditTrail{
+		sink:  sink,
+		actor: actor,
+	}
+	if resumable, ok := sink.(interface{ Last() *AuditRecord }); ok {
+		if last := resumable.Last(); last != nil {
+			trail.sequence = last.Sequence
+			trail.lastHash = last.Hash
+		}
+	}
+	return trail
+}
+
+// record chains the record to the previous one and writes it to the sink.
+func (trail *AuditTrail) record(record *AuditRecord) error {
+	trail.mutex.Lock()
+	defer trail.mutex.Unlock()
+	record.Sequence = trail.sequence + 1
+	record.Time = time.Now().UTC()
+	record.Actor = trail.actor
+	record.PreviousHash = trail.lastHash
+	hash, err := record.computeHash()
+	if err != nil {
+		return err
+	}
+	record.Hash = hash
+	if err := trail.sink.WriteAuditRecord(record); err != nil {
+		return fmt.Errorf("could not write the audit record: %w", err)
+	}
+	trail.sequence = record.Sequence
+	trail.lastHash = record.Hash
+	return nil
+}
+
+// auditSubmission records a submission when the client has an audit trail.
+func (client *ThreatMatrixClient) auditSubmission(ctx context.Context, endpoint string, basicAnalysisParams *BasicAnalysisParams, observables []string, files []string) error {
+	if client.options.AuditTrail == nil {
+		return nil
+	}
+	tokenSum := sha256.Sum256([]byte(client.options.Token))
+	var metadata *CallMetadata
+	if contextMetadata, ok := CallMetadataFromContext(ctx); ok {
+		metadata = &contextMetadata
+	}
+	return client.options.AuditTrail.record(&AuditRecord{
+		TokenFingerprint:    hex.EncodeToString(tokenSum[:8]),
+		Endpoint:            endpoint,
+		Observables:         observables,
+		Files:               files,
+		AnalyzersRequested:  basicAnalysisParams.AnalyzersRequested,
+		ConnectorsRequested: basicAnalysisParams.ConnectorsRequested,
+		PlaybookRequested:   basicAnalysisParams.PlaybookRequested,
+		Tlp:                 basicAnalysisParams.Tlp,
+		Metadata:            metadata,
+	})
+}
+
+// VerifyAuditRecords checks the hash chain of the records, in the order they were written.
+// The records can start anywhere in the trail, e.g. after a rotation.
+func VerifyAuditRecords(records []AuditRecord) error {
+	for index, record := range records {
+		hash, err := record.computeHash()
+		if err != nil {
+			return err
+		}
+		if hash != record.Hash {
+			return fmt.Errorf("record %d was modified: %w", record.Sequence, ErrAuditChainBroken)
+		}
+		if index == 0 {
+			continue
+		}
+		previous := records[index-1]
+		if record.PreviousHash != previous.Hash || record.Sequence != previous.Sequence+1 {
+			return fmt.Errorf("record %d does not follow record %d: %w", record.Sequence, previous.Sequence, ErrAuditChainBroken)
+		}
+	}
+	return nil
+}
+
+// WriterAuditSink writes the audit records as JSON lines to any io.Writer, e.g. a *syslog.Writer.
+type WriterAuditSink struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// NewWriterAuditSink returns a WriterAuditSink writing to writer.
+func NewWriterAuditSink(writer io.Writer) *WriterAuditSink {
+	return &WriterAuditSink{
+		writer: writer,
+	}
+}
+
+// WriteAuditRecord writes the record as one JSON line.
+func (sink *WriterAuditSink) WriteAuditRecord(record *AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	_, err = sink.writer.Write(append(data, '\n'))
+	return err
+}
+
+// FileAuditSink appends the audit records as JSON lines to a file, syncing it after every record.
+type FileAuditSink struct {
+	mutex sync.Mutex
+	file  *os.File
+	last  *AuditRecord
+}
+
+// OpenFileAuditSink opens (or creates) the audit file at path.
+// The records already in the file are verified, so a trail is never continued on top of a tampered one.
+func OpenFileAuditSink(path string) (*FileAuditSink, error) {
+	records, err := ReadAuditFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := VerifyAuditRecords(records); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	sink := &FileAuditSink{
+		file: file,
+	}
+	if len(records) > 0 {
+		sink.last = &records[len(records)-1]
+	}
+	return sink, nil
+}
+
+// WriteAuditRecord appends the record to the file.
+func (sink *FileAuditSink) WriteAuditRecord(record *AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	if _, err := sink.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if err := sink.file.Sync(); err != nil {
+		return err
+	}
+	recordCopy := *record
+	sink.last = &recordCopy
+	return nil
+}
+
+// Last returns the last record of the file, nil when it is empty.
+func (sink *FileAuditSink) Last() *AuditRecord {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	return sink.last
+}
+
+// Close closes the audit file.
+func (sink *FileAuditSink) Close() error {
+	return sink.file.Close()
+}
+
+// ReadAuditFile reads every record of an audit file written by a FileAuditSink or a WriterAuditSink.
+func ReadAuditFile(path string) ([]AuditRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	records := []AuditRecord{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		record := AuditRecord{}
+		if unmarshalError := json.Unmarshal(scanner.Bytes(), &record); unmarshalError != nil {
+			return nil, unmarshalError
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,264 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// DEFAULT_JWT_REFRESH_MARGIN is how long before its expiry the JWT of a JWTSessionAuth is renewed
+// when RefreshMargin is left at 0.
+const DEFAULT_JWT_REFRESH_MARGIN = 30 * time.Second
+
+// ErrAuthentication is returned when an AuthProvider could not get the credentials of a request, e.g. a failed login.
+var ErrAuthentication = errors.New("gothreatmatrix: authentication failed")
+
+// AuthProvider authenticates the requests of the client, see ThreatMatrixClientOptions.AuthProvider.
+// It is called for every request, from several goroutines at once.
+type AuthProvider interface {
+	// Authenticate sets the credentials of the request, e.g. its Authorization header.
+	Authenticate(ctx context.Context, request *http.Request) error
+}
+
+// RefreshableAuthProvider is an AuthProvider whose credentials can be renewed. When the server answers
+// 401 Unauthorized to a request, the client calls Invalidate and sends the request once more, authenticated again.
+type RefreshableAuthProvider interface {
+	AuthProvider
+	// Invalidate discards the credentials the request was sent with.
+	Invalidate(request *http.Request)
+}
+
+// authBinder is implemented by the AuthProviders needing the client, e.g. to log in through its transport.
+type authBinder interface {
+	bind(client *ThreatMatrixClient)
+}
+
+// clientCertificateProvider is implemented by the AuthProviders presenting a TLS client certificate.
+type clientCertificateProvider interface {
+	clientCertificates() []tls.Certificate
+}
+
+// TokenAuth authenticates the requests with a static API token, the default when AuthProvider is not set.
+type TokenAuth struct {
+	Token string
+}
+
+// Authenticate implements AuthProvider.
+func (tokenAuth *TokenAuth) Authenticate(ctx context.Context, request *http.Request) error {
+	request.Header.Set("Authorization", "token "+tokenAuth.Token)
+	return nil
+}
+
+// JWTSessionAuth authenticates the requests with a JWT obtained by logging in with a username and a password,
+// sent as a Bearer token. The JWT is renewed by logging in again before it expires, and when the server rejects it.
+// A JWTSessionAuth is bound to the client it is given to, it must not be shared by several clients.
+//
+//	Endpoint: POST /api/auth/login
+type JWTSessionAuth struct {
+	Username string
+	Password string
+	// LoginUrl is where the login is posted, the /api/auth/login endpoint of the instance when empty.
+	LoginUrl string
+	// RefreshMargin is how long before its expiry the JWT is renewed, DEFAULT_JWT_REFRESH_MARGIN when 0.
+	RefreshMargin time.Duration
+
+	client *ThreatMatrixClient
+	mutex  sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// jwtLoginResponse represents the answer of the login endpoint, the JWT being in access or token.
+type jwtLoginResponse struct {
+	Access string    `json:"access"`
+	Token  string    `json:"token"`
+	Expiry time.Time `json:"expiry"`
+}
+
+// bind implements authBinder.
+func (jwtSessionAuth *JWTSessionAuth) bind(client *ThreatMatrixClient) {
+	jwtSessionAuth.client = client
+}
+
+// Authenticate implements AuthProvider, logging in when there is no valid JWT.
+func (jwtSessionAuth *JWTSessionAuth) Authenticate(ctx context.Context, request *http.Request) error {
+	jwtSessionAuth.mutex.Lock()
+	defer jwtSessionAuth.mutex.Unlock()
+	refreshMargin := jwtSessionAuth.RefreshMargin
+	if refreshMargin <= 0 {
+		refreshMargin = DEFAULT_JWT_REFRESH_MARGIN
+	}
+	expired := !jwtSessionAuth.expiry.IsZero() && time.Now().Add(refreshMargin).After(jwtSessionAuth.expiry)
+	if jwtSessionAuth.token == "" || expired {
+		if err := jwtSessionAuth.login(ctx); err != nil {
+			return err
+		}
+	}
+	request.Header.Set("Authorization", "Bearer "+jwtSessionAuth.token)
+	return nil
+}
+
+// Invalidate implements RefreshableAuthProvider. The JWT is only discarded when it is the one the request was
+// sent with, so the requests rejected together log in once.
+func (jwtSessionAuth *JWTSessionAuth) Invalidate(request *http.Request) {
+	jwtSessionAuth.mutex.Lock()
+	defer jwtSessionAuth.mutex.Unlock()
+	if request.Header.Get("Authorization") == "Bearer "+jwtSessionAuth.token {
+		jwtSessionAuth.token = ""
+	}
+}
+
+// login gets a new JWT, its expiry being the one the server answered or the exp claim of the JWT.
+func (jwtSessionAuth *JWTSessionAuth) login(ctx context.Context) error {
+	if jwtSessionAuth.client == nil {
+		return fmt.Errorf("%w: the JWTSessionAuth is not the AuthProvider of a client", ErrAuthentication)
+	}
+	loginUrl := jwtSessionAuth.LoginUrl
+	if loginUrl == "" {
+		loginUrl = jwtSessionAuth.client.options.Url + constants.AUTH_LOGIN_URL
+	}
+	jsonData, err := json.Marshal(map[string]string{"username": jwtSessionAuth.Username, "password": jwtSessionAuth.Password})
+	if err != nil {
+		return err
+	}
+	// * the login is not authenticated, it goes through the transport of the client only
+	request, err := http.NewRequestWithContext(ctx, "POST", loginUrl, bytes.NewReader(jsonData))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := jwtSessionAuth.client.do(request)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrAuthentication, err)
+	}
+	defer response.Body.Close()
+	msgBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrAuthentication, err)
+	}
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%w: %v", ErrAuthentication, newThreatMatrixError(response.StatusCode, string(msgBytes), response))
+	}
+	loginResponse := jwtLoginResponse{}
+	if err := json.Unmarshal(msgBytes, &loginResponse); err != nil {
+		return fmt.Errorf("%w: %v", ErrAuthentication, err)
+	}
+	token := loginResponse.Access
+	if token == "" {
+		token = loginResponse.Token
+	}
+	if token == "" {
+		return fmt.Errorf("%w: the login answered no token", ErrAuthentication)
+	}
+	expiry := loginResponse.Expiry
+	if expiry.IsZero() {
+		expiry = jwtExpiry(token)
+	}
+	jwtSessionAuth.token = token
+	jwtSessionAuth.expiry = expiry
+	return nil
+}
+
+// jwtExpiry returns the time of the exp claim of the JWT, the zero time when it has none or is not a JWT.
+// The signature is not verified, only the server can.
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+	claims := struct {
+		Exp float64 `json:"exp"`
+	}{}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(claims.Exp), 0)
+}
+
+// ClientCertificateAuth presents a TLS client certificate (mTLS) to the instance, e.g. behind a reverse proxy
+// requiring one. The requests are then authenticated by Next too when it is set, e.g. a TokenAuth.
+// The certificate is only presented by the Transport NewThreatMatrixClient builds, not by ThreatMatrixClientOptions.Transport.
+type ClientCertificateAuth struct {
+	Certificate tls.Certificate
+	Next        AuthProvider
+}
+
+// NewClientCertificateAuth returns a ClientCertificateAuth presenting the PEM certificate and key of the files.
+func NewClientCertificateAuth(certFile string, keyFile string, next AuthProvider) (*ClientCertificateAuth, error) {
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the client certificate %s: %w", certFile, err)
+	}
+	return &ClientCertificateAuth{Certificate: certificate, Next: next}, nil
+}
+
+// Authenticate implements AuthProvider, the certificate being presented by the TLS handshake.
+func (clientCertificateAuth *ClientCertificateAuth) Authenticate(ctx context.Context, request *http.Request) error {
+	if clientCertificateAuth.Next == nil {
+		return nil
+	}
+	return clientCertificateAuth.Next.Authenticate(ctx, request)
+}
+
+// Invalidate implements RefreshableAuthProvider, for a refreshable Next.
+func (clientCertificateAuth *ClientCertificateAuth) Invalidate(request *http.Request) {
+	if refreshable, ok := clientCertificateAuth.Next.(RefreshableAuthProvider); ok {
+		refreshable.Invalidate(request)
+	}
+}
+
+// bind implements authBinder, for a Next needing the client.
+func (clientCertificateAuth *ClientCertificateAuth) bind(client *ThreatMatrixClient) {
+	if binder, ok := clientCertificateAuth.Next.(authBinder); ok {
+		binder.bind(client)
+	}
+}
+
+// clientCertificates implements clientCertificateProvider.
+func (clientCertificateAuth *ClientCertificateAuth) clientCertificates() []tls.Certificate {
+	return []tls.Certificate{clientCertificateAuth.Certificate}
+}
+
+// authProvider returns the AuthProvider of the client, a TokenAuth of the Token when none is set.
+func (client *ThreatMatrixClient) authProvider() AuthProvider {
+	if client.options.AuthProvider != nil {
+		return client.options.AuthProvider
+	}
+	return &TokenAuth{Token: client.options.Token}
+}
+
+// reauthenticate invalidates the credentials of the request rejected with a 401 and authenticates it again,
+// reporting whether it can be sent once more: with a RefreshableAuthProvider and a body that can be replayed.
+func (client *ThreatMatrixClient) reauthenticate(ctx context.Context, request *http.Request) bool {
+	refreshable, ok := client.authProvider().(RefreshableAuthProvider)
+	if !ok || (request.Body != nil && request.Body != http.NoBody && request.GetBody == nil) {
+		return false
+	}
+	refreshable.Invalidate(request)
+	if refreshable.Authenticate(ctx, request) != nil {
+		return false
+	}
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return false
+		}
+		request.Body = body
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// DEFAULT_BULK_CONCURRENCY is the number of submissions BulkObservableAnalysis runs at once
+// when BulkOptions leaves Concurrency at 0.
+const DEFAULT_BULK_CONCURRENCY = 4
+
+// DEFAULT_BULK_BATCH_SIZE is the number of observables of a batch when BulkOptions leaves BatchSize at 0.
+const DEFAULT_BULK_BATCH_SIZE = 50
+
+// ObservableInput represents an observable of BulkObservableAnalysis.
+type ObservableInput struct {
+	Name string `json:"name"`
+	// Classification is left to ThreatMatrix when empty, see ObservableAnalysisParams.ObservableClassification.
+	Classification string `json:"classification,omitempty"`
+}
+
+// BulkOptions represents the fields used to configure BulkObservableAnalysis.
+type BulkOptions struct {
+	// BasicAnalysisParams are the params of every analysis. Its IdempotencyKey, when set, is suffixed with the
+	// index of the observable, or "batch-" and the index of the first one of the batch, so each submission has
+	// a key of its own.
+	BasicAnalysisParams
+	// Concurrency is the number of submissions sent at once, DEFAULT_BULK_CONCURRENCY when 0.
+	Concurrency int
+	// UseBatchEndpoint submits the observables BatchSize at a time through analyze_multiple_observables,
+	// falling back to one submission per observable on instances without that endpoint.
+	UseBatchEndpoint bool
+	// BatchSize is the number of observables of a batch, DEFAULT_BULK_BATCH_SIZE when 0.
+	BatchSize int
+}
+
+// BulkAnalysisResult represents the outcome of the submission of one observable of BulkObservableAnalysis.
+type BulkAnalysisResult struct {
+	Input ObservableInput `json:"input"`
+	// Response is nil when the submission failed.
+	Response *AnalysisResponse `json:"response,omitempty"`
+	Err      error             `json:"-"`
+}
+
+// BulkObservableAnalysis submits the observables through a pool of Concurrency workers and returns the outcome
+// of each of them, in the order of the observables. A failed submission does not stop the others,
+// its error is the Err of its results.
+// The returned error is the context one when it was done before every observable was submitted.
+func (client *ThreatMatrixClient) BulkObservableAnalysis(ctx context.Context, observables []ObservableInput, options *BulkOptions) ([]BulkAnalysisResult, error) {
+	bulkOptions := BulkOptions{}
+	if options != nil {
+		bulkOptions = *options
+	}
+	if bulkOptions.Concurrency <= 0 {
+		bulkOptions.Concurrency = DEFAULT_BULK_CONCURRENCY
+	}
+	if bulkOptions.BatchSize <= 0 {
+		bulkOptions.BatchSize = DEFAULT_BULK_BATCH_SIZE
+	}
+	results := make([]BulkAnalysisResult, len(observables))
+	for index, observable := range observables {
+		results[index].Input = observable
+	}
+	// * a unit of work is the range of observables submitted together, a single one without the batch endpoint
+	size := 1
+	if bulkOptions.UseBatchEndpoint {
+		size = bulkOptions.BatchSize
+	}
+	ranges := make(chan [2]int)
+	// * the first batch the instance does not know the endpoint of turns batching off for the others
+	var batchUnavailable bool
+	var mutex sync.Mutex
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < bulkOptions.Concurrency; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for bounds := range ranges {
+				mutex.Lock()
+				batch := bulkOptions.UseBatchEndpoint && !batchUnavailable
+				mutex.Unlock()
+				if batch && client.submitBatch(ctx, &bulkOptions, bounds[0], results[bounds[0]:bounds[1]]) {
+					continue
+				}
+				if batch {
+					mutex.Lock()
+					batchUnavailable = true
+					mutex.Unlock()
+				}
+				for index := bounds[0]; index < bounds[1]; index++ {
+					client.submitBulkObservable(ctx, &bulkOptions, index, &results[index])
+				}
+			}
+		}()
+	}
+	var err error
+	for start := 0; start < len(observables) && err == nil; start += size {
+		end := start + size
+		if end > len(observables) {
+			end = len(observables)
+		}
+		select {
+		case ranges <- [2]int{start, end}:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	close(ranges)
+	waitGroup.Wait()
+	if err != nil {
+		for index := range results {
+			if results[index].Response == nil && results[index].Err == nil {
+				results[index].Err = err
+			}
+		}
+	}
+	return results, err
+}
+
+// bulkKey returns the idempotency key of the submission at the index, empty when the options have none.
+func bulkKey(bulkOptions *BulkOptions, index int) string {
+	if bulkOptions.IdempotencyKey == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s-%d", bulkOptions.IdempotencyKey, index)
+}
+
+// submitBulkObservable submits the observable of the result on its own.
+func (client *ThreatMatrixClient) submitBulkObservable(ctx context.Context, bulkOptions *BulkOptions, index int, result *BulkAnalysisResult) {
+	params := ObservableAnalysisParams{
+		BasicAnalysisParams:      bulkOptions.BasicAnalysisParams,
+		ObservableName:           result.Input.Name,
+		ObservableClassification: result.Input.Classification,
+	}
+	params.IdempotencyKey = bulkKey(bulkOptions, index)
+	result.Response, result.Err = client.CreateObservableAnalysis(ctx, &params)
+}
+
+// submitBatch submits the observables of the results, starting at the index, through the batch endpoint.
+// It reports false, leaving the results untouched, when the instance does not have the endpoint.
+func (client *ThreatMatrixClient) submitBatch(ctx context.Context, bulkOptions *BulkOptions, start int, results []BulkAnalysisResult) bool {
+	params := MultipleObservableAnalysisParams{
+		BasicAnalysisParams: bulkOptions.BasicAnalysisParams,
+		Observables:         make([][]string, len(results)),
+	}
+	if bulkOptions.IdempotencyKey != "" {
+		params.IdempotencyKey = fmt.Sprintf("%s-batch-%d", bulkOptions.IdempotencyKey, start)
+	}
+	for index, result := range results {
+		if result.Input.Classification == "" {
+			params.Observables[index] = []string{result.Input.Name}
+		} else {
+			params.Observables[index] = []string{result.Input.Classification, result.Input.Name}
+		}
+	}
+	multipleAnalysisResponse, err := client.CreateMultipleObservableAnalysis(ctx, &params)
+	if err != nil && errors.Is(client.featureError(FEATURE_BATCH_ANALYSES, err), ErrFeatureUnavailable) {
+		return false
+	}
+	for index := range results {
+		switch {
+		case err != nil:
+			results[index].Err = err
+		case index < len(multipleAnalysisResponse.Results):
+			response := multipleAnalysisResponse.Results[index]
+			results[index].Response = &response
+		default:
+			results[index].Err = fmt.Errorf("gothreatmatrix: the batch response has %d results for %d observables", len(multipleAnalysisResponse.Results), len(results))
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"net/http"
+	"sort"
+)
+
+// Headers carrying the CallMetadata of the context of a request.
+const (
+	REQUESTING_USER_HEADER = "X-ThreatMatrix-Requesting-User"
+	SOURCE_SYSTEM_HEADER   = "X-ThreatMatrix-Source-System"
+	CASE_ID_HEADER         = "X-ThreatMatrix-Case-Id"
+	// METADATA_HEADER_PREFIX is followed by the key of every CallMetadata.Extra entry.
+	METADATA_HEADER_PREFIX = "X-ThreatMatrix-Meta-"
+)
+
+// CallMetadata represents the business context of a call, e.g. the analyst and the case an enrichment is made for.
+// Attached to a context with WithCallMetadata, it is sent as headers with every request made with that context
+// and recorded in the audit trail.
+type CallMetadata struct {
+	RequestingUser string            `json:"requesting_user,omitempty"`
+	SourceSystem   string            `json:"source_system,omitempty"`
+	CaseID         string            `json:"case_id,omitempty"`
+	Extra          map[string]string `json:"extra,omitempty"`
+}
+
+type callMetadataKey struct{}
+
+// WithCallMetadata returns a copy of ctx carrying the metadata.
+// The fields it leaves empty keep the value of the metadata already carried by ctx, if any.
+func WithCallMetadata(ctx context.Context, metadata CallMetadata) context.Context {
+	merged := metadata
+	if parent, ok := CallMetadataFromContext(ctx); ok {
+		if merged.RequestingUser == "" {
+			merged.RequestingUser = parent.RequestingUser
+		}
+		if merged.SourceSystem == "" {
+			merged.SourceSystem = parent.SourceSystem
+		}
+		if merged.CaseID == "" {
+			merged.CaseID = parent.CaseID
+		}
+		if len(parent.Extra) > 0 {
+			merged.Extra = make(map[string]string, len(parent.Extra)+len(metadata.Extra))
+			for key, value := range parent.Extra {
+				merged.Extra[key] = value
+			}
+			for key, value := range metadata.Extra {
+				merged.Extra[key] = value
+			}
+		}
+	}
+	return context.WithValue(ctx, callMetadataKey{}, merged)
+}
+
+// CallMetadataFromContext returns the metadata carried by ctx, ok is false when there is none.
+func CallMetadataFromContext(ctx context.Context) (metadata CallMetadata, ok bool) {
+	metadata, ok = ctx.Value(callMetadataKey{}).(CallMetadata)
+	return metadata, ok
+}
+
+// setMetadataHeaders sets the headers of the metadata carried by ctx on the request.
+func setMetadataHeaders(ctx context.Context, header http.Header) {
+	metadata, ok := CallMetadataFromContext(ctx)
+	if !ok {
+		return
+	}
+	if metadata.RequestingUser != "" {
+		header.Set(REQUESTING_USER_HEADER, metadata.RequestingUser)
+	}
+	if metadata.SourceSystem != "" {
+		header.Set(SOURCE_SYSTEM_HEADER, metadata.SourceSystem)
+	}
+	if metadata.CaseID != "" {
+		header.Set(CASE_ID_HEADER, metadata.CaseID)
+	}
+	keys := make([]string, 0, len(metadata.Extra))
+	for key := range metadata.Extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		header.Set(METADATA_HEADER_PREFIX+key, metadata.Extra[key])
+	}
+}
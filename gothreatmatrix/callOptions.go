@@ -0,0 +1,137 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// CallOption tunes the requests of a single call, see WithCallOptions.
+type CallOption func(settings *callSettings)
+
+// callSettings represents the CallOptions carried by a context.
+type callSettings struct {
+	timeout      time.Duration
+	header       http.Header
+	query        url.Values
+	forceRefresh bool
+	noHedging    bool
+}
+
+type callSettingsKey struct{}
+
+// WithTimeout bounds the whole call, retries and the reading of the response included, by the timeout,
+// instead of the Timeout of the client bounding every attempt.
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(settings *callSettings) {
+		settings.timeout = timeout
+	}
+}
+
+// WithHeader sets the header on the requests of the call, replacing the value the client would send.
+func WithHeader(key string, value string) CallOption {
+	return func(settings *callSettings) {
+		settings.header.Set(key, value)
+	}
+}
+
+// WithQueryParam adds the query param to the URL of the requests of the call.
+func WithQueryParam(key string, value string) CallOption {
+	return func(settings *callSettings) {
+		settings.query.Add(key, value)
+	}
+}
+
+// ForceRefresh makes the configuration calls of the call skip the configuration cache of the client,
+// see ThreatMatrixClientOptions.ConfigCacheTTL, and refresh it with the response of the instance.
+func ForceRefresh() CallOption {
+	return func(settings *callSettings) {
+		settings.forceRefresh = true
+	}
+}
+
+// WithoutHedging makes the requests of the call wait for their single attempt, even when the client hedges the
+// read-only requests, see ThreatMatrixClientOptions.Hedging.
+func WithoutHedging() CallOption {
+	return func(settings *callSettings) {
+		settings.noHedging = true
+	}
+}
+
+// WithCallOptions returns a copy of ctx carrying the options, on top of the ones ctx already carries.
+// Every service method taking the returned context applies them, the per-call counterpart of the client options:
+//
+//	ctx := gothreatmatrix.WithCallOptions(ctx, gothreatmatrix.WithTimeout(5*time.Second), gothreatmatrix.WithHeader("X-Tenant", "soc-eu"))
+//	job, err := client.JobService.Get(ctx, jobId)
+func WithCallOptions(ctx context.Context, options ...CallOption) context.Context {
+	settings := &callSettings{header: http.Header{}, query: url.Values{}}
+	if parent, ok := ctx.Value(callSettingsKey{}).(*callSettings); ok {
+		settings.timeout = parent.timeout
+		settings.forceRefresh = parent.forceRefresh
+		settings.noHedging = parent.noHedging
+		settings.header = parent.header.Clone()
+		for key, values := range parent.query {
+			settings.query[key] = append([]string{}, values...)
+		}
+	}
+	for _, option := range options {
+		option(settings)
+	}
+	return context.WithValue(ctx, callSettingsKey{}, settings)
+}
+
+// applyCallHeaders sets the headers and query params of the CallOptions carried by ctx on the request.
+func applyCallHeaders(ctx context.Context, request *http.Request) {
+	settings, ok := ctx.Value(callSettingsKey{}).(*callSettings)
+	if !ok {
+		return
+	}
+	for key, values := range settings.header {
+		request.Header[key] = append([]string{}, values...)
+	}
+	if len(settings.query) > 0 {
+		query := request.URL.Query()
+		for key, values := range settings.query {
+			for _, value := range values {
+				query.Add(key, value)
+			}
+		}
+		request.URL.RawQuery = query.Encode()
+	}
+}
+
+// forcesRefresh reports whether the CallOptions carried by ctx include ForceRefresh.
+func forcesRefresh(ctx context.Context) bool {
+	settings, ok := ctx.Value(callSettingsKey{}).(*callSettings)
+	return ok && settings.forceRefresh
+}
+
+// hedgingDisabled reports whether the CallOptions carried by ctx include WithoutHedging.
+func hedgingDisabled(ctx context.Context) bool {
+	settings, ok := ctx.Value(callSettingsKey{}).(*callSettings)
+	return ok && settings.noHedging
+}
+
+// callContext returns the context bounded by the WithTimeout of the CallOptions carried by ctx, if any,
+// and the function releasing it.
+func callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if settings, ok := ctx.Value(callSettingsKey{}).(*callSettings); ok && settings.timeout > 0 {
+		return context.WithTimeout(ctx, settings.timeout)
+	}
+	return ctx, func() {}
+}
+
+// cancelingBody releases the context of its call once closed, the response being read after the call returned.
+type cancelingBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and releases the context.
+func (body *cancelingBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
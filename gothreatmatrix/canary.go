@@ -0,0 +1,300 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Known benign observables submitted by RunCanary when CanaryOptions leaves Observables empty.
+const (
+	// CANARY_EICAR_MD5 is the md5 of the EICAR anti-virus test file.
+	CANARY_EICAR_MD5 = "44d88612fea8a8f36de82e1278abb02f"
+	CANARY_DOMAIN    = "example.com"
+)
+
+// CANARY_TAG is the tag of the jobs of the canaries, so they can be told apart from the real analyses.
+const CANARY_TAG = "canary"
+
+// CANARY_CHECK is the Name of the PreflightCheck returned by Canary.Check.
+const CANARY_CHECK = "canary"
+
+// DEFAULT_CANARY_INTERVAL and DEFAULT_CANARY_SLA are used when CanaryOptions leaves Interval and SLA at 0.
+const (
+	DEFAULT_CANARY_INTERVAL = 15 * time.Minute
+	DEFAULT_CANARY_SLA      = 5 * time.Minute
+)
+
+// ErrCanarySLAExceeded is the Err of a CanaryResult whose job did not complete within the SLA.
+var ErrCanarySLAExceeded = errors.New("gothreatmatrix: the canary did not complete within its SLA")
+
+// ErrCanaryJobFailed is the Err of a CanaryResult whose job ended otherwise than reported without fails.
+var ErrCanaryJobFailed = errors.New("gothreatmatrix: the canary job did not report without fails")
+
+// CanaryOptions represents the fields used to configure RunCanary.
+type CanaryOptions struct {
+	// Observables are submitted on every round, CANARY_EICAR_MD5 and CANARY_DOMAIN when empty.
+	Observables []string
+	// Playbook is requested by the canary analyses, Analyzers being used when it is empty.
+	Playbook  string
+	Analyzers []string
+	// Interval is the wait between two rounds, DEFAULT_CANARY_INTERVAL when 0.
+	Interval time.Duration
+	// SLA bounds the time from the submission to the completion of a canary job, DEFAULT_CANARY_SLA when 0.
+	SLA time.Duration
+	// Wait configures how the canary jobs are waited for. Its AnalyzerTimeout is ignored, the SLA bounding the wait.
+	Wait *WaitOptions
+}
+
+// CanaryResult represents the outcome of the analysis of a canary observable.
+type CanaryResult struct {
+	Observable string
+	// JobID is 0 when the submission failed.
+	JobID  uint64
+	Status JobStatus
+	// Healthy is true when the job reported without fails within the SLA.
+	Healthy bool
+	// Duration is the time from the submission to the completion, or to the failure.
+	Duration time.Duration
+	// Err is why the canary is not Healthy: the error of the submission or the wait, ErrCanarySLAExceeded
+	// or ErrCanaryJobFailed.
+	Err       error
+	CheckedAt time.Time
+}
+
+// CanaryMetrics represents the counters of a Canary since it started.
+type CanaryMetrics struct {
+	Runs      int
+	Successes int
+	Failures  int
+	// SLABreaches counts the Failures due to ErrCanarySLAExceeded.
+	SLABreaches   int
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+	// LastSuccess is the CheckedAt of the last Healthy result, zero when none was.
+	LastSuccess time.Time
+}
+
+// Canary represents a running end-to-end self-test of the ThreatMatrix instance, see RunCanary.
+type Canary struct {
+	results chan CanaryResult
+	cancel  context.CancelFunc
+	done    chan struct{}
+	mutex   sync.Mutex
+	err     error
+	last    map[string]CanaryResult
+	order   []string
+	metrics CanaryMetrics
+}
+
+// Results returns the channel every CanaryResult is sent on. It is closed once the canary stops,
+// and it must be drained for the canary to make progress.
+func (canary *Canary) Results() <-chan CanaryResult {
+	return canary.results
+}
+
+// Last returns the last result of the observable, ok is false when it was not checked yet.
+func (canary *Canary) Last(observable string) (result CanaryResult, ok bool) {
+	canary.mutex.Lock()
+	defer canary.mutex.Unlock()
+	result, ok = canary.last[observable]
+	return result, ok
+}
+
+// Metrics returns the counters of the canary.
+func (canary *Canary) Metrics() CanaryMetrics {
+	canary.mutex.Lock()
+	defer canary.mutex.Unlock()
+	return canary.metrics
+}
+
+// Check summarizes the last results of the canary as a PreflightCheck named CANARY_CHECK, so it can be reported
+// along the other health checks: it fails when the last result of an observable is not Healthy,
+// and is skipped before the first round completed.
+func (canary *Canary) Check() PreflightCheck {
+	canary.mutex.Lock()
+	defer canary.mutex.Unlock()
+	check := PreflightCheck{Name: CANARY_CHECK, Status: PREFLIGHT_SKIP, Detail: "no canary completed yet"}
+	for _, observable := range canary.order {
+		result := canary.last[observable]
+		if !result.Healthy {
+			check.Status = PREFLIGHT_FAIL
+			check.Detail = fmt.Sprintf("canary %s: %v", observable, result.Err)
+			check.Err = result.Err
+			return check
+		}
+		check.Status = PREFLIGHT_PASS
+		check.Detail = fmt.Sprintf("%d canaries completed within their SLA", len(canary.order))
+	}
+	return check
+}
+
+// Stop stops the canary.
+func (canary *Canary) Stop() {
+	canary.cancel()
+}
+
+// Wait blocks until the canary stopped and returns why, the cancellation of its context.
+func (canary *Canary) Wait() error {
+	<-canary.done
+	canary.mutex.Lock()
+	defer canary.mutex.Unlock()
+	return canary.err
+}
+
+// record saves the result and updates the metrics.
+func (canary *Canary) record(result CanaryResult) {
+	canary.mutex.Lock()
+	defer canary.mutex.Unlock()
+	if _, ok := canary.last[result.Observable]; !ok {
+		canary.order = append(canary.order, result.Observable)
+	}
+	canary.last[result.Observable] = result
+	canary.metrics.Runs++
+	canary.metrics.TotalDuration += result.Duration
+	if result.Duration > canary.metrics.MaxDuration {
+		canary.metrics.MaxDuration = result.Duration
+	}
+	switch {
+	case result.Healthy:
+		canary.metrics.Successes++
+		canary.metrics.LastSuccess = result.CheckedAt
+	case errors.Is(result.Err, ErrCanarySLAExceeded):
+		canary.metrics.Failures++
+		canary.metrics.SLABreaches++
+	default:
+		canary.metrics.Failures++
+	}
+}
+
+// RunCanary submits the canary observables through the configured playbook on every interval, until ctx is done
+// or the canary is stopped, and checks that their jobs complete without fails within the SLA.
+// A broken worker, queue or analyzer then shows up in the results before the real analyses suffer from it.
+//
+// The canaries are submitted with ForceFreshScan and tagged CANARY_TAG.
+func (client *ThreatMatrixClient) RunCanary(ctx context.Context, options *CanaryOptions) *Canary {
+	if options == nil {
+		options = &CanaryOptions{}
+	}
+	canaryCtx, cancel := context.WithCancel(ctx)
+	canary := &Canary{
+		results: make(chan CanaryResult),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		last:    map[string]CanaryResult{},
+	}
+	interval := options.Interval
+	if interval <= 0 {
+		interval = DEFAULT_CANARY_INTERVAL
+	}
+	go func() {
+		defer close(canary.done)
+		defer close(canary.results)
+		err := client.canary(canaryCtx, options, interval, canary)
+		canary.mutex.Lock()
+		canary.err = err
+		canary.mutex.Unlock()
+	}()
+	return canary
+}
+
+// canary runs a round of canaries on every interval until ctx is done.
+func (client *ThreatMatrixClient) canary(ctx context.Context, options *CanaryOptions, interval time.Duration, canary *Canary) error {
+	for {
+		for _, result := range client.CanaryCheck(ctx, options) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			canary.record(result)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case canary.results <- result:
+			}
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// CanaryCheck runs a single round of the canaries of RunCanary, concurrently, and returns their results
+// in the order of the observables.
+func (client *ThreatMatrixClient) CanaryCheck(ctx context.Context, options *CanaryOptions) []CanaryResult {
+	if options == nil {
+		options = &CanaryOptions{}
+	}
+	observables := options.Observables
+	if len(observables) == 0 {
+		observables = []string{CANARY_EICAR_MD5, CANARY_DOMAIN}
+	}
+	results := make([]CanaryResult, len(observables))
+	var waitGroup sync.WaitGroup
+	for index, observable := range observables {
+		waitGroup.Add(1)
+		go func(index int, observable string) {
+			defer waitGroup.Done()
+			results[index] = client.runCanary(ctx, options, observable)
+		}(index, observable)
+	}
+	waitGroup.Wait()
+	return results
+}
+
+// runCanary submits the observable and waits for its job within the SLA.
+func (client *ThreatMatrixClient) runCanary(ctx context.Context, options *CanaryOptions, observable string) (result CanaryResult) {
+	sla := options.SLA
+	if sla <= 0 {
+		sla = DEFAULT_CANARY_SLA
+	}
+	result.Observable = observable
+	start := time.Now()
+	slaCtx, cancel := context.WithTimeout(ctx, sla)
+	defer cancel()
+	defer func() {
+		result.CheckedAt = time.Now()
+		result.Duration = result.CheckedAt.Sub(start)
+		// * the SLA, not the caller, ended the canary
+		if result.Err != nil && ctx.Err() == nil && errors.Is(slaCtx.Err(), context.DeadlineExceeded) {
+			result.Err = fmt.Errorf("%w (%v): %v", ErrCanarySLAExceeded, sla, result.Err)
+		}
+	}()
+	params := &ObservableAnalysisParams{
+		BasicAnalysisParams: BasicAnalysisParams{
+			AnalyzersRequested: options.Analyzers,
+			PlaybookRequested:  options.Playbook,
+			TagsLabels:         []string{CANARY_TAG},
+			ForceFreshScan:     true,
+		},
+		ObservableName: observable,
+	}
+	analysisResponse, err := client.CreateObservableAnalysis(slaCtx, params)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.JobID = uint64(analysisResponse.JobID)
+	waitOptions := WaitOptions{}
+	if options.Wait != nil {
+		waitOptions = *options.Wait
+	}
+	waitOptions.AnalyzerTimeout = 0
+	waitResult, err := client.JobService.WaitForCompletion(slaCtx, result.JobID, &waitOptions)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Status = waitResult.Job.State()
+	if result.Status != REPORTED_WITHOUT_FAILS {
+		result.Err = fmt.Errorf("%w: job %d is %s", ErrCanaryJobFailed, result.JobID, result.Status)
+		return result
+	}
+	result.Healthy = true
+	return result
+}
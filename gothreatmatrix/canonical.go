@@ -0,0 +1,142 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IndicatorCanonicalizer maps an observable to the identity it is deduplicated on: two observables with the same
+// canonical form are the same entity, e.g. an URL and the final target of its redirects.
+// Set it in ThreatMatrixClientOptions.Canonicalizer, DefaultCanonicalizer is used otherwise.
+type IndicatorCanonicalizer interface {
+	// Canonicalize returns the canonical form of the observable, classification being ip, url, domain, hash, generic,
+	// or empty when it is unknown.
+	Canonicalize(ctx context.Context, classification string, observable string) (string, error)
+}
+
+// CanonicalizerFunc lets an ordinary function be used as an IndicatorCanonicalizer.
+type CanonicalizerFunc func(ctx context.Context, classification string, observable string) (string, error)
+
+// Canonicalize calls the function.
+func (canonicalizerFunc CanonicalizerFunc) Canonicalize(ctx context.Context, classification string, observable string) (string, error) {
+	return canonicalizerFunc(ctx, classification, observable)
+}
+
+// DefaultCanonicalizer only normalizes the spelling of the observables: IPs are formatted the standard way,
+// domains and hashes are lowercased, domains lose their trailing dot, and URLs get a lowercase scheme and host,
+// no default port, no fragment, and at least "/" as path. It never fails.
+type DefaultCanonicalizer struct{}
+
+// Canonicalize returns the normalized spelling of the observable.
+func (DefaultCanonicalizer) Canonicalize(ctx context.Context, classification string, observable string) (string, error) {
+	value := strings.TrimSpace(observable)
+	if classification == "" {
+		classification = ClassifyObservable(value)
+	}
+	switch classification {
+	case "ip":
+		if ip := net.ParseIP(value); ip != nil {
+			return ip.String(), nil
+		}
+	case "domain":
+		return strings.TrimSuffix(strings.ToLower(value), "."), nil
+	case "hash":
+		return strings.ToLower(value), nil
+	case "url":
+		return canonicalUrl(value), nil
+	}
+	return value, nil
+}
+
+// canonicalUrl normalizes the spelling of the URL, returning it as is when it cannot be parsed.
+func canonicalUrl(value string) string {
+	parsedUrl, err := url.Parse(value)
+	if err != nil || parsedUrl.Host == "" {
+		return value
+	}
+	parsedUrl.Scheme = strings.ToLower(parsedUrl.Scheme)
+	host := strings.TrimSuffix(strings.ToLower(parsedUrl.Hostname()), ".")
+	port := parsedUrl.Port()
+	if (parsedUrl.Scheme == "http" && port == "80") || (parsedUrl.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	parsedUrl.Host = host
+	parsedUrl.Fragment = ""
+	parsedUrl.RawFragment = ""
+	if parsedUrl.Path == "" {
+		parsedUrl.Path = "/"
+	}
+	return parsedUrl.String()
+}
+
+// canonicalObservable returns the canonical form of the observable, with the client's canonicalizer.
+func (client *ThreatMatrixClient) canonicalObservable(ctx context.Context, classification string, observable string) (string, error) {
+	var canonicalizer IndicatorCanonicalizer = DefaultCanonicalizer{}
+	if client.options.Canonicalizer != nil {
+		canonicalizer = client.options.Canonicalizer
+	}
+	return canonicalizer.Canonicalize(ctx, classification, observable)
+}
+
+// recentAnalyses remembers the analyses submitted through the client, keyed by the canonical form of what
+// was analyzed and by what was requested, so reusing recent results also covers observables the server
+// does not know as identical.
+type recentAnalyses struct {
+	mutex     sync.Mutex
+	entries   map[string]recentAnalysis
+	maxWindow time.Duration
+}
+
+type recentAnalysis struct {
+	response    AnalysisResponse
+	submittedAt time.Time
+}
+
+// recentAnalysisKey returns the key an analysis of the canonical observable is remembered with.
+func recentAnalysisKey(canonical string, basicAnalysisParams *BasicAnalysisParams) string {
+	analyzers := append([]string{}, basicAnalysisParams.AnalyzersRequested...)
+	sort.Strings(analyzers)
+	return canonical + "\x00" + basicAnalysisParams.PlaybookRequested + "\x00" + strings.Join(analyzers, ",")
+}
+
+// get returns the analysis remembered with the key if it was submitted within the window.
+func (recent *recentAnalyses) get(key string, window time.Duration) (*AnalysisResponse, bool) {
+	recent.mutex.Lock()
+	defer recent.mutex.Unlock()
+	entry, ok := recent.entries[key]
+	if !ok || time.Since(entry.submittedAt) > window {
+		return nil, false
+	}
+	response := entry.response
+	return &response, true
+}
+
+// add remembers the analysis, forgetting the ones older than every window seen so far.
+func (recent *recentAnalyses) add(key string, window time.Duration, response *AnalysisResponse) {
+	recent.mutex.Lock()
+	defer recent.mutex.Unlock()
+	if recent.entries == nil {
+		recent.entries = map[string]recentAnalysis{}
+	}
+	if window > recent.maxWindow {
+		recent.maxWindow = window
+	}
+	now := time.Now()
+	for entryKey, entry := range recent.entries {
+		if now.Sub(entry.submittedAt) > recent.maxWindow {
+			delete(recent.entries, entryKey)
+		}
+	}
+	recent.entries[key] = recentAnalysis{response: *response, submittedAt: now}
+}
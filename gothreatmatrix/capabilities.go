@@ -0,0 +1,118 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"fmt"
+)
+
+// Capability represents something the token of the client is allowed to do.
+type Capability string
+
+// Values of the Capability enum.
+const (
+	CAPABILITY_READ   Capability = "read"
+	CAPABILITY_SUBMIT Capability = "submit"
+	// CAPABILITY_MANAGE_ORGANIZATION is held by the owners of an organization, who can invite and remove its members.
+	CAPABILITY_MANAGE_ORGANIZATION Capability = "manage_organization"
+)
+
+// capabilities are the Capability values, in the order they are probed.
+var capabilities = []Capability{CAPABILITY_READ, CAPABILITY_SUBMIT, CAPABILITY_MANAGE_ORGANIZATION}
+
+// CapabilityReport represents what the token of the client can do, see Capabilities.
+type CapabilityReport struct {
+	Username string `json:"username"`
+	// Granted are the capabilities the token holds.
+	Granted []Capability `json:"granted"`
+	// Undetermined are the capabilities the probes could not tell, e.g. on an instance not exposing its permissions.
+	Undetermined []Capability `json:"undetermined,omitempty"`
+}
+
+// Has reports whether the token holds the capability.
+func (report *CapabilityReport) Has(capability Capability) bool {
+	return containsCapability(report.Granted, capability)
+}
+
+// LeastPrivilegeWarning represents a capability the token holds, or may hold, that the application does not need.
+type LeastPrivilegeWarning struct {
+	Capability Capability `json:"capability"`
+	// Undetermined is true when the token may hold the capability, the probe could not tell.
+	Undetermined bool   `json:"undetermined"`
+	Message      string `json:"message"`
+}
+
+// LeastPrivilegeWarnings returns a warning for every capability granted, or undetermined, that is not needed.
+// An application doing read-only enrichment only needs CAPABILITY_READ, and is warned when it holds a key
+// able to submit analyses or manage its organization.
+func (report *CapabilityReport) LeastPrivilegeWarnings(needed ...Capability) []LeastPrivilegeWarning {
+	warnings := []LeastPrivilegeWarning{}
+	for _, capability := range capabilities {
+		if containsCapability(needed, capability) {
+			continue
+		}
+		switch {
+		case report.Has(capability):
+			warnings = append(warnings, LeastPrivilegeWarning{
+				Capability: capability,
+				Message:    fmt.Sprintf("the token of %s holds %s, which the application does not need", report.Username, capability),
+			})
+		case containsCapability(report.Undetermined, capability):
+			warnings = append(warnings, LeastPrivilegeWarning{
+				Capability:   capability,
+				Undetermined: true,
+				Message:      fmt.Sprintf("the token of %s may hold %s, which the application does not need", report.Username, capability),
+			})
+		}
+	}
+	return warnings
+}
+
+// containsCapability reports whether the capability is listed.
+func containsCapability(list []Capability, capability Capability) bool {
+	for _, listed := range list {
+		if listed == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities probes what the token of the client can do, with the read-only requests of Preflight:
+// nothing is submitted nor changed. It only returns an error when the token could not be authenticated.
+func (client *ThreatMatrixClient) Capabilities(ctx context.Context) (*CapabilityReport, error) {
+	user, err := client.UserService.Access(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &CapabilityReport{Username: user.User.Username, Granted: []Capability{}}
+	probes := &PreflightReport{}
+	client.preflightRead(ctx, probes)
+	client.preflightSubmit(ctx, probes)
+	for index, capability := range []Capability{CAPABILITY_READ, CAPABILITY_SUBMIT} {
+		switch probes.Checks[index].Status {
+		case PREFLIGHT_PASS:
+			report.Granted = append(report.Granted, capability)
+		case PREFLIGHT_WARN:
+			report.Undetermined = append(report.Undetermined, capability)
+		}
+	}
+	// * the admin check of Preflight only warns, the owner is told apart here
+	organization, err := client.UserService.Organization(ctx)
+	switch {
+	case err == nil && organization.IsUserOwner:
+		report.Granted = append(report.Granted, CAPABILITY_MANAGE_ORGANIZATION)
+	case err != nil && !IsNotFound(err):
+		report.Undetermined = append(report.Undetermined, CAPABILITY_MANAGE_ORGANIZATION)
+	}
+	return report, nil
+}
+
+// CheckLeastPrivilege probes the Capabilities of the token and returns the warnings of the capabilities
+// the RequiredCapabilities of the client options do not list.
+func (client *ThreatMatrixClient) CheckLeastPrivilege(ctx context.Context) ([]LeastPrivilegeWarning, error) {
+	report, err := client.Capabilities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return report.LeastPrivilegeWarnings(client.options.RequiredCapabilities...), nil
+}
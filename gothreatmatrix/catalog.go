@@ -0,0 +1,116 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CatalogSnapshot is a point in time copy of the analyzer, connector and playbook catalogs of a ThreatMatrix instance.
+// It is stored as a JSON file so air-gapped clients can work through ThreatMatrixClientOptions.CatalogSnapshotPath.
+type CatalogSnapshot struct {
+	CapturedAt time.Time                  `json:"captured_at"`
+	Analyzers  map[string]AnalyzerConfig  `json:"analyzers"`
+	Connectors map[string]ConnectorConfig `json:"connectors"`
+	Playbooks  map[string]PlaybookConfig  `json:"playbooks"`
+}
+
+// CaptureCatalogSnapshot fetches the current catalogs from your ThreatMatrix instance,
+// ignoring any configured CatalogSnapshotPath.
+//
+// Run it where the config endpoints are reachable and ship the saved file to the restricted environment.
+func (client *ThreatMatrixClient) CaptureCatalogSnapshot(ctx context.Context) (*CatalogSnapshot, error) {
+	analyzers, err := client.AnalyzerService.fetchConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	connectors, err := client.ConnectorService.fetchConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	playbooks, err := client.PlaybookService.fetchConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &CatalogSnapshot{
+		CapturedAt: time.Now().UTC(),
+		Analyzers:  analyzers,
+		Connectors: connectors,
+		Playbooks:  playbooks,
+	}, nil
+}
+
+// LoadCatalogSnapshot reads a catalog snapshot file.
+func LoadCatalogSnapshot(path string) (*CatalogSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := CatalogSnapshot{}
+	if unmarshalError := json.Unmarshal(data, &snapshot); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &snapshot, nil
+}
+
+// Save writes the snapshot to path.
+// The file is replaced atomically, so clients reading it never see a partial snapshot.
+func (snapshot *CatalogSnapshot) Save(path string) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), path)
+}
+
+// catalogSource caches the snapshot file configured in the client options.
+type catalogSource struct {
+	mutex    sync.Mutex
+	path     string
+	modTime  time.Time
+	size     int64
+	snapshot *CatalogSnapshot
+}
+
+// catalogSnapshot returns the configured catalog snapshot, reloading it when the file changed.
+// It returns nil when no CatalogSnapshotPath is configured.
+func (client *ThreatMatrixClient) catalogSnapshot() (*CatalogSnapshot, error) {
+	path := client.options.CatalogSnapshotPath
+	if path == "" || client.catalog == nil {
+		return nil, nil
+	}
+	source := client.catalog
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if source.snapshot != nil && source.path == path && source.modTime.Equal(fileInfo.ModTime()) && source.size == fileInfo.Size() {
+		return source.snapshot, nil
+	}
+	snapshot, err := LoadCatalogSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+	source.path = path
+	source.modTime = fileInfo.ModTime()
+	source.size = fileInfo.Size()
+	source.snapshot = snapshot
+	return snapshot, nil
+}
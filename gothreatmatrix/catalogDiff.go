@@ -0,0 +1,140 @@
+package gothreatmatrix
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// CatalogChange represents a plugin that differs between two catalog snapshots, see DiffCatalogSnapshots.
+type CatalogChange struct {
+	// Type is ANALYZER_PLUGIN, CONNECTOR_PLUGIN or PLAYBOOK_PLUGIN.
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Fields are the JSON fields of the configuration that changed, sorted, e.g. "disabled" or "params".
+	// They are empty for an added or removed plugin.
+	Fields []string `json:"fields,omitempty"`
+	// Params are the parameters added, removed or changed, sorted, when "params" is among the Fields.
+	Params []string `json:"params,omitempty"`
+}
+
+// CatalogDiff represents the differences between two catalog snapshots, the plugins being sorted by type then name.
+type CatalogDiff struct {
+	Added   []CatalogChange `json:"added"`
+	Removed []CatalogChange `json:"removed"`
+	Changed []CatalogChange `json:"changed"`
+}
+
+// IsEmpty reports whether the snapshots have the same catalogs.
+func (diff *CatalogDiff) IsEmpty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+}
+
+// DiffCatalogSnapshots compares the catalogs of two snapshots, e.g. the ones captured before and after an upgrade
+// of the instance: the plugins added, the ones removed and the ones whose configuration changed.
+// The times the snapshots were captured at are not compared.
+func DiffCatalogSnapshots(older *CatalogSnapshot, newer *CatalogSnapshot) (*CatalogDiff, error) {
+	diff := &CatalogDiff{
+		Added:   []CatalogChange{},
+		Removed: []CatalogChange{},
+		Changed: []CatalogChange{},
+	}
+	if err := diffCatalog(diff, ANALYZER_PLUGIN, older.Analyzers, newer.Analyzers); err != nil {
+		return nil, err
+	}
+	if err := diffCatalog(diff, CONNECTOR_PLUGIN, older.Connectors, newer.Connectors); err != nil {
+		return nil, err
+	}
+	if err := diffCatalog(diff, PLAYBOOK_PLUGIN, older.Playbooks, newer.Playbooks); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// diffCatalog adds the differences between the configurations of the plugin type to the diff.
+func diffCatalog[T any](diff *CatalogDiff, pluginType string, older map[string]T, newer map[string]T) error {
+	names := []string{}
+	for name := range older {
+		names = append(names, name)
+	}
+	for name := range newer {
+		if _, ok := older[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		olderConfig, inOlder := older[name]
+		newerConfig, inNewer := newer[name]
+		switch {
+		case !inOlder:
+			diff.Added = append(diff.Added, CatalogChange{Type: pluginType, Name: name})
+		case !inNewer:
+			diff.Removed = append(diff.Removed, CatalogChange{Type: pluginType, Name: name})
+		default:
+			change, err := diffConfig(olderConfig, newerConfig)
+			if err != nil {
+				return err
+			}
+			if len(change.Fields) > 0 {
+				change.Type = pluginType
+				change.Name = name
+				diff.Changed = append(diff.Changed, *change)
+			}
+		}
+	}
+	return nil
+}
+
+// diffConfig compares two configurations of a plugin field by field, as they are encoded in JSON.
+func diffConfig(older interface{}, newer interface{}) (*CatalogChange, error) {
+	olderFields, err := configFields(older)
+	if err != nil {
+		return nil, err
+	}
+	newerFields, err := configFields(newer)
+	if err != nil {
+		return nil, err
+	}
+	change := &CatalogChange{}
+	change.Fields = changedKeys(olderFields, newerFields)
+	for _, field := range change.Fields {
+		if field != "params" {
+			continue
+		}
+		olderParams, _ := olderFields["params"].(map[string]interface{})
+		newerParams, _ := newerFields["params"].(map[string]interface{})
+		change.Params = changedKeys(olderParams, newerParams)
+	}
+	return change, nil
+}
+
+// configFields returns the JSON fields of the configuration.
+func configFields(config interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// changedKeys returns the keys added, removed or whose value changed between the maps, sorted.
+func changedKeys(older map[string]interface{}, newer map[string]interface{}) []string {
+	keys := []string{}
+	for key, olderValue := range older {
+		if newerValue, ok := newer[key]; !ok || !reflect.DeepEqual(olderValue, newerValue) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range newer {
+		if _, ok := older[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,214 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DEFAULT_CIRCUIT_COOLDOWN is how long an open circuit fails fast when CircuitBreaker.Cooldown is 0.
+const DEFAULT_CIRCUIT_COOLDOWN = 30 * time.Second
+
+// ErrCircuitOpen is returned, wrapped with the host, instead of sending a request to a host whose circuit is open.
+var ErrCircuitOpen = errors.New("gothreatmatrix: the circuit of the instance is open")
+
+// CircuitState represents the state of the circuit of a host.
+type CircuitState int
+
+// Values of the CircuitState enum.
+const (
+	// CIRCUIT_CLOSED sends the requests, counting the consecutive failures.
+	CIRCUIT_CLOSED CircuitState = iota
+	// CIRCUIT_OPEN fails the requests fast until the cooldown is over.
+	CIRCUIT_OPEN
+	// CIRCUIT_HALF_OPEN sends one probe request, closing the circuit when it succeeds and opening it again otherwise.
+	CIRCUIT_HALF_OPEN
+)
+
+// Overriding the String method to get the string representation of the CircuitState enum
+func (state CircuitState) String() string {
+	switch state {
+	case CIRCUIT_OPEN:
+		return "OPEN"
+	case CIRCUIT_HALF_OPEN:
+		return "HALF_OPEN"
+	}
+	return "CLOSED"
+}
+
+// CircuitBreaker represents when the client stops sending requests to an unhealthy instance, so that bulk
+// pipelines do not hammer a host that is down. A failure is a request that got no response, or a 5xx one;
+// the other responses, 4xx included, tell the host is up.
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failures of a host opening its circuit, 0 disables the breaker.
+	FailureThreshold int `json:"failure_threshold"`
+	// Cooldown is how long an open circuit fails fast before a probe request is sent, DEFAULT_CIRCUIT_COOLDOWN when 0.
+	Cooldown time.Duration `json:"cooldown"`
+	// OnStateChange, when set, is called whenever the circuit of a host changes state.
+	OnStateChange func(host string, from CircuitState, to CircuitState) `json:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, Cooldown also reading a duration string, e.g. "30s".
+func (circuitBreaker *CircuitBreaker) UnmarshalJSON(data []byte) error {
+	type plainCircuitBreaker CircuitBreaker
+	return unmarshalWithDurations(data, (*plainCircuitBreaker)(circuitBreaker))
+}
+
+// hostCircuit is the state of the circuit of a host.
+type hostCircuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// circuitBreaker holds the circuits of the hosts the client talks to, shared by every service and copy of the client.
+type circuitBreaker struct {
+	mutex    sync.Mutex
+	settings *CircuitBreaker
+	cooldown time.Duration
+	circuits map[string]*hostCircuit
+}
+
+// newCircuitBreaker returns the breaker of the settings, nil when it is disabled.
+func newCircuitBreaker(settings *CircuitBreaker) *circuitBreaker {
+	if settings == nil || settings.FailureThreshold <= 0 {
+		return nil
+	}
+	cooldown := settings.Cooldown
+	if cooldown <= 0 {
+		cooldown = DEFAULT_CIRCUIT_COOLDOWN
+	}
+	return &circuitBreaker{
+		settings: settings,
+		cooldown: cooldown,
+		circuits: map[string]*hostCircuit{},
+	}
+}
+
+// stateChange is a change of state of the circuit of a host, reported once the lock is released.
+type stateChange struct {
+	host     string
+	from, to CircuitState
+}
+
+// report calls OnStateChange with the change, if any.
+func (breaker *circuitBreaker) report(change *stateChange) {
+	if change != nil && breaker.settings.OnStateChange != nil {
+		breaker.settings.OnStateChange(change.host, change.from, change.to)
+	}
+}
+
+// setState moves the circuit to the state, returning the change.
+func (circuit *hostCircuit) setState(host string, state CircuitState) *stateChange {
+	if circuit.state == state {
+		return nil
+	}
+	change := &stateChange{host: host, from: circuit.state, to: state}
+	circuit.state = state
+	return change
+}
+
+// allow reports whether a request may be sent to the host, an error wrapping ErrCircuitOpen otherwise.
+// Once the cooldown of an open circuit is over, only one request is let through as the probe.
+func (breaker *circuitBreaker) allow(host string) error {
+	breaker.mutex.Lock()
+	circuit, ok := breaker.circuits[host]
+	if !ok {
+		breaker.mutex.Unlock()
+		return nil
+	}
+	var change *stateChange
+	var err error
+	switch circuit.state {
+	case CIRCUIT_OPEN:
+		if retryAt := circuit.openedAt.Add(breaker.cooldown); time.Now().Before(retryAt) {
+			err = fmt.Errorf("%w: %s, retrying after %s", ErrCircuitOpen, host, retryAt.Format(time.RFC3339))
+		} else {
+			change = circuit.setState(host, CIRCUIT_HALF_OPEN)
+			circuit.probing = true
+		}
+	case CIRCUIT_HALF_OPEN:
+		if circuit.probing {
+			err = fmt.Errorf("%w: %s, probing it", ErrCircuitOpen, host)
+		} else {
+			circuit.probing = true
+		}
+	}
+	breaker.mutex.Unlock()
+	breaker.report(change)
+	return err
+}
+
+// record counts the outcome of a request sent to the host.
+func (breaker *circuitBreaker) record(host string, failed bool) {
+	breaker.mutex.Lock()
+	circuit, ok := breaker.circuits[host]
+	if !ok {
+		if !failed {
+			breaker.mutex.Unlock()
+			return
+		}
+		circuit = &hostCircuit{}
+		breaker.circuits[host] = circuit
+	}
+	var change *stateChange
+	circuit.probing = false
+	switch {
+	case !failed:
+		circuit.failures = 0
+		change = circuit.setState(host, CIRCUIT_CLOSED)
+	case circuit.state == CIRCUIT_HALF_OPEN:
+		circuit.openedAt = time.Now()
+		change = circuit.setState(host, CIRCUIT_OPEN)
+	case circuit.state == CIRCUIT_CLOSED:
+		circuit.failures++
+		if circuit.failures >= breaker.settings.FailureThreshold {
+			circuit.openedAt = time.Now()
+			change = circuit.setState(host, CIRCUIT_OPEN)
+		}
+	}
+	breaker.mutex.Unlock()
+	breaker.report(change)
+}
+
+// release lets another request probe the host, the probe having been canceled before telling whether the host is up.
+func (breaker *circuitBreaker) release(host string) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	if circuit, ok := breaker.circuits[host]; ok {
+		circuit.probing = false
+	}
+}
+
+// state returns the state of the circuit of the host.
+func (breaker *circuitBreaker) state(host string) CircuitState {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	if circuit, ok := breaker.circuits[host]; ok {
+		return circuit.state
+	}
+	return CIRCUIT_CLOSED
+}
+
+// done records the outcome of the request sent to the host: a failure when it got no response or a 5xx one.
+// A canceled request tells nothing about the host.
+func (breaker *circuitBreaker) done(host string, response *http.Response, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		breaker.release(host)
+		return
+	}
+	breaker.record(host, response == nil || response.StatusCode >= http.StatusInternalServerError)
+}
+
+// CircuitState returns the state of the circuit of the host, as written in the URL of the instance, e.g.
+// "threatmatrix.example.com" or "localhost:8000". It is CIRCUIT_CLOSED when the client has no CircuitBreaker.
+func (client *ThreatMatrixClient) CircuitState(host string) CircuitState {
+	if client.breaker == nil {
+		return CIRCUIT_CLOSED
+	}
+	return client.breaker.state(host)
+}
@@ -0,0 +1,98 @@
+package gothreatmatrix
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GENERIC_CLASSIFICATION is the observable classification ThreatMatrix gives to values that are not an ip, url, domain or hash.
+const GENERIC_CLASSIFICATION = "generic"
+
+// CustomClassification represents a user-defined kind of observable (e.g. CVEs, email addresses, phone numbers).
+// ThreatMatrix analyzes them as generic observables: the client recognizes the values and requests the analyzers
+// mapped to the classification, see ThreatMatrixClientOptions.CustomClassifications.
+type CustomClassification struct {
+	// Name is the classification, e.g. "cve". It can be used as ObservableAnalysisParams.ObservableClassification
+	// and as a ThreatMatrixClientOptions.DefaultPlaybooks key.
+	Name string
+	// Pattern and Match recognize the values of the classification, a value matching either of them belongs to it.
+	Pattern *regexp.Regexp
+	Match   func(value string) bool
+	// Analyzers are requested for the observables of the classification when the analysis requests
+	// neither a playbook nor analyzers, Playbook when there are no Analyzers.
+	Analyzers []string
+	Playbook  string
+	// Tags are added to the analyses of the observables of the classification.
+	Tags []string
+}
+
+// Ready-made custom classifications, copy them to map them to your analyzers.
+var (
+	CVE_CLASSIFICATION   = CustomClassification{Name: "cve", Pattern: regexp.MustCompile(`^(?i)CVE-\d{4}-\d{4,}$`)}
+	EMAIL_CLASSIFICATION = CustomClassification{Name: "email", Pattern: regexp.MustCompile(`^[A-Za-z0-9._%+\-]+@(?:[A-Za-z0-9](?:[A-Za-z0-9\-]{0,61}[A-Za-z0-9])?\.)+[A-Za-z]{2,63}$`)}
+	PHONE_CLASSIFICATION = CustomClassification{Name: "phone", Pattern: regexp.MustCompile(`^\+[1-9][0-9 ().\-]{6,20}[0-9]$`)}
+)
+
+// matches reports whether the value belongs to the classification.
+func (classification *CustomClassification) matches(value string) bool {
+	if classification.Pattern != nil && classification.Pattern.MatchString(value) {
+		return true
+	}
+	return classification.Match != nil && classification.Match(value)
+}
+
+// ClassifyObservable returns the classification of the value: one of the custom classifications of the client,
+// checked in order, then ip, url, domain or hash, and GENERIC_CLASSIFICATION when it is none of them.
+func (client *ThreatMatrixClient) ClassifyObservable(value string) string {
+	if classification := client.matchCustomClassification(value); classification != "" {
+		return classification
+	}
+	if classification := ClassifyObservable(value); classification != "" {
+		return classification
+	}
+	return GENERIC_CLASSIFICATION
+}
+
+// matchCustomClassification returns the name of the first custom classification of the client matching the value,
+// an empty string when none does.
+func (client *ThreatMatrixClient) matchCustomClassification(value string) string {
+	value = strings.TrimSpace(value)
+	for index := range client.options.CustomClassifications {
+		if classification := &client.options.CustomClassifications[index]; classification.matches(value) {
+			return classification.Name
+		}
+	}
+	return ""
+}
+
+// customClassification returns the custom classification of the client with that name, nil when there is none.
+func (client *ThreatMatrixClient) customClassification(name string) *CustomClassification {
+	for index := range client.options.CustomClassifications {
+		if classification := &client.options.CustomClassifications[index]; classification.Name == name {
+			return classification
+		}
+	}
+	return nil
+}
+
+// applyClassification fills the analysis with the defaults of the custom classification
+// and returns the classification sent to ThreatMatrix, GENERIC_CLASSIFICATION for a custom one.
+func (client *ThreatMatrixClient) applyClassification(basicAnalysisParams *BasicAnalysisParams, name string) string {
+	classification := client.customClassification(name)
+	if classification == nil {
+		return name
+	}
+	if !client.applyDefaultPlaybook(basicAnalysisParams, name) && basicAnalysisParams.PlaybookRequested == "" && len(basicAnalysisParams.AnalyzersRequested) == 0 {
+		if len(classification.Analyzers) > 0 {
+			basicAnalysisParams.AnalyzersRequested = append([]string{}, classification.Analyzers...)
+		} else {
+			basicAnalysisParams.PlaybookRequested = classification.Playbook
+		}
+	}
+	for _, tag := range classification.Tags {
+		if !containsValue(basicAnalysisParams.TagsLabels, tag) {
+			basicAnalysisParams.TagsLabels = append(basicAnalysisParams.TagsLabels, tag)
+		}
+	}
+	return GENERIC_CLASSIFICATION
+}
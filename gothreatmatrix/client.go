@@ -7,6 +7,7 @@ package gothreatmatrix
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -16,13 +17,31 @@ import (
 	"time"
 )
 
+// REQUEST_ID_HEADER is the header of the request ID, sent with the requests, see WithRequestID, and read back
+// from the responses into ThreatMatrixError.RequestID and ResponseInfo.RequestID.
+const REQUEST_ID_HEADER = "X-Request-ID"
+
 // ThreatMatrixError represents an error that has occurred when communicating with ThreatMatrix.
 type ThreatMatrixError struct {
 	StatusCode int
 	Message    string
-	Response   *http.Response
+	// Detail and Errors are decoded from Message when it is a ThreatMatrix error body: Detail is set by the
+	// authentication, permission and not found errors, Errors holds the "errors" field of the body or,
+	// for the 400 validation errors of a serializer, the body itself, e.g. a map from each invalid field to its messages.
+	Detail string
+	Errors interface{}
+	// RequestID identifies the request in the logs of the instance: the REQUEST_ID_HEADER of the response, the one
+	// of the request when the instance does not echo it.
+	RequestID string
+	Response  *http.Response
 }
 
+// APIError is the name the typed error is usually looked up with, e.g.
+//
+//	var apiError *gothreatmatrix.APIError
+//	if errors.As(err, &apiError) && apiError.StatusCode == http.StatusConflict { ... }
+type APIError = ThreatMatrixError
+
 // Error lets you implement the error interface.
 // This is used for making custom go errors.
 func (threatMatrixError *ThreatMatrixError) Error() string {
@@ -32,11 +51,53 @@ func (threatMatrixError *ThreatMatrixError) Error() string {
 
 // newThreatMatrixError lets you easily create new ThreatMatrixErrors.
 func newThreatMatrixError(statusCode int, message string, response *http.Response) *ThreatMatrixError {
-	return &ThreatMatrixError{
+	threatMatrixError := &ThreatMatrixError{
 		StatusCode: statusCode,
 		Message:    message,
 		Response:   response,
 	}
+	threatMatrixError.RequestID = requestIdOf(nil, response)
+	errorBody := map[string]interface{}{}
+	if !strings.HasPrefix(strings.TrimSpace(message), "{") || json.Unmarshal([]byte(message), &errorBody) != nil {
+		return threatMatrixError
+	}
+	threatMatrixError.Detail, _ = errorBody["detail"].(string)
+	if fieldErrors, ok := errorBody["errors"]; ok {
+		threatMatrixError.Errors = fieldErrors
+	} else if _, ok := errorBody["detail"]; !ok && statusCode == http.StatusBadRequest {
+		// * the validation errors of a serializer are the body itself
+		threatMatrixError.Errors = errorBody
+	}
+	return threatMatrixError
+}
+
+// hasStatusCode reports whether err is, or wraps, a ThreatMatrixError with one of the status codes.
+func hasStatusCode(err error, statusCodes ...int) bool {
+	var threatMatrixError *ThreatMatrixError
+	if !errors.As(err, &threatMatrixError) {
+		return false
+	}
+	for _, statusCode := range statusCodes {
+		if threatMatrixError.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound reports whether err is ThreatMatrix answering that the requested resource does not exist.
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err is ThreatMatrix rejecting the token, missing or invalid.
+func IsUnauthorized(err error) bool {
+	return hasStatusCode(err, http.StatusUnauthorized)
+}
+
+// IsRateLimited reports whether err is ThreatMatrix, or its proxy, throttling the client.
+func IsRateLimited(err error) bool {
+	return hasStatusCode(err, http.StatusTooManyRequests)
 }
 
 type successResponse struct {
@@ -48,22 +109,186 @@ type successResponse struct {
 type ThreatMatrixClientOptions struct {
 	Url   string `json:"url"`
 	Token string `json:"token"`
+	// AuthProvider, when set, authenticates the requests instead of Token, e.g. a JWTSessionAuth or a
+	// ClientCertificateAuth. The requests are sent with the static Token otherwise.
+	AuthProvider AuthProvider `json:"-"`
 	// Certificate represents your SSL cert: path to the cert file!
+	// It is a PEM bundle of CA certificates trusted on top of the system ones.
 	Certificate string `json:"certificate"`
+	// InsecureSkipVerify accepts any certificate of the server, e.g. a lab instance with a self-signed one.
+	// PinnedSPKIHashes are still checked, trusting such a certificate by its pin being the safer way.
+	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+	// MinTLSVersion is the lowest TLS version accepted: 1.0, 1.1, 1.2 or 1.3.
+	MinTLSVersion string `json:"min_tls_version"`
+	// CipherSuites restricts the TLS 1.0-1.2 cipher suites to these names, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+	CipherSuites []string `json:"cipher_suites"`
+	// PinnedSPKIHashes, when not empty, only accepts servers presenting a certificate whose SPKIHash is listed.
+	PinnedSPKIHashes []string `json:"pinned_spki_hashes"`
+	// ProxyUrl is the proxy every request goes through: http://, https://, socks5:// or socks5h:// (resolving names on the proxy).
+	ProxyUrl string `json:"proxy_url"`
+	// ProxyRules maps a host, or "*.example.com" for its subdomains, to the proxy used for it instead of ProxyUrl.
+	// PROXY_DIRECT sends the host without a proxy, e.g. only route the ThreatMatrix host through a jump host
+	// with ProxyRules alone. Without ProxyUrl and ProxyRules the proxy environment variables are used.
+	ProxyRules map[string]string `json:"proxy_rules"`
+	// Transport, when set, is the http.RoundTripper of the http.Client built by NewThreatMatrixClient,
+	// e.g. a corporate one. The TLS and proxy options above are then not applied.
+	Transport http.RoundTripper `json:"-"`
 	// Timeout is in seconds
 	Timeout uint64 `json:"timeout"`
+	// MaxIdleConnsPerHost is the number of idle connections to the instance kept for reuse. When 0, it is
+	// MaxConcurrentRequests when set, the http.Transport default of 2 otherwise, so that concurrent submitters
+	// do not keep opening sockets once more than 2 requests are in flight.
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host"`
+	// MaxConnsPerHost, when above 0, caps the connections to the instance, the requests beyond it waiting for one.
+	MaxConnsPerHost int `json:"max_conns_per_host"`
+	// IdleConnTimeout, in seconds, closes the connections idle for longer, the http.Transport default of 90 when 0.
+	IdleConnTimeout uint64 `json:"idle_conn_timeout"`
+	// CompatibilityMode lets you talk to older ThreatMatrix/IntelOwl servers, see NegotiateCompatibility
+	CompatibilityMode CompatibilityMode `json:"compatibility_mode"`
+	// AutoNegotiate, when true, runs NegotiateCompatibility in NewThreatMatrixClient, so that the client talks to
+	// the API generation of the instance whatever its CompatibilityMode, and knows its ServerVersion from the start.
+	// A failed probe, e.g. the instance being down, is run again before the next call of the client.
+	AutoNegotiate bool `json:"auto_negotiate"`
+	// ServerVersion, e.g. "v5.2.0", is reported in the FeatureUnavailableErrors of the instance until
+	// NegotiateCompatibility learns the actual one, see ThreatMatrixClient.ServerVersion.
+	ServerVersion string `json:"server_version"`
+	// DisableCompression stops the client from asking for gzip responses and from compressing its requests.
+	// The gzip responses are decompressed by the client otherwise, the large configuration payloads being much smaller so.
+	DisableCompression bool `json:"disable_compression"`
+	// GzipRequestsAbove, in bytes, gzips the JSON request bodies larger than it, e.g. bulk submissions.
+	// 0 never compresses them, the instance having to accept a gzip Content-Encoding.
+	GzipRequestsAbove int64 `json:"gzip_requests_above"`
+	// UploadBytesPerSecond limits the bandwidth used when uploading files, 0 means unlimited.
+	// The uploads are streamed without the Timeout, so a throttled upload can last longer than it.
+	UploadBytesPerSecond int64 `json:"upload_bytes_per_second"`
+	// DefaultPlaybooks maps an observable classification (ip, url, domain, hash, generic) or a file mime type
+	// to the playbook used when an analysis requests neither a playbook nor analyzers.
+	// The "file" key is the fallback for every file mime type.
+	DefaultPlaybooks map[string]string `json:"default_playbooks"`
+	// FileTypePlaybooks routes the files to a playbook by their type as told by DetectFileType (FILE_TYPE_PE,
+	// FILE_TYPE_OFFICE, FILE_TYPE_APK...), e.g. executables to a sandbox-heavy playbook and documents to a macro-focused one.
+	// It applies to the file analyses requesting neither a playbook nor analyzers and wins over DefaultPlaybooks.
+	FileTypePlaybooks map[string]string `json:"file_type_playbooks"`
+	// CustomClassifications are the user-defined kinds of observable the client recognizes and maps to analyzers.
+	CustomClassifications []CustomClassification `json:"-"`
+	// AnalyzersAllowed, when not empty, is the only set of analyzers this client will ever submit.
+	AnalyzersAllowed []string `json:"analyzers_allowed"`
+	// AnalyzersDenied are analyzers (e.g. ones that leak data or cost money) this client will never submit.
+	AnalyzersDenied []string `json:"analyzers_denied"`
+	// TLPEnforcement, when not TLP_ENFORCEMENT_OFF, keeps the analyses at AMBER or RED away from the analyzers
+	// calling an external service or leaking info, the way ThreatMatrix does on its side.
+	TLPEnforcement TLPEnforcement `json:"tlp_enforcement"`
+	// DryRun, when true, sends the requests reading the instance only: the ones that would change it (analyses,
+	// kills, deletions, tag creations...) are recorded in the DryRunLog of the client instead, and answered as
+	// successful with an empty result, so that orchestration playbooks can be validated safely.
+	DryRun bool `json:"dry_run"`
+	// CatalogSnapshotPath, when set, is a catalog snapshot file (see CaptureCatalogSnapshot) that the configuration calls
+	// read from instead of the ThreatMatrix instance, for environments where the config endpoints are restricted.
+	// The file is read again whenever it changes, so it can be refreshed out-of-band.
+	CatalogSnapshotPath string `json:"catalog_snapshot_path"`
+	// AuditTrail, when set, records every submission made through the client, see NewAuditTrail.
+	AuditTrail *AuditTrail `json:"-"`
+	// PIIGuard, when set, scans observables and file names for likely PII before they are submitted.
+	PIIGuard *PIIGuard `json:"-"`
+	// ReuseRecentResults, when true, returns the job of a recent identical analysis instead of submitting a new one.
+	// The window is the scan_check_time of the requested playbook, DedupWindowMinutes when no playbook is requested.
+	// Set BasicAnalysisParams.ForceFreshScan to always submit a new analysis.
+	ReuseRecentResults bool `json:"reuse_recent_results"`
+	DedupWindowMinutes int  `json:"dedup_window_minutes"`
+	// Canonicalizer, when set, decides which observables are the same entity when reusing recent results,
+	// DefaultCanonicalizer is used otherwise.
+	Canonicalizer IndicatorCanonicalizer `json:"-"`
+	// GenerateIdempotencyKeys, when true, sends a random idempotency key with the submissions that have none, see
+	// BasicAnalysisParams.IdempotencyKey, so that the RetryOptions retry them without creating a duplicate job.
+	GenerateIdempotencyKeys bool `json:"generate_idempotency_keys"`
+	// DedupeInFlightSubmissions, when true, makes a CreateObservableAnalysis or CreateFileAnalysis identical to one
+	// still being submitted wait for it and share its response instead of creating another job. The files are
+	// told apart by their md5, read once more for every file submission.
+	DedupeInFlightSubmissions bool `json:"dedupe_in_flight_submissions"`
+	// RetryOptions, when set, retries the requests that failed on a network error or a 429/5xx response,
+	// waiting as long as their Retry-After header asks.
+	RetryOptions *RetryOptions `json:"retry_options"`
+	// Hedging, when set, sends a second attempt of the GET requests still waiting for a response after a delay,
+	// the first successful response being used and the other attempt canceled, for latency-sensitive dashboards.
+	Hedging *HedgingOptions `json:"hedging"`
+	// EnableTelemetry turns the reporting of every API call to Telemetry on, so it can be toggled from a JSON file.
+	EnableTelemetry bool `json:"enable_telemetry"`
+	// Telemetry receives a span and a request record for every API call, e.g. through an OpenTelemetry adapter
+	// or a RequestMetrics.
+	Telemetry Telemetry `json:"-"`
+	// RateLimit, when set, limits the rate of the requests of every service, retries included.
+	RateLimit *RateLimit `json:"rate_limit"`
+	// CircuitBreaker, when set, fails the requests fast for a while once a host failed too many times in a row.
+	CircuitBreaker *CircuitBreaker `json:"circuit_breaker"`
+	// MaxConcurrentRequests, when above 0, caps the requests of the client in flight at once, retries and
+	// background work (watchers, bulk submissions, monitors) included.
+	MaxConcurrentRequests int `json:"max_concurrent_requests"`
+	// ConnectionBudget, when set, is shared with the other clients using it and overrides MaxConcurrentRequests,
+	// see NewConnectionBudget.
+	ConnectionBudget *ConnectionBudget `json:"-"`
+	// Logger, when set, receives the debug logs of every request, response and retry, e.g. a *slog.Logger.
+	Logger Logger `json:"-"`
+	// VerifyUploads, when true, fetches the job of every file analysis right after its creation and compares the
+	// hashes the server computed with the local file, see CreateFileAnalysis.
+	VerifyUploads bool `json:"verify_uploads"`
+	// ResubmitCorruptUploads, when true with VerifyUploads, submits a file once more when its upload did not match.
+	ResubmitCorruptUploads bool `json:"resubmit_corrupt_uploads"`
+	// OnDeprecation, when set, receives a DeprecationNotice whenever a catalog the client reads marks a plugin
+	// as deprecated, or no longer lists a plugin it listed before.
+	OnDeprecation func(notice DeprecationNotice) `json:"-"`
+	// RequiredCapabilities are the capabilities the application needs, e.g. only CAPABILITY_READ for read-only
+	// enrichment, the others being reported by CheckLeastPrivilege.
+	RequiredCapabilities []Capability `json:"required_capabilities"`
+	// ConfigCacheTTL, in seconds, caches the plugin configurations read from the instance: they are served from
+	// memory for that long, then revalidated with the ETag or Last-Modified of their last response. 0 disables the cache,
+	// and the ForceRefresh call option bypasses it.
+	ConfigCacheTTL uint64 `json:"config_cache_ttl"`
+	// CorrelationHeaders are sent with every request, e.g. the ID of the tenant or of the deployment, so that the
+	// logs of the instance can be matched against the ones of the caller.
+	CorrelationHeaders map[string]string `json:"correlation_headers"`
+	// GenerateRequestIDs, when true, sends a random REQUEST_ID_HEADER with the requests whose context carries none,
+	// see WithRequestID, so that every failure can be matched against the logs of the instance.
+	GenerateRequestIDs bool `json:"generate_request_ids"`
 }
 
 // ThreatMatrixClient handles all the communication with your ThreatMatrix instance.
+//
+// A client is safe for concurrent use by multiple goroutines, copies of it included: the state it changes
+// while in use (the negotiated CompatibilityMode, the middlewares, the rate limit, the circuits, the catalog snapshot, the configuration cache and
+// the recent analyses) is shared behind locks. The ThreatMatrixClientOptions are copied when the client is built, so changing
+// their fields afterwards does not affect it; the maps and slices they hold must not be modified though.
+// The connections are pooled by the transport the client builds, see MaxIdleConnsPerHost and MaxConnsPerHost.
 type ThreatMatrixClient struct {
-	options          *ThreatMatrixClientOptions
-	client           *http.Client
-	TagService       *TagService
-	JobService       *JobService
-	AnalyzerService  *AnalyzerService
-	ConnectorService *ConnectorService
-	UserService      *UserService
-	Logger           *ThreatMatrixLogger
+	options              *ThreatMatrixClientOptions
+	client               *http.Client
+	TagService           *TagService
+	JobService           *JobService
+	AnalyzerService      *AnalyzerService
+	ConnectorService     *ConnectorService
+	UserService          *UserService
+	CommentService       *CommentService
+	InvestigationService *InvestigationService
+	PlaybookService      *PlaybookService
+	VisualizerService    *VisualizerService
+	PivotService         *PivotService
+	IngestorService      *IngestorService
+	StatisticsService    *StatisticsService
+	PluginConfigService  *PluginConfigService
+	DataModelService     *DataModelService
+	NotificationService  *NotificationService
+	SearchService        *SearchService
+	catalog              *catalogSource
+	recent               *recentAnalyses
+	inFlight             *inFlightSubmissions
+	middleware           *middlewareChain
+	compatibility        *negotiatedCompatibility
+	limiter              *rateLimiter
+	breaker              *circuitBreaker
+	budget               *ConnectionBudget
+	deprecations         *deprecationTracker
+	configCache          *configCache
+	dryRun               *DryRunLog
+	Logger               *ThreatMatrixLogger
 }
 
 // TLP represents an enum for the TLP attribute used in ThreatMatrix's REST API.
@@ -79,8 +304,12 @@ const (
 	RED
 )
 
+// CLEAR is the TLP 2.0 name of WHITE, it is sent as WHITE.
+const CLEAR = WHITE
+
 // TLPVALUES represents a map to easily access the TLP values.
 var TLPVALUES = map[string]int{
+	"CLEAR": 1,
 	"WHITE": 1,
 	"GREEN": 2,
 	"AMBER": 3,
@@ -132,6 +361,10 @@ func (tlp *TLP) UnmarshalJSON(data []byte) (err error) {
 // NewThreatMatrixClient lets you easily create a new ThreatMatrixClient by providing ThreatMatrixClientOptions, http.Clients, and LoggerParams.
 func NewThreatMatrixClient(options *ThreatMatrixClientOptions, httpClient *http.Client, loggerParams *LoggerParams) ThreatMatrixClient {
 
+	// copying the options, the goroutines using the client read them without locks
+	clientOptions := *options
+	options = &clientOptions
+
 	var timeout time.Duration
 
 	if options.Timeout == 0 {
@@ -140,17 +373,34 @@ func NewThreatMatrixClient(options *ThreatMatrixClientOptions, httpClient *http.
 		timeout = time.Duration(options.Timeout) * time.Second
 	}
 
-	// configuring the http.Client
+	// configuring the http.Client, the Transport, TLS and proxy options only apply to the client built here
 	if httpClient == nil {
 		httpClient = &http.Client{
-			Timeout: timeout,
+			Timeout:   timeout,
+			Transport: newTransport(options),
 		}
 	}
 
 	// configuring the client
 	client := ThreatMatrixClient{
-		options: options,
-		client:  httpClient,
+		options:       options,
+		client:        httpClient,
+		catalog:       &catalogSource{},
+		recent:        &recentAnalyses{},
+		inFlight:      &inFlightSubmissions{},
+		middleware:    &middlewareChain{},
+		compatibility: &negotiatedCompatibility{},
+		limiter:       newRateLimiter(options.RateLimit),
+		breaker:       newCircuitBreaker(options.CircuitBreaker),
+		budget:        newClientBudget(options),
+		deprecations:  &deprecationTracker{},
+		configCache:   &configCache{},
+	}
+	if options.DryRun {
+		client.dryRun = &DryRunLog{}
+	}
+	if binder, ok := options.AuthProvider.(authBinder); ok {
+		binder.bind(&client)
 	}
 
 	// Adding the services
@@ -169,11 +419,47 @@ func NewThreatMatrixClient(options *ThreatMatrixClientOptions, httpClient *http.
 	client.UserService = &UserService{
 		client: &client,
 	}
+	client.CommentService = &CommentService{
+		client: &client,
+	}
+	client.InvestigationService = &InvestigationService{
+		client: &client,
+	}
+	client.PlaybookService = &PlaybookService{
+		client: &client,
+	}
+	client.VisualizerService = &VisualizerService{
+		client: &client,
+	}
+	client.PivotService = &PivotService{
+		client: &client,
+	}
+	client.IngestorService = &IngestorService{
+		client: &client,
+	}
+	client.StatisticsService = &StatisticsService{
+		client: &client,
+	}
+	client.PluginConfigService = &PluginConfigService{
+		client: &client,
+	}
+	client.DataModelService = &DataModelService{
+		client: &client,
+	}
+	client.NotificationService = &NotificationService{
+		client: &client,
+	}
+	client.SearchService = &SearchService{
+		client: &client,
+	}
 
 	// configuring the logger!
 	client.Logger = &ThreatMatrixLogger{}
 	client.Logger.Init(loggerParams)
 
+	// * bounded by the Timeout of the http.Client, the probes being regular requests
+	client.autoNegotiate(context.Background())
+
 	return client
 }
 
@@ -198,21 +484,52 @@ func NewThreatMatrixClientThroughJsonFile(filePath string, httpClient *http.Clie
 
 // buildRequest is used for building requests.
 func (client *ThreatMatrixClient) buildRequest(ctx context.Context, method string, contentType string, body io.Reader, url string) (*http.Request, error) {
+	client.autoNegotiate(ctx)
+	if profile := client.profile(); profile != nil {
+		route := strings.TrimPrefix(url, client.options.Url)
+		if index := strings.Index(route, "?"); index >= 0 {
+			route = route[:index]
+		}
+		url = profile.translateUrl(client.options.Url, url)
+		if body != nil && contentType == "application/json" {
+			translatedBody, err := profile.translateBody(route, body)
+			if err != nil {
+				return nil, err
+			}
+			body = translatedBody
+		}
+	}
+	compressed := false
+	if contentType == "application/json" {
+		compressedBody, ok, err := client.compressBody(body)
+		if err != nil {
+			return nil, err
+		}
+		body, compressed = compressedBody, ok
+	}
 	request, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
 	request.Header.Set("Content-Type", contentType)
+	if compressed {
+		request.Header.Set("Content-Encoding", "gzip")
+	}
 
-	tokenString := fmt.Sprintf("token %s", client.options.Token)
-
-	request.Header.Set("Authorization", tokenString)
+	if err := client.authProvider().Authenticate(ctx, request); err != nil {
+		return nil, err
+	}
+	setMetadataHeaders(ctx, request.Header)
+	if err := client.setCorrelationHeaders(ctx, request); err != nil {
+		return nil, err
+	}
+	applyCallHeaders(ctx, request)
 	return request, nil
 }
 
-// newRequest is used for making requests.
-func (client *ThreatMatrixClient) newRequest(ctx context.Context, request *http.Request) (*successResponse, error) {
-	response, err := client.client.Do(request)
+// sendRequest sends the request once.
+func (client *ThreatMatrixClient) sendRequest(ctx context.Context, request *http.Request) (*successResponse, error) {
+	response, err := client.do(request)
 
 	// Checking for context errors such as reaching the deadline and/or Timeout
 	if err != nil {
@@ -240,6 +557,10 @@ func (client *ThreatMatrixClient) newRequest(ctx context.Context, request *http.
 		return nil, threatMatrixError
 	}
 
+	if profile := client.profile(); profile != nil && strings.HasPrefix(response.Header.Get("Content-Type"), "application/json") {
+		msgBytes = profile.translateResponse(response.Request.URL.Path, msgBytes)
+	}
+
 	sucessResp := successResponse{
 		StatusCode: statusCode,
 		Data:       msgBytes,
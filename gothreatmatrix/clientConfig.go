@@ -0,0 +1,266 @@
+package gothreatmatrix
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ENV_PREFIX prefixes the environment variables read by NewThreatMatrixClientFromEnv, e.g. THREATMATRIX_URL.
+const ENV_PREFIX = "THREATMATRIX_"
+
+// ErrMissingUrl is returned by the constructors reading their configuration when it sets no URL.
+var ErrMissingUrl = errors.New("gothreatmatrix: the configuration sets no URL of the instance")
+
+// NewThreatMatrixClientFromEnv lets you create a new ThreatMatrixClient configured by environment variables,
+// the way the Python client bootstraps: every field of ThreatMatrixClientOptions with a JSON name can be set by
+// ENV_PREFIX followed by that name in upper case, e.g. THREATMATRIX_URL, THREATMATRIX_TOKEN,
+// THREATMATRIX_CERTIFICATE, THREATMATRIX_TIMEOUT (in seconds) or THREATMATRIX_PROXY_URL.
+// Lists are comma separated, e.g. THREATMATRIX_ANALYZERS_DENIED=Shodan_Search,VirusTotal_v3.
+// The options that are neither a scalar nor a list (RetryOptions, RateLimit, ProxyRules...) are only read from a JSON or YAML file.
+func NewThreatMatrixClientFromEnv(httpClient *http.Client, loggerParams *LoggerParams) (*ThreatMatrixClient, error) {
+	options := &ThreatMatrixClientOptions{}
+	if err := optionsFromEnv(options, os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if options.Url == "" {
+		return nil, fmt.Errorf("%w: set %sURL", ErrMissingUrl, ENV_PREFIX)
+	}
+	client := NewThreatMatrixClient(options, httpClient, loggerParams)
+	return &client, nil
+}
+
+// NewThreatMatrixClientFromFile lets you create a new ThreatMatrixClient through a configuration file, told apart
+// by its extension: a JSON file is read like NewThreatMatrixClientThroughJsonFile does, a .yaml, .yml or .toml file
+// sets the options by their JSON names, e.g.
+//
+//	url: https://threatmatrix.example.com
+//	token: "..."
+//	timeout: 30
+//	analyzers_denied: [Shodan_Search, VirusTotal_v3]
+//	retry_options:
+//	  max_retries: 3
+//
+// A YAML file can set every option a JSON one can, nested ones included, an unknown option being an error.
+// Only the flat subset of TOML is read: scalars and lists of scalars, no table.
+// The environment variables of NewThreatMatrixClientFromEnv override the values of the file.
+func NewThreatMatrixClientFromFile(filePath string, httpClient *http.Client, loggerParams *LoggerParams) (*ThreatMatrixClient, error) {
+	options := &ThreatMatrixClientOptions{}
+	switch extension := strings.ToLower(filepath.Ext(filePath)); extension {
+	case ".json":
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, options); err != nil {
+			return nil, fmt.Errorf("invalid configuration %s: %w", filePath, err)
+		}
+	case ".yaml", ".yml":
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := unmarshalYamlStrict(data, options); err != nil {
+			return nil, fmt.Errorf("invalid configuration %s: %w", filePath, err)
+		}
+	case ".toml":
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		values, err := parseFlatToml(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration %s: %w", filePath, err)
+		}
+		for name, value := range values {
+			if err := setOption(options, name, value); err != nil {
+				return nil, fmt.Errorf("invalid configuration %s: %w", filePath, err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown configuration format %q, expected .json, .yaml, .yml or .toml", extension)
+	}
+	if err := optionsFromEnv(options, os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if options.Url == "" {
+		return nil, fmt.Errorf("%w: set url in %s", ErrMissingUrl, filePath)
+	}
+	client := NewThreatMatrixClient(options, httpClient, loggerParams)
+	return &client, nil
+}
+
+// optionsFromEnv sets the options from the environment variables named after their JSON names.
+func optionsFromEnv(options *ThreatMatrixClientOptions, lookupEnv func(key string) (string, bool)) error {
+	optionsType := reflect.TypeOf(*options)
+	for index := 0; index < optionsType.NumField(); index++ {
+		name := optionName(optionsType.Field(index))
+		if name == "" {
+			continue
+		}
+		value, ok := lookupEnv(ENV_PREFIX + strings.ToUpper(name))
+		if !ok {
+			continue
+		}
+		configValue := []string{value}
+		if optionsType.Field(index).Type.Kind() == reflect.Slice {
+			configValue = splitConfigList(value)
+		}
+		if err := setOption(options, name, configValue); err != nil {
+			return fmt.Errorf("invalid %s%s: %w", ENV_PREFIX, strings.ToUpper(name), err)
+		}
+	}
+	return nil
+}
+
+// optionName returns the JSON name of the field of ThreatMatrixClientOptions, empty when it is not configurable.
+func optionName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// setOption sets the option of the JSON name to the value, its items when it is a list.
+func setOption(options *ThreatMatrixClientOptions, name string, value []string) error {
+	optionsValue := reflect.ValueOf(options).Elem()
+	for index := 0; index < optionsValue.NumField(); index++ {
+		if optionName(optionsValue.Type().Field(index)) != name {
+			continue
+		}
+		field := optionsValue.Field(index)
+		if field.Kind() != reflect.Slice {
+			if len(value) != 1 {
+				return fmt.Errorf("%s is not a list", name)
+			}
+			return setScalar(field, name, value[0])
+		}
+		items := reflect.MakeSlice(field.Type(), len(value), len(value))
+		for itemIndex, item := range value {
+			if err := setScalar(items.Index(itemIndex), name, item); err != nil {
+				return err
+			}
+		}
+		field.Set(items)
+		return nil
+	}
+	return fmt.Errorf("unknown option %s", name)
+}
+
+// setScalar parses the value into the scalar field of the option.
+func setScalar(field reflect.Value, name string, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s is not a boolean: %q", name, value)
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || field.OverflowInt(parsed) {
+			return fmt.Errorf("%s is not an integer: %q", name, value)
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || field.OverflowUint(parsed) {
+			return fmt.Errorf("%s is not a positive integer: %q", name, value)
+		}
+		field.SetUint(parsed)
+	default:
+		return fmt.Errorf("%s can only be set in a JSON or YAML configuration file", name)
+	}
+	return nil
+}
+
+// parseFlatToml parses the flat subset of TOML: one "name = value" per line, the value being a scalar, quoted or not,
+// or an inline list "[a, b]". Comments start with #.
+func parseFlatToml(data []byte) (map[string][]string, error) {
+	values := map[string][]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		trimmed := strings.TrimSpace(stripConfigComment(scanner.Text()))
+		switch {
+		case trimmed == "":
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			return nil, fmt.Errorf("line %d: tables are not supported", lineNumber)
+		}
+		name, value, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected name = value", lineNumber)
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if _, ok := values[name]; ok {
+			return nil, fmt.Errorf("line %d: %s is set twice", lineNumber, name)
+		}
+		if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+			items := []string{}
+			for _, item := range splitConfigList(value[1 : len(value)-1]) {
+				unquoted, err := unquoteConfigValue(item)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+				}
+				items = append(items, unquoted)
+			}
+			values[name] = items
+			continue
+		}
+		unquoted, err := unquoteConfigValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		values[name] = []string{unquoted}
+	}
+	return values, scanner.Err()
+}
+
+// stripConfigComment removes the # comment of the line, outside of quotes.
+func stripConfigComment(line string) string {
+	var quote rune
+	for index, char := range line {
+		switch {
+		case quote != 0 && char == quote:
+			quote = 0
+		case quote == 0 && (char == '"' || char == '\''):
+			quote = char
+		case quote == 0 && char == '#':
+			return line[:index]
+		}
+	}
+	return line
+}
+
+// unquoteConfigValue returns the value without its quotes, escapes being read in double quotes.
+func unquoteConfigValue(value string) (string, error) {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		return strconv.Unquote(value)
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1], nil
+	}
+	return value, nil
+}
+
+// splitConfigList splits a comma separated list, an empty value giving no items.
+func splitConfigList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
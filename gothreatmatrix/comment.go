@@ -0,0 +1,205 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// Comment represents a comment left on a job or an investigation in ThreatMatrix.
+type Comment struct {
+	ID        uint64      `json:"id"`
+	User      UserDetails `json:"user"`
+	Content   string      `json:"content"`
+	CreatedAt *time.Time  `json:"created_at"`
+	UpdatedAt *time.Time  `json:"updated_at"`
+}
+
+// CommentParams represents the fields needed for creating and editing comments.
+type CommentParams struct {
+	Content string `json:"content"`
+}
+
+// CommentService handles communication with comment related methods of ThreatMatrix API.
+type CommentService struct {
+	client *ThreatMatrixClient
+}
+
+// ListForInvestigation fetches every comment of an investigation.
+//
+//	Endpoint: GET /api/investigation/{investigationID}/comments
+func (commentService *CommentService) ListForInvestigation(ctx context.Context, investigationId uint64) (*[]Comment, error) {
+	route := commentService.client.options.Url + constants.INVESTIGATION_COMMENTS_URL
+	requestUrl := fmt.Sprintf(route, investigationId)
+	contentType := "application/json"
+	method := "GET"
+	request, err := commentService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := commentService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, commentService.client.featureError(FEATURE_INVESTIGATIONS, err)
+	}
+	commentList := []Comment{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &commentList); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &commentList, nil
+}
+
+// CreateForInvestigation posts a new comment on an investigation.
+//
+//	Endpoint: POST /api/investigation/{investigationID}/comments
+func (commentService *CommentService) CreateForInvestigation(ctx context.Context, investigationId uint64, commentParams *CommentParams) (*Comment, error) {
+	route := commentService.client.options.Url + constants.INVESTIGATION_COMMENTS_URL
+	requestUrl := fmt.Sprintf(route, investigationId)
+	commentJson, err := json.Marshal(commentParams)
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/json"
+	method := "POST"
+	body := bytes.NewBuffer(commentJson)
+	request, err := commentService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := commentService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, commentService.client.featureError(FEATURE_INVESTIGATIONS, err)
+	}
+	createdComment := Comment{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &createdComment); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &createdComment, nil
+}
+
+// UpdateForInvestigation lets you edit a comment of an investigation through its comment ID.
+//
+//	Endpoint: PATCH /api/investigation/{investigationID}/comments/{commentID}
+func (commentService *CommentService) UpdateForInvestigation(ctx context.Context, investigationId uint64, commentId uint64, commentParams *CommentParams) (*Comment, error) {
+	route := commentService.client.options.Url + constants.SPECIFIC_INVESTIGATION_COMMENT_URL
+	requestUrl := fmt.Sprintf(route, investigationId, commentId)
+	commentJson, err := json.Marshal(commentParams)
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/json"
+	method := "PATCH"
+	body := bytes.NewBuffer(commentJson)
+	request, err := commentService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := commentService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, commentService.client.featureError(FEATURE_INVESTIGATIONS, err)
+	}
+	updatedComment := Comment{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &updatedComment); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &updatedComment, nil
+}
+
+// DeleteForInvestigation removes a comment from an investigation.
+//
+//	Endpoint: DELETE /api/investigation/{investigationID}/comments/{commentID}
+func (commentService *CommentService) DeleteForInvestigation(ctx context.Context, investigationId uint64, commentId uint64) (bool, error) {
+	route := commentService.client.options.Url + constants.SPECIFIC_INVESTIGATION_COMMENT_URL
+	requestUrl := fmt.Sprintf(route, investigationId, commentId)
+	contentType := "application/json"
+	method := "DELETE"
+	request, err := commentService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return false, err
+	}
+	successResp, err := commentService.client.newRequest(ctx, request)
+	if err != nil {
+		return false, commentService.client.featureError(FEATURE_INVESTIGATIONS, err)
+	}
+	if successResp.StatusCode == http.StatusNoContent {
+		return true, nil
+	}
+	return false, nil
+}
+
+// ListForJob fetches every comment of a job.
+//
+//	Endpoint: GET /api/jobs/{jobID}/comments
+func (commentService *CommentService) ListForJob(ctx context.Context, jobId uint64) (*[]Comment, error) {
+	route := commentService.client.options.Url + constants.JOB_COMMENTS_URL
+	requestUrl := fmt.Sprintf(route, jobId)
+	contentType := "application/json"
+	method := "GET"
+	request, err := commentService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := commentService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	commentList := []Comment{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &commentList); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &commentList, nil
+}
+
+// CreateForJob posts a new comment on a job.
+//
+//	Endpoint: POST /api/jobs/{jobID}/comments
+func (commentService *CommentService) CreateForJob(ctx context.Context, jobId uint64, commentParams *CommentParams) (*Comment, error) {
+	route := commentService.client.options.Url + constants.JOB_COMMENTS_URL
+	requestUrl := fmt.Sprintf(route, jobId)
+	commentJson, err := json.Marshal(commentParams)
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/json"
+	method := "POST"
+	body := bytes.NewBuffer(commentJson)
+	request, err := commentService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := commentService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	createdComment := Comment{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &createdComment); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &createdComment, nil
+}
+
+// DeleteForJob removes a comment from a job.
+//
+//	Endpoint: DELETE /api/jobs/{jobID}/comments/{commentID}
+func (commentService *CommentService) DeleteForJob(ctx context.Context, jobId uint64, commentId uint64) (bool, error) {
+	route := commentService.client.options.Url + constants.SPECIFIC_JOB_COMMENT_URL
+	requestUrl := fmt.Sprintf(route, jobId, commentId)
+	contentType := "application/json"
+	method := "DELETE"
+	request, err := commentService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return false, err
+	}
+	successResp, err := commentService.client.newRequest(ctx, request)
+	if err != nil {
+		return false, err
+	}
+	if successResp.StatusCode == http.StatusNoContent {
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,320 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// ErrCompatibilityUndetected is returned by NegotiateCompatibility when the instance answers the probes of neither
+// API generation, e.g. a wrong Url.
+var ErrCompatibilityUndetected = errors.New("gothreatmatrix: could not detect the API generation of the instance")
+
+// CompatibilityMode represents which generation of the ThreatMatrix REST API the client talks to.
+type CompatibilityMode int
+
+// Values of the CompatibilityMode enum.
+const (
+	// CURRENT_API is the default mode, no translation is done.
+	CURRENT_API CompatibilityMode = iota
+	// LEGACY_API translates endpoint paths and field names for older ThreatMatrix/IntelOwl servers.
+	LEGACY_API
+)
+
+// Overriding the String method to get the string representation of the CompatibilityMode enum
+func (compatibilityMode CompatibilityMode) String() string {
+	switch compatibilityMode {
+	case LEGACY_API:
+		return "LEGACY"
+	}
+	return "CURRENT"
+}
+
+// compatibilityProfile holds the translations applied to talk to a given API generation.
+type compatibilityProfile struct {
+	// paths maps a current endpoint path to its older counterpart.
+	paths map[string]string
+	// requestFields rename the current request fields to their older counterpart, matched on the current routes.
+	requestFields []fieldRename
+	// responseFields rename the older response fields to their current counterpart, matched on the older routes.
+	responseFields []fieldRename
+}
+
+// fieldRename renames a top-level field of the JSON bodies of an endpoint, so that the payloads the body carries,
+// e.g. the reports of the analyzers, are left untouched.
+type fieldRename struct {
+	// route is the endpoint path, its "%d" and "%s" segments matching any ID and name.
+	route string
+	from  string
+	to    string
+}
+
+var legacyProfile = compatibilityProfile{
+	paths: map[string]string{
+		constants.ANALYZE_OBSERVABLE_URL: constants.LEGACY_ANALYSIS_REQUEST_URL,
+		constants.ANALYZE_FILE_URL:       constants.LEGACY_ANALYSIS_REQUEST_URL,
+	},
+	requestFields: []fieldRename{
+		{route: constants.ANALYZE_OBSERVABLE_URL, from: "classification", to: "observable_classification"},
+	},
+	responseFields: []fieldRename{
+		{route: constants.LEGACY_ANALYSIS_REQUEST_URL, from: "report_id", to: "job_id"},
+		{route: constants.LEGACY_ANALYSIS_RESULT_URL, from: "report_id", to: "job_id"},
+		{route: constants.LEGACY_ANALYSIS_RESULT_URL, from: "analysis_reports", to: "analyzer_reports"},
+		{route: constants.SPECIFIC_JOB_URL, from: "analysis_reports", to: "analyzer_reports"},
+	},
+}
+
+// negotiatedCompatibility holds the CompatibilityMode and the server version detected by NegotiateCompatibility,
+// which goroutines sending requests read concurrently.
+type negotiatedCompatibility struct {
+	mutex         sync.RWMutex
+	mode          CompatibilityMode
+	serverVersion string
+	negotiated    bool
+	// autoMutex makes the concurrent first calls of a client with AutoNegotiate wait for a single probe.
+	autoMutex sync.Mutex
+}
+
+// CompatibilityMode returns the API generation the client talks to: the one detected by NegotiateCompatibility,
+// ThreatMatrixClientOptions.CompatibilityMode until then.
+func (client *ThreatMatrixClient) CompatibilityMode() CompatibilityMode {
+	if client.compatibility != nil {
+		client.compatibility.mutex.RLock()
+		defer client.compatibility.mutex.RUnlock()
+		if client.compatibility.negotiated {
+			return client.compatibility.mode
+		}
+	}
+	return client.options.CompatibilityMode
+}
+
+// ServerVersion returns the version of the instance, e.g. "v6.0.2": the one it answered NegotiateCompatibility
+// with in its SERVER_VERSION_HEADER, ThreatMatrixClientOptions.ServerVersion when it did not tell or before.
+func (client *ThreatMatrixClient) ServerVersion() string {
+	if client.compatibility != nil {
+		client.compatibility.mutex.RLock()
+		defer client.compatibility.mutex.RUnlock()
+		if client.compatibility.serverVersion != "" {
+			return client.compatibility.serverVersion
+		}
+	}
+	return client.options.ServerVersion
+}
+
+// autoNegotiate runs NegotiateCompatibility for a client with AutoNegotiate until it succeeds: in
+// NewThreatMatrixClient, then before the calls following a failed probe, which leaves the configured
+// CompatibilityMode in use for the call.
+func (client *ThreatMatrixClient) autoNegotiate(ctx context.Context) {
+	if !client.options.AutoNegotiate || client.compatibility == nil {
+		return
+	}
+	client.compatibility.mutex.RLock()
+	negotiated := client.compatibility.negotiated
+	client.compatibility.mutex.RUnlock()
+	if negotiated {
+		return
+	}
+	client.compatibility.autoMutex.Lock()
+	defer client.compatibility.autoMutex.Unlock()
+	client.compatibility.mutex.RLock()
+	negotiated = client.compatibility.negotiated
+	client.compatibility.mutex.RUnlock()
+	if !negotiated {
+		client.NegotiateCompatibility(ctx)
+	}
+}
+
+// profile returns the compatibility profile to apply, nil when no translation is needed.
+func (client *ThreatMatrixClient) profile() *compatibilityProfile {
+	if client.CompatibilityMode() == LEGACY_API {
+		return &legacyProfile
+	}
+	return nil
+}
+
+// NegotiateCompatibility detects which generation of the REST API your ThreatMatrix instance speaks
+// and stores the result in the client so every following call is translated accordingly, see CompatibilityMode.
+// It can run while other goroutines use the client.
+//
+// The detection probes the analyze_observable endpoint: current servers answer 405 (it only accepts POST).
+// Older servers do not know the route, a 404, but know the send_analysis_request one, which is probed then:
+// when neither route exists, e.g. a wrong Url or a proxy answering 404 for everything, an error wrapping
+// ErrCompatibilityUndetected is returned and the mode is left as it was.
+func (client *ThreatMatrixClient) NegotiateCompatibility(ctx context.Context) (CompatibilityMode, error) {
+	mode, serverVersion, err := client.probeCompatibility(ctx)
+	if err != nil {
+		return CURRENT_API, err
+	}
+	if client.compatibility != nil {
+		client.compatibility.mutex.Lock()
+		client.compatibility.mode = mode
+		client.compatibility.serverVersion = serverVersion
+		client.compatibility.negotiated = true
+		client.compatibility.mutex.Unlock()
+	}
+	return mode, nil
+}
+
+// probeCompatibility probes the endpoints of the API generations, see NegotiateCompatibility, and returns the
+// detected mode with the SERVER_VERSION_HEADER the server answered the probes with.
+func (client *ThreatMatrixClient) probeCompatibility(ctx context.Context) (CompatibilityMode, string, error) {
+	response, err := client.probeRoute(ctx, constants.ANALYZE_OBSERVABLE_URL)
+	if err != nil {
+		return CURRENT_API, "", err
+	}
+	serverVersion := response.Header.Get(SERVER_VERSION_HEADER)
+	if response.StatusCode != http.StatusNotFound {
+		return CURRENT_API, serverVersion, nil
+	}
+	legacyResponse, err := client.probeRoute(ctx, constants.LEGACY_ANALYSIS_REQUEST_URL)
+	if err != nil {
+		return CURRENT_API, "", err
+	}
+	if serverVersion == "" {
+		serverVersion = legacyResponse.Header.Get(SERVER_VERSION_HEADER)
+	}
+	if legacyResponse.StatusCode == http.StatusNotFound || legacyResponse.StatusCode >= http.StatusInternalServerError {
+		return CURRENT_API, "", fmt.Errorf("%w: %s and %s answered %d and %d", ErrCompatibilityUndetected,
+			constants.ANALYZE_OBSERVABLE_URL, constants.LEGACY_ANALYSIS_REQUEST_URL, response.StatusCode, legacyResponse.StatusCode)
+	}
+	return LEGACY_API, serverVersion, nil
+}
+
+// probeRoute sends an authenticated GET to the route, its response body being closed.
+func (client *ThreatMatrixClient) probeRoute(ctx context.Context, route string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", client.options.Url+route, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.authProvider().Authenticate(ctx, request); err != nil {
+		return nil, err
+	}
+	response, err := client.do(request)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+	return response, nil
+}
+
+// translateUrl rewrites the endpoint path of the given URL for older servers.
+func (profile *compatibilityProfile) translateUrl(baseUrl string, requestUrl string) string {
+	if !strings.HasPrefix(requestUrl, baseUrl) {
+		return requestUrl
+	}
+	path := strings.TrimPrefix(requestUrl, baseUrl)
+	query := ""
+	if index := strings.Index(path, "?"); index >= 0 {
+		path, query = path[:index], path[index:]
+	}
+	if legacyPath, ok := profile.paths[path]; ok {
+		return baseUrl + legacyPath + query
+	}
+	return requestUrl
+}
+
+// translateBody renames the fields of a JSON request body of the route for older servers.
+func (profile *compatibilityProfile) translateBody(route string, body io.Reader) (io.Reader, error) {
+	renames := matchingRenames(profile.requestFields, route)
+	if len(renames) == 0 {
+		return body, nil
+	}
+	bodyBytes, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	translated, err := renameJsonFields(bodyBytes, renames)
+	if err != nil {
+		// not JSON: send it untouched
+		return bytes.NewBuffer(bodyBytes), nil
+	}
+	return bytes.NewBuffer(translated), nil
+}
+
+// translateResponse renames the fields of a JSON response body of the route to their current names.
+func (profile *compatibilityProfile) translateResponse(route string, data []byte) []byte {
+	renames := matchingRenames(profile.responseFields, route)
+	if len(renames) == 0 {
+		return data
+	}
+	translated, err := renameJsonFields(data, renames)
+	if err != nil {
+		return data
+	}
+	return translated
+}
+
+// matchingRenames returns the renames of the route, an URL path ending with the route of the rename.
+func matchingRenames(renames []fieldRename, route string) []fieldRename {
+	var matching []fieldRename
+	for _, rename := range renames {
+		if matchRoute(rename.route, route) {
+			matching = append(matching, rename)
+		}
+	}
+	return matching
+}
+
+// matchRoute reports whether the path ends with the route, its "%d" segments matching digits and its "%s" ones
+// any segment, so that the path prefix of the Url does not matter.
+func matchRoute(route string, path string) bool {
+	routeSegments := strings.Split(strings.Trim(route, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pathSegments) < len(routeSegments) {
+		return false
+	}
+	pathSegments = pathSegments[len(pathSegments)-len(routeSegments):]
+	for index, routeSegment := range routeSegments {
+		pathSegment := pathSegments[index]
+		switch routeSegment {
+		case "%s":
+		case "%d":
+			if _, err := strconv.ParseUint(pathSegment, 10, 64); err != nil {
+				return false
+			}
+		default:
+			if routeSegment != pathSegment {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// renameJsonFields applies the renames to the top-level object of a JSON document, an existing field of the new
+// name being left untouched.
+func renameJsonFields(data []byte, renames []fieldRename) ([]byte, error) {
+	if len(renames) == 0 || len(bytes.TrimSpace(data)) == 0 {
+		return data, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	object, ok := document.(map[string]interface{})
+	if !ok {
+		return data, nil
+	}
+	for _, rename := range renames {
+		if fieldValue, ok := object[rename.from]; ok {
+			if _, exists := object[rename.to]; !exists {
+				object[rename.to] = fieldValue
+				delete(object, rename.from)
+			}
+		}
+	}
+	return json.Marshal(object)
+}
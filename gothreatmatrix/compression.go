@@ -0,0 +1,88 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// gzipBody decompresses a gzip response body, reading its gzip header on the first Read.
+type gzipBody struct {
+	body   io.ReadCloser
+	reader *gzip.Reader
+	err    error
+}
+
+// Read implements io.Reader.
+func (gzipBody *gzipBody) Read(p []byte) (int, error) {
+	if gzipBody.reader == nil && gzipBody.err == nil {
+		gzipBody.reader, gzipBody.err = gzip.NewReader(gzipBody.body)
+	}
+	if gzipBody.err != nil {
+		return 0, gzipBody.err
+	}
+	return gzipBody.reader.Read(p)
+}
+
+// Close implements io.Closer, closing the compressed body.
+func (gzipBody *gzipBody) Close() error {
+	return gzipBody.body.Close()
+}
+
+// acceptGzip returns a copy of the request asking for a gzip response, unless the compression is disabled or
+// the caller chose the encodings. It reports whether the response is to be decompressed by the client.
+// The request itself is left untouched, so that its retries ask for gzip too.
+// Like the http.Transport, it does not ask for gzip in the HEAD requests, nor in the Range requests whose offsets
+// would point into the compressed content, e.g. resuming a DownloadSampleToFile, nor in the upgrade requests of
+// the websockets.
+func (client *ThreatMatrixClient) acceptGzip(request *http.Request) (*http.Request, bool) {
+	if client.options.DisableCompression || request.Header.Get("Accept-Encoding") != "" {
+		return request, false
+	}
+	if request.Method == "HEAD" || request.Header.Get("Range") != "" || request.Header.Get("Upgrade") != "" {
+		return request, false
+	}
+	gzipRequest := request.Clone(request.Context())
+	gzipRequest.Header.Set("Accept-Encoding", "gzip")
+	return gzipRequest, true
+}
+
+// decompressResponse replaces the body of a gzip response with its decompressed content, the way the
+// http.Transport does when it asks for gzip itself.
+func decompressResponse(response *http.Response) {
+	if response == nil || response.Body == nil || !strings.EqualFold(response.Header.Get("Content-Encoding"), "gzip") {
+		return
+	}
+	response.Body = &gzipBody{body: response.Body}
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.ContentLength = -1
+	response.Uncompressed = true
+}
+
+// compressBody gzips the JSON body of a request when it is larger than GzipRequestsAbove, reporting whether it did.
+func (client *ThreatMatrixClient) compressBody(body io.Reader) (io.Reader, bool, error) {
+	threshold := client.options.GzipRequestsAbove
+	if client.options.DisableCompression || threshold <= 0 || body == nil {
+		return body, false, nil
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(data)) <= threshold {
+		return bytes.NewReader(data), false, nil
+	}
+	compressed := &bytes.Buffer{}
+	writer := gzip.NewWriter(compressed)
+	if _, err := writer.Write(data); err != nil {
+		return nil, false, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, false, err
+	}
+	return bytes.NewReader(compressed.Bytes()), true, nil
+}
@@ -0,0 +1,107 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// configCache caches the responses of the configuration endpoints, keyed by URL, see ThreatMatrixClientOptions.ConfigCacheTTL.
+type configCache struct {
+	mutex   sync.Mutex
+	entries map[string]configCacheEntry
+}
+
+// configCacheEntry represents a cached response and the validators of its revalidation.
+type configCacheEntry struct {
+	data         []byte
+	etag         string
+	lastModified string
+	fetchedAt    time.Time
+}
+
+// get returns the entry of the URL, ok is false when there is none.
+func (cache *configCache) get(requestUrl string) (entry configCacheEntry, ok bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, ok = cache.entries[requestUrl]
+	return entry, ok
+}
+
+// put saves the entry of the URL.
+func (cache *configCache) put(requestUrl string, entry configCacheEntry) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.entries == nil {
+		cache.entries = map[string]configCacheEntry{}
+	}
+	cache.entries[requestUrl] = entry
+}
+
+// clear drops every entry, after a change of the plugin configurations.
+func (cache *configCache) clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.entries = nil
+}
+
+// cachedGet fetches the body of the configuration endpoint through the configuration cache of the client:
+// a fresh entry is returned without any request, a stale one is revalidated with a conditional request
+// and kept when the instance answers 304 Not Modified.
+func (client *ThreatMatrixClient) cachedGet(ctx context.Context, requestUrl string) ([]byte, error) {
+	ttl := time.Duration(client.options.ConfigCacheTTL) * time.Second
+	if ttl <= 0 || client.configCache == nil {
+		return client.get(ctx, requestUrl, nil)
+	}
+	entry, cached := client.configCache.get(requestUrl)
+	if cached && !forcesRefresh(ctx) && time.Since(entry.fetchedAt) < ttl {
+		return entry.data, nil
+	}
+	// * the validators are read from the response, the info of the caller being filled in afterwards
+	info := &ResponseInfo{}
+	header := http.Header{}
+	if cached && !forcesRefresh(ctx) {
+		if entry.etag != "" {
+			header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+	data, err := client.get(WithResponseInfo(ctx, info), requestUrl, header)
+	if callerInfo, ok := ResponseInfoFromContext(ctx); ok {
+		*callerInfo = *info
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.StatusCode == http.StatusNotModified && cached {
+		entry.fetchedAt = time.Now()
+		client.configCache.put(requestUrl, entry)
+		return entry.data, nil
+	}
+	client.configCache.put(requestUrl, configCacheEntry{
+		data:         data,
+		etag:         info.Header.Get("ETag"),
+		lastModified: info.Header.Get("Last-Modified"),
+		fetchedAt:    time.Now(),
+	})
+	return data, nil
+}
+
+// get sends a GET request to the URL with the header and returns the body of its response.
+func (client *ThreatMatrixClient) get(ctx context.Context, requestUrl string, header http.Header) ([]byte, error) {
+	request, err := client.buildRequest(ctx, "GET", "application/json", nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	successResp, err := client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return successResp.Data, nil
+}
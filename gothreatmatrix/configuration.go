@@ -1,5 +1,7 @@
 package gothreatmatrix
 
+import "encoding/json"
+
 type ConfigType struct {
 	Queue         string `json:"queue"`
 	SoftTimeLimit int    `json:"soft_time_limit"`
@@ -9,12 +11,29 @@ type Secret struct {
 	EnvironmentVariableKey string `json:"env_var_key"`
 	Description            string `json:"description"`
 	Required               bool   `json:"required"`
+	// Type is the type of the secret, e.g. "str", empty when the instance does not tell it.
+	Type string `json:"type,omitempty"`
 }
 
+// Parameter represents a param of a plugin, that the runtime configuration of an analysis can set.
 type Parameter struct {
-	Value       interface{} `json:"value"`
+	// Value is the value the plugin is configured with, used when the runtime configuration does not set the param.
+	Value interface{} `json:"value"`
+	// Type is the type of the param: "str", "int", "float", "bool", "list" or "dict", see TypeName.
 	Type        interface{} `json:"type"`
 	Description string      `json:"description"`
+	// Required params must have a Value, a Default or be set by the runtime configuration.
+	Required bool `json:"required,omitempty"`
+	// IsSecret params hold credentials, the instance hiding their Value.
+	IsSecret bool `json:"is_secret,omitempty"`
+	// Default is the value the plugin declares for the param, nil when the instance does not tell it.
+	Default interface{} `json:"default,omitempty"`
+}
+
+// TypeName returns the Type of the param, empty when the instance sent none or not a string.
+func (parameter Parameter) TypeName() string {
+	typeName, _ := parameter.Type.(string)
+	return typeName
 }
 
 type VerificationType struct {
@@ -23,11 +42,12 @@ type VerificationType struct {
 	MissingSecrets []string `json:"missing_secrets"`
 }
 
-// BaseConfigurationType represents the common fields in an analyzer and a connector configuration.
+// BaseConfigurationType represents the common fields in the configuration of every plugin type.
 type BaseConfigurationType struct {
 	Name         string               `json:"name"`
 	PythonModule string               `json:"python_module"`
 	Disabled     bool                 `json:"disabled"`
+	Deprecated   bool                 `json:"deprecated,omitempty"`
 	Description  string               `json:"description"`
 	Config       ConfigType           `json:"config"`
 	Secrets      map[string]Secret    `json:"secrets"`
@@ -35,7 +55,42 @@ type BaseConfigurationType struct {
 	Verification VerificationType     `json:"verification"`
 }
 
+// ConfigName implements BaseConfig.
+func (baseConfiguration BaseConfigurationType) ConfigName() string {
+	return baseConfiguration.Name
+}
+
 // StatusResponse represents the status of an analyzer or connector i.e are they working or not.
 type StatusResponse struct {
 	Status bool `json:"status"`
 }
+
+// Implementing the UnmarshalJSON interface so that out of range numbers in a parameter do not
+// make the whole configuration list undecodable.
+func (parameter *Parameter) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		Value       json.RawMessage `json:"value"`
+		Type        json.RawMessage `json:"type"`
+		Description string          `json:"description"`
+		Required    bool            `json:"required"`
+		IsSecret    bool            `json:"is_secret"`
+		Default     json.RawMessage `json:"default"`
+	}{}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	parameter.Description = aux.Description
+	parameter.Required = aux.Required
+	parameter.IsSecret = aux.IsSecret
+	var err error
+	if parameter.Value, err = decodeUntrustedJson(aux.Value); err != nil {
+		return err
+	}
+	if parameter.Type, err = decodeUntrustedJson(aux.Type); err != nil {
+		return err
+	}
+	if parameter.Default, err = decodeUntrustedJson(aux.Default); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// ConnectionBudget caps the number of requests in flight to a ThreatMatrix instance. Every request of the
+// client goes through it, so the watchers, bulk submitters, monitors and report fetchers running at the same
+// time never exceed it together. Set the same budget in the options of several clients to share it between them.
+type ConnectionBudget struct {
+	slots chan struct{}
+}
+
+// NewConnectionBudget returns a budget of maxConnections simultaneous requests, 1 when lower than 1.
+func NewConnectionBudget(maxConnections int) *ConnectionBudget {
+	if maxConnections < 1 {
+		maxConnections = 1
+	}
+	return &ConnectionBudget{slots: make(chan struct{}, maxConnections)}
+}
+
+// Limit returns the number of simultaneous requests of the budget.
+func (budget *ConnectionBudget) Limit() int {
+	return cap(budget.slots)
+}
+
+// InUse returns the number of requests in flight.
+func (budget *ConnectionBudget) InUse() int {
+	return len(budget.slots)
+}
+
+// acquire blocks until a request may be sent, or the context is done.
+func (budget *ConnectionBudget) acquire(ctx context.Context) error {
+	select {
+	case budget.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees the slot of a finished request.
+func (budget *ConnectionBudget) release() {
+	<-budget.slots
+}
+
+// newClientBudget returns the budget of the options: the shared ConnectionBudget, or one of MaxConcurrentRequests
+// for the client alone, nil when there is no limit.
+func newClientBudget(options *ThreatMatrixClientOptions) *ConnectionBudget {
+	if options.ConnectionBudget != nil {
+		return options.ConnectionBudget
+	}
+	if options.MaxConcurrentRequests > 0 {
+		return NewConnectionBudget(options.MaxConcurrentRequests)
+	}
+	return nil
+}
+
+// budgetedBody releases the slot of its request once closed, the response being read after do returned.
+type budgetedBody struct {
+	io.ReadCloser
+	budget *ConnectionBudget
+	once   sync.Once
+}
+
+// Close closes the body and releases the slot.
+func (body *budgetedBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.once.Do(body.budget.release)
+	return err
+}
+
+// budgetedConn is the budgetedBody of an upgraded connection, e.g. a websocket, which holds its slot until closed.
+type budgetedConn struct {
+	*budgetedBody
+	io.Writer
+}
+
+// newBudgetedBody returns the body releasing the slot of its request once closed, still writable when it is
+// an upgraded connection.
+func newBudgetedBody(body io.ReadCloser, budget *ConnectionBudget) io.ReadCloser {
+	budgeted := &budgetedBody{ReadCloser: body, budget: budget}
+	if conn, ok := body.(io.ReadWriteCloser); ok {
+		return &budgetedConn{budgetedBody: budgeted, Writer: conn}
+	}
+	return budgeted
+}
@@ -2,9 +2,6 @@ package gothreatmatrix
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"sort"
 
 	"github.com/khulnasoft/go-threatmatrix/constants"
 )
@@ -30,36 +27,25 @@ type ConnectorService struct {
 //
 // ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/get_connector_configs
 func (connectorService *ConnectorService) GetConfigs(ctx context.Context) (*[]ConnectorConfig, error) {
-	requestUrl := connectorService.client.options.Url + constants.CONNECTOR_CONFIG_URL
-	contentType := "application/json"
-	method := "GET"
-	request, err := connectorService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	snapshot, err := connectorService.client.catalogSnapshot()
 	if err != nil {
 		return nil, err
 	}
-
-	successResp, err := connectorService.client.newRequest(ctx, request)
-	if err != nil {
+	var connectorConfigurationResponse map[string]ConnectorConfig
+	if snapshot != nil {
+		connectorConfigurationResponse = snapshot.Connectors
+	} else if connectorConfigurationResponse, err = connectorService.fetchConfigs(ctx); err != nil {
 		return nil, err
 	}
-	connectorConfigurationResponse := map[string]ConnectorConfig{}
-	if unmarshalError := json.Unmarshal(successResp.Data, &connectorConfigurationResponse); unmarshalError != nil {
-		return nil, unmarshalError
-	}
+	observeCatalog(connectorService.client, CONNECTOR_PLUGIN, connectorConfigurationResponse, func(config ConnectorConfig) bool {
+		return config.Deprecated
+	})
+	return sortPluginConfigs(connectorConfigurationResponse), nil
+}
 
-	connectorNames := make([]string, 0)
-	// *getting all the analyzer key names!
-	for connectorName := range connectorConfigurationResponse {
-		connectorNames = append(connectorNames, connectorName)
-	}
-	// * sorting them alphabetically
-	sort.Strings(connectorNames)
-	connectorConfigurationList := []ConnectorConfig{}
-	for _, connectorName := range connectorNames {
-		connectorConfig := connectorConfigurationResponse[connectorName]
-		connectorConfigurationList = append(connectorConfigurationList, connectorConfig)
-	}
-	return &connectorConfigurationList, nil
+// fetchConfigs gets the connector configurations from the ThreatMatrix instance, keyed by connector name.
+func (connectorService *ConnectorService) fetchConfigs(ctx context.Context) (map[string]ConnectorConfig, error) {
+	return fetchPluginConfigs[ConnectorConfig](ctx, connectorService.client, constants.CONNECTOR_CONFIG_URL)
 }
 
 // HealthCheck checks if the specified connector is up and running
@@ -68,21 +54,48 @@ func (connectorService *ConnectorService) GetConfigs(ctx context.Context) (*[]Co
 //
 // ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/connector/operation/connector_healthcheck_retrieve
 func (connectorService *ConnectorService) HealthCheck(ctx context.Context, connectorName string) (bool, error) {
-	route := connectorService.client.options.Url + constants.CONNECTOR_HEALTHCHECK_URL
-	requestUrl := fmt.Sprintf(route, connectorName)
-	contentType := "application/json"
-	method := "GET"
-	request, err := connectorService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
-	if err != nil {
-		return false, err
+	return pluginHealthCheck(ctx, connectorService.client, constants.CONNECTOR_HEALTHCHECK_URL, connectorName)
+}
+
+// HealthCheckAll fetches the connector configurations and runs the health check of every connector in parallel,
+// keyed by connector name, the way AnalyzerService.HealthCheckAll does for the analyzers.
+//
+//	Endpoint: GET /api/get_connector_configs
+//	Endpoint: GET /api/connector/{NameOfConnector}/healthcheck
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/connector/operation/connector_healthcheck_retrieve
+func (connectorService *ConnectorService) HealthCheckAll(ctx context.Context, options *HealthCheckAllOptions) (map[string]PluginHealth, error) {
+	if options == nil {
+		options = &HealthCheckAllOptions{}
 	}
-	status := StatusResponse{}
-	successResp, err := connectorService.client.newRequest(ctx, request)
+	connectorConfigs, err := connectorService.GetConfigs(ctx)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	if unmarshalError := json.Unmarshal(successResp.Data, &status); unmarshalError != nil {
-		return false, unmarshalError
+	connectorNames := []string{}
+	for _, connectorConfig := range *connectorConfigs {
+		if !connectorConfig.Disabled || options.IncludeDisabled {
+			connectorNames = append(connectorNames, connectorConfig.Name)
+		}
 	}
-	return status.Status, nil
+	return connectorService.client.healthCheckAll(ctx, CONNECTOR_PLUGIN, connectorNames, options.Concurrency)
+}
+
+// Enable enables the connector back for the organization of the user, after Disable.
+//
+//	Endpoint: DELETE /api/connector/{NameOfConnector}/organization
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/connector/operation/connector_organization_destroy
+func (connectorService *ConnectorService) Enable(ctx context.Context, connectorName string) error {
+	return setPluginOrganizationState(ctx, connectorService.client, constants.CONNECTOR_ORGANIZATION_URL, connectorName, true)
+}
+
+// Disable disables the connector for the organization of the user: its members cannot run it anymore.
+// It needs the user to be an admin of the organization.
+//
+//	Endpoint: POST /api/connector/{NameOfConnector}/organization
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/connector/operation/connector_organization_create
+func (connectorService *ConnectorService) Disable(ctx context.Context, connectorName string) error {
+	return setPluginOrganizationState(ctx, connectorService.client, constants.CONNECTOR_ORGANIZATION_URL, connectorName, false)
 }
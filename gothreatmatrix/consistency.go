@@ -0,0 +1,174 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DEFAULT_CONSISTENCY_ATTEMPTS and DEFAULT_CONSISTENCY_INTERVAL are used when ConsistencyOptions leaves them at 0.
+const (
+	DEFAULT_CONSISTENCY_ATTEMPTS = 5
+	DEFAULT_CONSISTENCY_INTERVAL = 200 * time.Millisecond
+)
+
+// ConsistencyOptions represents how the *Verified methods read a resource back after writing it.
+// A resource that is not found or does not match yet is read again, to ride out the eventual consistency of the server.
+type ConsistencyOptions struct {
+	// Attempts is the number of reads, DEFAULT_CONSISTENCY_ATTEMPTS when 0.
+	Attempts int
+	// Interval is the wait between two reads, DEFAULT_CONSISTENCY_INTERVAL when 0.
+	Interval time.Duration
+}
+
+// MismatchError is returned when a resource read back after a write still differs from what was written.
+type MismatchError struct {
+	Resource string
+	ID       uint64
+	Field    string
+	Want     interface{}
+	Got      interface{}
+}
+
+// Error lets you implement the error interface.
+func (mismatchError *MismatchError) Error() string {
+	return fmt.Sprintf("%s %d: %s is %v instead of %v", mismatchError.Resource, mismatchError.ID, mismatchError.Field, mismatchError.Got, mismatchError.Want)
+}
+
+// readBack reads the resource until check finds no mismatch, or the attempts run out.
+// A not found error counts as a mismatch, as the write may not be visible yet. The resource read may be nil.
+func readBack[T any](ctx context.Context, options *ConsistencyOptions, read func() (*T, error), check func(resource *T) *MismatchError) (*T, error) {
+	if options == nil {
+		options = &ConsistencyOptions{}
+	}
+	attempts := options.Attempts
+	if attempts <= 0 {
+		attempts = DEFAULT_CONSISTENCY_ATTEMPTS
+	}
+	interval := options.Interval
+	if interval <= 0 {
+		interval = DEFAULT_CONSISTENCY_INTERVAL
+	}
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+		resource, err := read()
+		if err != nil {
+			if !IsNotFound(err) {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+		mismatch := check(resource)
+		if mismatch == nil {
+			return resource, nil
+		}
+		lastErr = mismatch
+	}
+	return nil, lastErr
+}
+
+// checkTag compares the tag read back with the params written.
+func checkTag(tag *Tag, tagId uint64, tagParams *TagParams) *MismatchError {
+	if tag.Label != tagParams.Label {
+		return &MismatchError{Resource: "tag", ID: tagId, Field: "label", Want: tagParams.Label, Got: tag.Label}
+	}
+	if !strings.EqualFold(tag.Color, tagParams.Color) {
+		return &MismatchError{Resource: "tag", ID: tagId, Field: "color", Want: tagParams.Color, Got: tag.Color}
+	}
+	return nil
+}
+
+// CreateVerified creates the tag, then reads it back until it matches the params, see ConsistencyOptions.
+// A tag that still differs is reported as a *MismatchError.
+//
+//	Endpoint: POST "/api/tags/"
+//	Endpoint: GET "/api/tags/{id}"
+func (tagService *TagService) CreateVerified(ctx context.Context, tagParams *TagParams, options *ConsistencyOptions) (*Tag, error) {
+	createdTag, err := tagService.Create(ctx, tagParams)
+	if err != nil {
+		return nil, err
+	}
+	return tagService.readBack(ctx, createdTag.ID, tagParams, options)
+}
+
+// UpdateVerified updates the tag, then reads it back until it matches the params, see ConsistencyOptions.
+// A tag that still differs is reported as a *MismatchError.
+//
+//	Endpoint: PUT "/api/tags/{id}"
+//	Endpoint: GET "/api/tags/{id}"
+func (tagService *TagService) UpdateVerified(ctx context.Context, tagId uint64, tagParams *TagParams, options *ConsistencyOptions) (*Tag, error) {
+	if _, err := tagService.Update(ctx, tagId, tagParams); err != nil {
+		return nil, err
+	}
+	return tagService.readBack(ctx, tagId, tagParams, options)
+}
+
+func (tagService *TagService) readBack(ctx context.Context, tagId uint64, tagParams *TagParams, options *ConsistencyOptions) (*Tag, error) {
+	return readBack(ctx, options, func() (*Tag, error) {
+		return tagService.Get(ctx, tagId)
+	}, func(tag *Tag) *MismatchError {
+		return checkTag(tag, tagId, tagParams)
+	})
+}
+
+// CreateForInvestigationVerified posts the comment, then reads the comments of the investigation back
+// until it is listed with the content written, see ConsistencyOptions.
+// A comment that still differs is reported as a *MismatchError.
+//
+//	Endpoint: POST /api/investigation/{investigationID}/comments
+//	Endpoint: GET /api/investigation/{investigationID}/comments
+func (commentService *CommentService) CreateForInvestigationVerified(ctx context.Context, investigationId uint64, commentParams *CommentParams, options *ConsistencyOptions) (*Comment, error) {
+	createdComment, err := commentService.CreateForInvestigation(ctx, investigationId, commentParams)
+	if err != nil {
+		return nil, err
+	}
+	return commentService.readBack(ctx, investigationId, createdComment.ID, commentParams, options)
+}
+
+// UpdateForInvestigationVerified edits the comment, then reads the comments of the investigation back
+// until it is listed with the content written, see ConsistencyOptions.
+// A comment that still differs is reported as a *MismatchError.
+//
+//	Endpoint: PATCH /api/investigation/{investigationID}/comments/{commentID}
+//	Endpoint: GET /api/investigation/{investigationID}/comments
+func (commentService *CommentService) UpdateForInvestigationVerified(ctx context.Context, investigationId uint64, commentId uint64, commentParams *CommentParams, options *ConsistencyOptions) (*Comment, error) {
+	if _, err := commentService.UpdateForInvestigation(ctx, investigationId, commentId, commentParams); err != nil {
+		return nil, err
+	}
+	return commentService.readBack(ctx, investigationId, commentId, commentParams, options)
+}
+
+func (commentService *CommentService) readBack(ctx context.Context, investigationId uint64, commentId uint64, commentParams *CommentParams, options *ConsistencyOptions) (*Comment, error) {
+	return readBack(ctx, options, func() (*Comment, error) {
+		comments, err := commentService.ListForInvestigation(ctx, investigationId)
+		if err != nil {
+			return nil, err
+		}
+		for index := range *comments {
+			if comment := &(*comments)[index]; comment.ID == commentId {
+				return comment, nil
+			}
+		}
+		// * the comment is not listed yet
+		return nil, nil
+	}, func(comment *Comment) *MismatchError {
+		if comment == nil {
+			return &MismatchError{Resource: "comment", ID: commentId, Field: "id", Want: commentId, Got: nil}
+		}
+		if comment.Content != commentParams.Content {
+			return &MismatchError{Resource: "comment", ID: commentId, Field: "content", Want: commentParams.Content, Got: comment.Content}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,170 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// CoverageConstraints represents the restrictions PlanCoverage plans with.
+type CoverageConstraints struct {
+	// Tlp of the planned analyses: AMBER leaves out the analyzers that leak info, RED the ones using an external service.
+	Tlp TLP
+	// NoExternal leaves out the analyzers using an external service whatever the TLP.
+	NoExternal bool
+	// NoSecrets leaves out the analyzers needing a secret, e.g. the paid ones.
+	NoSecrets bool
+}
+
+// PlaybookSuggestion represents a playbook able to analyze an indicator type.
+type PlaybookSuggestion struct {
+	Name string
+	// Analyzers are the playbook analyzers that would run for the indicator type.
+	Analyzers []string
+}
+
+// CoveragePlan represents the outcome of PlanCoverage.
+type CoveragePlan struct {
+	// Analyzers maps every indicator type to the analyzers that would run for it.
+	Analyzers map[string][]string
+	// Uncovered are the indicator types no analyzer would run for.
+	Uncovered []string
+	// Playbooks maps every indicator type to the playbooks covering it, the ones running the most analyzers first.
+	Playbooks map[string][]PlaybookSuggestion
+}
+
+// isFileType reports whether the indicator type is a file mime type rather than an observable classification.
+func isFileType(indicatorType string) bool {
+	return indicatorType == "file" || strings.Contains(indicatorType, "/")
+}
+
+// supportsIndicator reports whether the analyzer runs on the indicator type:
+// an observable classification, a file mime type or "file" for any file.
+func (analyzerConfig *AnalyzerConfig) supportsIndicator(indicatorType string) bool {
+	if isFileType(indicatorType) {
+		if analyzerConfig.Type != "file" {
+			return false
+		}
+		if indicatorType == "file" {
+			return true
+		}
+		for _, fileType := range analyzerConfig.NotSupportedFiletypes {
+			if fileType == indicatorType {
+				return false
+			}
+		}
+		if len(analyzerConfig.SupportedFiletypes) == 0 {
+			return true
+		}
+		for _, fileType := range analyzerConfig.SupportedFiletypes {
+			if fileType == indicatorType {
+				return true
+			}
+		}
+		return false
+	}
+	if analyzerConfig.Type == "file" {
+		// * file analyzers with run_hash also look hashes up
+		return indicatorType == "hash" && analyzerConfig.RunHash
+	}
+	for _, classification := range analyzerConfig.ObservableSupported {
+		if classification == indicatorType {
+			return true
+		}
+	}
+	return false
+}
+
+// meetsConstraints reports whether the analyzer can run under the constraints.
+func (analyzerConfig *AnalyzerConfig) meetsConstraints(constraints *CoverageConstraints) bool {
+	if analyzerConfig.Disabled {
+		return false
+	}
+	if analyzerConfig.ExternalService && (constraints.NoExternal || constraints.Tlp == RED) {
+		return false
+	}
+	if analyzerConfig.LeaksInfo && (constraints.Tlp == AMBER || constraints.Tlp == RED) {
+		return false
+	}
+	if constraints.NoSecrets {
+		for _, secret := range analyzerConfig.Secrets {
+			if secret.Required {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// PlanCoverage reports, before submitting anything, which analyzers would run for every indicator type
+// (observable classifications such as ip or domain, file mime types, or "file"), which types no analyzer covers
+// and which playbooks cover them. The client's analyzer policy is taken into account.
+// constraints can be nil.
+func (client *ThreatMatrixClient) PlanCoverage(ctx context.Context, indicatorTypes []string, constraints *CoverageConstraints) (*CoveragePlan, error) {
+	if constraints == nil {
+		constraints = &CoverageConstraints{}
+	}
+	analyzerConfigs, err := client.AnalyzerService.GetConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	playbookConfigs, err := client.playbookConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan := &CoveragePlan{
+		Analyzers: map[string][]string{},
+		Uncovered: []string{},
+		Playbooks: map[string][]PlaybookSuggestion{},
+	}
+	for _, indicatorType := range indicatorTypes {
+		runnable := map[string]bool{}
+		analyzers := []string{}
+		for index := range *analyzerConfigs {
+			analyzerConfig := &(*analyzerConfigs)[index]
+			if analyzerConfig.supportsIndicator(indicatorType) && analyzerConfig.meetsConstraints(constraints) &&
+				isAnalyzerAllowed(analyzerConfig.Name, client.options.AnalyzersAllowed, client.options.AnalyzersDenied) {
+				runnable[analyzerConfig.Name] = true
+				analyzers = append(analyzers, analyzerConfig.Name)
+			}
+		}
+		plan.Analyzers[indicatorType] = analyzers
+		if len(analyzers) == 0 {
+			plan.Uncovered = append(plan.Uncovered, indicatorType)
+		}
+		suggestions := []PlaybookSuggestion{}
+		for _, playbookConfig := range playbookConfigs {
+			if playbookConfig.Disabled || !playbookSupports(&playbookConfig, indicatorType) {
+				continue
+			}
+			suggestion := PlaybookSuggestion{Name: playbookConfig.Name, Analyzers: []string{}}
+			for analyzerName := range playbookConfig.Analyzers {
+				if runnable[analyzerName] {
+					suggestion.Analyzers = append(suggestion.Analyzers, analyzerName)
+				}
+			}
+			if len(suggestion.Analyzers) > 0 {
+				sort.Strings(suggestion.Analyzers)
+				suggestions = append(suggestions, suggestion)
+			}
+		}
+		sort.Slice(suggestions, func(i, j int) bool {
+			if len(suggestions[i].Analyzers) != len(suggestions[j].Analyzers) {
+				return len(suggestions[i].Analyzers) > len(suggestions[j].Analyzers)
+			}
+			return suggestions[i].Name < suggestions[j].Name
+		})
+		plan.Playbooks[indicatorType] = suggestions
+	}
+	return plan, nil
+}
+
+// playbookSupports reports whether the playbook accepts the indicator type, every file type being a "file".
+func playbookSupports(playbookConfig *PlaybookConfig, indicatorType string) bool {
+	for _, supported := range playbookConfig.Supports {
+		if supported == indicatorType || (supported == "file" && isFileType(indicatorType)) {
+			return true
+		}
+	}
+	return false
+}
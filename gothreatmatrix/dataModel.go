@@ -0,0 +1,175 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// DataModelEvaluation represents what the analyzers concluded about the observable of a data model.
+type DataModelEvaluation string
+
+// Values of the DataModelEvaluation enum.
+const (
+	EVALUATION_TRUSTED    DataModelEvaluation = "trusted"
+	EVALUATION_CLEAN      DataModelEvaluation = "clean"
+	EVALUATION_SUSPICIOUS DataModelEvaluation = "suspicious"
+	EVALUATION_MALICIOUS  DataModelEvaluation = "malicious"
+)
+
+// Verdict returns the Verdict of the evaluation: BENIGN for a trusted or clean observable, UNKNOWN when the
+// analyzers did not conclude.
+func (evaluation DataModelEvaluation) Verdict() Verdict {
+	switch evaluation {
+	case EVALUATION_TRUSTED, EVALUATION_CLEAN:
+		return BENIGN
+	case EVALUATION_SUSPICIOUS:
+		return SUSPICIOUS
+	case EVALUATION_MALICIOUS:
+		return MALICIOUS
+	}
+	return UNKNOWN
+}
+
+// BaseDataModel represents the fields every data model shares: the normalized outcome of the analyzer reports of
+// a job about its observable or file, whatever the analyzers.
+//
+// ThreatMatrix docs: https://threatmatrix.readthedocs.io/en/latest/Usage.html#data-model
+type BaseDataModel struct {
+	ID int `json:"id"`
+	// Evaluation is empty when the analyzers did not conclude.
+	Evaluation DataModelEvaluation `json:"evaluation"`
+	// Reliability, from 0 to 10, is how much the Evaluation can be trusted.
+	Reliability int `json:"reliability"`
+	// KillChainPhase is the phase of the kill chain the observable was seen in, e.g. "delivery" or "c2".
+	KillChainPhase     string                 `json:"kill_chain_phase"`
+	ExternalReferences []string               `json:"external_references"`
+	RelatedThreats     []string               `json:"related_threats"`
+	Tags               []string               `json:"tags"`
+	MalwareFamily      string                 `json:"malware_family"`
+	AdditionalInfo     map[string]interface{} `json:"additional_info"`
+	Date               *time.Time             `json:"date"`
+}
+
+// DomainDataModel represents the data model of a domain or URL. Rank is its popularity rank, 0 when unknown.
+type DomainDataModel struct {
+	BaseDataModel
+	IetfReport  []map[string]interface{} `json:"ietf_report"`
+	Rank        int                      `json:"rank"`
+	Resolutions []string                 `json:"resolutions"`
+}
+
+// IPDataModel represents the data model of an IP address.
+type IPDataModel struct {
+	BaseDataModel
+	IetfReport            []map[string]interface{} `json:"ietf_report"`
+	Asn                   int                      `json:"asn"`
+	AsnRank               int                      `json:"asn_rank"`
+	Certificates          []string                 `json:"certificates"`
+	OrgName               string                   `json:"org_name"`
+	CountryCode           string                   `json:"country_code"`
+	RegisteredCountryCode string                   `json:"registered_country_code"`
+	Isp                   string                   `json:"isp"`
+	Resolutions           []string                 `json:"resolutions"`
+}
+
+// FileDataModel represents the data model of a file or a hash.
+type FileDataModel struct {
+	BaseDataModel
+	Signatures      []map[string]interface{} `json:"signatures"`
+	Comments        []string                 `json:"comments"`
+	FileInformation map[string]interface{}   `json:"file_information"`
+	Stats           map[string]interface{}   `json:"stats"`
+}
+
+// DataModelService handles communication with data model related methods of the ThreatMatrix API, available on
+// the instances normalizing the analyzer reports into data models.
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/data_model
+type DataModelService struct {
+	client *ThreatMatrixClient
+}
+
+// listDataModels fetches every data model of the route.
+func listDataModels[T any](ctx context.Context, client *ThreatMatrixClient, route string) ([]T, error) {
+	paginator := newPaginator[T](client, route, nil, 0)
+	paginator.feature = FEATURE_DATA_MODELS
+	return paginator.All(ctx)
+}
+
+// getDataModel fetches the data model of the route with the ID.
+func getDataModel[T any](ctx context.Context, client *ThreatMatrixClient, route string, dataModelId uint64) (*T, error) {
+	requestUrl := fmt.Sprintf(client.options.Url+route, dataModelId)
+	contentType := "application/json"
+	method := "GET"
+	request, err := client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := client.newRequest(ctx, request)
+	if err != nil {
+		return nil, client.featureError(FEATURE_DATA_MODELS, err)
+	}
+	dataModel := new(T)
+	if unmarshalError := json.Unmarshal(successResp.Data, dataModel); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return dataModel, nil
+}
+
+// ListDomains lists every domain data model.
+//
+//	Endpoint: GET /api/data_model/domain
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/data_model/operation/data_model_domain_list
+func (dataModelService *DataModelService) ListDomains(ctx context.Context) ([]DomainDataModel, error) {
+	return listDataModels[DomainDataModel](ctx, dataModelService.client, constants.DOMAIN_DATA_MODEL_URL)
+}
+
+// GetDomain fetches a domain data model through its ID.
+//
+//	Endpoint: GET /api/data_model/domain/{id}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/data_model/operation/data_model_domain_retrieve
+func (dataModelService *DataModelService) GetDomain(ctx context.Context, dataModelId uint64) (*DomainDataModel, error) {
+	return getDataModel[DomainDataModel](ctx, dataModelService.client, constants.SPECIFIC_DOMAIN_DATA_MODEL_URL, dataModelId)
+}
+
+// ListIPs lists every IP data model.
+//
+//	Endpoint: GET /api/data_model/ip
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/data_model/operation/data_model_ip_list
+func (dataModelService *DataModelService) ListIPs(ctx context.Context) ([]IPDataModel, error) {
+	return listDataModels[IPDataModel](ctx, dataModelService.client, constants.IP_DATA_MODEL_URL)
+}
+
+// GetIP fetches an IP data model through its ID.
+//
+//	Endpoint: GET /api/data_model/ip/{id}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/data_model/operation/data_model_ip_retrieve
+func (dataModelService *DataModelService) GetIP(ctx context.Context, dataModelId uint64) (*IPDataModel, error) {
+	return getDataModel[IPDataModel](ctx, dataModelService.client, constants.SPECIFIC_IP_DATA_MODEL_URL, dataModelId)
+}
+
+// ListFiles lists every file data model.
+//
+//	Endpoint: GET /api/data_model/file
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/data_model/operation/data_model_file_list
+func (dataModelService *DataModelService) ListFiles(ctx context.Context) ([]FileDataModel, error) {
+	return listDataModels[FileDataModel](ctx, dataModelService.client, constants.FILE_DATA_MODEL_URL)
+}
+
+// GetFile fetches a file data model through its ID.
+//
+//	Endpoint: GET /api/data_model/file/{id}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/data_model/operation/data_model_file_retrieve
+func (dataModelService *DataModelService) GetFile(ctx context.Context, dataModelId uint64) (*FileDataModel, error) {
+	return getDataModel[FileDataModel](ctx, dataModelService.client, constants.SPECIFIC_FILE_DATA_MODEL_URL, dataModelId)
+}
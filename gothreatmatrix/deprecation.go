@@ -0,0 +1,140 @@
+package gothreatmatrix
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Values of the Reason of a DeprecationNotice.
+const (
+	// DEPRECATION_MARKED is the reason of the plugins the server marks as deprecated.
+	DEPRECATION_MARKED = "deprecated"
+	// DEPRECATION_REMOVED is the reason of the plugins gone from the catalog since the client last fetched it.
+	DEPRECATION_REMOVED = "removed"
+)
+
+// DeprecationNotice tells that a plugin is on its way out of the ThreatMatrix instance, so the pipelines still
+// using it can migrate before their analyses break.
+type DeprecationNotice struct {
+	// Type is ANALYZER_PLUGIN, CONNECTOR_PLUGIN or PLAYBOOK_PLUGIN.
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Reason is DEPRECATION_MARKED or DEPRECATION_REMOVED.
+	Reason     string    `json:"reason"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// String describes the notice, e.g. "analyzer Shodan_Search is deprecated".
+func (notice DeprecationNotice) String() string {
+	if notice.Reason == DEPRECATION_REMOVED {
+		return fmt.Sprintf("%s %s was removed", notice.Type, notice.Name)
+	}
+	return fmt.Sprintf("%s %s is deprecated", notice.Type, notice.Name)
+}
+
+// deprecationTracker remembers the catalogs the client fetched and the DeprecationNotices raised on them.
+type deprecationTracker struct {
+	mutex sync.Mutex
+	// names are the plugins of the last catalog of every plugin type.
+	names map[string]map[string]bool
+	// notices are keyed by plugin type, then plugin name.
+	notices map[string]map[string]DeprecationNotice
+}
+
+// observe compares the catalog of the plugin type with the previous one and returns the notices it raises:
+// a notice is raised once, when its plugin gets deprecated or removed.
+func (tracker *deprecationTracker) observe(pluginType string, names map[string]bool, deprecated map[string]bool) []DeprecationNotice {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	if tracker.names == nil {
+		tracker.names = map[string]map[string]bool{}
+		tracker.notices = map[string]map[string]DeprecationNotice{}
+	}
+	previous := tracker.names[pluginType]
+	tracker.names[pluginType] = names
+	notices := tracker.notices[pluginType]
+	if notices == nil {
+		notices = map[string]DeprecationNotice{}
+		tracker.notices[pluginType] = notices
+	}
+	now := time.Now().UTC()
+	raised := []DeprecationNotice{}
+	raise := func(name string, reason string) {
+		if notice, ok := notices[name]; ok && notice.Reason == reason {
+			return
+		}
+		notice := DeprecationNotice{Type: pluginType, Name: name, Reason: reason, DetectedAt: now}
+		notices[name] = notice
+		raised = append(raised, notice)
+	}
+	for name := range previous {
+		if !names[name] {
+			raise(name, DEPRECATION_REMOVED)
+		}
+	}
+	for name := range names {
+		if deprecated[name] {
+			raise(name, DEPRECATION_MARKED)
+		} else {
+			// * the plugin is back or no longer deprecated
+			delete(notices, name)
+		}
+	}
+	sort.Slice(raised, func(i, j int) bool {
+		return raised[i].Name < raised[j].Name
+	})
+	return raised
+}
+
+// notice returns the notice of the plugin, if any.
+func (tracker *deprecationTracker) notice(pluginType string, name string) (DeprecationNotice, bool) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	notice, ok := tracker.notices[pluginType][name]
+	return notice, ok
+}
+
+// observeCatalog raises the DeprecationNotices of a freshly read catalog to the OnDeprecation of the client options.
+func observeCatalog[T any](client *ThreatMatrixClient, pluginType string, configs map[string]T, isDeprecated func(config T) bool) {
+	if client.deprecations == nil {
+		return
+	}
+	names := make(map[string]bool, len(configs))
+	deprecated := map[string]bool{}
+	for name, config := range configs {
+		names[name] = true
+		if isDeprecated(config) {
+			deprecated[name] = true
+		}
+	}
+	for _, notice := range client.deprecations.observe(pluginType, names, deprecated) {
+		if client.options.OnDeprecation != nil {
+			client.options.OnDeprecation(notice)
+		}
+	}
+}
+
+// deprecatedReferences returns the notices of the plugins the analysis requests.
+func (client *ThreatMatrixClient) deprecatedReferences(basicAnalysisParams *BasicAnalysisParams) []DeprecationNotice {
+	if client.deprecations == nil {
+		return nil
+	}
+	var references []DeprecationNotice
+	lookup := func(pluginType string, name string) {
+		if notice, ok := client.deprecations.notice(pluginType, name); ok {
+			references = append(references, notice)
+		}
+	}
+	for _, analyzerName := range basicAnalysisParams.AnalyzersRequested {
+		lookup(ANALYZER_PLUGIN, analyzerName)
+	}
+	for _, connectorName := range basicAnalysisParams.ConnectorsRequested {
+		lookup(CONNECTOR_PLUGIN, connectorName)
+	}
+	if basicAnalysisParams.PlaybookRequested != "" {
+		lookup(PLAYBOOK_PLUGIN, basicAnalysisParams.PlaybookRequested)
+	}
+	return references
+}
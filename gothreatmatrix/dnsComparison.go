@@ -0,0 +1,163 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DEFAULT_DNS_RESOLVER_ANALYZERS are the DNS resolver analyzers compared by CompareDNSResolutions by default.
+var DEFAULT_DNS_RESOLVER_ANALYZERS = []string{"Classic_DNS", "CloudFlare_DNS", "Google_DNS", "Quad9_DNS", "DNS0_EU"}
+
+// DNSComparisonOptions represents the fields used to configure CompareDNSResolutions.
+type DNSComparisonOptions struct {
+	// Analyzers are the DNS resolver analyzers to compare, DEFAULT_DNS_RESOLVER_ANALYZERS when empty.
+	Analyzers []string
+	// Tlp of the analysis.
+	Tlp TLP
+	// WaitOptions configure how the job is waited for.
+	WaitOptions *WaitOptions
+}
+
+// DNSDifference represents how the answers of a resolver differ from the majority.
+type DNSDifference struct {
+	Analyzer string
+	// Missing are the majority answers the resolver did not return.
+	Missing []string
+	// Extra are the answers only a minority of resolvers, this one included, returned.
+	Extra []string
+}
+
+// DNSComparison represents the outcome of CompareDNSResolutions.
+type DNSComparison struct {
+	Domain string
+	JobID  uint64
+	// Answers are the sorted answers of each resolver that succeeded.
+	Answers map[string][]string
+	// Consensus are the answers returned by the majority of the resolvers.
+	Consensus   []string
+	Differences []DNSDifference
+	// Failed are the resolvers that did not produce a report.
+	Failed []string
+}
+
+// Divergent reports whether at least one resolver disagreed with the majority,
+// a hint of DNS-level blocking or poisoning.
+func (comparison *DNSComparison) Divergent() bool {
+	return len(comparison.Differences) > 0
+}
+
+// CompareDNSResolutions resolves the domain through several DNS resolver analyzers in a single job
+// and reports which resolvers diverge from the majority.
+func (client *ThreatMatrixClient) CompareDNSResolutions(ctx context.Context, domain string, options *DNSComparisonOptions) (*DNSComparison, error) {
+	if options == nil {
+		options = &DNSComparisonOptions{}
+	}
+	analyzers := options.Analyzers
+	if len(analyzers) == 0 {
+		analyzers = DEFAULT_DNS_RESOLVER_ANALYZERS
+	}
+	analysisResponse, err := client.CreateObservableAnalysis(ctx, &ObservableAnalysisParams{
+		BasicAnalysisParams: BasicAnalysisParams{
+			Tlp:                options.Tlp,
+			AnalyzersRequested: analyzers,
+		},
+		ObservableName:           domain,
+		ObservableClassification: "domain",
+	})
+	if err != nil {
+		return nil, err
+	}
+	jobId := uint64(analysisResponse.JobID)
+	waitResult, err := client.JobService.WaitForCompletion(ctx, jobId, options.WaitOptions)
+	if err != nil {
+		return nil, err
+	}
+	comparison := compareDNSReports(waitResult.Job, analyzers)
+	comparison.Domain = domain
+	comparison.JobID = jobId
+	return comparison, nil
+}
+
+// compareDNSReports compares the resolutions of the given analyzers in the job.
+func compareDNSReports(job *Job, analyzers []string) *DNSComparison {
+	comparison := &DNSComparison{
+		Answers:     map[string][]string{},
+		Consensus:   []string{},
+		Differences: []DNSDifference{},
+		Failed:      []string{},
+	}
+	reports := map[string]*Report{}
+	for index := range job.AnalyzerReports {
+		reports[job.AnalyzerReports[index].Name] = &job.AnalyzerReports[index]
+	}
+	votes := map[string]int{}
+	for _, analyzerName := range analyzers {
+		report, ok := reports[analyzerName]
+		if !ok || strings.ToUpper(report.Status) != "SUCCESS" {
+			comparison.Failed = append(comparison.Failed, analyzerName)
+			continue
+		}
+		answers := dnsAnswers(report.Report["resolutions"])
+		comparison.Answers[analyzerName] = answers
+		for _, answer := range answers {
+			votes[answer]++
+		}
+	}
+	majority := len(comparison.Answers)/2 + 1
+	consensus := map[string]bool{}
+	for answer, count := range votes {
+		if count >= majority {
+			consensus[answer] = true
+			comparison.Consensus = append(comparison.Consensus, answer)
+		}
+	}
+	sort.Strings(comparison.Consensus)
+	for _, analyzerName := range analyzers {
+		answers, ok := comparison.Answers[analyzerName]
+		if !ok {
+			continue
+		}
+		returned := map[string]bool{}
+		difference := DNSDifference{Analyzer: analyzerName}
+		for _, answer := range answers {
+			returned[answer] = true
+			if !consensus[answer] {
+				difference.Extra = append(difference.Extra, answer)
+			}
+		}
+		for _, answer := range comparison.Consensus {
+			if !returned[answer] {
+				difference.Missing = append(difference.Missing, answer)
+			}
+		}
+		if len(difference.Missing) > 0 || len(difference.Extra) > 0 {
+			comparison.Differences = append(comparison.Differences, difference)
+		}
+	}
+	return comparison
+}
+
+// dnsAnswers reads the answers of a DNS report: either plain strings or DoH records with a "data" field.
+func dnsAnswers(resolutions interface{}) []string {
+	answerSet := map[string]bool{}
+	if resolutionList, ok := resolutions.([]interface{}); ok {
+		for _, resolution := range resolutionList {
+			switch typedResolution := resolution.(type) {
+			case string:
+				answerSet[strings.ToLower(strings.TrimSuffix(typedResolution, "."))] = true
+			case map[string]interface{}:
+				if data, ok := typedResolution["data"]; ok {
+					answerSet[strings.ToLower(strings.TrimSuffix(fmt.Sprint(data), "."))] = true
+				}
+			}
+		}
+	}
+	answers := make([]string, 0, len(answerSet))
+	for answer := range answerSet {
+		answers = append(answers, answer)
+	}
+	sort.Strings(answers)
+	return answers
+}
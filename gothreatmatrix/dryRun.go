@@ -0,0 +1,120 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// dryRunReadRoutes are the POST routes that change nothing on the instance, sent even in DryRun mode.
+var dryRunReadRoutes = []string{
+	constants.ASK_ANALYSIS_AVAILABILITY_URL,
+	constants.AUTH_LOGIN_URL,
+}
+
+// DryRunRequest represents a request a client in DryRun mode recorded instead of sending it.
+type DryRunRequest struct {
+	Method      string `json:"method"`
+	Url         string `json:"url"`
+	ContentType string `json:"content_type"`
+	// Body is the body the request would have been sent with, decompressed when GzipRequestsAbove gzipped it.
+	Body       string    `json:"body"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// DryRunLog records the requests of a client in DryRun mode, see ThreatMatrixClient.DryRunLog.
+type DryRunLog struct {
+	mutex    sync.Mutex
+	requests []DryRunRequest
+}
+
+// Requests returns the requests recorded so far, oldest first.
+func (dryRunLog *DryRunLog) Requests() []DryRunRequest {
+	dryRunLog.mutex.Lock()
+	defer dryRunLog.mutex.Unlock()
+	return append([]DryRunRequest{}, dryRunLog.requests...)
+}
+
+// Reset forgets the requests recorded so far.
+func (dryRunLog *DryRunLog) Reset() {
+	dryRunLog.mutex.Lock()
+	defer dryRunLog.mutex.Unlock()
+	dryRunLog.requests = nil
+}
+
+// record adds the request to the log.
+func (dryRunLog *DryRunLog) record(request DryRunRequest) {
+	dryRunLog.mutex.Lock()
+	defer dryRunLog.mutex.Unlock()
+	dryRunLog.requests = append(dryRunLog.requests, request)
+}
+
+// DryRunLog returns the requests recorded by the client in DryRun mode, nil when it is not in DryRun mode.
+func (client *ThreatMatrixClient) DryRunLog() *DryRunLog {
+	return client.dryRun
+}
+
+// isDryRunRead reports whether the request changes nothing on the instance, so that it is sent in DryRun mode.
+func isDryRunRead(request *http.Request) bool {
+	switch request.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return true
+	}
+	for _, route := range dryRunReadRoutes {
+		if strings.HasSuffix(request.URL.Path, route) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordDryRun records the request instead of sending it when the client is in DryRun mode and the request
+// would change something on the instance. It answers such a request with a 204 whose body is an empty JSON object,
+// what the delete, kill and retry calls take for success and the create calls decode into an empty result.
+func (client *ThreatMatrixClient) recordDryRun(request *http.Request) (*http.Response, bool, error) {
+	if client.dryRun == nil || isDryRunRead(request) {
+		return nil, false, nil
+	}
+	body := []byte{}
+	if request.Body != nil {
+		var reader io.Reader = request.Body
+		if request.Header.Get("Content-Encoding") == "gzip" {
+			gzipReader, err := gzip.NewReader(request.Body)
+			if err != nil {
+				request.Body.Close()
+				return nil, true, err
+			}
+			reader = gzipReader
+		}
+		data, err := ioutil.ReadAll(reader)
+		request.Body.Close()
+		if err != nil {
+			return nil, true, err
+		}
+		body = data
+	}
+	client.dryRun.record(DryRunRequest{
+		Method:      request.Method,
+		Url:         request.URL.String(),
+		ContentType: request.Header.Get("Content-Type"),
+		Body:        string(body),
+		RecordedAt:  time.Now(),
+	})
+	return &http.Response{
+		Status:     "204 No Content",
+		StatusCode: http.StatusNoContent,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+		Request:    request,
+	}, true, nil
+}
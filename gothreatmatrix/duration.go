@@ -0,0 +1,64 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unmarshalWithDurations decodes the JSON object into value, a pointer to a struct type without an UnmarshalJSON
+// method, its time.Duration fields accepting a duration string, e.g. "1.5s" or "500ms", as well as the integer
+// nanoseconds encoding/json reads. The option structs decode themselves through it, so that a configuration
+// file can say how long to wait in words.
+func unmarshalWithDurations(data []byte, value interface{}) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		// * not an object: encoding/json reports the mismatch
+		return json.Unmarshal(data, value)
+	}
+	structType := reflect.TypeOf(value).Elem()
+	for key, raw := range fields {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 || raw[0] != '"' || !isDurationField(structType, key) {
+			continue
+		}
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return err
+		}
+		duration, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		fields[key] = json.RawMessage(strconv.FormatInt(int64(duration), 10))
+	}
+	normalized, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(normalized, value)
+}
+
+// isDurationField reports whether the key names a time.Duration field of the struct, matched like encoding/json
+// matches the keys, case-insensitively.
+func isDurationField(structType reflect.Type, key string) bool {
+	durationType := reflect.TypeOf(time.Duration(0))
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		if field.Type != durationType {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
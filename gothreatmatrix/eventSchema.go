@@ -0,0 +1,96 @@
+package gothreatmatrix
+
+//go:generate go run ../cmd/threatmatrix event-schemas ../docs/schemas
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+
+	"github.com/khulnasoft/go-threatmatrix/internal/jsonschema"
+)
+
+// EVENT_SCHEMA_VERSION is the version of the payloads described by EventSchemas, the SchemaVersion of every JobEvent.
+// It is bumped when a field is removed or changes meaning, new fields being added within a version.
+const EVENT_SCHEMA_VERSION = 1
+
+// EVENT_SCHEMA_BASE_ID is the base of the $id of the schemas of EventSchemas.
+const EVENT_SCHEMA_BASE_ID = "https://github.com/khulnasoft/go-threatmatrix/schemas/"
+
+// eventSchemaTypes maps the schemas of EventSchemas to the type they describe.
+var eventSchemaTypes = map[string]reflect.Type{
+	"job_event": reflect.TypeOf(JobEvent{}),
+	"artifact":  reflect.TypeOf(Artifact{}),
+	"summary":   reflect.TypeOf(JobSummary{}),
+}
+
+// EventSchemas returns the JSON Schemas (draft 2020-12) of the payloads the sinks and exporters write, keyed by name:
+// job_event (JobEvent), artifact (Artifact) and summary (JobSummary). They let consumers written in any language
+// validate the payloads, and are published in docs/schemas by go generate.
+//
+// Every schema carries EVENT_SCHEMA_VERSION as its version and in its $id.
+func EventSchemas() map[string]map[string]interface{} {
+	schemas := map[string]map[string]interface{}{}
+	for name, schemaType := range eventSchemaTypes {
+		definitions := map[string]interface{}{}
+		jsonschema.Of(schemaType, definitions)
+		schema := definitions[schemaType.Name()].(map[string]interface{})
+		delete(definitions, schemaType.Name())
+		if name == "job_event" {
+			properties := schema["properties"].(map[string]interface{})
+			properties["schema_version"] = map[string]interface{}{"const": EVENT_SCHEMA_VERSION}
+			properties["event"] = map[string]interface{}{"enum": []string{JOB_COMPLETED_EVENT, REPORT_COMPLETED_EVENT}}
+		}
+		schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+		schema["$id"] = eventSchemaId(name)
+		schema["title"] = "ThreatMatrix " + name
+		schema["version"] = EVENT_SCHEMA_VERSION
+		if len(definitions) > 0 {
+			schema["$defs"] = definitions
+		}
+		schemas[name] = schema
+	}
+	return schemas
+}
+
+// eventSchemaId returns the $id of the schema, e.g. https://github.com/khulnasoft/go-threatmatrix/schemas/v1/artifact.schema.json.
+func eventSchemaId(name string) string {
+	return EVENT_SCHEMA_BASE_ID + "v" + strconv.Itoa(EVENT_SCHEMA_VERSION) + "/" + eventSchemaFileName(name)
+}
+
+// eventSchemaFileName returns the name of the file the schema is published in.
+func eventSchemaFileName(name string) string {
+	return name + ".schema.json"
+}
+
+// MarshalEventSchemas returns EventSchemas as indented JSON, keyed by the name of the file they are published in.
+func MarshalEventSchemas() (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for name, schema := range EventSchemas() {
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files[eventSchemaFileName(name)] = append(data, '\n')
+	}
+	return files, nil
+}
+
+// WriteEventSchemas writes the files of MarshalEventSchemas to the directory, creating it if needed.
+func WriteEventSchemas(directory string) error {
+	files, err := MarshalEventSchemas()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return err
+	}
+	for fileName, data := range files {
+		if err := os.WriteFile(filepath.Join(directory, fileName), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
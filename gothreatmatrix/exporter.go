@@ -0,0 +1,214 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DEFAULT_EXPORT_FILE_BYTES is the size an export file is rotated at when ExportOptions leaves MaxFileBytes at 0.
+const DEFAULT_EXPORT_FILE_BYTES = 100 << 20
+
+// DEFAULT_EXPORT_PAGE_SIZE is the number of jobs listed at a time when ExportOptions leaves PageSize at 0.
+const DEFAULT_EXPORT_PAGE_SIZE = 200
+
+// ExportOptions represents the fields used to configure NewExporter.
+type ExportOptions struct {
+	// Dir is the directory the files are written to, named Prefix-00001.ndjson, Prefix-00002.ndjson, ...
+	Dir string
+	// Prefix is the name of the files, "jobs" when empty.
+	Prefix string
+	// Filter selects the jobs to export, nil for every job. Its ordering, page and page size are overridden.
+	Filter *JobFilter
+	// MaxFileBytes is the size a file is rotated at, DEFAULT_EXPORT_FILE_BYTES when 0. A file holds at least one job.
+	MaxFileBytes int64
+	// PageSize is the number of jobs listed at a time, DEFAULT_EXPORT_PAGE_SIZE when 0.
+	PageSize int
+}
+
+// ExportCheckpoint represents how far an export went, saved in Dir after every page so a stopped export resumes
+// where it was instead of starting over.
+type ExportCheckpoint struct {
+	// Page is the last page written.
+	Page int `json:"page"`
+	// LastJobID is the ID of the last job written.
+	LastJobID int `json:"last_job_id"`
+	// File is the number of the file being written, starting at 1, and FileBytes its size at the end of Page.
+	File      int   `json:"file"`
+	FileBytes int64 `json:"file_bytes"`
+	// Exported is the number of jobs written so far.
+	Exported  int       `json:"exported"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Done is true once every job was written.
+	Done bool `json:"done"`
+}
+
+// Exporter streams the jobs of an instance into newline-delimited JSON files, one JobList per line, for
+// data-warehouse backfills of hundreds of thousands of jobs. The jobs are listed by increasing ID, so the jobs
+// created during the export are exported at its end, but jobs deleted during it can shift the pages and be
+// followed by skipped ones.
+type Exporter struct {
+	client  *ThreatMatrixClient
+	options ExportOptions
+}
+
+// NewExporter returns an Exporter of the jobs matching the filter of the options.
+func (jobService *JobService) NewExporter(options *ExportOptions) *Exporter {
+	exporter := &Exporter{
+		client:  jobService.client,
+		options: *options,
+	}
+	if exporter.options.Prefix == "" {
+		exporter.options.Prefix = "jobs"
+	}
+	if exporter.options.MaxFileBytes <= 0 {
+		exporter.options.MaxFileBytes = DEFAULT_EXPORT_FILE_BYTES
+	}
+	if exporter.options.PageSize <= 0 {
+		exporter.options.PageSize = DEFAULT_EXPORT_PAGE_SIZE
+	}
+	return exporter
+}
+
+// CheckpointPath returns the path of the checkpoint file of the export.
+func (exporter *Exporter) CheckpointPath() string {
+	return filepath.Join(exporter.options.Dir, exporter.options.Prefix+".checkpoint.json")
+}
+
+// FilePath returns the path of the export file of that number.
+func (exporter *Exporter) FilePath(file int) string {
+	return filepath.Join(exporter.options.Dir, fmt.Sprintf("%s-%05d.ndjson", exporter.options.Prefix, file))
+}
+
+// Checkpoint returns the saved checkpoint of the export, a zero one when it has not started.
+func (exporter *Exporter) Checkpoint() (*ExportCheckpoint, error) {
+	checkpoint := &ExportCheckpoint{File: 1}
+	data, err := os.ReadFile(exporter.CheckpointPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// Run exports the jobs, resuming from the checkpoint of an earlier run, and returns the last checkpoint.
+// Whatever an interrupted run wrote after its last checkpoint is dropped then written again,
+// so every job is exported once. A finished export is not run again.
+func (exporter *Exporter) Run(ctx context.Context) (*ExportCheckpoint, error) {
+	checkpoint, err := exporter.Checkpoint()
+	if err != nil || checkpoint.Done {
+		return checkpoint, err
+	}
+	if err := os.MkdirAll(exporter.options.Dir, 0o755); err != nil {
+		return checkpoint, err
+	}
+	file, err := exporter.openFile(checkpoint)
+	if err != nil {
+		return checkpoint, err
+	}
+	// * the file is replaced when rotated
+	defer func() {
+		file.Close()
+	}()
+
+	jobFilter := NewJobFilter()
+	if exporter.options.Filter != nil {
+		jobFilter.values = exporter.options.Filter.Values()
+	}
+	jobFilter.Ordering("id")
+	paginator := exporter.client.JobService.Paginate(jobFilter, exporter.options.PageSize)
+	paginator.info.Page = checkpoint.Page
+	for paginator.More() {
+		jobs, err := paginator.Next(ctx)
+		if err != nil {
+			return checkpoint, err
+		}
+		next := *checkpoint
+		for index := range jobs {
+			// * a page shifted by deleted jobs lists again some already exported
+			if jobs[index].ID <= next.LastJobID {
+				continue
+			}
+			line, err := json.Marshal(&jobs[index])
+			if err != nil {
+				return checkpoint, err
+			}
+			line = append(line, '\n')
+			if next.FileBytes > 0 && next.FileBytes+int64(len(line)) > exporter.options.MaxFileBytes {
+				if err := file.Close(); err != nil {
+					return checkpoint, err
+				}
+				next.File++
+				next.FileBytes = 0
+				if file, err = exporter.openFile(&next); err != nil {
+					return checkpoint, err
+				}
+			}
+			if _, err := file.Write(line); err != nil {
+				return checkpoint, err
+			}
+			next.FileBytes += int64(len(line))
+			next.LastJobID = jobs[index].ID
+			next.Exported++
+		}
+		next.Page = paginator.info.Page
+		next.Done = !paginator.More()
+		next.UpdatedAt = time.Now()
+		if err := file.Sync(); err != nil {
+			return checkpoint, err
+		}
+		if err := exporter.saveCheckpoint(&next); err != nil {
+			return checkpoint, err
+		}
+		*checkpoint = next
+	}
+	return checkpoint, nil
+}
+
+// openFile opens the file of the checkpoint, truncated to its size at the checkpoint.
+func (exporter *Exporter) openFile(checkpoint *ExportCheckpoint) (*os.File, error) {
+	file, err := os.OpenFile(exporter.FilePath(checkpoint.File), os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Truncate(checkpoint.FileBytes); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(checkpoint.FileBytes, 0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// saveCheckpoint saves the checkpoint, replaced atomically.
+func (exporter *Exporter) saveCheckpoint(checkpoint *ExportCheckpoint) error {
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := exporter.CheckpointPath()
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), path)
+}
@@ -0,0 +1,84 @@
+package gothreatmatrix
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrFeatureUnavailable is matched by the errors of the optional features the ThreatMatrix instance does not have,
+// see FeatureUnavailableError.
+var ErrFeatureUnavailable = errors.New("gothreatmatrix: feature unavailable")
+
+// SERVER_VERSION_HEADER is the response header FeatureUnavailableError.ServerVersion is read from
+// when ThreatMatrixClient.ServerVersion is empty.
+const SERVER_VERSION_HEADER = "X-ThreatMatrix-Version"
+
+// Values of the FeatureUnavailableError.Feature field, the optional features older instances lack.
+const (
+	FEATURE_INVESTIGATIONS = "investigations"
+	FEATURE_VISUALIZERS    = "visualizers"
+	FEATURE_PIVOTS         = "pivots"
+	FEATURE_INGESTORS      = "ingestors"
+	FEATURE_DATA_MODELS    = "data models"
+	// FEATURE_BATCH_ANALYSES is the analyze_multiple_observables endpoint BulkObservableAnalysis can batch with.
+	FEATURE_BATCH_ANALYSES = "batch analyses"
+	// FEATURE_REPORT_SEARCH is the Elasticsearch search of the plugin reports, see SearchQuery.Elastic.
+	FEATURE_REPORT_SEARCH = "plugin report searches"
+)
+
+// FeatureUnavailableError is returned by the calls of an optional feature when the instance does not have its
+// endpoints, so multi-version tooling can feature-detect with errors.Is(err, ErrFeatureUnavailable)
+// instead of failing on an opaque 404.
+type FeatureUnavailableError struct {
+	// Feature is one of the FEATURE_* values.
+	Feature string
+	// ServerVersion is the version of the instance, empty when neither the client options nor the response tell it.
+	ServerVersion string
+	// Err is the ThreatMatrixError the instance answered with.
+	Err error
+}
+
+// Error lets you implement the error interface.
+func (featureError *FeatureUnavailableError) Error() string {
+	version := featureError.ServerVersion
+	if version == "" {
+		version = "unknown"
+	}
+	return fmt.Sprintf("gothreatmatrix: %s are not available on this ThreatMatrix instance (version %s)", featureError.Feature, version)
+}
+
+// Is makes errors.Is match ErrFeatureUnavailable.
+func (featureError *FeatureUnavailableError) Is(target error) bool {
+	return target == ErrFeatureUnavailable
+}
+
+// Unwrap returns the ThreatMatrixError the instance answered with.
+func (featureError *FeatureUnavailableError) Unwrap() error {
+	return featureError.Err
+}
+
+// featureError turns the error of a call of the optional feature into a FeatureUnavailableError when the instance
+// does not have the endpoint: a 501, or a 404 from the router rather than a JSON 404 for a missing object.
+// Other errors are returned unchanged.
+func (client *ThreatMatrixClient) featureError(feature string, err error) error {
+	var threatMatrixError *ThreatMatrixError
+	if !errors.As(err, &threatMatrixError) {
+		return err
+	}
+	switch threatMatrixError.StatusCode {
+	case http.StatusNotImplemented:
+	case http.StatusNotFound:
+		if strings.HasPrefix(strings.TrimSpace(threatMatrixError.Message), "{") {
+			return err
+		}
+	default:
+		return err
+	}
+	serverVersion := client.ServerVersion()
+	if serverVersion == "" && threatMatrixError.Response != nil {
+		serverVersion = threatMatrixError.Response.Header.Get(SERVER_VERSION_HEADER)
+	}
+	return &FeatureUnavailableError{Feature: feature, ServerVersion: serverVersion, Err: err}
+}
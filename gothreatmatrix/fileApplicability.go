@@ -0,0 +1,94 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// fileTypeMimeTypes are the mime types ThreatMatrix, through libmagic, gives the file types DetectFileType tells apart
+// and the mime type sniffing of the Go standard library does not.
+var fileTypeMimeTypes = map[string]string{
+	FILE_TYPE_PE:    "application/vnd.microsoft.portable-executable",
+	FILE_TYPE_ELF:   "application/x-executable",
+	FILE_TYPE_MACHO: "application/x-mach-binary",
+	FILE_TYPE_PDF:   "application/pdf",
+	FILE_TYPE_APK:   "application/vnd.android.package-archive",
+}
+
+// Reasons of FileApplicability.Rejected.
+const (
+	REJECTED_DISABLED              = "disabled"
+	REJECTED_DENIED                = "denied by the analyzer policy of the client"
+	REJECTED_UNSUPPORTED_FILE_TYPE = "unsupported file type"
+)
+
+// FileApplicability represents which file analyzers would accept a file, see ThreatMatrixClient.FileApplicability.
+type FileApplicability struct {
+	FileName string `json:"file_name"`
+	// MimeType is the mime type the file is matched against the analyzers with, as ThreatMatrix would detect it.
+	MimeType string `json:"mime_type"`
+	// FileType is the kind of file told by DetectFileType.
+	FileType string `json:"file_type"`
+	// Analyzers are the file analyzers that would accept the file, sorted.
+	Analyzers []string `json:"analyzers"`
+	// Rejected maps the other file analyzers to the reason they would not run, one of the REJECTED_* values.
+	Rejected map[string]string `json:"rejected"`
+}
+
+// DetectFileMimeType tells from the content of the file the mime type ThreatMatrix would detect for it, and rewinds it.
+// The executables, PDFs and APKs get their libmagic mime type, the other files the one Go sniffs.
+func DetectFileMimeType(file *os.File) (string, error) {
+	fileType, err := DetectFileType(file)
+	if err != nil {
+		return "", err
+	}
+	if mimeType, ok := fileTypeMimeTypes[fileType]; ok {
+		return mimeType, nil
+	}
+	return detectMimeType(file)
+}
+
+// FileApplicability reports, without uploading anything, which file analyzers of the catalog would accept the file
+// according to their supported and not supported file types, and why the others would not.
+// The catalog is the one AnalyzerService.GetConfigs reads, the catalog snapshot when the client has one.
+func (client *ThreatMatrixClient) FileApplicability(ctx context.Context, file *os.File) (*FileApplicability, error) {
+	fileType, err := DetectFileType(file)
+	if err != nil {
+		return nil, err
+	}
+	mimeType, err := DetectFileMimeType(file)
+	if err != nil {
+		return nil, err
+	}
+	analyzerConfigs, err := client.AnalyzerService.GetConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applicability := &FileApplicability{
+		FileName:  filepath.Base(file.Name()),
+		MimeType:  mimeType,
+		FileType:  fileType,
+		Analyzers: []string{},
+		Rejected:  map[string]string{},
+	}
+	for index := range *analyzerConfigs {
+		analyzerConfig := &(*analyzerConfigs)[index]
+		if analyzerConfig.Type != "file" {
+			continue
+		}
+		switch {
+		case analyzerConfig.Disabled:
+			applicability.Rejected[analyzerConfig.Name] = REJECTED_DISABLED
+		case !isAnalyzerAllowed(analyzerConfig.Name, client.options.AnalyzersAllowed, client.options.AnalyzersDenied):
+			applicability.Rejected[analyzerConfig.Name] = REJECTED_DENIED
+		case !analyzerConfig.supportsIndicator(mimeType):
+			applicability.Rejected[analyzerConfig.Name] = REJECTED_UNSUPPORTED_FILE_TYPE
+		default:
+			applicability.Analyzers = append(applicability.Analyzers, analyzerConfig.Name)
+		}
+	}
+	sort.Strings(applicability.Analyzers)
+	return applicability, nil
+}
@@ -0,0 +1,110 @@
+package gothreatmatrix
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"strings"
+)
+
+// Values of the file types DetectFileType tells apart, used as ThreatMatrixClientOptions.FileTypePlaybooks keys.
+const (
+	FILE_TYPE_PE      = "pe"
+	FILE_TYPE_ELF     = "elf"
+	FILE_TYPE_MACHO   = "macho"
+	FILE_TYPE_OFFICE  = "office"
+	FILE_TYPE_PDF     = "pdf"
+	FILE_TYPE_APK     = "apk"
+	FILE_TYPE_ARCHIVE = "archive"
+	FILE_TYPE_UNKNOWN = "unknown"
+)
+
+// DetectFileType tells from the content of the file from its current offset, the part of it an upload sends, what kind
+// of file it is, FILE_TYPE_UNKNOWN when none, and seeks the file back to that offset.
+// Unlike the mime type sniffed for DefaultPlaybooks, it looks inside zip containers to tell Office documents and APKs apart
+// from plain archives, and recognizes legacy OLE Office documents and executables.
+func DetectFileType(file *os.File) (string, error) {
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	header := make([]byte, 512)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	header = header[:n]
+	switch {
+	case isPE(header):
+		return FILE_TYPE_PE, nil
+	case bytes.HasPrefix(header, []byte("\x7fELF")):
+		return FILE_TYPE_ELF, nil
+	case isMachO(header):
+		return FILE_TYPE_MACHO, nil
+	case bytes.HasPrefix(header, []byte("%PDF-")):
+		return FILE_TYPE_PDF, nil
+	case bytes.HasPrefix(header, []byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1}):
+		// * the OLE compound format of the legacy .doc, .xls and .ppt documents
+		return FILE_TYPE_OFFICE, nil
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		return detectZipType(file, offset)
+	case bytes.HasPrefix(header, []byte("Rar!\x1a\x07")), bytes.HasPrefix(header, []byte("7z\xbc\xaf\x27\x1c")), bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return FILE_TYPE_ARCHIVE, nil
+	}
+	return FILE_TYPE_UNKNOWN, nil
+}
+
+// isPE reports whether the header is the one of a Windows executable: an MZ stub pointing to a PE signature.
+// A stub pointing past the header is trusted, the signature cannot be checked then.
+func isPE(header []byte) bool {
+	if len(header) < 0x40 || !bytes.HasPrefix(header, []byte("MZ")) {
+		return false
+	}
+	offset := int(binary.LittleEndian.Uint32(header[0x3c:0x40]))
+	if offset+4 > len(header) {
+		return true
+	}
+	return bytes.Equal(header[offset:offset+4], []byte("PE\x00\x00"))
+}
+
+// isMachO reports whether the header is the one of a macOS executable, thin or universal.
+func isMachO(header []byte) bool {
+	for _, magic := range [][]byte{{0xfe, 0xed, 0xfa, 0xce}, {0xfe, 0xed, 0xfa, 0xcf}, {0xce, 0xfa, 0xed, 0xfe}, {0xcf, 0xfa, 0xed, 0xfe}, {0xca, 0xfe, 0xba, 0xbe}} {
+		if bytes.HasPrefix(header, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectZipType tells an APK and an Office Open XML document from a plain zip archive by the entries of the zip
+// starting at the offset of the file.
+func detectZipType(file *os.File, offset int64) (string, error) {
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	reader, err := zip.NewReader(io.NewSectionReader(file, offset, fileInfo.Size()-offset), fileInfo.Size()-offset)
+	if err != nil {
+		// * a truncated or self-extracting zip is still an archive
+		return FILE_TYPE_ARCHIVE, nil
+	}
+	office := false
+	for _, entry := range reader.File {
+		switch {
+		case entry.Name == "AndroidManifest.xml" || entry.Name == "classes.dex":
+			return FILE_TYPE_APK, nil
+		case entry.Name == "[Content_Types].xml", strings.HasPrefix(entry.Name, "word/"), strings.HasPrefix(entry.Name, "xl/"), strings.HasPrefix(entry.Name, "ppt/"):
+			office = true
+		}
+	}
+	if office {
+		return FILE_TYPE_OFFICE, nil
+	}
+	return FILE_TYPE_ARCHIVE, nil
+}
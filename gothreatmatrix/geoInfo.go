@@ -0,0 +1,206 @@
+package gothreatmatrix
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GeoLocation represents coordinates in decimal degrees.
+type GeoLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// GeoInfo represents the geolocation and ASN of an IP, normalized from whichever analyzers returned them.
+type GeoInfo struct {
+	// CountryCode is the ISO 3166-1 alpha-2 code of the country, e.g. "US".
+	CountryCode    string       `json:"country_code,omitempty"`
+	Country        string       `json:"country,omitempty"`
+	Region         string       `json:"region,omitempty"`
+	City           string       `json:"city,omitempty"`
+	Location       *GeoLocation `json:"location,omitempty"`
+	ASN            int          `json:"asn,omitempty"`
+	ASOrganization string       `json:"as_organization,omitempty"`
+	// Sources maps every field set, by its JSON name, to the analyzer it was taken from.
+	Sources map[string]string `json:"sources"`
+}
+
+// GEO_SOURCE_PRIORITY lists the analyzers GeoInfo is extracted from, by name prefix, the most trusted first.
+// A field reported by several analyzers is taken from the first of them.
+var GEO_SOURCE_PRIORITY = []string{"MaxMind", "IPInfo", "GreyNoise"}
+
+// geoExtractors parse the report of each GEO_SOURCE_PRIORITY source.
+var geoExtractors = map[string]func(report map[string]interface{}) GeoInfo{
+	"MaxMind":   extractMaxMindGeoInfo,
+	"IPInfo":    extractIPInfoGeoInfo,
+	"GreyNoise": extractGreyNoiseGeoInfo,
+}
+
+// ExtractGeoInfo normalizes the geolocation and ASN data of the analyzer reports of the job into one GeoInfo,
+// resolving conflicts through GEO_SOURCE_PRIORITY. It returns nil when no report had any.
+func ExtractGeoInfo(job *Job) *GeoInfo {
+	merged := GeoInfo{Sources: map[string]string{}}
+	for _, source := range GEO_SOURCE_PRIORITY {
+		extract, ok := geoExtractors[source]
+		if !ok {
+			continue
+		}
+		for index := range job.AnalyzerReports {
+			report := &job.AnalyzerReports[index]
+			if !strings.HasPrefix(strings.ToLower(report.Name), strings.ToLower(source)) || report.Report == nil {
+				continue
+			}
+			merged.merge(extract(report.Report), report.Name)
+		}
+	}
+	if len(merged.Sources) == 0 {
+		return nil
+	}
+	return &merged
+}
+
+// merge sets the fields still empty from the other info, recording the analyzer they come from.
+func (geoInfo *GeoInfo) merge(other GeoInfo, analyzer string) {
+	mergeString := func(field string, target *string, value string) {
+		if *target == "" && value != "" {
+			*target = value
+			geoInfo.Sources[field] = analyzer
+		}
+	}
+	mergeString("country_code", &geoInfo.CountryCode, strings.ToUpper(other.CountryCode))
+	mergeString("country", &geoInfo.Country, other.Country)
+	mergeString("region", &geoInfo.Region, other.Region)
+	mergeString("city", &geoInfo.City, other.City)
+	mergeString("as_organization", &geoInfo.ASOrganization, other.ASOrganization)
+	if geoInfo.Location == nil && other.Location != nil {
+		geoInfo.Location = other.Location
+		geoInfo.Sources["location"] = analyzer
+	}
+	if geoInfo.ASN == 0 && other.ASN != 0 {
+		geoInfo.ASN = other.ASN
+		geoInfo.Sources["asn"] = analyzer
+	}
+}
+
+// geoString returns the string at the key of the map, empty when it is missing or not a string.
+func geoString(values map[string]interface{}, key string) string {
+	value, _ := values[key].(string)
+	return strings.TrimSpace(value)
+}
+
+// geoMap returns the map at the key of the map, nil when it is missing or not a map.
+func geoMap(values map[string]interface{}, key string) map[string]interface{} {
+	value, _ := values[key].(map[string]interface{})
+	return value
+}
+
+// geoEnglishName returns the English name of a MaxMind record, e.g. {"names": {"en": "Italy"}}.
+func geoEnglishName(record map[string]interface{}) string {
+	return geoString(geoMap(record, "names"), "en")
+}
+
+// parseASN parses "AS15169", "15169" or a JSON number.
+func parseASN(value interface{}) int {
+	switch typedValue := value.(type) {
+	case float64:
+		return int(typedValue)
+	case string:
+		number, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(typedValue)), "AS"))
+		if err == nil {
+			return number
+		}
+	}
+	return 0
+}
+
+// maxMindRecordKeys are the fields of a GeoIP2 record, as opposed to the databases nesting records.
+var maxMindRecordKeys = map[string]bool{
+	"country": true, "city": true, "location": true, "continent": true, "registered_country": true, "represented_country": true, "postal": true, "traits": true,
+}
+
+// extractMaxMindGeoInfo parses the GeoIP2 records of a MaxMind report, the city, country and ASN databases
+// being either merged at the top of the report or nested under the name of each database.
+func extractMaxMindGeoInfo(report map[string]interface{}) GeoInfo {
+	geoInfo := GeoInfo{Sources: map[string]string{}}
+	var visit func(record map[string]interface{})
+	visit = func(record map[string]interface{}) {
+		if country := geoMap(record, "country"); country != nil {
+			part := GeoInfo{CountryCode: geoString(country, "iso_code"), Country: geoEnglishName(country)}
+			geoInfo.merge(part, "")
+		}
+		if subdivisions, ok := record["subdivisions"].([]interface{}); ok && len(subdivisions) > 0 {
+			if subdivision, ok := subdivisions[0].(map[string]interface{}); ok {
+				geoInfo.merge(GeoInfo{Region: geoEnglishName(subdivision)}, "")
+			}
+		}
+		if city := geoMap(record, "city"); city != nil {
+			geoInfo.merge(GeoInfo{City: geoEnglishName(city)}, "")
+		}
+		if location := geoMap(record, "location"); location != nil {
+			latitude, latitudeOk := location["latitude"].(float64)
+			longitude, longitudeOk := location["longitude"].(float64)
+			if latitudeOk && longitudeOk {
+				geoInfo.merge(GeoInfo{Location: &GeoLocation{Latitude: latitude, Longitude: longitude}}, "")
+			}
+		}
+		geoInfo.merge(GeoInfo{
+			ASN:            parseASN(record["autonomous_system_number"]),
+			ASOrganization: geoString(record, "autonomous_system_organization"),
+		}, "")
+		// * the databases are visited in a stable order
+		keys := make([]string, 0, len(record))
+		for key := range record {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if nested, ok := record[key].(map[string]interface{}); ok && !maxMindRecordKeys[key] {
+				visit(nested)
+			}
+		}
+	}
+	visit(report)
+	return geoInfo
+}
+
+// extractIPInfoGeoInfo parses an IPInfo report, e.g. {"country": "US", "city": "Mountain View", "loc": "37.4,-122.1", "org": "AS15169 Google LLC"}.
+func extractIPInfoGeoInfo(report map[string]interface{}) GeoInfo {
+	geoInfo := GeoInfo{
+		CountryCode: geoString(report, "country"),
+		Region:      geoString(report, "region"),
+		City:        geoString(report, "city"),
+	}
+	if latitude, longitude, ok := strings.Cut(geoString(report, "loc"), ","); ok {
+		latitudeValue, latitudeErr := strconv.ParseFloat(strings.TrimSpace(latitude), 64)
+		longitudeValue, longitudeErr := strconv.ParseFloat(strings.TrimSpace(longitude), 64)
+		if latitudeErr == nil && longitudeErr == nil {
+			geoInfo.Location = &GeoLocation{Latitude: latitudeValue, Longitude: longitudeValue}
+		}
+	}
+	if asn := geoMap(report, "asn"); asn != nil {
+		geoInfo.ASN = parseASN(asn["asn"])
+		geoInfo.ASOrganization = geoString(asn, "name")
+	} else if org := geoString(report, "org"); strings.HasPrefix(strings.ToUpper(org), "AS") {
+		number, name, _ := strings.Cut(org, " ")
+		geoInfo.ASN = parseASN(number)
+		geoInfo.ASOrganization = strings.TrimSpace(name)
+	}
+	return geoInfo
+}
+
+// extractGreyNoiseGeoInfo parses the metadata of a GreyNoise report, the community API reporting none.
+func extractGreyNoiseGeoInfo(report map[string]interface{}) GeoInfo {
+	metadata := geoMap(report, "metadata")
+	if metadata == nil {
+		return GeoInfo{}
+	}
+	return GeoInfo{
+		CountryCode:    geoString(metadata, "country_code"),
+		Country:        geoString(metadata, "country"),
+		Region:         geoString(metadata, "region"),
+		City:           geoString(metadata, "city"),
+		ASN:            parseASN(metadata["asn"]),
+		ASOrganization: geoString(metadata, "organization"),
+	}
+}
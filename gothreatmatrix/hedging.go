@@ -0,0 +1,77 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// DEFAULT_HEDGING_DELAY is the wait before the hedged attempt when HedgingOptions.Delay is 0.
+const DEFAULT_HEDGING_DELAY = 200 * time.Millisecond
+
+// HedgingOptions represents how the read-only requests are hedged, see ThreatMatrixClientOptions.Hedging.
+// Only the GET requests are hedged, so that a hedged attempt never changes anything on the instance twice.
+// Disable hedging for a call with WithoutHedging.
+type HedgingOptions struct {
+	// Delay is how long the first attempt waits for a response before the second attempt is sent,
+	// DEFAULT_HEDGING_DELAY when 0. Setting it around the 95th percentile of the latency of the instance
+	// hedges the slowest requests only.
+	Delay time.Duration `json:"delay"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, Delay also reading a duration string, e.g. "200ms".
+func (hedgingOptions *HedgingOptions) UnmarshalJSON(data []byte) error {
+	type plainHedgingOptions HedgingOptions
+	return unmarshalWithDurations(data, (*plainHedgingOptions)(hedgingOptions))
+}
+
+// hedgedAttempt represents the outcome of an attempt of a hedged request.
+type hedgedAttempt struct {
+	response *successResponse
+	err      error
+}
+
+// sendHedged sends the request, hedged when the client hedges it: a second attempt is sent if the first one did not
+// answer within the delay, the first successful response is returned and the other attempt is canceled.
+// An attempt failing before the delay is returned as is, the RetryOptions deciding whether to send it again.
+func (client *ThreatMatrixClient) sendHedged(ctx context.Context, request *http.Request) (*successResponse, error) {
+	hedging := client.options.Hedging
+	if hedging == nil || request.Method != "GET" || request.Body != nil || hedgingDisabled(ctx) {
+		return client.sendRequest(ctx, request)
+	}
+	delay := hedging.Delay
+	if delay <= 0 {
+		delay = DEFAULT_HEDGING_DELAY
+	}
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	attempts := make(chan hedgedAttempt, 2)
+	send := func() {
+		response, err := client.sendRequest(hedgeCtx, request.Clone(hedgeCtx))
+		attempts <- hedgedAttempt{response: response, err: err}
+	}
+	go send()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	pending, hedged := 1, false
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			hedged = true
+			pending++
+			go send()
+		case attempt := <-attempts:
+			pending--
+			if attempt.err == nil || !hedged {
+				return attempt.response, attempt.err
+			}
+			if firstErr == nil {
+				firstErr = attempt.err
+			}
+			if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
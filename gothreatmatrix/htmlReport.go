@@ -0,0 +1,163 @@
+package gothreatmatrix
+
+import (
+	"html/template"
+	"io"
+	"strings"
+)
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>ThreatMatrix job {{.Summary.JobID}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+img { max-width: 100%; border: 1px solid #ccc; }
+</style>
+</head>
+<body>
+<h1>Job {{.Summary.JobID}}</h1>
+<table>
+<tr><th>Status</th><td>{{.Summary.Status}}</td></tr>
+{{- if .Summary.ObservableName}}
+<tr><th>Observable</th><td>{{.Summary.ObservableName}} ({{.Summary.ObservableClassification}})</td></tr>
+{{- end}}
+{{- if .Summary.FileName}}
+<tr><th>File</th><td>{{.Summary.FileName}}</td></tr>
+{{- end}}
+<tr><th>MD5</th><td>{{.Summary.Md5}}</td></tr>
+<tr><th>TLP</th><td>{{.Summary.Tlp}}</td></tr>
+<tr><th>Tags</th><td>{{range $index, $tag := .Summary.Tags}}{{if $index}}, {{end}}{{$tag}}{{end}}</td></tr>
+</table>
+{{- if .Summary.MalwareFamilies}}
+<h2>Malware families</h2>
+<ul>
+{{- range .Summary.MalwareFamilies}}
+<li>{{.Name}} ({{.Detections}} detections)</li>
+{{- end}}
+</ul>
+{{- end}}
+<h2>Analyzers</h2>
+<table>
+<tr><th>Analyzer</th><th>Status</th></tr>
+{{- range .Reports}}
+<tr><td>{{.Name}}</td><td>{{.Status}}</td></tr>
+{{- end}}
+</table>
+{{- if .Screenshots}}
+<h2>Screenshots</h2>
+{{- range .Screenshots}}
+<figure><img src="{{.Source}}" alt="{{.Analyzer}} screenshot"><figcaption>{{.Analyzer}}: {{.Field}}</figcaption></figure>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+type htmlReportScreenshot struct {
+	Analyzer string
+	Field    string
+	Source   template.URL
+}
+
+// WriteHTMLReport renders a self-contained HTML report of the job: its summary, analyzers, and screenshots,
+// which are embedded so the page needs no remote resource.
+func WriteHTMLReport(writer io.Writer, job *Job) error {
+	screenshots := []htmlReportScreenshot{}
+	for _, screenshot := range ExtractScreenshots(job) {
+		screenshots = append(screenshots, htmlReportScreenshot{
+			Analyzer: screenshot.Analyzer,
+			Field:    screenshot.Field,
+			// * the image was decoded and re-encoded by us, it is safe to embed as is
+			Source: template.URL(screenshot.DataURI()),
+		})
+	}
+	return htmlReportTemplate.Execute(writer, struct {
+		Summary     *JobSummary
+		Reports     []Report
+		Screenshots []htmlReportScreenshot
+	}{
+		Summary:     job.Summary(),
+		Reports:     job.AnalyzerReports,
+		Screenshots: screenshots,
+	})
+}
+
+// Email clients strip <style> blocks and block remote resources, so every style of the email is inlined.
+var htmlInvestigationEmailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>ThreatMatrix investigation {{.Investigation.ID}}</title>
+</head>
+<body style="margin: 0; padding: 16px; font-family: Arial, Helvetica, sans-serif; font-size: 14px; color: #222222; background-color: #ffffff;">
+<h1 style="font-size: 20px; margin: 0 0 8px 0;">Investigation: {{.Investigation.Name}}</h1>
+{{- if .Investigation.Description}}
+<p style="margin: 0 0 16px 0;">{{.Investigation.Description}}</p>
+{{- end}}
+<table cellpadding="0" cellspacing="0" border="0" style="border-collapse: collapse; margin: 0 0 16px 0;">
+<tr><th align="left" style="padding: 4px 12px 4px 0;">Status</th><td style="padding: 4px 0;">{{.Investigation.Status}}</td></tr>
+<tr><th align="left" style="padding: 4px 12px 4px 0;">Jobs</th><td style="padding: 4px 0;">{{len .Jobs}}</td></tr>
+{{- if .Investigation.Tags}}
+<tr><th align="left" style="padding: 4px 12px 4px 0;">Tags</th><td style="padding: 4px 0;">{{range $index, $tag := .Investigation.Tags}}{{if $index}}, {{end}}{{$tag}}{{end}}</td></tr>
+{{- end}}
+</table>
+<table cellpadding="0" cellspacing="0" border="0" width="100%" style="border-collapse: collapse;">
+<tr style="background-color: #f2f2f2;"><th align="left" style="border: 1px solid #cccccc; padding: 6px;">Job</th><th align="left" style="border: 1px solid #cccccc; padding: 6px;">Indicator</th><th align="left" style="border: 1px solid #cccccc; padding: 6px;">Status</th><th align="left" style="border: 1px solid #cccccc; padding: 6px;">Malware families</th></tr>
+{{- range .Jobs}}
+<tr><td style="border: 1px solid #cccccc; padding: 6px;">{{.JobID}}</td><td style="border: 1px solid #cccccc; padding: 6px; font-family: Courier New, monospace;">{{.Indicator}}</td><td style="border: 1px solid #cccccc; padding: 6px;">{{.Status}}</td><td style="border: 1px solid #cccccc; padding: 6px;">{{range $index, $family := .MalwareFamilies}}{{if $index}}, {{end}}{{$family.Name}}{{end}}</td></tr>
+{{- end}}
+</table>
+<p style="margin: 16px 0 0 0; font-size: 12px; color: #666666;">Indicators are defanged so they cannot be clicked: restore "[.]" to "." and "hxxp" to "http" before using them.</p>
+</body>
+</html>
+`))
+
+type htmlInvestigationEmailJob struct {
+	*JobSummary
+	Indicator string
+}
+
+// WriteInvestigationEmail renders an HTML summary of the investigation and its jobs fit to be sent by email
+// to non-analyst stakeholders: the styles are inlined, no remote resource is referenced,
+// and the indicators are defanged (see DefangIndicator) so no mail client turns them into links.
+func WriteInvestigationEmail(writer io.Writer, investigation *Investigation, jobs []Job) error {
+	emailJobs := make([]htmlInvestigationEmailJob, 0, len(jobs))
+	for index := range jobs {
+		summary := jobs[index].Summary()
+		indicator := summary.ObservableName
+		if indicator == "" {
+			indicator = summary.FileName
+		}
+		emailJobs = append(emailJobs, htmlInvestigationEmailJob{
+			JobSummary: summary,
+			Indicator:  DefangIndicator(indicator),
+		})
+	}
+	return htmlInvestigationEmailTemplate.Execute(writer, struct {
+		Investigation *Investigation
+		Jobs          []htmlInvestigationEmailJob
+	}{
+		Investigation: investigation,
+		Jobs:          emailJobs,
+	})
+}
+
+// defangReplacer neutralizes the schemes, dots and at signs that make an indicator clickable.
+var defangReplacer = strings.NewReplacer(
+	"http://", "hxxp://",
+	"https://", "hxxps://",
+	"ftp://", "fxp://",
+	".", "[.]",
+	"@", "[@]",
+	"://", "[://]",
+)
+
+// DefangIndicator returns the indicator (an URL, a domain, an IP, an email address...) in a form that cannot be
+// resolved nor clicked, e.g. "hxxps://evil[.]com/login". Hashes and other indicators without dots are left as is.
+func DefangIndicator(indicator string) string {
+	return defangReplacer.Replace(indicator)
+}
@@ -0,0 +1,137 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"sync"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// inFlightSubmission represents an analysis being submitted, that identical submissions wait for.
+type inFlightSubmission struct {
+	done     chan struct{}
+	response *AnalysisResponse
+	err      error
+	// canceled is set when the submission failed because the context of its caller was done.
+	canceled bool
+}
+
+// inFlightSubmissions holds the analyses being submitted by the client, by submission key.
+type inFlightSubmissions struct {
+	mutex       sync.Mutex
+	submissions map[string]*inFlightSubmission
+}
+
+// newIdempotencyKey returns a random idempotency key.
+func newIdempotencyKey() (string, error) {
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(keyBytes), nil
+}
+
+// setIdempotencyKey sends the idempotency key of the analysis with the request, a random one for an analysis with
+// none when the client generates them. The key is set once on the request, so that its retries send the same one.
+func (client *ThreatMatrixClient) setIdempotencyKey(request *http.Request, basicAnalysisParams *BasicAnalysisParams) error {
+	idempotencyKey := basicAnalysisParams.IdempotencyKey
+	if idempotencyKey == "" && client.options.GenerateIdempotencyKeys {
+		generatedKey, err := newIdempotencyKey()
+		if err != nil {
+			return err
+		}
+		idempotencyKey = generatedKey
+	}
+	if idempotencyKey != "" {
+		request.Header.Set(IDEMPOTENCY_KEY_HEADER, idempotencyKey)
+	}
+	return nil
+}
+
+// submissionKey returns the key identifying a submission of the route: the hash of its JSON encoded identity.
+func submissionKey(route string, identity interface{}) (string, error) {
+	identityBytes, err := json.Marshal(identity)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(append([]byte(route+"\n"), identityBytes...))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// observableSubmissionKey returns the key of an observable analysis, its idempotency key and fresh scan flag included.
+func observableSubmissionKey(params *ObservableAnalysisParams) (string, error) {
+	return submissionKey(constants.ANALYZE_OBSERVABLE_URL, struct {
+		Params         *ObservableAnalysisParams `json:"params"`
+		ForceFreshScan bool                      `json:"force_fresh_scan"`
+		IdempotencyKey string                    `json:"idempotency_key"`
+	}{params, params.ForceFreshScan, params.IdempotencyKey})
+}
+
+// fileSubmissionKey returns the key of a file analysis, identified by the md5 and the name of the file.
+func fileSubmissionKey(params *FileAnalysisParams) (string, error) {
+	fileHash, err := fileMd5(params.File)
+	if err != nil {
+		return "", err
+	}
+	return submissionKey(constants.ANALYZE_FILE_URL, struct {
+		Params         *BasicAnalysisParams `json:"params"`
+		ForceFreshScan bool                 `json:"force_fresh_scan"`
+		IdempotencyKey string               `json:"idempotency_key"`
+		FileName       string               `json:"file_name"`
+		Md5            string               `json:"md5"`
+		ExtraFields    map[string]string    `json:"extra_fields"`
+	}{&params.BasicAnalysisParams, params.ForceFreshScan, params.IdempotencyKey, filepath.Base(params.File.Name()), fileHash, params.ExtraFields})
+}
+
+// dedupeSubmission runs the submission, unless an identical one is in flight: it then waits for that one and
+// returns its response, so that concurrent identical submissions create a single job. When the one in flight fails
+// because the context of its own caller is done, the callers waiting for it submit again under their context,
+// one of them leading the others.
+func (client *ThreatMatrixClient) dedupeSubmission(ctx context.Context, key string, submit func() (*AnalysisResponse, error)) (*AnalysisResponse, error) {
+	inFlight := client.inFlight
+	for {
+		inFlight.mutex.Lock()
+		submission, ok := inFlight.submissions[key]
+		if !ok {
+			break
+		}
+		inFlight.mutex.Unlock()
+		select {
+		case <-submission.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if !submission.canceled {
+			return submission.result()
+		}
+	}
+	submission := &inFlightSubmission{done: make(chan struct{})}
+	if inFlight.submissions == nil {
+		inFlight.submissions = map[string]*inFlightSubmission{}
+	}
+	inFlight.submissions[key] = submission
+	inFlight.mutex.Unlock()
+
+	submission.response, submission.err = submit()
+	submission.canceled = submission.err != nil && ctx.Err() != nil
+	inFlight.mutex.Lock()
+	delete(inFlight.submissions, key)
+	inFlight.mutex.Unlock()
+	close(submission.done)
+	return submission.result()
+}
+
+// result returns a copy of the response of the submission, along with its error.
+func (submission *inFlightSubmission) result() (*AnalysisResponse, error) {
+	if submission.response == nil {
+		return nil, submission.err
+	}
+	response := *submission.response
+	return &response, submission.err
+}
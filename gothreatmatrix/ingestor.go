@@ -0,0 +1,75 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// IngestorConfig represents how an ingestor is configured in ThreatMatrix.
+//
+// ThreatMatrix docs: https://threatmatrix.readthedocs.io/en/latest/Usage.html#ingestors
+type IngestorConfig struct {
+	BaseConfigurationType
+	// PlaybookToExecute is the playbook the ingested observables are analyzed with.
+	PlaybookToExecute string `json:"playbook_to_execute"`
+	// Schedule is the crontab the ingestor runs on, e.g. "*/10 * * * *".
+	Schedule string `json:"schedule"`
+}
+
+// IngestorService handles communication with ingestor related methods of the ThreatMatrix API.
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/ingestor
+type IngestorService struct {
+	client *ThreatMatrixClient
+}
+
+// GetConfigs lists down every ingestor configuration in your ThreatMatrix instance.
+//
+//	Endpoint: GET /api/get_ingestor_configs
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/get_ingestor_configs
+func (ingestorService *IngestorService) GetConfigs(ctx context.Context) (*[]IngestorConfig, error) {
+	ingestorConfigurationResponse, err := fetchPluginConfigs[IngestorConfig](ctx, ingestorService.client, constants.INGESTOR_CONFIG_URL)
+	if err != nil {
+		return nil, ingestorService.client.featureError(FEATURE_INGESTORS, err)
+	}
+	return sortPluginConfigs(ingestorConfigurationResponse), nil
+}
+
+// HealthCheck checks if the specified ingestor is up and running
+//
+//	Endpoint: GET /api/ingestor/{NameOfIngestor}/healthcheck
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/ingestor/operation/ingestor_healthcheck_retrieve
+func (ingestorService *IngestorService) HealthCheck(ctx context.Context, ingestorName string) (bool, error) {
+	status, err := pluginHealthCheck(ctx, ingestorService.client, constants.INGESTOR_HEALTHCHECK_URL, ingestorName)
+	return status, ingestorService.client.featureError(FEATURE_INGESTORS, err)
+}
+
+// Pull triggers a run of the ingestor right away, outside of its Schedule, e.g. to fetch the latest IOCs of a
+// ThreatFox or MalwareBazaar feed. The ingested observables are analyzed with its PlaybookToExecute as usual.
+//
+//	Endpoint: POST /api/ingestor/{NameOfIngestor}/pull
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/ingestor/operation/ingestor_pull_create
+func (ingestorService *IngestorService) Pull(ctx context.Context, ingestorName string) (bool, error) {
+	requestUrl := fmt.Sprintf(ingestorService.client.options.Url+constants.INGESTOR_PULL_URL, ingestorName)
+	contentType := "application/json"
+	method := "POST"
+	request, err := ingestorService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return false, err
+	}
+	successResp, err := ingestorService.client.newRequest(ctx, request)
+	if err != nil {
+		return false, ingestorService.client.featureError(FEATURE_INGESTORS, err)
+	}
+	status := StatusResponse{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &status); unmarshalError != nil {
+		return false, unmarshalError
+	}
+	return status.Status, nil
+}
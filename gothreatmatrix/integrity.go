@@ -0,0 +1,308 @@
+package gothreatmatrix
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Integrity errors, wrapped by IntegrityError.
+var (
+	ErrSampleChecksumMismatch  = errors.New("gothreatmatrix: the downloaded sample does not match the job hashes")
+	ErrUploadChecksumMismatch  = errors.New("gothreatmatrix: the uploaded file does not match the job hashes")
+	ErrBundleManifestMissing   = errors.New("gothreatmatrix: the bundle has no manifest")
+	ErrBundleChecksumMismatch  = errors.New("gothreatmatrix: a bundle file does not match the manifest")
+	ErrBundleFileMissing       = errors.New("gothreatmatrix: a file listed in the bundle manifest is missing")
+	ErrBundleFileNotInManifest = errors.New("gothreatmatrix: a bundle file is not listed in the manifest")
+)
+
+// BUNDLE_MANIFEST_NAME is the name of the manifest in a job bundle, in the sha256sum format.
+const BUNDLE_MANIFEST_NAME = "MANIFEST.sha256"
+
+// IntegrityError represents content that does not match its expected checksum.
+type IntegrityError struct {
+	// Subject is what was verified: "sample", the name of the uploaded file or the name of the bundle file.
+	Subject   string
+	Algorithm string
+	Expected  string
+	Actual    string
+	Err       error
+}
+
+// Error lets you implement the error interface.
+func (integrityError *IntegrityError) Error() string {
+	if integrityError.Algorithm == "" {
+		return fmt.Sprintf("%s: %s", integrityError.Err, integrityError.Subject)
+	}
+	return fmt.Sprintf("%s: %s %s is %s, expected %s", integrityError.Err, integrityError.Subject, integrityError.Algorithm, integrityError.Actual, integrityError.Expected)
+}
+
+// Unwrap returns the integrity error sentinel, so errors.Is can be used.
+func (integrityError *IntegrityError) Unwrap() error {
+	return integrityError.Err
+}
+
+// sampleHashes returns the hashes the ThreatMatrix instance stored for the sample of the job:
+// the md5 of the job and the sha1 and sha256 computed by the File_Info analyzer, when it ran.
+func sampleHashes(job *Job) map[string]string {
+	hashes := map[string]string{}
+	if job.Md5 != "" {
+		hashes["md5"] = strings.ToLower(job.Md5)
+	}
+	for index := range job.AnalyzerReports {
+		report := &job.AnalyzerReports[index]
+		if report.Name != "File_Info" {
+			continue
+		}
+		for _, algorithm := range []string{"sha1", "sha256"} {
+			if value, ok := report.Report[algorithm].(string); ok && value != "" {
+				hashes[algorithm] = strings.ToLower(value)
+			}
+		}
+	}
+	return hashes
+}
+
+// VerifySample reads the sample and compares it with every hash stored for the job,
+// returning an *IntegrityError wrapping ErrSampleChecksumMismatch on the first mismatch.
+func VerifySample(job *Job, sample io.Reader) error {
+	expected := sampleHashes(job)
+	hashers := map[string]hash.Hash{"md5": md5.New(), "sha1": sha1.New(), "sha256": sha256.New()}
+	writers := []io.Writer{}
+	for algorithm := range expected {
+		writers = append(writers, hashers[algorithm])
+	}
+	if len(writers) == 0 {
+		return nil
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), sample); err != nil {
+		return err
+	}
+	for _, algorithm := range []string{"md5", "sha1", "sha256"} {
+		expectedHash, ok := expected[algorithm]
+		if !ok {
+			continue
+		}
+		if actualHash := hex.EncodeToString(hashers[algorithm].Sum(nil)); actualHash != expectedHash {
+			return &IntegrityError{
+				Subject:   "sample",
+				Algorithm: algorithm,
+				Expected:  expectedHash,
+				Actual:    actualHash,
+				Err:       ErrSampleChecksumMismatch,
+			}
+		}
+	}
+	return nil
+}
+
+// verifyUpload fetches the job of a file analysis and compares its hashes with the file from the offset it was
+// uploaded from, then seeks the file back to that offset.
+// A mismatch is returned as an *IntegrityError wrapping ErrUploadChecksumMismatch.
+func (client *ThreatMatrixClient) verifyUpload(ctx context.Context, jobId int, file *os.File, offset int64) error {
+	job, err := client.JobService.Get(ctx, uint64(jobId))
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	err = VerifySample(job, file)
+	if _, seekErr := file.Seek(offset, io.SeekStart); err == nil {
+		err = seekErr
+	}
+	var integrityError *IntegrityError
+	if errors.As(err, &integrityError) {
+		integrityError.Subject = filepath.Base(file.Name())
+		integrityError.Err = ErrUploadChecksumMismatch
+	}
+	return err
+}
+
+// WriteJobBundle writes a zip bundle of the job: job.json, report.html, its screenshots and, when not nil, the sample.
+// The bundle ends with a MANIFEST.sha256 listing the SHA256 of every other file, see VerifyJobBundle.
+func WriteJobBundle(writer io.Writer, job *Job, sample []byte) error {
+	files := map[string][]byte{}
+	jobJson, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+	files["job.json"] = jobJson
+	htmlReport := &bytes.Buffer{}
+	if err := WriteHTMLReport(htmlReport, job); err != nil {
+		return err
+	}
+	files["report.html"] = htmlReport.Bytes()
+	for index, screenshot := range ExtractScreenshots(job) {
+		files[fmt.Sprintf("screenshots/%s_%d%s", sanitizeFileName(screenshot.Analyzer), index+1, screenshot.Extension())] = screenshot.Data
+	}
+	if sample != nil {
+		files["sample/"+sanitizeFileName(path.Base(job.FileName))] = sample
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	archive := zip.NewWriter(writer)
+	manifest := &bytes.Buffer{}
+	for _, name := range names {
+		fileWriter, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := fileWriter.Write(files[name]); err != nil {
+			return err
+		}
+		checksum := sha256.Sum256(files[name])
+		fmt.Fprintf(manifest, "%s  %s\n", hex.EncodeToString(checksum[:]), name)
+	}
+	manifestWriter, err := archive.Create(BUNDLE_MANIFEST_NAME)
+	if err != nil {
+		return err
+	}
+	if _, err := manifestWriter.Write(manifest.Bytes()); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// ExportBundle fetches the job, and its sample when includeSample is true, and writes its bundle, see WriteJobBundle.
+// The sample is verified against the job hashes before being bundled.
+//
+//	Endpoint: GET /api/jobs/{jobID}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_retrieve
+func (jobService *JobService) ExportBundle(ctx context.Context, jobId uint64, writer io.Writer, includeSample bool) error {
+	job, err := jobService.Get(ctx, jobId)
+	if err != nil {
+		return err
+	}
+	var sample []byte
+	if includeSample && job.IsSample {
+		if sample, err = jobService.DownloadSample(ctx, jobId); err != nil {
+			return err
+		}
+		if err := VerifySample(job, bytes.NewReader(sample)); err != nil {
+			return err
+		}
+	}
+	return WriteJobBundle(writer, job, sample)
+}
+
+// VerifyJobBundle checks every file of the bundle against its manifest, returning an *IntegrityError
+// for a missing manifest, a file whose SHA256 differs, a listed file that is missing or a file that is not listed.
+func VerifyJobBundle(reader io.ReaderAt, size int64) error {
+	archive, err := zip.NewReader(reader, size)
+	if err != nil {
+		return err
+	}
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	manifestFile, ok := files[BUNDLE_MANIFEST_NAME]
+	if !ok {
+		return &IntegrityError{Subject: BUNDLE_MANIFEST_NAME, Err: ErrBundleManifestMissing}
+	}
+	delete(files, BUNDLE_MANIFEST_NAME)
+	manifest, err := readBundleManifest(manifestFile)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(manifest))
+	for name := range manifest {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file, ok := files[name]
+		if !ok {
+			return &IntegrityError{Subject: name, Err: ErrBundleFileMissing}
+		}
+		delete(files, name)
+		actualHash, err := zipFileSha256(file)
+		if err != nil {
+			return err
+		}
+		if actualHash != manifest[name] {
+			return &IntegrityError{
+				Subject:   name,
+				Algorithm: "sha256",
+				Expected:  manifest[name],
+				Actual:    actualHash,
+				Err:       ErrBundleChecksumMismatch,
+			}
+		}
+	}
+	for name := range files {
+		return &IntegrityError{Subject: name, Err: ErrBundleFileNotInManifest}
+	}
+	return nil
+}
+
+// VerifyJobBundleFile verifies the bundle stored at filePath, see VerifyJobBundle.
+func VerifyJobBundleFile(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	return VerifyJobBundle(file, fileInfo.Size())
+}
+
+// readBundleManifest parses the "<sha256>  <name>" lines of the manifest.
+func readBundleManifest(manifestFile *zip.File) (map[string]string, error) {
+	reader, err := manifestFile.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	manifest := map[string]string{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "  ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed manifest line %q", line)
+		}
+		manifest[fields[1]] = strings.ToLower(fields[0])
+	}
+	return manifest, scanner.Err()
+}
+
+// zipFileSha256 returns the hex encoded SHA256 of the content of the file.
+func zipFileSha256(file *zip.File) (string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,250 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// Investigation represents an investigation grouping several jobs in ThreatMatrix.
+type Investigation struct {
+	ID          uint64      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Owner       UserDetails `json:"owner"`
+	Status      string      `json:"status"`
+	Tags        []string    `json:"tags"`
+	Jobs        []uint64    `json:"jobs"`
+	TotalJobs   int         `json:"total_jobs"`
+	StartTime   *time.Time  `json:"start_time"`
+	EndTime     *time.Time  `json:"end_time"`
+}
+
+// InvestigationListResponse represents a page of the investigations of your ThreatMatrix instance.
+type InvestigationListResponse struct {
+	Count      int             `json:"count"`
+	TotalPages int             `json:"total_pages"`
+	Results    []Investigation `json:"results"`
+}
+
+// InvestigationTreeJob represents a job of an InvestigationTree, with the jobs its pivots started.
+type InvestigationTreeJob struct {
+	ID                  uint64                 `json:"pk"`
+	AnalyzedObjectName  string                 `json:"analyzed_object_name"`
+	Playbook            string                 `json:"playbook"`
+	Status              string                 `json:"status"`
+	IsSample            bool                   `json:"is_sample"`
+	ReceivedRequestTime *time.Time             `json:"received_request_time"`
+	Children            []InvestigationTreeJob `json:"children"`
+}
+
+// InvestigationTree represents the jobs of an investigation as a tree, the jobs started by pivots being the
+// children of the job they pivoted from.
+type InvestigationTree struct {
+	Name string                 `json:"name"`
+	Jobs []InvestigationTreeJob `json:"jobs"`
+}
+
+// InvestigationParams represents the fields needed for creating investigations.
+type InvestigationParams struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// INVESTIGATION_UI_PATH is the path of an investigation in the ThreatMatrix web interface.
+const INVESTIGATION_UI_PATH = "/investigation/%d"
+
+// InvestigationService handles communication with investigation related methods of ThreatMatrix API.
+type InvestigationService struct {
+	client *ThreatMatrixClient
+}
+
+// Create lets you create a new investigation.
+//
+//	Endpoint: POST /api/investigation
+func (investigationService *InvestigationService) Create(ctx context.Context, investigationParams *InvestigationParams) (*Investigation, error) {
+	requestUrl := investigationService.client.options.Url + constants.BASE_INVESTIGATION_URL
+	investigationJson, err := json.Marshal(investigationParams)
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/json"
+	method := "POST"
+	body := bytes.NewBuffer(investigationJson)
+	request, err := investigationService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := investigationService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, investigationService.client.featureError(FEATURE_INVESTIGATIONS, err)
+	}
+	createdInvestigation := Investigation{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &createdInvestigation); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &createdInvestigation, nil
+}
+
+// List fetches the first page of the investigations, see Paginate to walk all of them.
+//
+//	Endpoint: GET /api/investigation
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/investigation/operation/investigation_list
+func (investigationService *InvestigationService) List(ctx context.Context) (*InvestigationListResponse, error) {
+	requestUrl := investigationService.client.options.Url + constants.BASE_INVESTIGATION_URL
+	contentType := "application/json"
+	method := "GET"
+	request, err := investigationService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := investigationService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, investigationService.client.featureError(FEATURE_INVESTIGATIONS, err)
+	}
+	investigationList := InvestigationListResponse{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &investigationList); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &investigationList, nil
+}
+
+// Get fetches a specific investigation through its ID.
+//
+//	Endpoint: GET /api/investigation/{investigationID}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/investigation/operation/investigation_retrieve
+func (investigationService *InvestigationService) Get(ctx context.Context, investigationId uint64) (*Investigation, error) {
+	route := investigationService.client.options.Url + constants.SPECIFIC_INVESTIGATION_URL
+	requestUrl := fmt.Sprintf(route, investigationId)
+	contentType := "application/json"
+	method := "GET"
+	request, err := investigationService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := investigationService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, investigationService.client.featureError(FEATURE_INVESTIGATIONS, err)
+	}
+	investigation := Investigation{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &investigation); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &investigation, nil
+}
+
+// Tree fetches the jobs of an investigation as a tree, see InvestigationTree.
+//
+//	Endpoint: GET /api/investigation/{investigationID}/tree
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/investigation/operation/investigation_tree_retrieve
+func (investigationService *InvestigationService) Tree(ctx context.Context, investigationId uint64) (*InvestigationTree, error) {
+	route := investigationService.client.options.Url + constants.INVESTIGATION_TREE_URL
+	requestUrl := fmt.Sprintf(route, investigationId)
+	contentType := "application/json"
+	method := "GET"
+	request, err := investigationService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := investigationService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, investigationService.client.featureError(FEATURE_INVESTIGATIONS, err)
+	}
+	investigationTree := InvestigationTree{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &investigationTree); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &investigationTree, nil
+}
+
+// Delete lets you delete an investigation, its jobs are kept.
+//
+//	Endpoint: DELETE /api/investigation/{investigationID}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/investigation/operation/investigation_destroy
+func (investigationService *InvestigationService) Delete(ctx context.Context, investigationId uint64) (bool, error) {
+	route := investigationService.client.options.Url + constants.SPECIFIC_INVESTIGATION_URL
+	requestUrl := fmt.Sprintf(route, investigationId)
+	contentType := "application/json"
+	method := "DELETE"
+	request, err := investigationService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return false, err
+	}
+	successResp, err := investigationService.client.newRequest(ctx, request)
+	if err != nil {
+		return false, investigationService.client.featureError(FEATURE_INVESTIGATIONS, err)
+	}
+	if successResp.StatusCode == http.StatusNoContent {
+		return true, nil
+	}
+	return false, nil
+}
+
+// AddJob lets you add a job to an investigation.
+//
+//	Endpoint: POST /api/investigation/{investigationID}/add_job
+func (investigationService *InvestigationService) AddJob(ctx context.Context, investigationId uint64, jobId uint64) (bool, error) {
+	route := investigationService.client.options.Url + constants.ADD_JOB_TO_INVESTIGATION_URL
+	requestUrl := fmt.Sprintf(route, investigationId)
+	jobJson, err := json.Marshal(map[string]uint64{"job": jobId})
+	if err != nil {
+		return false, err
+	}
+	contentType := "application/json"
+	method := "POST"
+	body := bytes.NewBuffer(jobJson)
+	request, err := investigationService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	if err != nil {
+		return false, err
+	}
+	successResp, err := investigationService.client.newRequest(ctx, request)
+	if err != nil {
+		return false, investigationService.client.featureError(FEATURE_INVESTIGATIONS, err)
+	}
+	if successResp.StatusCode == http.StatusOK || successResp.StatusCode == http.StatusNoContent {
+		return true, nil
+	}
+	return false, nil
+}
+
+// RemoveJob lets you remove a job from an investigation, the job itself is kept.
+//
+//	Endpoint: POST /api/investigation/{investigationID}/remove_job
+func (investigationService *InvestigationService) RemoveJob(ctx context.Context, investigationId uint64, jobId uint64) (bool, error) {
+	route := investigationService.client.options.Url + constants.REMOVE_JOB_FROM_INVESTIGATION_URL
+	requestUrl := fmt.Sprintf(route, investigationId)
+	jobJson, err := json.Marshal(map[string]uint64{"job": jobId})
+	if err != nil {
+		return false, err
+	}
+	contentType := "application/json"
+	method := "POST"
+	body := bytes.NewBuffer(jobJson)
+	request, err := investigationService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	if err != nil {
+		return false, err
+	}
+	successResp, err := investigationService.client.newRequest(ctx, request)
+	if err != nil {
+		return false, investigationService.client.featureError(FEATURE_INVESTIGATIONS, err)
+	}
+	if successResp.StatusCode == http.StatusOK || successResp.StatusCode == http.StatusNoContent {
+		return true, nil
+	}
+	return false, nil
+}
+
+// WebUrl returns the URL of the investigation in the ThreatMatrix web interface, e.g. to link it from a ticket.
+func (investigationService *InvestigationService) WebUrl(investigationId uint64) string {
+	return investigationService.client.options.Url + fmt.Sprintf(INVESTIGATION_UI_PATH, investigationId)
+}
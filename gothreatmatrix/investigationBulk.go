@@ -0,0 +1,134 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// InvestigationJobResult represents the outcome of a bulk action on one job of an investigation.
+type InvestigationJobResult struct {
+	JobID uint64
+	// Retry lists the plugins run again, set by RetryFailed only.
+	Retry *JobRetry
+	Err   error
+}
+
+// flattenInvestigationJobs returns the jobs of the tree and the ones their pivots started, parents first.
+func flattenInvestigationJobs(jobs []InvestigationTreeJob) []InvestigationTreeJob {
+	flattened := []InvestigationTreeJob{}
+	for _, job := range jobs {
+		flattened = append(flattened, job)
+		flattened = append(flattened, flattenInvestigationJobs(job.Children)...)
+	}
+	return flattened
+}
+
+// forEachJob runs the action on every job of the investigation the filter keeps, each result carrying its own error.
+// It stops, with the results so far, when the tree can't be fetched or the context is done.
+func (investigationService *InvestigationService) forEachJob(ctx context.Context, investigationId uint64, keep func(status JobStatus) bool, action func(result *InvestigationJobResult)) ([]InvestigationJobResult, error) {
+	tree, err := investigationService.Tree(ctx, investigationId)
+	if err != nil {
+		return nil, err
+	}
+	results := []InvestigationJobResult{}
+	for _, job := range flattenInvestigationJobs(tree.Jobs) {
+		if !keep(JobStatus(job.Status)) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result := InvestigationJobResult{JobID: job.ID}
+		action(&result)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// KillRunning kills every job of the investigation, pivoted ones included, that has not stopped yet.
+// A job that could not be killed does not stop the others, its result carries the error.
+//
+//	Endpoint: GET /api/investigation/{investigationID}/tree
+//	Endpoint: PATCH /api/jobs/{jobID}/kill
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/investigation
+func (investigationService *InvestigationService) KillRunning(ctx context.Context, investigationId uint64) ([]InvestigationJobResult, error) {
+	jobService := investigationService.client.JobService
+	return investigationService.forEachJob(ctx, investigationId, JobStatus.CanKill, func(result *InvestigationJobResult) {
+		_, result.Err = jobService.Kill(ctx, result.JobID)
+	})
+}
+
+// RetryFailed re-runs the failed and killed plugins of every job of the investigation that failed or reported
+// with fails, see JobService.Retry. A job that could not be retried does not stop the others.
+//
+//	Endpoint: GET /api/investigation/{investigationID}/tree
+//	Endpoint: GET /api/jobs/{jobID}
+//	Endpoint: PATCH /api/jobs/{jobID}/analyzer/{nameOfAnalyzer}/retry
+//	Endpoint: PATCH /api/jobs/{jobID}/connector/{nameOfConnector}/retry
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/investigation
+func (investigationService *InvestigationService) RetryFailed(ctx context.Context, investigationId uint64) ([]InvestigationJobResult, error) {
+	jobService := investigationService.client.JobService
+	failed := func(status JobStatus) bool {
+		return status == FAILED || status == REPORTED_WITH_FAILS
+	}
+	return investigationService.forEachJob(ctx, investigationId, failed, func(result *InvestigationJobResult) {
+		result.Retry, result.Err = jobService.Retry(ctx, result.JobID)
+	})
+}
+
+// ExportAll writes every job of the investigation to the sinks, see JobService.ExportJob.
+// A job that could not be exported does not stop the others.
+//
+//	Endpoint: GET /api/investigation/{investigationID}/tree
+//	Endpoint: GET /api/jobs/{jobID}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/investigation
+func (investigationService *InvestigationService) ExportAll(ctx context.Context, investigationId uint64, sinks ...JobSink) ([]InvestigationJobResult, error) {
+	jobService := investigationService.client.JobService
+	every := func(status JobStatus) bool {
+		return true
+	}
+	return investigationService.forEachJob(ctx, investigationId, every, func(result *InvestigationJobResult) {
+		result.Err = jobService.ExportJob(ctx, result.JobID, sinks...)
+	})
+}
+
+// Retag replaces the tags of the investigation with the labels. The tags of its jobs are set when they are
+// submitted and can't be changed through the API.
+//
+//	Endpoint: PATCH /api/investigation/{investigationID}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/investigation/operation/investigation_partial_update
+func (investigationService *InvestigationService) Retag(ctx context.Context, investigationId uint64, labels []string) (*Investigation, error) {
+	route := investigationService.client.options.Url + constants.SPECIFIC_INVESTIGATION_URL
+	requestUrl := fmt.Sprintf(route, investigationId)
+	if labels == nil {
+		labels = []string{}
+	}
+	tagsJson, err := json.Marshal(map[string][]string{"tags": labels})
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/json"
+	method := "PATCH"
+	body := bytes.NewBuffer(tagsJson)
+	request, err := investigationService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := investigationService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, investigationService.client.featureError(FEATURE_INVESTIGATIONS, err)
+	}
+	investigation := Investigation{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &investigation); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &investigation, nil
+}
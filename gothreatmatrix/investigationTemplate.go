@@ -0,0 +1,161 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// InvestigationTemplate represents a scaffold for a common kind of incident: the playbooks run on its indicators
+// and the tags given to the investigation and to every job of it.
+type InvestigationTemplate struct {
+	// Name prefixes the name of the investigations created from the template.
+	Name        string
+	Description string
+	Tags        []string
+	// Playbooks maps an indicator type, an observable classification or DEFAULT_FILE_PLAYBOOK_KEY for files,
+	// to the playbooks run on the indicators of that type.
+	Playbooks map[string][]string
+	// Tlp of the analyses, AMBER when 0.
+	Tlp TLP
+}
+
+// Predefined investigation templates, copy them to change their playbooks or tags.
+var (
+	PHISHING_EMAIL_TEMPLATE = InvestigationTemplate{
+		Name:        "Phishing email",
+		Description: "Triage of a reported phishing email: its links, sender domains and attachments.",
+		Tags:        []string{"phishing"},
+		Playbooks: map[string][]string{
+			"url":                     {"Popular_URL_Reputation_Services"},
+			"domain":                  {"Dns", "Popular_URL_Reputation_Services"},
+			"ip":                      {"Popular_IP_Reputation_Services"},
+			DEFAULT_FILE_PLAYBOOK_KEY: {"Sample_Static_Analysis"},
+		},
+	}
+	SUSPICIOUS_BINARY_TEMPLATE = InvestigationTemplate{
+		Name:        "Suspicious binary",
+		Description: "Analysis of a suspicious executable and of the hashes it is known by.",
+		Tags:        []string{"malware"},
+		Playbooks: map[string][]string{
+			"hash":                    {"FREE_TO_USE_ANALYZERS"},
+			DEFAULT_FILE_PLAYBOOK_KEY: {"Sample_Static_Analysis"},
+		},
+	}
+	C2_BEACON_TEMPLATE = InvestigationTemplate{
+		Name:        "C2 beacon",
+		Description: "Investigation of the infrastructure contacted by a beaconing host.",
+		Tags:        []string{"c2"},
+		Playbooks: map[string][]string{
+			"ip":     {"Popular_IP_Reputation_Services"},
+			"domain": {"Dns", "Passive_DNS"},
+			"url":    {"Popular_URL_Reputation_Services"},
+		},
+	}
+)
+
+// InvestigationSeed represents the indicators an investigation created from a template starts with.
+type InvestigationSeed struct {
+	// Name is appended to the name of the template, e.g. the ticket of the incident.
+	Name string
+	// Observables are classified by the client, see ThreatMatrixClient.ClassifyObservable.
+	Observables []string
+	Files       []*os.File
+}
+
+// TemplatedInvestigation represents an investigation created from a template.
+type TemplatedInvestigation struct {
+	Investigation *Investigation
+	// Jobs are the jobs of the playbook runs, in the order they were submitted.
+	Jobs []uint64
+	// Skipped are the observables with no playbook in the template.
+	Skipped []string
+}
+
+// CreateFromTemplate creates an investigation from the template and seeds it in one call: every indicator
+// is analyzed with the playbooks of its type, and the investigation and its jobs get the tags of the template.
+// When a submission fails, the investigation and the jobs created so far are returned along with the error.
+//
+//	Endpoint: POST /api/investigation
+//	Endpoint: POST /api/investigation/{investigationID}/add_job
+func (investigationService *InvestigationService) CreateFromTemplate(ctx context.Context, template *InvestigationTemplate, seed *InvestigationSeed) (*TemplatedInvestigation, error) {
+	name := template.Name
+	if seed.Name != "" {
+		name = fmt.Sprintf("%s: %s", template.Name, seed.Name)
+	}
+	investigation, err := investigationService.Create(ctx, &InvestigationParams{
+		Name:        name,
+		Description: template.Description,
+		Tags:        template.Tags,
+	})
+	if err != nil {
+		return nil, err
+	}
+	templated := &TemplatedInvestigation{
+		Investigation: investigation,
+		Jobs:          []uint64{},
+		Skipped:       []string{},
+	}
+	tlp := template.Tlp
+	if tlp == TLP(0) {
+		tlp = AMBER
+	}
+	client := investigationService.client
+	submit := func(playbook string, analyze func(basicAnalysisParams BasicAnalysisParams) (*AnalysisResponse, error)) error {
+		analysisResponse, err := analyze(BasicAnalysisParams{
+			Tlp:               tlp,
+			TagsLabels:        append([]string{}, template.Tags...),
+			PlaybookRequested: playbook,
+		})
+		if err != nil {
+			return err
+		}
+		jobId := uint64(analysisResponse.JobID)
+		if _, err := investigationService.AddJob(ctx, investigation.ID, jobId); err != nil {
+			return err
+		}
+		templated.Jobs = append(templated.Jobs, jobId)
+		return nil
+	}
+	for _, observable := range seed.Observables {
+		classification := client.ClassifyObservable(observable)
+		playbooks := template.Playbooks[classification]
+		if len(playbooks) == 0 {
+			templated.Skipped = append(templated.Skipped, observable)
+			continue
+		}
+		for _, playbook := range playbooks {
+			err := submit(playbook, func(basicAnalysisParams BasicAnalysisParams) (*AnalysisResponse, error) {
+				return client.CreateObservableAnalysis(ctx, &ObservableAnalysisParams{
+					BasicAnalysisParams:      basicAnalysisParams,
+					ObservableName:           observable,
+					ObservableClassification: classification,
+				})
+			})
+			if err != nil {
+				return templated, err
+			}
+		}
+	}
+	for _, file := range seed.Files {
+		for _, playbook := range template.Playbooks[DEFAULT_FILE_PLAYBOOK_KEY] {
+			err := submit(playbook, func(basicAnalysisParams BasicAnalysisParams) (*AnalysisResponse, error) {
+				// * every playbook run uploads the file from the start
+				if _, err := file.Seek(0, io.SeekStart); err != nil {
+					return nil, err
+				}
+				return client.CreateFileAnalysis(ctx, &FileAnalysisParams{
+					BasicAnalysisParams: basicAnalysisParams,
+					File:                file,
+				})
+			})
+			if err != nil {
+				return templated, err
+			}
+		}
+	}
+	sort.Strings(templated.Skipped)
+	return templated, nil
+}
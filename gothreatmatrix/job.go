@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/khulnasoft/go-threatmatrix/constants"
@@ -57,6 +58,9 @@ type Job struct {
 	AnalyzerReports  []Report               `json:"analyzer_reports"`
 	ConnectorReports []Report               `json:"connector_reports"`
 	Permission       map[string]interface{} `json:"permission"`
+	// DataModel is the final evaluation the analysis engine made of the job from its reports, nil on the instances
+	// without data models or before the engine ran, see Verdict.
+	DataModel *BaseDataModel `json:"data_model,omitempty"`
 }
 
 // JobList represents a list of jobs in ThreatMatrix.
@@ -83,7 +87,22 @@ type JobService struct {
 //
 // ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_list
 func (jobService *JobService) List(ctx context.Context) (*JobListResponse, error) {
+	return jobService.ListWithFilter(ctx, nil)
+}
+
+// ListWithFilter fetches the jobs in your ThreatMatrix instance that match the given JobFilter.
+// A nil filter fetches every job.
+//
+//	Endpoint: GET /api/jobs
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_list
+func (jobService *JobService) ListWithFilter(ctx context.Context, jobFilter *JobFilter) (*JobListResponse, error) {
 	requestUrl := jobService.client.options.Url + constants.BASE_JOB_URL
+	if jobFilter != nil {
+		if query := jobFilter.Encode(); query != "" {
+			requestUrl = requestUrl + "?" + query
+		}
+	}
 	contentType := "application/json"
 	method := "GET"
 	request, err := jobService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
@@ -129,6 +148,48 @@ func (jobService *JobService) Get(ctx context.Context, jobId uint64) (*Job, erro
 	return &jobResponse, nil
 }
 
+// JobResult represents the outcome of fetching a single job when fetching many jobs at once.
+type JobResult struct {
+	ID  uint64
+	Job *Job
+	Err error
+}
+
+// GetMany fetches the given jobs in parallel with at most concurrency requests in flight.
+// The results are returned in the same order as jobIds, each one carrying its own error.
+// A concurrency lower than 1 is treated as 1.
+//
+//	Endpoint: GET /api/jobs/{jobID}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_retrieve
+func (jobService *JobService) GetMany(ctx context.Context, jobIds []uint64, concurrency int) []JobResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]JobResult, len(jobIds))
+	semaphore := make(chan struct{}, concurrency)
+	var waitGroup sync.WaitGroup
+	for index, jobId := range jobIds {
+		results[index].ID = jobId
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			results[index].Err = ctx.Err()
+			continue
+		}
+		waitGroup.Add(1)
+		go func(index int, jobId uint64) {
+			defer waitGroup.Done()
+			defer func() { <-semaphore }()
+			job, err := jobService.Get(ctx, jobId)
+			results[index].Job = job
+			results[index].Err = err
+		}(index, jobId)
+	}
+	waitGroup.Wait()
+	return results
+}
+
 // DownloadSample fetches the File sample with the given job through its job ID.
 //
 //	Endpoint: GET /api/jobs/{jobID}/download_sample
@@ -293,3 +354,27 @@ func (jobService *JobService) RetryConnector(ctx context.Context, jobId uint64,
 	}
 	return false, nil
 }
+
+// Implementing the UnmarshalJSON interface so that the free-form parts of a report,
+// written by the analyzer, cannot make the whole job undecodable.
+func (report *Report) UnmarshalJSON(data []byte) error {
+	type reportAlias Report
+	aux := struct {
+		*reportAlias
+		Report               json.RawMessage `json:"report"`
+		RuntimeConfiguration json.RawMessage `json:"runtime_configuration"`
+	}{
+		reportAlias: (*reportAlias)(report),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	if report.Report, err = decodeUntrustedObject(aux.Report); err != nil {
+		return err
+	}
+	if report.RuntimeConfiguration, err = decodeUntrustedObject(aux.RuntimeConfiguration); err != nil {
+		return err
+	}
+	return nil
+}
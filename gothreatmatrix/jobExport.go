@@ -0,0 +1,260 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidExportFormat is returned, wrapped with the culprit, by JobService.Export for an unknown encoding or column.
+var ErrInvalidExportFormat = errors.New("gothreatmatrix: invalid export format")
+
+// Encodings of a JobExportFormat.
+const (
+	// EXPORT_JSONL writes every job as a line of JSON, an object keyed by the columns.
+	EXPORT_JSONL = "jsonl"
+	// EXPORT_CSV writes a header row of the columns, then a row for every job.
+	EXPORT_CSV = "csv"
+)
+
+// Columns of a JobExportFormat.
+const (
+	EXPORT_COLUMN_ID             = "id"
+	EXPORT_COLUMN_OBSERVABLE     = "observable"
+	EXPORT_COLUMN_CLASSIFICATION = "classification"
+	EXPORT_COLUMN_MD5            = "md5"
+	EXPORT_COLUMN_STATUS         = "status"
+	EXPORT_COLUMN_TLP            = "tlp"
+	// EXPORT_COLUMN_VERDICT is the verdict told by JobExportFormat.Verdict.
+	EXPORT_COLUMN_VERDICT = "verdict"
+	// EXPORT_COLUMN_TAGS are the tag labels, joined by ";" in CSV.
+	EXPORT_COLUMN_TAGS = "tags"
+	// EXPORT_COLUMN_RECEIVED and EXPORT_COLUMN_FINISHED are the received and finished times, RFC 3339 formatted.
+	EXPORT_COLUMN_RECEIVED = "received_request_time"
+	EXPORT_COLUMN_FINISHED = "finished_analysis_time"
+	// EXPORT_COLUMN_ANALYZERS are the analyzers run by the job, joined by ";" in CSV.
+	EXPORT_COLUMN_ANALYZERS = "analyzers"
+)
+
+// DEFAULT_EXPORT_COLUMNS are the columns exported when JobExportFormat leaves Columns empty.
+var DEFAULT_EXPORT_COLUMNS = []string{
+	EXPORT_COLUMN_ID,
+	EXPORT_COLUMN_OBSERVABLE,
+	EXPORT_COLUMN_CLASSIFICATION,
+	EXPORT_COLUMN_STATUS,
+	EXPORT_COLUMN_TLP,
+	EXPORT_COLUMN_TAGS,
+	EXPORT_COLUMN_RECEIVED,
+	EXPORT_COLUMN_FINISHED,
+	EXPORT_COLUMN_ANALYZERS,
+}
+
+// JobExportFormat represents how JobService.Export writes the jobs.
+type JobExportFormat struct {
+	// Encoding is EXPORT_JSONL, the default, or EXPORT_CSV.
+	Encoding string
+	// Columns are the EXPORT_COLUMN_* written, in order, DEFAULT_EXPORT_COLUMNS when empty.
+	Columns []string
+	// Verdict tells the verdict of a job for EXPORT_COLUMN_VERDICT, e.g. with ApplyVerdictRules. Every job is
+	// then fetched with its reports, one request each. The verdict is UNKNOWN when Verdict is nil.
+	Verdict func(job *Job) Verdict
+	// PageSize is the number of jobs listed at a time, DEFAULT_EXPORT_PAGE_SIZE when 0.
+	PageSize int
+}
+
+// jobExportWriter writes the exported jobs in an encoding.
+type jobExportWriter interface {
+	writeRow(values []interface{}) error
+	flush() error
+}
+
+// jsonLinesExportWriter writes the jobs as JSON lines.
+type jsonLinesExportWriter struct {
+	encoder *json.Encoder
+	columns []string
+}
+
+func (writer *jsonLinesExportWriter) writeRow(values []interface{}) error {
+	row := make(map[string]interface{}, len(values))
+	for index, value := range values {
+		row[writer.columns[index]] = value
+	}
+	return writer.encoder.Encode(row)
+}
+
+func (writer *jsonLinesExportWriter) flush() error {
+	return nil
+}
+
+// csvExportWriter writes the jobs as CSV rows.
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func (writer *csvExportWriter) writeRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for index, value := range values {
+		switch value := value.(type) {
+		case nil:
+			record[index] = ""
+		case []string:
+			record[index] = strings.Join(value, ";")
+		case int:
+			record[index] = strconv.Itoa(value)
+		default:
+			record[index] = fmt.Sprint(value)
+		}
+	}
+	return writer.writer.Write(record)
+}
+
+func (writer *csvExportWriter) flush() error {
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+// exportColumns are the columns JobService.Export knows.
+var exportColumns = map[string]bool{
+	EXPORT_COLUMN_ID:             true,
+	EXPORT_COLUMN_OBSERVABLE:     true,
+	EXPORT_COLUMN_CLASSIFICATION: true,
+	EXPORT_COLUMN_MD5:            true,
+	EXPORT_COLUMN_STATUS:         true,
+	EXPORT_COLUMN_TLP:            true,
+	EXPORT_COLUMN_VERDICT:        true,
+	EXPORT_COLUMN_TAGS:           true,
+	EXPORT_COLUMN_RECEIVED:       true,
+	EXPORT_COLUMN_FINISHED:       true,
+	EXPORT_COLUMN_ANALYZERS:      true,
+}
+
+// exportTime formats a time of a job, nil when it is not set.
+func exportTime(timestamp *time.Time) interface{} {
+	if timestamp == nil {
+		return nil
+	}
+	return timestamp.UTC().Format(time.RFC3339)
+}
+
+// exportValue returns the value of the column for the job.
+func exportValue(job *Job, column string, verdict Verdict) interface{} {
+	switch column {
+	case EXPORT_COLUMN_ID:
+		return job.ID
+	case EXPORT_COLUMN_OBSERVABLE:
+		if job.IsSample {
+			return job.FileName
+		}
+		return job.ObservableName
+	case EXPORT_COLUMN_CLASSIFICATION:
+		if job.IsSample {
+			return job.FileMimetype
+		}
+		return job.ObservableClassification
+	case EXPORT_COLUMN_MD5:
+		return job.Md5
+	case EXPORT_COLUMN_STATUS:
+		return job.Status
+	case EXPORT_COLUMN_TLP:
+		return job.Tlp
+	case EXPORT_COLUMN_VERDICT:
+		return verdict.String()
+	case EXPORT_COLUMN_TAGS:
+		labels := []string{}
+		for _, tag := range job.Tags {
+			labels = append(labels, tag.Label)
+		}
+		return labels
+	case EXPORT_COLUMN_RECEIVED:
+		return exportTime(job.ReceivedRequestTime)
+	case EXPORT_COLUMN_FINISHED:
+		return exportTime(job.FinishedAnalysisTime)
+	case EXPORT_COLUMN_ANALYZERS:
+		if job.AnalyzersToExecute == nil {
+			return []string{}
+		}
+		return job.AnalyzersToExecute
+	}
+	return nil
+}
+
+// Export writes the jobs matching the filter, nil for every job, to w in the format, paging through them on the
+// server, e.g. to feed a SIEM with JSON lines or a spreadsheet with CSV. It returns the number of jobs written,
+// the ones written before an error included. The page and page size of the filter are overridden.
+//
+//	Endpoint: GET /api/jobs
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_list
+func (jobService *JobService) Export(ctx context.Context, jobFilter *JobFilter, format *JobExportFormat, w io.Writer) (int, error) {
+	if format == nil {
+		format = &JobExportFormat{}
+	}
+	columns := format.Columns
+	if len(columns) == 0 {
+		columns = DEFAULT_EXPORT_COLUMNS
+	}
+	verdictColumn := false
+	for _, column := range columns {
+		if !exportColumns[column] {
+			return 0, fmt.Errorf("%w: unknown column %q", ErrInvalidExportFormat, column)
+		}
+		verdictColumn = verdictColumn || column == EXPORT_COLUMN_VERDICT
+	}
+	var writer jobExportWriter
+	switch format.Encoding {
+	case "", EXPORT_JSONL:
+		writer = &jsonLinesExportWriter{encoder: json.NewEncoder(w), columns: columns}
+	case EXPORT_CSV:
+		csvWriter := &csvExportWriter{writer: csv.NewWriter(w)}
+		header := make([]interface{}, len(columns))
+		for index, column := range columns {
+			header[index] = column
+		}
+		if err := csvWriter.writeRow(header); err != nil {
+			return 0, err
+		}
+		writer = csvWriter
+	default:
+		return 0, fmt.Errorf("%w: unknown encoding %q", ErrInvalidExportFormat, format.Encoding)
+	}
+	pageSize := format.PageSize
+	if pageSize <= 0 {
+		pageSize = DEFAULT_EXPORT_PAGE_SIZE
+	}
+	exported := 0
+	paginator := jobService.Paginate(jobFilter, pageSize)
+	for paginator.More() {
+		jobs, err := paginator.Next(ctx)
+		if err != nil {
+			return exported, err
+		}
+		for index := range jobs {
+			job := &Job{BaseJob: jobs[index].BaseJob}
+			verdict := UNKNOWN
+			if verdictColumn && format.Verdict != nil {
+				if job, err = jobService.Get(ctx, uint64(jobs[index].ID)); err != nil {
+					return exported, err
+				}
+				verdict = format.Verdict(job)
+			}
+			values := make([]interface{}, len(columns))
+			for columnIndex, column := range columns {
+				values[columnIndex] = exportValue(job, column, verdict)
+			}
+			if err := writer.writeRow(values); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+		if err := writer.flush(); err != nil {
+			return exported, err
+		}
+	}
+	return exported, writer.flush()
+}
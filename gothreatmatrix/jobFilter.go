@@ -0,0 +1,129 @@
+package gothreatmatrix
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JobStatus represents the status of a job in ThreatMatrix.
+type JobStatus string
+
+// Values of the JobStatus enum.
+const (
+	PENDING                JobStatus = "pending"
+	RUNNING                JobStatus = "running"
+	REPORTED_WITHOUT_FAILS JobStatus = "reported_without_fails"
+	REPORTED_WITH_FAILS    JobStatus = "reported_with_fails"
+	KILLED                 JobStatus = "killed"
+	FAILED                 JobStatus = "failed"
+)
+
+// JobFilter lets you build the query parameters used to filter the job list in a type-safe way.
+//
+// Example:
+//
+//	filter := gothreatmatrix.NewJobFilter().Status(gothreatmatrix.RUNNING).TagIn("phishing").ReceivedAfter(yesterday)
+type JobFilter struct {
+	values url.Values
+}
+
+// NewJobFilter returns an empty JobFilter.
+func NewJobFilter() *JobFilter {
+	return &JobFilter{
+		values: url.Values{},
+	}
+}
+
+// Status filters the jobs by their status.
+func (jobFilter *JobFilter) Status(status JobStatus) *JobFilter {
+	jobFilter.values.Set("status", string(status))
+	return jobFilter
+}
+
+// TagIn filters the jobs that have at least one of the given tag labels.
+func (jobFilter *JobFilter) TagIn(labels ...string) *JobFilter {
+	jobFilter.values.Set("tags", strings.Join(labels, ","))
+	return jobFilter
+}
+
+// User filters the jobs submitted by the given user.
+func (jobFilter *JobFilter) User(username string) *JobFilter {
+	jobFilter.values.Set("user", username)
+	return jobFilter
+}
+
+// ObservableName filters the jobs whose observable name contains the given value.
+func (jobFilter *JobFilter) ObservableName(name string) *JobFilter {
+	jobFilter.values.Set("observable_name", name)
+	return jobFilter
+}
+
+// ObservableClassification filters the jobs by their observable classification (ip, url, domain, hash, generic).
+func (jobFilter *JobFilter) ObservableClassification(classification string) *JobFilter {
+	jobFilter.values.Set("observable_classification", classification)
+	return jobFilter
+}
+
+// Md5 filters the jobs by the md5 of their observable or file.
+func (jobFilter *JobFilter) Md5(md5 string) *JobFilter {
+	jobFilter.values.Set("md5", md5)
+	return jobFilter
+}
+
+// IsSample filters the jobs between file (true) and observable (false) analyses.
+func (jobFilter *JobFilter) IsSample(isSample bool) *JobFilter {
+	jobFilter.values.Set("is_sample", strconv.FormatBool(isSample))
+	return jobFilter
+}
+
+// Tlp filters the jobs by their TLP.
+func (jobFilter *JobFilter) Tlp(tlp TLP) *JobFilter {
+	jobFilter.values.Set("tlp", tlp.String())
+	return jobFilter
+}
+
+// ReceivedAfter filters the jobs received at or after the given time.
+func (jobFilter *JobFilter) ReceivedAfter(receivedAfter time.Time) *JobFilter {
+	jobFilter.values.Set("received_request_time__gte", receivedAfter.UTC().Format(time.RFC3339))
+	return jobFilter
+}
+
+// ReceivedBefore filters the jobs received at or before the given time.
+func (jobFilter *JobFilter) ReceivedBefore(receivedBefore time.Time) *JobFilter {
+	jobFilter.values.Set("received_request_time__lte", receivedBefore.UTC().Format(time.RFC3339))
+	return jobFilter
+}
+
+// Ordering sorts the jobs by the given fields, a leading "-" sorting in descending order.
+func (jobFilter *JobFilter) Ordering(fields ...string) *JobFilter {
+	jobFilter.values.Set("ordering", strings.Join(fields, ","))
+	return jobFilter
+}
+
+// Page selects the page of the job list, starting at 1.
+func (jobFilter *JobFilter) Page(page int) *JobFilter {
+	jobFilter.values.Set("page", strconv.Itoa(page))
+	return jobFilter
+}
+
+// PageSize sets the number of jobs per page.
+func (jobFilter *JobFilter) PageSize(pageSize int) *JobFilter {
+	jobFilter.values.Set("page_size", strconv.Itoa(pageSize))
+	return jobFilter
+}
+
+// Values returns a copy of the query parameters built by the filter.
+func (jobFilter *JobFilter) Values() url.Values {
+	values := url.Values{}
+	for key, value := range jobFilter.values {
+		values[key] = append([]string(nil), value...)
+	}
+	return values
+}
+
+// Encode returns the filter encoded as a URL query string.
+func (jobFilter *JobFilter) Encode() string {
+	return jobFilter.values.Encode()
+}
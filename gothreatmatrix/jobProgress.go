@@ -0,0 +1,102 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"time"
+)
+
+// MAX_PROGRESS_SNAPSHOT_INTERVAL caps the spacing of the progress snapshots sent by WatchMany, see WaitOptions.ProgressSnapshots.
+const MAX_PROGRESS_SNAPSHOT_INTERVAL = 5 * time.Minute
+
+// maxRunningFraction is the most a running analyzer counts for in the progress, so a job is only done once it is.
+const maxRunningFraction = 0.95
+
+// JobProgress represents an estimation of how far a job is.
+type JobProgress struct {
+	AnalyzersCompleted int
+	AnalyzersTotal     int
+	// Elapsed is the time since the job was received, until it finished.
+	Elapsed time.Duration
+	// Expected is the soft time limit of the slowest analyzer of the job, 0 when unknown.
+	Expected time.Duration
+	// Fraction is between 0 and 1: every finished analyzer counts for 1, a running one for the share
+	// of its soft time limit it used, or 0 when its limit is unknown.
+	Fraction float64
+}
+
+// Progress estimates the progress of the job at now from the soft time limits of its analyzers,
+// see AnalyzerService.SoftTimeLimits. The limits can be nil to only count the finished analyzers.
+func (job *Job) Progress(softTimeLimits map[string]time.Duration, now time.Time) JobProgress {
+	progress := JobProgress{
+		AnalyzersTotal: len(job.AnalyzersToExecute),
+	}
+	if job.ReceivedRequestTime != nil {
+		end := now
+		if job.FinishedAnalysisTime != nil && !isJobRunning(job.Status) {
+			end = *job.FinishedAnalysisTime
+		}
+		progress.Elapsed = end.Sub(*job.ReceivedRequestTime)
+	}
+	reports := map[string]*Report{}
+	for index := range job.AnalyzerReports {
+		reports[job.AnalyzerReports[index].Name] = &job.AnalyzerReports[index]
+	}
+	done := 0.0
+	for _, analyzerName := range job.AnalyzersToExecute {
+		limit := softTimeLimits[analyzerName]
+		if limit > progress.Expected {
+			progress.Expected = limit
+		}
+		report, ok := reports[analyzerName]
+		if ok && isReportFinished(report) {
+			progress.AnalyzersCompleted++
+			done++
+			continue
+		}
+		if limit <= 0 {
+			continue
+		}
+		elapsed := progress.Elapsed
+		if ok && !report.StartTime.IsZero() {
+			elapsed = now.Sub(report.StartTime)
+		}
+		fraction := float64(elapsed) / float64(limit)
+		if fraction > maxRunningFraction {
+			fraction = maxRunningFraction
+		}
+		if fraction > 0 {
+			done += fraction
+		}
+	}
+	if isJobRunning(job.Status) {
+		if progress.AnalyzersTotal > 0 {
+			progress.Fraction = done / float64(progress.AnalyzersTotal)
+		}
+		if progress.Fraction > maxRunningFraction {
+			progress.Fraction = maxRunningFraction
+		}
+	} else {
+		progress.AnalyzersCompleted = progress.AnalyzersTotal
+		progress.Fraction = 1
+	}
+	return progress
+}
+
+// SoftTimeLimits returns the soft time limit of every analyzer that has one, to estimate the progress of jobs with.
+//
+//	Endpoint: GET /api/get_analyzer_configs
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/get_analyzer_configs
+func (analyzerService *AnalyzerService) SoftTimeLimits(ctx context.Context) (map[string]time.Duration, error) {
+	analyzerConfigs, err := analyzerService.GetConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	softTimeLimits := map[string]time.Duration{}
+	for _, analyzerConfig := range *analyzerConfigs {
+		if analyzerConfig.Config.SoftTimeLimit > 0 {
+			softTimeLimits[analyzerConfig.Name] = time.Duration(analyzerConfig.Config.SoftTimeLimit) * time.Second
+		}
+	}
+	return softTimeLimits, nil
+}
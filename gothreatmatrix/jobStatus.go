@@ -0,0 +1,99 @@
+package gothreatmatrix
+
+import "fmt"
+
+// Values of the JobStatus enum for the phases newer ThreatMatrix instances report while a job runs.
+const (
+	ANALYZERS_RUNNING     JobStatus = "analyzers_running"
+	ANALYZERS_COMPLETED   JobStatus = "analyzers_completed"
+	CONNECTORS_RUNNING    JobStatus = "connectors_running"
+	CONNECTORS_COMPLETED  JobStatus = "connectors_completed"
+	PIVOTS_RUNNING        JobStatus = "pivots_running"
+	PIVOTS_COMPLETED      JobStatus = "pivots_completed"
+	VISUALIZERS_RUNNING   JobStatus = "visualizers_running"
+	VISUALIZERS_COMPLETED JobStatus = "visualizers_completed"
+)
+
+// runningJobStatuses are the statuses of a job whose plugins are at work.
+var runningJobStatuses = map[JobStatus]bool{
+	RUNNING:               true,
+	ANALYZERS_RUNNING:     true,
+	ANALYZERS_COMPLETED:   true,
+	CONNECTORS_RUNNING:    true,
+	CONNECTORS_COMPLETED:  true,
+	PIVOTS_RUNNING:        true,
+	PIVOTS_COMPLETED:      true,
+	VISUALIZERS_RUNNING:   true,
+	VISUALIZERS_COMPLETED: true,
+}
+
+// terminalJobStatuses are the statuses of a job that stopped.
+var terminalJobStatuses = map[JobStatus]bool{
+	REPORTED_WITHOUT_FAILS: true,
+	REPORTED_WITH_FAILS:    true,
+	KILLED:                 true,
+	FAILED:                 true,
+}
+
+// IsKnown reports whether the status is one of the JobStatus values.
+func (status JobStatus) IsKnown() bool {
+	return status == PENDING || runningJobStatuses[status] || terminalJobStatuses[status]
+}
+
+// IsTerminal reports whether the job stopped: reported, killed or failed.
+// An unknown status is not terminal, so a newer server's intermediate phase is never taken for the end of a job.
+func (status JobStatus) IsTerminal() bool {
+	return terminalJobStatuses[status]
+}
+
+// IsRunning reports whether the plugins of the job are at work.
+func (status JobStatus) IsRunning() bool {
+	return runningJobStatuses[status]
+}
+
+// CanKill reports whether a job in this status can still be killed.
+func (status JobStatus) CanKill() bool {
+	return !status.IsTerminal()
+}
+
+// CanTransitionTo reports whether a job can go from this status to next:
+// a pending job can go anywhere, a running one to another phase or to a terminal status,
+// and a stopped one only back to running when one of its plugins is retried.
+// Unknown statuses are accepted, as long as a job never goes back to pending.
+func (status JobStatus) CanTransitionTo(next JobStatus) bool {
+	switch {
+	case status == next:
+		return true
+	case next == PENDING:
+		return false
+	case status.IsTerminal():
+		return next == RUNNING || !next.IsKnown()
+	}
+	return true
+}
+
+// InvalidTransitionError is returned when a job is seen going through a transition CanTransitionTo rejects,
+// e.g. a server mislabelling its jobs.
+type InvalidTransitionError struct {
+	JobID uint64
+	From  JobStatus
+	To    JobStatus
+}
+
+// Error lets you implement the error interface.
+func (transitionError *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("job %d went from %s to %s", transitionError.JobID, transitionError.From, transitionError.To)
+}
+
+// validateTransition checks the transition of the job from the previously seen status, empty when there is none.
+func validateTransition(jobId uint64, previous string, current string) error {
+	if previous == "" || JobStatus(previous).CanTransitionTo(JobStatus(current)) {
+		return nil
+	}
+	return &InvalidTransitionError{JobID: jobId, From: JobStatus(previous), To: JobStatus(current)}
+}
+
+// State returns the status of the job as a JobStatus.
+func (baseJob *BaseJob) State() JobStatus {
+	return JobStatus(baseJob.Status)
+}
@@ -0,0 +1,124 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// JOB_WATCH_MAX_RECONNECTS and JOB_WATCH_RECONNECT_BACKOFF bound how Watch reconnects a dropped websocket:
+// every drop is given JOB_WATCH_MAX_RECONNECTS attempts, the backoff doubling on each one.
+const (
+	JOB_WATCH_MAX_RECONNECTS    = 5
+	JOB_WATCH_RECONNECT_BACKOFF = 500 * time.Millisecond
+)
+
+// Watch subscribes to the live updates of the job through the websocket of the instance, instead of polling it:
+// a REPORT_COMPLETED_EVENT is sent whenever an analyzer or connector report of the job finishes, and a
+// JOB_COMPLETED_EVENT once the job stopped running, after which the channel is closed.
+//
+// A dropped connection is reconnected, and the job is then fetched once to catch up with the updates missed meanwhile.
+// The channel is closed without a JOB_COMPLETED_EVENT when ctx is done or the connection could not be re-established,
+// and it must be drained for the watch to make progress. The error is the one of the first connection,
+// e.g. a *ThreatMatrixError for which IsNotFound is true on an instance without websockets, where WatchMany still works.
+//
+//	Endpoint: GET /ws/jobs/{jobID}
+func (jobService *JobService) Watch(ctx context.Context, jobId uint64) (<-chan JobEvent, error) {
+	route := fmt.Sprintf(constants.JOB_WEBSOCKET_URL, jobId)
+	websocket, err := jobService.client.dialWebsocket(ctx, route)
+	if err != nil {
+		return nil, err
+	}
+	websocket.closeWhenDone(ctx)
+	events := make(chan JobEvent)
+	go jobService.streamJobEvents(ctx, jobId, route, websocket, events)
+	return events, nil
+}
+
+// jobEventTracker turns the successive states of a job into JobEvents, every report being reported once.
+type jobEventTracker struct {
+	finished map[string]bool
+}
+
+// events returns the events of the new state of the job, done is true once the job stopped running.
+func (tracker *jobEventTracker) events(job *Job) (events []JobEvent, done bool) {
+	for _, reports := range [][]Report{job.AnalyzerReports, job.ConnectorReports} {
+		for index := range reports {
+			report := reports[index]
+			key := report.Type + "/" + report.Name
+			if !isReportFinished(&report) || tracker.finished[key] {
+				continue
+			}
+			tracker.finished[key] = true
+			events = append(events, JobEvent{SchemaVersion: EVENT_SCHEMA_VERSION, Event: REPORT_COMPLETED_EVENT, Job: job, Report: &report})
+		}
+	}
+	if JobStatus(job.Status).IsTerminal() {
+		events = append(events, *newJobCompletedEvent(job))
+		return events, true
+	}
+	return events, false
+}
+
+// streamJobEvents sends the events of the job read from the websocket until the job is done, reconnecting it when it drops.
+func (jobService *JobService) streamJobEvents(ctx context.Context, jobId uint64, route string, websocket *websocketConn, events chan<- JobEvent) {
+	defer close(events)
+	tracker := &jobEventTracker{finished: map[string]bool{}}
+	// send sends the events of the job, it returns false once the watch is over
+	send := func(job *Job) bool {
+		jobEvents, done := tracker.events(job)
+		for _, event := range jobEvents {
+			select {
+			case <-ctx.Done():
+				return false
+			case events <- event:
+			}
+		}
+		return !done
+	}
+	for {
+		message, err := websocket.readMessage()
+		if err == nil {
+			job := Job{}
+			// * the messages that are not a job are ignored
+			if json.Unmarshal(message, &job) == nil && uint64(job.ID) == jobId && !send(&job) {
+				websocket.Close()
+				return
+			}
+			continue
+		}
+		websocket.Close()
+		if websocket = jobService.reconnect(ctx, route); websocket == nil {
+			return
+		}
+		job, err := jobService.Get(ctx, jobId)
+		if err == nil && !send(job) {
+			websocket.Close()
+			return
+		}
+	}
+}
+
+// reconnect opens the websocket again, waiting JOB_WATCH_RECONNECT_BACKOFF, doubled on every attempt, before each one.
+// It returns nil once ctx is done or JOB_WATCH_MAX_RECONNECTS attempts failed.
+func (jobService *JobService) reconnect(ctx context.Context, route string) *websocketConn {
+	backoff := JOB_WATCH_RECONNECT_BACKOFF
+	for attempt := 0; attempt < JOB_WATCH_MAX_RECONNECTS; attempt++ {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		if websocket, err := jobService.client.dialWebsocket(ctx, route); err == nil {
+			websocket.closeWhenDone(ctx)
+			return websocket
+		}
+		backoff *= 2
+	}
+	return nil
+}
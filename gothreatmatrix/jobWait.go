@@ -0,0 +1,265 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// DEFAULT_POLL_INTERVAL is how often WaitForCompletion fetches the job when no PollInterval is given.
+const DEFAULT_POLL_INTERVAL = 5 * time.Second
+
+// WaitOptions represents the fields used to configure WaitForCompletion.
+type WaitOptions struct {
+	// PollInterval is how often the job is fetched, DEFAULT_POLL_INTERVAL when 0.
+	PollInterval time.Duration
+	// MaxPollInterval, when greater than PollInterval, makes WaitForCompletion back off:
+	// the wait doubles after every poll finding the job still running, up to MaxPollInterval.
+	MaxPollInterval time.Duration
+	// Jitter randomizes every wait by up to this fraction of it, e.g. 0.2 for ±20%,
+	// so the consumers waiting on many jobs don't poll in lockstep.
+	Jitter float64
+	// MustHaveAnalyzers, when not empty, lets WaitForCompletion return as soon as these analyzers finished
+	// instead of waiting for the slower optional ones.
+	MustHaveAnalyzers []string
+	// AnalyzerTimeout, when not 0, is how long the analyzers are waited for before returning with what finished.
+	AnalyzerTimeout time.Duration
+	// KillOnTimeout kills the analyzers still running when the AnalyzerTimeout is reached.
+	KillOnTimeout bool
+	// ProgressSnapshots lets WatchMany estimate the progress of the jobs from the soft time limits of their analyzers
+	// and, while a job shows no change, send updates with its progress after 1, 2, 4... poll intervals,
+	// up to MAX_PROGRESS_SNAPSHOT_INTERVAL, so long sandbox jobs can still show a progress bar.
+	ProgressSnapshots bool
+	// Heartbeat, when set, is called after every poll of a job, e.g. to log its progress, give it more time or
+	// abort the wait. WatchMany calls it from the watchers of all its jobs at once.
+	Heartbeat HeartbeatFunc
+}
+
+// Heartbeat represents a poll of a job by WaitForCompletion or WatchMany.
+type Heartbeat struct {
+	JobID uint64
+	// Poll is the number of the poll, starting at 1.
+	Poll int
+	// Elapsed is the time since the wait started.
+	Elapsed time.Duration
+	Status  JobStatus
+	// PendingAnalyzers are the analyzers to execute that had not finished yet.
+	PendingAnalyzers []string
+	// Deadline is the deadline of the context of the wait, zero when it has none, so long waits can be aborted
+	// before it rather than at it.
+	Deadline  time.Time
+	extension time.Duration
+}
+
+// ExtendTimeout postpones the AnalyzerTimeout of WaitForCompletion by the duration, WatchMany having none.
+func (heartbeat *Heartbeat) ExtendTimeout(duration time.Duration) {
+	if duration > 0 {
+		heartbeat.extension += duration
+	}
+}
+
+// HeartbeatFunc is called after every poll of a job. A non-nil error stops the wait, which returns it as is.
+type HeartbeatFunc func(heartbeat *Heartbeat) error
+
+// newHeartbeat returns the heartbeat of the poll of the job.
+func newHeartbeat(ctx context.Context, jobId uint64, poll int, start time.Time, job *Job, pendingAnalyzers []string) *Heartbeat {
+	deadline, _ := ctx.Deadline()
+	return &Heartbeat{
+		JobID:            jobId,
+		Poll:             poll,
+		Elapsed:          time.Since(start),
+		Status:           JobStatus(job.Status),
+		PendingAnalyzers: pendingAnalyzers,
+		Deadline:         deadline,
+	}
+}
+
+// WaitResult represents the job returned by WaitForCompletion.
+type WaitResult struct {
+	Job *Job
+	// Complete is true when the whole job finished, false when only part of its analyzers did.
+	Complete bool
+	// PendingAnalyzers are the analyzers to execute that had not finished yet.
+	PendingAnalyzers []string
+	// TimedOut is true when the AnalyzerTimeout was reached.
+	TimedOut bool
+}
+
+// isJobRunning reports whether the job status means the job has not stopped yet, see JobStatus.IsTerminal.
+func isJobRunning(status string) bool {
+	return !JobStatus(status).IsTerminal()
+}
+
+// isReportFinished reports whether the analyzer or connector report reached a final status.
+func isReportFinished(report *Report) bool {
+	switch strings.ToUpper(report.Status) {
+	case "SUCCESS", "FAILED", "KILLED":
+		return true
+	}
+	return false
+}
+
+// pendingAnalyzers lists the analyzers to execute whose report has not reached a final status.
+func (job *Job) pendingAnalyzers() []string {
+	finished := map[string]bool{}
+	for index := range job.AnalyzerReports {
+		if isReportFinished(&job.AnalyzerReports[index]) {
+			finished[job.AnalyzerReports[index].Name] = true
+		}
+	}
+	pending := []string{}
+	for _, analyzerName := range job.AnalyzersToExecute {
+		if !finished[analyzerName] {
+			pending = append(pending, analyzerName)
+		}
+	}
+	return pending
+}
+
+// WaitForCompletion polls the job until it finishes, or until the must-have analyzers finished,
+// or until the analyzer timeout is reached, whichever comes first.
+// The polling backs off with jitter when MaxPollInterval and Jitter are set.
+// A status transition rejected by JobStatus.CanTransitionTo returns an *InvalidTransitionError.
+// The returned result tells which analyzers are still pending.
+// The Heartbeat of the options is called after every poll, the error it returns is returned with the last result.
+// options can be nil to simply wait for the whole job.
+//
+//	Endpoint: GET /api/jobs/{jobID}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_retrieve
+func (jobService *JobService) WaitForCompletion(ctx context.Context, jobId uint64, options *WaitOptions) (*WaitResult, error) {
+	if options == nil {
+		options = &WaitOptions{}
+	}
+	pollInterval := options.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = DEFAULT_POLL_INTERVAL
+	}
+	start := time.Now()
+	var timeoutTimer *time.Timer
+	var timeout <-chan time.Time
+	if options.AnalyzerTimeout > 0 {
+		timeoutTimer = time.NewTimer(options.AnalyzerTimeout)
+		defer timeoutTimer.Stop()
+		timeout = timeoutTimer.C
+	}
+	timeoutAt := start.Add(options.AnalyzerTimeout)
+	previousStatus := ""
+	for poll := 1; ; poll++ {
+		job, err := jobService.Get(ctx, jobId)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateTransition(jobId, previousStatus, job.Status); err != nil {
+			return nil, err
+		}
+		previousStatus = job.Status
+		result := &WaitResult{
+			Job:              job,
+			Complete:         !isJobRunning(job.Status),
+			PendingAnalyzers: job.pendingAnalyzers(),
+		}
+		if options.Heartbeat != nil {
+			heartbeat := newHeartbeat(ctx, jobId, poll, start, job, result.PendingAnalyzers)
+			if err := options.Heartbeat(heartbeat); err != nil {
+				return result, err
+			}
+			if heartbeat.extension > 0 && timeoutTimer != nil {
+				if !timeoutTimer.Stop() {
+					select {
+					case <-timeoutTimer.C:
+					default:
+					}
+				}
+				timeoutAt = timeoutAt.Add(heartbeat.extension)
+				timeoutTimer.Reset(time.Until(timeoutAt))
+			}
+		}
+		if result.Complete || hasMustHaveAnalyzers(options.MustHaveAnalyzers, result.PendingAnalyzers) {
+			return result, nil
+		}
+		timer := time.NewTimer(jitterInterval(pollInterval, options.Jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timeout:
+			timer.Stop()
+			result.TimedOut = true
+			if options.KillOnTimeout {
+				for _, analyzerName := range result.PendingAnalyzers {
+					if _, err := jobService.KillAnalyzer(ctx, jobId, analyzerName); err != nil {
+						return result, err
+					}
+				}
+			}
+			return result, nil
+		case <-timer.C:
+		}
+		if options.MaxPollInterval > pollInterval {
+			pollInterval = doubleInterval(pollInterval, options.MaxPollInterval)
+		}
+	}
+}
+
+// jitterInterval returns the interval randomized by up to jitter times itself, either way.
+func jitterInterval(interval time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	return time.Duration(float64(interval) * (1 + jitter*(2*rand.Float64()-1)))
+}
+
+// hasMustHaveAnalyzers reports whether none of the must-have analyzers is pending.
+func hasMustHaveAnalyzers(mustHaveAnalyzers []string, pendingAnalyzers []string) bool {
+	if len(mustHaveAnalyzers) == 0 {
+		return false
+	}
+	pending := map[string]bool{}
+	for _, analyzerName := range pendingAnalyzers {
+		pending[analyzerName] = true
+	}
+	for _, analyzerName := range mustHaveAnalyzers {
+		if pending[analyzerName] {
+			return false
+		}
+	}
+	return true
+}
+
+// PartialReports represents the analyzer reports of a job that are already available.
+type PartialReports struct {
+	Status string
+	// Reports are the analyzer reports that reached a final status.
+	Reports []Report
+	// PendingAnalyzers are the analyzers to execute that had not finished yet.
+	PendingAnalyzers []string
+}
+
+// GetPartialReports fetches the analyzer reports that already finished for a job, even while it is still running,
+// so the results can be rendered progressively.
+//
+//	Endpoint: GET /api/jobs/{jobID}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_retrieve
+func (jobService *JobService) GetPartialReports(ctx context.Context, jobId uint64) (*PartialReports, error) {
+	job, err := jobService.Get(ctx, jobId)
+	if err != nil {
+		return nil, err
+	}
+	reports := []Report{}
+	for index := range job.AnalyzerReports {
+		if isReportFinished(&job.AnalyzerReports[index]) {
+			reports = append(reports, job.AnalyzerReports[index])
+		}
+	}
+	return &PartialReports{
+		Status:           job.Status,
+		Reports:          reports,
+		PendingAnalyzers: job.pendingAnalyzers(),
+	}, nil
+}
@@ -0,0 +1,172 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// JobUpdate represents a change seen on a watched job.
+type JobUpdate struct {
+	JobID uint64
+	Job   *Job
+	// PendingAnalyzers are the analyzers to execute that had not finished yet.
+	PendingAnalyzers []string
+	// Done is true on the last update of the job, once it stopped running.
+	Done bool
+	// Progress estimates how far the job is, see WaitOptions.ProgressSnapshots.
+	Progress JobProgress
+	// Snapshot is true for the progress snapshots, the updates sent while the job shows no change.
+	Snapshot bool
+}
+
+// JobWatch represents jobs being watched by WatchMany.
+type JobWatch struct {
+	updates   chan JobUpdate
+	cancel    context.CancelFunc
+	waitGroup sync.WaitGroup
+	errOnce   sync.Once
+	err       error
+}
+
+// Updates returns the channel every update is sent on. It is closed once every job is done or the watch is torn down,
+// and it must be drained for the watchers to make progress.
+func (watch *JobWatch) Updates() <-chan JobUpdate {
+	return watch.updates
+}
+
+// Wait blocks until every watcher returned and returns the first fatal error, if any.
+func (watch *JobWatch) Wait() error {
+	watch.waitGroup.Wait()
+	watch.cancel()
+	return watch.err
+}
+
+// Stop tears every watcher down, Wait then returns context.Canceled if the jobs were not all done.
+func (watch *JobWatch) Stop() {
+	watch.cancel()
+}
+
+// fail records the first fatal error and tears every other watcher down.
+func (watch *JobWatch) fail(err error) {
+	watch.errOnce.Do(func() {
+		watch.err = err
+		watch.cancel()
+	})
+}
+
+// WatchMany watches the jobs concurrently and multiplexes their updates onto a single channel.
+// An update is sent whenever the status or the finished analyzers of a job change.
+// The first error (e.g. a job that does not exist, or an *InvalidTransitionError) or the cancellation of ctx tears every watcher down,
+// the same way an errgroup does, an error of the Heartbeat of options included.
+// Only the PollInterval, ProgressSnapshots and Heartbeat of options are used.
+//
+//	Endpoint: GET /api/jobs/{jobID}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_retrieve
+func (jobService *JobService) WatchMany(ctx context.Context, jobIds []uint64, options *WaitOptions) *JobWatch {
+	if options == nil {
+		options = &WaitOptions{}
+	}
+	pollInterval := options.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = DEFAULT_POLL_INTERVAL
+	}
+	watchCtx, cancel := context.WithCancel(ctx)
+	watch := &JobWatch{
+		updates: make(chan JobUpdate),
+		cancel:  cancel,
+	}
+	var softTimeLimits map[string]time.Duration
+	var limitsErr error
+	var limitsOnce sync.Once
+	for _, jobId := range jobIds {
+		watch.waitGroup.Add(1)
+		go func(jobId uint64) {
+			defer watch.waitGroup.Done()
+			if options.ProgressSnapshots {
+				limitsOnce.Do(func() {
+					softTimeLimits, limitsErr = jobService.client.AnalyzerService.SoftTimeLimits(watchCtx)
+				})
+				if limitsErr != nil {
+					watch.fail(limitsErr)
+					return
+				}
+			}
+			if err := jobService.watch(watchCtx, jobId, pollInterval, options, softTimeLimits, watch.updates); err != nil {
+				watch.fail(err)
+			}
+		}(jobId)
+	}
+	go func() {
+		watch.waitGroup.Wait()
+		close(watch.updates)
+	}()
+	return watch
+}
+
+// watch polls a single job and sends its updates until it is done.
+func (jobService *JobService) watch(ctx context.Context, jobId uint64, pollInterval time.Duration, options *WaitOptions, softTimeLimits map[string]time.Duration, updates chan<- JobUpdate) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	lastStatus := ""
+	lastPending := -1
+	start := time.Now()
+	lastSent := start
+	snapshots := options.ProgressSnapshots
+	snapshotInterval := pollInterval
+	for poll := 1; ; poll++ {
+		job, err := jobService.Get(ctx, jobId)
+		if err != nil {
+			return err
+		}
+		if err := validateTransition(jobId, lastStatus, job.Status); err != nil {
+			return err
+		}
+		pending := job.pendingAnalyzers()
+		if options.Heartbeat != nil {
+			if err := options.Heartbeat(newHeartbeat(ctx, jobId, poll, start, job, pending)); err != nil {
+				return err
+			}
+		}
+		done := !isJobRunning(job.Status)
+		changed := done || job.Status != lastStatus || len(pending) != lastPending
+		now := time.Now()
+		snapshot := !changed && snapshots && now.Sub(lastSent) >= snapshotInterval
+		if changed || snapshot {
+			lastStatus = job.Status
+			lastPending = len(pending)
+			lastSent = now
+			if snapshot {
+				// * the snapshots get sparser the longer the job shows no change
+				snapshotInterval *= 2
+				if snapshotInterval > MAX_PROGRESS_SNAPSHOT_INTERVAL {
+					snapshotInterval = MAX_PROGRESS_SNAPSHOT_INTERVAL
+				}
+			} else {
+				snapshotInterval = pollInterval
+			}
+			update := JobUpdate{
+				JobID:            jobId,
+				Job:              job,
+				PendingAnalyzers: pending,
+				Done:             done,
+				Progress:         job.Progress(softTimeLimits, now),
+				Snapshot:         snapshot,
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case updates <- update:
+			}
+		}
+		if done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package gothreatmatrix
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MalwareFamily represents a malware family named by the analyzer reports of a job.
+type MalwareFamily struct {
+	// Name is the normalized family name, e.g. "emotet" for "Geodo" or "Trojan.Win32.Emotet.abc".
+	Name string `json:"name"`
+	// Aliases are the raw names the analyzers used.
+	Aliases []string `json:"aliases"`
+	// Sources are the analyzers that named the family.
+	Sources []string `json:"sources"`
+	// Detections is how many times the family was named.
+	Detections int `json:"detections"`
+}
+
+// MALWARE_FAMILY_ALIASES maps the lowercase vendor names of malware families to their normalized name.
+// You can add your own aliases before extracting families.
+var MALWARE_FAMILY_ALIASES = map[string]string{
+	"emotet": "emotet", "geodo": "emotet", "heodo": "emotet",
+	"trickbot": "trickbot", "trickster": "trickbot", "trickloader": "trickbot",
+	"qakbot": "qakbot", "qbot": "qakbot", "quakbot": "qakbot", "pinkslipbot": "qakbot",
+	"agenttesla": "agenttesla", "negasteal": "agenttesla",
+	"formbook": "formbook", "xloader": "formbook",
+	"lokibot": "lokibot", "lokipws": "lokibot",
+	"remcos": "remcos", "remcosrat": "remcos",
+	"njrat": "njrat", "bladabindi": "njrat",
+	"nanocore": "nanocore", "nanocorerat": "nanocore",
+	"asyncrat": "asyncrat",
+	"redline":  "redline", "redlinestealer": "redline",
+	"cobaltstrike": "cobaltstrike", "cobeacon": "cobaltstrike",
+	"icedid": "icedid", "bokbot": "icedid",
+	"dridex": "dridex", "bugat": "dridex", "cridex": "dridex",
+	"zeus": "zeus", "zbot": "zeus",
+	"ursnif": "ursnif", "gozi": "ursnif", "isfb": "ursnif",
+	"wannacry": "wannacry", "wannacrypt": "wannacry", "wcry": "wannacry",
+	"ryuk":    "ryuk",
+	"lockbit": "lockbit",
+	"mirai":   "mirai",
+	"gafgyt":  "gafgyt", "bashlite": "gafgyt",
+	"raccoon": "raccoon", "racealer": "raccoon",
+}
+
+// malwareFamilyKeys are the report fields naming a malware family directly.
+var malwareFamilyKeys = map[string]bool{
+	"family": true, "families": true, "malware_family": true, "malware_families": true, "signature": true,
+}
+
+// malwareDetectionKeys are the report fields holding an antivirus detection label.
+var malwareDetectionKeys = map[string]bool{
+	"result": true, "detection": true, "detection_name": true, "threat": true, "threat_name": true,
+}
+
+// genericMalwareTokens are the words of antivirus labels that never name a family.
+var genericMalwareTokens = map[string]bool{
+	"trojan": true, "troj": true, "win32": true, "win64": true, "w32": true, "w64": true, "msil": true, "heur": true, "gen": true,
+	"generic": true, "malware": true, "agent": true, "variant": true, "mtb": true, "malicious": true, "suspicious": true,
+	"backdoor": true, "downloader": true, "dropper": true, "banker": true, "spy": true, "ransom": true, "worm": true,
+	"virus": true, "riskware": true, "adware": true, "pua": true, "pup": true, "unsafe": true, "high": true,
+	"confidence": true, "linux": true, "android": true, "script": true, "macro": true, "ml": true, "ai": true,
+	"score": true, "behaveslike": true, "trojanspy": true, "trojandownloader": true, "trojanbanker": true,
+	"ransomware": true, "injector": true, "packed": true, "crypt": true, "kryptik": true, "razy": true, "cryp": true,
+}
+
+var malwareTokenSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NormalizeMalwareFamily returns the normalized name of a malware family name, e.g. "emotet" for "Geodo".
+// Unknown families are lowercased with their punctuation removed.
+func NormalizeMalwareFamily(name string) string {
+	normalized := malwareTokenSeparators.ReplaceAllString(strings.ToLower(name), "")
+	if canonical, ok := MALWARE_FAMILY_ALIASES[normalized]; ok {
+		return canonical
+	}
+	return normalized
+}
+
+// malwareFamilyFromLabel finds the family named by an antivirus label such as "HEUR:Trojan-Banker.Win32.Emotet.gen".
+// It returns the known family of the label, or else the first word that is not a generic one, and whether it is known.
+func malwareFamilyFromLabel(label string) (string, bool) {
+	candidate := ""
+	for _, token := range malwareTokenSeparators.Split(strings.ToLower(label), -1) {
+		if canonical, ok := MALWARE_FAMILY_ALIASES[token]; ok {
+			return canonical, true
+		}
+		if candidate == "" && len(token) >= 4 && !genericMalwareTokens[token] && !strings.HasPrefix(token, "generic") && strings.Trim(token, "0123456789abcdef") != "" {
+			candidate = token
+		}
+	}
+	return candidate, false
+}
+
+type malwareFamilyCandidate struct {
+	family  *MalwareFamily
+	trusted bool
+}
+
+// ExtractMalwareFamilies finds the malware families named by the antivirus and sandbox reports of the job,
+// normalizing vendor aliases through MALWARE_FAMILY_ALIASES.
+//
+// Families named in a family field, or known to the alias table, are always kept.
+// Unknown names only found in antivirus labels are kept when at least two labels agree on them.
+// The families are sorted by number of detections.
+func ExtractMalwareFamilies(job *Job) []MalwareFamily {
+	candidates := map[string]*malwareFamilyCandidate{}
+	add := func(name string, alias string, source string, trusted bool) {
+		if name == "" {
+			return
+		}
+		candidate, ok := candidates[name]
+		if !ok {
+			candidate = &malwareFamilyCandidate{family: &MalwareFamily{Name: name}}
+			candidates[name] = candidate
+		}
+		candidate.trusted = candidate.trusted || trusted
+		candidate.family.Detections++
+		candidate.family.Aliases = appendUnique(candidate.family.Aliases, alias)
+		candidate.family.Sources = appendUnique(candidate.family.Sources, source)
+	}
+	for index := range job.AnalyzerReports {
+		report := &job.AnalyzerReports[index]
+		walkMalwareFields(report.Report, "", func(key string, value string) {
+			if malwareFamilyKeys[key] {
+				add(NormalizeMalwareFamily(value), value, report.Name, true)
+			} else if malwareDetectionKeys[key] {
+				name, known := malwareFamilyFromLabel(value)
+				add(name, value, report.Name, known)
+			}
+		})
+	}
+	families := []MalwareFamily{}
+	for _, candidate := range candidates {
+		if candidate.trusted || candidate.family.Detections >= 2 {
+			families = append(families, *candidate.family)
+		}
+	}
+	sort.Slice(families, func(i, j int) bool {
+		if families[i].Detections != families[j].Detections {
+			return families[i].Detections > families[j].Detections
+		}
+		return families[i].Name < families[j].Name
+	})
+	return families
+}
+
+// walkMalwareFields calls visit with every string of the report and the field it is in.
+func walkMalwareFields(value interface{}, key string, visit func(key string, value string)) {
+	switch typedValue := value.(type) {
+	case string:
+		if typedValue != "" {
+			visit(key, typedValue)
+		}
+	case map[string]interface{}:
+		for fieldKey, fieldValue := range typedValue {
+			walkMalwareFields(fieldValue, strings.ToLower(fieldKey), visit)
+		}
+	case []interface{}:
+		for _, item := range typedValue {
+			walkMalwareFields(item, key, visit)
+		}
+	}
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
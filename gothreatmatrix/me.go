@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -44,6 +45,17 @@ type Organization struct {
 	IsUserOwner  bool       `json:"is_user_owner,omitempty"`
 	CreatedAt    *time.Time `json:"created_at,omitempty"`
 	Name         string     `json:"name"`
+	// Members are listed by the servers telling them, see UserService.Members.
+	Members []Member `json:"members,omitempty"`
+}
+
+// Member represents a user belonging to an organization.
+type Member struct {
+	Username string    `json:"username"`
+	FullName string    `json:"full_name"`
+	Joined   time.Time `json:"joined"`
+	IsOwner  bool      `json:"is_owner"`
+	IsAdmin  bool      `json:"is_admin"`
 }
 
 type OrganizationParams struct {
@@ -213,3 +225,112 @@ func (userService *UserService) RemoveMemberFromOrganization(ctx context.Context
 	}
 	return false, nil
 }
+
+// Members lists the members of the organization of the user, its owner included, to audit who has access.
+//
+//	Endpoint: GET /api/me/organization
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/me/operation/me_organization_list
+func (userService *UserService) Members(ctx context.Context) ([]Member, error) {
+	organization, err := userService.Organization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members := organization.Members
+	if members == nil {
+		members = []Member{}
+	}
+	hasOwner := false
+	for _, member := range members {
+		hasOwner = hasOwner || member.Username == organization.Owner.Username
+	}
+	// * older servers only tell the owner of the organization
+	if !hasOwner && organization.Owner.Username != "" {
+		members = append([]Member{{
+			Username: organization.Owner.Username,
+			FullName: organization.Owner.FullName,
+			Joined:   organization.Owner.Joined,
+			IsOwner:  true,
+			IsAdmin:  true,
+		}}, members...)
+	}
+	return members, nil
+}
+
+// LeaveOrganization lets you leave your organization. The owner cannot leave it.
+//
+//	Endpoint: POST /api/me/organization/leave
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/me/operation/me_organization_leave_create
+func (userService *UserService) LeaveOrganization(ctx context.Context) (bool, error) {
+	requestUrl := userService.client.options.Url + constants.LEAVE_ORGANIZATION_URL
+	contentType := "application/json"
+	method := "POST"
+	request, err := userService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return false, err
+	}
+	successResp, err := userService.client.newRequest(ctx, request)
+	if err != nil {
+		return false, err
+	}
+	if successResp.StatusCode == http.StatusNoContent {
+		return true, nil
+	}
+	return false, nil
+}
+
+// Invitations lists the invitations to join an organization the user received.
+//
+//	Endpoint: GET /api/me/invitations
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/me/operation/me_invitations_list
+func (userService *UserService) Invitations(ctx context.Context) ([]Invitation, error) {
+	requestUrl := userService.client.options.Url + constants.INVITATIONS_URL
+	contentType := "application/json"
+	method := "GET"
+	request, err := userService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	invitations := []Invitation{}
+	successResp, err := userService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalError := json.Unmarshal(successResp.Data, &invitations); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation joins the organization of the invitation.
+//
+//	Endpoint: POST /api/me/invitations/{id}/accept
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/me/operation/me_invitations_accept_create
+func (userService *UserService) AcceptInvitation(ctx context.Context, invitationId int) error {
+	return userService.answerInvitation(ctx, constants.ACCEPT_INVITATION_URL, invitationId)
+}
+
+// DeclineInvitation declines the invitation.
+//
+//	Endpoint: POST /api/me/invitations/{id}/decline
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/me/operation/me_invitations_decline_create
+func (userService *UserService) DeclineInvitation(ctx context.Context, invitationId int) error {
+	return userService.answerInvitation(ctx, constants.DECLINE_INVITATION_URL, invitationId)
+}
+
+// answerInvitation posts to the accept or decline route of the invitation.
+func (userService *UserService) answerInvitation(ctx context.Context, route string, invitationId int) error {
+	requestUrl := userService.client.options.Url + fmt.Sprintf(route, invitationId)
+	contentType := "application/json"
+	method := "POST"
+	request, err := userService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return err
+	}
+	_, err = userService.client.newRequest(ctx, request)
+	return err
+}
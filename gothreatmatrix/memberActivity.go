@@ -0,0 +1,140 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// ActivityOptions represents the fields used to configure OrganizationActivity.
+type ActivityOptions struct {
+	// From and To bound the received time of the counted jobs, a zero time leaving that side open.
+	From time.Time
+	To   time.Time
+	// Filter, when set, further restricts the counted jobs. Its pagination is overridden.
+	Filter *JobFilter
+	// PageSize is the number of jobs fetched per page, DEFAULT_REPLAY_PAGE_SIZE when 0.
+	PageSize int
+}
+
+// MemberActivity represents the jobs submitted by a member of the organization.
+type MemberActivity struct {
+	Username string `json:"username"`
+	Jobs     int    `json:"jobs"`
+	// Observables and Samples split the Jobs between observable and file analyses.
+	Observables int `json:"observables"`
+	Samples     int `json:"samples"`
+	// AnalyzerRuns counts the analyzers executed by the jobs, ExternalAnalyzerRuns the ones using an external service,
+	// which is what consumes the quotas of the external providers.
+	AnalyzerRuns         int `json:"analyzer_runs"`
+	ExternalAnalyzerRuns int `json:"external_analyzer_runs"`
+	// Outcomes counts the jobs per status. ThreatMatrix keeps no verdict per job, so the status is the outcome reported on.
+	Outcomes map[JobStatus]int `json:"outcomes"`
+}
+
+// OutcomeRatio returns the share of the jobs of the member that ended with the status, between 0 and 1.
+func (memberActivity *MemberActivity) OutcomeRatio(status JobStatus) float64 {
+	if memberActivity.Jobs == 0 {
+		return 0
+	}
+	return float64(memberActivity.Outcomes[status]) / float64(memberActivity.Jobs)
+}
+
+// add counts the job in the activity.
+func (memberActivity *MemberActivity) add(job *JobList, externalAnalyzers map[string]bool) {
+	memberActivity.Jobs++
+	if job.IsSample {
+		memberActivity.Samples++
+	} else {
+		memberActivity.Observables++
+	}
+	memberActivity.AnalyzerRuns += len(job.AnalyzersToExecute)
+	for _, analyzer := range job.AnalyzersToExecute {
+		if externalAnalyzers[analyzer] {
+			memberActivity.ExternalAnalyzerRuns++
+		}
+	}
+	memberActivity.Outcomes[job.State()]++
+}
+
+// ActivityReport represents the activity of the members of the organization over a period.
+type ActivityReport struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Members are sorted by number of jobs, the most active first.
+	Members []MemberActivity `json:"members"`
+	// Total adds up the activity of every member, its Username is empty.
+	Total MemberActivity `json:"total"`
+}
+
+// OrganizationActivity aggregates the jobs visible to the user, i.e. the ones of their organization, per member
+// who submitted them: job counts, observables and samples submitted, analyzer runs and outcome ratios.
+// The analyzer catalog is fetched to tell the analyzers using an external service apart.
+//
+//	Endpoint: GET /api/jobs
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_list
+func (userService *UserService) OrganizationActivity(ctx context.Context, options *ActivityOptions) (*ActivityReport, error) {
+	if options == nil {
+		options = &ActivityOptions{}
+	}
+	analyzerConfigs, err := userService.client.AnalyzerService.GetConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	externalAnalyzers := map[string]bool{}
+	for _, analyzerConfig := range *analyzerConfigs {
+		externalAnalyzers[analyzerConfig.Name] = analyzerConfig.ExternalService
+	}
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = DEFAULT_REPLAY_PAGE_SIZE
+	}
+	filter := NewJobFilter()
+	if options.Filter != nil {
+		filter.values = options.Filter.Values()
+	}
+	if !options.From.IsZero() {
+		filter.ReceivedAfter(options.From)
+	}
+	if !options.To.IsZero() {
+		filter.ReceivedBefore(options.To)
+	}
+	filter.PageSize(pageSize)
+	report := &ActivityReport{
+		From:    options.From,
+		To:      options.To,
+		Members: []MemberActivity{},
+		Total:   MemberActivity{Outcomes: map[JobStatus]int{}},
+	}
+	members := map[string]*MemberActivity{}
+	for page := 1; ; page++ {
+		jobList, err := userService.client.JobService.ListWithFilter(ctx, filter.Page(page))
+		if err != nil {
+			return nil, err
+		}
+		for index := range jobList.Results {
+			job := &jobList.Results[index]
+			member, ok := members[job.User.Username]
+			if !ok {
+				member = &MemberActivity{Username: job.User.Username, Outcomes: map[JobStatus]int{}}
+				members[job.User.Username] = member
+			}
+			member.add(job, externalAnalyzers)
+			report.Total.add(job, externalAnalyzers)
+		}
+		if page >= jobList.TotalPages || len(jobList.Results) == 0 {
+			break
+		}
+	}
+	for _, member := range members {
+		report.Members = append(report.Members, *member)
+	}
+	sort.Slice(report.Members, func(i, j int) bool {
+		if report.Members[i].Jobs != report.Members[j].Jobs {
+			return report.Members[i].Jobs > report.Members[j].Jobs
+		}
+		return report.Members[i].Username < report.Members[j].Username
+	})
+	return report, nil
+}
@@ -0,0 +1,103 @@
+package gothreatmatrix
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RoundTripFunc is an http.RoundTripper written as a function, what the middlewares of the client wrap.
+type RoundTripFunc func(request *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (roundTrip RoundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return roundTrip(request)
+}
+
+// Middleware wraps the sending of the requests of the client, e.g. to add headers, log or trace them:
+//
+//	client.Use(func(next gothreatmatrix.RoundTripFunc) gothreatmatrix.RoundTripFunc {
+//		return func(request *http.Request) (*http.Response, error) {
+//			request.Header.Set("X-Tenant", "soc-eu")
+//			return next(request)
+//		}
+//	})
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// middlewareChain holds the middlewares registered through Use.
+type middlewareChain struct {
+	mutex       sync.RWMutex
+	middlewares []Middleware
+}
+
+// Use registers middlewares around every request the client sends, retried attempts included.
+// The first middleware registered is the outermost one, and the innermost one calls the http.Client.
+func (client *ThreatMatrixClient) Use(middlewares ...Middleware) {
+	client.middleware.mutex.Lock()
+	defer client.middleware.mutex.Unlock()
+	client.middleware.middlewares = append(client.middleware.middlewares, middlewares...)
+}
+
+// do sends the request through the middlewares, then the http.Client, logging what the http.Client sends,
+// the streaming transfers being sent without its Timeout (see withStreaming),
+// and records its response in the ResponseInfo of its context. It first checks the CircuitBreaker of the client,
+// then waits for its RateLimit and for a slot of its ConnectionBudget, held until the response body is closed.
+// It asks for a gzip response and decompresses it, unless DisableCompression is set.
+// In DryRun mode, the requests changing something on the instance are recorded instead, see recordDryRun.
+func (client *ThreatMatrixClient) do(request *http.Request) (*http.Response, error) {
+	if response, recorded, err := client.recordDryRun(request); recorded {
+		return response, err
+	}
+	roundTrip := client.loggedRoundTrip(client.httpClientFor(request).Do)
+	if client.middleware != nil {
+		client.middleware.mutex.RLock()
+		middlewares := client.middleware.middlewares
+		client.middleware.mutex.RUnlock()
+		for index := len(middlewares) - 1; index >= 0; index-- {
+			roundTrip = middlewares[index](roundTrip)
+		}
+	}
+	host := request.URL.Host
+	if client.breaker != nil {
+		if err := client.breaker.allow(host); err != nil {
+			return nil, err
+		}
+	}
+	if client.limiter != nil {
+		if err := client.limiter.wait(request.Context()); err != nil {
+			client.releaseCircuit(host)
+			return nil, err
+		}
+	}
+	if client.budget != nil {
+		if err := client.budget.acquire(request.Context()); err != nil {
+			client.releaseCircuit(host)
+			return nil, err
+		}
+	}
+	request, decompress := client.acceptGzip(request)
+	start := time.Now()
+	response, err := roundTrip(request)
+	recordResponse(request, response, time.Since(start))
+	if decompress {
+		decompressResponse(response)
+	}
+	if client.breaker != nil {
+		client.breaker.done(host, response, err)
+	}
+	if client.budget != nil {
+		if response == nil || response.Body == nil {
+			client.budget.release()
+		} else {
+			response.Body = newBudgetedBody(response.Body, client.budget)
+		}
+	}
+	return response, err
+}
+
+// releaseCircuit lets another request probe the host when the request was given up before being sent.
+func (client *ThreatMatrixClient) releaseCircuit(host string) {
+	if client.breaker != nil {
+		client.breaker.release(host)
+	}
+}
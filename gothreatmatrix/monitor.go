@@ -0,0 +1,259 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Values of the plugin types reported by the Monitor and the DeprecationNotices.
+const (
+	ANALYZER_PLUGIN  = "analyzer"
+	CONNECTOR_PLUGIN = "connector"
+	PLAYBOOK_PLUGIN  = "playbook"
+)
+
+// DEFAULT_MONITOR_MIN_INTERVAL, DEFAULT_MONITOR_MAX_INTERVAL and DEFAULT_MONITOR_FAILURE_MAX_INTERVAL bound
+// the intervals between the health checks of the Monitor when MonitorOptions leaves them at 0.
+const (
+	DEFAULT_MONITOR_MIN_INTERVAL         = 30 * time.Second
+	DEFAULT_MONITOR_MAX_INTERVAL         = 10 * time.Minute
+	DEFAULT_MONITOR_FAILURE_MAX_INTERVAL = 5 * time.Minute
+)
+
+// PluginHealth represents the health of a plugin as seen by the Monitor.
+type PluginHealth struct {
+	// Type is ANALYZER_PLUGIN or CONNECTOR_PLUGIN.
+	Type    string
+	Name    string
+	Healthy bool
+	// Err is the error of the health check, the plugin is then not Healthy.
+	Err       error
+	CheckedAt time.Time
+}
+
+// MonitorOptions represents the fields used to configure MonitorPluginHealth.
+type MonitorOptions struct {
+	// Analyzers and Connectors are the plugins monitored. When both are empty, the docker based analyzers
+	// and every connector are monitored, the plugins ThreatMatrix can run a health check on.
+	Analyzers  []string
+	Connectors []string
+	// Every plugin has its own schedule. MinInterval (DEFAULT_MONITOR_MIN_INTERVAL when 0) is the wait before checking
+	// a plugin again once its health changed. The wait then doubles after every check with the same outcome:
+	// up to MaxInterval (DEFAULT_MONITOR_MAX_INTERVAL when 0) for a healthy plugin, which ends up checked rarely,
+	// and up to FailureMaxInterval (DEFAULT_MONITOR_FAILURE_MAX_INTERVAL when 0) for a failing one,
+	// whose failure is meanwhile cached rather than hammering a broken Docker analyzer.
+	MinInterval        time.Duration
+	MaxInterval        time.Duration
+	FailureMaxInterval time.Duration
+}
+
+// Monitor represents a live stream of plugin health, see MonitorPluginHealth.
+type Monitor struct {
+	updates chan PluginHealth
+	cancel  context.CancelFunc
+	done    chan struct{}
+	mutex   sync.Mutex
+	err     error
+	health  map[[2]string]PluginHealth
+}
+
+// Health returns the last health seen of the plugin of that type (ANALYZER_PLUGIN or CONNECTOR_PLUGIN),
+// ok is false when it was not checked yet.
+func (monitor *Monitor) Health(pluginType string, name string) (health PluginHealth, ok bool) {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	health, ok = monitor.health[[2]string{pluginType, name}]
+	return health, ok
+}
+
+// Updates returns the channel the health of a plugin is sent on, on the first check and every time it changes.
+// It is closed once the monitor stops, and it must be drained for the monitor to make progress.
+func (monitor *Monitor) Updates() <-chan PluginHealth {
+	return monitor.updates
+}
+
+// Stop stops the monitor.
+func (monitor *Monitor) Stop() {
+	monitor.cancel()
+}
+
+// Wait blocks until the monitor stopped and returns why: the error that prevented listing the plugins,
+// or the cancellation of its context.
+func (monitor *Monitor) Wait() error {
+	<-monitor.done
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	return monitor.err
+}
+
+// MonitorPluginHealth streams the health of the plugins until ctx is done or the monitor is stopped.
+// ThreatMatrix exposes no push channel for the state of the plugins, so the monitor polls their health checks,
+// each plugin on its own schedule, see MonitorOptions.
+//
+//	Endpoint: GET /api/analyzer/{NameOfAnalyzer}/healthcheck
+//	Endpoint: GET /api/connector/{NameOfConnector}/healthcheck
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/analyzer/operation/analyzer_healthcheck_retrieve
+func (client *ThreatMatrixClient) MonitorPluginHealth(ctx context.Context, options *MonitorOptions) *Monitor {
+	if options == nil {
+		options = &MonitorOptions{}
+	}
+	monitorCtx, cancel := context.WithCancel(ctx)
+	monitor := &Monitor{
+		updates: make(chan PluginHealth),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		health:  map[[2]string]PluginHealth{},
+	}
+	go func() {
+		defer close(monitor.done)
+		defer close(monitor.updates)
+		err := client.monitor(monitorCtx, options, monitor)
+		monitor.mutex.Lock()
+		monitor.err = err
+		monitor.mutex.Unlock()
+	}()
+	return monitor
+}
+
+// monitoredPlugins returns the plugins to monitor, as [type, name] pairs.
+func (client *ThreatMatrixClient) monitoredPlugins(ctx context.Context, options *MonitorOptions) ([][2]string, error) {
+	plugins := [][2]string{}
+	analyzers, connectors := options.Analyzers, options.Connectors
+	if len(analyzers) == 0 && len(connectors) == 0 {
+		analyzerConfigs, err := client.AnalyzerService.GetConfigs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, analyzerConfig := range *analyzerConfigs {
+			if analyzerConfig.DockerBased && !analyzerConfig.Disabled {
+				analyzers = append(analyzers, analyzerConfig.Name)
+			}
+		}
+		connectorConfigs, err := client.ConnectorService.GetConfigs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, connectorConfig := range *connectorConfigs {
+			if !connectorConfig.Disabled {
+				connectors = append(connectors, connectorConfig.Name)
+			}
+		}
+	}
+	for _, analyzer := range analyzers {
+		plugins = append(plugins, [2]string{ANALYZER_PLUGIN, analyzer})
+	}
+	for _, connector := range connectors {
+		plugins = append(plugins, [2]string{CONNECTOR_PLUGIN, connector})
+	}
+	return plugins, nil
+}
+
+// pluginSchedule represents when a plugin is checked next.
+type pluginSchedule struct {
+	plugin   [2]string
+	interval time.Duration
+	next     time.Time
+	checked  bool
+	last     PluginHealth
+}
+
+// monitor checks the health of the plugins when they are due and sends the changes until ctx is done.
+func (client *ThreatMatrixClient) monitor(ctx context.Context, options *MonitorOptions, monitor *Monitor) error {
+	minInterval := options.MinInterval
+	if minInterval <= 0 {
+		minInterval = DEFAULT_MONITOR_MIN_INTERVAL
+	}
+	maxInterval := options.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = DEFAULT_MONITOR_MAX_INTERVAL
+	}
+	failureMaxInterval := options.FailureMaxInterval
+	if failureMaxInterval <= 0 {
+		failureMaxInterval = DEFAULT_MONITOR_FAILURE_MAX_INTERVAL
+	}
+	plugins, err := client.monitoredPlugins(ctx, options)
+	if err != nil {
+		return err
+	}
+	schedules := make([]*pluginSchedule, len(plugins))
+	for index, plugin := range plugins {
+		schedules[index] = &pluginSchedule{plugin: plugin}
+	}
+	for {
+		now := time.Now()
+		next := time.Time{}
+		for _, schedule := range schedules {
+			if !schedule.next.After(now) {
+				health, err := client.checkPlugin(ctx, schedule.plugin)
+				if err != nil {
+					return err
+				}
+				changed := !schedule.checked || schedule.last.Healthy != health.Healthy || (schedule.last.Err == nil) != (health.Err == nil)
+				switch {
+				case changed:
+					schedule.interval = minInterval
+				case health.Healthy:
+					schedule.interval = doubleInterval(schedule.interval, maxInterval)
+				default:
+					schedule.interval = doubleInterval(schedule.interval, failureMaxInterval)
+				}
+				schedule.checked = true
+				schedule.last = health
+				schedule.next = health.CheckedAt.Add(schedule.interval)
+				monitor.mutex.Lock()
+				monitor.health[schedule.plugin] = health
+				monitor.mutex.Unlock()
+				if changed {
+					select {
+					case <-ctx.Done():
+						return ctx.Err()
+					case monitor.updates <- health:
+					}
+				}
+			}
+			if next.IsZero() || schedule.next.Before(next) {
+				next = schedule.next
+			}
+		}
+		if len(schedules) == 0 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// checkPlugin runs the health check of the plugin, err is only set when ctx is done.
+func (client *ThreatMatrixClient) checkPlugin(ctx context.Context, plugin [2]string) (PluginHealth, error) {
+	health := PluginHealth{Type: plugin[0], Name: plugin[1]}
+	if plugin[0] == ANALYZER_PLUGIN {
+		health.Healthy, health.Err = client.AnalyzerService.HealthCheck(ctx, plugin[1])
+	} else {
+		health.Healthy, health.Err = client.ConnectorService.HealthCheck(ctx, plugin[1])
+	}
+	if ctx.Err() != nil {
+		return health, ctx.Err()
+	}
+	if health.Err != nil {
+		health.Healthy = false
+	}
+	health.CheckedAt = time.Now()
+	return health, nil
+}
+
+// doubleInterval doubles the interval, up to max.
+func doubleInterval(interval time.Duration, max time.Duration) time.Duration {
+	interval *= 2
+	if interval > max {
+		return max
+	}
+	return interval
+}
@@ -0,0 +1,271 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrNoInstances is returned by NewMultiClient when MultiClientOptions has no URL.
+var ErrNoInstances = errors.New("gothreatmatrix: no instance URL")
+
+// DEFAULT_FAILOVER_COOLDOWN is how long an unavailable instance is skipped when MultiClientOptions leaves Cooldown at 0.
+const DEFAULT_FAILOVER_COOLDOWN = 30 * time.Second
+
+// MultiClientOptions represents the fields used to configure NewMultiClient.
+type MultiClientOptions struct {
+	// Urls are the base URLs of the instances, the primary first, e.g. the primary and the DR instance.
+	// The calls go to the first healthy one.
+	Urls []string `json:"urls"`
+	// Cooldown is how long an instance found unavailable is skipped, DEFAULT_FAILOVER_COOLDOWN when 0.
+	Cooldown time.Duration `json:"cooldown"`
+	// StickyJobs, when true, sends the calls about a job created through the MultiClient to the instance that
+	// created it, see MultiClient.ForJob, the instances not sharing their jobs.
+	StickyJobs bool `json:"sticky_jobs"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, Cooldown also reading a duration string, e.g. "1m".
+func (multiClientOptions *MultiClientOptions) UnmarshalJSON(data []byte) error {
+	type plainMultiClientOptions MultiClientOptions
+	return unmarshalWithDurations(data, (*plainMultiClientOptions)(multiClientOptions))
+}
+
+// InstanceHealth represents the health of an instance of a MultiClient as seen by its calls.
+type InstanceHealth struct {
+	Url     string `json:"url"`
+	Healthy bool   `json:"healthy"`
+	// UnhealthyUntil is when the instance gets tried again, zero for a healthy instance.
+	UnhealthyUntil time.Time `json:"unhealthy_until"`
+	// LastError is the error the instance was found unavailable with, nil for a healthy instance.
+	LastError error `json:"-"`
+}
+
+// multiInstance represents an instance of a MultiClient.
+type multiInstance struct {
+	client         *ThreatMatrixClient
+	unhealthyUntil time.Time
+	lastError      error
+}
+
+// MultiClient wraps the clients of redundant ThreatMatrix instances, routing the calls to the first healthy one
+// and failing over to the next ones when an instance is unavailable: unreachable, answering 502, 503 or 504,
+// or with an open circuit, see ThreatMatrixClientOptions.CircuitBreaker.
+//
+// The reads fail over on any unavailability, timeouts included. The writes only fail over when the instance
+// surely did not take them, so that a submission timing out on an instance that actually created its job is not
+// submitted to the next one.
+type MultiClient struct {
+	options   MultiClientOptions
+	mutex     sync.Mutex
+	instances []*multiInstance
+	// jobs are the indexes of the instances that created the jobs, by job ID, when StickyJobs is set.
+	jobs map[int]int
+}
+
+// NewMultiClient returns a MultiClient with a client for every URL of multiOptions, made by NewThreatMatrixClient
+// from options, its Url replaced.
+func NewMultiClient(multiOptions *MultiClientOptions, options *ThreatMatrixClientOptions, httpClient *http.Client, loggerParams *LoggerParams) (*MultiClient, error) {
+	if len(multiOptions.Urls) == 0 {
+		return nil, ErrNoInstances
+	}
+	multiClient := &MultiClient{
+		options: *multiOptions,
+		jobs:    map[int]int{},
+	}
+	if multiClient.options.Cooldown <= 0 {
+		multiClient.options.Cooldown = DEFAULT_FAILOVER_COOLDOWN
+	}
+	for _, url := range multiOptions.Urls {
+		instanceOptions := *options
+		instanceOptions.Url = url
+		client := NewThreatMatrixClient(&instanceOptions, httpClient, loggerParams)
+		multiClient.instances = append(multiClient.instances, &multiInstance{client: &client})
+	}
+	return multiClient, nil
+}
+
+// Clients returns the clients of the instances, in the order of MultiClientOptions.Urls.
+func (multiClient *MultiClient) Clients() []*ThreatMatrixClient {
+	clients := make([]*ThreatMatrixClient, len(multiClient.instances))
+	for index, instance := range multiClient.instances {
+		clients[index] = instance.client
+	}
+	return clients
+}
+
+// Health returns the health of the instances, in the order of MultiClientOptions.Urls.
+func (multiClient *MultiClient) Health() []InstanceHealth {
+	multiClient.mutex.Lock()
+	defer multiClient.mutex.Unlock()
+	now := time.Now()
+	health := make([]InstanceHealth, len(multiClient.instances))
+	for index, instance := range multiClient.instances {
+		health[index] = InstanceHealth{Url: instance.client.options.Url, Healthy: !now.Before(instance.unhealthyUntil)}
+		if !health[index].Healthy {
+			health[index].UnhealthyUntil = instance.unhealthyUntil
+			health[index].LastError = instance.lastError
+		}
+	}
+	return health
+}
+
+// Read runs the call on the first healthy instance, then on the next ones for as long as the instances are
+// unavailable. It returns the error of the last instance tried when none answered.
+func (multiClient *MultiClient) Read(ctx context.Context, call func(client *ThreatMatrixClient) error) error {
+	_, err := multiClient.route(ctx, false, call)
+	return err
+}
+
+// Write runs the call on the first healthy instance, failing over to the next ones only when the instance
+// surely did not take the call, e.g. refused the connection or answered 503.
+func (multiClient *MultiClient) Write(ctx context.Context, call func(client *ThreatMatrixClient) error) error {
+	_, err := multiClient.route(ctx, true, call)
+	return err
+}
+
+// ForJob runs the call about the job on the instance that created it when StickyJobs is set and the job was
+// created through the MultiClient, without failover since no other instance has the job. It runs it as Read does
+// otherwise.
+func (multiClient *MultiClient) ForJob(ctx context.Context, jobId int, call func(client *ThreatMatrixClient) error) error {
+	multiClient.mutex.Lock()
+	index, ok := multiClient.jobs[jobId]
+	multiClient.mutex.Unlock()
+	if !ok {
+		return multiClient.Read(ctx, call)
+	}
+	instance := multiClient.instances[index]
+	err := call(instance.client)
+	multiClient.recordOutcome(instance, err, false)
+	return err
+}
+
+// CreateObservableAnalysis creates the analysis of the observable as Write does, on the first healthy instance,
+// see ThreatMatrixClient.CreateObservableAnalysis. With StickyJobs, the instance of the job is remembered.
+func (multiClient *MultiClient) CreateObservableAnalysis(ctx context.Context, params *ObservableAnalysisParams) (*AnalysisResponse, error) {
+	var analysisResponse *AnalysisResponse
+	index, err := multiClient.route(ctx, true, func(client *ThreatMatrixClient) error {
+		response, err := client.CreateObservableAnalysis(ctx, params)
+		analysisResponse = response
+		return err
+	})
+	if err != nil {
+		return analysisResponse, err
+	}
+	multiClient.rememberJob(analysisResponse.JobID, index)
+	return analysisResponse, nil
+}
+
+// CreateFileAnalysis creates the analysis of the file as Write does, on the first healthy instance, see
+// ThreatMatrixClient.CreateFileAnalysis. The file is read again from where it was for every instance tried.
+// With StickyJobs, the instance of the job is remembered.
+func (multiClient *MultiClient) CreateFileAnalysis(ctx context.Context, fileAnalysisParams *FileAnalysisParams) (*AnalysisResponse, error) {
+	offset, err := fileAnalysisParams.File.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	var analysisResponse *AnalysisResponse
+	index, err := multiClient.route(ctx, true, func(client *ThreatMatrixClient) error {
+		if _, err := fileAnalysisParams.File.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		response, err := client.CreateFileAnalysis(ctx, fileAnalysisParams)
+		analysisResponse = response
+		return err
+	})
+	if err != nil {
+		return analysisResponse, err
+	}
+	multiClient.rememberJob(analysisResponse.JobID, index)
+	return analysisResponse, nil
+}
+
+// rememberJob records the instance that created the job, when StickyJobs is set.
+func (multiClient *MultiClient) rememberJob(jobId int, index int) {
+	if !multiClient.options.StickyJobs {
+		return
+	}
+	multiClient.mutex.Lock()
+	defer multiClient.mutex.Unlock()
+	multiClient.jobs[jobId] = index
+}
+
+// candidates returns the indexes of the instances to try in order: the healthy ones, then the ones cooling down,
+// tried anyway rather than failing without trying any.
+func (multiClient *MultiClient) candidates() []int {
+	multiClient.mutex.Lock()
+	defer multiClient.mutex.Unlock()
+	now := time.Now()
+	healthy, unhealthy := []int{}, []int{}
+	for index, instance := range multiClient.instances {
+		if now.Before(instance.unhealthyUntil) {
+			unhealthy = append(unhealthy, index)
+		} else {
+			healthy = append(healthy, index)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// route runs the call on the instances in the order of candidates until one is not unavailable, returning the
+// index of the last instance tried.
+func (multiClient *MultiClient) route(ctx context.Context, write bool, call func(client *ThreatMatrixClient) error) (int, error) {
+	var err error
+	index := 0
+	for _, index = range multiClient.candidates() {
+		instance := multiClient.instances[index]
+		err = call(instance.client)
+		if !multiClient.recordOutcome(instance, err, write) || ctx.Err() != nil {
+			return index, err
+		}
+	}
+	return index, err
+}
+
+// recordOutcome updates the health of the instance with the error of a call, reporting whether the call should
+// fail over to the next instance.
+func (multiClient *MultiClient) recordOutcome(instance *multiInstance, err error, write bool) bool {
+	unavailable := isInstanceUnavailable(err, false)
+	multiClient.mutex.Lock()
+	defer multiClient.mutex.Unlock()
+	if !unavailable {
+		// * the instance answered, even with an error of the call
+		instance.unhealthyUntil = time.Time{}
+		instance.lastError = nil
+		return false
+	}
+	instance.unhealthyUntil = time.Now().Add(multiClient.options.Cooldown)
+	instance.lastError = err
+	return !write || isInstanceUnavailable(err, true)
+}
+
+// isInstanceUnavailable reports whether the call failed with err because the instance is unavailable. For a
+// write, only the failures where the instance surely did not take the call count.
+func isInstanceUnavailable(err error, write bool) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+	var threatMatrixError *ThreatMatrixError
+	if errors.As(err, &threatMatrixError) {
+		switch threatMatrixError.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable:
+			return true
+		case http.StatusGatewayTimeout:
+			return !write
+		}
+		return false
+	}
+	var opError *net.OpError
+	if errors.As(err, &opError) && opError.Op == "dial" {
+		return true
+	}
+	// * the request may have reached the instance before failing, e.g. on a timeout
+	var netError net.Error
+	return !write && (errors.As(err, &netError) || errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,84 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// Notification represents a notification the administrators of the ThreatMatrix instance sent to its users,
+// e.g. about an upgrade or an analyzer going down.
+type Notification struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	// Description is the content of the notification, HTML formatted.
+	Description string     `json:"description"`
+	Read        bool       `json:"read"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+// NotificationService handles communication with notification related methods of the ThreatMatrix API.
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/notification
+type NotificationService struct {
+	client *ThreatMatrixClient
+}
+
+// List fetches every notification of the user, newest first.
+//
+//	Endpoint: GET /api/notification
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/notification/operation/notification_list
+func (notificationService *NotificationService) List(ctx context.Context) ([]Notification, error) {
+	return newPaginator[Notification](notificationService.client, constants.BASE_NOTIFICATION_URL, nil, 0).All(ctx)
+}
+
+// ListUnread fetches the notifications the user did not read yet, e.g. to forward them to a chat channel before
+// marking them as read.
+//
+//	Endpoint: GET /api/notification?read=false
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/notification/operation/notification_list
+func (notificationService *NotificationService) ListUnread(ctx context.Context) ([]Notification, error) {
+	query := url.Values{}
+	query.Set("read", "false")
+	notifications, err := newPaginator[Notification](notificationService.client, constants.BASE_NOTIFICATION_URL, query, 0).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// * older servers ignore the read filter
+	unread := []Notification{}
+	for _, notification := range notifications {
+		if !notification.Read {
+			unread = append(unread, notification)
+		}
+	}
+	return unread, nil
+}
+
+// MarkAsRead marks the notification as read by the user.
+//
+//	Endpoint: POST /api/notification/{id}/mark-as-read
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/notification/operation/notification_mark_as_read_create
+func (notificationService *NotificationService) MarkAsRead(ctx context.Context, notificationId int) (bool, error) {
+	requestUrl := notificationService.client.options.Url + fmt.Sprintf(constants.MARK_NOTIFICATION_AS_READ_URL, notificationId)
+	contentType := "application/json"
+	method := "POST"
+	request, err := notificationService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return false, err
+	}
+	successResp, err := notificationService.client.newRequest(ctx, request)
+	if err != nil {
+		return false, err
+	}
+	if successResp.StatusCode == http.StatusNoContent {
+		return true, nil
+	}
+	return false, nil
+}
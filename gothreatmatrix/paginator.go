@@ -0,0 +1,171 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// ErrNoMorePages is returned by Paginator.Next once every page was fetched.
+var ErrNoMorePages = errors.New("gothreatmatrix: no more pages")
+
+// PageInfo represents where a Paginator is in the list.
+type PageInfo struct {
+	// Page is the number of the last fetched page, starting at 1, 0 before the first one.
+	Page     int
+	PageSize int
+	// Count is the number of items of the whole list and TotalPages its number of pages, as the server reported them.
+	Count      int
+	TotalPages int
+}
+
+// listPage represents a page of a list endpoint, as ThreatMatrix paginates them.
+type listPage[T any] struct {
+	Count      int     `json:"count"`
+	TotalPages int     `json:"total_pages"`
+	Next       *string `json:"next"`
+	Results    []T     `json:"results"`
+}
+
+// Paginator walks a paginated list endpoint page by page, driven by the page and page_size query params.
+// Endpoints answering with a plain JSON array are walked as a single page.
+//
+// Example:
+//
+//	paginator := client.JobService.Paginate(gothreatmatrix.NewJobFilter().Status(gothreatmatrix.RUNNING), 100)
+//	for paginator.More() {
+//		jobs, err := paginator.Next(ctx)
+//		...
+//	}
+type Paginator[T any] struct {
+	client *ThreatMatrixClient
+	route  string
+	query  url.Values
+	info   PageInfo
+	done   bool
+	// feature is the optional feature the list belongs to, empty for the core ones.
+	feature string
+}
+
+// newPaginator returns a Paginator over the route, sending the query with every page.
+// A pageSize of 0 leaves the page size to the server.
+func newPaginator[T any](client *ThreatMatrixClient, route string, query url.Values, pageSize int) *Paginator[T] {
+	if query == nil {
+		query = url.Values{}
+	}
+	if pageSize > 0 {
+		query.Set("page_size", strconv.Itoa(pageSize))
+	}
+	return &Paginator[T]{
+		client: client,
+		route:  route,
+		query:  query,
+		info:   PageInfo{PageSize: pageSize},
+	}
+}
+
+// More reports whether Next has a page left to fetch.
+func (paginator *Paginator[T]) More() bool {
+	return !paginator.done
+}
+
+// PageInfo returns where the paginator is in the list.
+func (paginator *Paginator[T]) PageInfo() PageInfo {
+	return paginator.info
+}
+
+// Next fetches the next page, ErrNoMorePages once every page was fetched.
+// A failed page can be fetched again by calling Next again.
+func (paginator *Paginator[T]) Next(ctx context.Context) ([]T, error) {
+	if paginator.done {
+		return nil, ErrNoMorePages
+	}
+	page := paginator.info.Page + 1
+	paginator.query.Set("page", strconv.Itoa(page))
+	requestUrl := paginator.client.options.Url + paginator.route + "?" + paginator.query.Encode()
+	contentType := "application/json"
+	method := "GET"
+	request, err := paginator.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := paginator.client.newRequest(ctx, request)
+	if err != nil {
+		if paginator.feature != "" {
+			return nil, paginator.client.featureError(paginator.feature, err)
+		}
+		return nil, err
+	}
+	results := listPage[T]{}
+	if bytes.HasPrefix(bytes.TrimSpace(successResp.Data), []byte("[")) {
+		if err := json.Unmarshal(successResp.Data, &results.Results); err != nil {
+			return nil, err
+		}
+		results.Count = len(results.Results)
+		results.TotalPages = 1
+	} else if err := json.Unmarshal(successResp.Data, &results); err != nil {
+		return nil, err
+	}
+	paginator.info.Page = page
+	paginator.info.Count = results.Count
+	paginator.info.TotalPages = results.TotalPages
+	if len(results.Results) == 0 || (results.TotalPages > 0 && page >= results.TotalPages) || (results.TotalPages == 0 && results.Next == nil) {
+		paginator.done = true
+	}
+	if results.Results == nil {
+		results.Results = []T{}
+	}
+	return results.Results, nil
+}
+
+// All fetches the pages left and returns their items. On error, it returns the items fetched so far with it.
+func (paginator *Paginator[T]) All(ctx context.Context) ([]T, error) {
+	items := []T{}
+	for paginator.More() {
+		page, err := paginator.Next(ctx)
+		if err != nil {
+			return items, err
+		}
+		items = append(items, page...)
+	}
+	return items, nil
+}
+
+// Paginate returns a Paginator over the jobs matching the filter, nil for every job, pageSize jobs at a time.
+// The page and page_size of the filter are overridden.
+//
+//	Endpoint: GET /api/jobs
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_list
+func (jobService *JobService) Paginate(jobFilter *JobFilter, pageSize int) *Paginator[JobList] {
+	var query url.Values
+	if jobFilter != nil {
+		query = jobFilter.Values()
+	}
+	return newPaginator[JobList](jobService.client, constants.BASE_JOB_URL, query, pageSize)
+}
+
+// Paginate returns a Paginator over the tags, pageSize tags at a time.
+//
+//	Endpoint: GET /api/tags
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/tags/operation/tags_list
+func (tagService *TagService) Paginate(pageSize int) *Paginator[Tag] {
+	return newPaginator[Tag](tagService.client, constants.BASE_TAG_URL, nil, pageSize)
+}
+
+// Paginate returns a Paginator over the investigations, pageSize investigations at a time.
+//
+//	Endpoint: GET /api/investigation
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/investigation/operation/investigation_list
+func (investigationService *InvestigationService) Paginate(pageSize int) *Paginator[Investigation] {
+	paginator := newPaginator[Investigation](investigationService.client, constants.BASE_INVESTIGATION_URL, nil, pageSize)
+	paginator.feature = FEATURE_INVESTIGATIONS
+	return paginator
+}
@@ -0,0 +1,212 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PIIKind represents the kind of personal or internal information found by the PIIGuard.
+type PIIKind string
+
+// Values of the PIIKind enum.
+const (
+	PII_EMAIL             PIIKind = "email"
+	PII_INTERNAL_HOSTNAME PIIKind = "internal_hostname"
+	PII_CREDIT_CARD       PIIKind = "credit_card"
+)
+
+// PIIAction represents what the PIIGuard does with a submission containing PII.
+type PIIAction int
+
+// Values of the PIIAction enum.
+const (
+	// PII_BLOCK refuses the submission with a *PIIError.
+	PII_BLOCK PIIAction = iota
+	// PII_FORCE_RED submits the analysis with TLP:RED.
+	PII_FORCE_RED
+	// PII_LOCAL_ONLY submits the analysis with TLP:RED and only to analyzers that do not call an external service.
+	PII_LOCAL_ONLY
+)
+
+// Overriding the String method to get the string representation of the PIIAction enum
+func (piiAction PIIAction) String() string {
+	switch piiAction {
+	case PII_FORCE_RED:
+		return "FORCE_RED"
+	case PII_LOCAL_ONLY:
+		return "LOCAL_ONLY"
+	}
+	return "BLOCK"
+}
+
+// DEFAULT_INTERNAL_SUFFIXES are the domain suffixes always treated as internal hostnames.
+var DEFAULT_INTERNAL_SUFFIXES = []string{"local", "localdomain", "lan", "internal", "intranet", "corp", "home.arpa"}
+
+// ErrNoLocalAnalyzer is returned when the PIIGuard restricts an analysis to local analyzers and none of them can run it.
+var ErrNoLocalAnalyzer = errors.New("no analyzer of the analysis runs without an external service")
+
+// PIIFinding represents a likely piece of PII found in a submission.
+type PIIFinding struct {
+	Kind PIIKind
+	// Value is the submitted value (observable or file name) the PII was found in.
+	Value string
+	Match string
+}
+
+// PIIError is returned when the PIIGuard blocks a submission.
+type PIIError struct {
+	Findings []PIIFinding
+}
+
+// Error lets you implement the error interface.
+func (piiError *PIIError) Error() string {
+	matches := make([]string, 0, len(piiError.Findings))
+	for _, finding := range piiError.Findings {
+		matches = append(matches, fmt.Sprintf("%s %q", finding.Kind, finding.Match))
+	}
+	return "submission blocked, likely PII found: " + strings.Join(matches, ", ")
+}
+
+// PIIGuard scans observables and file names for likely PII before they are submitted,
+// set it in ThreatMatrixClientOptions.PIIGuard.
+type PIIGuard struct {
+	// InternalDomains are your organization's domains: emails in them are employees' emails,
+	// hostnames in them are internal hostnames. When empty every email is flagged.
+	InternalDomains []string
+	Action          PIIAction
+	// OnDetect, when set, is called with the findings of every flagged submission, whatever the Action.
+	OnDetect func(findings []PIIFinding)
+}
+
+var (
+	piiEmailRegex      = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@([A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)+)`)
+	piiHostnameRegex   = regexp.MustCompile(`[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)+`)
+	piiCreditCardRegex = regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`)
+)
+
+// Scan returns the likely PII found in the given values.
+func (guard *PIIGuard) Scan(values ...string) []PIIFinding {
+	findings := []PIIFinding{}
+	for _, value := range values {
+		for _, match := range piiEmailRegex.FindAllStringSubmatch(value, -1) {
+			if len(guard.InternalDomains) == 0 || guard.isInternalDomain(match[1]) {
+				findings = append(findings, PIIFinding{Kind: PII_EMAIL, Value: value, Match: match[0]})
+			}
+		}
+		// * the emails were already checked, their domain is not an hostname of its own
+		withoutEmails := piiEmailRegex.ReplaceAllString(value, " ")
+		for _, match := range piiHostnameRegex.FindAllString(withoutEmails, -1) {
+			if guard.isInternalHostname(match) {
+				findings = append(findings, PIIFinding{Kind: PII_INTERNAL_HOSTNAME, Value: value, Match: match})
+			}
+		}
+		for _, match := range piiCreditCardRegex.FindAllString(value, -1) {
+			if luhnValid(match) {
+				findings = append(findings, PIIFinding{Kind: PII_CREDIT_CARD, Value: value, Match: match})
+			}
+		}
+	}
+	return findings
+}
+
+// isInternalDomain reports whether the domain is one of the InternalDomains or a subdomain of one.
+func (guard *PIIGuard) isInternalDomain(domain string) bool {
+	return hasDomainSuffix(domain, guard.InternalDomains)
+}
+
+func (guard *PIIGuard) isInternalHostname(hostname string) bool {
+	return hasDomainSuffix(hostname, DEFAULT_INTERNAL_SUFFIXES) || guard.isInternalDomain(hostname)
+}
+
+func hasDomainSuffix(domain string, suffixes []string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for _, suffix := range suffixes {
+		suffix = strings.ToLower(strings.Trim(suffix, "."))
+		if suffix != "" && (domain == suffix || strings.HasSuffix(domain, "."+suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// luhnValid reports whether the digits of the number pass the Luhn checksum used by payment cards.
+func luhnValid(number string) bool {
+	sum := 0
+	digits := 0
+	for index := len(number) - 1; index >= 0; index-- {
+		character := number[index]
+		if character < '0' || character > '9' {
+			continue
+		}
+		digit := int(character - '0')
+		if digits%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		digits++
+	}
+	return digits >= 13 && sum%10 == 0
+}
+
+// guardPII applies the client's PIIGuard to the submitted values.
+func (client *ThreatMatrixClient) guardPII(ctx context.Context, basicAnalysisParams *BasicAnalysisParams, values []string) error {
+	guard := client.options.PIIGuard
+	if guard == nil {
+		return nil
+	}
+	findings := guard.Scan(values...)
+	if len(findings) == 0 {
+		return nil
+	}
+	if guard.OnDetect != nil {
+		guard.OnDetect(findings)
+	}
+	switch guard.Action {
+	case PII_FORCE_RED:
+		basicAnalysisParams.Tlp = RED
+	case PII_LOCAL_ONLY:
+		basicAnalysisParams.Tlp = RED
+		return client.restrictToLocalAnalyzers(ctx, basicAnalysisParams)
+	default:
+		return &PIIError{Findings: findings}
+	}
+	return nil
+}
+
+// restrictToLocalAnalyzers removes the analyzers calling an external service from the analysis.
+// When no analyzer is requested, every enabled local analyzer is requested explicitly instead.
+func (client *ThreatMatrixClient) restrictToLocalAnalyzers(ctx context.Context, basicAnalysisParams *BasicAnalysisParams) error {
+	analyzerConfigs, err := client.AnalyzerService.GetConfigs(ctx)
+	if err != nil {
+		return err
+	}
+	localAnalyzers := map[string]bool{}
+	for _, analyzerConfig := range *analyzerConfigs {
+		if !analyzerConfig.Disabled && !analyzerConfig.ExternalService {
+			localAnalyzers[analyzerConfig.Name] = true
+		}
+	}
+	requested := basicAnalysisParams.AnalyzersRequested
+	if len(requested) == 0 {
+		for _, analyzerConfig := range *analyzerConfigs {
+			requested = append(requested, analyzerConfig.Name)
+		}
+	}
+	kept := []string{}
+	for _, analyzerName := range requested {
+		if localAnalyzers[analyzerName] {
+			kept = append(kept, analyzerName)
+		}
+	}
+	if len(kept) == 0 {
+		return ErrNoLocalAnalyzer
+	}
+	basicAnalysisParams.AnalyzersRequested = kept
+	return nil
+}
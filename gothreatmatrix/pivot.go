@@ -0,0 +1,154 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PivotOptions represents the fields used to configure PivotHunt.
+type PivotOptions struct {
+	// InvestigationName is the name of the investigation gathering the jobs, "Pivot hunt: <seed>" when empty.
+	InvestigationName string
+	// MaxDepth is how many times new indicators are pivoted on, 1 when 0.
+	MaxDepth int
+	// MaxWidth is the maximum number of new indicators analyzed at each depth, 10 when 0.
+	MaxWidth int
+	// Classifications, when not empty, are the only artifact classifications pivoted on.
+	Classifications []string
+	// Filter, when set, selects which artifacts are worth analyzing.
+	Filter func(artifact Artifact) bool
+	// AnalysisParams are used for every analysis of the hunt.
+	AnalysisParams BasicAnalysisParams
+	// WaitOptions configure how each job is waited for before extracting its artifacts.
+	WaitOptions *WaitOptions
+}
+
+// PivotJob represents an indicator analyzed during a pivot hunt.
+type PivotJob struct {
+	ObservableName           string
+	ObservableClassification string
+	Depth                    int
+	JobID                    uint64
+	// ParentJobID is the job whose reports the indicator was found in, 0 for the seed.
+	ParentJobID uint64
+	Artifacts   []Artifact
+	// Err is set when the analysis of the indicator failed, the hunt goes on without it.
+	Err error
+}
+
+// PivotResult represents the outcome of a pivot hunt.
+type PivotResult struct {
+	Investigation *Investigation
+	Jobs          []PivotJob
+}
+
+// PivotHunt analyzes the seed indicator, extracts the artifacts found in its reports,
+// and analyzes the new indicators in turn up to the MaxDepth/MaxWidth limits.
+// Every job is gathered into one new investigation.
+//
+// Only failures to create the investigation or to analyze the seed stop the hunt,
+// the others are recorded in the PivotJob.
+func (client *ThreatMatrixClient) PivotHunt(ctx context.Context, observableName string, observableClassification string, options *PivotOptions) (*PivotResult, error) {
+	if options == nil {
+		options = &PivotOptions{}
+	}
+	maxDepth := options.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = 1
+	}
+	maxWidth := options.MaxWidth
+	if maxWidth <= 0 {
+		maxWidth = 10
+	}
+	investigationName := options.InvestigationName
+	if investigationName == "" {
+		investigationName = "Pivot hunt: " + observableName
+	}
+	investigation, err := client.InvestigationService.Create(ctx, &InvestigationParams{
+		Name:        investigationName,
+		Description: fmt.Sprintf("Automated pivot hunt from %s (%s), depth %d, width %d", observableName, observableClassification, maxDepth, maxWidth),
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &PivotResult{
+		Investigation: investigation,
+	}
+	seen := map[string]bool{strings.ToLower(observableName): true}
+	level := []PivotJob{{ObservableName: observableName, ObservableClassification: observableClassification}}
+	for depth := 0; depth <= maxDepth && len(level) > 0; depth++ {
+		for index := range level {
+			level[index].Depth = depth
+			client.pivotAnalyze(ctx, investigation.ID, &level[index], options)
+			if depth == 0 && level[index].Err != nil {
+				return result, level[index].Err
+			}
+		}
+		result.Jobs = append(result.Jobs, level...)
+		if depth == maxDepth {
+			break
+		}
+		next := []PivotJob{}
+		for _, pivotJob := range level {
+			for _, artifact := range pivotJob.Artifacts {
+				if len(next) >= maxWidth {
+					break
+				}
+				key := strings.ToLower(artifact.Value)
+				if seen[key] || !options.pivotsOn(artifact) {
+					continue
+				}
+				seen[key] = true
+				next = append(next, PivotJob{
+					ObservableName:           artifact.Value,
+					ObservableClassification: artifact.Classification,
+					ParentJobID:              pivotJob.JobID,
+				})
+			}
+		}
+		level = next
+	}
+	return result, nil
+}
+
+// pivotsOn reports whether the artifact is worth analyzing.
+func (options *PivotOptions) pivotsOn(artifact Artifact) bool {
+	if len(options.Classifications) > 0 {
+		allowed := false
+		for _, classification := range options.Classifications {
+			if classification == artifact.Classification {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return options.Filter == nil || options.Filter(artifact)
+}
+
+// pivotAnalyze analyzes the indicator, adds its job to the investigation and extracts its artifacts.
+func (client *ThreatMatrixClient) pivotAnalyze(ctx context.Context, investigationId uint64, pivotJob *PivotJob, options *PivotOptions) {
+	analysisResponse, err := client.CreateObservableAnalysis(ctx, &ObservableAnalysisParams{
+		BasicAnalysisParams:      options.AnalysisParams,
+		ObservableName:           pivotJob.ObservableName,
+		ObservableClassification: pivotJob.ObservableClassification,
+	})
+	if err != nil {
+		pivotJob.Err = err
+		return
+	}
+	pivotJob.JobID = uint64(analysisResponse.JobID)
+	if _, err := client.InvestigationService.AddJob(ctx, investigationId, pivotJob.JobID); err != nil {
+		pivotJob.Err = err
+		return
+	}
+	waitResult, err := client.JobService.WaitForCompletion(ctx, pivotJob.JobID, options.WaitOptions)
+	if err != nil {
+		pivotJob.Err = err
+		return
+	}
+	pivotJob.Artifacts = ExtractArtifacts(waitResult.Job)
+}
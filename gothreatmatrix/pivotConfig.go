@@ -0,0 +1,49 @@
+package gothreatmatrix
+
+import (
+	"context"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// PivotConfig represents how a pivot is configured in ThreatMatrix.
+//
+// ThreatMatrix docs: https://threatmatrix.readthedocs.io/en/latest/Usage.html#pivots
+type PivotConfig struct {
+	BaseConfigurationType
+	// RelatedAnalyzerConfigs and RelatedConnectorConfigs are the plugins whose reports the pivot starts from.
+	RelatedAnalyzerConfigs  []string `json:"related_analyzer_configs"`
+	RelatedConnectorConfigs []string `json:"related_connector_configs"`
+	// PlaybookToExecute is the playbook the pivoted jobs are run with.
+	PlaybookToExecute string `json:"playbook_to_execute"`
+}
+
+// PivotService handles communication with pivot related methods of the ThreatMatrix API.
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/pivot
+type PivotService struct {
+	client *ThreatMatrixClient
+}
+
+// GetConfigs lists down every pivot configuration in your ThreatMatrix instance.
+//
+//	Endpoint: GET /api/get_pivot_configs
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/get_pivot_configs
+func (pivotService *PivotService) GetConfigs(ctx context.Context) (*[]PivotConfig, error) {
+	pivotConfigurationResponse, err := fetchPluginConfigs[PivotConfig](ctx, pivotService.client, constants.PIVOT_CONFIG_URL)
+	if err != nil {
+		return nil, pivotService.client.featureError(FEATURE_PIVOTS, err)
+	}
+	return sortPluginConfigs(pivotConfigurationResponse), nil
+}
+
+// HealthCheck checks if the specified pivot is up and running
+//
+//	Endpoint: GET /api/pivot/{NameOfPivot}/healthcheck
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/pivot/operation/pivot_healthcheck_retrieve
+func (pivotService *PivotService) HealthCheck(ctx context.Context, pivotName string) (bool, error) {
+	status, err := pluginHealthCheck(ctx, pivotService.client, constants.PIVOT_HEALTHCHECK_URL, pivotName)
+	return status, pivotService.client.featureError(FEATURE_PIVOTS, err)
+}
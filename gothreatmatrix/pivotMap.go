@@ -0,0 +1,115 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// PivotMap represents a job spawned by a pivot: the pivot ran on the reports of StartingJob and created EndingJob.
+type PivotMap struct {
+	ID          uint64 `json:"id"`
+	StartingJob uint64 `json:"starting_job"`
+	// PivotConfig is the name of the pivot that created the ending job.
+	PivotConfig string `json:"pivot_config"`
+	EndingJob   uint64 `json:"ending_job"`
+}
+
+// PivotChain represents the jobs chained by the pivots from a job, see Descendants.
+type PivotChain struct {
+	JobID uint64
+	// Maps are the pivot maps from JobID and from the jobs they spawned in turn, breadth first.
+	Maps []PivotMap
+}
+
+// JobIDs returns the IDs of the jobs of the chain, the starting job first.
+func (chain *PivotChain) JobIDs() []uint64 {
+	jobIds := []uint64{chain.JobID}
+	for _, pivotMap := range chain.Maps {
+		jobIds = append(jobIds, pivotMap.EndingJob)
+	}
+	return jobIds
+}
+
+// listPivotMaps fetches every pivot map whose field is the job.
+func (pivotService *PivotService) listPivotMaps(ctx context.Context, field string, jobId uint64) ([]PivotMap, error) {
+	query := url.Values{}
+	query.Set(field, strconv.FormatUint(jobId, 10))
+	paginator := newPaginator[PivotMap](pivotService.client, constants.PIVOT_MAP_URL, query, 0)
+	paginator.feature = FEATURE_PIVOTS
+	return paginator.All(ctx)
+}
+
+// Children lists the pivot maps of the jobs the pivots spawned from the reports of the job.
+//
+//	Endpoint: GET /api/pivot_map?starting_job={id}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/pivot_map/operation/pivot_map_list
+func (pivotService *PivotService) Children(ctx context.Context, jobId uint64) ([]PivotMap, error) {
+	return pivotService.listPivotMaps(ctx, "starting_job", jobId)
+}
+
+// Parents lists the pivot maps of the jobs whose reports a pivot spawned the job from, none for a job
+// submitted by a user.
+//
+//	Endpoint: GET /api/pivot_map?ending_job={id}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/pivot_map/operation/pivot_map_list
+func (pivotService *PivotService) Parents(ctx context.Context, jobId uint64) ([]PivotMap, error) {
+	return pivotService.listPivotMaps(ctx, "ending_job", jobId)
+}
+
+// Descendants follows the pivots from the job down to maxDepth jobs away, every level when maxDepth is 0.
+// A job reached twice is followed once, so chains looping back are walked safely. On error, it returns the chain
+// walked so far with it.
+//
+//	Endpoint: GET /api/pivot_map?starting_job={id}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/pivot_map/operation/pivot_map_list
+func (pivotService *PivotService) Descendants(ctx context.Context, jobId uint64, maxDepth int) (*PivotChain, error) {
+	chain := &PivotChain{JobID: jobId, Maps: []PivotMap{}}
+	visited := map[uint64]bool{jobId: true}
+	level := []uint64{jobId}
+	for depth := 0; len(level) > 0 && (maxDepth <= 0 || depth < maxDepth); depth++ {
+		nextLevel := []uint64{}
+		for _, levelJobId := range level {
+			children, err := pivotService.Children(ctx, levelJobId)
+			if err != nil {
+				return chain, err
+			}
+			for _, child := range children {
+				if visited[child.EndingJob] {
+					continue
+				}
+				visited[child.EndingJob] = true
+				chain.Maps = append(chain.Maps, child)
+				nextLevel = append(nextLevel, child.EndingJob)
+			}
+		}
+		level = nextLevel
+	}
+	return chain, nil
+}
+
+// Root follows the pivots up from the job to the job submitted by a user that started the chain, the job itself
+// when no pivot spawned it. A job spawned by several pivots is followed through its first parent.
+//
+//	Endpoint: GET /api/pivot_map?ending_job={id}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/pivot_map/operation/pivot_map_list
+func (pivotService *PivotService) Root(ctx context.Context, jobId uint64) (uint64, error) {
+	visited := map[uint64]bool{jobId: true}
+	for {
+		parents, err := pivotService.Parents(ctx, jobId)
+		if err != nil {
+			return 0, err
+		}
+		if len(parents) == 0 || visited[parents[0].StartingJob] {
+			return jobId, nil
+		}
+		jobId = parents[0].StartingJob
+		visited[jobId] = true
+	}
+}
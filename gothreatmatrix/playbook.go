@@ -0,0 +1,297 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// PlaybookConfig represents how a playbook is configured in ThreatMatrix.
+//
+// ThreatMatrix docs: https://threatmatrix.readthedocs.io/en/latest/Usage.html#playbooks
+type PlaybookConfig struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Disabled    bool                   `json:"disabled"`
+	Deprecated  bool                   `json:"deprecated,omitempty"`
+	Analyzers   map[string]interface{} `json:"analyzers"`
+	Connectors  map[string]interface{} `json:"connectors"`
+	Supports    []string               `json:"supports"`
+	// ScanMode is SCAN_MODE_FORCE_NEW or SCAN_MODE_CHECK_PREVIOUS.
+	ScanMode int `json:"scan_mode"`
+	// ScanCheckTime is how far back previous analyses are reused, e.g. "1 00:00:00" for one day, see ScanCheckWindow.
+	ScanCheckTime string `json:"scan_check_time"`
+}
+
+// ConfigName implements BaseConfig.
+func (playbookConfig PlaybookConfig) ConfigName() string {
+	return playbookConfig.Name
+}
+
+// Values of the PlaybookConfig.ScanMode field.
+const (
+	SCAN_MODE_FORCE_NEW      = 1
+	SCAN_MODE_CHECK_PREVIOUS = 2
+)
+
+// ScanCheckWindow returns how far back previous analyses are reused by the playbook,
+// 0 when the playbook always forces new analyses.
+func (playbookConfig *PlaybookConfig) ScanCheckWindow() (time.Duration, error) {
+	if playbookConfig.ScanMode == SCAN_MODE_FORCE_NEW || playbookConfig.ScanCheckTime == "" {
+		return 0, nil
+	}
+	return ParseScanCheckTime(playbookConfig.ScanCheckTime)
+}
+
+// ParseScanCheckTime parses the durations used by the scan_check_time field,
+// formatted as "[DD ]HH:MM:SS[.ffffff]" or "DD:HH:MM:SS".
+func ParseScanCheckTime(scanCheckTime string) (time.Duration, error) {
+	value := strings.TrimSpace(scanCheckTime)
+	days := 0
+	if index := strings.Index(value, " "); index >= 0 {
+		parsedDays, err := strconv.Atoi(value[:index])
+		if err != nil {
+			return 0, fmt.Errorf("invalid scan_check_time %q", scanCheckTime)
+		}
+		days = parsedDays
+		value = strings.TrimSpace(value[index+1:])
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) == 4 && days == 0 {
+		parsedDays, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return 0, fmt.Errorf("invalid scan_check_time %q", scanCheckTime)
+		}
+		days = parsedDays
+		parts = parts[1:]
+	}
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid scan_check_time %q", scanCheckTime)
+	}
+	hours, hoursErr := strconv.Atoi(parts[0])
+	minutes, minutesErr := strconv.Atoi(parts[1])
+	seconds, secondsErr := strconv.ParseFloat(parts[2], 64)
+	if hoursErr != nil || minutesErr != nil || secondsErr != nil || days < 0 || hours < 0 || minutes < 0 || seconds < 0 {
+		return 0, fmt.Errorf("invalid scan_check_time %q", scanCheckTime)
+	}
+	duration := time.Duration(days)*24*time.Hour + time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	return duration + time.Duration(seconds*float64(time.Second)), nil
+}
+
+// FormatScanCheckTime formats the duration for the scan_check_time field, as "DD HH:MM:SS".
+func FormatScanCheckTime(duration time.Duration) string {
+	if duration < 0 {
+		duration = 0
+	}
+	seconds := int64(duration / time.Second)
+	return fmt.Sprintf("%d %02d:%02d:%02d", seconds/86400, seconds%86400/3600, seconds%3600/60, seconds%60)
+}
+
+// ErrPlaybookNotRunnable is returned when running a playbook that does not exist, is disabled,
+// or does not support the observable classification or file.
+var ErrPlaybookNotRunnable = errors.New("gothreatmatrix: the playbook cannot run this analysis")
+
+// PlaybookParams represents the fields needed for creating a playbook.
+type PlaybookParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Analyzers and Connectors map the name of each plugin of the playbook to its runtime configuration.
+	Analyzers  map[string]interface{} `json:"analyzers"`
+	Connectors map[string]interface{} `json:"connectors"`
+	// Supports are the observable classifications (ip, url, domain, hash, generic) and "file" the playbook runs on.
+	Supports []string `json:"supports"`
+}
+
+// PlaybookService handles communication with playbook related methods of the ThreatMatrix API.
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/playbook
+type PlaybookService struct {
+	client *ThreatMatrixClient
+}
+
+// GetConfigs lists down every playbook configuration in your ThreatMatrix instance.
+//
+//	Endpoint: GET /api/get_playbook_configs
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/get_playbook_configs
+func (playbookService *PlaybookService) GetConfigs(ctx context.Context) (*[]PlaybookConfig, error) {
+	playbookConfigurationResponse, err := playbookService.client.playbookConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sortPluginConfigs(playbookConfigurationResponse), nil
+}
+
+// CreatePlaybook lets you create a new playbook.
+//
+//	Endpoint: POST /api/playbook
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/playbook/operation/playbook_create
+func (playbookService *PlaybookService) CreatePlaybook(ctx context.Context, playbookParams *PlaybookParams) (*PlaybookConfig, error) {
+	requestUrl := playbookService.client.options.Url + constants.BASE_PLAYBOOK_URL
+	playbookJson, err := json.Marshal(&playbookParams)
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/json"
+	method := "POST"
+	body := bytes.NewBuffer(playbookJson)
+	request, err := playbookService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	successResp, err := playbookService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	playbookConfig := PlaybookConfig{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &playbookConfig); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &playbookConfig, nil
+}
+
+// UpdatePlaybook lets you edit a playbook through its name.
+//
+//	Endpoint: PATCH /api/playbook/{name}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/playbook/operation/playbook_partial_update
+func (playbookService *PlaybookService) UpdatePlaybook(ctx context.Context, name string, playbookParams *PlaybookParams) (*PlaybookConfig, error) {
+	route := playbookService.client.options.Url + constants.SPECIFIC_PLAYBOOK_URL
+	requestUrl := fmt.Sprintf(route, url.PathEscape(name))
+	playbookJson, err := json.Marshal(&playbookParams)
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/json"
+	method := "PATCH"
+	body := bytes.NewBuffer(playbookJson)
+	request, err := playbookService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	successResp, err := playbookService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	playbookConfig := PlaybookConfig{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &playbookConfig); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &playbookConfig, nil
+}
+
+// Enable enables the playbook back for the organization of the user, after Disable.
+//
+//	Endpoint: DELETE /api/playbook/{NameOfPlaybook}/organization
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/playbook/operation/playbook_organization_destroy
+func (playbookService *PlaybookService) Enable(ctx context.Context, playbookName string) error {
+	return setPluginOrganizationState(ctx, playbookService.client, constants.PLAYBOOK_ORGANIZATION_URL, playbookName, true)
+}
+
+// Disable disables the playbook for the organization of the user: its members cannot run it anymore.
+// It needs the user to be an admin of the organization.
+//
+//	Endpoint: POST /api/playbook/{NameOfPlaybook}/organization
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/playbook/operation/playbook_organization_create
+func (playbookService *PlaybookService) Disable(ctx context.Context, playbookName string) error {
+	return setPluginOrganizationState(ctx, playbookService.client, constants.PLAYBOOK_ORGANIZATION_URL, playbookName, false)
+}
+
+// RunPlaybookOnObservable analyzes the observable with the playbook, the way the web UI does:
+// the analysis requests the playbook instead of analyzers and connectors.
+// It returns ErrPlaybookNotRunnable when the playbook is unknown, disabled, or does not support the observable classification.
+//
+//	Endpoint: POST /api/analyze_observable
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/analyze_observable
+func (playbookService *PlaybookService) RunPlaybookOnObservable(ctx context.Context, playbook string, params *ObservableAnalysisParams) (*AnalysisResponse, error) {
+	classification := params.ObservableClassification
+	if classification == "" {
+		classification = playbookService.client.ClassifyObservable(params.ObservableName)
+	}
+	if customClassification := playbookService.client.customClassification(classification); customClassification != nil {
+		classification = GENERIC_CLASSIFICATION
+	}
+	if err := playbookService.checkRunnable(ctx, playbook, classification); err != nil {
+		return nil, err
+	}
+	usePlaybook(&params.BasicAnalysisParams, playbook)
+	return playbookService.client.CreateObservableAnalysis(ctx, params)
+}
+
+// RunPlaybookOnFile analyzes the file with the playbook, the way the web UI does:
+// the analysis requests the playbook instead of analyzers and connectors.
+// It returns ErrPlaybookNotRunnable when the playbook is unknown, disabled, or does not support files.
+//
+//	Endpoint: POST /api/analyze_file
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/analyze_file
+func (playbookService *PlaybookService) RunPlaybookOnFile(ctx context.Context, playbook string, params *FileAnalysisParams) (*AnalysisResponse, error) {
+	if err := playbookService.checkRunnable(ctx, playbook, "file"); err != nil {
+		return nil, err
+	}
+	usePlaybook(&params.BasicAnalysisParams, playbook)
+	return playbookService.client.CreateFileAnalysis(ctx, params)
+}
+
+// checkRunnable checks that the playbook exists, is enabled and supports the kind of analysis.
+// A playbook supporting nothing is assumed to support everything.
+func (playbookService *PlaybookService) checkRunnable(ctx context.Context, playbook string, supported string) error {
+	playbookConfigs, err := playbookService.client.playbookConfigs(ctx)
+	if err != nil {
+		return err
+	}
+	playbookConfig, ok := playbookConfigs[playbook]
+	switch {
+	case !ok:
+		return fmt.Errorf("%w: %s does not exist", ErrPlaybookNotRunnable, playbook)
+	case playbookConfig.Disabled:
+		return fmt.Errorf("%w: %s is disabled", ErrPlaybookNotRunnable, playbook)
+	case supported != "" && len(playbookConfig.Supports) > 0 && !containsValue(playbookConfig.Supports, supported):
+		return fmt.Errorf("%w: %s does not support %s", ErrPlaybookNotRunnable, playbook, supported)
+	}
+	return nil
+}
+
+// usePlaybook makes the analysis request the playbook, which picks the analyzers and connectors.
+func usePlaybook(basicAnalysisParams *BasicAnalysisParams, playbook string) {
+	basicAnalysisParams.PlaybookRequested = playbook
+	basicAnalysisParams.AnalyzersRequested = nil
+	basicAnalysisParams.ConnectorsRequested = nil
+}
+
+// playbookConfigs returns the playbook configurations, from the catalog snapshot when one is configured.
+func (client *ThreatMatrixClient) playbookConfigs(ctx context.Context) (map[string]PlaybookConfig, error) {
+	snapshot, err := client.catalogSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	var playbookConfigs map[string]PlaybookConfig
+	if snapshot != nil {
+		playbookConfigs = snapshot.Playbooks
+	} else if playbookConfigs, err = client.PlaybookService.fetchConfigs(ctx); err != nil {
+		return nil, err
+	}
+	observeCatalog(client, PLAYBOOK_PLUGIN, playbookConfigs, func(config PlaybookConfig) bool {
+		return config.Deprecated
+	})
+	return playbookConfigs, nil
+}
+
+// fetchConfigs gets the playbook configurations from the ThreatMatrix instance, keyed by playbook name.
+func (playbookService *PlaybookService) fetchConfigs(ctx context.Context) (map[string]PlaybookConfig, error) {
+	return fetchPluginConfigs[PlaybookConfig](ctx, playbookService.client, constants.PLAYBOOK_CONFIG_URL)
+}
@@ -0,0 +1,133 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// BaseConfig is the constraint of the plugin configurations FetchPluginConfigs decodes: every type embedding
+// BaseConfigurationType satisfies it, as does PlaybookConfig.
+type BaseConfig interface {
+	// ConfigName is the name of the plugin, its key in the configurations keyed by name.
+	ConfigName() string
+}
+
+// FetchPluginConfigs gets the configurations of a plugin type the SDK does not wrap yet from the ThreatMatrix
+// instance, through the configuration cache of the client, and lists them sorted alphabetically by name. The route
+// is the configuration endpoint of the type, relative to the ThreatMatrix url, e.g. "/api/get_visualizer_configs".
+//
+// Example:
+//
+//	type AnalyzerPluginConfig struct {
+//		gothreatmatrix.BaseConfigurationType
+//		Queue string `json:"queue"`
+//	}
+//	configs, err := gothreatmatrix.FetchPluginConfigs[AnalyzerPluginConfig](ctx, client, "/api/analyzer")
+func FetchPluginConfigs[T BaseConfig](ctx context.Context, client *ThreatMatrixClient, route string) ([]T, error) {
+	configurationResponse, err := fetchPluginConfigs[T](ctx, client, route)
+	if err != nil {
+		return nil, err
+	}
+	return *sortPluginConfigs(configurationResponse), nil
+}
+
+// fetchPluginConfigs gets the configurations of a plugin type from the ThreatMatrix instance, keyed by plugin name,
+// through the configuration cache of the client.
+func fetchPluginConfigs[T BaseConfig](ctx context.Context, client *ThreatMatrixClient, route string) (map[string]T, error) {
+	requestUrl := client.options.Url + route
+	data, err := client.cachedGet(ctx, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	return decodePluginConfigs[T](data)
+}
+
+// decodePluginConfigs decodes the configurations of a plugin type, keyed by plugin name, whatever the shape the
+// API version answers with: the map keyed by name of the get_*_configs endpoints, a list, or a page of a list.
+func decodePluginConfigs[T BaseConfig](data []byte) (map[string]T, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		page := struct {
+			Results *[]T `json:"results"`
+		}{}
+		if err := json.Unmarshal(trimmed, &page); err == nil && page.Results != nil {
+			return keyPluginConfigs(*page.Results), nil
+		}
+		configurationResponse := map[string]T{}
+		if unmarshalError := json.Unmarshal(trimmed, &configurationResponse); unmarshalError != nil {
+			return nil, unmarshalError
+		}
+		return configurationResponse, nil
+	}
+	configurationList := []T{}
+	if unmarshalError := json.Unmarshal(trimmed, &configurationList); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return keyPluginConfigs(configurationList), nil
+}
+
+// keyPluginConfigs keys the plugin configurations by their name.
+func keyPluginConfigs[T BaseConfig](configurationList []T) map[string]T {
+	configurationResponse := make(map[string]T, len(configurationList))
+	for _, configuration := range configurationList {
+		configurationResponse[configuration.ConfigName()] = configuration
+	}
+	return configurationResponse
+}
+
+// sortPluginConfigs lists the plugin configurations sorted alphabetically by plugin name.
+func sortPluginConfigs[T any](configurationResponse map[string]T) *[]T {
+	names := make([]string, 0, len(configurationResponse))
+	for name := range configurationResponse {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	configurationList := []T{}
+	for _, name := range names {
+		configurationList = append(configurationList, configurationResponse[name])
+	}
+	return &configurationList
+}
+
+// pluginHealthCheck runs the health check of the plugin, route being the health check endpoint of its type.
+func pluginHealthCheck(ctx context.Context, client *ThreatMatrixClient, route string, name string) (bool, error) {
+	requestUrl := fmt.Sprintf(client.options.Url+route, name)
+	contentType := "application/json"
+	method := "GET"
+	request, err := client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return false, err
+	}
+	status := StatusResponse{}
+	successResp, err := client.newRequest(ctx, request)
+	if err != nil {
+		return false, err
+	}
+	if unmarshalError := json.Unmarshal(successResp.Data, &status); unmarshalError != nil {
+		return false, unmarshalError
+	}
+	return status.Status, nil
+}
+
+// setPluginOrganizationState enables or disables the plugin for the organization of the user, route being the
+// organization endpoint of its type: ThreatMatrix disables it on POST and enables it back on DELETE.
+func setPluginOrganizationState(ctx context.Context, client *ThreatMatrixClient, route string, name string, enabled bool) error {
+	requestUrl := fmt.Sprintf(client.options.Url+route, name)
+	contentType := "application/json"
+	method := "POST"
+	if enabled {
+		method = "DELETE"
+	}
+	request, err := client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return err
+	}
+	_, err = client.newRequest(ctx, request)
+	if err == nil && client.configCache != nil {
+		client.configCache.clear()
+	}
+	return err
+}
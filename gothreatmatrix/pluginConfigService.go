@@ -0,0 +1,259 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// SECRET_MASK replaces the value of the secrets in the representations of a PluginConfigEntry meant for humans.
+const SECRET_MASK = "********"
+
+// PluginConfigEntry represents the value of a parameter or a secret of a plugin, set for the user or for
+// their whole organization, overriding the default of the plugin configuration.
+//
+// Its String and GoString mask the value of a secret, so the entry can be logged; its JSON holds the value.
+type PluginConfigEntry struct {
+	ID uint64 `json:"id,omitempty"`
+	// Type is ANALYZER_PLUGIN, CONNECTOR_PLUGIN or the type of another plugin, e.g. "visualizer".
+	Type       string `json:"type"`
+	PluginName string `json:"plugin_name"`
+	// Attribute is the name of the parameter or secret, e.g. "api_key_name".
+	Attribute string      `json:"attribute"`
+	Value     interface{} `json:"value"`
+	// IsSecret tells a secret, e.g. an API key, from a parameter.
+	IsSecret bool `json:"is_secret"`
+	// ForOrganization sets the value for every member of the organization of the user, an admin of it.
+	ForOrganization bool   `json:"for_organization"`
+	Owner           string `json:"owner,omitempty"`
+}
+
+// Masked returns a copy of the entry whose value is SECRET_MASK when it is a secret.
+func (entry PluginConfigEntry) Masked() PluginConfigEntry {
+	if entry.IsSecret && entry.Value != nil {
+		entry.Value = SECRET_MASK
+	}
+	return entry
+}
+
+// String implements fmt.Stringer, masking the value of a secret.
+func (entry PluginConfigEntry) String() string {
+	masked := entry.Masked()
+	scope := "user"
+	if masked.ForOrganization {
+		scope = "organization"
+	}
+	return fmt.Sprintf("%s %s %s=%v (%s)", masked.Type, masked.PluginName, masked.Attribute, masked.Value, scope)
+}
+
+// GoString implements fmt.GoStringer, masking the value of a secret in %#v too.
+func (entry PluginConfigEntry) GoString() string {
+	type plain PluginConfigEntry
+	return fmt.Sprintf("gothreatmatrix.PluginConfigEntry%+v", plain(entry.Masked()))
+}
+
+// sameSetting reports whether the entries set the same attribute of the same plugin for the same scope.
+func (entry *PluginConfigEntry) sameSetting(other *PluginConfigEntry) bool {
+	return entry.Type == other.Type && entry.PluginName == other.PluginName &&
+		entry.Attribute == other.Attribute && entry.ForOrganization == other.ForOrganization
+}
+
+// PluginConfigService handles communication with the plugin config endpoints of the ThreatMatrix API,
+// where the parameters and secrets of the plugins are set per user and per organization.
+//
+// Writing to it clears the configuration cache of the client, the plugin configurations telling
+// whether the plugins are configured.
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/plugin_config
+type PluginConfigService struct {
+	client *ThreatMatrixClient
+}
+
+// checkPluginConfigID is used to check if a plugin config ID is valid (id should be greater than zero).
+func checkPluginConfigID(id uint64) error {
+	if id > 0 {
+		return nil
+	}
+	return errors.New("Plugin config ID cannot be 0")
+}
+
+// List fetches the plugin config entries visible to the user: their own and the ones of their organization.
+// The instance may mask the values of the secrets it answers.
+//
+//	Endpoint: GET /api/plugin_config
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/plugin_config/operation/plugin_config_list
+func (pluginConfigService *PluginConfigService) List(ctx context.Context) ([]PluginConfigEntry, error) {
+	requestUrl := pluginConfigService.client.options.Url + constants.PLUGIN_CONFIG_URL
+	contentType := "application/json"
+	method := "GET"
+	request, err := pluginConfigService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := pluginConfigService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	entries := []PluginConfigEntry{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &entries); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return entries, nil
+}
+
+// ListForPlugin fetches the plugin config entries of the plugin of that type, e.g. ANALYZER_PLUGIN.
+//
+//	Endpoint: GET /api/plugin_config
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/plugin_config/operation/plugin_config_list
+func (pluginConfigService *PluginConfigService) ListForPlugin(ctx context.Context, pluginType string, pluginName string) ([]PluginConfigEntry, error) {
+	entries, err := pluginConfigService.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pluginEntries := []PluginConfigEntry{}
+	for _, entry := range entries {
+		if entry.Type == pluginType && entry.PluginName == pluginName {
+			pluginEntries = append(pluginEntries, entry)
+		}
+	}
+	return pluginEntries, nil
+}
+
+// Create adds the plugin config entries, their ID being ignored, and returns them as created.
+//
+//	Endpoint: POST /api/plugin_config
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/plugin_config/operation/plugin_config_create
+func (pluginConfigService *PluginConfigService) Create(ctx context.Context, entries []PluginConfigEntry) ([]PluginConfigEntry, error) {
+	requestUrl := pluginConfigService.client.options.Url + constants.PLUGIN_CONFIG_URL
+	creations := make([]PluginConfigEntry, len(entries))
+	for index, entry := range entries {
+		entry.ID = 0
+		creations[index] = entry
+	}
+	entriesJson, err := json.Marshal(creations)
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/json"
+	method := "POST"
+	body := bytes.NewBuffer(entriesJson)
+	request, err := pluginConfigService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := pluginConfigService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	pluginConfigService.client.configCache.clear()
+	createdEntries := []PluginConfigEntry{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &createdEntries); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return createdEntries, nil
+}
+
+// Update sets the value of the plugin config entry through its ID.
+//
+//	Endpoint: PATCH /api/plugin_config/{id}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/plugin_config/operation/plugin_config_partial_update
+func (pluginConfigService *PluginConfigService) Update(ctx context.Context, entryId uint64, value interface{}) (*PluginConfigEntry, error) {
+	if err := checkPluginConfigID(entryId); err != nil {
+		return nil, err
+	}
+	requestUrl := fmt.Sprintf(pluginConfigService.client.options.Url+constants.SPECIFIC_PLUGIN_CONFIG_URL, entryId)
+	valueJson, err := json.Marshal(map[string]interface{}{"value": value})
+	if err != nil {
+		return nil, err
+	}
+	contentType := "application/json"
+	method := "PATCH"
+	body := bytes.NewBuffer(valueJson)
+	request, err := pluginConfigService.client.buildRequest(ctx, method, contentType, body, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := pluginConfigService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	pluginConfigService.client.configCache.clear()
+	updatedEntry := PluginConfigEntry{}
+	if unmarshalError := json.Unmarshal(successResp.Data, &updatedEntry); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	return &updatedEntry, nil
+}
+
+// Delete removes the plugin config entry through its ID, the plugin getting back its default value.
+//
+//	Endpoint: DELETE /api/plugin_config/{id}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/plugin_config/operation/plugin_config_destroy
+func (pluginConfigService *PluginConfigService) Delete(ctx context.Context, entryId uint64) (bool, error) {
+	if err := checkPluginConfigID(entryId); err != nil {
+		return false, err
+	}
+	requestUrl := fmt.Sprintf(pluginConfigService.client.options.Url+constants.SPECIFIC_PLUGIN_CONFIG_URL, entryId)
+	contentType := "application/json"
+	method := "DELETE"
+	request, err := pluginConfigService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return false, err
+	}
+	successResp, err := pluginConfigService.client.newRequest(ctx, request)
+	if err != nil {
+		return false, err
+	}
+	pluginConfigService.client.configCache.clear()
+	return successResp.StatusCode == http.StatusNoContent, nil
+}
+
+// Set creates the plugin config entry, or updates the value of the entry setting the same attribute of the same
+// plugin for the same scope, so provisioning can be run again and again.
+//
+//	Endpoint: GET /api/plugin_config
+//	Endpoint: POST /api/plugin_config
+//	Endpoint: PATCH /api/plugin_config/{id}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/plugin_config
+func (pluginConfigService *PluginConfigService) Set(ctx context.Context, entry PluginConfigEntry) (*PluginConfigEntry, error) {
+	entries, err := pluginConfigService.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range entries {
+		if existing.sameSetting(&entry) {
+			return pluginConfigService.Update(ctx, existing.ID, entry.Value)
+		}
+	}
+	createdEntries, err := pluginConfigService.Create(ctx, []PluginConfigEntry{entry})
+	if err != nil {
+		return nil, err
+	}
+	if len(createdEntries) != 1 {
+		return nil, fmt.Errorf("the instance created %d plugin config entries instead of 1", len(createdEntries))
+	}
+	return &createdEntries[0], nil
+}
+
+// SetSecret sets the secret of the plugin, e.g. the API key of an analyzer, see Set.
+func (pluginConfigService *PluginConfigService) SetSecret(ctx context.Context, pluginType string, pluginName string, attribute string, value string, forOrganization bool) (*PluginConfigEntry, error) {
+	return pluginConfigService.Set(ctx, PluginConfigEntry{
+		Type:            pluginType,
+		PluginName:      pluginName,
+		Attribute:       attribute,
+		Value:           value,
+		IsSecret:        true,
+		ForOrganization: forOrganization,
+	})
+}
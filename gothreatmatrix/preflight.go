@@ -0,0 +1,234 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// Names of the checks of a PreflightReport, in the order Preflight runs them.
+const (
+	PREFLIGHT_REACHABILITY = "reachability"
+	PREFLIGHT_TLS          = "tls"
+	PREFLIGHT_AUTH         = "auth"
+	PREFLIGHT_API_VERSION  = "api_version"
+	PREFLIGHT_READ         = "read"
+	PREFLIGHT_SUBMIT       = "submit"
+	PREFLIGHT_ADMIN        = "admin"
+)
+
+// PreflightStatus represents the outcome of a PreflightCheck.
+type PreflightStatus string
+
+// Values of the PreflightStatus enum.
+const (
+	PREFLIGHT_PASS PreflightStatus = "pass"
+	// PREFLIGHT_WARN is an outcome that does not prevent using the client, e.g. an undetermined permission.
+	PREFLIGHT_WARN PreflightStatus = "warn"
+	PREFLIGHT_FAIL PreflightStatus = "fail"
+	// PREFLIGHT_SKIP is the outcome of the checks an earlier failure made pointless.
+	PREFLIGHT_SKIP PreflightStatus = "skip"
+)
+
+// PreflightCheck represents one item of the checklist of Preflight.
+type PreflightCheck struct {
+	// Name is one of the PREFLIGHT_* check names.
+	Name   string          `json:"name"`
+	Status PreflightStatus `json:"status"`
+	// Detail explains the status, e.g. the server version or why a check failed.
+	Detail string `json:"detail,omitempty"`
+	Err    error  `json:"-"`
+}
+
+// PreflightReport represents the checklist returned by Preflight.
+type PreflightReport struct {
+	Checks []PreflightCheck `json:"checks"`
+	// ServerVersion is the version the instance reported, empty when it reported none.
+	ServerVersion string `json:"server_version,omitempty"`
+	// CompatibilityMode is the API generation the instance speaks, see NegotiateCompatibility.
+	CompatibilityMode CompatibilityMode `json:"compatibility_mode"`
+}
+
+// OK reports whether no check failed.
+func (report *PreflightReport) OK() bool {
+	for _, check := range report.Checks {
+		if check.Status == PREFLIGHT_FAIL {
+			return false
+		}
+	}
+	return true
+}
+
+// Check returns the check of that name, ok is false when the report has none.
+func (report *PreflightReport) Check(name string) (check PreflightCheck, ok bool) {
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check, true
+		}
+	}
+	return PreflightCheck{}, false
+}
+
+// add appends the check to the report.
+func (report *PreflightReport) add(name string, status PreflightStatus, detail string, err error) {
+	report.Checks = append(report.Checks, PreflightCheck{Name: name, Status: status, Detail: detail, Err: err})
+}
+
+// skip appends the checks as skipped because of the reason.
+func (report *PreflightReport) skip(reason string, names ...string) {
+	for _, name := range names {
+		report.add(name, PREFLIGHT_SKIP, reason, nil)
+	}
+}
+
+// isTLSError reports whether the error is the server's certificate or TLS handshake being rejected.
+func isTLSError(err error) bool {
+	var unknownAuthorityError x509.UnknownAuthorityError
+	var certificateInvalidError x509.CertificateInvalidError
+	var hostnameError x509.HostnameError
+	var recordHeaderError tls.RecordHeaderError
+	return errors.As(err, &unknownAuthorityError) || errors.As(err, &certificateInvalidError) ||
+		errors.As(err, &hostnameError) || errors.As(err, &recordHeaderError) ||
+		errors.Is(err, ErrCertificatePinMismatch) || strings.Contains(err.Error(), "tls: ")
+}
+
+// Preflight checks that the client can work with the instance: the base URL is reachable, its TLS certificate is
+// valid, the token is accepted, which API generation and version the instance runs, and whether the token can
+// read jobs, submit analyses and administer its organization. It is meant for setup wizards and CI smoke tests:
+// nothing is submitted nor changed, the client options included, and the failures are reported in the checklist
+// rather than returned.
+func (client *ThreatMatrixClient) Preflight(ctx context.Context) *PreflightReport {
+	report := &PreflightReport{Checks: []PreflightCheck{}}
+	user, err := client.UserService.Access(ctx)
+	var threatMatrixError *ThreatMatrixError
+	switch {
+	case err == nil || errors.As(err, &threatMatrixError):
+		report.add(PREFLIGHT_REACHABILITY, PREFLIGHT_PASS, client.options.Url, nil)
+	case isTLSError(err):
+		report.add(PREFLIGHT_REACHABILITY, PREFLIGHT_PASS, client.options.Url, nil)
+		report.add(PREFLIGHT_TLS, PREFLIGHT_FAIL, "the TLS connection was rejected", err)
+		report.skip("TLS failed", PREFLIGHT_AUTH, PREFLIGHT_API_VERSION, PREFLIGHT_READ, PREFLIGHT_SUBMIT, PREFLIGHT_ADMIN)
+		return report
+	default:
+		report.add(PREFLIGHT_REACHABILITY, PREFLIGHT_FAIL, "could not reach "+client.options.Url, err)
+		report.skip("unreachable", PREFLIGHT_TLS, PREFLIGHT_AUTH, PREFLIGHT_API_VERSION, PREFLIGHT_READ, PREFLIGHT_SUBMIT, PREFLIGHT_ADMIN)
+		return report
+	}
+
+	switch {
+	case !strings.HasPrefix(strings.ToLower(client.options.Url), "https://"):
+		report.add(PREFLIGHT_TLS, PREFLIGHT_WARN, "plain HTTP, the token is sent unencrypted", nil)
+	case client.options.InsecureSkipVerify && len(client.options.PinnedSPKIHashes) == 0:
+		report.add(PREFLIGHT_TLS, PREFLIGHT_WARN, "InsecureSkipVerify, the certificate of the server is not verified", nil)
+	default:
+		report.add(PREFLIGHT_TLS, PREFLIGHT_PASS, "certificate accepted", nil)
+	}
+
+	if err != nil {
+		detail := fmt.Sprintf("the token check failed with status %d", threatMatrixError.StatusCode)
+		if IsUnauthorized(err) || hasStatusCode(err, http.StatusForbidden) {
+			detail = "the token was rejected"
+		}
+		report.add(PREFLIGHT_AUTH, PREFLIGHT_FAIL, detail, err)
+		report.skip("authentication failed", PREFLIGHT_API_VERSION, PREFLIGHT_READ, PREFLIGHT_SUBMIT, PREFLIGHT_ADMIN)
+		return report
+	}
+	report.add(PREFLIGHT_AUTH, PREFLIGHT_PASS, "authenticated as "+user.User.Username, nil)
+
+	client.preflightApiVersion(ctx, report)
+	client.preflightRead(ctx, report)
+	client.preflightSubmit(ctx, report)
+	client.preflightAdmin(ctx, report)
+	return report
+}
+
+// preflightApiVersion adds the API generation and version of the instance to the report.
+func (client *ThreatMatrixClient) preflightApiVersion(ctx context.Context, report *PreflightReport) {
+	mode, serverVersion, err := client.probeCompatibility(ctx)
+	if err != nil {
+		report.add(PREFLIGHT_API_VERSION, PREFLIGHT_FAIL, "the API probe failed", err)
+		return
+	}
+	report.CompatibilityMode = mode
+	report.ServerVersion = serverVersion
+	if report.ServerVersion == "" {
+		report.ServerVersion = client.ServerVersion()
+	}
+	detail := mode.String() + " API"
+	if report.ServerVersion != "" {
+		detail += ", version " + report.ServerVersion
+	}
+	status := PREFLIGHT_PASS
+	if configured := client.CompatibilityMode(); mode != configured {
+		status = PREFLIGHT_WARN
+		detail += fmt.Sprintf(", but the client is configured for the %s API, see NegotiateCompatibility", configured)
+	}
+	report.add(PREFLIGHT_API_VERSION, status, detail, nil)
+}
+
+// preflightRead checks that the token can list the jobs.
+func (client *ThreatMatrixClient) preflightRead(ctx context.Context, report *PreflightReport) {
+	_, err := client.JobService.Paginate(nil, 1).Next(ctx)
+	switch {
+	case err == nil:
+		report.add(PREFLIGHT_READ, PREFLIGHT_PASS, "can list the jobs", nil)
+	case hasStatusCode(err, http.StatusForbidden):
+		report.add(PREFLIGHT_READ, PREFLIGHT_FAIL, "not allowed to list the jobs", err)
+	default:
+		report.add(PREFLIGHT_READ, PREFLIGHT_FAIL, "listing the jobs failed", err)
+	}
+}
+
+// preflightSubmit checks, without submitting anything, that the token can create analyses: the OPTIONS metadata
+// of the analyze_observable endpoint only lists the POST action for users allowed to make it.
+func (client *ThreatMatrixClient) preflightSubmit(ctx context.Context, report *PreflightReport) {
+	requestUrl := client.options.Url + constants.ANALYZE_OBSERVABLE_URL
+	request, err := client.buildRequest(ctx, "OPTIONS", "application/json", nil, requestUrl)
+	if err != nil {
+		report.add(PREFLIGHT_SUBMIT, PREFLIGHT_FAIL, "could not build the request", err)
+		return
+	}
+	successResp, err := client.newRequest(ctx, request)
+	if err != nil {
+		if hasStatusCode(err, http.StatusForbidden) {
+			report.add(PREFLIGHT_SUBMIT, PREFLIGHT_FAIL, "not allowed to submit analyses", err)
+		} else {
+			report.add(PREFLIGHT_SUBMIT, PREFLIGHT_WARN, "could not be determined", err)
+		}
+		return
+	}
+	metadata := struct {
+		Actions map[string]json.RawMessage `json:"actions"`
+	}{}
+	if json.Unmarshal(successResp.Data, &metadata) != nil || metadata.Actions == nil {
+		report.add(PREFLIGHT_SUBMIT, PREFLIGHT_WARN, "could not be determined, the instance lists no actions", nil)
+		return
+	}
+	if _, ok := metadata.Actions["POST"]; !ok {
+		report.add(PREFLIGHT_SUBMIT, PREFLIGHT_FAIL, "not allowed to submit analyses", nil)
+		return
+	}
+	report.add(PREFLIGHT_SUBMIT, PREFLIGHT_PASS, "can submit analyses", nil)
+}
+
+// preflightAdmin checks whether the token owns its organization, the users who can manage its members.
+func (client *ThreatMatrixClient) preflightAdmin(ctx context.Context, report *PreflightReport) {
+	organization, err := client.UserService.Organization(ctx)
+	switch {
+	case err == nil && organization.IsUserOwner:
+		report.add(PREFLIGHT_ADMIN, PREFLIGHT_PASS, "owner of the organization "+organization.Name, nil)
+	case err == nil:
+		report.add(PREFLIGHT_ADMIN, PREFLIGHT_WARN, "member of the organization "+organization.Name+", not its owner", nil)
+	case IsNotFound(err):
+		report.add(PREFLIGHT_ADMIN, PREFLIGHT_WARN, "not a member of any organization", nil)
+	default:
+		report.add(PREFLIGHT_ADMIN, PREFLIGHT_WARN, "could not be determined", err)
+	}
+}
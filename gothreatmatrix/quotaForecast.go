@@ -0,0 +1,186 @@
+package gothreatmatrix
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DEFAULT_QUOTA_FORECAST_WINDOW is how much history the daily rate is computed on when QuotaForecastOptions.Window is 0.
+const DEFAULT_QUOTA_FORECAST_WINDOW = 30 * 24 * time.Hour
+
+// QuotaForecastOptions represents the fields used to configure ForecastQuotas.
+type QuotaForecastOptions struct {
+	// Quotas maps the name of an external analyzer to the number of analyses its provider allows per calendar month (UTC).
+	Quotas map[string]int
+	// Playbooks, e.g. the ones of a CatalogSnapshot, resolve the analyzers of the submissions that requested a playbook.
+	// Without them those submissions are not counted.
+	Playbooks map[string]PlaybookConfig
+	// Window is the history the daily rate is computed on, DEFAULT_QUOTA_FORECAST_WINDOW when 0.
+	Window time.Duration
+	// Now is the time the forecast is made at, time.Now() when zero.
+	Now time.Time
+}
+
+// QuotaForecast represents the usage of the monthly quota of an external analyzer.
+type QuotaForecast struct {
+	Analyzer     string
+	MonthlyQuota int
+	// Used is the number of analyses submitted to the analyzer since the start of the month.
+	Used      int
+	Remaining int
+	// DailyRate is the average number of analyses submitted per day over the forecast window.
+	DailyRate float64
+	// ProjectedUsage is the number of analyses expected by ResetsAt at the current rate.
+	ProjectedUsage int
+	ResetsAt       time.Time
+	// ExhaustedAt is when the quota was, or is expected to be, used up. It is nil when the quota lasts until ResetsAt.
+	ExhaustedAt *time.Time
+}
+
+// ForecastQuotas estimates from the submission history of an audit trail (see ReadAuditFile) when the monthly quotas
+// of the external analyzers will be exhausted. Every observable or file of a submission counts as one analysis
+// for each analyzer it requested. The forecasts are sorted by analyzer name.
+func ForecastQuotas(records []AuditRecord, options *QuotaForecastOptions) []QuotaForecast {
+	now := options.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+	window := options.Window
+	if window <= 0 {
+		window = DEFAULT_QUOTA_FORECAST_WINDOW
+	}
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	resetsAt := monthStart.AddDate(0, 1, 0)
+	windowStart := now.Add(-window)
+
+	sortedRecords := make([]AuditRecord, len(records))
+	copy(sortedRecords, records)
+	sort.SliceStable(sortedRecords, func(i, j int) bool {
+		return sortedRecords[i].Time.Before(sortedRecords[j].Time)
+	})
+	forecasts := map[string]*QuotaForecast{}
+	windowCounts := map[string]int{}
+	var firstSeen time.Time
+	for analyzer, quota := range options.Quotas {
+		forecasts[analyzer] = &QuotaForecast{Analyzer: analyzer, MonthlyQuota: quota, ResetsAt: resetsAt}
+	}
+	for _, record := range sortedRecords {
+		if record.Time.After(now) {
+			break
+		}
+		count := len(record.Observables) + len(record.Files)
+		if count == 0 {
+			count = 1
+		}
+		if firstSeen.IsZero() {
+			firstSeen = record.Time
+		}
+		for _, analyzer := range recordAnalyzers(&record, options.Playbooks) {
+			forecast, ok := forecasts[analyzer]
+			if !ok {
+				continue
+			}
+			if !record.Time.Before(windowStart) {
+				windowCounts[analyzer] += count
+			}
+			if record.Time.Before(monthStart) {
+				continue
+			}
+			forecast.Used += count
+			if forecast.ExhaustedAt == nil && forecast.Used >= forecast.MonthlyQuota {
+				exhaustedAt := record.Time.UTC()
+				forecast.ExhaustedAt = &exhaustedAt
+			}
+		}
+	}
+	// * with less history than the window, the rate is computed on the history there is
+	if !firstSeen.IsZero() && firstSeen.After(windowStart) {
+		windowStart = firstSeen
+	}
+	days := now.Sub(windowStart).Hours() / 24
+
+	result := make([]QuotaForecast, 0, len(forecasts))
+	for analyzer, forecast := range forecasts {
+		if days > 0 {
+			forecast.DailyRate = float64(windowCounts[analyzer]) / days
+		}
+		forecast.Remaining = forecast.MonthlyQuota - forecast.Used
+		if forecast.Remaining < 0 {
+			forecast.Remaining = 0
+		}
+		daysUntilReset := resetsAt.Sub(now).Hours() / 24
+		forecast.ProjectedUsage = forecast.Used + int(forecast.DailyRate*daysUntilReset)
+		if forecast.ExhaustedAt == nil && forecast.DailyRate > 0 {
+			exhaustedAt := now.Add(time.Duration(float64(forecast.Remaining) / forecast.DailyRate * float64(24*time.Hour)))
+			if exhaustedAt.Before(resetsAt) {
+				forecast.ExhaustedAt = &exhaustedAt
+			}
+		}
+		result = append(result, *forecast)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Analyzer < result[j].Analyzer
+	})
+	return result
+}
+
+// recordAnalyzers returns the analyzers a submission requested, directly or through its playbook.
+func recordAnalyzers(record *AuditRecord, playbooks map[string]PlaybookConfig) []string {
+	if len(record.AnalyzersRequested) > 0 || record.PlaybookRequested == "" {
+		return record.AnalyzersRequested
+	}
+	playbookConfig, ok := playbooks[record.PlaybookRequested]
+	if !ok {
+		return nil
+	}
+	analyzers := make([]string, 0, len(playbookConfig.Analyzers))
+	for analyzer := range playbookConfig.Analyzers {
+		analyzers = append(analyzers, analyzer)
+	}
+	return analyzers
+}
+
+// WriteQuotaMetrics writes the forecasts in the Prometheus text exposition format, e.g. to be served on /metrics
+// or dropped in the textfile directory of a node exporter.
+func WriteQuotaMetrics(writer io.Writer, forecasts []QuotaForecast) error {
+	metrics := []struct {
+		name  string
+		help  string
+		value func(forecast *QuotaForecast) (float64, bool)
+	}{
+		{"threatmatrix_analyzer_quota_limit", "Monthly quota of the external analyzer.", func(forecast *QuotaForecast) (float64, bool) {
+			return float64(forecast.MonthlyQuota), true
+		}},
+		{"threatmatrix_analyzer_quota_used", "Analyses submitted to the external analyzer since the start of the month.", func(forecast *QuotaForecast) (float64, bool) {
+			return float64(forecast.Used), true
+		}},
+		{"threatmatrix_analyzer_quota_daily_rate", "Average analyses submitted to the external analyzer per day.", func(forecast *QuotaForecast) (float64, bool) {
+			return forecast.DailyRate, true
+		}},
+		{"threatmatrix_analyzer_quota_exhaustion_timestamp_seconds", "When the monthly quota of the external analyzer is expected to be used up.", func(forecast *QuotaForecast) (float64, bool) {
+			if forecast.ExhaustedAt == nil {
+				return 0, false
+			}
+			return float64(forecast.ExhaustedAt.Unix()), true
+		}},
+	}
+	for _, metric := range metrics {
+		if _, err := fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s gauge\n", metric.name, metric.help, metric.name); err != nil {
+			return err
+		}
+		for index := range forecasts {
+			value, ok := metric.value(&forecasts[index])
+			if !ok {
+				continue
+			}
+			if _, err := fmt.Fprintf(writer, "%s{analyzer=%q} %s\n", metric.name, forecasts[index].Analyzer, strconv.FormatFloat(value, 'f', -1, 64)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit represents the rate the client sends its requests at, so that bulk submissions stay below the
+// throttling of ThreatMatrix. It is a token bucket: up to Burst requests are sent at once, then RequestsPerSecond.
+type RateLimit struct {
+	// RequestsPerSecond is the sustained rate, 0 disables the limit.
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	// Burst is the number of requests that can be sent at once after a quiet period, 1 when 0.
+	Burst int `json:"burst"`
+}
+
+// rateLimiter is the token bucket of a RateLimit, shared by every service and copy of the client.
+type rateLimiter struct {
+	mutex             sync.Mutex
+	requestsPerSecond float64
+	burst             float64
+	tokens            float64
+	last              time.Time
+}
+
+// newRateLimiter returns the limiter of the rate limit, nil when there is no limit.
+func newRateLimiter(rateLimit *RateLimit) *rateLimiter {
+	if rateLimit == nil || rateLimit.RequestsPerSecond <= 0 {
+		return nil
+	}
+	burst := float64(rateLimit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		requestsPerSecond: rateLimit.RequestsPerSecond,
+		burst:             burst,
+		tokens:            burst,
+	}
+}
+
+// wait blocks until a request may be sent, or the context is done. The requests get their turn in the order
+// they called wait: each one takes a token, possibly ahead of it being refilled, and waits for it.
+func (limiter *rateLimiter) wait(ctx context.Context) error {
+	limiter.mutex.Lock()
+	now := time.Now()
+	if !limiter.last.IsZero() {
+		limiter.tokens += now.Sub(limiter.last).Seconds() * limiter.requestsPerSecond
+		if limiter.tokens > limiter.burst {
+			limiter.tokens = limiter.burst
+		}
+	}
+	limiter.last = now
+	limiter.tokens--
+	delay := time.Duration(-limiter.tokens / limiter.requestsPerSecond * float64(time.Second))
+	limiter.mutex.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// * giving the token back to the requests waiting behind this one
+		limiter.mutex.Lock()
+		limiter.tokens++
+		limiter.mutex.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,82 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Response represents the response of an endpoint called through Do.
+type Response struct {
+	StatusCode int
+	// Header is the header of the response, e.g. to read its pagination links or rate limit headers.
+	Header http.Header
+	// Data is the raw body of the response.
+	Data []byte
+	// RequestID identifies the request in the logs of the instance, see ThreatMatrixError.RequestID.
+	RequestID string
+}
+
+// Decode unmarshals the JSON body of the response into out, leaving it untouched when the body is empty.
+func (response *Response) Decode(out interface{}) error {
+	if out == nil || len(bytes.TrimSpace(response.Data)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(response.Data, out)
+}
+
+// Do calls an endpoint the SDK does not wrap yet, with the authentication, RetryOptions and error handling of the client:
+// a non-2xx response is returned as a *ThreatMatrixError.
+// The path is relative to the ThreatMatrix url and may carry a query, e.g. "/api/plugin-disabler?type=analyzer".
+// The body is sent as is when it is an io.Reader or a []byte, encoded as JSON otherwise, nil sending no body.
+// When out is not nil the JSON response is decoded into it, the raw body and the header staying in the Response.
+//
+// Example:
+//
+//	var stats map[string]interface{}
+//	_, err := client.Do(ctx, "GET", "/api/jobs/aggregate/status", nil, &stats)
+func (client *ThreatMatrixClient) Do(ctx context.Context, method string, path string, body interface{}, out interface{}) (*Response, error) {
+	// * a ResponseInfo of the caller is filled in too
+	info, ok := ResponseInfoFromContext(ctx)
+	if !ok {
+		info = &ResponseInfo{}
+		ctx = WithResponseInfo(ctx, info)
+	}
+	requestUrl := strings.TrimSuffix(client.options.Url, "/") + "/" + strings.TrimPrefix(path, "/")
+	contentType := "application/json"
+	var requestBody io.Reader
+	switch typedBody := body.(type) {
+	case nil:
+	case io.Reader:
+		requestBody = typedBody
+	case []byte:
+		requestBody = bytes.NewReader(typedBody)
+	default:
+		jsonData, err := json.Marshal(typedBody)
+		if err != nil {
+			return nil, err
+		}
+		requestBody = bytes.NewReader(jsonData)
+	}
+	request, err := client.buildRequest(ctx, strings.ToUpper(method), contentType, requestBody, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	response := &Response{
+		StatusCode: successResp.StatusCode,
+		Header:     info.Header,
+		Data:       successResp.Data,
+		RequestID:  info.RequestID,
+	}
+	if err := response.Decode(out); err != nil {
+		return response, err
+	}
+	return response, nil
+}
@@ -0,0 +1,86 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// REFERENCE_TAG_PREFIX starts the label of the tags carrying an ExternalReference, e.g. "ref:siem:ALERT-4821".
+const REFERENCE_TAG_PREFIX = "ref:"
+
+// MAX_TAG_LABEL_LENGTH is the longest tag label ThreatMatrix accepts.
+const MAX_TAG_LABEL_LENGTH = 50
+
+// ErrInvalidReference is returned for an ExternalReference that cannot be stored as a tag label.
+var ErrInvalidReference = errors.New("gothreatmatrix: the external reference needs a system without ':' and an id, and must fit in a tag label")
+
+// ExternalReference represents the record of another system a job relates to: a SIEM alert, a SOAR case, a ticket.
+// References are stored as tags of the job, ticket systems are referenced through their key (e.g. SOC-123)
+// rather than through an url, which would not fit in a tag label.
+type ExternalReference struct {
+	// System is the kind of record, e.g. "siem", "soar" or "jira". It is lowercased.
+	System string `json:"system"`
+	ID     string `json:"id"`
+}
+
+// Label returns the tag label storing the reference.
+func (reference ExternalReference) Label() string {
+	return REFERENCE_TAG_PREFIX + strings.ToLower(reference.System) + ":" + reference.ID
+}
+
+// validate checks that the reference can be stored as a tag label and parsed back.
+func (reference ExternalReference) validate() error {
+	if reference.System == "" || reference.ID == "" || strings.Contains(reference.System, ":") || len(reference.Label()) > MAX_TAG_LABEL_LENGTH {
+		return ErrInvalidReference
+	}
+	return nil
+}
+
+// ParseExternalReference parses a tag label made by ExternalReference.Label, ok is false for any other label.
+func ParseExternalReference(label string) (reference ExternalReference, ok bool) {
+	if !strings.HasPrefix(label, REFERENCE_TAG_PREFIX) {
+		return ExternalReference{}, false
+	}
+	system, id, found := strings.Cut(strings.TrimPrefix(label, REFERENCE_TAG_PREFIX), ":")
+	if !found || system == "" || id == "" {
+		return ExternalReference{}, false
+	}
+	return ExternalReference{System: system, ID: id}, true
+}
+
+// AddReferences tags the analysis with the references, so its job can be found back with FindByReference.
+func (basicAnalysisParams *BasicAnalysisParams) AddReferences(references ...ExternalReference) error {
+	for _, reference := range references {
+		if err := reference.validate(); err != nil {
+			return err
+		}
+		if label := reference.Label(); !containsValue(basicAnalysisParams.TagsLabels, label) {
+			basicAnalysisParams.TagsLabels = append(basicAnalysisParams.TagsLabels, label)
+		}
+	}
+	return nil
+}
+
+// References returns the external references the job is tagged with.
+func (baseJob *BaseJob) References() []ExternalReference {
+	references := []ExternalReference{}
+	for _, tag := range baseJob.Tags {
+		if reference, ok := ParseExternalReference(tag.Label); ok {
+			references = append(references, reference)
+		}
+	}
+	return references
+}
+
+// FindByReference fetches the jobs tagged with the external reference.
+//
+//	Endpoint: GET /api/jobs
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_list
+func (jobService *JobService) FindByReference(ctx context.Context, reference ExternalReference) (*JobListResponse, error) {
+	if err := reference.validate(); err != nil {
+		return nil, err
+	}
+	return jobService.ListWithFilter(ctx, NewJobFilter().TagIn(reference.Label()))
+}
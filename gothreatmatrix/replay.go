@@ -0,0 +1,117 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"time"
+)
+
+// DEFAULT_REPLAY_PAGE_SIZE is the number of jobs fetched per page by Replay when ReplayOptions.PageSize is 0.
+const DEFAULT_REPLAY_PAGE_SIZE = 100
+
+// ReplayOptions represents the fields used to configure Replay.
+type ReplayOptions struct {
+	// From and To bound the received time of the replayed jobs, a zero time leaving that side open.
+	From time.Time
+	To   time.Time
+	// Filter, when set, further restricts the replayed jobs. Its ordering and pagination are overridden.
+	Filter *JobFilter
+	// PageSize is the number of jobs fetched per page, DEFAULT_REPLAY_PAGE_SIZE when 0.
+	PageSize int
+	// StopOnError stops the replay at the first job a sink could not write, instead of recording it in the result.
+	StopOnError bool
+	// OnJob, when set, is called after every job written to the sinks.
+	OnJob func(job *Job)
+}
+
+// ReplayFailure represents a job Replay could not fetch or write.
+type ReplayFailure struct {
+	JobID uint64
+	Err   error
+}
+
+// ReplayResult represents the outcome of Replay.
+type ReplayResult struct {
+	Replayed int
+	// Skipped are the jobs of the range that had not finished yet.
+	Skipped  int
+	Failures []ReplayFailure
+	// LastReceived is the received time of the last job written, to resume an interrupted replay from.
+	LastReceived time.Time
+}
+
+// Replay re-emits the job completed events of the finished jobs received in a time range through the sinks,
+// oldest first, e.g. to bootstrap a new downstream consumer with the existing data.
+//
+//	Endpoint: GET /api/jobs
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_list
+func (jobService *JobService) Replay(ctx context.Context, options *ReplayOptions, sinks ...JobSink) (*ReplayResult, error) {
+	if options == nil {
+		options = &ReplayOptions{}
+	}
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = DEFAULT_REPLAY_PAGE_SIZE
+	}
+	filter := NewJobFilter()
+	if options.Filter != nil {
+		filter.values = options.Filter.Values()
+	}
+	if !options.From.IsZero() {
+		filter.ReceivedAfter(options.From)
+	}
+	if !options.To.IsZero() {
+		filter.ReceivedBefore(options.To)
+	}
+	filter.Ordering("received_request_time", "id").PageSize(pageSize)
+	result := &ReplayResult{
+		Failures: []ReplayFailure{},
+	}
+	for page := 1; ; page++ {
+		jobList, err := jobService.ListWithFilter(ctx, filter.Page(page))
+		if err != nil {
+			return result, err
+		}
+		for _, listedJob := range jobList.Results {
+			if isJobRunning(listedJob.Status) {
+				result.Skipped++
+				continue
+			}
+			jobId := uint64(listedJob.ID)
+			job, err := jobService.Get(ctx, jobId)
+			if err == nil {
+				err = writeToSinks(ctx, job, sinks)
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return result, ctx.Err()
+				}
+				if options.StopOnError {
+					return result, err
+				}
+				result.Failures = append(result.Failures, ReplayFailure{JobID: jobId, Err: err})
+				continue
+			}
+			result.Replayed++
+			if job.ReceivedRequestTime != nil {
+				result.LastReceived = *job.ReceivedRequestTime
+			}
+			if options.OnJob != nil {
+				options.OnJob(job)
+			}
+		}
+		if page >= jobList.TotalPages || len(jobList.Results) == 0 {
+			return result, nil
+		}
+	}
+}
+
+// writeToSinks writes the job to every sink, stopping at the first error.
+func writeToSinks(ctx context.Context, job *Job, sinks []JobSink) error {
+	for _, sink := range sinks {
+		if err := sink.WriteJob(ctx, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package gothreatmatrix
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrInvalidArchiveFormat is returned, wrapped with the culprit, by WriteReportsArchive for an unknown format.
+var ErrInvalidArchiveFormat = errors.New("gothreatmatrix: invalid archive format")
+
+// Formats of WriteReportsArchive.
+const (
+	ARCHIVE_ZIP    = "zip"
+	ARCHIVE_TAR    = "tar"
+	ARCHIVE_TAR_GZ = "tar.gz"
+)
+
+// reportArchiveWriter writes the files of a reports archive in a format.
+type reportArchiveWriter interface {
+	writeFile(name string, data []byte, modified time.Time) error
+	close() error
+}
+
+// zipArchiveWriter writes the reports as a zip archive.
+type zipArchiveWriter struct {
+	writer *zip.Writer
+}
+
+func (archive *zipArchiveWriter) writeFile(name string, data []byte, modified time.Time) error {
+	fileWriter, err := archive.writer.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = fileWriter.Write(data)
+	return err
+}
+
+func (archive *zipArchiveWriter) close() error {
+	return archive.writer.Close()
+}
+
+// tarArchiveWriter writes the reports as a tar archive, gzipped when gzipWriter is set.
+type tarArchiveWriter struct {
+	writer     *tar.Writer
+	gzipWriter *gzip.Writer
+}
+
+func (archive *tarArchiveWriter) writeFile(name string, data []byte, modified time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: modified, Typeflag: tar.TypeReg}
+	if err := archive.writer.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := archive.writer.Write(data)
+	return err
+}
+
+func (archive *tarArchiveWriter) close() error {
+	if err := archive.writer.Close(); err != nil {
+		return err
+	}
+	if archive.gzipWriter != nil {
+		return archive.gzipWriter.Close()
+	}
+	return nil
+}
+
+// checkArchiveFormat fails for an unknown archive format.
+func checkArchiveFormat(format string) error {
+	switch format {
+	case "", ARCHIVE_ZIP, ARCHIVE_TAR, ARCHIVE_TAR_GZ:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidArchiveFormat, format)
+}
+
+// newReportArchiveWriter returns the writer of the format.
+func newReportArchiveWriter(writer io.Writer, format string) (reportArchiveWriter, error) {
+	if err := checkArchiveFormat(format); err != nil {
+		return nil, err
+	}
+	switch format {
+	case ARCHIVE_TAR:
+		return &tarArchiveWriter{writer: tar.NewWriter(writer)}, nil
+	case ARCHIVE_TAR_GZ:
+		gzipWriter := gzip.NewWriter(writer)
+		return &tarArchiveWriter{writer: tar.NewWriter(gzipWriter), gzipWriter: gzipWriter}, nil
+	}
+	return &zipArchiveWriter{writer: zip.NewWriter(writer)}, nil
+}
+
+// WriteReportsArchive writes the reports of the job as an archive in the format, ARCHIVE_ZIP when empty: a JSON
+// file per plugin, analyzers/<name>.json and connectors/<name>.json, dated with the end of its run. The archive
+// ends with a MANIFEST.sha256 listing the SHA256 of every report, so a zip archive can be checked with
+// VerifyJobBundle.
+func WriteReportsArchive(writer io.Writer, job *Job, format string) error {
+	archive, err := newReportArchiveWriter(writer, format)
+	if err != nil {
+		return err
+	}
+	manifest := &bytes.Buffer{}
+	finished := time.Now()
+	if job.FinishedAnalysisTime != nil {
+		finished = *job.FinishedAnalysisTime
+	}
+	sections := []struct {
+		directory string
+		reports   []Report
+	}{
+		{"analyzers", job.AnalyzerReports},
+		{"connectors", job.ConnectorReports},
+	}
+	for _, section := range sections {
+		for _, report := range section.reports {
+			reportJson, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			modified := report.EndTime
+			if modified.IsZero() {
+				modified = finished
+			}
+			name := section.directory + "/" + sanitizeFileName(report.Name) + ".json"
+			if err := archive.writeFile(name, reportJson, modified); err != nil {
+				return err
+			}
+			checksum := sha256.Sum256(reportJson)
+			fmt.Fprintf(manifest, "%s  %s\n", hex.EncodeToString(checksum[:]), name)
+		}
+	}
+	if err := archive.writeFile(BUNDLE_MANIFEST_NAME, manifest.Bytes(), finished); err != nil {
+		return err
+	}
+	return archive.close()
+}
+
+// DownloadReports fetches the job and streams its reports to the writer as an archive in the format, see
+// WriteReportsArchive, e.g. to preserve the evidence of an investigation.
+//
+//	Endpoint: GET /api/jobs/{jobID}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_retrieve
+func (jobService *JobService) DownloadReports(ctx context.Context, jobId uint64, format string, writer io.Writer) error {
+	if err := checkArchiveFormat(format); err != nil {
+		return err
+	}
+	job, err := jobService.Get(ctx, jobId)
+	if err != nil {
+		return err
+	}
+	return WriteReportsArchive(writer, job, format)
+}
@@ -0,0 +1,126 @@
+package gothreatmatrix
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ReportValidator is implemented by report schemas that can check their own content once decoded.
+type ReportValidator interface {
+	Validate() error
+}
+
+// ReportSchemaRegistry maps analyzer names to the Go types their reports are decoded into.
+// It is safe for concurrent use.
+type ReportSchemaRegistry struct {
+	mutex   sync.RWMutex
+	schemas map[string]reflect.Type
+}
+
+// DefaultReportSchemaRegistry is the registry used by Report.Decode, starting with the typed reports of popular
+// analyzers, e.g. VirusTotalReport and AbuseIPDBReport.
+var DefaultReportSchemaRegistry = newDefaultReportSchemaRegistry()
+
+// NewReportSchemaRegistry returns an empty ReportSchemaRegistry.
+func NewReportSchemaRegistry() *ReportSchemaRegistry {
+	return &ReportSchemaRegistry{
+		schemas: map[string]reflect.Type{},
+	}
+}
+
+// newDefaultReportSchemaRegistry returns a registry of the builtin report schemas.
+func newDefaultReportSchemaRegistry() *ReportSchemaRegistry {
+	registry := NewReportSchemaRegistry()
+	for analyzerName, schema := range builtinReportSchemas {
+		registry.Register(analyzerName, schema)
+	}
+	return registry
+}
+
+// Register associates the analyzer name with the type of schema.
+// schema can either be a struct value or a pointer to one, e.g. VirusTotalReport{} or &VirusTotalReport{}.
+func (registry *ReportSchemaRegistry) Register(analyzerName string, schema interface{}) {
+	schemaType := reflect.TypeOf(schema)
+	for schemaType != nil && schemaType.Kind() == reflect.Ptr {
+		schemaType = schemaType.Elem()
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if schemaType == nil {
+		delete(registry.schemas, analyzerName)
+		return
+	}
+	registry.schemas[analyzerName] = schemaType
+}
+
+// Lookup returns the type registered for the analyzer name.
+func (registry *ReportSchemaRegistry) Lookup(analyzerName string) (reflect.Type, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	schemaType, ok := registry.schemas[analyzerName]
+	return schemaType, ok
+}
+
+// Decode converts the report into the type registered for its analyzer name.
+// The returned value is a pointer to the registered type, validated through ReportValidator when implemented.
+// Reports of unknown analyzers are returned as a json.RawMessage.
+func (registry *ReportSchemaRegistry) Decode(report *Report) (interface{}, error) {
+	rawReport, err := json.Marshal(report.Report)
+	if err != nil {
+		return nil, err
+	}
+	schemaType, ok := registry.Lookup(report.Name)
+	if !ok {
+		return json.RawMessage(rawReport), nil
+	}
+	decoded := reflect.New(schemaType).Interface()
+	if unmarshalError := json.Unmarshal(rawReport, decoded); unmarshalError != nil {
+		return nil, fmt.Errorf("could not decode the %s report: %w", report.Name, unmarshalError)
+	}
+	if validator, ok := decoded.(ReportValidator); ok {
+		if validationError := validator.Validate(); validationError != nil {
+			return nil, fmt.Errorf("invalid %s report: %w", report.Name, validationError)
+		}
+	}
+	return decoded, nil
+}
+
+// RegisterReportSchema registers the schema of an analyzer's report in the DefaultReportSchemaRegistry.
+func RegisterReportSchema(analyzerName string, schema interface{}) {
+	DefaultReportSchemaRegistry.Register(analyzerName, schema)
+}
+
+// Decode converts the report through the DefaultReportSchemaRegistry.
+func (report *Report) Decode() (interface{}, error) {
+	return DefaultReportSchemaRegistry.Decode(report)
+}
+
+// DecodeKnown converts the report into the type the DefaultReportSchemaRegistry has for its analyzer,
+// e.g. a *VirusTotalReport, ok is false for the reports of unknown analyzers.
+func (report *Report) DecodeKnown() (decoded interface{}, ok bool, err error) {
+	if _, ok := DefaultReportSchemaRegistry.Lookup(report.Name); !ok {
+		return nil, false, nil
+	}
+	decoded, err = DefaultReportSchemaRegistry.Decode(report)
+	return decoded, err == nil, err
+}
+
+// DecodeInto decodes the report into v, a pointer to the schema of the caller's choice,
+// validated through ReportValidator when implemented.
+func (report *Report) DecodeInto(v interface{}) error {
+	rawReport, err := json.Marshal(report.Report)
+	if err != nil {
+		return err
+	}
+	if unmarshalError := json.Unmarshal(rawReport, v); unmarshalError != nil {
+		return fmt.Errorf("could not decode the %s report: %w", report.Name, unmarshalError)
+	}
+	if validator, ok := v.(ReportValidator); ok {
+		if validationError := validator.Validate(); validationError != nil {
+			return fmt.Errorf("invalid %s report: %w", report.Name, validationError)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+type requestIdKey struct{}
+
+// WithRequestID returns a copy of ctx whose requests are sent with the request ID as their REQUEST_ID_HEADER,
+// e.g. the ID of the incoming request of a service calling ThreatMatrix, instead of a generated one.
+func WithRequestID(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, ok is false when there is none.
+func RequestIDFromContext(ctx context.Context) (requestId string, ok bool) {
+	requestId, ok = ctx.Value(requestIdKey{}).(string)
+	return requestId, ok && requestId != ""
+}
+
+// newRequestID returns a random UUID, version 4.
+func newRequestID() (string, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", err
+	}
+	idBytes[6] = idBytes[6]&0x0f | 0x40
+	idBytes[8] = idBytes[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", idBytes[0:4], idBytes[4:6], idBytes[6:8], idBytes[8:10], idBytes[10:16]), nil
+}
+
+// setCorrelationHeaders sets the CorrelationHeaders of the options on the request, then its request ID: the one
+// carried by ctx, a random one otherwise when GenerateRequestIDs is set. The ID is set once on the request, so
+// that its retries send the same one.
+func (client *ThreatMatrixClient) setCorrelationHeaders(ctx context.Context, request *http.Request) error {
+	for key, value := range client.options.CorrelationHeaders {
+		request.Header.Set(key, value)
+	}
+	if requestId, ok := RequestIDFromContext(ctx); ok {
+		request.Header.Set(REQUEST_ID_HEADER, requestId)
+		return nil
+	}
+	if !client.options.GenerateRequestIDs || request.Header.Get(REQUEST_ID_HEADER) != "" {
+		return nil
+	}
+	requestId, err := newRequestID()
+	if err != nil {
+		return err
+	}
+	request.Header.Set(REQUEST_ID_HEADER, requestId)
+	return nil
+}
+
+// requestIdOf returns the request ID of the exchange: the one the instance answered with, e.g. set by its proxy,
+// the one the request was sent with otherwise.
+func requestIdOf(request *http.Request, response *http.Response) string {
+	if response != nil {
+		if requestId := response.Header.Get(REQUEST_ID_HEADER); requestId != "" {
+			return requestId
+		}
+		if request == nil {
+			request = response.Request
+		}
+	}
+	if request == nil {
+		return ""
+	}
+	return request.Header.Get(REQUEST_ID_HEADER)
+}
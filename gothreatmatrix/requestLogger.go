@@ -0,0 +1,90 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// REDACTED replaces the secrets of the headers the Logger is given, e.g. the API token.
+const REDACTED = "[REDACTED]"
+
+// redactedHeaders are the headers carrying secrets, never logged as they are.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Logger receives the debug logs of the requests of the client: their method, URL, headers and body size,
+// the status and body size of their responses, and the retries. Its method is the one of *slog.Logger,
+// so a *slog.Logger can be set as is, and the arguments are alternating keys and values.
+// The API token is redacted from the logged headers.
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// redactHeaders returns the headers with the values of redactedHeaders replaced by REDACTED,
+// keeping the scheme of the Authorization header, e.g. "token [REDACTED]".
+func redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, name := range redactedHeaders {
+		values := redacted.Values(name)
+		for index, value := range values {
+			if scheme, _, ok := strings.Cut(value, " "); ok && strings.HasSuffix(name, "Authorization") {
+				values[index] = scheme + " " + REDACTED
+			} else {
+				values[index] = REDACTED
+			}
+		}
+	}
+	return redacted
+}
+
+// loggedRoundTrip wraps the round trip so the request and its response are logged to the Logger of the options.
+func (client *ThreatMatrixClient) loggedRoundTrip(roundTrip RoundTripFunc) RoundTripFunc {
+	logger := client.options.Logger
+	if logger == nil {
+		return roundTrip
+	}
+	return func(request *http.Request) (*http.Response, error) {
+		ctx := request.Context()
+		logger.DebugContext(ctx, "threatmatrix request",
+			"method", request.Method,
+			"url", request.URL.String(),
+			"headers", redactHeaders(request.Header),
+			"request_bytes", request.ContentLength,
+		)
+		start := time.Now()
+		response, err := roundTrip(request)
+		if err != nil {
+			logger.DebugContext(ctx, "threatmatrix request failed",
+				"method", request.Method,
+				"url", request.URL.String(),
+				"duration", time.Since(start),
+				"error", err,
+			)
+			return response, err
+		}
+		logger.DebugContext(ctx, "threatmatrix response",
+			"method", request.Method,
+			"url", request.URL.String(),
+			"status", response.StatusCode,
+			"headers", redactHeaders(response.Header),
+			"response_bytes", response.ContentLength,
+			"duration", time.Since(start),
+		)
+		return response, err
+	}
+}
+
+// logRetry logs that the request is sent again after failing with err.
+func (client *ThreatMatrixClient) logRetry(ctx context.Context, request *http.Request, attempt int, delay time.Duration, err error) {
+	if client.options.Logger == nil {
+		return
+	}
+	client.options.Logger.DebugContext(ctx, "threatmatrix request retried",
+		"method", request.Method,
+		"url", request.URL.String(),
+		"attempt", attempt,
+		"delay", delay,
+		"error", err,
+	)
+}
@@ -0,0 +1,58 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ResponseInfo represents the HTTP response of a call, e.g. to read its pagination links or deprecation headers.
+// Attached to a context with WithResponseInfo, it is filled in by the calls made with that context.
+// A call sending several requests, retries included, leaves the info of its last response, and Attempts counts them.
+// Use one ResponseInfo per call: calls running concurrently must not share one.
+type ResponseInfo struct {
+	// StatusCode and Header are the ones of the last response, error responses included.
+	// They are left zero when no response was received.
+	StatusCode int
+	Header     http.Header
+	// Duration is the time the last request took, from sending it to receiving the headers of its response.
+	Duration time.Duration
+	// Attempts is the number of requests sent.
+	Attempts int
+	// RequestID is the one of the last request, see ThreatMatrixError.RequestID, set even when no response was received.
+	RequestID string
+}
+
+type responseInfoKey struct{}
+
+// WithResponseInfo returns a copy of ctx filling in the info with the responses of the calls made with it:
+//
+//	info := &gothreatmatrix.ResponseInfo{}
+//	job, err := client.JobService.Get(gothreatmatrix.WithResponseInfo(ctx, info), jobId)
+//	deprecation := info.Header.Get("Deprecation")
+func WithResponseInfo(ctx context.Context, info *ResponseInfo) context.Context {
+	return context.WithValue(ctx, responseInfoKey{}, info)
+}
+
+// ResponseInfoFromContext returns the info carried by ctx, ok is false when there is none.
+func ResponseInfoFromContext(ctx context.Context) (info *ResponseInfo, ok bool) {
+	info, ok = ctx.Value(responseInfoKey{}).(*ResponseInfo)
+	return info, ok && info != nil
+}
+
+// recordResponse fills in the info carried by the context of the request, if any, with its response.
+func recordResponse(request *http.Request, response *http.Response, duration time.Duration) {
+	info, ok := ResponseInfoFromContext(request.Context())
+	if !ok {
+		return
+	}
+	info.Attempts++
+	info.Duration = duration
+	info.StatusCode = 0
+	info.Header = nil
+	info.RequestID = requestIdOf(request, response)
+	if response != nil {
+		info.StatusCode = response.StatusCode
+		info.Header = response.Header.Clone()
+	}
+}
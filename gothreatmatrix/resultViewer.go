@@ -0,0 +1,158 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// VIEWER_CONTENT_SECURITY_POLICY is the Content-Security-Policy of the pages of a ResultViewer: they load nothing,
+// the screenshots being embedded as data URIs.
+const VIEWER_CONTENT_SECURITY_POLICY = "default-src 'none'; img-src data:; style-src 'unsafe-inline'"
+
+var htmlInvestigationViewTemplate = template.Must(template.New("investigation").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>ThreatMatrix investigation {{.Investigation.ID}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Investigation: {{.Investigation.Name}}</h1>
+{{- if .Investigation.Description}}
+<p>{{.Investigation.Description}}</p>
+{{- end}}
+<table>
+<tr><th>Status</th><td>{{.Investigation.Status}}</td></tr>
+<tr><th>Tags</th><td>{{range $index, $tag := .Investigation.Tags}}{{if $index}}, {{end}}{{$tag}}{{end}}</td></tr>
+</table>
+<h2>Jobs</h2>
+<table>
+<tr><th>Job</th><th>Indicator</th><th>Status</th><th>Malware families</th></tr>
+{{- range .Jobs}}
+<tr><td><a href="../jobs/{{.JobID}}">{{.JobID}}</a></td><td>{{.Indicator}}</td><td>{{.Status}}</td><td>{{range $index, $family := .MalwareFamilies}}{{if $index}}, {{end}}{{$family.Name}}{{end}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// WriteInvestigationView renders a self-contained HTML page of the investigation, listing its jobs with a link
+// to their page at ../jobs/{jobID}, the layout of a ResultViewer.
+func WriteInvestigationView(writer io.Writer, investigation *Investigation, jobs []Job) error {
+	viewJobs := make([]htmlInvestigationEmailJob, 0, len(jobs))
+	for index := range jobs {
+		summary := jobs[index].Summary()
+		indicator := summary.ObservableName
+		if indicator == "" {
+			indicator = summary.FileName
+		}
+		viewJobs = append(viewJobs, htmlInvestigationEmailJob{
+			JobSummary: summary,
+			Indicator:  indicator,
+		})
+	}
+	return htmlInvestigationViewTemplate.Execute(writer, struct {
+		Investigation *Investigation
+		Jobs          []htmlInvestigationEmailJob
+	}{
+		Investigation: investigation,
+		Jobs:          viewJobs,
+	})
+}
+
+// ResultViewerOptions represents the fields used to configure NewResultViewer.
+type ResultViewerOptions struct {
+	// Authorize, when set, is asked whether the request may be served, the others being answered 403.
+	Authorize func(r *http.Request) bool
+}
+
+// ResultViewer is an http.Handler serving read-only HTML pages of the jobs and investigations of the instance,
+// for embedding quick-look pages into internal tools without exposing the ThreatMatrix UI:
+//
+//	GET /jobs/{jobID}                    the page of WriteHTMLReport
+//	GET /investigations/{investigationID} the page of WriteInvestigationView
+//
+// Mount it under a prefix with http.StripPrefix. The pages load no remote resource and are served with
+// VIEWER_CONTENT_SECURITY_POLICY. It answers 404 for unknown paths and for what ThreatMatrix does not find,
+// 405 for other methods than GET and HEAD, and 502 when ThreatMatrix failed.
+type ResultViewer struct {
+	client  *ThreatMatrixClient
+	options ResultViewerOptions
+}
+
+// NewResultViewer returns a ResultViewer fetching the jobs and investigations through the client.
+func (client *ThreatMatrixClient) NewResultViewer(options *ResultViewerOptions) *ResultViewer {
+	viewer := &ResultViewer{client: client}
+	if options != nil {
+		viewer.options = *options
+	}
+	return viewer
+}
+
+// ServeHTTP implements http.Handler.
+func (viewer *ResultViewer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if viewer.options.Authorize != nil && !viewer.options.Authorize(r) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	kind, idString, ok := strings.Cut(strings.Trim(r.URL.Path, "/"), "/")
+	id, err := strconv.ParseUint(idString, 10, 64)
+	if !ok || err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	page := &bytes.Buffer{}
+	switch kind {
+	case "jobs":
+		job, fetchError := viewer.client.JobService.Get(r.Context(), id)
+		if err = fetchError; err == nil {
+			err = WriteHTMLReport(page, job)
+		}
+	case "investigations":
+		err = viewer.writeInvestigation(r, page, id)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if IsNotFound(err) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "could not fetch the results from ThreatMatrix", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", VIEWER_CONTENT_SECURITY_POLICY)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(page.Bytes())
+}
+
+// writeInvestigation renders the page of the investigation, with its jobs.
+func (viewer *ResultViewer) writeInvestigation(r *http.Request, writer io.Writer, investigationId uint64) error {
+	investigation, err := viewer.client.InvestigationService.Get(r.Context(), investigationId)
+	if err != nil {
+		return err
+	}
+	jobs := []Job{}
+	for _, result := range viewer.client.JobService.GetMany(r.Context(), investigation.Jobs, DEFAULT_BULK_CONCURRENCY) {
+		if result.Err != nil {
+			return result.Err
+		}
+		jobs = append(jobs, *result.Job)
+	}
+	return WriteInvestigationView(writer, investigation, jobs)
+}
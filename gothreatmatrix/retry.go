@@ -0,0 +1,182 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// IDEMPOTENCY_KEY_HEADER is the header carrying BasicAnalysisParams.IdempotencyKey.
+const IDEMPOTENCY_KEY_HEADER = "Idempotency-Key"
+
+// DEFAULT_RETRY_BASE_DELAY is the wait before the first retry when RetryOptions.BaseDelay is 0, it doubles on every retry.
+const DEFAULT_RETRY_BASE_DELAY = 500 * time.Millisecond
+
+// RetryOptions represents how failed requests are retried.
+// Only idempotent operations are retried (see IsIdempotent): a submission is retried only when it carries an
+// idempotency key, so a request that reached the server before failing never creates a duplicate job.
+type RetryOptions struct {
+	// MaxRetries is the number of retries after the first attempt, 0 disables retrying.
+	MaxRetries int `json:"max_retries"`
+	// BaseDelay is the wait before the first retry, DEFAULT_RETRY_BASE_DELAY when 0.
+	BaseDelay time.Duration `json:"base_delay"`
+	// MaxDelay caps the doubling wait between retries, 0 leaving it uncapped. A Retry-After header asking
+	// for a longer wait than MaxDelay stops retrying, the error being returned instead.
+	MaxDelay time.Duration `json:"max_delay"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, BaseDelay and MaxDelay also reading a duration string, e.g. "500ms".
+func (retryOptions *RetryOptions) UnmarshalJSON(data []byte) error {
+	type plainRetryOptions RetryOptions
+	return unmarshalWithDurations(data, (*plainRetryOptions)(retryOptions))
+}
+
+// idempotentPostRoutes are the POST endpoints that only read data.
+var idempotentPostRoutes = []string{
+	constants.ASK_ANALYSIS_AVAILABILITY_URL,
+	constants.LEGACY_ANALYSIS_RESULT_URL,
+}
+
+// IsIdempotent reports whether sending the request twice has the same effect as sending it once.
+// GET, HEAD, OPTIONS, PUT and DELETE are idempotent, as are the POST endpoints that only read data.
+// Submissions and the kill/retry actions are not.
+func IsIdempotent(method string, path string) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	case "POST":
+		for _, route := range idempotentPostRoutes {
+			if strings.HasSuffix(strings.TrimSuffix(path, "/"), route) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isRetryable reports whether the request may be sent again after failing with err.
+func isRetryable(request *http.Request, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	if request.Body != nil && request.GetBody == nil {
+		return false
+	}
+	if !IsIdempotent(request.Method, request.URL.Path) && request.Header.Get(IDEMPOTENCY_KEY_HEADER) == "" {
+		return false
+	}
+	var threatMatrixError *ThreatMatrixError
+	if errors.As(err, &threatMatrixError) {
+		statusCode := threatMatrixError.StatusCode
+		return statusCode == http.StatusTooManyRequests || (statusCode >= http.StatusInternalServerError && statusCode != http.StatusNotImplemented)
+	}
+	// * the request did not get a response
+	return true
+}
+
+// newRequest is used for making requests, retrying them according to the RetryOptions of the client
+// and reporting them to its Telemetry. A request built for a streaming transfer stays one, see withStreaming.
+func (client *ThreatMatrixClient) newRequest(ctx context.Context, request *http.Request) (*successResponse, error) {
+	if isStreaming(request) {
+		ctx = withStreaming(ctx)
+	}
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+	request = request.WithContext(ctx)
+	if telemetry := client.telemetry(); telemetry != nil {
+		return client.instrumentedRequest(ctx, request, telemetry)
+	}
+	return client.sendWithRetries(ctx, request)
+}
+
+// sendWithRetries sends the request, retrying it according to the RetryOptions of the client.
+func (client *ThreatMatrixClient) sendWithRetries(ctx context.Context, request *http.Request) (*successResponse, error) {
+	retryOptions := client.options.RetryOptions
+	baseDelay := DEFAULT_RETRY_BASE_DELAY
+	if retryOptions != nil && retryOptions.BaseDelay > 0 {
+		baseDelay = retryOptions.BaseDelay
+	}
+	reauthenticated := false
+	for attempt := 0; ; attempt++ {
+		successResp, err := client.sendHedged(ctx, request)
+		// * rejected credentials are renewed once, without counting as a retry
+		if err != nil && IsUnauthorized(err) && !reauthenticated && client.reauthenticate(ctx, request) {
+			reauthenticated = true
+			attempt--
+			continue
+		}
+		if err == nil || retryOptions == nil || attempt >= retryOptions.MaxRetries || !isRetryable(request, err) {
+			return successResp, err
+		}
+		delay := retryDelay(retryOptions, baseDelay, attempt)
+		if wait, ok := retryAfter(err, time.Now()); ok {
+			if retryOptions.MaxDelay > 0 && wait > retryOptions.MaxDelay {
+				return successResp, err
+			}
+			delay = wait
+		}
+		client.logRetry(ctx, request, attempt+1, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		countRetry(ctx)
+		if request.GetBody != nil {
+			body, bodyError := request.GetBody()
+			if bodyError != nil {
+				return nil, bodyError
+			}
+			request.Body = body
+		}
+	}
+}
+
+// retryDelay returns the wait before the retry following the attempt: the base delay doubled on every retry,
+// capped at the MaxDelay of the RetryOptions.
+func retryDelay(retryOptions *RetryOptions, baseDelay time.Duration, attempt int) time.Duration {
+	delay := baseDelay
+	for retry := 0; retry < attempt; retry++ {
+		if retryOptions.MaxDelay > 0 && delay >= retryOptions.MaxDelay {
+			break
+		}
+		delay *= 2
+	}
+	if retryOptions.MaxDelay > 0 && delay > retryOptions.MaxDelay {
+		delay = retryOptions.MaxDelay
+	}
+	return delay
+}
+
+// retryAfter returns the wait the Retry-After header of the failed response asks for, in seconds or as an HTTP date.
+func retryAfter(err error, now time.Time) (time.Duration, bool) {
+	var threatMatrixError *ThreatMatrixError
+	if !errors.As(err, &threatMatrixError) || threatMatrixError.Response == nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(threatMatrixError.Response.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, parseError := strconv.Atoi(value); parseError == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, parseError := http.ParseTime(value)
+	if parseError != nil {
+		return 0, false
+	}
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
@@ -0,0 +1,270 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Sections of a runtime configuration built by RuntimeConfig.
+const (
+	RUNTIME_CONFIG_ANALYZERS  = "analyzers"
+	RUNTIME_CONFIG_CONNECTORS = "connectors"
+)
+
+// ErrInvalidRuntimeConfig is wrapped by the RuntimeConfigError of ValidateRuntimeConfig.
+var ErrInvalidRuntimeConfig = errors.New("gothreatmatrix: invalid runtime configuration")
+
+// RuntimeConfig builds the runtime_configuration of an analysis, plugin by plugin:
+//
+//	runtimeConfig := gothreatmatrix.NewRuntimeConfig()
+//	runtimeConfig.Analyzer("VirusTotal_v3_Get_File").Set("max_tries", 3).Set("include_behaviour_summary", true)
+//	params.RuntimeConfiguration, err = client.BuildRuntimeConfig(ctx, runtimeConfig)
+//
+// BuildRuntimeConfig checks the params against the plugin configurations of the instance,
+// so a typo fails before the submission instead of being ignored by the server.
+type RuntimeConfig struct {
+	// sections map a section to the params of its plugins, by plugin name.
+	sections map[string]map[string]map[string]interface{}
+}
+
+// NewRuntimeConfig returns an empty RuntimeConfig.
+func NewRuntimeConfig() *RuntimeConfig {
+	return &RuntimeConfig{sections: map[string]map[string]map[string]interface{}{}}
+}
+
+// PluginRuntimeConfig represents the section of a plugin in a RuntimeConfig.
+type PluginRuntimeConfig struct {
+	params map[string]interface{}
+}
+
+// Analyzer returns the section of the analyzer.
+func (runtimeConfig *RuntimeConfig) Analyzer(name string) *PluginRuntimeConfig {
+	return runtimeConfig.plugin(RUNTIME_CONFIG_ANALYZERS, name)
+}
+
+// Connector returns the section of the connector.
+func (runtimeConfig *RuntimeConfig) Connector(name string) *PluginRuntimeConfig {
+	return runtimeConfig.plugin(RUNTIME_CONFIG_CONNECTORS, name)
+}
+
+// plugin returns the section of the plugin, creating it if needed.
+func (runtimeConfig *RuntimeConfig) plugin(section string, name string) *PluginRuntimeConfig {
+	plugins, ok := runtimeConfig.sections[section]
+	if !ok {
+		plugins = map[string]map[string]interface{}{}
+		runtimeConfig.sections[section] = plugins
+	}
+	params, ok := plugins[name]
+	if !ok {
+		params = map[string]interface{}{}
+		plugins[name] = params
+	}
+	return &PluginRuntimeConfig{params: params}
+}
+
+// Set sets the param of the plugin, replacing its previous value.
+func (pluginRuntimeConfig *PluginRuntimeConfig) Set(key string, value interface{}) *PluginRuntimeConfig {
+	pluginRuntimeConfig.params[key] = value
+	return pluginRuntimeConfig
+}
+
+// Map returns the runtime configuration as BasicAnalysisParams.RuntimeConfiguration expects it, without validating it.
+func (runtimeConfig *RuntimeConfig) Map() map[string]interface{} {
+	runtimeConfiguration := map[string]interface{}{}
+	for section, plugins := range runtimeConfig.sections {
+		sectionMap := map[string]interface{}{}
+		for name, params := range plugins {
+			paramsMap := make(map[string]interface{}, len(params))
+			for key, value := range params {
+				paramsMap[key] = value
+			}
+			sectionMap[name] = paramsMap
+		}
+		runtimeConfiguration[section] = sectionMap
+	}
+	return runtimeConfiguration
+}
+
+// RuntimeConfigProblem represents a param of a RuntimeConfig that does not match the configuration of its plugin.
+type RuntimeConfigProblem struct {
+	Section string
+	Plugin  string
+	// Key is empty when the plugin itself is unknown.
+	Key    string
+	Reason string
+}
+
+// String describes the problem, e.g. "analyzers.Shodan_Search.max_tries: expected int, got string".
+func (problem RuntimeConfigProblem) String() string {
+	path := problem.Plugin
+	if problem.Section != "" {
+		path = problem.Section + "." + path
+	}
+	if problem.Key != "" {
+		path += "." + problem.Key
+	}
+	return path + ": " + problem.Reason
+}
+
+// RuntimeConfigError is returned by ValidateRuntimeConfig with every problem of the RuntimeConfig, sorted.
+type RuntimeConfigError struct {
+	Problems []RuntimeConfigProblem
+}
+
+// Error implements error.
+func (runtimeConfigError *RuntimeConfigError) Error() string {
+	problems := make([]string, len(runtimeConfigError.Problems))
+	for index, problem := range runtimeConfigError.Problems {
+		problems[index] = problem.String()
+	}
+	return fmt.Sprintf("%v: %s", ErrInvalidRuntimeConfig, strings.Join(problems, "; "))
+}
+
+// Unwrap returns ErrInvalidRuntimeConfig.
+func (runtimeConfigError *RuntimeConfigError) Unwrap() error {
+	return ErrInvalidRuntimeConfig
+}
+
+// ValidateRuntimeConfig checks every param of the RuntimeConfig against the params of its plugin, as listed by
+// GetConfigs: the plugin must exist, declare the param and the value must match its type (str, int, float, bool,
+// list or dict), the required params without a value must be set. It returns a *RuntimeConfigError listing every problem found.
+func (client *ThreatMatrixClient) ValidateRuntimeConfig(ctx context.Context, runtimeConfig *RuntimeConfig) error {
+	problems := []RuntimeConfigProblem{}
+	if plugins := runtimeConfig.sections[RUNTIME_CONFIG_ANALYZERS]; len(plugins) > 0 {
+		analyzerConfigs, err := client.AnalyzerService.GetConfigs(ctx)
+		if err != nil {
+			return err
+		}
+		declared := map[string]BaseConfigurationType{}
+		for _, analyzerConfig := range *analyzerConfigs {
+			declared[analyzerConfig.Name] = analyzerConfig.BaseConfigurationType
+		}
+		problems = append(problems, validateRuntimeSection(RUNTIME_CONFIG_ANALYZERS, plugins, declared)...)
+	}
+	if plugins := runtimeConfig.sections[RUNTIME_CONFIG_CONNECTORS]; len(plugins) > 0 {
+		connectorConfigs, err := client.ConnectorService.GetConfigs(ctx)
+		if err != nil {
+			return err
+		}
+		declared := map[string]BaseConfigurationType{}
+		for _, connectorConfig := range *connectorConfigs {
+			declared[connectorConfig.Name] = connectorConfig.BaseConfigurationType
+		}
+		problems = append(problems, validateRuntimeSection(RUNTIME_CONFIG_CONNECTORS, plugins, declared)...)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].String() < problems[j].String()
+	})
+	return &RuntimeConfigError{Problems: problems}
+}
+
+// BuildRuntimeConfig validates the RuntimeConfig, see ValidateRuntimeConfig, and returns its Map.
+func (client *ThreatMatrixClient) BuildRuntimeConfig(ctx context.Context, runtimeConfig *RuntimeConfig) (map[string]interface{}, error) {
+	if err := client.ValidateRuntimeConfig(ctx, runtimeConfig); err != nil {
+		return nil, err
+	}
+	return runtimeConfig.Map(), nil
+}
+
+// validateRuntimeSection returns the problems of the plugins of a section, declared holds the configuration of every plugin.
+func validateRuntimeSection(section string, plugins map[string]map[string]interface{}, declared map[string]BaseConfigurationType) []RuntimeConfigProblem {
+	problems := []RuntimeConfigProblem{}
+	for name, params := range plugins {
+		pluginConfig, ok := declared[name]
+		if !ok {
+			problems = append(problems, RuntimeConfigProblem{Section: section, Plugin: name, Reason: "unknown plugin"})
+			continue
+		}
+		problems = append(problems, pluginConfig.runtimeProblems(section, params)...)
+	}
+	return problems
+}
+
+// runtimeProblems returns the problems of the runtime configuration of the plugin: the params it does not declare,
+// the values not matching the type of their param and the required params left without a value.
+func (baseConfiguration BaseConfigurationType) runtimeProblems(section string, params map[string]interface{}) []RuntimeConfigProblem {
+	problems := []RuntimeConfigProblem{}
+	for key, value := range params {
+		parameter, ok := baseConfiguration.Params[key]
+		if !ok {
+			problems = append(problems, RuntimeConfigProblem{Section: section, Plugin: baseConfiguration.Name, Key: key, Reason: "unknown param"})
+			continue
+		}
+		if parameterType := parameter.TypeName(); !matchesParameterType(parameterType, value) {
+			problems = append(problems, RuntimeConfigProblem{
+				Section: section,
+				Plugin:  baseConfiguration.Name,
+				Key:     key,
+				Reason:  fmt.Sprintf("expected %s, got %T", parameterType, value),
+			})
+		}
+	}
+	for key, parameter := range baseConfiguration.Params {
+		if _, ok := params[key]; ok || !parameter.Required || parameter.Value != nil || parameter.Default != nil {
+			continue
+		}
+		problems = append(problems, RuntimeConfigProblem{Section: section, Plugin: baseConfiguration.Name, Key: key, Reason: "required param not set"})
+	}
+	return problems
+}
+
+// Validate checks the runtime configuration of the plugin, its params by name, against the params the plugin
+// declares, the way ValidateRuntimeConfig does without fetching the configurations: every param must be declared
+// and match its type, and the required params without a value must be set. It returns a *RuntimeConfigError
+// listing every problem found, their Section being empty.
+func (baseConfiguration BaseConfigurationType) Validate(runtimeConfig map[string]interface{}) error {
+	problems := baseConfiguration.runtimeProblems("", runtimeConfig)
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].String() < problems[j].String()
+	})
+	return &RuntimeConfigError{Problems: problems}
+}
+
+// matchesParameterType reports whether the value can be sent for a param of the type, unknown types accepting any value.
+func matchesParameterType(parameterType string, value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	kind := reflect.TypeOf(value).Kind()
+	switch parameterType {
+	case "str":
+		return kind == reflect.String
+	case "bool":
+		return kind == reflect.Bool
+	case "int":
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		case reflect.Float32, reflect.Float64:
+			// * decoded JSON numbers are float64
+			number := reflect.ValueOf(value).Float()
+			return number == math.Trunc(number)
+		}
+		return false
+	case "float":
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+		return false
+	case "list":
+		return kind == reflect.Slice || kind == reflect.Array
+	case "dict":
+		return kind == reflect.Map || kind == reflect.Struct
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// DownloadOptions represents the fields used to configure DownloadSampleToFile.
+type DownloadOptions struct {
+	// BytesPerSecond limits the bandwidth used by the download, 0 means unlimited.
+	BytesPerSecond int64
+	// SkipChecksum does not compare the downloaded sample with the hashes of the job.
+	SkipChecksum bool
+}
+
+// DownloadSampleToFile streams the File sample of the job to filePath, so samples too large to be held in memory
+// (e.g. memory dumps) can be retrieved.
+// The sample is written to filePath + ".part" first: when a download is interrupted, calling DownloadSampleToFile
+// again resumes it from where it stopped through an HTTP range request.
+// Once complete, the sample is checked against the hashes of the job (see VerifySample) before being moved to filePath.
+// The download is not bounded by the Timeout of the client, which would cut large samples, but by ctx.
+// options can be nil.
+//
+//	Endpoint: GET /api/jobs/{jobID}/download_sample
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_download_sample_retrieve
+func (jobService *JobService) DownloadSampleToFile(ctx context.Context, jobId uint64, filePath string, options *DownloadOptions) error {
+	if options == nil {
+		options = &DownloadOptions{}
+	}
+	partPath := filePath + ".part"
+	partFile, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	downloadError := jobService.downloadSample(ctx, jobId, partFile, options.BytesPerSecond)
+	if closeError := partFile.Close(); downloadError == nil {
+		downloadError = closeError
+	}
+	if downloadError != nil {
+		return downloadError
+	}
+	if !options.SkipChecksum {
+		if err := jobService.verifySampleChecksum(ctx, jobId, partPath); err != nil {
+			return err
+		}
+	}
+	return os.Rename(partPath, filePath)
+}
+
+// StreamSample returns the File sample of the job as a stream read straight from the response, so large samples
+// can be processed without being held in memory, unlike DownloadSample.
+// The caller must close the returned reader. The stream is not bounded by the Timeout of the client, so a slow
+// consumer is not cut off, but by ctx: errors happening mid-stream, ctx or the WithTimeout of the call being done
+// included, are returned by its Read.
+//
+//	Endpoint: GET /api/jobs/{jobID}/download_sample
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_download_sample_retrieve
+func (jobService *JobService) StreamSample(ctx context.Context, jobId uint64) (io.ReadCloser, error) {
+	ctx, cancel := callContext(withStreaming(ctx))
+	route := jobService.client.options.Url + constants.DOWNLOAD_SAMPLE_JOB_URL
+	requestUrl := fmt.Sprintf(route, jobId)
+	contentType := "application/json"
+	method := "GET"
+	request, err := jobService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	response, err := jobService.client.do(request)
+	if err != nil {
+		defer cancel()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		defer cancel()
+		defer response.Body.Close()
+		msgBytes, _ := ioutil.ReadAll(response.Body)
+		return nil, newThreatMatrixError(response.StatusCode, string(msgBytes), response)
+	}
+	return &cancelingBody{ReadCloser: response.Body, cancel: cancel}, nil
+}
+
+// downloadSample appends the missing part of the sample to partFile.
+func (jobService *JobService) downloadSample(ctx context.Context, jobId uint64, partFile *os.File, bytesPerSecond int64) error {
+	fileInfo, err := partFile.Stat()
+	if err != nil {
+		return err
+	}
+	offset := fileInfo.Size()
+	ctx, cancel := callContext(withStreaming(ctx))
+	defer cancel()
+	route := jobService.client.options.Url + constants.DOWNLOAD_SAMPLE_JOB_URL
+	requestUrl := fmt.Sprintf(route, jobId)
+	contentType := "application/json"
+	method := "GET"
+	request, err := jobService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	response, err := jobService.client.do(request)
+	if err != nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		return err
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusPartialContent:
+		// * resuming where the previous download stopped
+		if contentRange := response.Header.Get("Content-Range"); !strings.HasPrefix(contentRange, fmt.Sprintf("bytes %d-", offset)) {
+			return newThreatMatrixError(response.StatusCode, "unexpected Content-Range "+contentRange, response)
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// * the previous download already got every byte
+		if offset > 0 {
+			return nil
+		}
+		return newThreatMatrixError(response.StatusCode, "range not satisfiable", response)
+	case http.StatusOK:
+		// * the server sent the whole sample: starting over
+		offset = 0
+	default:
+		msgBytes, _ := ioutil.ReadAll(response.Body)
+		return newThreatMatrixError(response.StatusCode, string(msgBytes), response)
+	}
+	if err := partFile.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := partFile.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(partFile, newThrottledReader(ctx, response.Body, bytesPerSecond))
+	return err
+}
+
+// verifySampleChecksum compares the downloaded file with the hashes of the job.
+// A corrupted file is removed, as resuming it can only give a corrupted sample again.
+func (jobService *JobService) verifySampleChecksum(ctx context.Context, jobId uint64, partPath string) error {
+	job, err := jobService.Get(ctx, jobId)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(partPath)
+	if err != nil {
+		return err
+	}
+	err = VerifySample(job, file)
+	file.Close()
+	if errors.Is(err, ErrSampleChecksumMismatch) {
+		os.Remove(partPath)
+	}
+	return err
+}
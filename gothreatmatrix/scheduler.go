@@ -0,0 +1,261 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DEFAULT_SCHEDULER_RETRY_INTERVAL is the wait of Scheduler.Run before submitting again the analyses
+// that could not reach the server or that it failed, when SchedulerOptions leaves RetryInterval at 0.
+const DEFAULT_SCHEDULER_RETRY_INTERVAL = time.Minute
+
+// ScheduledAnalysis represents an observable analysis held by a Scheduler until its time comes.
+type ScheduledAnalysis struct {
+	ID     string                   `json:"id"`
+	At     time.Time                `json:"at"`
+	Params ObservableAnalysisParams `json:"params"`
+	// JobID is the job of the analysis once submitted.
+	JobID int `json:"job_id,omitempty"`
+	// Err is the error the server refused the submission with, the analysis is then not submitted.
+	Err error `json:"-"`
+}
+
+// SchedulerOptions represents the fields used to configure NewScheduler.
+type SchedulerOptions struct {
+	// Path is the JSON file the pending analyses are persisted to, so they survive a restart of the process.
+	// When empty, they are kept in memory only.
+	Path string
+	// RetryInterval is how long Run waits before submitting again the analyses that could not reach the server, that it failed
+	// or that its open circuit held back,
+	// DEFAULT_SCHEDULER_RETRY_INTERVAL when 0.
+	RetryInterval time.Duration
+}
+
+// Scheduler holds observable analyses until their scheduled time and then submits them, ThreatMatrix having
+// no delayed execution of its own. Each analysis is submitted with its ID as idempotency key, so an analysis
+// submitted right before a crash is not run twice by servers honoring the key.
+type Scheduler struct {
+	client  *ThreatMatrixClient
+	options SchedulerOptions
+	mutex   sync.Mutex
+	pending []ScheduledAnalysis
+	wake    chan struct{}
+}
+
+// NewScheduler returns a Scheduler submitting through the client, with the analyses still pending in the
+// file of the options.
+func (client *ThreatMatrixClient) NewScheduler(options *SchedulerOptions) (*Scheduler, error) {
+	scheduler := &Scheduler{
+		client: client,
+		wake:   make(chan struct{}, 1),
+	}
+	if options != nil {
+		scheduler.options = *options
+	}
+	if scheduler.options.RetryInterval <= 0 {
+		scheduler.options.RetryInterval = DEFAULT_SCHEDULER_RETRY_INTERVAL
+	}
+	if scheduler.options.Path == "" {
+		return scheduler, nil
+	}
+	data, err := os.ReadFile(scheduler.options.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return scheduler, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &scheduler.pending); err != nil {
+		return nil, err
+	}
+	return scheduler, nil
+}
+
+// Schedule holds the analysis until at, and persists it.
+// Use time.Now().Add(delay) to delay an analysis.
+func (scheduler *Scheduler) Schedule(params *ObservableAnalysisParams, at time.Time) (*ScheduledAnalysis, error) {
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+	scheduled := ScheduledAnalysis{
+		ID:     hex.EncodeToString(idBytes),
+		At:     at,
+		Params: *params,
+	}
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+	pending := append(append([]ScheduledAnalysis{}, scheduler.pending...), scheduled)
+	if err := scheduler.save(pending); err != nil {
+		return nil, err
+	}
+	scheduler.pending = pending
+	select {
+	case scheduler.wake <- struct{}{}:
+	default:
+	}
+	return &scheduled, nil
+}
+
+// Cancel drops the pending analysis with that ID, false when there is none.
+func (scheduler *Scheduler) Cancel(id string) (bool, error) {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+	pending := []ScheduledAnalysis{}
+	for _, scheduled := range scheduler.pending {
+		if scheduled.ID != id {
+			pending = append(pending, scheduled)
+		}
+	}
+	if len(pending) == len(scheduler.pending) {
+		return false, nil
+	}
+	if err := scheduler.save(pending); err != nil {
+		return false, err
+	}
+	scheduler.pending = pending
+	return true, nil
+}
+
+// Pending returns the analyses not submitted yet, the earliest first.
+func (scheduler *Scheduler) Pending() []ScheduledAnalysis {
+	scheduler.mutex.Lock()
+	defer scheduler.mutex.Unlock()
+	pending := append([]ScheduledAnalysis{}, scheduler.pending...)
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].At.Before(pending[j].At)
+	})
+	return pending
+}
+
+// SubmitDue submits the analyses scheduled at or before now and returns them, with their JobID or, when the
+// server refused them with a 4xx, their Err. Both leave the pending analyses. When the server cannot be reached
+// or fails, the analyses not submitted yet stay pending and the error is returned with the ones submitted so far.
+func (scheduler *Scheduler) SubmitDue(ctx context.Context, now time.Time) ([]ScheduledAnalysis, error) {
+	submitted := []ScheduledAnalysis{}
+	for _, scheduled := range scheduler.Pending() {
+		if scheduled.At.After(now) {
+			break
+		}
+		params := scheduled.Params
+		params.IdempotencyKey = "scheduled-" + scheduled.ID
+		analysisResponse, err := scheduler.client.CreateObservableAnalysis(ctx, &params)
+		if err != nil && !isRefusedSubmission(err) {
+			return submitted, err
+		}
+		if err != nil {
+			scheduled.Err = err
+		} else {
+			scheduled.JobID = analysisResponse.JobID
+		}
+		if _, err := scheduler.Cancel(scheduled.ID); err != nil {
+			return submitted, err
+		}
+		submitted = append(submitted, scheduled)
+	}
+	return submitted, nil
+}
+
+// isRefusedSubmission reports whether the server refused the submission itself, so submitting it again can't succeed.
+func isRefusedSubmission(err error) bool {
+	var threatMatrixError *ThreatMatrixError
+	if !errors.As(err, &threatMatrixError) {
+		return false
+	}
+	switch threatMatrixError.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return threatMatrixError.StatusCode >= http.StatusBadRequest && threatMatrixError.StatusCode < http.StatusInternalServerError
+}
+
+// isTransientFailure reports whether the submission failed with err on an outage of the instance, so submitting it
+// again later can succeed: it was not reached, it failed or throttled the submission, or its circuit is open.
+func isTransientFailure(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+	var threatMatrixError *ThreatMatrixError
+	if errors.As(err, &threatMatrixError) {
+		return !isRefusedSubmission(err)
+	}
+	var urlError *url.Error
+	return errors.As(err, &urlError)
+}
+
+// Run submits the analyses as they become due until the context is done, calling submitted with each of them,
+// see SubmitDue. It returns the error of the context, or the one that prevented persisting the pending analyses.
+// Submissions that could not reach the server, that it failed or that its open circuit held back are tried again
+// every RetryInterval.
+func (scheduler *Scheduler) Run(ctx context.Context, submitted func(ScheduledAnalysis)) error {
+	for {
+		due, err := scheduler.SubmitDue(ctx, time.Now())
+		for _, scheduled := range due {
+			submitted(scheduled)
+		}
+		wait := time.Duration(-1)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !isTransientFailure(err) {
+				return err
+			}
+			wait = scheduler.options.RetryInterval
+		} else if pending := scheduler.Pending(); len(pending) > 0 {
+			wait = time.Until(pending[0].At)
+		}
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if wait >= 0 {
+			timer = time.NewTimer(wait)
+			expired = timer.C
+		}
+		select {
+		case <-ctx.Done():
+		case <-scheduler.wake:
+		case <-expired:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// save persists the pending analyses to the file of the options, replaced atomically.
+func (scheduler *Scheduler) save(pending []ScheduledAnalysis) error {
+	path := scheduler.options.Path
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return err
+	}
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), path)
+}
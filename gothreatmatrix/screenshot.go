@@ -0,0 +1,144 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Screenshot represents an image produced by an analyzer, e.g. a URL analyzer rendering the page.
+type Screenshot struct {
+	// Analyzer is the name of the analyzer whose report contained the screenshot.
+	Analyzer string
+	// Field is the path of the screenshot in the report, e.g. "result.screenshot".
+	Field    string
+	MimeType string
+	Data     []byte
+}
+
+// Extension returns the file extension matching the screenshot's mime type.
+func (screenshot *Screenshot) Extension() string {
+	switch screenshot.MimeType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/bmp":
+		return ".bmp"
+	}
+	return ".png"
+}
+
+// DataURI returns the screenshot as a data URI, ready to be embedded in an HTML page.
+func (screenshot *Screenshot) DataURI() string {
+	return "data:" + screenshot.MimeType + ";base64," + base64.StdEncoding.EncodeToString(screenshot.Data)
+}
+
+// ExtractScreenshots decodes every screenshot found in the analyzer reports of the job.
+// Screenshots are the base64 images (raw or as data URIs) stored in a report field whose name contains "screenshot".
+func ExtractScreenshots(job *Job) []Screenshot {
+	screenshots := []Screenshot{}
+	for index := range job.AnalyzerReports {
+		report := &job.AnalyzerReports[index]
+		found := []Screenshot{}
+		collectScreenshots(report.Report, "", false, report.Name, &found)
+		// * map iteration is random, keeping the output stable
+		sort.Slice(found, func(i, j int) bool { return found[i].Field < found[j].Field })
+		screenshots = append(screenshots, found...)
+	}
+	return screenshots
+}
+
+func collectScreenshots(value interface{}, field string, inScreenshotField bool, analyzerName string, screenshots *[]Screenshot) {
+	switch typedValue := value.(type) {
+	case string:
+		if !inScreenshotField {
+			return
+		}
+		if data, mimeType, ok := decodeImage(typedValue); ok {
+			*screenshots = append(*screenshots, Screenshot{Analyzer: analyzerName, Field: field, MimeType: mimeType, Data: data})
+		}
+	case map[string]interface{}:
+		for key, fieldValue := range typedValue {
+			fieldPath := key
+			if field != "" {
+				fieldPath = field + "." + key
+			}
+			collectScreenshots(fieldValue, fieldPath, inScreenshotField || strings.Contains(strings.ToLower(key), "screenshot"), analyzerName, screenshots)
+		}
+	case []interface{}:
+		for index, item := range typedValue {
+			collectScreenshots(item, fmt.Sprintf("%s[%d]", field, index), inScreenshotField, analyzerName, screenshots)
+		}
+	}
+}
+
+// decodeImage decodes a base64 image, raw or as a data URI.
+func decodeImage(value string) ([]byte, string, bool) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "data:") {
+		index := strings.Index(value, ";base64,")
+		if index < 0 {
+			return nil, "", false
+		}
+		value = value[index+len(";base64,"):]
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		if data, err = base64.RawStdEncoding.DecodeString(value); err != nil {
+			return nil, "", false
+		}
+	}
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, "", false
+	}
+	return data, mimeType, true
+}
+
+// SaveScreenshots writes the screenshots to the directory, named after their analyzer,
+// and returns the paths of the written files.
+func SaveScreenshots(screenshots []Screenshot, directory string) ([]string, error) {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for index := range screenshots {
+		screenshot := &screenshots[index]
+		path := filepath.Join(directory, fmt.Sprintf("%s_%d%s", sanitizeFileName(screenshot.Analyzer), index+1, screenshot.Extension()))
+		if err := os.WriteFile(path, screenshot.Data, 0644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func sanitizeFileName(name string) string {
+	return strings.Map(func(character rune) rune {
+		if character == '/' || character == '\\' || character == ':' || character == os.PathSeparator {
+			return '_'
+		}
+		return character
+	}, name)
+}
+
+// GetScreenshots fetches a job and decodes the screenshots produced by its analyzers.
+//
+//	Endpoint: GET /api/jobs/{jobID}
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_retrieve
+func (jobService *JobService) GetScreenshots(ctx context.Context, jobId uint64) ([]Screenshot, error) {
+	job, err := jobService.Get(ctx, jobId)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractScreenshots(job), nil
+}
@@ -0,0 +1,246 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// ErrInvalidSearchQuery is returned, wrapped with the culprit, for a SearchQuery the search cannot run,
+// e.g. a field the Elasticsearch search has no filter for.
+var ErrInvalidSearchQuery = errors.New("gothreatmatrix: invalid search query")
+
+// Fields of the fielded queries of ParseSearchQuery, e.g. "tag:phishing".
+const (
+	SEARCH_FIELD_OBSERVABLE = "observable"
+	SEARCH_FIELD_TAG        = "tag"
+	SEARCH_FIELD_ANALYZER   = "analyzer"
+	SEARCH_FIELD_STATUS     = "status"
+)
+
+// SearchQuery represents a search over the jobs, see SearchService.Jobs.
+type SearchQuery struct {
+	// Text is the free text searched for: in the jobs, or in the plugin reports with Elastic.
+	Text string `json:"text,omitempty"`
+	// Observable filters the jobs whose observable name contains it.
+	Observable string `json:"observable,omitempty"`
+	// Tags filters the jobs that have at least one of the tag labels.
+	Tags []string `json:"tags,omitempty"`
+	// Analyzer filters the jobs that requested the analyzer, or the reports of the analyzer with Elastic.
+	Analyzer string `json:"analyzer,omitempty"`
+	// Status filters the jobs by their status, or the reports by theirs with Elastic, e.g. "FAILED".
+	Status string `json:"status,omitempty"`
+	// Elastic, when true, searches the plugin reports indexed in the Elasticsearch of the instance instead of the
+	// jobs, which needs it to be enabled on the instance, see FEATURE_REPORT_SEARCH. Observable and Tags are not
+	// supported then.
+	Elastic bool `json:"elastic,omitempty"`
+	// Page is the page fetched by SearchService.Jobs, starting at 1, the first one when 0.
+	Page int `json:"page,omitempty"`
+	// PageSize is the number of hits per page, left to the server when 0.
+	PageSize int `json:"page_size,omitempty"`
+}
+
+// ParseSearchQuery parses a query made of space separated terms: "field:value" terms for the SEARCH_FIELD_*
+// fields, the "tag" one repeatable, and free text terms, e.g.
+//
+//	gothreatmatrix.ParseSearchQuery("tag:phishing analyzer:Classic_DNS login page")
+//
+// An unknown field is kept in the free text, e.g. a URL "https://example.com".
+func ParseSearchQuery(query string) *SearchQuery {
+	searchQuery := &SearchQuery{}
+	text := []string{}
+	for _, term := range strings.Fields(query) {
+		field, value, ok := strings.Cut(term, ":")
+		if !ok || value == "" {
+			text = append(text, term)
+			continue
+		}
+		switch strings.ToLower(field) {
+		case SEARCH_FIELD_OBSERVABLE:
+			searchQuery.Observable = value
+		case SEARCH_FIELD_TAG:
+			searchQuery.Tags = append(searchQuery.Tags, value)
+		case SEARCH_FIELD_ANALYZER:
+			searchQuery.Analyzer = value
+		case SEARCH_FIELD_STATUS:
+			searchQuery.Status = value
+		default:
+			text = append(text, term)
+		}
+	}
+	searchQuery.Text = strings.Join(text, " ")
+	return searchQuery
+}
+
+// route returns the route of the search and its query params.
+func (searchQuery *SearchQuery) route() (string, url.Values, error) {
+	values := url.Values{}
+	if searchQuery.Elastic {
+		if searchQuery.Observable != "" || len(searchQuery.Tags) > 0 {
+			return "", nil, fmt.Errorf("%w: the Elasticsearch search has no observable and tag filters", ErrInvalidSearchQuery)
+		}
+		if searchQuery.Text != "" {
+			values.Set("report", searchQuery.Text)
+		}
+		if searchQuery.Analyzer != "" {
+			values.Set("type", ANALYZER_PLUGIN)
+			values.Set("name", searchQuery.Analyzer)
+		}
+		if searchQuery.Status != "" {
+			values.Set("status", searchQuery.Status)
+		}
+		return constants.PLUGIN_REPORT_SEARCH_URL, values, nil
+	}
+	if searchQuery.Text != "" {
+		values.Set("search", searchQuery.Text)
+	}
+	if searchQuery.Observable != "" {
+		values.Set("observable_name", searchQuery.Observable)
+	}
+	if len(searchQuery.Tags) > 0 {
+		values.Set("tags", strings.Join(searchQuery.Tags, ","))
+	}
+	if searchQuery.Analyzer != "" {
+		values.Set("analyzers_requested", searchQuery.Analyzer)
+	}
+	if searchQuery.Status != "" {
+		values.Set("status", searchQuery.Status)
+	}
+	return constants.BASE_JOB_URL, values, nil
+}
+
+// PluginReportHit represents a plugin report found by the Elasticsearch search.
+type PluginReportHit struct {
+	JobID int `json:"job_id"`
+	// PluginType is the type of the plugin, e.g. ANALYZER_PLUGIN, and Name its name.
+	PluginType string                 `json:"plugin_type"`
+	Name       string                 `json:"name"`
+	Status     string                 `json:"status"`
+	StartTime  *time.Time             `json:"start_time"`
+	EndTime    *time.Time             `json:"end_time"`
+	Errors     []string               `json:"errors"`
+	Report     map[string]interface{} `json:"report"`
+}
+
+// SearchHit represents a hit of a search, a job or, for an Elastic search, a plugin report.
+type SearchHit struct {
+	JobID int `json:"job_id"`
+	// Job is set for the hits of a job search.
+	Job *JobList `json:"job,omitempty"`
+	// PluginReport is set for the hits of an Elastic search.
+	PluginReport *PluginReportHit `json:"plugin_report,omitempty"`
+}
+
+// UnmarshalJSON decodes a hit as the instance sends it: a job, or a plugin report carrying its job and its
+// plugin config.
+func (searchHit *SearchHit) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if _, ok := fields["config"]; !ok {
+		job := &JobList{}
+		if err := json.Unmarshal(data, job); err != nil {
+			return err
+		}
+		*searchHit = SearchHit{JobID: job.ID, Job: job}
+		return nil
+	}
+	elasticHit := struct {
+		Job struct {
+			ID int `json:"id"`
+		} `json:"job"`
+		Config struct {
+			Name       string `json:"name"`
+			PluginName string `json:"plugin_name"`
+		} `json:"config"`
+		Status    string                 `json:"status"`
+		StartTime *time.Time             `json:"start_time"`
+		EndTime   *time.Time             `json:"end_time"`
+		Errors    []string               `json:"errors"`
+		Report    map[string]interface{} `json:"report"`
+	}{}
+	if err := json.Unmarshal(data, &elasticHit); err != nil {
+		return err
+	}
+	*searchHit = SearchHit{
+		JobID: elasticHit.Job.ID,
+		PluginReport: &PluginReportHit{
+			JobID:      elasticHit.Job.ID,
+			PluginType: elasticHit.Config.PluginName,
+			Name:       elasticHit.Config.Name,
+			Status:     elasticHit.Status,
+			StartTime:  elasticHit.StartTime,
+			EndTime:    elasticHit.EndTime,
+			Errors:     elasticHit.Errors,
+			Report:     elasticHit.Report,
+		},
+	}
+	return nil
+}
+
+// SearchResults represents a page of hits of a search.
+type SearchResults struct {
+	PageInfo
+	Hits []SearchHit
+}
+
+// SearchService handles communication with the search related methods of the ThreatMatrix API: the jobs, and
+// the plugin reports indexed in Elasticsearch when the instance has it enabled.
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/plugin_report
+type SearchService struct {
+	client *ThreatMatrixClient
+}
+
+// Paginate returns a Paginator over the hits of the search, its Page being ignored.
+//
+//	Endpoint: GET /api/jobs
+//	Endpoint: GET /api/plugin_report/search (Elastic)
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/plugin_report/operation/plugin_report_search_retrieve
+func (searchService *SearchService) Paginate(query *SearchQuery) (*Paginator[SearchHit], error) {
+	route, values, err := query.route()
+	if err != nil {
+		return nil, err
+	}
+	paginator := newPaginator[SearchHit](searchService.client, route, values, query.PageSize)
+	if query.Elastic {
+		paginator.feature = FEATURE_REPORT_SEARCH
+	}
+	return paginator, nil
+}
+
+// Jobs searches the jobs, or the plugin reports for an Elastic query, fetching the Page of the query.
+//
+// Example:
+//
+//	results, err := client.SearchService.Jobs(ctx, gothreatmatrix.ParseSearchQuery("tag:phishing 8.8.8.8"))
+//
+//	Endpoint: GET /api/jobs
+//	Endpoint: GET /api/plugin_report/search (Elastic)
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/plugin_report/operation/plugin_report_search_retrieve
+func (searchService *SearchService) Jobs(ctx context.Context, query *SearchQuery) (*SearchResults, error) {
+	paginator, err := searchService.Paginate(query)
+	if err != nil {
+		return nil, err
+	}
+	if query.Page > 1 {
+		paginator.info.Page = query.Page - 1
+	}
+	hits, err := paginator.Next(ctx)
+	if errors.Is(err, ErrNoMorePages) {
+		hits, err = []SearchHit{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResults{PageInfo: paginator.PageInfo(), Hits: hits}, nil
+}
@@ -0,0 +1,286 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SANDBOX_ANALYZERS are the name prefixes of the sandbox analyzers whose reports ExtractSandboxIndicators reads.
+// You can add your own sandboxes before extracting indicators.
+var SANDBOX_ANALYZERS = []string{
+	"Cuckoo", "CAPE", "Triage", "Intezer", "VMRay", "Joe", "Hybrid_Analysis", "AnyRun", "Any_Run", "Dragonfly", "Zenbox", "Vmray",
+}
+
+// sandboxNetworkKeys are the sandbox report fields holding a contacted host, IP or URL.
+var sandboxNetworkKeys = map[string]bool{
+	"domain": true, "domains": true, "host": true, "hosts": true, "hostname": true, "request": true, "query": true,
+	"dst": true, "dst_ip": true, "dstip": true, "destination_ip": true, "ip": true, "ips": true, "url": true, "urls": true,
+}
+
+// sandboxProcessKeys are the sandbox report fields holding the path of a spawned process.
+var sandboxProcessKeys = map[string]bool{
+	"process_name": true, "process_path": true, "image": true, "module_path": true, "executable": true,
+}
+
+// sandboxCommandLineKeys are the sandbox report fields holding the command line of a spawned process.
+var sandboxCommandLineKeys = map[string]bool{
+	"command_line": true, "commandline": true, "cmdline": true,
+}
+
+// SandboxIndicators represents the network and process activity the sandbox reports of a job observed.
+type SandboxIndicators struct {
+	Domains      []string `json:"domains"`
+	IPs          []string `json:"ips"`
+	URLs         []string `json:"urls"`
+	Processes    []string `json:"processes"`
+	CommandLines []string `json:"command_lines"`
+}
+
+// isSandboxAnalyzer reports whether the analyzer is one of SANDBOX_ANALYZERS.
+func isSandboxAnalyzer(name string) bool {
+	for _, prefix := range SANDBOX_ANALYZERS {
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractSandboxIndicators walks the reports of the SANDBOX_ANALYZERS of the job and returns the hosts they
+// contacted and the processes they spawned, sorted. Private, loopback and link-local IPs are left out,
+// they are the network of the sandbox itself.
+func ExtractSandboxIndicators(job *Job) *SandboxIndicators {
+	found := map[string]map[string]bool{}
+	add := func(kind string, value string) {
+		if found[kind] == nil {
+			found[kind] = map[string]bool{}
+		}
+		found[kind][value] = true
+	}
+	for index := range job.AnalyzerReports {
+		report := &job.AnalyzerReports[index]
+		if !isSandboxAnalyzer(report.Name) {
+			continue
+		}
+		walkMalwareFields(report.Report, "", func(key string, value string) {
+			value = strings.TrimSpace(value)
+			switch {
+			case sandboxNetworkKeys[key]:
+				switch ClassifyObservable(value) {
+				case "ip":
+					if ip := net.ParseIP(value); !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsUnspecified() {
+						add("ip", ip.String())
+					}
+				case "domain":
+					add("domain", strings.ToLower(value))
+				case "url":
+					add("url", value)
+				}
+			case sandboxProcessKeys[key]:
+				add("process", value)
+			case sandboxCommandLineKeys[key]:
+				add("command_line", value)
+			}
+		})
+	}
+	sorted := func(kind string) []string {
+		values := make([]string, 0, len(found[kind]))
+		for value := range found[kind] {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		return values
+	}
+	return &SandboxIndicators{
+		Domains:      sorted("domain"),
+		IPs:          sorted("ip"),
+		URLs:         sorted("url"),
+		Processes:    sorted("process"),
+		CommandLines: sorted("command_line"),
+	}
+}
+
+// SigmaExportOptions represents the fields used to configure SigmaRules.
+type SigmaExportOptions struct {
+	// Author is the author of the rules, "ThreatMatrix" when empty.
+	Author string
+	// Level is the level of the rules, "medium" when empty.
+	Level string
+	// Date is the date of the rules, time.Now() when zero.
+	Date time.Time
+	// References, e.g. the URL of the job on the instance, are listed in every rule.
+	References []string
+}
+
+// SigmaLogSource represents the logsource section of a Sigma rule.
+type SigmaLogSource struct {
+	Category string `json:"category"`
+	Product  string `json:"product,omitempty"`
+}
+
+// SigmaRule represents a Sigma rule stub: its detection section is filled with the indicators of a job,
+// the rest is left for the detection engineer to review.
+type SigmaRule struct {
+	Title          string                 `json:"title"`
+	ID             string                 `json:"id"`
+	Status         string                 `json:"status"`
+	Description    string                 `json:"description"`
+	References     []string               `json:"references,omitempty"`
+	Author         string                 `json:"author"`
+	Date           string                 `json:"date"`
+	Tags           []string               `json:"tags,omitempty"`
+	LogSource      SigmaLogSource         `json:"logsource"`
+	Detection      map[string]interface{} `json:"detection"`
+	FalsePositives []string               `json:"falsepositives"`
+	Level          string                 `json:"level"`
+}
+
+// SigmaRules converts the sandbox indicators of the job into one rule stub per Sigma log source category:
+// dns_query for the domains, network_connection for the IPs, proxy for the URLs and process_creation for the
+// processes. Categories without indicators get no rule. The IDs are derived from the job, so exporting
+// the same job again gives the same rules.
+func SigmaRules(job *Job, options *SigmaExportOptions) []SigmaRule {
+	if options == nil {
+		options = &SigmaExportOptions{}
+	}
+	author := options.Author
+	if author == "" {
+		author = "ThreatMatrix"
+	}
+	level := options.Level
+	if level == "" {
+		level = "medium"
+	}
+	date := options.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	subject := job.ObservableName
+	if job.IsSample {
+		subject = job.FileName
+	}
+	tags := []string{}
+	if job.Tlp != "" {
+		tags = append(tags, "tlp."+strings.ToLower(job.Tlp))
+	}
+	indicators := ExtractSandboxIndicators(job)
+	rules := []SigmaRule{}
+	newRule := func(category string, activity string, observed string, detection map[string]interface{}) {
+		selections := 0
+		for key := range detection {
+			if strings.HasPrefix(key, "selection") {
+				selections++
+			}
+		}
+		if selections == 0 {
+			return
+		}
+		if selections == 1 {
+			detection["condition"] = "selection"
+		} else {
+			detection["condition"] = "1 of selection_*"
+		}
+		rules = append(rules, SigmaRule{
+			Title:          fmt.Sprintf("%s of %s", activity, subject),
+			ID:             sigmaRuleID(job, category),
+			Status:         "experimental",
+			Description:    fmt.Sprintf("Detects the %s observed by the sandbox analyzers of ThreatMatrix job %d on %s.", observed, job.ID, subject),
+			References:     options.References,
+			Author:         author,
+			Date:           date.Format("2006-01-02"),
+			Tags:           tags,
+			LogSource:      SigmaLogSource{Category: category},
+			Detection:      detection,
+			FalsePositives: []string{"Unknown"},
+			Level:          level,
+		})
+	}
+	if len(indicators.Domains) > 0 {
+		newRule("dns_query", "DNS queries", "DNS queries", map[string]interface{}{
+			"selection": map[string]interface{}{"QueryName": indicators.Domains},
+		})
+	}
+	if len(indicators.IPs) > 0 {
+		newRule("network_connection", "Network connections", "network connections", map[string]interface{}{
+			"selection": map[string]interface{}{"DestinationIp": indicators.IPs},
+		})
+	}
+	if len(indicators.URLs) > 0 {
+		newRule("proxy", "Web requests", "web requests", map[string]interface{}{
+			"selection": map[string]interface{}{"c-uri|contains": indicators.URLs},
+		})
+	}
+	processDetection := map[string]interface{}{}
+	if len(indicators.Processes) > 0 {
+		images := []string{}
+		for _, process := range indicators.Processes {
+			images = appendUnique(images, processImageSuffix(process))
+		}
+		processDetection["selection_image"] = map[string]interface{}{"Image|endswith": images}
+	}
+	if len(indicators.CommandLines) > 0 {
+		processDetection["selection_cmdline"] = map[string]interface{}{"CommandLine|contains": indicators.CommandLines}
+	}
+	newRule("process_creation", "Process creations", "processes spawned", processDetection)
+	return rules
+}
+
+// processImageSuffix returns what the Image field of a process must end with: its file name and the path separator before it.
+func processImageSuffix(process string) string {
+	if index := strings.LastIndexAny(process, `\/`); index >= 0 {
+		return process[index:]
+	}
+	return `\` + process
+}
+
+// sigmaRuleID derives the UUID of a rule from the job and the log source category.
+func sigmaRuleID(job *Job, category string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("threatmatrix/%d/%s/%s", job.ID, job.Md5, category)))
+	// * formatted as a name-based (version 5) UUID
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// sigmaRuleFields are the fields of a Sigma rule in the order the Sigma specification lists them.
+var sigmaRuleFields = []string{
+	"title", "id", "status", "description", "references", "author", "date", "tags", "logsource", "detection", "falsepositives", "level",
+}
+
+// WriteSigmaRules writes the rules as a YAML stream, one document per rule, with the fields in the usual Sigma order.
+func WriteSigmaRules(writer io.Writer, rules []SigmaRule) error {
+	buffer := &bytes.Buffer{}
+	for index := range rules {
+		if index > 0 {
+			buffer.WriteString("---\n")
+		}
+		ruleData, err := json.Marshal(&rules[index])
+		if err != nil {
+			return err
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(ruleData, &fields); err != nil {
+			return err
+		}
+		for _, field := range sigmaRuleFields {
+			value, ok := fields[field]
+			if !ok {
+				continue
+			}
+			data, err := marshalYaml(map[string]interface{}{field: value})
+			if err != nil {
+				return err
+			}
+			buffer.Write(data)
+		}
+	}
+	_, err := writer.Write(buffer.Bytes())
+	return err
+}
@@ -0,0 +1,132 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// JobSink is implemented by the destinations jobs are exported to (search indexes, threat sharing platforms, ...).
+// The heavier sinks live in their own modules under integrations/ so the core client stays dependency-light.
+type JobSink interface {
+	WriteJob(ctx context.Context, job *Job) error
+}
+
+// JobSinkFunc lets an ordinary function be used as a JobSink.
+type JobSinkFunc func(ctx context.Context, job *Job) error
+
+// WriteJob calls the function itself.
+func (sinkFunc JobSinkFunc) WriteJob(ctx context.Context, job *Job) error {
+	return sinkFunc(ctx, job)
+}
+
+// ExportJob fetches the job and writes it to every sink, stopping at the first error.
+func (jobService *JobService) ExportJob(ctx context.Context, jobId uint64, sinks ...JobSink) error {
+	job, err := jobService.Get(ctx, jobId)
+	if err != nil {
+		return err
+	}
+	return writeToSinks(ctx, job, sinks)
+}
+
+// JobEvent represents the payload a WebhookSink posts for every job, and the events of JobService.Watch.
+// Its JSON Schema is published in docs/schemas, see EventSchemas.
+type JobEvent struct {
+	// SchemaVersion is the EVENT_SCHEMA_VERSION the payload conforms to.
+	SchemaVersion int    `json:"schema_version"`
+	Event         string `json:"event"`
+	Job           *Job   `json:"job"`
+	// Report is the report that completed, on a REPORT_COMPLETED_EVENT.
+	Report *Report `json:"report,omitempty"`
+}
+
+// Values of the Event of a JobEvent.
+const (
+	// JOB_COMPLETED_EVENT is sent once the job stopped running.
+	JOB_COMPLETED_EVENT = "job_completed"
+	// REPORT_COMPLETED_EVENT is sent by JobService.Watch whenever a report of the job finished.
+	REPORT_COMPLETED_EVENT = "report_completed"
+)
+
+// newJobCompletedEvent returns the JobEvent of the job.
+func newJobCompletedEvent(job *Job) *JobEvent {
+	return &JobEvent{SchemaVersion: EVENT_SCHEMA_VERSION, Event: JOB_COMPLETED_EVENT, Job: job}
+}
+
+// WebhookSink posts every job as a JobEvent to a webhook.
+type WebhookSink struct {
+	Url string
+	// Headers are added to every request, e.g. an authorization header.
+	Headers map[string]string
+	// Secret, when set, signs the TIMESTAMP_HEADER and the body with HMAC-SHA256 in the SIGNATURE_HEADER header
+	// (hex encoded), see webhook.Sign.
+	Secret     string
+	HttpClient *http.Client
+}
+
+// WriteJob posts the job to the webhook.
+func (sink *WebhookSink) WriteJob(ctx context.Context, job *Job) error {
+	body, err := json.Marshal(newJobCompletedEvent(job))
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, "POST", sink.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range sink.Headers {
+		request.Header.Set(key, value)
+	}
+	if sink.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		signature := hmac.New(sha256.New, []byte(sink.Secret))
+		signature.Write([]byte(timestamp + "."))
+		signature.Write(body)
+		request.Header.Set(TIMESTAMP_HEADER, timestamp)
+		request.Header.Set(SIGNATURE_HEADER, hex.EncodeToString(signature.Sum(nil)))
+	}
+	httpClient := sink.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusBadRequest {
+		msgBytes, _ := ioutil.ReadAll(response.Body)
+		return newThreatMatrixError(response.StatusCode, string(msgBytes), response)
+	}
+	return nil
+}
+
+// JSONLinesSink writes every job as a line of JSON, e.g. to a file or the standard output.
+type JSONLinesSink struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewJSONLinesSink returns a JSONLinesSink writing to writer.
+func NewJSONLinesSink(writer io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{
+		encoder: json.NewEncoder(writer),
+	}
+}
+
+// WriteJob writes the job as a JobEvent line.
+func (sink *JSONLinesSink) WriteJob(ctx context.Context, job *Job) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	return sink.encoder.Encode(newJobCompletedEvent(job))
+}
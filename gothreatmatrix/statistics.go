@@ -0,0 +1,216 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// Values of the range of the statistics, how far back they aggregate the jobs.
+const (
+	STATISTICS_RANGE_DAY   = "24h"
+	STATISTICS_RANGE_WEEK  = "7d"
+	STATISTICS_RANGE_MONTH = "30d"
+	STATISTICS_RANGE_YEAR  = "1y"
+)
+
+// StatisticsService handles communication with the job aggregation endpoints of the ThreatMatrix API,
+// the ones the dashboard of ThreatMatrix is drawn from.
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs
+type StatisticsService struct {
+	client *ThreatMatrixClient
+}
+
+// StatisticsOptions represents the fields used to configure the calls of the StatisticsService.
+type StatisticsOptions struct {
+	// Range is how far back the jobs are aggregated, e.g. STATISTICS_RANGE_WEEK. The server default is used when empty.
+	Range string
+}
+
+// StatisticsPoint represents the counts of a period of a StatisticsSeries, keyed by series.
+type StatisticsPoint struct {
+	Date   time.Time      `json:"date"`
+	Counts map[string]int `json:"counts"`
+}
+
+// Total returns the sum of the counts of the point.
+func (point *StatisticsPoint) Total() int {
+	total := 0
+	for _, count := range point.Counts {
+		total += count
+	}
+	return total
+}
+
+// StatisticsSeries represents a time series of job counts, e.g. the jobs of every status per day.
+type StatisticsSeries struct {
+	// Keys are the series of the points, sorted, e.g. the statuses. The top aggregations list them by rank.
+	Keys   []string          `json:"keys"`
+	Points []StatisticsPoint `json:"points"`
+}
+
+// Totals returns the count of every key over the whole series.
+func (series *StatisticsSeries) Totals() map[string]int {
+	totals := map[string]int{}
+	for _, point := range series.Points {
+		for key, count := range point.Counts {
+			totals[key] += count
+		}
+	}
+	return totals
+}
+
+// Rate returns the share of the counts of the keys among all the counts of the series, between 0 and 1.
+func (series *StatisticsSeries) Rate(keys ...string) float64 {
+	total, matched := 0, 0
+	for key, count := range series.Totals() {
+		total += count
+		if containsValue(keys, key) {
+			matched += count
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(matched) / float64(total)
+}
+
+// JobsPerStatus fetches the jobs of every status per period, their Total being the jobs per period.
+// The success rate is Rate(string(REPORTED_WITHOUT_FAILS)).
+//
+//	Endpoint: GET /api/jobs/aggregate/status
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_aggregate_status_retrieve
+func (statisticsService *StatisticsService) JobsPerStatus(ctx context.Context, options *StatisticsOptions) (*StatisticsSeries, error) {
+	return statisticsService.aggregate(ctx, constants.JOB_AGGREGATE_STATUS_URL, options)
+}
+
+// JobsPerType fetches the file and observable jobs per period.
+//
+//	Endpoint: GET /api/jobs/aggregate/type
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_aggregate_type_retrieve
+func (statisticsService *StatisticsService) JobsPerType(ctx context.Context, options *StatisticsOptions) (*StatisticsSeries, error) {
+	return statisticsService.aggregate(ctx, constants.JOB_AGGREGATE_TYPE_URL, options)
+}
+
+// JobsPerObservableClassification fetches the observable jobs of every classification (ip, url, domain...) per period.
+//
+//	Endpoint: GET /api/jobs/aggregate/observable_classification
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_aggregate_observable_classification_retrieve
+func (statisticsService *StatisticsService) JobsPerObservableClassification(ctx context.Context, options *StatisticsOptions) (*StatisticsSeries, error) {
+	return statisticsService.aggregate(ctx, constants.JOB_AGGREGATE_OBSERVABLE_CLASSIFICATION_URL, options)
+}
+
+// JobsPerFileMimetype fetches the file jobs of every mime type per period.
+//
+//	Endpoint: GET /api/jobs/aggregate/file_mimetype
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_aggregate_file_mimetype_retrieve
+func (statisticsService *StatisticsService) JobsPerFileMimetype(ctx context.Context, options *StatisticsOptions) (*StatisticsSeries, error) {
+	return statisticsService.aggregate(ctx, constants.JOB_AGGREGATE_FILE_MIMETYPE_URL, options)
+}
+
+// TopPlaybooks fetches the jobs of the most used playbooks per period.
+//
+//	Endpoint: GET /api/jobs/aggregate/top_playbook
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_aggregate_top_playbook_retrieve
+func (statisticsService *StatisticsService) TopPlaybooks(ctx context.Context, options *StatisticsOptions) (*StatisticsSeries, error) {
+	return statisticsService.aggregate(ctx, constants.JOB_AGGREGATE_TOP_PLAYBOOK_URL, options)
+}
+
+// TopUsers fetches the jobs of the most active users per period.
+//
+//	Endpoint: GET /api/jobs/aggregate/top_user
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_aggregate_top_user_retrieve
+func (statisticsService *StatisticsService) TopUsers(ctx context.Context, options *StatisticsOptions) (*StatisticsSeries, error) {
+	return statisticsService.aggregate(ctx, constants.JOB_AGGREGATE_TOP_USER_URL, options)
+}
+
+// TopTLPs fetches the jobs of the most used TLPs per period.
+//
+//	Endpoint: GET /api/jobs/aggregate/top_tlp
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/jobs/operation/jobs_aggregate_top_tlp_retrieve
+func (statisticsService *StatisticsService) TopTLPs(ctx context.Context, options *StatisticsOptions) (*StatisticsSeries, error) {
+	return statisticsService.aggregate(ctx, constants.JOB_AGGREGATE_TOP_TLP_URL, options)
+}
+
+// aggregate fetches the aggregation of the route.
+func (statisticsService *StatisticsService) aggregate(ctx context.Context, route string, options *StatisticsOptions) (*StatisticsSeries, error) {
+	requestUrl := statisticsService.client.options.Url + route
+	if options != nil && options.Range != "" {
+		requestUrl += "?" + url.Values{"range": {options.Range}}.Encode()
+	}
+	contentType := "application/json"
+	method := "GET"
+	request, err := statisticsService.client.buildRequest(ctx, method, contentType, nil, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	successResp, err := statisticsService.client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return parseStatisticsSeries(successResp.Data)
+}
+
+// parseStatisticsSeries decodes an aggregation: a list of points with a "date" and a count per key, wrapped
+// as {"values": [keys by rank], "aggregation": [points]} by the top aggregations.
+func parseStatisticsSeries(data []byte) (*StatisticsSeries, error) {
+	top := struct {
+		Values      []string          `json:"values"`
+		Aggregation []json.RawMessage `json:"aggregation"`
+	}{}
+	var rawPoints []json.RawMessage
+	if err := json.Unmarshal(data, &rawPoints); err != nil {
+		if topError := json.Unmarshal(data, &top); topError != nil {
+			return nil, err
+		}
+		rawPoints = top.Aggregation
+	}
+	series := &StatisticsSeries{Keys: top.Values, Points: make([]StatisticsPoint, 0, len(rawPoints))}
+	keys := map[string]bool{}
+	for _, rawPoint := range rawPoints {
+		fields := map[string]json.RawMessage{}
+		if err := json.Unmarshal(rawPoint, &fields); err != nil {
+			return nil, err
+		}
+		point := StatisticsPoint{Counts: map[string]int{}}
+		for key, value := range fields {
+			if key == "date" {
+				if err := json.Unmarshal(value, &point.Date); err != nil {
+					return nil, fmt.Errorf("invalid date of the statistics: %w", err)
+				}
+				continue
+			}
+			// * the fields that are not counts are ignored
+			var count int
+			if json.Unmarshal(value, &count) == nil {
+				point.Counts[key] = count
+				keys[key] = true
+			}
+		}
+		series.Points = append(series.Points, point)
+	}
+	if series.Keys == nil {
+		series.Keys = make([]string, 0, len(keys))
+		for key := range keys {
+			series.Keys = append(series.Keys, key)
+		}
+		sort.Strings(series.Keys)
+	}
+	sort.Slice(series.Points, func(i, j int) bool {
+		return series.Points[i].Date.Before(series.Points[j].Date)
+	})
+	return series, nil
+}
@@ -0,0 +1,189 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// ErrUploadSizeMismatch is returned when a streamed file is not as long as the Size it was submitted with.
+var ErrUploadSizeMismatch = errors.New("gothreatmatrix: the streamed file does not match its size")
+
+// UploadProgress represents how much of a file upload was sent.
+type UploadProgress struct {
+	FileName string
+	// Sent counts the bytes of the file read so far, it starts over when the upload is retried.
+	Sent int64
+	// Total is the size of the file, -1 when unknown.
+	Total int64
+}
+
+// StreamFileAnalysisParams represents the fields needed to analyze a file read from a stream,
+// which is sent as it is read instead of being loaded in memory first.
+type StreamFileAnalysisParams struct {
+	BasicAnalysisParams
+	// Reader is the content of the file. When it is an io.Seeker the upload can be retried,
+	// it is then rewound to where it was on submission.
+	Reader   io.Reader
+	FileName string
+	// Size is the length of the content of Reader. When it is 0 or less the upload is sent
+	// with a chunked transfer encoding, which some proxies do not accept.
+	Size int64
+	// ExtraFields are additional form fields (e.g. original path, source system) sent along with the file.
+	// The fields of the analysis itself, e.g. tlp or file, are rejected with ErrReservedExtraField.
+	ExtraFields map[string]string
+	// OnProgress, when set, is called every time a chunk of the file is sent.
+	OnProgress func(UploadProgress)
+}
+
+// CreateFileAnalysisFromReader lets you analyze a file read from a stream, e.g. a multi-GB sample
+// downloaded from an object storage, without holding it in memory. The upload is not bounded by the Timeout
+// of the client, which would cut it, but by ctx.
+// Unlike CreateFileAnalysis, it neither reuses recent results nor verifies the upload, both needing
+// to read the file twice, and FileTypePlaybooks only apply when Reader is an *os.File.
+//
+//	Endpoint: POST /api/analyze_file
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/analyze_file
+func (client *ThreatMatrixClient) CreateFileAnalysisFromReader(ctx context.Context, streamFileAnalysisParams *StreamFileAnalysisParams) (*AnalysisResponse, error) {
+	requestUrl := client.options.Url + constants.ANALYZE_FILE_URL
+	basicAnalysisParams := streamFileAnalysisParams.BasicAnalysisParams
+	if file, ok := streamFileAnalysisParams.Reader.(*os.File); ok {
+		if err := client.applyFilePlaybook(&basicAnalysisParams, file); err != nil {
+			return nil, err
+		}
+	}
+	fileName := filepath.Base(streamFileAnalysisParams.FileName)
+	if err := client.guardPII(ctx, &basicAnalysisParams, []string{fileName}); err != nil {
+		return nil, err
+	}
+	if err := client.enforceAnalyzerPolicy(ctx, &basicAnalysisParams); err != nil {
+		return nil, err
+	}
+	builder := newAnalysisFormBuilder()
+	if err := builder.writeBasicParams(&basicAnalysisParams); err != nil {
+		return nil, err
+	}
+	if err := builder.writeExtraFields(streamFileAnalysisParams.ExtraFields); err != nil {
+		return nil, err
+	}
+	if err := client.auditSubmission(ctx, constants.ANALYZE_FILE_URL, &basicAnalysisParams, nil, []string{fileName}); err != nil {
+		return nil, err
+	}
+	if err := builder.writeReader("file", fileName, streamFileAnalysisParams.Reader, streamFileAnalysisParams.Size, streamFileAnalysisParams.OnProgress); err != nil {
+		return nil, err
+	}
+	request, err := client.buildUploadRequest(ctx, builder, requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.setIdempotencyKey(request, &basicAnalysisParams); err != nil {
+		return nil, err
+	}
+	analysisResponse := AnalysisResponse{}
+	successResp, err := client.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalError := json.Unmarshal(successResp.Data, &analysisResponse); unmarshalError != nil {
+		return nil, unmarshalError
+	}
+	analysisResponse.Deprecations = client.deprecatedReferences(&basicAnalysisParams)
+	return &analysisResponse, nil
+}
+
+// buildUploadRequest finishes the form of the builder and builds the request streaming it: each file is read while
+// the request is sent, between the bytes of the form around it, the whole body being throttled to UploadBytesPerSecond.
+// The body can be replayed when retried if the readers of the files are io.Seekers.
+// It is a streaming transfer, sent without the Timeout of the client and bounded by ctx only, see withStreaming.
+func (client *ThreatMatrixClient) buildUploadRequest(ctx context.Context, builder *analysisFormBuilder, requestUrl string) (*http.Request, error) {
+	ctx = withStreaming(ctx)
+	contentType, err := builder.close()
+	if err != nil {
+		return nil, err
+	}
+	tail := builder.body.Bytes()
+	contentLength := int64(len(tail))
+	seekable := true
+	for _, file := range builder.files {
+		if contentLength >= 0 && file.size > 0 {
+			contentLength += int64(len(file.head)) + file.size
+		} else {
+			contentLength = -1
+		}
+		seekable = seekable && file.seeker != nil
+	}
+	uploadBody := func() io.Reader {
+		readers := make([]io.Reader, 0, 2*len(builder.files)+1)
+		for _, file := range builder.files {
+			readers = append(readers, bytes.NewReader(file.head), &progressReader{
+				reader:     file.reader,
+				onProgress: file.onProgress,
+				progress:   UploadProgress{FileName: file.fileName, Total: file.size},
+			})
+		}
+		readers = append(readers, bytes.NewReader(tail))
+		return newThrottledReader(ctx, io.MultiReader(readers...), client.options.UploadBytesPerSecond)
+	}
+	request, err := client.buildRequest(ctx, "POST", contentType, uploadBody(), requestUrl)
+	if err != nil {
+		return nil, err
+	}
+	request.ContentLength = contentLength
+	if seekable {
+		request.GetBody = func() (io.ReadCloser, error) {
+			for _, file := range builder.files {
+				if _, err := file.seeker.Seek(file.offset, io.SeekStart); err != nil {
+					return nil, err
+				}
+			}
+			return ioutil.NopCloser(uploadBody()), nil
+		}
+	}
+	return request, nil
+}
+
+// remainingSize returns the length of the file from its current offset, the part of it an upload sends.
+func remainingSize(file *os.File) (int64, error) {
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	return fileInfo.Size() - offset, nil
+}
+
+// progressReader reports the progress of the file it reads, and checks it matches its total.
+type progressReader struct {
+	reader     io.Reader
+	onProgress func(UploadProgress)
+	progress   UploadProgress
+}
+
+// Read implements io.Reader.
+func (progressReader *progressReader) Read(p []byte) (int, error) {
+	n, err := progressReader.reader.Read(p)
+	progressReader.progress.Sent += int64(n)
+	if n > 0 && progressReader.onProgress != nil {
+		progressReader.onProgress(progressReader.progress)
+	}
+	total := progressReader.progress.Total
+	switch {
+	case total > 0 && progressReader.progress.Sent > total:
+		return n, ErrUploadSizeMismatch
+	case total > 0 && err == io.EOF && progressReader.progress.Sent < total:
+		return n, ErrUploadSizeMismatch
+	}
+	return n, err
+}
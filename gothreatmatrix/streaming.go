@@ -0,0 +1,41 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"net/http"
+)
+
+// streamingKey is the context key marking the requests of streaming transfers.
+type streamingKey struct{}
+
+// withStreaming marks the requests sent with ctx as streaming transfers, e.g. the download of a multi-GB sample:
+// they are sent without the Timeout of the http.Client, which covers reading the whole body and would cut them,
+// and are only bounded by ctx, a WithTimeout of the call included.
+func withStreaming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamingKey{}, true)
+}
+
+// isStreaming reports whether the request is a streaming transfer, see withStreaming.
+func isStreaming(request *http.Request) bool {
+	streaming, _ := request.Context().Value(streamingKey{}).(bool)
+	return streaming
+}
+
+// streamingClient returns an http.Client like the one of the client, its Transport, redirects and cookies included,
+// but without Timeout.
+func (client *ThreatMatrixClient) streamingClient() *http.Client {
+	if client.client.Timeout == 0 {
+		return client.client
+	}
+	streamingClient := *client.client
+	streamingClient.Timeout = 0
+	return &streamingClient
+}
+
+// httpClientFor returns the http.Client sending the request: the streaming client for the streaming transfers.
+func (client *ThreatMatrixClient) httpClientFor(request *http.Request) *http.Client {
+	if isStreaming(request) {
+		return client.streamingClient()
+	}
+	return client.client
+}
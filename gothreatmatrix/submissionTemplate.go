@@ -0,0 +1,130 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// SubmissionTemplate represents reusable defaults applied to analyses, so pipelines can declare how each kind
+// of submission is made instead of building the params by hand.
+//
+// Templates are stored as YAML, e.g.
+//
+//	name: phishing-triage
+//	tlp: AMBER
+//	tags: [phishing, triage]
+//	playbook: FREE_TO_USE_ANALYZERS
+//	runtime_configuration:
+//	  analyzers:
+//	    Doc_Info:
+//	      additional_passwords_to_check: [infected]
+//	analyzers_denied:
+//	  - VirusTotal_v3_Get_File
+type SubmissionTemplate struct {
+	Name string `json:"name"`
+	// Tlp is used when the analysis has no TLP.
+	Tlp TLP `json:"tlp,omitempty"`
+	// Tags are added to the tags of the analysis.
+	Tags []string `json:"tags,omitempty"`
+	// Playbook is requested when the analysis requests neither a playbook nor analyzers.
+	Playbook string `json:"playbook,omitempty"`
+	// RuntimeConfiguration is merged with the one of the analysis, whose own keys win.
+	RuntimeConfiguration map[string]interface{} `json:"runtime_configuration,omitempty"`
+	// AnalyzersAllowed and AnalyzersDenied restrict the analyzers, the same way the client options of the same name do.
+	AnalyzersAllowed []string `json:"analyzers_allowed,omitempty"`
+	AnalyzersDenied  []string `json:"analyzers_denied,omitempty"`
+}
+
+// LoadSubmissionTemplate reads the template stored at path, as YAML or JSON.
+func LoadSubmissionTemplate(path string) (*SubmissionTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	template := SubmissionTemplate{}
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("{")) {
+		err = json.Unmarshal(data, &template)
+	} else {
+		err = unmarshalYaml(data, &template)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// Save writes the template to path as YAML.
+// The file is replaced atomically, so a pipeline loading it never sees a partial template.
+func (template *SubmissionTemplate) Save(path string) error {
+	data, err := marshalYaml(template)
+	if err != nil {
+		return err
+	}
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), path)
+}
+
+// ApplyTemplate fills the analysis with the defaults of the template and restricts its analyzers.
+// When the analysis lets the server pick the analyzers and the template restricts them, the analyzer
+// catalog is fetched so the allowed analyzers can be requested explicitly, see AnalyzersAllowed.
+// The client's own analyzer policy still applies when the analysis is submitted.
+func (client *ThreatMatrixClient) ApplyTemplate(ctx context.Context, template *SubmissionTemplate, basicAnalysisParams *BasicAnalysisParams) error {
+	if basicAnalysisParams.Tlp == TLP(0) {
+		basicAnalysisParams.Tlp = template.Tlp
+	}
+	for _, tag := range template.Tags {
+		if !containsValue(basicAnalysisParams.TagsLabels, tag) {
+			basicAnalysisParams.TagsLabels = append(basicAnalysisParams.TagsLabels, tag)
+		}
+	}
+	if basicAnalysisParams.PlaybookRequested == "" && len(basicAnalysisParams.AnalyzersRequested) == 0 {
+		basicAnalysisParams.PlaybookRequested = template.Playbook
+	}
+	basicAnalysisParams.RuntimeConfiguration = mergeRuntimeConfiguration(template.RuntimeConfiguration, basicAnalysisParams.RuntimeConfiguration)
+	return client.applyAnalyzerPolicy(ctx, basicAnalysisParams, template.AnalyzersAllowed, template.AnalyzersDenied)
+}
+
+// containsValue reports whether the values contain value.
+func containsValue(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeRuntimeConfiguration returns a deep merge of the two runtime configurations, the overrides winning.
+func mergeRuntimeConfiguration(defaults map[string]interface{}, overrides map[string]interface{}) map[string]interface{} {
+	if len(defaults) == 0 {
+		return overrides
+	}
+	merged := make(map[string]interface{}, len(defaults)+len(overrides))
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		defaultSection, defaultIsSection := merged[key].(map[string]interface{})
+		overrideSection, overrideIsSection := value.(map[string]interface{})
+		if defaultIsSection && overrideIsSection {
+			merged[key] = mergeRuntimeConfiguration(defaultSection, overrideSection)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
@@ -0,0 +1,47 @@
+package gothreatmatrix
+
+import "strings"
+
+// JobSummary represents the key facts of a job, post-processed from its reports.
+type JobSummary struct {
+	JobID                    int             `json:"job_id"`
+	Status                   string          `json:"status"`
+	ObservableName           string          `json:"observable_name,omitempty"`
+	ObservableClassification string          `json:"observable_classification,omitempty"`
+	FileName                 string          `json:"file_name,omitempty"`
+	Md5                      string          `json:"md5"`
+	Tlp                      string          `json:"tlp"`
+	Tags                     []string        `json:"tags"`
+	AnalyzersSucceeded       []string        `json:"analyzers_succeeded"`
+	AnalyzersFailed          []string        `json:"analyzers_failed"`
+	MalwareFamilies          []MalwareFamily `json:"malware_families"`
+}
+
+// Summary post-processes the job into a JobSummary.
+func (job *Job) Summary() *JobSummary {
+	summary := &JobSummary{
+		JobID:                    job.ID,
+		Status:                   job.Status,
+		ObservableName:           job.ObservableName,
+		ObservableClassification: job.ObservableClassification,
+		FileName:                 job.FileName,
+		Md5:                      job.Md5,
+		Tlp:                      job.Tlp,
+		Tags:                     []string{},
+		AnalyzersSucceeded:       []string{},
+		AnalyzersFailed:          []string{},
+		MalwareFamilies:          ExtractMalwareFamilies(job),
+	}
+	for _, tag := range job.Tags {
+		summary.Tags = append(summary.Tags, tag.Label)
+	}
+	for _, report := range job.AnalyzerReports {
+		switch strings.ToUpper(report.Status) {
+		case "SUCCESS":
+			summary.AnalyzersSucceeded = append(summary.AnalyzersSucceeded, report.Name)
+		case "FAILED", "KILLED":
+			summary.AnalyzersFailed = append(summary.AnalyzersFailed, report.Name)
+		}
+	}
+	return summary
+}
@@ -0,0 +1,229 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Attribute keys of the telemetry of the API calls, the OpenTelemetry HTTP semantic conventions where one exists.
+const (
+	TELEMETRY_HTTP_METHOD      = "http.request.method"
+	TELEMETRY_HTTP_ROUTE       = "http.route"
+	TELEMETRY_HTTP_STATUS_CODE = "http.response.status_code"
+	TELEMETRY_JOB_ID           = "threatmatrix.job_id"
+	// TELEMETRY_PLUGIN_PREFIX is followed by the type of the plugin the call is about, e.g. "threatmatrix.analyzer".
+	TELEMETRY_PLUGIN_PREFIX = "threatmatrix."
+)
+
+// telemetryPluginSegments are the path segments followed by the name of a plugin.
+var telemetryPluginSegments = map[string]bool{
+	"analyzer":   true,
+	"connector":  true,
+	"visualizer": true,
+	"pivot":      true,
+	"ingestor":   true,
+	"playbook":   true,
+}
+
+// Telemetry receives the traces and metrics of the API calls of a client with EnableTelemetry, retries included
+// in the call. It is meant to be backed by OpenTelemetry, e.g. StartSpan starting a span of an otel Tracer and
+// RecordRequest feeding a latency histogram and an error counter of an otel Meter, which keeps the SDK free of
+// the dependency. RequestMetrics is a ready-made in-memory one.
+type Telemetry interface {
+	// StartSpan starts the span of a call, named after its method and route, e.g. "GET /api/jobs/{id}".
+	// The returned context is the one the request is sent with, so a Middleware can propagate the trace.
+	StartSpan(ctx context.Context, name string, attributes map[string]interface{}) (context.Context, TelemetrySpan)
+	// RecordRequest records the metrics of a finished call.
+	RecordRequest(ctx context.Context, record RequestRecord)
+}
+
+// TelemetrySpan represents the span of a call started by Telemetry.StartSpan.
+type TelemetrySpan interface {
+	// End ends the span, adding the attributes known once the call finished. err is the error of the call, nil on success.
+	End(attributes map[string]interface{}, err error)
+}
+
+// RequestRecord represents a finished API call.
+type RequestRecord struct {
+	Method string
+	// Route is the path of the call with its IDs and plugin names replaced, e.g. /api/jobs/{id}/analyzer/{name}/kill.
+	Route string
+	// StatusCode is 0 when no response was received.
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+	// Retries is the number of times the request was sent again, see RetryOptions.
+	Retries int
+	// Attributes are the attributes of the span of the call.
+	Attributes map[string]interface{}
+}
+
+// telemetryAttributes returns the route of the path and the attributes of a call to it:
+// method, route, job ID and plugin name.
+func telemetryAttributes(method string, path string) (string, map[string]interface{}) {
+	attributes := map[string]interface{}{TELEMETRY_HTTP_METHOD: method}
+	segments := strings.Split(path, "/")
+	for index, segment := range segments {
+		if segment == "" || index == 0 {
+			continue
+		}
+		previous := segments[index-1]
+		if isNumeric(segment) {
+			if previous == "jobs" {
+				attributes[TELEMETRY_JOB_ID] = segment
+			}
+			segments[index] = "{id}"
+		} else if telemetryPluginSegments[previous] {
+			attributes[TELEMETRY_PLUGIN_PREFIX+previous] = segment
+			segments[index] = "{name}"
+		}
+	}
+	route := strings.Join(segments, "/")
+	attributes[TELEMETRY_HTTP_ROUTE] = route
+	return route, attributes
+}
+
+// isNumeric reports whether the path segment is made of digits only.
+func isNumeric(segment string) bool {
+	for _, character := range segment {
+		if character < '0' || character > '9' {
+			return false
+		}
+	}
+	return segment != ""
+}
+
+// telemetry returns the Telemetry of the client, nil when it is not enabled.
+func (client *ThreatMatrixClient) telemetry() Telemetry {
+	if !client.options.EnableTelemetry {
+		return nil
+	}
+	return client.options.Telemetry
+}
+
+type retryCounterKey struct{}
+
+// countRetry counts a retry of the call of ctx, when it is instrumented.
+func countRetry(ctx context.Context) {
+	if retries, ok := ctx.Value(retryCounterKey{}).(*int); ok {
+		*retries++
+	}
+}
+
+// instrumentedRequest sends the request inside a span, then records the call.
+func (client *ThreatMatrixClient) instrumentedRequest(ctx context.Context, request *http.Request, telemetry Telemetry) (*successResponse, error) {
+	route, attributes := telemetryAttributes(request.Method, request.URL.Path)
+	spanCtx, span := telemetry.StartSpan(ctx, request.Method+" "+route, attributes)
+	retries := 0
+	spanCtx = context.WithValue(spanCtx, retryCounterKey{}, &retries)
+	start := time.Now()
+	successResp, err := client.sendWithRetries(spanCtx, request.WithContext(spanCtx))
+	record := RequestRecord{
+		Method:     request.Method,
+		Route:      route,
+		Duration:   time.Since(start),
+		Err:        err,
+		Retries:    retries,
+		Attributes: attributes,
+	}
+	var threatMatrixError *ThreatMatrixError
+	if successResp != nil {
+		record.StatusCode = successResp.StatusCode
+	} else if errors.As(err, &threatMatrixError) {
+		record.StatusCode = threatMatrixError.StatusCode
+	}
+	endAttributes := map[string]interface{}{}
+	if record.StatusCode != 0 {
+		endAttributes[TELEMETRY_HTTP_STATUS_CODE] = record.StatusCode
+	}
+	span.End(endAttributes, err)
+	telemetry.RecordRequest(ctx, record)
+	return successResp, err
+}
+
+// RouteMetrics represents the calls made to a route, see RequestMetrics.
+type RouteMetrics struct {
+	Count           int
+	Errors          int
+	TotalDuration   time.Duration
+	MaxDuration     time.Duration
+	StatusCodeCount map[int]int
+}
+
+// RequestMetrics is a Telemetry counting the calls, errors and latency of every route in memory, without tracing.
+type RequestMetrics struct {
+	mutex  sync.Mutex
+	routes map[string]*RouteMetrics
+}
+
+// NewRequestMetrics returns an empty RequestMetrics.
+func NewRequestMetrics() *RequestMetrics {
+	return &RequestMetrics{routes: map[string]*RouteMetrics{}}
+}
+
+// noopSpan is the span of a Telemetry that does not trace.
+type noopSpan struct{}
+
+// End implements TelemetrySpan.
+func (noopSpan) End(attributes map[string]interface{}, err error) {}
+
+// StartSpan implements Telemetry, RequestMetrics does not trace.
+func (metrics *RequestMetrics) StartSpan(ctx context.Context, name string, attributes map[string]interface{}) (context.Context, TelemetrySpan) {
+	return ctx, noopSpan{}
+}
+
+// RecordRequest implements Telemetry.
+func (metrics *RequestMetrics) RecordRequest(ctx context.Context, record RequestRecord) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	key := record.Method + " " + record.Route
+	routeMetrics, ok := metrics.routes[key]
+	if !ok {
+		routeMetrics = &RouteMetrics{StatusCodeCount: map[int]int{}}
+		metrics.routes[key] = routeMetrics
+	}
+	routeMetrics.Count++
+	if record.Err != nil {
+		routeMetrics.Errors++
+	}
+	routeMetrics.TotalDuration += record.Duration
+	if record.Duration > routeMetrics.MaxDuration {
+		routeMetrics.MaxDuration = record.Duration
+	}
+	if record.StatusCode != 0 {
+		routeMetrics.StatusCodeCount[record.StatusCode]++
+	}
+}
+
+// Routes returns the method and route of every call recorded so far, e.g. "GET /api/jobs/{id}", sorted.
+func (metrics *RequestMetrics) Routes() []string {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	routes := make([]string, 0, len(metrics.routes))
+	for route := range metrics.routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// Route returns a copy of the metrics of the method and route, ok is false when no call was recorded for them.
+func (metrics *RequestMetrics) Route(route string) (routeMetrics RouteMetrics, ok bool) {
+	metrics.mutex.Lock()
+	defer metrics.mutex.Unlock()
+	recorded, ok := metrics.routes[route]
+	if !ok {
+		return RouteMetrics{}, false
+	}
+	routeMetrics = *recorded
+	routeMetrics.StatusCodeCount = make(map[int]int, len(recorded.StatusCodeCount))
+	for statusCode, count := range recorded.StatusCodeCount {
+		routeMetrics.StatusCodeCount[statusCode] = count
+	}
+	return routeMetrics, true
+}
@@ -0,0 +1,58 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// throttledReader limits the rate at which the wrapped reader can be consumed.
+type throttledReader struct {
+	ctx            context.Context
+	reader         io.Reader
+	bytesPerSecond int64
+	start          time.Time
+	total          int64
+}
+
+// newThrottledReader wraps the reader so it is read at most bytesPerSecond bytes per second.
+// A bytesPerSecond of 0 leaves the reader untouched.
+func newThrottledReader(ctx context.Context, reader io.Reader, bytesPerSecond int64) io.Reader {
+	if bytesPerSecond <= 0 {
+		return reader
+	}
+	return &throttledReader{
+		ctx:            ctx,
+		reader:         reader,
+		bytesPerSecond: bytesPerSecond,
+	}
+}
+
+// Read implements io.Reader, pausing whenever the reader is ahead of the allowed rate.
+func (throttled *throttledReader) Read(p []byte) (int, error) {
+	if throttled.start.IsZero() {
+		throttled.start = time.Now()
+	}
+	// reading in slices of a tenth of a second keeps the rate smooth
+	chunkSize := throttled.bytesPerSecond / 10
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+	if int64(len(p)) > chunkSize {
+		p = p[:chunkSize]
+	}
+	n, err := throttled.reader.Read(p)
+	throttled.total += int64(n)
+
+	expected := time.Duration(float64(throttled.total) / float64(throttled.bytesPerSecond) * float64(time.Second))
+	if wait := expected - time.Since(throttled.start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-throttled.ctx.Done():
+			return n, throttled.ctx.Err()
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,85 @@
+package gothreatmatrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTLPViolation is returned, wrapped with the analyzers, when an analysis at AMBER or RED requests analyzers
+// calling an external service or leaking info and the TLPEnforcement rejects it.
+var ErrTLPViolation = errors.New("gothreatmatrix: the analysis requests analyzers its TLP forbids")
+
+// TLPEnforcement represents what the client does with the analyzers an analysis at AMBER or RED must not run:
+// the ones whose AnalyzerConfig tells they call an ExternalService or they LeaksInfo.
+type TLPEnforcement int
+
+// Values of the TLPEnforcement enum.
+const (
+	// TLP_ENFORCEMENT_OFF leaves the TLP to ThreatMatrix.
+	TLP_ENFORCEMENT_OFF TLPEnforcement = iota
+	// TLP_ENFORCEMENT_EXCLUDE removes the forbidden analyzers from the analysis.
+	TLP_ENFORCEMENT_EXCLUDE
+	// TLP_ENFORCEMENT_REJECT refuses to submit the analysis, with an ErrTLPViolation naming the forbidden analyzers.
+	TLP_ENFORCEMENT_REJECT
+)
+
+// Overriding the String method to get the string representation of the TLPEnforcement enum
+func (tlpEnforcement TLPEnforcement) String() string {
+	switch tlpEnforcement {
+	case TLP_ENFORCEMENT_EXCLUDE:
+		return "EXCLUDE"
+	case TLP_ENFORCEMENT_REJECT:
+		return "REJECT"
+	}
+	return "OFF"
+}
+
+// leaksData reports whether the analyzer must not run on an analysis at AMBER or RED.
+func (analyzerConfig *AnalyzerConfig) leaksData() bool {
+	return analyzerConfig.ExternalService || analyzerConfig.LeaksInfo
+}
+
+// applyTLPEnforcement applies the TLPEnforcement of the client to the analysis. At AMBER or RED, the analyzers
+// calling an external service or leaking info are removed, or the analysis is rejected; the analyzers missing from
+// the catalog are left to ThreatMatrix. As with the analyzer policy, an analysis letting the server pick its
+// analyzers gets the enabled analyzers it may run requested explicitly instead.
+func (client *ThreatMatrixClient) applyTLPEnforcement(ctx context.Context, basicAnalysisParams *BasicAnalysisParams) error {
+	enforcement := client.options.TLPEnforcement
+	if enforcement == TLP_ENFORCEMENT_OFF || basicAnalysisParams.Tlp < AMBER {
+		return nil
+	}
+	requested, err := client.requestedAnalyzers(ctx, basicAnalysisParams)
+	if err != nil {
+		return err
+	}
+	analyzerConfigs, err := client.AnalyzerService.GetConfigs(ctx)
+	if err != nil {
+		return err
+	}
+	leaking := map[string]bool{}
+	for index := range *analyzerConfigs {
+		if analyzerConfig := &(*analyzerConfigs)[index]; analyzerConfig.leaksData() {
+			leaking[analyzerConfig.Name] = true
+		}
+	}
+	allowedAnalyzers := []string{}
+	forbiddenAnalyzers := []string{}
+	for _, analyzerName := range requested {
+		if leaking[analyzerName] {
+			forbiddenAnalyzers = append(forbiddenAnalyzers, analyzerName)
+		} else {
+			allowedAnalyzers = append(allowedAnalyzers, analyzerName)
+		}
+	}
+	if len(forbiddenAnalyzers) == 0 {
+		return nil
+	}
+	if enforcement == TLP_ENFORCEMENT_REJECT || len(allowedAnalyzers) == 0 {
+		return fmt.Errorf("%w: %s call an external service or leak info at TLP %s", ErrTLPViolation, strings.Join(forbiddenAnalyzers, ", "), basicAnalysisParams.Tlp)
+	}
+	basicAnalysisParams.AnalyzersRequested = allowedAnalyzers
+	basicAnalysisParams.RuntimeConfiguration = filterRuntimeConfiguration(basicAnalysisParams.RuntimeConfiguration, leaking)
+	return nil
+}
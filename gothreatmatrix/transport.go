@@ -0,0 +1,218 @@
+package gothreatmatrix
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrCertificatePinMismatch is returned when none of the certificates of the server matches PinnedSPKIHashes.
+var ErrCertificatePinMismatch = errors.New("gothreatmatrix: no server certificate matches the pinned SPKI hashes")
+
+// tlsVersions maps the accepted MinTLSVersion values to their crypto/tls constants.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// SPKIHash returns the base64 encoded SHA-256 hash of the certificate's SubjectPublicKeyInfo,
+// the format expected by PinnedSPKIHashes.
+func SPKIHash(certificate *x509.Certificate) string {
+	hash := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// TLSConfig builds the TLS configuration described by the options: the Certificate CA bundle,
+// InsecureSkipVerify, MinTLSVersion, CipherSuites and PinnedSPKIHashes.
+// Every connection the client opens to ThreatMatrix uses it, so it is the one to use for any other
+// connection to the instance as well.
+func (options *ThreatMatrixClientOptions) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: options.InsecureSkipVerify}
+	if provider, ok := options.AuthProvider.(clientCertificateProvider); ok {
+		tlsConfig.Certificates = provider.clientCertificates()
+	}
+	if options.Certificate != "" {
+		pemBytes, err := os.ReadFile(options.Certificate)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the certificate %s: %w", options.Certificate, err)
+		}
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("no PEM certificate found in %s", options.Certificate)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	if options.MinTLSVersion != "" {
+		version, ok := tlsVersions[options.MinTLSVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown TLS version %q, expected one of 1.0, 1.1, 1.2, 1.3", options.MinTLSVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+	if len(options.CipherSuites) > 0 {
+		cipherSuites := map[string]uint16{}
+		for _, cipherSuite := range tls.CipherSuites() {
+			cipherSuites[cipherSuite.Name] = cipherSuite.ID
+		}
+		for _, name := range options.CipherSuites {
+			id, ok := cipherSuites[strings.TrimSpace(name)]
+			if !ok {
+				return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+			}
+			tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+		}
+	}
+	if len(options.PinnedSPKIHashes) > 0 {
+		pins := map[string]bool{}
+		for _, pin := range options.PinnedSPKIHashes {
+			pins[strings.TrimSpace(pin)] = true
+		}
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			certificates := state.PeerCertificates
+			for _, chain := range state.VerifiedChains {
+				certificates = append(certificates, chain...)
+			}
+			for _, certificate := range certificates {
+				if pins[SPKIHash(certificate)] {
+					return nil
+				}
+			}
+			return ErrCertificatePinMismatch
+		}
+	}
+	return tlsConfig, nil
+}
+
+// PROXY_DIRECT is the ProxyRules value that sends the matching hosts without a proxy.
+const PROXY_DIRECT = "direct"
+
+// parseProxyUrl validates a proxy URL of the http, https, socks5 or socks5h scheme.
+func parseProxyUrl(proxyUrl string) (*url.URL, error) {
+	parsedUrl, err := url.Parse(proxyUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", proxyUrl, err)
+	}
+	switch parsedUrl.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: the scheme must be http, https, socks5 or socks5h", proxyUrl)
+	}
+	if parsedUrl.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", proxyUrl)
+	}
+	return parsedUrl, nil
+}
+
+// matchesProxyHost reports whether the lower-cased host matches the rule: either the same host or, for a "*.example.com" rule,
+// any of its subdomains.
+func matchesProxyHost(rule string, host string) bool {
+	if strings.HasPrefix(rule, "*.") {
+		return strings.HasSuffix(host, rule[1:])
+	}
+	return host == rule
+}
+
+// proxyFunc returns the Proxy function of the transport described by ProxyUrl and ProxyRules.
+// Without either, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used.
+func (options *ThreatMatrixClientOptions) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if options.ProxyUrl == "" && len(options.ProxyRules) == 0 {
+		return http.ProxyFromEnvironment, nil
+	}
+	var defaultProxy *url.URL
+	if options.ProxyUrl != "" {
+		parsedUrl, err := parseProxyUrl(options.ProxyUrl)
+		if err != nil {
+			return nil, err
+		}
+		defaultProxy = parsedUrl
+	}
+	rules := map[string]*url.URL{}
+	for host, proxyUrl := range options.ProxyRules {
+		host = strings.ToLower(host)
+		if strings.EqualFold(proxyUrl, PROXY_DIRECT) {
+			rules[host] = nil
+			continue
+		}
+		parsedUrl, err := parseProxyUrl(proxyUrl)
+		if err != nil {
+			return nil, err
+		}
+		rules[host] = parsedUrl
+	}
+	return func(request *http.Request) (*url.URL, error) {
+		host := strings.ToLower(request.URL.Hostname())
+		// * an exact host wins over a wildcard one
+		if proxyUrl, ok := rules[host]; ok {
+			return proxyUrl, nil
+		}
+		// * the longest matching wildcard wins, so the choice does not depend on the map order
+		matchedRule := ""
+		for rule := range rules {
+			if len(rule) > len(matchedRule) && matchesProxyHost(rule, host) {
+				matchedRule = rule
+			}
+		}
+		if matchedRule != "" {
+			return rules[matchedRule], nil
+		}
+		return defaultProxy, nil
+	}, nil
+}
+
+// failingTransport fails every request, it is used when the TLS or proxy options are invalid so that
+// the client never falls back to a weaker transport.
+type failingTransport struct {
+	err error
+}
+
+// RoundTrip implements http.RoundTripper.
+func (transport *failingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Body != nil {
+		request.Body.Close()
+	}
+	return nil, transport.err
+}
+
+// newTransport returns the transport of the http.Client built by NewThreatMatrixClient.
+func newTransport(options *ThreatMatrixClientOptions) http.RoundTripper {
+	if options.Transport != nil {
+		return options.Transport
+	}
+	tlsConfig, err := options.TLSConfig()
+	if err != nil {
+		return &failingTransport{err: err}
+	}
+	proxy, err := options.proxyFunc()
+	if err != nil {
+		return &failingTransport{err: err}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.Proxy = proxy
+	transport.DisableCompression = options.DisableCompression
+	transport.MaxIdleConnsPerHost = options.MaxIdleConnsPerHost
+	if transport.MaxIdleConnsPerHost <= 0 {
+		transport.MaxIdleConnsPerHost = options.MaxConcurrentRequests
+	}
+	if transport.MaxIdleConns > 0 && transport.MaxIdleConns < transport.MaxIdleConnsPerHost {
+		transport.MaxIdleConns = transport.MaxIdleConnsPerHost
+	}
+	transport.MaxConnsPerHost = options.MaxConnsPerHost
+	if options.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = time.Duration(options.IdleConnTimeout) * time.Second
+	}
+	return transport
+}
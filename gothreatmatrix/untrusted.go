@@ -0,0 +1,62 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+)
+
+// Analyzer reports and parameters are produced by third party services and are effectively attacker-influenced data:
+// a single out of range number in one report must not make the whole job or configuration list undecodable.
+
+// decodeUntrustedJson decodes any free-form JSON value like json.Unmarshal into an interface{} would,
+// except that numbers which do not fit in a float64 are kept as a json.Number instead of failing the decoding.
+// A missing value gives nil.
+func decodeUntrustedJson(data json.RawMessage) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return normalizeNumbers(value), nil
+}
+
+// decodeUntrustedObject decodes a free-form JSON object through the same rules as decodeUntrustedJson.
+// A null or missing value gives a nil map.
+func decodeUntrustedObject(data json.RawMessage) (map[string]interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	normalizeNumbers(object)
+	return object, nil
+}
+
+func normalizeNumbers(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case json.Number:
+		number, err := typedValue.Float64()
+		if err != nil || math.IsInf(number, 0) {
+			return typedValue
+		}
+		return number
+	case map[string]interface{}:
+		for key, fieldValue := range typedValue {
+			typedValue[key] = normalizeNumbers(fieldValue)
+		}
+	case []interface{}:
+		for index, item := range typedValue {
+			typedValue[index] = normalizeNumbers(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,108 @@
+package gothreatmatrix
+
+import "strings"
+
+// Verdict represents how dangerous an observable or a file is considered.
+type Verdict int
+
+// Values of the Verdict enum, from the least to the most dangerous.
+const (
+	UNKNOWN Verdict = iota
+	BENIGN
+	SUSPICIOUS
+	MALICIOUS
+)
+
+// Overriding the String method to get the string representation of the Verdict enum
+func (verdict Verdict) String() string {
+	switch verdict {
+	case BENIGN:
+		return "benign"
+	case SUSPICIOUS:
+		return "suspicious"
+	case MALICIOUS:
+		return "malicious"
+	}
+	return "unknown"
+}
+
+// Verdict returns the verdict of the final evaluation of the analysis engine, UNKNOWN when the job has none,
+// e.g. to start ApplyVerdictRules from.
+func (job *Job) Verdict() Verdict {
+	if job.DataModel == nil {
+		return UNKNOWN
+	}
+	return job.DataModel.Evaluation.Verdict()
+}
+
+// VerdictRule adjusts the verdict given to a job, e.g. to account for evidence the scoring did not weigh.
+// It returns the verdict unchanged when it does not apply.
+type VerdictRule func(job *Job, verdict Verdict) Verdict
+
+// ApplyVerdictRules runs the rules on the verdict of the job, in order, each one adjusting the result of the previous.
+func ApplyVerdictRules(job *Job, verdict Verdict, rules ...VerdictRule) Verdict {
+	for _, rule := range rules {
+		verdict = rule(job, verdict)
+	}
+	return verdict
+}
+
+// NoiseClassification represents what a noise-classification analyzer (GreyNoise) reported about an IP.
+type NoiseClassification struct {
+	Analyzer string
+	// Noise is true for IPs seen scanning the internet, Riot for the IPs of common business services.
+	Noise bool
+	Riot  bool
+	// Classification is "benign", "malicious" or "unknown".
+	Classification string
+	// Name is the actor or service behind the IP, e.g. "Shodan.io".
+	Name string
+}
+
+// IsBenign reports whether the IP is a known benign scanner or business service.
+func (noiseClassification *NoiseClassification) IsBenign() bool {
+	return noiseClassification.Riot || noiseClassification.Classification == "benign"
+}
+
+// ExtractNoiseClassification returns the noise classification of the GreyNoise reports of the job, nil when there is none.
+func ExtractNoiseClassification(job *Job) *NoiseClassification {
+	for index := range job.AnalyzerReports {
+		report := &job.AnalyzerReports[index]
+		if !strings.HasPrefix(strings.ToLower(report.Name), "greynoise") || report.Report == nil {
+			continue
+		}
+		noise, noiseOk := report.Report["noise"].(bool)
+		seen, seenOk := report.Report["seen"].(bool)
+		riot, riotOk := report.Report["riot"].(bool)
+		classification := strings.ToLower(geoString(report.Report, "classification"))
+		if !noiseOk && !seenOk && !riotOk && classification == "" {
+			continue
+		}
+		name := geoString(report.Report, "name")
+		if name == "" {
+			name = geoString(report.Report, "actor")
+		}
+		return &NoiseClassification{
+			Analyzer:       report.Name,
+			Noise:          noise || seen,
+			Riot:           riot,
+			Classification: classification,
+			Name:           name,
+		}
+	}
+	return nil
+}
+
+// BENIGN_NOISE_RULE downgrades by one level the verdict of an IP that a noise-classification analyzer
+// reports as a benign internet scanner or a common business service, a frequent source of false positives
+// in automated pipelines. A malicious GreyNoise classification always wins, the verdict is then kept.
+var BENIGN_NOISE_RULE VerdictRule = func(job *Job, verdict Verdict) Verdict {
+	if job.ObservableClassification != "ip" || verdict <= BENIGN {
+		return verdict
+	}
+	noiseClassification := ExtractNoiseClassification(job)
+	if noiseClassification == nil || noiseClassification.Classification == "malicious" || !noiseClassification.IsBenign() {
+		return verdict
+	}
+	return verdict - 1
+}
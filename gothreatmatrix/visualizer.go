@@ -0,0 +1,46 @@
+package gothreatmatrix
+
+import (
+	"context"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+)
+
+// VisualizerConfig represents how a visualizer is configured in ThreatMatrix.
+//
+// ThreatMatrix docs: https://threatmatrix.readthedocs.io/en/latest/Usage.html#visualizers
+type VisualizerConfig struct {
+	BaseConfigurationType
+	// Playbooks are the playbooks whose jobs the visualizer renders.
+	Playbooks []string `json:"playbooks"`
+}
+
+// VisualizerService handles communication with visualizer related methods of the ThreatMatrix API.
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/visualizer
+type VisualizerService struct {
+	client *ThreatMatrixClient
+}
+
+// GetConfigs lists down every visualizer configuration in your ThreatMatrix instance.
+//
+//	Endpoint: GET /api/get_visualizer_configs
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/get_visualizer_configs
+func (visualizerService *VisualizerService) GetConfigs(ctx context.Context) (*[]VisualizerConfig, error) {
+	visualizerConfigurationResponse, err := fetchPluginConfigs[VisualizerConfig](ctx, visualizerService.client, constants.VISUALIZER_CONFIG_URL)
+	if err != nil {
+		return nil, visualizerService.client.featureError(FEATURE_VISUALIZERS, err)
+	}
+	return sortPluginConfigs(visualizerConfigurationResponse), nil
+}
+
+// HealthCheck checks if the specified visualizer is up and running
+//
+//	Endpoint: GET /api/visualizer/{NameOfVisualizer}/healthcheck
+//
+// ThreatMatrix REST API docs: https://threatmatrix.readthedocs.io/en/latest/Redoc.html#tag/visualizer/operation/visualizer_healthcheck_retrieve
+func (visualizerService *VisualizerService) HealthCheck(ctx context.Context, visualizerName string) (bool, error) {
+	status, err := pluginHealthCheck(ctx, visualizerService.client, constants.VISUALIZER_HEALTHCHECK_URL, visualizerName)
+	return status, visualizerService.client.featureError(FEATURE_VISUALIZERS, err)
+}
@@ -0,0 +1,203 @@
+package gothreatmatrix
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// websocketGUID is appended to the key of the handshake to compute its accept value, see RFC 6455 section 1.3.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Opcodes of the websocket frames.
+const (
+	websocketContinuation = 0x0
+	websocketText         = 0x1
+	websocketBinary       = 0x2
+	websocketClose        = 0x8
+	websocketPing         = 0x9
+	websocketPong         = 0xa
+)
+
+// MAX_WEBSOCKET_MESSAGE_SIZE bounds the messages read from a websocket, a job with all its reports included.
+const MAX_WEBSOCKET_MESSAGE_SIZE = 64 << 20
+
+// ErrWebsocketProtocol is returned when the server breaks the websocket protocol.
+var ErrWebsocketProtocol = errors.New("gothreatmatrix: websocket protocol error")
+
+// websocketConn is the client side of a websocket, reading the text and binary messages of the server.
+// Its reads must not be concurrent, its writes can be.
+type websocketConn struct {
+	conn       io.ReadWriteCloser
+	reader     *bufio.Reader
+	writeMutex sync.Mutex
+	closeOnce  sync.Once
+	closed     chan struct{}
+}
+
+// dialWebsocket opens a websocket to the route of the instance, authenticated like the other requests.
+// The handshake is sent through do like the other requests, as a streaming transfer so that the Timeout of the
+// client does not cut the connection, and the connection holds its slot of the ConnectionBudget until closed.
+// A refused handshake is returned as a *ThreatMatrixError.
+func (client *ThreatMatrixClient) dialWebsocket(ctx context.Context, route string) (*websocketConn, error) {
+	request, err := client.buildRequest(withStreaming(ctx), "GET", "application/json", nil, client.options.Url+route)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	request.Header.Set("Sec-WebSocket-Key", key)
+	response, err := client.do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		defer response.Body.Close()
+		msgBytes, _ := ioutil.ReadAll(io.LimitReader(response.Body, MAX_WEBSOCKET_MESSAGE_SIZE))
+		return nil, newThreatMatrixError(response.StatusCode, string(msgBytes), response)
+	}
+	conn, ok := response.Body.(io.ReadWriteCloser)
+	if !ok {
+		response.Body.Close()
+		return nil, fmt.Errorf("%w: the upgraded connection is not writable", ErrWebsocketProtocol)
+	}
+	acceptSum := sha1.Sum([]byte(key + websocketGUID))
+	if response.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(acceptSum[:]) {
+		conn.Close()
+		return nil, fmt.Errorf("%w: invalid Sec-WebSocket-Accept", ErrWebsocketProtocol)
+	}
+	return &websocketConn{conn: conn, reader: bufio.NewReader(conn), closed: make(chan struct{})}, nil
+}
+
+// closeWhenDone closes the connection once ctx is done, unblocking its reads.
+func (websocket *websocketConn) closeWhenDone(ctx context.Context) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			websocket.Close()
+		case <-websocket.closed:
+		}
+	}()
+}
+
+// readMessage returns the next text or binary message, answering the pings read meanwhile.
+// It returns io.EOF once the server closed the websocket.
+func (websocket *websocketConn) readMessage() ([]byte, error) {
+	var message []byte
+	for {
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(websocket.reader, header); err != nil {
+			return nil, err
+		}
+		fin := header[0]&0x80 != 0
+		opcode := header[0] & 0x0f
+		masked := header[1]&0x80 != 0
+		length := uint64(header[1] & 0x7f)
+		switch length {
+		case 126:
+			extended := make([]byte, 2)
+			if _, err := io.ReadFull(websocket.reader, extended); err != nil {
+				return nil, err
+			}
+			length = uint64(binary.BigEndian.Uint16(extended))
+		case 127:
+			extended := make([]byte, 8)
+			if _, err := io.ReadFull(websocket.reader, extended); err != nil {
+				return nil, err
+			}
+			length = binary.BigEndian.Uint64(extended)
+		}
+		if length > uint64(MAX_WEBSOCKET_MESSAGE_SIZE-len(message)) {
+			return nil, fmt.Errorf("%w: message larger than %d bytes", ErrWebsocketProtocol, MAX_WEBSOCKET_MESSAGE_SIZE)
+		}
+		mask := make([]byte, 4)
+		if masked {
+			if _, err := io.ReadFull(websocket.reader, mask); err != nil {
+				return nil, err
+			}
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(websocket.reader, payload); err != nil {
+			return nil, err
+		}
+		if masked {
+			for index := range payload {
+				payload[index] ^= mask[index%4]
+			}
+		}
+		switch opcode {
+		case websocketClose:
+			// * echoing the status code of the server, as the closing handshake asks
+			if len(payload) > 2 {
+				payload = payload[:2]
+			}
+			websocket.writeFrame(websocketClose, payload)
+			return nil, io.EOF
+		case websocketPing:
+			if err := websocket.writeFrame(websocketPong, payload); err != nil {
+				return nil, err
+			}
+		case websocketPong:
+		case websocketText, websocketBinary, websocketContinuation:
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown opcode %d", ErrWebsocketProtocol, opcode)
+		}
+	}
+}
+
+// writeFrame writes a single frame, masked as every frame of a client must be.
+func (websocket *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, 0x80|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, 0x80|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(length))
+	default:
+		frame = append(frame, 0x80|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(length))
+	}
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	frame = append(frame, mask...)
+	for index, value := range payload {
+		frame = append(frame, value^mask[index%4])
+	}
+	websocket.writeMutex.Lock()
+	defer websocket.writeMutex.Unlock()
+	_, err := websocket.conn.Write(frame)
+	return err
+}
+
+// Close sends a normal closure to the server, if it is still listening, and closes the connection.
+func (websocket *websocketConn) Close() error {
+	var err error
+	websocket.closeOnce.Do(func() {
+		close(websocket.closed)
+		websocket.writeFrame(websocketClose, []byte{0x03, 0xe8})
+		err = websocket.conn.Close()
+	})
+	return err
+}
@@ -0,0 +1,627 @@
+package gothreatmatrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The YAML read and written here is the subset used by configuration files: block mappings and sequences,
+// flow [lists] and {maps}, plain, single and double quoted scalars and comments.
+// Anchors, tags and multi-line scalars are not supported. Values are converted through encoding/json,
+// so the json tags of a struct name its YAML keys.
+
+// yamlLine represents a significant line of a YAML document.
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+// unmarshalYaml decodes the YAML document into value, through its json tags.
+func unmarshalYaml(data []byte, value interface{}) error {
+	document, err := decodeYaml(data)
+	if err != nil {
+		return err
+	}
+	jsonData, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, value)
+}
+
+// unmarshalYamlStrict decodes the YAML document into value like unmarshalYaml, rejecting the keys value has no field for.
+func unmarshalYamlStrict(data []byte, value interface{}) error {
+	document, err := decodeYaml(data)
+	if err != nil {
+		return err
+	}
+	if err := checkKnownFields(document, reflect.TypeOf(value)); err != nil {
+		return err
+	}
+	jsonData, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, value)
+}
+
+// checkKnownFields returns an error naming the first key of the document the type has no field for, walking the
+// nested mappings and sequences: encoding/json's DisallowUnknownFields does not reach the structs decoding
+// themselves, e.g. RetryOptions. The mismatched types are left for encoding/json to report.
+func checkKnownFields(document interface{}, valueType reflect.Type) error {
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	switch document := document.(type) {
+	case map[string]interface{}:
+		switch valueType.Kind() {
+		case reflect.Struct:
+			keys := make([]string, 0, len(document))
+			for key := range document {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				field, ok := jsonField(valueType, key)
+				if !ok {
+					return fmt.Errorf("json: unknown field %q", key)
+				}
+				if err := checkKnownFields(document[key], field.Type); err != nil {
+					return err
+				}
+			}
+		case reflect.Map:
+			for _, item := range document {
+				if err := checkKnownFields(item, valueType.Elem()); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if valueType.Kind() == reflect.Slice || valueType.Kind() == reflect.Array {
+			for _, item := range document {
+				if err := checkKnownFields(item, valueType.Elem()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// jsonField returns the exported field of the struct encoding/json decodes the key into, matched like it does:
+// on the json tag or the field name, case-insensitively.
+func jsonField(structType reflect.Type, key string) (reflect.StructField, bool) {
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// marshalYaml encodes the value as a YAML document, through its json tags.
+func marshalYaml(value interface{}) ([]byte, error) {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonData))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	buffer := &bytes.Buffer{}
+	switch document.(type) {
+	case map[string]interface{}, []interface{}:
+		if isEmptyYamlCollection(document) {
+			buffer.WriteString(encodeYamlScalar(document) + "\n")
+		} else {
+			encodeYamlBlock(buffer, document, 0)
+		}
+	default:
+		buffer.WriteString(encodeYamlScalar(document) + "\n")
+	}
+	return buffer.Bytes(), nil
+}
+
+// decodeYaml parses the YAML document into maps, slices, strings, bools, json.Numbers and nils.
+func decodeYaml(data []byte) (interface{}, error) {
+	lines := []yamlLine{}
+	for index, rawLine := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		text := stripYamlComment(rawLine)
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if trimmed == "..." {
+			break
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		if strings.HasPrefix(text[indent:], "\t") {
+			return nil, fmt.Errorf("yaml: line %d: tabs cannot be used for indentation", index+1)
+		}
+		lines = append(lines, yamlLine{number: index + 1, indent: indent, text: strings.TrimRight(text[indent:], " \t")})
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	value, next, err := parseYamlBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("yaml: line %d: unexpected indentation", lines[next].number)
+	}
+	return value, nil
+}
+
+// stripYamlComment removes the comment of the line, if any.
+func stripYamlComment(line string) string {
+	quote := rune(0)
+	escaped := false
+	for index, character := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && character == '\\':
+			escaped = true
+		case quote != 0:
+			if character == quote {
+				quote = 0
+			}
+		case (character == '"' || character == '\'') && startsYamlQuotedScalar(line, index):
+			quote = character
+		case character == '#' && (index == 0 || line[index-1] == ' ' || line[index-1] == '\t'):
+			return line[:index]
+		}
+	}
+	return line
+}
+
+// startsYamlQuotedScalar reports whether the quote at the index of the text opens a quoted scalar: at the start of the
+// text or after "[", "{", ",", ": " or "- ". Elsewhere, e.g. in analyst's pick, it is a character of a plain scalar.
+func startsYamlQuotedScalar(text string, index int) bool {
+	before := strings.TrimRight(text[:index], " \t")
+	if before == "" {
+		return true
+	}
+	switch before[len(before)-1] {
+	case '[', '{', ',':
+		return true
+	case ':':
+		return len(before) < index
+	case '-':
+		// * the dash of a sequence item, not one of a plain scalar like "a - 'b'"
+		return len(before) < index && strings.Trim(before, "- \t") == ""
+	}
+	return false
+}
+
+// isYamlSequenceItem reports whether the line starts a sequence item.
+func isYamlSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseYamlBlock parses the mapping or sequence starting at lines[start], whose lines are indented by indent.
+func parseYamlBlock(lines []yamlLine, start int, indent int) (interface{}, int, error) {
+	if isYamlSequenceItem(lines[start].text) {
+		return parseYamlSequence(lines, start, indent)
+	}
+	if _, _, ok := splitYamlMappingKey(lines[start].text); !ok {
+		// * a lone scalar, e.g. a whole document made of a flow collection
+		value, err := parseYamlFlow(lines[start].text, lines[start].number)
+		return value, start + 1, err
+	}
+	return parseYamlMapping(lines, start, indent)
+}
+
+// parseYamlMapping parses the block mapping starting at lines[start].
+func parseYamlMapping(lines []yamlLine, start int, indent int) (interface{}, int, error) {
+	mapping := map[string]interface{}{}
+	index := start
+	for index < len(lines) && lines[index].indent == indent && !isYamlSequenceItem(lines[index].text) {
+		line := lines[index]
+		rawKey, rest, ok := splitYamlMappingKey(line.text)
+		if !ok {
+			return nil, index, fmt.Errorf("yaml: line %d: expected a \"key: value\" pair", line.number)
+		}
+		keyValue, err := parseYamlFlow(rawKey, line.number)
+		if err != nil {
+			return nil, index, err
+		}
+		key := fmt.Sprint(keyValue)
+		if _, duplicated := mapping[key]; duplicated {
+			return nil, index, fmt.Errorf("yaml: line %d: duplicated key %q", line.number, key)
+		}
+		index++
+		var value interface{}
+		switch {
+		case rest != "":
+			if value, err = parseYamlFlow(rest, line.number); err != nil {
+				return nil, index, err
+			}
+		case index < len(lines) && lines[index].indent > indent:
+			if value, index, err = parseYamlBlock(lines, index, lines[index].indent); err != nil {
+				return nil, index, err
+			}
+		case index < len(lines) && lines[index].indent == indent && isYamlSequenceItem(lines[index].text):
+			// * a sequence may be indented at the same level as its key
+			if value, index, err = parseYamlSequence(lines, index, indent); err != nil {
+				return nil, index, err
+			}
+		}
+		mapping[key] = value
+	}
+	if index < len(lines) && lines[index].indent > indent {
+		return nil, index, fmt.Errorf("yaml: line %d: unexpected indentation", lines[index].number)
+	}
+	return mapping, index, nil
+}
+
+// parseYamlSequence parses the block sequence starting at lines[start].
+func parseYamlSequence(lines []yamlLine, start int, indent int) (interface{}, int, error) {
+	sequence := []interface{}{}
+	index := start
+	for index < len(lines) && lines[index].indent == indent && isYamlSequenceItem(lines[index].text) {
+		line := lines[index]
+		content := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		var value interface{}
+		var err error
+		switch {
+		case content == "":
+			index++
+			if index < len(lines) && lines[index].indent > indent {
+				if value, index, err = parseYamlBlock(lines, index, lines[index].indent); err != nil {
+					return nil, index, err
+				}
+			}
+		case isYamlSequenceItem(content) || isYamlInlineMapping(content):
+			// * "- key: value" starts a mapping (or "- - item" a sequence) indented at the position of its content
+			itemLines := append([]yamlLine{}, lines...)
+			itemLines[index] = yamlLine{number: line.number, indent: indent + len(line.text) - len(content), text: content}
+			if value, index, err = parseYamlBlock(itemLines, index, itemLines[index].indent); err != nil {
+				return nil, index, err
+			}
+		default:
+			if value, err = parseYamlFlow(content, line.number); err != nil {
+				return nil, index, err
+			}
+			index++
+		}
+		sequence = append(sequence, value)
+	}
+	return sequence, index, nil
+}
+
+// isYamlInlineMapping reports whether the content of a sequence item is a "key: value" pair.
+func isYamlInlineMapping(content string) bool {
+	if strings.HasPrefix(content, "[") || strings.HasPrefix(content, "{") {
+		return false
+	}
+	_, _, ok := splitYamlMappingKey(content)
+	return ok
+}
+
+// splitYamlMappingKey splits "key: value" on the first colon followed by a space, or ending the line,
+// that is not quoted nor part of a flow collection.
+func splitYamlMappingKey(text string) (string, string, bool) {
+	quote := byte(0)
+	escaped := false
+	depth := 0
+	for index := 0; index < len(text); index++ {
+		character := text[index]
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && character == '\\':
+			escaped = true
+		case quote != 0:
+			if character == quote {
+				quote = 0
+			}
+		case (character == '"' || character == '\'') && startsYamlQuotedScalar(text, index):
+			quote = character
+		case character == '[' || character == '{':
+			depth++
+		case character == ']' || character == '}':
+			depth--
+		case character == ':' && depth == 0 && (index == len(text)-1 || text[index+1] == ' '):
+			return strings.TrimSpace(text[:index]), strings.TrimSpace(text[index+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// parseYamlFlow parses a scalar or a flow collection.
+func parseYamlFlow(text string, lineNumber int) (interface{}, error) {
+	parser := &yamlFlowParser{text: text, lineNumber: lineNumber}
+	value, err := parser.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	parser.skipSpaces()
+	if parser.position < len(parser.text) {
+		return nil, parser.errorf("unexpected %q", parser.text[parser.position:])
+	}
+	return value, nil
+}
+
+// yamlFlowParser parses the flow value of a single line.
+type yamlFlowParser struct {
+	text       string
+	position   int
+	lineNumber int
+	// depth is the number of flow collections the parser is in, where commas and closing brackets end plain scalars.
+	depth int
+}
+
+func (parser *yamlFlowParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", parser.lineNumber, fmt.Sprintf(format, args...))
+}
+
+func (parser *yamlFlowParser) skipSpaces() {
+	for parser.position < len(parser.text) && parser.text[parser.position] == ' ' {
+		parser.position++
+	}
+}
+
+func (parser *yamlFlowParser) parseValue() (interface{}, error) {
+	parser.skipSpaces()
+	if parser.position >= len(parser.text) {
+		return nil, nil
+	}
+	switch parser.text[parser.position] {
+	case '[':
+		return parser.parseSequence()
+	case '{':
+		return parser.parseMapping()
+	case '"':
+		return parser.parseDoubleQuoted()
+	case '\'':
+		return parser.parseSingleQuoted()
+	case '&', '*', '!', '|', '>':
+		return nil, parser.errorf("anchors, tags and multi-line scalars are not supported")
+	}
+	start := parser.position
+	for parser.position < len(parser.text) {
+		character := parser.text[parser.position]
+		if parser.depth > 0 && (character == ',' || character == ']' || character == '}') {
+			break
+		}
+		if parser.depth > 0 && character == ':' && (parser.position+1 == len(parser.text) || parser.text[parser.position+1] == ' ') {
+			break
+		}
+		parser.position++
+	}
+	return parseYamlPlainScalar(strings.TrimSpace(parser.text[start:parser.position])), nil
+}
+
+func (parser *yamlFlowParser) parseSequence() (interface{}, error) {
+	parser.position++
+	parser.depth++
+	defer func() { parser.depth-- }()
+	sequence := []interface{}{}
+	for {
+		parser.skipSpaces()
+		if parser.position >= len(parser.text) {
+			return nil, parser.errorf("unterminated flow sequence")
+		}
+		if parser.text[parser.position] == ']' {
+			parser.position++
+			return sequence, nil
+		}
+		value, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, value)
+		if err := parser.endFlowItem(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (parser *yamlFlowParser) parseMapping() (interface{}, error) {
+	parser.position++
+	parser.depth++
+	defer func() { parser.depth-- }()
+	mapping := map[string]interface{}{}
+	for {
+		parser.skipSpaces()
+		if parser.position >= len(parser.text) {
+			return nil, parser.errorf("unterminated flow mapping")
+		}
+		if parser.text[parser.position] == '}' {
+			parser.position++
+			return mapping, nil
+		}
+		key, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		parser.skipSpaces()
+		if parser.position >= len(parser.text) || parser.text[parser.position] != ':' {
+			return nil, parser.errorf("expected ':' after the key %v", key)
+		}
+		parser.position++
+		value, err := parser.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		mapping[fmt.Sprint(key)] = value
+		if err := parser.endFlowItem('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// endFlowItem consumes the comma after an item, leaving the closing bracket for the caller.
+func (parser *yamlFlowParser) endFlowItem(closing byte) error {
+	parser.skipSpaces()
+	if parser.position < len(parser.text) {
+		switch parser.text[parser.position] {
+		case ',':
+			parser.position++
+			return nil
+		case closing:
+			return nil
+		}
+	}
+	return parser.errorf("expected ',' or '%c'", closing)
+}
+
+func (parser *yamlFlowParser) parseDoubleQuoted() (interface{}, error) {
+	start := parser.position
+	parser.position++
+	for parser.position < len(parser.text) {
+		switch parser.text[parser.position] {
+		case '\\':
+			parser.position += 2
+			continue
+		case '"':
+			parser.position++
+			value, err := strconv.Unquote(parser.text[start:parser.position])
+			if err != nil {
+				return nil, parser.errorf("invalid double quoted string %s", parser.text[start:parser.position])
+			}
+			return value, nil
+		}
+		parser.position++
+	}
+	return nil, parser.errorf("unterminated double quoted string")
+}
+
+func (parser *yamlFlowParser) parseSingleQuoted() (interface{}, error) {
+	parser.position++
+	builder := strings.Builder{}
+	for parser.position < len(parser.text) {
+		character := parser.text[parser.position]
+		parser.position++
+		if character != '\'' {
+			builder.WriteByte(character)
+			continue
+		}
+		// * '' is an escaped quote
+		if parser.position < len(parser.text) && parser.text[parser.position] == '\'' {
+			builder.WriteByte('\'')
+			parser.position++
+			continue
+		}
+		return builder.String(), nil
+	}
+	return nil, parser.errorf("unterminated single quoted string")
+}
+
+// parseYamlPlainScalar converts an unquoted scalar into a nil, a bool, a json.Number or a string.
+func parseYamlPlainScalar(text string) interface{} {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil && !strings.ContainsAny(text, "xXoObB_") && strings.ToLower(text) != "nan" && !strings.Contains(strings.ToLower(text), "inf") {
+		return json.Number(text)
+	}
+	return text
+}
+
+// isEmptyYamlCollection reports whether the value is an empty mapping or sequence.
+func isEmptyYamlCollection(value interface{}) bool {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		return len(typedValue) == 0
+	case []interface{}:
+		return len(typedValue) == 0
+	}
+	return false
+}
+
+// encodeYamlBlock writes a non-empty mapping or sequence in block style.
+func encodeYamlBlock(buffer *bytes.Buffer, value interface{}, indent int) {
+	padding := strings.Repeat(" ", indent)
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typedValue))
+		for key := range typedValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			item := typedValue[key]
+			buffer.WriteString(padding + encodeYamlScalar(key) + ":")
+			if isYamlBlock(item) {
+				buffer.WriteString("\n")
+				encodeYamlBlock(buffer, item, indent+2)
+			} else {
+				buffer.WriteString(" " + encodeYamlScalar(item) + "\n")
+			}
+		}
+	case []interface{}:
+		for _, item := range typedValue {
+			buffer.WriteString(padding + "-")
+			if isYamlBlock(item) {
+				buffer.WriteString("\n")
+				encodeYamlBlock(buffer, item, indent+2)
+			} else {
+				buffer.WriteString(" " + encodeYamlScalar(item) + "\n")
+			}
+		}
+	}
+}
+
+// isYamlBlock reports whether the value is written in block style: non-empty mappings and sequences.
+func isYamlBlock(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return !isEmptyYamlCollection(value)
+	}
+	return false
+}
+
+// encodeYamlScalar writes a scalar, or an empty collection, in flow style.
+// Strings are double quoted whenever reading them back as plain scalars would change them.
+func encodeYamlScalar(value interface{}) string {
+	switch typedValue := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(typedValue)
+	case json.Number:
+		return typedValue.String()
+	case map[string]interface{}:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	case string:
+		if typedValue != strings.TrimSpace(typedValue) ||
+			strings.ContainsAny(typedValue, ":#{}[],&*!|>'\"%@`\\\n\t") ||
+			strings.HasPrefix(typedValue, "-") || strings.HasPrefix(typedValue, "?") ||
+			parseYamlPlainScalar(typedValue) != typedValue {
+			return strconv.Quote(typedValue)
+		}
+		return typedValue
+	}
+	return strconv.Quote(fmt.Sprint(value))
+}
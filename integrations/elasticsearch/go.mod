@@ -0,0 +1,13 @@
+module github.com/khulnasoft/go-threatmatrix/integrations/elasticsearch
+
+go 1.18
+
+require github.com/khulnasoft/go-threatmatrix v0.0.0
+
+require (
+	github.com/google/go-cmp v0.6.0 // indirect
+	github.com/sirupsen/logrus v1.9.3 // indirect
+	golang.org/x/sys v0.0.0-20220722155257-8c9f86f7a55f // indirect
+)
+
+replace github.com/khulnasoft/go-threatmatrix => ../..
@@ -0,0 +1,81 @@
+// Package elasticsearch exports ThreatMatrix jobs to an Elasticsearch index.
+//
+// It is a separate module so the core go-threatmatrix client does not depend on it.
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// DEFAULT_INDEX is the index jobs are written to when Sink.Index is empty.
+const DEFAULT_INDEX = "threatmatrix-jobs"
+
+// Document represents what is indexed for every job: its summary and the time it was exported.
+type Document struct {
+	gothreatmatrix.JobSummary
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// Sink writes jobs to Elasticsearch through its REST API.
+// Documents are keyed on the job ID, so exporting a job again overwrites it.
+type Sink struct {
+	// Url of the Elasticsearch cluster, e.g. https://localhost:9200
+	Url   string
+	Index string
+	// ApiKey is sent as an "ApiKey" authorization, Username and Password are used when it is empty.
+	ApiKey     string
+	Username   string
+	Password   string
+	HttpClient *http.Client
+}
+
+// WriteJob indexes the summary of the job.
+func (sink *Sink) WriteJob(ctx context.Context, job *gothreatmatrix.Job) error {
+	document := Document{
+		JobSummary: *job.Summary(),
+		ExportedAt: time.Now().UTC(),
+	}
+	documentJson, err := json.Marshal(&document)
+	if err != nil {
+		return err
+	}
+	index := sink.Index
+	if index == "" {
+		index = DEFAULT_INDEX
+	}
+	requestUrl := fmt.Sprintf("%s/%s/_doc/%d", strings.TrimSuffix(sink.Url, "/"), url.PathEscape(index), job.ID)
+	request, err := http.NewRequestWithContext(ctx, "PUT", requestUrl, bytes.NewReader(documentJson))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if sink.ApiKey != "" {
+		request.Header.Set("Authorization", "ApiKey "+sink.ApiKey)
+	} else if sink.Username != "" {
+		request.SetBasicAuth(sink.Username, sink.Password)
+	}
+	httpClient := sink.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusBadRequest {
+		msgBytes, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("elasticsearch: could not index job %d: status code %d: %s", job.ID, response.StatusCode, msgBytes)
+	}
+	return nil
+}
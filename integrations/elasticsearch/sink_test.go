@@ -0,0 +1,50 @@
+package elasticsearch_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/khulnasoft/go-threatmatrix/integrations/elasticsearch"
+)
+
+func TestSinkWriteJob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.URL.Path != "/jobs/_doc/7" {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "ApiKey secret" {
+			t.Errorf("Unexpected authorization: %s", r.Header.Get("Authorization"))
+		}
+		document := elasticsearch.Document{}
+		if err := json.NewDecoder(r.Body).Decode(&document); err != nil {
+			t.Errorf("Could not parse request body: %v", err)
+		}
+		if document.JobID != 7 || document.ObservableName != "dns.google" {
+			t.Errorf("Unexpected document: %+v", document)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	sink := &elasticsearch.Sink{Url: server.URL, Index: "jobs", ApiKey: "secret"}
+	job := &gothreatmatrix.Job{}
+	job.ID = 7
+	job.ObservableName = "dns.google"
+	if err := sink.WriteJob(context.Background(), job); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestSinkWriteJobError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	sink := &elasticsearch.Sink{Url: server.URL}
+	if err := sink.WriteJob(context.Background(), &gothreatmatrix.Job{}); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
@@ -0,0 +1,163 @@
+// Package misp exports ThreatMatrix jobs to a MISP instance as events.
+//
+// It is a separate module so the core go-threatmatrix client does not depend on it.
+package misp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// Values of the MISP distribution levels.
+const (
+	DISTRIBUTION_ORGANISATION = 0
+	DISTRIBUTION_COMMUNITY    = 1
+	DISTRIBUTION_CONNECTED    = 2
+	DISTRIBUTION_ALL          = 3
+)
+
+// Attribute represents a MISP event attribute.
+type Attribute struct {
+	Type     string `json:"type"`
+	Category string `json:"category"`
+	Value    string `json:"value"`
+	ToIds    bool   `json:"to_ids"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Tag represents a MISP tag.
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Event represents the MISP event created for a job.
+type Event struct {
+	Info          string      `json:"info"`
+	Distribution  int         `json:"distribution,string"`
+	ThreatLevelId int         `json:"threat_level_id,string"`
+	Analysis      int         `json:"analysis,string"`
+	Tags          []Tag       `json:"Tag"`
+	Attributes    []Attribute `json:"Attribute"`
+}
+
+// Sink creates a MISP event for every job, holding its observable, the artifacts found in its reports
+// and its malware families as tags.
+type Sink struct {
+	// Url of the MISP instance, e.g. https://misp.example.com
+	Url string
+	// Key is the MISP automation key.
+	Key          string
+	Distribution int
+	HttpClient   *http.Client
+}
+
+// WriteJob creates the event of the job.
+func (sink *Sink) WriteJob(ctx context.Context, job *gothreatmatrix.Job) error {
+	eventJson, err := json.Marshal(map[string]*Event{"Event": NewEvent(job, sink.Distribution)})
+	if err != nil {
+		return err
+	}
+	requestUrl := strings.TrimSuffix(sink.Url, "/") + "/events/add"
+	request, err := http.NewRequestWithContext(ctx, "POST", requestUrl, bytes.NewReader(eventJson))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("Authorization", sink.Key)
+	httpClient := sink.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusBadRequest {
+		msgBytes, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("misp: could not create the event of job %d: status code %d: %s", job.ID, response.StatusCode, msgBytes)
+	}
+	return nil
+}
+
+// NewEvent converts the job into a MISP event.
+func NewEvent(job *gothreatmatrix.Job, distribution int) *Event {
+	summary := job.Summary()
+	subject := summary.ObservableName
+	if subject == "" {
+		subject = summary.FileName
+	}
+	event := &Event{
+		Info:          fmt.Sprintf("ThreatMatrix job %d: %s", job.ID, subject),
+		Distribution:  distribution,
+		ThreatLevelId: 4,
+		Analysis:      2,
+		Tags:          []Tag{},
+		Attributes:    []Attribute{},
+	}
+	if summary.Tlp != "" {
+		event.Tags = append(event.Tags, Tag{Name: "tlp:" + strings.ToLower(summary.Tlp)})
+	}
+	for _, family := range summary.MalwareFamilies {
+		event.Tags = append(event.Tags, Tag{Name: fmt.Sprintf("malware_classification:malware-family=\"%s\"", family.Name)})
+	}
+	if summary.ObservableName != "" {
+		if attributeType := attributeType(summary.ObservableClassification, summary.ObservableName); attributeType != "" {
+			event.Attributes = append(event.Attributes, newAttribute(attributeType, summary.ObservableName, "analyzed observable"))
+		}
+	}
+	if summary.Md5 != "" && summary.FileName != "" {
+		event.Attributes = append(event.Attributes, newAttribute("md5", summary.Md5, summary.FileName))
+	}
+	for _, artifact := range gothreatmatrix.ExtractArtifacts(job) {
+		if attributeType := attributeType(artifact.Classification, artifact.Value); attributeType != "" {
+			event.Attributes = append(event.Attributes, newAttribute(attributeType, artifact.Value, "found by "+strings.Join(artifact.Sources, ", ")))
+		}
+	}
+	return event
+}
+
+// newAttribute returns an attribute in the category MISP expects for its type.
+func newAttribute(attributeType string, value string, comment string) Attribute {
+	category := "Network activity"
+	switch attributeType {
+	case "md5", "sha1", "sha256":
+		category = "Payload delivery"
+	}
+	return Attribute{
+		Type:     attributeType,
+		Category: category,
+		Value:    value,
+		Comment:  comment,
+	}
+}
+
+// attributeType maps an observable classification to a MISP attribute type.
+func attributeType(classification string, value string) string {
+	switch classification {
+	case "ip":
+		return "ip-dst"
+	case "domain":
+		return "domain"
+	case "url":
+		return "url"
+	case "hash":
+		switch len(value) {
+		case 32:
+			return "md5"
+		case 40:
+			return "sha1"
+		case 64:
+			return "sha256"
+		}
+	}
+	return ""
+}
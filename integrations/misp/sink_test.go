@@ -0,0 +1,55 @@
+package misp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/khulnasoft/go-threatmatrix/integrations/misp"
+)
+
+func TestSinkWriteJob(t *testing.T) {
+	jobJson := `{"id": 3, "observable_name": "8.8.8.8", "observable_classification": "ip", "tlp": "AMBER", "analyzer_reports": [
+		{"name": "Classic_DNS", "status": "SUCCESS", "report": {"resolutions": ["dns.google"]}}
+	]}`
+	job := &gothreatmatrix.Job{}
+	if err := json.Unmarshal([]byte(jobJson), job); err != nil {
+		t.Fatalf("Could not parse the job: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/events/add" {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "key" {
+			t.Errorf("Unexpected authorization: %s", r.Header.Get("Authorization"))
+		}
+		body := map[string]misp.Event{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Could not parse request body: %v", err)
+		}
+		want := misp.Event{
+			Info:          "ThreatMatrix job 3: 8.8.8.8",
+			Distribution:  misp.DISTRIBUTION_ORGANISATION,
+			ThreatLevelId: 4,
+			Analysis:      2,
+			Tags:          []misp.Tag{{Name: "tlp:amber"}},
+			Attributes: []misp.Attribute{
+				{Type: "ip-dst", Category: "Network activity", Value: "8.8.8.8", Comment: "analyzed observable"},
+				{Type: "domain", Category: "Network activity", Value: "dns.google", Comment: "found by Classic_DNS"},
+			},
+		}
+		if diff := cmp.Diff(want, body["Event"]); diff != "" {
+			t.Error(diff)
+		}
+		w.Write([]byte(`{"Event": {"id": "1"}}`))
+	}))
+	defer server.Close()
+	sink := &misp.Sink{Url: server.URL, Key: "key"}
+	if err := sink.WriteJob(context.Background(), job); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+// Package cli holds the versioned --json contract of the threatmatrix command (cmd/threatmatrix): the types of the
+// documents it writes and their JSON Schema. The types keep their CLI prefix, their names being the $defs of the
+// published schema.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"sort"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/khulnasoft/go-threatmatrix/internal/jsonschema"
+)
+
+// CLI_CONTRACT_VERSION is the version of the --json output of the threatmatrix command (cmd/threatmatrix).
+// Fields are only ever added within a version; renaming, retyping or removing one bumps it.
+const CLI_CONTRACT_VERSION = 1
+
+// Values of the CLIError.Kind field.
+const (
+	CLI_ERROR_USAGE        = "usage"
+	CLI_ERROR_NOT_FOUND    = "not_found"
+	CLI_ERROR_UNAUTHORIZED = "unauthorized"
+	CLI_ERROR_RATE_LIMITED = "rate_limited"
+	CLI_ERROR_API          = "api"
+	CLI_ERROR_TIMEOUT      = "timeout"
+	CLI_ERROR_NETWORK      = "network"
+	CLI_ERROR_INTERNAL     = "internal"
+)
+
+// CLIResponse represents the single JSON document the threatmatrix command writes to the standard output with --json,
+// whatever the outcome. Result is the CLIScanResult, CLIWaitResult, CLIExportResult, JobListResponse, CLIHealthResult or PreflightReport
+// of the command when OK is true, Error is set otherwise.
+type CLIResponse struct {
+	ContractVersion int         `json:"contract_version"`
+	Command         string      `json:"command"`
+	OK              bool        `json:"ok"`
+	Result          interface{} `json:"result,omitempty"`
+	Error           *CLIError   `json:"error,omitempty"`
+}
+
+// CLIError represents why a command failed, Kind being what tooling is meant to branch on.
+type CLIError struct {
+	// Kind is one of the CLI_ERROR_* values.
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	// StatusCode and RequestID are set when ThreatMatrix answered with an error.
+	StatusCode int    `json:"status_code,omitempty"`
+	RequestID  string `json:"request_id,omitempty"`
+}
+
+// NewCLIError classifies the error of a command into a CLIError.
+func NewCLIError(err error) *CLIError {
+	cliError := &CLIError{Kind: CLI_ERROR_INTERNAL, Message: err.Error()}
+	var threatMatrixError *gothreatmatrix.ThreatMatrixError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		cliError.Kind = CLI_ERROR_TIMEOUT
+	case gothreatmatrix.IsNotFound(err):
+		cliError.Kind = CLI_ERROR_NOT_FOUND
+	case gothreatmatrix.IsUnauthorized(err):
+		cliError.Kind = CLI_ERROR_UNAUTHORIZED
+	case gothreatmatrix.IsRateLimited(err):
+		cliError.Kind = CLI_ERROR_RATE_LIMITED
+	case errors.As(err, &threatMatrixError):
+		cliError.Kind = CLI_ERROR_API
+	case errors.As(err, new(net.Error)):
+		cliError.Kind = CLI_ERROR_NETWORK
+	}
+	if errors.As(err, &threatMatrixError) {
+		cliError.StatusCode = threatMatrixError.StatusCode
+		cliError.RequestID = threatMatrixError.RequestID
+	}
+	return cliError
+}
+
+// CLIScanResult represents the result of the scan command, Wait being set when it waited for the job.
+type CLIScanResult struct {
+	Analysis gothreatmatrix.AnalysisResponse `json:"analysis"`
+	Wait     *CLIWaitResult                  `json:"wait,omitempty"`
+}
+
+// CLIWaitResult represents the result of the wait command.
+type CLIWaitResult struct {
+	// Complete is false when only the analyzers waited for finished, PendingAnalyzers listing the others.
+	Complete         bool                      `json:"complete"`
+	TimedOut         bool                      `json:"timed_out"`
+	PendingAnalyzers []string                  `json:"pending_analyzers"`
+	Summary          gothreatmatrix.JobSummary `json:"summary"`
+}
+
+// NewCLIWaitResult converts the result of WaitForCompletion into a CLIWaitResult.
+func NewCLIWaitResult(waitResult *gothreatmatrix.WaitResult) *CLIWaitResult {
+	pendingAnalyzers := waitResult.PendingAnalyzers
+	if pendingAnalyzers == nil {
+		pendingAnalyzers = []string{}
+	}
+	return &CLIWaitResult{
+		Complete:         waitResult.Complete,
+		TimedOut:         waitResult.TimedOut,
+		PendingAnalyzers: pendingAnalyzers,
+		Summary:          *waitResult.Job.Summary(),
+	}
+}
+
+// CLIExportResult represents the result of the export command: the job rendered in the format, as text.
+type CLIExportResult struct {
+	JobID   int    `json:"job_id"`
+	Format  string `json:"format"`
+	Content string `json:"content"`
+}
+
+// CLIPluginHealth represents the health of a plugin checked by the health commands.
+type CLIPluginHealth struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+}
+
+// CLIHealthResult represents the result of the analyzers health and connectors health commands.
+type CLIHealthResult struct {
+	// PluginType is "analyzer" or "connector".
+	PluginType string            `json:"plugin_type"`
+	Plugins    []CLIPluginHealth `json:"plugins"`
+}
+
+// OK reports whether every plugin checked is healthy.
+func (result *CLIHealthResult) OK() bool {
+	for _, plugin := range result.Plugins {
+		if !plugin.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// cliCommandResults maps the commands of the contract to the type of their result.
+// The grouped commands are named by their group and subcommand, e.g. "jobs list".
+var cliCommandResults = map[string]reflect.Type{
+	"scan":               reflect.TypeOf(CLIScanResult{}),
+	"wait":               reflect.TypeOf(CLIWaitResult{}),
+	"export":             reflect.TypeOf(CLIExportResult{}),
+	"preflight":          reflect.TypeOf(gothreatmatrix.PreflightReport{}),
+	"analyze observable": reflect.TypeOf(CLIScanResult{}),
+	"analyze file":       reflect.TypeOf(CLIScanResult{}),
+	"jobs list":          reflect.TypeOf(gothreatmatrix.JobListResponse{}),
+	"analyzers health":   reflect.TypeOf(CLIHealthResult{}),
+	"connectors health":  reflect.TypeOf(CLIHealthResult{}),
+}
+
+// CLIContractSchema returns the JSON Schema (draft 2020-12) of the --json output of the threatmatrix command.
+// It is generated from the Go types, so it never drifts from what the command writes.
+func CLIContractSchema() map[string]interface{} {
+	definitions := map[string]interface{}{}
+	jsonschema.Of(reflect.TypeOf(CLIResponse{}), definitions)
+	envelope := definitions["CLIResponse"].(map[string]interface{})
+	delete(definitions, "CLIResponse")
+	results := []interface{}{}
+	// * commands sharing a result type share its schema, oneOf needing exactly one match
+	seen := map[reflect.Type]bool{}
+	for _, command := range sortedKeys(cliCommandResults) {
+		if resultType := cliCommandResults[command]; !seen[resultType] {
+			seen[resultType] = true
+			results = append(results, jsonschema.Of(resultType, definitions))
+		}
+	}
+	properties := envelope["properties"].(map[string]interface{})
+	properties["result"] = map[string]interface{}{"oneOf": results}
+	properties["command"] = map[string]interface{}{"enum": sortedKeys(cliCommandResults)}
+	properties["contract_version"] = map[string]interface{}{"const": CLI_CONTRACT_VERSION}
+	errorProperties := definitions["CLIError"].(map[string]interface{})["properties"].(map[string]interface{})
+	errorProperties["kind"] = map[string]interface{}{"enum": []string{
+		CLI_ERROR_USAGE, CLI_ERROR_NOT_FOUND, CLI_ERROR_UNAUTHORIZED, CLI_ERROR_RATE_LIMITED, CLI_ERROR_API, CLI_ERROR_TIMEOUT, CLI_ERROR_NETWORK, CLI_ERROR_INTERNAL,
+	}}
+	envelope["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	envelope["title"] = "threatmatrix --json output"
+	envelope["$defs"] = definitions
+	return envelope
+}
+
+// sortedKeys returns the keys of the map, sorted.
+func sortedKeys(values map[string]reflect.Type) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MarshalCLIContractSchema returns CLIContractSchema as indented JSON, as published in docs/cli-contract.schema.json.
+func MarshalCLIContractSchema() ([]byte, error) {
+	data, err := json.MarshalIndent(CLIContractSchema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,94 @@
+// Package jsonschema generates the JSON Schemas (draft 2020-12) of Go types, as encoding/json marshals their values.
+// It backs the published schemas of the event payloads and of the CLI contract.
+package jsonschema
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Of returns the JSON Schema of the values of the type as encoding/json marshals them.
+// Named structs are added to definitions and referenced.
+func Of(valueType reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	switch {
+	case valueType == reflect.TypeOf(time.Time{}):
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case valueType == reflect.TypeOf(time.Duration(0)):
+		return map[string]interface{}{"type": "integer", "description": "nanoseconds"}
+	}
+	switch valueType.Kind() {
+	case reflect.Ptr:
+		return Of(valueType.Elem(), definitions)
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if valueType.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": Of(valueType.Elem(), definitions)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": Of(valueType.Elem(), definitions)}
+	case reflect.Struct:
+		name := valueType.Name()
+		if name == "" {
+			return structSchema(valueType, definitions)
+		}
+		if _, ok := definitions[name]; !ok {
+			// * a placeholder first, so recursive types terminate
+			definitions[name] = map[string]interface{}{}
+			definitions[name] = structSchema(valueType, definitions)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	}
+	// * interfaces hold any JSON value
+	return map[string]interface{}{}
+}
+
+// structSchema returns the object schema of the struct, embedded structs being flattened like encoding/json does.
+func structSchema(structType reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	var addFields func(structType reflect.Type)
+	addFields = func(structType reflect.Type) {
+		for index := 0; index < structType.NumField(); index++ {
+			field := structType.Field(index)
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				addFields(field.Type)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = Of(field.Type, definitions)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(structType)
+	// * no additionalProperties: false, fields are added within a contract version
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,304 @@
+// Package metrics exports the usage of a ThreatMatrixClient as Prometheus metrics: the requests by route and
+// status, their duration, the jobs submitted and the submissions failed, and the retries.
+//
+// A Collector is the Telemetry of the client, it writes its metrics in the Prometheus text exposition format
+// (it is an http.Handler too), which keeps the SDK free of the Prometheus client dependency:
+//
+//	collector := metrics.NewCollector(nil)
+//	client := gothreatmatrix.NewThreatMatrixClient(&gothreatmatrix.ThreatMatrixClientOptions{
+//		Url:             url,
+//		Token:           token,
+//		EnableTelemetry: true,
+//		Telemetry:       collector,
+//	}, nil, nil)
+//	http.Handle("/metrics/threatmatrix", collector)
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// DEFAULT_NAMESPACE prefixes the names of the metrics when CollectorOptions leaves Namespace empty.
+const DEFAULT_NAMESPACE = "threatmatrix"
+
+// DEFAULT_DURATION_BUCKETS are the upper bounds, in seconds, of the request duration histogram when
+// CollectorOptions leaves Buckets empty.
+var DEFAULT_DURATION_BUCKETS = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// submissionRoutes are the routes creating jobs.
+var submissionRoutes = map[string]bool{
+	constants.ANALYZE_OBSERVABLE_URL:           true,
+	constants.ANALYZE_MULTIPLE_OBSERVABLES_URL: true,
+	constants.ANALYZE_FILE_URL:                 true,
+	constants.ANALYZE_MULTIPLE_FILES_URL:       true,
+}
+
+// CollectorOptions represents the fields used to configure NewCollector.
+type CollectorOptions struct {
+	// Namespace prefixes the names of the metrics, DEFAULT_NAMESPACE when empty, e.g. "soc_pipeline" for
+	// soc_pipeline_requests_total.
+	Namespace string
+	// Buckets are the upper bounds, in seconds and ascending, of the request duration histogram,
+	// DEFAULT_DURATION_BUCKETS when empty.
+	Buckets []float64
+	// Next, when set, also receives the spans and records of the calls, e.g. the OpenTelemetry adapter the client
+	// used before.
+	Next gothreatmatrix.Telemetry
+}
+
+// routeKey identifies the calls to a route.
+type routeKey struct {
+	method string
+	route  string
+}
+
+// requestKey identifies the calls to a route answered with a status.
+type requestKey struct {
+	routeKey
+	status string
+}
+
+// histogram represents the durations of the calls to a route.
+type histogram struct {
+	// counts are the calls of every bucket, not cumulated.
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Collector is a gothreatmatrix.Telemetry turning the calls of the clients using it into Prometheus metrics:
+//
+//   - <namespace>_requests_total, by method, route and status, "none" when no response was received
+//   - <namespace>_request_duration_seconds, a histogram by method and route, retries included
+//   - <namespace>_jobs_submitted_total, the successful analysis submissions by route
+//   - <namespace>_jobs_failed_total, the failed analysis submissions by route
+//   - <namespace>_retries_total, by method and route
+//
+// The routes have their IDs and plugin names replaced, e.g. /api/jobs/{id}, which keeps the cardinality low.
+// A Collector is safe for concurrent use, and can be shared by several clients.
+type Collector struct {
+	namespace string
+	buckets   []float64
+	next      gothreatmatrix.Telemetry
+
+	mutex         sync.Mutex
+	requests      map[requestKey]uint64
+	durations     map[routeKey]*histogram
+	jobsSubmitted map[string]uint64
+	jobsFailed    map[string]uint64
+	retries       map[routeKey]uint64
+}
+
+// NewCollector returns an empty Collector, options may be nil.
+func NewCollector(options *CollectorOptions) *Collector {
+	if options == nil {
+		options = &CollectorOptions{}
+	}
+	collector := &Collector{
+		namespace:     options.Namespace,
+		buckets:       append([]float64{}, options.Buckets...),
+		next:          options.Next,
+		requests:      map[requestKey]uint64{},
+		durations:     map[routeKey]*histogram{},
+		jobsSubmitted: map[string]uint64{},
+		jobsFailed:    map[string]uint64{},
+		retries:       map[routeKey]uint64{},
+	}
+	if collector.namespace == "" {
+		collector.namespace = DEFAULT_NAMESPACE
+	}
+	if len(collector.buckets) == 0 {
+		collector.buckets = append(collector.buckets, DEFAULT_DURATION_BUCKETS...)
+	}
+	sort.Float64s(collector.buckets)
+	return collector
+}
+
+// noopSpan is the span of a Collector without a Next Telemetry.
+type noopSpan struct{}
+
+// End implements gothreatmatrix.TelemetrySpan.
+func (noopSpan) End(attributes map[string]interface{}, err error) {}
+
+// StartSpan implements gothreatmatrix.Telemetry, starting the span of the Next Telemetry, if any.
+func (collector *Collector) StartSpan(ctx context.Context, name string, attributes map[string]interface{}) (context.Context, gothreatmatrix.TelemetrySpan) {
+	if collector.next != nil {
+		return collector.next.StartSpan(ctx, name, attributes)
+	}
+	return ctx, noopSpan{}
+}
+
+// RecordRequest implements gothreatmatrix.Telemetry.
+func (collector *Collector) RecordRequest(ctx context.Context, record gothreatmatrix.RequestRecord) {
+	if collector.next != nil {
+		collector.next.RecordRequest(ctx, record)
+	}
+	key := routeKey{method: record.Method, route: record.Route}
+	status := "none"
+	if record.StatusCode != 0 {
+		status = strconv.Itoa(record.StatusCode)
+	}
+	seconds := record.Duration.Seconds()
+
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	collector.requests[requestKey{routeKey: key, status: status}]++
+	durations, ok := collector.durations[key]
+	if !ok {
+		durations = &histogram{counts: make([]uint64, len(collector.buckets))}
+		collector.durations[key] = durations
+	}
+	durations.count++
+	durations.sum += seconds
+	for index, bound := range collector.buckets {
+		if seconds <= bound {
+			durations.counts[index]++
+			break
+		}
+	}
+	if record.Retries > 0 {
+		collector.retries[key] += uint64(record.Retries)
+	}
+	if submissionRoutes[record.Route] {
+		if record.Err != nil {
+			collector.jobsFailed[record.Route]++
+		} else {
+			collector.jobsSubmitted[record.Route]++
+		}
+	}
+}
+
+// Write writes the metrics in the Prometheus text exposition format, the series sorted by their labels,
+// e.g. to be appended to the output of an existing /metrics handler.
+func (collector *Collector) Write(writer io.Writer) error {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+	name := func(metric string) string {
+		return collector.namespace + "_" + metric
+	}
+
+	requests := name("requests_total")
+	if err := writeHeader(writer, requests, "counter", "API calls made, by method, route and status."); err != nil {
+		return err
+	}
+	requestKeys := make([]requestKey, 0, len(collector.requests))
+	for key := range collector.requests {
+		requestKeys = append(requestKeys, key)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		if requestKeys[i].routeKey != requestKeys[j].routeKey {
+			return lessRoute(requestKeys[i].routeKey, requestKeys[j].routeKey)
+		}
+		return requestKeys[i].status < requestKeys[j].status
+	})
+	for _, key := range requestKeys {
+		if _, err := fmt.Fprintf(writer, "%s{method=%q,route=%q,status=%q} %d\n", requests, key.method, key.route, key.status, collector.requests[key]); err != nil {
+			return err
+		}
+	}
+
+	durations := name("request_duration_seconds")
+	if err := writeHeader(writer, durations, "histogram", "Duration of the API calls, retries included, by method and route."); err != nil {
+		return err
+	}
+	for _, key := range sortedRoutes(collector.durations) {
+		histogram := collector.durations[key]
+		cumulated := uint64(0)
+		for index, bound := range collector.buckets {
+			cumulated += histogram.counts[index]
+			if _, err := fmt.Fprintf(writer, "%s_bucket{method=%q,route=%q,le=%q} %d\n", durations, key.method, key.route, formatFloat(bound), cumulated); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(writer, "%s_bucket{method=%q,route=%q,le=\"+Inf\"} %d\n", durations, key.method, key.route, histogram.count); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(writer, "%s_sum{method=%q,route=%q} %s\n", durations, key.method, key.route, formatFloat(histogram.sum)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(writer, "%s_count{method=%q,route=%q} %d\n", durations, key.method, key.route, histogram.count); err != nil {
+			return err
+		}
+	}
+
+	jobCounters := []struct {
+		metric string
+		help   string
+		counts map[string]uint64
+	}{
+		{"jobs_submitted_total", "Analysis submissions accepted, by route.", collector.jobsSubmitted},
+		{"jobs_failed_total", "Analysis submissions failed, by route.", collector.jobsFailed},
+	}
+	for _, counter := range jobCounters {
+		if err := writeHeader(writer, name(counter.metric), "counter", counter.help); err != nil {
+			return err
+		}
+		routes := make([]string, 0, len(counter.counts))
+		for route := range counter.counts {
+			routes = append(routes, route)
+		}
+		sort.Strings(routes)
+		for _, route := range routes {
+			if _, err := fmt.Fprintf(writer, "%s{route=%q} %d\n", name(counter.metric), route, counter.counts[route]); err != nil {
+				return err
+			}
+		}
+	}
+
+	retries := name("retries_total")
+	if err := writeHeader(writer, retries, "counter", "API calls sent again after a failure, by method and route."); err != nil {
+		return err
+	}
+	for _, key := range sortedRoutes(collector.retries) {
+		if _, err := fmt.Fprintf(writer, "%s{method=%q,route=%q} %d\n", retries, key.method, key.route, collector.retries[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP serves the metrics, see Write.
+func (collector *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	collector.Write(w)
+}
+
+// writeHeader writes the HELP and TYPE lines of the metric.
+func writeHeader(writer io.Writer, name string, metricType string, help string) error {
+	_, err := fmt.Fprintf(writer, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+	return err
+}
+
+// sortedRoutes returns the keys of the map, sorted by route then method.
+func sortedRoutes[T any](values map[routeKey]T) []routeKey {
+	keys := make([]routeKey, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lessRoute(keys[i], keys[j])
+	})
+	return keys
+}
+
+// lessRoute orders the routes by route then method.
+func lessRoute(first routeKey, second routeKey) bool {
+	if first.route != second.route {
+		return first.route < second.route
+	}
+	return first.method < second.method
+}
+
+// formatFloat formats the value the shortest way, e.g. 0.25 or 10.
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
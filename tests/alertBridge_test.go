@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestAlertBridge(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.BASE_INVESTIGATION_URL, func(w http.ResponseWriter, r *http.Request) {
+		params := gothreatmatrix.InvestigationParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		testWantData(t, "C2 beacon: Beaconing to rare domain", params.Name)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 7, "name": "C2 beacon"}`))
+	})
+	submissions := []string{}
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		params := gothreatmatrix.ObservableAnalysisParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		submissions = append(submissions, params.ObservableName+" "+params.PlaybookRequested)
+		fmt.Fprintf(w, `{"job_id": %d, "status": "accepted"}`, len(submissions))
+	})
+	apiHandler.HandleFunc(fmt.Sprintf(constants.ADD_JOB_TO_INVESTIGATION_URL, 7), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	template := gothreatmatrix.InvestigationTemplate{
+		Name:      "C2 beacon",
+		Playbooks: map[string][]string{"ip": {"Popular_IP_Reputation_Services"}, "domain": {"Dns"}},
+	}
+	bridge := client.NewAlertBridge(&template, &gothreatmatrix.AlertBridgeOptions{
+		NameField:       "rule.name",
+		IndicatorFields: []string{"source.ip", "destinations.domain", "rule.name"},
+		Secret:          "webhook-secret",
+	})
+	alert := `{"rule": {"name": "Beaconing to rare domain"}, "source": {"ip": "10.1.2.3"}, "destinations": [{"domain": "evil.example.com"}, {"domain": "EVIL.example.com"}]}`
+	sign := func(body string) string {
+		signature := hmac.New(sha256.New, []byte("webhook-secret"))
+		signature.Write([]byte(body))
+		return hex.EncodeToString(signature.Sum(nil))
+	}
+
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["signed"] = TestData{
+		Input:      []string{alert, sign(alert)},
+		StatusCode: http.StatusCreated,
+	}
+	testCases["unsigned"] = TestData{
+		Input:      []string{alert, ""},
+		StatusCode: http.StatusUnauthorized,
+	}
+	testCases["no indicator"] = TestData{
+		Input:      []string{`{"rule": {"name": "Login failure"}}`, sign(`{"rule": {"name": "Login failure"}}`)},
+		StatusCode: http.StatusBadRequest,
+	}
+	testCases["not json"] = TestData{
+		Input:      []string{`[1, 2]`, sign(`[1, 2]`)},
+		StatusCode: http.StatusBadRequest,
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			input := testCase.Input.([]string)
+			request := httptest.NewRequest("POST", "/alerts", strings.NewReader(input[0]))
+			request.Header.Set(gothreatmatrix.SIGNATURE_HEADER, input[1])
+			recorder := httptest.NewRecorder()
+			bridge.ServeHTTP(recorder, request)
+			testWantData(t, testCase.StatusCode, recorder.Code)
+			if recorder.Code != http.StatusCreated {
+				return
+			}
+			response := gothreatmatrix.AlertBridgeResponse{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, gothreatmatrix.AlertBridgeResponse{
+				InvestigationID:  7,
+				InvestigationUrl: client.InvestigationService.WebUrl(7),
+				Indicators:       []string{"10.1.2.3", "evil.example.com"},
+				Jobs:             []uint64{1, 2},
+				Skipped:          []string{},
+			}, response)
+		})
+	}
+	testWantData(t, []string{"10.1.2.3 Popular_IP_Reputation_Services", "evil.example.com Dns"}, submissions)
+}
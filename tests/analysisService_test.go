@@ -3,10 +3,15 @@ package tests
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"path"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/khulnasoft/go-threatmatrix/constants"
 	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
@@ -214,3 +219,662 @@ func TestCreateMultipleFilesAnalysis(t *testing.T) {
 	}
 
 }
+
+func TestCreateFileAnalysisExtraFields(t *testing.T) {
+	analysisJsonString := `{"job_id":272,"status":"accepted","warnings":[],"analyzers_running":["File_Info"],"connectors_running":[]}`
+	analysisResponse := gothreatmatrix.AnalysisResponse{}
+	if unmarshalError := json.Unmarshal([]byte(analysisJsonString), &analysisResponse); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	file, err := os.Open(path.Join("./testFiles/", "fileForAnalysis.txt"))
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	defer file.Close()
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input: gothreatmatrix.FileAnalysisParams{
+			BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{
+				Tlp:                gothreatmatrix.AMBER,
+				AnalyzersRequested: []string{"File_Info"},
+				ScanMode:           gothreatmatrix.SCAN_MODE_CHECK_PREVIOUS,
+				ScanCheckTime:      gothreatmatrix.FormatScanCheckTime(36 * time.Hour),
+			},
+			File: file,
+			ExtraFields: map[string]string{
+				"original_path": `C:\Users\victim\Downloads\invoice.txt`,
+				"source_system": "edr-01",
+			},
+		},
+		Data:       analysisJsonString,
+		StatusCode: http.StatusOK,
+		Want:       &analysisResponse,
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					t.Fatalf("Could not parse the form: %v", err)
+				}
+				testWantData(t, "AMBER", r.FormValue("tlp"))
+				testWantData(t, []string{"File_Info"}, r.MultipartForm.Value["analyzers_requested"])
+				testWantData(t, `C:\Users\victim\Downloads\invoice.txt`, r.FormValue("original_path"))
+				testWantData(t, "edr-01", r.FormValue("source_system"))
+				testWantData(t, "2", r.FormValue("scan_mode"))
+				testWantData(t, "1 12:00:00", r.FormValue("scan_check_time"))
+				if _, _, err := r.FormFile("file"); err != nil {
+					t.Fatalf("Missing file: %v", err)
+				}
+				w.Write([]byte(testCase.Data))
+			})
+			ctx := context.Background()
+			fileAnalysisParams, ok := testCase.Input.(gothreatmatrix.FileAnalysisParams)
+			if ok {
+				gottenFileAnalysisResponse, err := client.CreateFileAnalysis(ctx, &fileAnalysisParams)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, testCase.Want, gottenFileAnalysisResponse)
+				}
+			} else {
+				t.Fatalf("Casting failed!")
+			}
+		})
+	}
+}
+
+func TestCreateFileAnalysisReservedExtraFields(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	requests := 0
+	apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	})
+	apiHandler.HandleFunc(constants.ANALYZE_MULTIPLE_FILES_URL, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	})
+	file, err := os.Open(path.Join("./testFiles/", "fileForAnalysis.txt"))
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	defer file.Close()
+	ctx := context.Background()
+	for _, fieldName := range []string{"tlp", "analyzers_requested", "connectors_requested", "file", "observable_name"} {
+		_, err := client.CreateFileAnalysis(ctx, &gothreatmatrix.FileAnalysisParams{
+			File:        file,
+			ExtraFields: map[string]string{"source_system": "edr-01", fieldName: "CLEAR"},
+		})
+		if !errors.Is(err, gothreatmatrix.ErrReservedExtraField) {
+			t.Errorf("%s: expected ErrReservedExtraField, got %v", fieldName, err)
+		}
+	}
+	_, err = client.CreateMultipleFileAnalysis(ctx, &gothreatmatrix.MultipleFileAnalysisParams{
+		Files:       []*os.File{file},
+		ExtraFields: map[string]string{"files": "x"},
+	})
+	if !errors.Is(err, gothreatmatrix.ErrReservedExtraField) {
+		t.Errorf("Expected ErrReservedExtraField, got %v", err)
+	}
+	_, err = client.CreateFileAnalysisFromReader(ctx, &gothreatmatrix.StreamFileAnalysisParams{
+		Reader:      strings.NewReader("content"),
+		FileName:    "sample.txt",
+		ExtraFields: map[string]string{"tlp": "CLEAR"},
+	})
+	if !errors.Is(err, gothreatmatrix.ErrReservedExtraField) {
+		t.Errorf("Expected ErrReservedExtraField, got %v", err)
+	}
+	testWantData(t, 0, requests)
+}
+
+func TestCheckExisting(t *testing.T) {
+	testCases := make(map[string]TestData)
+	testCases["exists"] = TestData{
+		Data:       `{"status":"running","job_id":42,"analyzers_to_execute":["Classic_DNS"]}`,
+		StatusCode: http.StatusOK,
+		Want:       true,
+	}
+	testCases["notAvailable"] = TestData{
+		Data:       `{"status":"not_available"}`,
+		StatusCode: http.StatusOK,
+		Want:       false,
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.HandleFunc(constants.ASK_ANALYSIS_AVAILABILITY_URL, func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				params := gothreatmatrix.AnalysisAvailabilityParams{}
+				if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+					t.Fatalf("Could not parse request body: %v", err)
+				}
+				// * the md5 of dns.google
+				testWantData(t, gothreatmatrix.AnalysisAvailabilityParams{
+					Md5:        "1872746d244c489367a7b543c484e60b",
+					Analyzers:  []string{"Classic_DNS"},
+					MinutesAgo: 60,
+				}, params)
+				w.Write([]byte(testCase.Data))
+			})
+			availability, err := client.CheckExisting(context.Background(), "dns.google", &gothreatmatrix.AnalysisAvailabilityParams{
+				Analyzers:  []string{"Classic_DNS"},
+				MinutesAgo: 60,
+			})
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, availability.Exists())
+			}
+		})
+	}
+}
+
+func TestSubmitByHash(t *testing.T) {
+	testCases := make(map[string]TestData)
+	testCases["alreadyAnalyzed"] = TestData{
+		Input:      `{"status":"reported_without_fails","job_id":42,"analyzers_to_execute":["File_Info"]}`,
+		StatusCode: http.StatusOK,
+		Want: &gothreatmatrix.AnalysisResponse{
+			JobID:            42,
+			Status:           "reported_without_fails",
+			AnalyzersRunning: []string{"File_Info"},
+		},
+	}
+	testCases["notAvailable"] = TestData{
+		Input:      `{"status":"not_available"}`,
+		Data:       `{"job_id":273,"status":"accepted","warnings":[],"analyzers_running":["File_Info"],"connectors_running":[]}`,
+		StatusCode: http.StatusOK,
+		Want: &gothreatmatrix.AnalysisResponse{
+			JobID:             273,
+			Status:            "accepted",
+			Warnings:          []string{},
+			AnalyzersRunning:  []string{"File_Info"},
+			ConnectorsRunning: []string{},
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			file, err := os.Open(path.Join("./testFiles/", "fileForAnalysis.txt"))
+			if err != nil {
+				t.Fatalf("Error: %s", err)
+			}
+			defer file.Close()
+			// * a file read before: it is hashed and uploaded from its current offset
+			content, _ := io.ReadAll(file)
+			content = content[5:]
+			file.Seek(5, io.SeekStart)
+			availabilityJson, _ := testCase.Input.(string)
+			apiHandler.HandleFunc(constants.ASK_ANALYSIS_AVAILABILITY_URL, func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				params := gothreatmatrix.AnalysisAvailabilityParams{}
+				if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+					t.Fatalf("Could not parse request body: %v", err)
+				}
+				testWantData(t, "514a7c0c380ada80a79927ab1ae22327", params.Md5)
+				testWantData(t, "73e293d5ff42d4f35d0ffa1ff0dc0d7068602fd6e7fdb17cc0db0a7228e38f5b", params.Sha256)
+				w.Write([]byte(availabilityJson))
+			})
+			uploaded := false
+			apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				uploaded = true
+				uploadedFile, _, err := r.FormFile("file")
+				if err != nil {
+					t.Errorf("Missing file: %v", err)
+					return
+				}
+				uploadedContent, _ := io.ReadAll(uploadedFile)
+				if string(uploadedContent) != string(content) {
+					t.Errorf("Uploaded %q, want %q", uploadedContent, content)
+				}
+				w.Write([]byte(testCase.Data))
+			})
+			ctx := context.Background()
+			gottenAnalysisResponse, err := client.SubmitByHash(ctx, &gothreatmatrix.FileAnalysisParams{
+				BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{
+					AnalyzersRequested: []string{"File_Info"},
+				},
+				File: file,
+			})
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, gottenAnalysisResponse)
+				testWantData(t, testCase.Data != "", uploaded)
+			}
+		})
+	}
+}
+
+func TestCreateFileAnalysisUploadThrottling(t *testing.T) {
+	analysisJsonString := `{"job_id":274,"status":"accepted","warnings":[],"analyzers_running":["File_Info"],"connectors_running":[]}`
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		UploadBytesPerSecond: 2048,
+	})
+	defer closeServer()
+	var receivedBytes int64
+	apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		receivedBytes, _ = io.Copy(ioutil.Discard, r.Body)
+		w.Write([]byte(analysisJsonString))
+	})
+	file, err := os.Open(path.Join("./testFiles/", "fileForAnalysis.txt"))
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	defer file.Close()
+	start := time.Now()
+	_, err = client.CreateFileAnalysis(context.Background(), &gothreatmatrix.FileAnalysisParams{File: file})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	elapsed := time.Since(start)
+	minimum := time.Duration(float64(receivedBytes)/2048*float64(time.Second)) - 100*time.Millisecond
+	if elapsed < minimum {
+		t.Fatalf("Upload of %d bytes took %v, expected at least %v", receivedBytes, elapsed, minimum)
+	}
+}
+
+func TestCreateMultipleFileAnalysisUploadThrottling(t *testing.T) {
+	multiAnalysisJsonString := `{"count":2,"results":[{"job_id":280,"status":"accepted"},{"job_id":281,"status":"accepted"}]}`
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		Timeout:              1,
+		UploadBytesPerSecond: 512,
+	})
+	defer closeServer()
+	var contentLength int64
+	var gottenFiles []string
+	apiHandler.HandleFunc(constants.ANALYZE_MULTIPLE_FILES_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		contentLength = r.ContentLength
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Could not parse the form: %v", err)
+			return
+		}
+		for _, fileHeader := range r.MultipartForm.File["files"] {
+			file, _ := fileHeader.Open()
+			content, _ := io.ReadAll(file)
+			file.Close()
+			gottenFiles = append(gottenFiles, fileHeader.Filename+":"+string(content))
+		}
+		w.Write([]byte(multiAnalysisJsonString))
+	})
+	files := make([]*os.File, 0, 2)
+	for _, fileName := range []string{"fileForAnalysis.txt", "fileForAnalysis2.txt"} {
+		file, err := os.Open(path.Join("./testFiles/", fileName))
+		if err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		defer file.Close()
+		files = append(files, file)
+	}
+	// * the throttled upload takes longer than the Timeout of the client
+	start := time.Now()
+	_, err := client.CreateMultipleFileAnalysis(context.Background(), &gothreatmatrix.MultipleFileAnalysisParams{Files: files})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("Upload of %d bytes took %v, expected it to outlast the Timeout", contentLength, elapsed)
+	}
+	if contentLength <= 0 {
+		t.Errorf("Expected the Content-Length of the streamed form, got %d", contentLength)
+	}
+	content1, _ := os.ReadFile(path.Join("./testFiles/", "fileForAnalysis.txt"))
+	content2, _ := os.ReadFile(path.Join("./testFiles/", "fileForAnalysis2.txt"))
+	testWantData(t, []string{"fileForAnalysis.txt:" + string(content1), "fileForAnalysis2.txt:" + string(content2)}, gottenFiles)
+}
+
+func TestDefaultPlaybooks(t *testing.T) {
+	testCases := make(map[string]TestData)
+	testCases["observable"] = TestData{
+		Input: gothreatmatrix.ObservableAnalysisParams{
+			ObservableName:           "8.8.8.8",
+			ObservableClassification: "ip",
+		},
+		Want: "IP_Reputation",
+	}
+	testCases["observableWithAnalyzers"] = TestData{
+		Input: gothreatmatrix.ObservableAnalysisParams{
+			BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{
+				AnalyzersRequested: []string{"Classic_DNS"},
+			},
+			ObservableName:           "8.8.8.8",
+			ObservableClassification: "ip",
+		},
+		Want: "",
+	}
+	testCases["fileFallback"] = TestData{
+		Input: "fileForAnalysis.txt",
+		Want:  "Sample_Static_Analysis",
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+				DefaultPlaybooks: map[string]string{
+					"ip": "IP_Reputation",
+					"application/vnd.microsoft.portable-executable": "Sandbox",
+					gothreatmatrix.DEFAULT_FILE_PLAYBOOK_KEY:        "Sample_Static_Analysis",
+				},
+			})
+			defer closeServer()
+			ctx := context.Background()
+			apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+				params := gothreatmatrix.ObservableAnalysisParams{}
+				if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+					t.Errorf("Could not parse request body: %v", err)
+				}
+				testWantData(t, testCase.Want, params.PlaybookRequested)
+				w.Write([]byte(`{"job_id":1,"status":"accepted"}`))
+			})
+			apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					t.Errorf("Could not parse the form: %v", err)
+				}
+				testWantData(t, testCase.Want, r.FormValue("playbook_requested"))
+				w.Write([]byte(`{"job_id":2,"status":"accepted"}`))
+			})
+			switch input := testCase.Input.(type) {
+			case gothreatmatrix.ObservableAnalysisParams:
+				if _, err := client.CreateObservableAnalysis(ctx, &input); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				// the caller's params are left untouched
+				testWantData(t, "", input.PlaybookRequested)
+			case string:
+				file, err := os.Open(path.Join("./testFiles/", input))
+				if err != nil {
+					t.Fatalf("Error: %s", err)
+				}
+				defer file.Close()
+				if _, err := client.CreateFileAnalysis(ctx, &gothreatmatrix.FileAnalysisParams{File: file}); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			default:
+				t.Fatalf("Casting failed!")
+			}
+		})
+	}
+}
+
+func TestCreateFileAnalysisFromOffset(t *testing.T) {
+	testCases := map[string]struct {
+		options      gothreatmatrix.ThreatMatrixClientOptions
+		wantPlaybook string
+	}{
+		"noDefaultPlaybooks": {},
+		// * the mime type is sniffed from the offset too
+		"defaultPlaybooks": {
+			options: gothreatmatrix.ThreatMatrixClientOptions{
+				DefaultPlaybooks: map[string]string{"application/pdf": "Pdf_Analysis", gothreatmatrix.DEFAULT_FILE_PLAYBOOK_KEY: "Sample_Static_Analysis"},
+			},
+			wantPlaybook: "Pdf_Analysis",
+		},
+		// * the file is hashed from the offset, and left there
+		"reuseRecentResults":        {options: gothreatmatrix.ThreatMatrixClientOptions{ReuseRecentResults: true, DedupWindowMinutes: 10}},
+		"dedupeInFlightSubmissions": {options: gothreatmatrix.ThreatMatrixClientOptions{DedupeInFlightSubmissions: true}},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			options := testCase.options
+			client, apiHandler, closeServer := setupWithOptions(&options)
+			defer closeServer()
+			apiHandler.HandleFunc(constants.ASK_ANALYSIS_AVAILABILITY_URL, func(w http.ResponseWriter, r *http.Request) {
+				params := gothreatmatrix.AnalysisAvailabilityParams{}
+				json.NewDecoder(r.Body).Decode(&params)
+				if params.Md5 != "5ba2a6c5eb5df43777faa787610f69de" {
+					t.Errorf("Md5 %q, want the one of the part from the offset", params.Md5)
+				}
+				w.Write([]byte(`{"status":"not_available"}`))
+			})
+			apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+				uploadedFile, _, err := r.FormFile("file")
+				if err != nil {
+					t.Errorf("Missing file: %v", err)
+					return
+				}
+				uploadedContent, _ := io.ReadAll(uploadedFile)
+				if string(uploadedContent) != "%PDF-1.4 payload" {
+					t.Errorf("Uploaded %q, want %q", uploadedContent, "%PDF-1.4 payload")
+				}
+				if playbook := r.FormValue("playbook_requested"); playbook != testCase.wantPlaybook {
+					t.Errorf("Playbook %q, want %q", playbook, testCase.wantPlaybook)
+				}
+				w.Write([]byte(`{"job_id":1,"status":"accepted"}`))
+			})
+			filePath := path.Join(t.TempDir(), "sample.bin")
+			os.WriteFile(filePath, []byte("HEADER%PDF-1.4 payload"), 0600)
+			file, err := os.Open(filePath)
+			if err != nil {
+				t.Fatalf("Error: %s", err)
+			}
+			defer file.Close()
+			// * the file is uploaded from its current offset
+			file.Seek(6, io.SeekStart)
+			if _, err := client.CreateFileAnalysis(context.Background(), &gothreatmatrix.FileAnalysisParams{File: file}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestAnalyzerPolicy(t *testing.T) {
+	analyzerConfigJsonString := `{
+		"Classic_DNS": {"name": "Classic_DNS", "disabled": false},
+		"Shodan_Search": {"name": "Shodan_Search", "disabled": false},
+		"VirusTotal_v3_Get_Observable": {"name": "VirusTotal_v3_Get_Observable", "disabled": false},
+		"Old_Analyzer": {"name": "Old_Analyzer", "disabled": true}
+	}`
+	testCases := make(map[string]TestData)
+	testCases["explicitAnalyzers"] = TestData{
+		Input: gothreatmatrix.BasicAnalysisParams{
+			Tlp:                gothreatmatrix.WHITE,
+			AnalyzersRequested: []string{"Classic_DNS", "VirusTotal_v3_Get_Observable"},
+			RuntimeConfiguration: map[string]interface{}{
+				"VirusTotal_v3_Get_Observable": map[string]interface{}{"max_tries": 3},
+				"Classic_DNS":                  map[string]interface{}{"query_type": "A"},
+			},
+		},
+		Want: gothreatmatrix.BasicAnalysisParams{
+			Tlp:                gothreatmatrix.WHITE,
+			AnalyzersRequested: []string{"Classic_DNS"},
+			RuntimeConfiguration: map[string]interface{}{
+				"Classic_DNS": map[string]interface{}{"query_type": "A"},
+			},
+		},
+	}
+	testCases["playbook"] = TestData{
+		Input: gothreatmatrix.BasicAnalysisParams{
+			Tlp:               gothreatmatrix.WHITE,
+			PlaybookRequested: "FREE_TO_USE_ANALYZERS",
+			RuntimeConfiguration: map[string]interface{}{
+				"analyzers": map[string]interface{}{
+					"VirusTotal_v3_Get_Observable": map[string]interface{}{"max_tries": 3},
+				},
+			},
+		},
+		Want: gothreatmatrix.BasicAnalysisParams{
+			Tlp:                gothreatmatrix.WHITE,
+			AnalyzersRequested: []string{"Classic_DNS", "Shodan_Search"},
+			RuntimeConfiguration: map[string]interface{}{
+				"analyzers": map[string]interface{}{},
+			},
+			PlaybookRequested: "FREE_TO_USE_ANALYZERS",
+		},
+	}
+	testCases["nothingAllowed"] = TestData{
+		Input: gothreatmatrix.BasicAnalysisParams{
+			Tlp:                gothreatmatrix.WHITE,
+			AnalyzersRequested: []string{"VirusTotal_v3_Get_Observable"},
+		},
+		Want: gothreatmatrix.ErrNoAnalyzerAllowed,
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+				AnalyzersDenied: []string{"VirusTotal_v3_Get_Observable"},
+			})
+			defer closeServer()
+			ctx := context.Background()
+			apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, TestData{StatusCode: http.StatusOK, Data: analyzerConfigJsonString}, "GET"))
+			apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+				params := gothreatmatrix.ObservableAnalysisParams{}
+				if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+					t.Errorf("Could not parse request body: %v", err)
+				}
+				testWantData(t, testCase.Want, params.BasicAnalysisParams)
+				w.Write([]byte(`{"job_id":1,"status":"accepted"}`))
+			})
+			basicAnalysisParams, ok := testCase.Input.(gothreatmatrix.BasicAnalysisParams)
+			if !ok {
+				t.Fatalf("Casting failed!")
+			}
+			_, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+				BasicAnalysisParams:      basicAnalysisParams,
+				ObservableName:           "8.8.8.8",
+				ObservableClassification: "ip",
+			})
+			if wantError, isError := testCase.Want.(error); isError {
+				testWantData(t, true, errors.Is(err, wantError))
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestTLPEnforcement(t *testing.T) {
+	analyzerConfigJsonString := `{
+		"Classic_DNS": {"name": "Classic_DNS", "disabled": false},
+		"Shodan_Search": {"name": "Shodan_Search", "disabled": false, "external_service": true},
+		"Yara": {"name": "Yara", "disabled": false, "leaks_info": true}
+	}`
+	testCases := map[string]struct {
+		enforcement gothreatmatrix.TLPEnforcement
+		params      gothreatmatrix.BasicAnalysisParams
+		// want is the analyzers submitted, nil when the analysis is rejected
+		want []string
+	}{
+		"amberExcluded": {
+			enforcement: gothreatmatrix.TLP_ENFORCEMENT_EXCLUDE,
+			params:      gothreatmatrix.BasicAnalysisParams{Tlp: gothreatmatrix.AMBER, AnalyzersRequested: []string{"Classic_DNS", "Shodan_Search"}},
+			want:        []string{"Classic_DNS"},
+		},
+		"redPlaybook": {
+			enforcement: gothreatmatrix.TLP_ENFORCEMENT_EXCLUDE,
+			params:      gothreatmatrix.BasicAnalysisParams{Tlp: gothreatmatrix.RED, PlaybookRequested: "FREE_TO_USE_ANALYZERS"},
+			want:        []string{"Classic_DNS"},
+		},
+		"clearUntouched": {
+			enforcement: gothreatmatrix.TLP_ENFORCEMENT_REJECT,
+			params:      gothreatmatrix.BasicAnalysisParams{Tlp: gothreatmatrix.CLEAR, AnalyzersRequested: []string{"Classic_DNS", "Shodan_Search"}},
+			want:        []string{"Classic_DNS", "Shodan_Search"},
+		},
+		"amberRejected": {
+			enforcement: gothreatmatrix.TLP_ENFORCEMENT_REJECT,
+			params:      gothreatmatrix.BasicAnalysisParams{Tlp: gothreatmatrix.AMBER, AnalyzersRequested: []string{"Classic_DNS", "Yara"}},
+		},
+		"nothingLeft": {
+			enforcement: gothreatmatrix.TLP_ENFORCEMENT_EXCLUDE,
+			params:      gothreatmatrix.BasicAnalysisParams{Tlp: gothreatmatrix.AMBER, AnalyzersRequested: []string{"Shodan_Search"}},
+		},
+		"off": {
+			params: gothreatmatrix.BasicAnalysisParams{Tlp: gothreatmatrix.RED, AnalyzersRequested: []string{"Shodan_Search"}},
+			want:   []string{"Shodan_Search"},
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+				TLPEnforcement: testCase.enforcement,
+			})
+			defer closeServer()
+			apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, TestData{StatusCode: http.StatusOK, Data: analyzerConfigJsonString}, "GET"))
+			apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+				params := gothreatmatrix.ObservableAnalysisParams{}
+				json.NewDecoder(r.Body).Decode(&params)
+				testWantData(t, testCase.want, params.AnalyzersRequested)
+				w.Write([]byte(`{"job_id":1,"status":"accepted"}`))
+			})
+			_, err := client.CreateObservableAnalysis(context.Background(), &gothreatmatrix.ObservableAnalysisParams{
+				BasicAnalysisParams:      testCase.params,
+				ObservableName:           "8.8.8.8",
+				ObservableClassification: "ip",
+			})
+			if testCase.want == nil {
+				if !errors.Is(err, gothreatmatrix.ErrTLPViolation) {
+					t.Errorf("Expected ErrTLPViolation, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+	testWantData(t, gothreatmatrix.WHITE, gothreatmatrix.ParseTLP("CLEAR"))
+}
+
+func TestReuseRecentResults(t *testing.T) {
+	playbookConfigs := `{"DNS": {"name": "DNS", "scan_mode": 2, "scan_check_time": "02:00:00"}, "FRESH": {"name": "FRESH", "scan_mode": 1, "scan_check_time": "1 00:00:00"}}`
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["playbookWindow"] = TestData{
+		Input: gothreatmatrix.BasicAnalysisParams{PlaybookRequested: "DNS"},
+		Want:  120,
+	}
+	testCases["defaultWindow"] = TestData{
+		Input: gothreatmatrix.BasicAnalysisParams{AnalyzersRequested: []string{"Classic_DNS"}},
+		Want:  30,
+	}
+	testCases["playbookForcesNew"] = TestData{
+		Input: gothreatmatrix.BasicAnalysisParams{PlaybookRequested: "FRESH"},
+		Want:  0,
+	}
+	testCases["forceFreshScan"] = TestData{
+		Input: gothreatmatrix.BasicAnalysisParams{PlaybookRequested: "DNS", ForceFreshScan: true},
+		Want:  0,
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+				ReuseRecentResults: true,
+				DedupWindowMinutes: 30,
+			})
+			defer closeServer()
+			ctx := context.Background()
+			minutesAgo := 0
+			apiHandler.Handle(constants.PLAYBOOK_CONFIG_URL, serverHandler(t, TestData{Data: playbookConfigs}, "GET"))
+			apiHandler.HandleFunc(constants.ASK_ANALYSIS_AVAILABILITY_URL, func(w http.ResponseWriter, r *http.Request) {
+				params := gothreatmatrix.AnalysisAvailabilityParams{}
+				if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+					t.Errorf("Could not parse request body: %v", err)
+				}
+				// * md5 of "dns.google"
+				testWantData(t, "1872746d244c489367a7b543c484e60b", params.Md5)
+				minutesAgo = params.MinutesAgo
+				w.Write([]byte(`{"status": "reported_without_fails", "job_id": 42, "analyzers_to_execute": ["Classic_DNS"]}`))
+			})
+			apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"job_id": 43, "status": "accepted"}`))
+			})
+			analysisResponse, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+				BasicAnalysisParams: testCase.Input.(gothreatmatrix.BasicAnalysisParams),
+				ObservableName:      "dns.google",
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.Want, minutesAgo)
+			if testCase.Want == 0 {
+				testWantData(t, 43, analysisResponse.JobID)
+			} else {
+				testWantData(t, 42, analysisResponse.JobID)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+const matrixAnalyzerConfigsJson = `{
+	"Classic_DNS": {"name": "Classic_DNS", "type": "observable", "observable_supported": ["domain", "url"]},
+	"Shodan": {"name": "Shodan", "type": "observable", "external_service": true, "observable_supported": ["ip"]},
+	"AbuseIPDB": {"name": "AbuseIPDB", "type": "observable", "external_service": true, "leaks_info": true, "observable_supported": ["ip"]},
+	"PDF_Info": {"name": "PDF_Info", "type": "file", "supported_filetypes": ["application/pdf"]},
+	"VirusTotal_v3_Get_File": {"name": "VirusTotal_v3_Get_File", "type": "file", "external_service": true, "run_hash": true},
+	"Old_DNS": {"name": "Old_DNS", "type": "observable", "disabled": true, "observable_supported": ["domain"]}
+}`
+
+func TestBuildAnalyzerMatrix(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["defaultTypes"] = TestData{
+		Input: []string(nil),
+		Want: &gothreatmatrix.AnalyzerMatrix{
+			IndicatorTypes: []string{"ip", "url", "domain", "hash", "generic", "file", "application/pdf"},
+			Rows: []gothreatmatrix.AnalyzerMatrixRow{
+				{Analyzer: "AbuseIPDB", MaxTlp: map[string]gothreatmatrix.TLP{"ip": gothreatmatrix.GREEN}},
+				{Analyzer: "Classic_DNS", MaxTlp: map[string]gothreatmatrix.TLP{"url": gothreatmatrix.RED, "domain": gothreatmatrix.RED}},
+				{Analyzer: "PDF_Info", MaxTlp: map[string]gothreatmatrix.TLP{"file": gothreatmatrix.RED, "application/pdf": gothreatmatrix.RED}},
+				{Analyzer: "Shodan", MaxTlp: map[string]gothreatmatrix.TLP{"ip": gothreatmatrix.AMBER}},
+				{Analyzer: "VirusTotal_v3_Get_File", MaxTlp: map[string]gothreatmatrix.TLP{"hash": gothreatmatrix.AMBER, "file": gothreatmatrix.AMBER, "application/pdf": gothreatmatrix.AMBER}},
+			},
+		},
+	}
+	testCases["givenTypes"] = TestData{
+		Input: []string{"domain"},
+		Want: &gothreatmatrix.AnalyzerMatrix{
+			IndicatorTypes: []string{"domain"},
+			Rows: []gothreatmatrix.AnalyzerMatrixRow{
+				{Analyzer: "Classic_DNS", MaxTlp: map[string]gothreatmatrix.TLP{"domain": gothreatmatrix.RED}},
+			},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, TestData{Data: matrixAnalyzerConfigsJson}, "GET"))
+			matrix, err := client.BuildAnalyzerMatrix(context.Background(), testCase.Input.([]string))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.Want, matrix)
+		})
+	}
+}
+
+func TestAnalyzerMatrixWrite(t *testing.T) {
+	matrix := &gothreatmatrix.AnalyzerMatrix{
+		IndicatorTypes: []string{"ip", "domain"},
+		Rows: []gothreatmatrix.AnalyzerMatrixRow{
+			{Analyzer: "Classic_DNS", MaxTlp: map[string]gothreatmatrix.TLP{"domain": gothreatmatrix.RED}},
+			{Analyzer: "Shodan", MaxTlp: map[string]gothreatmatrix.TLP{"ip": gothreatmatrix.AMBER}},
+		},
+	}
+	csvBuffer := &bytes.Buffer{}
+	if err := matrix.WriteCSV(csvBuffer); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, "analyzer,ip,domain\nClassic_DNS,,RED\nShodan,AMBER,\n", csvBuffer.String())
+	markdownBuffer := &bytes.Buffer{}
+	if err := matrix.WriteMarkdown(markdownBuffer); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, "| Analyzer | ip | domain |\n| --- | --- | --- |\n| Classic_DNS |  | RED |\n| Shodan | AMBER |  |\n", markdownBuffer.String())
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/khulnasoft/go-threatmatrix/constants"
 	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
@@ -113,6 +115,7 @@ func TestAnalyzerServiceHealthCheck(t *testing.T) {
 		Want: &gothreatmatrix.ThreatMatrixError{
 			StatusCode: http.StatusBadRequest,
 			Message:    `{"errors": {"detail": "Analyzer doesn't exist"}}`,
+			Errors:     map[string]interface{}{"detail": "Analyzer doesn't exist"},
 		},
 	}
 	for name, testCase := range testCases {
@@ -135,3 +138,168 @@ func TestAnalyzerServiceHealthCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalyzerServiceHealthCheckAll(t *testing.T) {
+	analyzerConfigJsonString := `{
+		"Yara": {"name": "Yara", "disabled": false},
+		"Floss": {"name": "Floss", "disabled": false},
+		"Classic_DNS": {"name": "Classic_DNS", "disabled": true},
+		"PE_Info": {"name": "PE_Info", "disabled": false}
+	}`
+	testCases := map[string]struct {
+		options *gothreatmatrix.HealthCheckAllOptions
+		want    map[string]bool
+	}{
+		"enabled": {
+			options: &gothreatmatrix.HealthCheckAllOptions{Concurrency: 2},
+			want:    map[string]bool{"Yara": true, "Floss": false, "PE_Info": false},
+		},
+		"includeDisabled": {
+			options: &gothreatmatrix.HealthCheckAllOptions{IncludeDisabled: true},
+			want:    map[string]bool{"Yara": true, "Floss": false, "PE_Info": false, "Classic_DNS": true},
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.HandleFunc(constants.ANALYZER_CONFIG_URL, func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(analyzerConfigJsonString))
+			})
+			var inFlight, maxInFlight int32
+			healthCheck := func(status int, data string) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					current := atomic.AddInt32(&inFlight, 1)
+					defer atomic.AddInt32(&inFlight, -1)
+					for {
+						maximum := atomic.LoadInt32(&maxInFlight)
+						if current <= maximum || atomic.CompareAndSwapInt32(&maxInFlight, maximum, current) {
+							break
+						}
+					}
+					time.Sleep(20 * time.Millisecond)
+					w.WriteHeader(status)
+					w.Write([]byte(data))
+				}
+			}
+			apiHandler.HandleFunc(fmt.Sprintf(constants.ANALYZER_HEALTHCHECK_URL, "Yara"), healthCheck(http.StatusOK, `{"status": true}`))
+			apiHandler.HandleFunc(fmt.Sprintf(constants.ANALYZER_HEALTHCHECK_URL, "Floss"), healthCheck(http.StatusOK, `{"status": false}`))
+			apiHandler.HandleFunc(fmt.Sprintf(constants.ANALYZER_HEALTHCHECK_URL, "PE_Info"), healthCheck(http.StatusBadRequest, `{"errors": {"detail": "No healthcheck implemented"}}`))
+			apiHandler.HandleFunc(fmt.Sprintf(constants.ANALYZER_HEALTHCHECK_URL, "Classic_DNS"), healthCheck(http.StatusOK, `{"status": true}`))
+			health, err := client.AnalyzerService.HealthCheckAll(context.Background(), testCase.options)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := map[string]bool{}
+			for analyzerName, pluginHealth := range health {
+				got[analyzerName] = pluginHealth.Healthy
+				testWantData(t, gothreatmatrix.ANALYZER_PLUGIN, pluginHealth.Type)
+			}
+			testWantData(t, testCase.want, got)
+			if health["PE_Info"].Err == nil {
+				t.Errorf("Expected the error of the PE_Info health check")
+			}
+			if testCase.options.Concurrency > 0 && maxInFlight > int32(testCase.options.Concurrency) {
+				t.Errorf("Expected at most %d health checks in flight, got %d", testCase.options.Concurrency, maxInFlight)
+			}
+		})
+	}
+}
+
+func TestAnalyzerServiceFreeLocalOnly(t *testing.T) {
+	analyzerConfigJsonString := `{
+		"Strings_Info": {"name": "Strings_Info", "disabled": false, "external_service": false, "secrets": {}},
+		"Yara": {"name": "Yara", "disabled": false, "external_service": false, "secrets": {"private_repositories": {"required": false}}},
+		"Classic_DNS": {"name": "Classic_DNS", "disabled": true, "external_service": false, "secrets": {}},
+		"VirusTotal_v3_Get_File": {"name": "VirusTotal_v3_Get_File", "disabled": false, "external_service": true, "secrets": {}},
+		"Intezer_Scan": {"name": "Intezer_Scan", "disabled": false, "external_service": false, "secrets": {"api_key_name": {"env_var_key": "INTEZER_KEY", "required": true}}}
+	}`
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      nil,
+		Data:       analyzerConfigJsonString,
+		StatusCode: http.StatusOK,
+		Want:       []string{"Strings_Info", "Yara"},
+	}
+	testCases["serverError"] = TestData{
+		Input:      nil,
+		Data:       `{"error": "Error occurred by the server"}`,
+		StatusCode: http.StatusInternalServerError,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusInternalServerError,
+			Message:    `{"error": "Error occurred by the server"}`,
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, testCase, "GET"))
+			analyzerNames, err := client.AnalyzerService.FreeLocalOnly(ctx)
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, analyzerNames)
+			}
+		})
+	}
+}
+
+func TestAnalyzerServiceEnableDisable(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	ctx := context.Background()
+	methods := []string{}
+	apiHandler.HandleFunc(fmt.Sprintf(constants.ANALYZER_ORGANIZATION_URL, "Shodan_Search"), func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	apiHandler.Handle(fmt.Sprintf(constants.ANALYZER_ORGANIZATION_URL, "notAnAnalyzer"), serverHandler(t, TestData{
+		Data:       `{"detail": "Not found."}`,
+		StatusCode: http.StatusNotFound,
+	}, "POST"))
+	if err := client.AnalyzerService.Disable(ctx, "Shodan_Search"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := client.AnalyzerService.Enable(ctx, "Shodan_Search"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"POST", "DELETE"}, methods)
+	if err := client.AnalyzerService.Disable(ctx, "notAnAnalyzer"); !gothreatmatrix.IsNotFound(err) {
+		t.Errorf("Expected a not found error, got: %v", err)
+	}
+}
+
+func TestAnalyzerConfigsFilter(t *testing.T) {
+	analyzerConfigs := gothreatmatrix.AnalyzerConfigs{
+		{BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "Shodan_Search"}, Type: "observable", ObservableSupported: []string{"ip"}},
+		{BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "Classic_DNS"}, Type: "observable", ObservableSupported: []string{"domain", "url"}},
+		{BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "AbuseIPDB", Disabled: true}, Type: "observable", ObservableSupported: []string{"ip"}},
+		{BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "File_Info"}, Type: "file"},
+		{BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "PE_Info"}, Type: "file", SupportedFiletypes: []string{"application/vnd.microsoft.portable-executable"}},
+		{BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "Strings_Info"}, Type: "file", NotSupportedFiletypes: []string{"application/pdf"}},
+		{BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "VirusTotal_v3_File"}, Type: "file", RunHash: true},
+	}
+	testCases := map[string]struct {
+		filter func() gothreatmatrix.AnalyzerConfigs
+		want   []string
+	}{
+		"ip":      {filter: func() gothreatmatrix.AnalyzerConfigs { return analyzerConfigs.FilterFor("ip") }, want: []string{"Shodan_Search"}},
+		"hash":    {filter: func() gothreatmatrix.AnalyzerConfigs { return analyzerConfigs.FilterFor("hash") }, want: []string{"VirusTotal_v3_File"}},
+		"generic": {filter: func() gothreatmatrix.AnalyzerConfigs { return analyzerConfigs.FilterFor("generic") }, want: []string{}},
+		"pe": {filter: func() gothreatmatrix.AnalyzerConfigs {
+			return analyzerConfigs.FilterForFile("application/vnd.microsoft.portable-executable")
+		}, want: []string{"File_Info", "PE_Info", "Strings_Info", "VirusTotal_v3_File"}},
+		"pdf":      {filter: func() gothreatmatrix.AnalyzerConfigs { return analyzerConfigs.FilterForFile("application/pdf") }, want: []string{"File_Info", "VirusTotal_v3_File"}},
+		"anyFile":  {filter: func() gothreatmatrix.AnalyzerConfigs { return analyzerConfigs.FilterForFile("") }, want: []string{"File_Info", "PE_Info", "Strings_Info", "VirusTotal_v3_File"}},
+		"notAMime": {filter: func() gothreatmatrix.AnalyzerConfigs { return analyzerConfigs.FilterForFile("ip") }, want: []string{}},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			testWantData(t, testCase.want, testCase.filter().Names())
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestAPIErrorHelpers(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["notFound"] = TestData{StatusCode: http.StatusNotFound, Want: []bool{true, false, false}}
+	testCases["unauthorized"] = TestData{StatusCode: http.StatusUnauthorized, Want: []bool{false, true, false}}
+	testCases["rateLimited"] = TestData{StatusCode: http.StatusTooManyRequests, Want: []bool{false, false, true}}
+	testCases["serverError"] = TestData{StatusCode: http.StatusInternalServerError, Want: []bool{false, false, false}}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.Handle(constants.BASE_TAG_URL, serverHandler(t, TestData{StatusCode: testCase.StatusCode, Data: `{"detail": "failed"}`}, "GET"))
+			_, err := client.TagService.List(context.Background())
+			// * the helpers see through wrapping
+			err = fmt.Errorf("listing the tags: %w", err)
+			testWantData(t, testCase.Want, []bool{gothreatmatrix.IsNotFound(err), gothreatmatrix.IsUnauthorized(err), gothreatmatrix.IsRateLimited(err)})
+		})
+	}
+	testWantData(t, false, gothreatmatrix.IsNotFound(errors.New("connection refused")))
+}
+
+func TestAPIErrorDetails(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.BASE_TAG_URL, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(gothreatmatrix.REQUEST_ID_HEADER, "4f1c2a")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors": {"label": ["This field may not be blank."]}}`))
+	}))
+	_, err := client.TagService.Create(context.Background(), &gothreatmatrix.TagParams{})
+	var apiError *gothreatmatrix.APIError
+	if !errors.As(err, &apiError) {
+		t.Fatalf("Expected an *APIError, got %v", err)
+	}
+	testWantData(t, http.StatusBadRequest, apiError.StatusCode)
+	testWantData(t, "4f1c2a", apiError.RequestID)
+	testWantData(t, "", apiError.Detail)
+	testWantData(t, map[string]interface{}{"label": []interface{}{"This field may not be blank."}}, apiError.Errors)
+	if apiError.Response == nil {
+		t.Errorf("Expected the raw response")
+	}
+}
@@ -0,0 +1,203 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+const applyStateYaml = `tags:
+  - label: phishing
+    color: "#ff0000"
+  - label: malware
+    color: "#000000"
+  - label: benign
+    color: "#00ff00"
+playbooks:
+  - name: FREE_TO_USE_ANALYZERS
+    description: free analyzers
+    analyzers: {Classic_DNS: {}, Crowdsec: {}}
+    connectors: {}
+    supports: [ip, domain, url]
+  - name: DNS
+    description: resolve the domains
+    analyzers: {Classic_DNS: {}}
+    connectors: {}
+    supports: [domain]
+`
+
+const applyTagsJson = `[{"id": 1, "label": "phishing", "color": "#ff0000"}, {"id": 2, "label": "malware", "color": "#ffffff"}]`
+
+// applyRequests records the requests writing to the instance.
+type applyRequests struct {
+	mutex    sync.Mutex
+	requests []string
+}
+
+func (recorded *applyRequests) add(r *http.Request) {
+	recorded.mutex.Lock()
+	defer recorded.mutex.Unlock()
+	recorded.requests = append(recorded.requests, r.Method+" "+r.URL.Path)
+}
+
+func setupApply(t *testing.T) (gothreatmatrix.ThreatMatrixClient, *applyRequests, func()) {
+	client, apiHandler, closeServer := setup()
+	recorded := &applyRequests{}
+	apiHandler.Handle(constants.BASE_TAG_URL, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Write([]byte(applyTagsJson))
+			return
+		}
+		recorded.add(r)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 3, "label": "benign", "color": "#00ff00"}`))
+	}))
+	apiHandler.Handle(constants.BASE_TAG_URL+"/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded.add(r)
+		w.Write([]byte(`{"id": 2, "label": "malware", "color": "#000000"}`))
+	}))
+	apiHandler.Handle(constants.PLAYBOOK_CONFIG_URL, serverHandler(t, TestData{Data: playbookServiceConfigsJson}, "GET"))
+	apiHandler.Handle(constants.BASE_PLAYBOOK_URL, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded.add(r)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"name": "DNS"}`))
+	}))
+	apiHandler.Handle(constants.BASE_PLAYBOOK_URL+"/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded.add(r)
+		w.Write([]byte(`{"name": "FREE_TO_USE_ANALYZERS"}`))
+	}))
+	return client, recorded, closeServer
+}
+
+func loadApplyState(t *testing.T) *gothreatmatrix.DesiredState {
+	path := filepath.Join(t.TempDir(), "state.yaml")
+	if err := os.WriteFile(path, []byte(applyStateYaml), 0644); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	state, err := gothreatmatrix.LoadDesiredState(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return state
+}
+
+func TestPlanApply(t *testing.T) {
+	client, recorded, closeServer := setupApply(t)
+	defer closeServer()
+	plan, err := client.PlanApply(context.Background(), loadApplyState(t))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// * the dry run never writes to the instance
+	testWantData(t, []string(nil), recorded.requests)
+	changes := []string{}
+	for _, change := range plan.Changes {
+		changes = append(changes, change.Action+" "+change.Resource+" "+change.Name)
+	}
+	testWantData(t, []string{
+		"update tag malware",
+		"create tag benign",
+		"update playbook FREE_TO_USE_ANALYZERS",
+		"create playbook DNS",
+	}, changes)
+	testWantData(t, uint64(2), plan.Changes[0].ID)
+	fields := []string{}
+	for _, field := range plan.Changes[2].Fields {
+		fields = append(fields, field.Field)
+	}
+	testWantData(t, []string{"analyzers"}, fields)
+}
+
+func TestApplyPlanWriteDiff(t *testing.T) {
+	client, _, closeServer := setupApply(t)
+	defer closeServer()
+	plan, err := client.PlanApply(context.Background(), loadApplyState(t))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	buffer := &bytes.Buffer{}
+	if err := plan.WriteDiff(buffer); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, `~ tag malware
+    ~ color: "#ffffff" -> "#000000"
++ tag benign
+    + color: "#00ff00"
+~ playbook FREE_TO_USE_ANALYZERS
+    ~ analyzers: {"Classic_DNS":{}} -> {"Classic_DNS":{},"Crowdsec":{}}
++ playbook DNS
+    + description: "resolve the domains"
+    + analyzers: {"Classic_DNS":{}}
+    + connectors: {}
+    + supports: ["domain"]
+
+2 to create, 2 to update.
+`, buffer.String())
+
+	buffer.Reset()
+	emptyPlan := &gothreatmatrix.ApplyPlan{}
+	if err := emptyPlan.WriteDiff(buffer); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, "No changes.\n", buffer.String())
+}
+
+func TestApplyPlanJSON(t *testing.T) {
+	client, _, closeServer := setupApply(t)
+	defer closeServer()
+	plan, err := client.PlanApply(context.Background(), loadApplyState(t))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	decoded := struct {
+		Changes []struct {
+			Action   string `json:"action"`
+			Resource string `json:"resource"`
+			Name     string `json:"name"`
+			ID       uint64 `json:"id"`
+			Fields   []struct {
+				Field  string      `json:"field"`
+				Before interface{} `json:"before"`
+				After  interface{} `json:"after"`
+			} `json:"fields"`
+		} `json:"changes"`
+	}{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 4, len(decoded.Changes))
+	testWantData(t, "update", decoded.Changes[0].Action)
+	testWantData(t, uint64(2), decoded.Changes[0].ID)
+	testWantData(t, "#ffffff", decoded.Changes[0].Fields[0].Before)
+	testWantData(t, "#000000", decoded.Changes[0].Fields[0].After)
+}
+
+func TestApply(t *testing.T) {
+	client, recorded, closeServer := setupApply(t)
+	defer closeServer()
+	plan, err := client.PlanApply(context.Background(), loadApplyState(t))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := client.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{
+		"PUT /api/tags/2",
+		"POST /api/tags",
+		"PATCH /api/playbook/FREE_TO_USE_ANALYZERS",
+		"POST /api/playbook",
+	}, recorded.requests)
+}
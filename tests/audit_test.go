@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/sirupsen/logrus"
+)
+
+type failingAuditSink struct{}
+
+func (sink failingAuditSink) WriteAuditRecord(record *gothreatmatrix.AuditRecord) error {
+	return errors.New("disk full")
+}
+
+func TestAuditTrail(t *testing.T) {
+	auditPath := path.Join(t.TempDir(), "audit.jsonl")
+	sink, err := gothreatmatrix.OpenFileAuditSink(auditPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	options := &gothreatmatrix.ThreatMatrixClientOptions{
+		AuditTrail: gothreatmatrix.NewAuditTrail(sink, "soc-pipeline"),
+	}
+	client, apiHandler, closeServer := setupWithOptions(options)
+	defer closeServer()
+	ctx := context.Background()
+	apiHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, serverHandler(t, TestData{Data: `{"job_id": 1, "status": "accepted"}`}, "POST"))
+	apiHandler.Handle(constants.ANALYZE_MULTIPLE_OBSERVABLES_URL, serverHandler(t, TestData{Data: `{"count": 2, "results": []}`}, "POST"))
+
+	if _, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+		BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{
+			Tlp:                gothreatmatrix.AMBER,
+			AnalyzersRequested: []string{"Classic_DNS"},
+		},
+		ObservableName: "dns.google",
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.CreateMultipleObservableAnalysis(ctx, &gothreatmatrix.MultipleObservableAnalysisParams{
+		Observables: [][]string{{"ip", "8.8.8.8"}, {"domain", "example.com"}},
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sink.Close()
+
+	records, err := gothreatmatrix.ReadAuditFile(auditPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 2, len(records))
+	testWantData(t, "soc-pipeline", records[0].Actor)
+	testWantData(t, []string{"dns.google"}, records[0].Observables)
+	testWantData(t, []string{"Classic_DNS"}, records[0].AnalyzersRequested)
+	testWantData(t, gothreatmatrix.AMBER, records[0].Tlp)
+	testWantData(t, []string{"8.8.8.8", "example.com"}, records[1].Observables)
+	testWantData(t, records[0].Hash, records[1].PreviousHash)
+	if strings.Contains(records[0].TokenFingerprint, options.Token) || records[0].TokenFingerprint == "" {
+		t.Errorf("Unexpected token fingerprint %q", records[0].TokenFingerprint)
+	}
+	if err := gothreatmatrix.VerifyAuditRecords(records); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	//* reopening the sink continues the chain
+	sink, err = gothreatmatrix.OpenFileAuditSink(auditPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	options.AuditTrail = gothreatmatrix.NewAuditTrail(sink, "soc-pipeline")
+	client = gothreatmatrix.NewThreatMatrixClient(options, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+	if _, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{ObservableName: "1.1.1.1"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sink.Close()
+	records, _ = gothreatmatrix.ReadAuditFile(auditPath)
+	testWantData(t, uint64(3), records[2].Sequence)
+	if err := gothreatmatrix.VerifyAuditRecords(records); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	//* tampering is detected
+	data, _ := os.ReadFile(auditPath)
+	if err := os.WriteFile(auditPath, []byte(strings.Replace(string(data), "dns.google", "evil.example", 1)), 0600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	records, _ = gothreatmatrix.ReadAuditFile(auditPath)
+	if err := gothreatmatrix.VerifyAuditRecords(records); !errors.Is(err, gothreatmatrix.ErrAuditChainBroken) {
+		t.Errorf("Expected ErrAuditChainBroken, got %v", err)
+	}
+	if _, err := gothreatmatrix.OpenFileAuditSink(auditPath); !errors.Is(err, gothreatmatrix.ErrAuditChainBroken) {
+		t.Errorf("Expected ErrAuditChainBroken, got %v", err)
+	}
+	if err := gothreatmatrix.VerifyAuditRecords([]gothreatmatrix.AuditRecord{records[1], records[2]}); err != nil {
+		t.Errorf("Unexpected error for the untouched records: %v", err)
+	}
+	if err := gothreatmatrix.VerifyAuditRecords([]gothreatmatrix.AuditRecord{records[1]}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := gothreatmatrix.VerifyAuditRecords([]gothreatmatrix.AuditRecord{records[2], records[1]}); !errors.Is(err, gothreatmatrix.ErrAuditChainBroken) {
+		t.Errorf("Expected ErrAuditChainBroken for reordered records, got %v", err)
+	}
+}
+
+func TestAuditTrailSinkFailure(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		AuditTrail: gothreatmatrix.NewAuditTrail(failingAuditSink{}, "soc-pipeline"),
+	})
+	defer closeServer()
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unaudited submission sent")
+	})
+	if _, err := client.CreateObservableAnalysis(context.Background(), &gothreatmatrix.ObservableAnalysisParams{ObservableName: "8.8.8.8"}); err == nil {
+		t.Errorf("Expected the submission to fail")
+	}
+}
@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/sirupsen/logrus"
+)
+
+// testJWT returns an unsigned JWT expiring at the time, only its claims being read by the client.
+func testJWT(subject string, expiry time.Time) string {
+	encode := func(value interface{}) string {
+		data, _ := json.Marshal(value)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	return encode(map[string]string{"alg": "none"}) + "." + encode(map[string]interface{}{"sub": subject, "exp": expiry.Unix()}) + ".signature"
+}
+
+func TestTokenAuthIsTheDefault(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		testWantData(t, "token test-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`[]`))
+	})
+	if _, err := client.TagService.List(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestJWTSessionAuth(t *testing.T) {
+	testCases := map[string]struct {
+		expiry time.Duration
+		// rejected is the number of requests the server answers 401 to, from the first one
+		rejected  int32
+		wantErr   bool
+		wantLogin int32
+	}{
+		"valid": {
+			expiry:    time.Hour,
+			wantLogin: 1,
+		},
+		// * a JWT expiring within the refresh margin is renewed before every request
+		"expiring": {
+			expiry:    10 * time.Second,
+			wantLogin: 2,
+		},
+		"rejected": {
+			expiry:    time.Hour,
+			rejected:  1,
+			wantLogin: 2,
+		},
+		"rejectedAgain": {
+			expiry:    time.Hour,
+			rejected:  2,
+			wantErr:   true,
+			wantLogin: 2,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var logins, requests int32
+			// * the last JWT the login handler issued, the requests must carry it
+			var issuedMutex sync.Mutex
+			issued := ""
+			auth := &gothreatmatrix.JWTSessionAuth{Username: "analyst", Password: "secret"}
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{AuthProvider: auth})
+			defer closeServer()
+			apiHandler.HandleFunc(constants.AUTH_LOGIN_URL, func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				credentials := map[string]string{}
+				json.NewDecoder(r.Body).Decode(&credentials)
+				if want := (map[string]string{"username": "analyst", "password": "secret"}); !reflect.DeepEqual(want, credentials) {
+					t.Errorf("Login credentials: %v, want %v", credentials, want)
+				}
+				login := atomic.AddInt32(&logins, 1)
+				token := testJWT(fmt.Sprint(login), time.Now().Add(testCase.expiry))
+				issuedMutex.Lock()
+				issued = token
+				issuedMutex.Unlock()
+				json.NewEncoder(w).Encode(map[string]string{"access": token})
+			})
+			apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) <= testCase.rejected {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write([]byte(`{"detail": "Token is invalid or expired"}`))
+					return
+				}
+				issuedMutex.Lock()
+				want := "Bearer " + issued
+				issuedMutex.Unlock()
+				if got := r.Header.Get("Authorization"); got != want {
+					t.Errorf("Authorization: %q, want %q", got, want)
+				}
+				w.Write([]byte(`[]`))
+			})
+			ctx := context.Background()
+			_, err := client.TagService.List(ctx)
+			if err == nil && testCase.rejected == 0 {
+				_, err = client.TagService.List(ctx)
+			}
+			if testCase.wantErr != (err != nil) {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if testCase.wantErr && !gothreatmatrix.IsUnauthorized(err) {
+				t.Errorf("Expected a 401 error, got %v", err)
+			}
+			testWantData(t, testCase.wantLogin, atomic.LoadInt32(&logins))
+		})
+	}
+}
+
+func TestJWTSessionAuthLoginFailure(t *testing.T) {
+	auth := &gothreatmatrix.JWTSessionAuth{Username: "analyst", Password: "wrong"}
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{AuthProvider: auth})
+	defer closeServer()
+	apiHandler.HandleFunc(constants.AUTH_LOGIN_URL, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors": {"detail": "Invalid credentials"}}`))
+	})
+	_, err := client.TagService.List(context.Background())
+	if !errors.Is(err, gothreatmatrix.ErrAuthentication) {
+		t.Errorf("Expected ErrAuthentication, got %v", err)
+	}
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key as PEM files of the directory.
+func writeClientCertificate(t *testing.T, directory string) (certFile string, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "analyst"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	certFile, keyFile = filepath.Join(directory, "client.pem"), filepath.Join(directory, "client.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	return certFile, keyFile
+}
+
+func TestClientCertificateAuth(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testWantData(t, "analyst", r.TLS.PeerCertificates[0].Subject.CommonName)
+		testWantData(t, "token test-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`[]`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+	directory := t.TempDir()
+	serverCertificate := filepath.Join(directory, "server.pem")
+	os.WriteFile(serverCertificate, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)
+	certFile, keyFile := writeClientCertificate(t, directory)
+
+	auth, err := gothreatmatrix.NewClientCertificateAuth(certFile, keyFile, &gothreatmatrix.TokenAuth{Token: "test-token"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	client := gothreatmatrix.NewThreatMatrixClient(&gothreatmatrix.ThreatMatrixClientOptions{
+		Url:          server.URL,
+		Certificate:  serverCertificate,
+		AuthProvider: auth,
+	}, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+	if _, err := client.TagService.List(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := gothreatmatrix.NewClientCertificateAuth(filepath.Join(directory, "missing.pem"), keyFile, nil); err == nil {
+		t.Errorf("Expected an error for a missing certificate")
+	}
+}
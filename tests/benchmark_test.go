@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/bulk"
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// Benchmarks of the hot paths of the SDK.
+// Compare them between releases with:
+//
+//	go test -run '^$' -bench . -benchmem -count 10 ./tests > new.txt
+//	benchstat old.txt new.txt
+
+// benchmarkAnalyzerConfigs returns an analyzer_configs response with count analyzers,
+// built from the captured v4.0.0 fixture.
+func benchmarkAnalyzerConfigs(b *testing.B, count int) []byte {
+	b.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("testFiles", "fixtures", "v4.0.0", "analyzer_configs.json"))
+	if err != nil {
+		b.Fatalf("Error: %s", err)
+	}
+	fixture := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		b.Fatalf("Error: %s", err)
+	}
+	configs := make(map[string]json.RawMessage, count)
+	for len(configs) < count {
+		for _, config := range fixture {
+			configs[fmt.Sprintf("Analyzer_%d", len(configs))] = config
+		}
+	}
+	data, err = json.Marshal(configs)
+	if err != nil {
+		b.Fatalf("Error: %s", err)
+	}
+	return data
+}
+
+func benchmarkJob(b *testing.B, reports int) []byte {
+	b.Helper()
+	job := gothreatmatrix.Job{}
+	for index := 0; index < reports; index++ {
+		job.AnalyzerReports = append(job.AnalyzerReports, gothreatmatrix.Report{
+			Name:   "Classic_DNS",
+			Status: "SUCCESS",
+			Report: map[string]interface{}{
+				"observable":  "dns.google",
+				"resolutions": []interface{}{"8.8.8.8", "8.8.4.4"},
+				"score":       float64(index),
+			},
+		})
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		b.Fatalf("Error: %s", err)
+	}
+	return data
+}
+
+func BenchmarkAnalyzerConfigDecoding(b *testing.B) {
+	data := benchmarkAnalyzerConfigs(b, 200)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		analyzerConfigs := map[string]gothreatmatrix.AnalyzerConfig{}
+		if err := json.Unmarshal(data, &analyzerConfigs); err != nil {
+			b.Fatalf("Error: %s", err)
+		}
+	}
+}
+
+func BenchmarkAnalyzerGetConfigs(b *testing.B) {
+	data := benchmarkAnalyzerConfigs(b, 200)
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.ANALYZER_CONFIG_URL, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+	ctx := context.Background()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.AnalyzerService.GetConfigs(ctx); err != nil {
+			b.Fatalf("Error: %s", err)
+		}
+	}
+}
+
+func BenchmarkJobDecoding(b *testing.B) {
+	data := benchmarkJob(b, 50)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		job := gothreatmatrix.Job{}
+		if err := json.Unmarshal(data, &job); err != nil {
+			b.Fatalf("Error: %s", err)
+		}
+	}
+}
+
+func BenchmarkReportDecode(b *testing.B) {
+	registry := gothreatmatrix.NewReportSchemaRegistry()
+	registry.Register("Classic_DNS", classicDnsReport{})
+	report := gothreatmatrix.Report{
+		Name: "Classic_DNS",
+		Report: map[string]interface{}{
+			"observable":  "dns.google",
+			"resolutions": []interface{}{"8.8.8.8", "8.8.4.4"},
+		},
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := registry.Decode(&report); err != nil {
+			b.Fatalf("Error: %s", err)
+		}
+	}
+}
+
+func BenchmarkBulkSubmitter(b *testing.B) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"job_id":1,"status":"accepted"}`))
+	})
+	queue := bulk.NewMemoryQueue()
+	submitter := bulk.NewSubmitter(&client, queue, 8)
+	for i := 0; i < b.N; i++ {
+		if _, err := submitter.Enqueue(&gothreatmatrix.ObservableAnalysisParams{ObservableName: fmt.Sprintf("10.0.%d.%d", i/256%256, i%256)}); err != nil {
+			b.Fatalf("Error: %s", err)
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	if err := submitter.Run(context.Background()); err != nil {
+		b.Fatalf("Error: %s", err)
+	}
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestBulkObservableAnalysis(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	var mutex sync.Mutex
+	submitted := map[string]int{}
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		params := gothreatmatrix.ObservableAnalysisParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		if params.ObservableName == "refused" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"detail": "invalid observable"}`))
+			return
+		}
+		mutex.Lock()
+		submitted[params.ObservableName]++
+		jobId := len(submitted)
+		mutex.Unlock()
+		fmt.Fprintf(w, `{"job_id": %d, "status": "accepted"}`, jobId)
+	})
+	observables := []gothreatmatrix.ObservableInput{}
+	for index := 0; index < 20; index++ {
+		observables = append(observables, gothreatmatrix.ObservableInput{Name: fmt.Sprintf("10.0.0.%d", index), Classification: "ip"})
+	}
+	observables = append(observables, gothreatmatrix.ObservableInput{Name: "refused"})
+	results, err := client.BulkObservableAnalysis(context.Background(), observables, &gothreatmatrix.BulkOptions{Concurrency: 3})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, len(observables), len(results))
+	for index, result := range results[:20] {
+		testWantData(t, observables[index], result.Input)
+		if result.Err != nil || result.Response == nil {
+			t.Errorf("Unexpected error for %s: %v", result.Input.Name, result.Err)
+		}
+	}
+	var threatMatrixError *gothreatmatrix.ThreatMatrixError
+	if !errors.As(results[20].Err, &threatMatrixError) || threatMatrixError.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected the refused observable to fail, got %v", results[20].Err)
+	}
+	testWantData(t, 20, len(submitted))
+}
+
+func TestBulkObservableAnalysisBatches(t *testing.T) {
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["batch endpoint"] = TestData{
+		Input: true,
+		Want:  []int{0, 3},
+	}
+	testCases["no batch endpoint"] = TestData{
+		Input: false,
+		Want:  []int{5, 1},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			var mutex sync.Mutex
+			calls := []int{0, 0}
+			apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+				mutex.Lock()
+				calls[0]++
+				mutex.Unlock()
+				w.Write([]byte(`{"job_id": 1, "status": "accepted"}`))
+			})
+			apiHandler.HandleFunc(constants.ANALYZE_MULTIPLE_OBSERVABLES_URL, func(w http.ResponseWriter, r *http.Request) {
+				mutex.Lock()
+				calls[1]++
+				mutex.Unlock()
+				if !testCase.Input.(bool) {
+					http.NotFound(w, r)
+					return
+				}
+				params := gothreatmatrix.MultipleObservableAnalysisParams{}
+				json.NewDecoder(r.Body).Decode(&params)
+				response := gothreatmatrix.MultipleAnalysisResponse{Count: len(params.Observables)}
+				for range params.Observables {
+					response.Results = append(response.Results, gothreatmatrix.AnalysisResponse{JobID: 2, Status: "accepted"})
+				}
+				json.NewEncoder(w).Encode(response)
+			})
+			observables := []gothreatmatrix.ObservableInput{}
+			for index := 0; index < 5; index++ {
+				observables = append(observables, gothreatmatrix.ObservableInput{Name: fmt.Sprintf("%d.example.com", index), Classification: "domain"})
+			}
+			results, err := client.BulkObservableAnalysis(context.Background(), observables, &gothreatmatrix.BulkOptions{Concurrency: 1, UseBatchEndpoint: true, BatchSize: 2})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, result := range results {
+				if result.Err != nil || result.Response == nil {
+					t.Errorf("Unexpected error for %s: %v", result.Input.Name, result.Err)
+				}
+			}
+			testWantData(t, testCase.Want, calls)
+		})
+	}
+}
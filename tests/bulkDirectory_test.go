@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/bulk"
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestBulkSubmitDirectory(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	existing := md5.Sum([]byte("already analyzed"))
+	var mutex sync.Mutex
+	uploaded := []string{}
+	apiHandler.HandleFunc(constants.ASK_ANALYSIS_AVAILABILITY_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		params := gothreatmatrix.AnalysisAvailabilityParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		if params.Md5 == hex.EncodeToString(existing[:]) {
+			w.Write([]byte(`{"status": "reported_without_fails", "job_id": 7, "analyzers_to_execute": []}`))
+			return
+		}
+		w.Write([]byte(`{"status": "not_available", "job_id": 0, "analyzers_to_execute": []}`))
+	})
+	apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("Could not read the uploaded file: %v", err)
+			return
+		}
+		content, _ := io.ReadAll(file)
+		mutex.Lock()
+		uploaded = append(uploaded, string(content))
+		mutex.Unlock()
+		w.Write([]byte(`{"job_id": 1, "status": "accepted"}`))
+	})
+
+	root := t.TempDir()
+	files := map[string]string{
+		"a.bin":        "first sample",
+		"b.bin":        "already analyzed",
+		"nested/c.bin": "second sample",
+	}
+	for name, content := range files {
+		os.MkdirAll(path.Dir(path.Join(root, name)), 0700)
+		if err := os.WriteFile(path.Join(root, name), []byte(content), 0600); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	manifestPath := path.Join(t.TempDir(), "manifest.jsonl")
+	options := &bulk.DirectoryOptions{Concurrency: 2, ManifestPath: manifestPath}
+
+	results, err := bulk.SubmitDirectory(context.Background(), &client, root, options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	statuses := []string{}
+	paths := []string{}
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+		paths = append(paths, result.Path)
+	}
+	testWantData(t, []string{"a.bin", "b.bin", "nested/c.bin"}, paths)
+	testWantData(t, []string{bulk.FILE_SUBMITTED, bulk.FILE_EXISTING, bulk.FILE_SUBMITTED}, statuses)
+	testWantData(t, 7, results[1].JobID)
+	testWantData(t, 2, len(uploaded))
+
+	// * a second run resumes from the manifest, submitting only the changed file
+	if err := os.WriteFile(path.Join(root, "a.bin"), []byte("changed sample"), 0600); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	uploaded = []string{}
+	results, err = bulk.SubmitDirectory(context.Background(), &client, root, options)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	statuses = []string{}
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	testWantData(t, []string{bulk.FILE_SUBMITTED, bulk.FILE_RESUMED, bulk.FILE_RESUMED}, statuses)
+	testWantData(t, []string{"changed sample"}, uploaded)
+}
+
+func TestBulkSubmitDirectoryTornManifest(t *testing.T) {
+	firstMd5 := md5.Sum([]byte("first sample"))
+	firstLine := `{"path":"b.bin","md5":"` + hex.EncodeToString(firstMd5[:]) + `","status":"submitted","job_id":1,"at":"2026-01-01T00:00:00Z"}`
+	testCases := map[string]struct {
+		manifest  string
+		wantError error
+	}{
+		"tornLastLine": {manifest: firstLine + "\n" + `{"path":"a.bin","md5":"`},
+		// * a whole result missing only its newline still counts
+		"unterminatedLastLine": {manifest: firstLine},
+		"corruptLine":          {manifest: `{"path":"a.bin"` + "\n" + firstLine + "\n", wantError: bulk.ErrCorruptManifest},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			uploads := 0
+			apiHandler.HandleFunc(constants.ASK_ANALYSIS_AVAILABILITY_URL, func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"status": "not_available", "job_id": 0, "analyzers_to_execute": []}`))
+			})
+			apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+				uploads++
+				w.Write([]byte(`{"job_id": 2, "status": "accepted"}`))
+			})
+			root := t.TempDir()
+			// * the first result appended is the one of a.bin
+			os.WriteFile(path.Join(root, "a.bin"), []byte("second sample"), 0600)
+			os.WriteFile(path.Join(root, "b.bin"), []byte("first sample"), 0600)
+			manifestPath := path.Join(t.TempDir(), "manifest.jsonl")
+			os.WriteFile(manifestPath, []byte(testCase.manifest), 0600)
+			options := &bulk.DirectoryOptions{ManifestPath: manifestPath}
+
+			results, err := bulk.SubmitDirectory(context.Background(), &client, root, options)
+			if testCase.wantError != nil {
+				if !errors.Is(err, testCase.wantError) {
+					t.Fatalf("Expected %v, got %v", testCase.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, bulk.FILE_SUBMITTED, results[0].Status)
+			testWantData(t, bulk.FILE_RESUMED, results[1].Status)
+			testWantData(t, 1, uploads)
+
+			// * the results appended after the torn line are read back: nothing is submitted again
+			results, err = bulk.SubmitDirectory(context.Background(), &client, root, options)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, bulk.FILE_RESUMED, results[0].Status)
+			testWantData(t, bulk.FILE_RESUMED, results[1].Status)
+			testWantData(t, 1, uploads)
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/bulk"
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestBulkSubmitterCrashRecovery(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	var mutex sync.Mutex
+	submitted := []string{}
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		params := gothreatmatrix.ObservableAnalysisParams{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("Could not parse request body: %v", err)
+		}
+		mutex.Lock()
+		submitted = append(submitted, params.ObservableName)
+		mutex.Unlock()
+		w.Write([]byte(`{"job_id":1,"status":"accepted"}`))
+	})
+
+	journalPath := path.Join(t.TempDir(), "queue.jsonl")
+	queue, err := bulk.OpenFileQueue(journalPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	submitter := bulk.NewSubmitter(&client, queue, 2)
+	for _, observable := range []string{"8.8.8.8", "1.1.1.1", "9.9.9.9"} {
+		if _, err := submitter.Enqueue(&gothreatmatrix.ObservableAnalysisParams{ObservableName: observable}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// simulating a crash while the first item was being submitted
+	if _, _, err := queue.Pop(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	queue.Close()
+
+	queue, err = bulk.OpenFileQueue(journalPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 3, queue.Len())
+	submitter = bulk.NewSubmitter(&client, queue, 2)
+	if err := submitter.Resume(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	queue.Close()
+	sort.Strings(submitted)
+	testWantData(t, []string{"1.1.1.1", "8.8.8.8", "9.9.9.9"}, submitted)
+
+	queue, err = bulk.OpenFileQueue(journalPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer queue.Close()
+	testWantData(t, 0, queue.Len())
+}
+
+func TestBulkSubmitterKeepsFailedItems(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, serverHandler(t, TestData{
+		StatusCode: http.StatusInternalServerError,
+		Data:       `{"error":"Error occurred by the server"}`,
+	}, "POST"))
+	queue := bulk.NewMemoryQueue()
+	submitter := bulk.NewSubmitter(&client, queue, 1)
+	results := []bulk.Result{}
+	submitter.OnResult = func(result bulk.Result) {
+		results = append(results, result)
+	}
+	if _, err := submitter.Enqueue(&gothreatmatrix.ObservableAnalysisParams{ObservableName: "8.8.8.8"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := submitter.Run(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 1, len(results))
+	testWantData(t, 1, results[0].Item.Attempts)
+	testWantData(t, 1, queue.Len())
+}
+
+func TestBulkSubmitterDeadLetters(t *testing.T) {
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["validationError"] = TestData{
+		Input:      1,
+		Data:       `{"errors":{"observable_classification":["not supported"]}}`,
+		StatusCode: http.StatusBadRequest,
+		Want:       1,
+	}
+	testCases["serverErrorAfterMaxAttempts"] = TestData{
+		Input:      3,
+		Data:       `{"error":"Error occurred by the server"}`,
+		StatusCode: http.StatusInternalServerError,
+		Want:       3,
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, serverHandler(t, testCase, "POST"))
+			queue := bulk.NewMemoryQueue()
+			deadLetters, err := bulk.OpenFileDeadLetterStore(path.Join(t.TempDir(), "dead.json"))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			submitter := bulk.NewSubmitter(&client, queue, 1)
+			submitter.DeadLetters = deadLetters
+			submitter.MaxAttempts = 3
+			id, err := submitter.Enqueue(&gothreatmatrix.ObservableAnalysisParams{ObservableName: "CVE-2022-0001"})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			runs, _ := testCase.Input.(int)
+			for run := 0; run < runs; run++ {
+				if err := submitter.Run(context.Background()); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+			testWantData(t, 0, queue.Len())
+			gottenDeadLetters, err := deadLetters.List()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, 1, len(gottenDeadLetters))
+			testWantData(t, id, gottenDeadLetters[0].Item.ID)
+			testWantData(t, testCase.Want, gottenDeadLetters[0].Item.Attempts)
+			testWantData(t, testCase.StatusCode, gottenDeadLetters[0].StatusCode)
+
+			if err := submitter.RetryDeadLetter(id); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, 1, queue.Len())
+			gottenDeadLetters, _ = deadLetters.List()
+			testWantData(t, 0, len(gottenDeadLetters))
+		})
+	}
+}
+
+func TestBulkSubmitterPriorityLanes(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	submitted := []string{}
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		params := gothreatmatrix.ObservableAnalysisParams{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("Could not parse request body: %v", err)
+		}
+		submitted = append(submitted, params.ObservableName)
+		w.Write([]byte(`{"job_id":1,"status":"accepted"}`))
+	})
+	journalPath := path.Join(t.TempDir(), "queue.jsonl")
+	queue, err := bulk.OpenFileQueue(journalPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	submitter := bulk.NewSubmitter(&client, queue, 1)
+	for _, observable := range []string{"backfill-1", "backfill-2", "backfill-3"} {
+		if _, err := submitter.Enqueue(&gothreatmatrix.ObservableAnalysisParams{ObservableName: observable}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if _, err := submitter.EnqueueWithPriority(&gothreatmatrix.ObservableAnalysisParams{ObservableName: "analyst-lookup"}, bulk.INTERACTIVE); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	queue.Close()
+
+	// the lanes survive a restart
+	queue, err = bulk.OpenFileQueue(journalPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer queue.Close()
+	testWantData(t, 1, queue.LenByPriority(bulk.INTERACTIVE))
+	testWantData(t, 3, queue.LenByPriority(bulk.BATCH))
+	submitter = bulk.NewSubmitter(&client, queue, 1)
+	if err := submitter.Run(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"analyst-lookup", "backfill-1", "backfill-2", "backfill-3"}, submitted)
+}
+
+func TestOpenFileQueueCorruptJournal(t *testing.T) {
+	push := `{"op":"push","item":{"id":"a","params":{"observable_name":"8.8.8.8"}}}` + "\n"
+	testCases := map[string]struct {
+		journal string
+		wantErr bool
+		wantLen int
+	}{
+		"tornLastLine": {
+			journal: push + `{"op":"push","item":{"id":"b","par`,
+			wantLen: 1,
+		},
+		"lastLineWithoutNewline": {
+			journal: push + `{"op":"ack","id":"a"}`,
+			wantLen: 0,
+		},
+		"corruptEntry": {
+			journal: `{"op":"push","item":{"id":"b","par` + "\n" + push,
+			wantErr: true,
+		},
+		"pushWithoutItem": {
+			journal: `{"op":"push"}` + "\n" + push,
+			wantErr: true,
+		},
+		"unknownOperation": {
+			journal: push + `{"op":"drop","id":"a"}` + "\n",
+			wantErr: true,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			journalPath := path.Join(t.TempDir(), "queue.jsonl")
+			os.WriteFile(journalPath, []byte(testCase.journal), 0600)
+			queue, err := bulk.OpenFileQueue(journalPath)
+			if testCase.wantErr {
+				if !errors.Is(err, bulk.ErrCorruptJournal) {
+					t.Fatalf("Expected ErrCorruptJournal, got %v", err)
+				}
+				// * the journal is left as it was for inspection
+				journal, _ := os.ReadFile(journalPath)
+				testWantData(t, testCase.journal, string(journal))
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer queue.Close()
+			testWantData(t, testCase.wantLen, queue.Len())
+		})
+	}
+}
+
+func TestBulkSubmitterCanceledAttempts(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		// * the run is stopped while the item is being submitted
+		io.ReadAll(r.Body)
+		cancel()
+		<-r.Context().Done()
+	})
+	queue := bulk.NewMemoryQueue()
+	deadLetters, err := bulk.OpenFileDeadLetterStore(path.Join(t.TempDir(), "dead.json"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	submitter := bulk.NewSubmitter(&client, queue, 1)
+	submitter.DeadLetters = deadLetters
+	submitter.MaxAttempts = 1
+	if _, err := submitter.Enqueue(&gothreatmatrix.ObservableAnalysisParams{ObservableName: "8.8.8.8"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := submitter.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	gottenDeadLetters, _ := deadLetters.List()
+	testWantData(t, 0, len(gottenDeadLetters))
+	item, ok, err := queue.Pop()
+	if err != nil || !ok {
+		t.Fatalf("Expected the item to be kept, got %v", err)
+	}
+	testWantData(t, 0, item.Attempts)
+}
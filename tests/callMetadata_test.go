@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+type recordingAuditSink struct {
+	records []gothreatmatrix.AuditRecord
+}
+
+func (sink *recordingAuditSink) WriteAuditRecord(record *gothreatmatrix.AuditRecord) error {
+	sink.records = append(sink.records, *record)
+	return nil
+}
+
+func TestWithCallMetadata(t *testing.T) {
+	ctx := gothreatmatrix.WithCallMetadata(context.Background(), gothreatmatrix.CallMetadata{
+		RequestingUser: "alice",
+		SourceSystem:   "soar",
+		Extra:          map[string]string{"Playbook-Run": "17"},
+	})
+	ctx = gothreatmatrix.WithCallMetadata(ctx, gothreatmatrix.CallMetadata{CaseID: "CASE-42", Extra: map[string]string{"Step": "enrich"}})
+	metadata, ok := gothreatmatrix.CallMetadataFromContext(ctx)
+	testWantData(t, true, ok)
+	testWantData(t, gothreatmatrix.CallMetadata{
+		RequestingUser: "alice",
+		SourceSystem:   "soar",
+		CaseID:         "CASE-42",
+		Extra:          map[string]string{"Playbook-Run": "17", "Step": "enrich"},
+	}, metadata)
+	_, ok = gothreatmatrix.CallMetadataFromContext(context.Background())
+	testWantData(t, false, ok)
+}
+
+func TestCallMetadataPropagation(t *testing.T) {
+	sink := &recordingAuditSink{}
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		AuditTrail: gothreatmatrix.NewAuditTrail(sink, "soc-pipeline"),
+	})
+	defer closeServer()
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		testWantData(t, "alice", r.Header.Get(gothreatmatrix.REQUESTING_USER_HEADER))
+		testWantData(t, "soar", r.Header.Get(gothreatmatrix.SOURCE_SYSTEM_HEADER))
+		testWantData(t, "CASE-42", r.Header.Get(gothreatmatrix.CASE_ID_HEADER))
+		testWantData(t, "17", r.Header.Get(gothreatmatrix.METADATA_HEADER_PREFIX+"Playbook-Run"))
+		w.Write([]byte(`{"job_id": 1, "status": "accepted"}`))
+	})
+	metadata := gothreatmatrix.CallMetadata{
+		RequestingUser: "alice",
+		SourceSystem:   "soar",
+		CaseID:         "CASE-42",
+		Extra:          map[string]string{"Playbook-Run": "17"},
+	}
+	ctx := gothreatmatrix.WithCallMetadata(context.Background(), metadata)
+	params := gothreatmatrix.ObservableAnalysisParams{ObservableName: "8.8.8.8", ObservableClassification: "ip"}
+	if _, err := client.CreateObservableAnalysis(ctx, &params); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 1, len(sink.records))
+	testWantData(t, &metadata, sink.records[0].Metadata)
+	if err := gothreatmatrix.VerifyAuditRecords(sink.records); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
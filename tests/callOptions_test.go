@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestCallOptionsHeadersAndQuery(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc("/api/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		testWantData(t, "soc-eu", r.Header.Get("X-Tenant"))
+		testWantData(t, "trace-1", r.Header.Get("X-Trace"))
+		testWantData(t, "token test-token", r.Header.Get("Authorization"))
+		testWantData(t, []string{"a", "b"}, r.URL.Query()["fields"])
+		w.Write([]byte(`{"id": 1}`))
+	})
+	ctx := gothreatmatrix.WithCallOptions(context.Background(), gothreatmatrix.WithHeader("X-Tenant", "soc-eu"), gothreatmatrix.WithQueryParam("fields", "a"))
+	// * the options of the parent context are kept
+	ctx = gothreatmatrix.WithCallOptions(ctx, gothreatmatrix.WithHeader("X-Trace", "trace-1"), gothreatmatrix.WithQueryParam("fields", "b"))
+	if _, err := client.JobService.Get(ctx, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestCallOptionsTimeout(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		RetryOptions: &gothreatmatrix.RetryOptions{MaxRetries: 10, BaseDelay: 10 * time.Millisecond},
+	})
+	defer closeServer()
+	apiHandler.HandleFunc("/api/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	ctx := gothreatmatrix.WithCallOptions(context.Background(), gothreatmatrix.WithTimeout(50*time.Millisecond))
+	start := time.Now()
+	_, err := client.JobService.Get(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the call to time out, got: %v", err)
+	}
+	// * the timeout bounds the retries as a whole
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the call to stop after its timeout, it took %v", elapsed)
+	}
+}
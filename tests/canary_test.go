@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// setupCanary serves a job per canary observable, with the status of statuses.
+func setupCanary(t *testing.T, statuses map[string]string) (gothreatmatrix.ThreatMatrixClient, func()) {
+	client, apiHandler, closeServer := setup()
+	jobIds := map[string]int{}
+	for observable, status := range statuses {
+		jobId := len(jobIds) + 1
+		jobIds[observable] = jobId
+		apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, jobId), serverHandler(t, TestData{
+			Data: fmt.Sprintf(`{"id": %d, "status": %q, "observable_name": %q, "analyzers_to_execute": ["Classic_DNS"]}`, jobId, status, observable),
+		}, "GET"))
+	}
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		params := gothreatmatrix.ObservableAnalysisParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		testWantData(t, "CANARY", params.PlaybookRequested)
+		testWantData(t, []string{gothreatmatrix.CANARY_TAG}, params.TagsLabels)
+		w.Write([]byte(fmt.Sprintf(`{"job_id": %d, "status": "accepted"}`, jobIds[params.ObservableName])))
+	})
+	return client, closeServer
+}
+
+func TestCanaryCheck(t *testing.T) {
+	client, closeServer := setupCanary(t, map[string]string{
+		gothreatmatrix.CANARY_EICAR_MD5: "reported_without_fails",
+		gothreatmatrix.CANARY_DOMAIN:    "reported_with_fails",
+		"8.8.8.8":                       "running",
+	})
+	defer closeServer()
+	results := client.CanaryCheck(context.Background(), &gothreatmatrix.CanaryOptions{
+		Observables: []string{gothreatmatrix.CANARY_EICAR_MD5, gothreatmatrix.CANARY_DOMAIN, "8.8.8.8"},
+		Playbook:    "CANARY",
+		SLA:         100 * time.Millisecond,
+		Wait:        &gothreatmatrix.WaitOptions{PollInterval: 5 * time.Millisecond},
+	})
+	testWantData(t, 3, len(results))
+	testWantData(t, true, results[0].Healthy)
+	testWantData(t, gothreatmatrix.REPORTED_WITHOUT_FAILS, results[0].Status)
+	if results[1].Healthy || !errors.Is(results[1].Err, gothreatmatrix.ErrCanaryJobFailed) {
+		t.Errorf("Expected the job with failures to fail the canary, got: %+v", results[1])
+	}
+	if results[2].Healthy || !errors.Is(results[2].Err, gothreatmatrix.ErrCanarySLAExceeded) {
+		t.Errorf("Expected the running job to exceed the SLA, got: %+v", results[2])
+	}
+}
+
+func TestRunCanary(t *testing.T) {
+	client, closeServer := setupCanary(t, map[string]string{
+		gothreatmatrix.CANARY_EICAR_MD5: "reported_without_fails",
+		gothreatmatrix.CANARY_DOMAIN:    "reported_without_fails",
+	})
+	defer closeServer()
+	canary := client.RunCanary(context.Background(), &gothreatmatrix.CanaryOptions{
+		Playbook: "CANARY",
+		Interval: time.Millisecond,
+		Wait:     &gothreatmatrix.WaitOptions{PollInterval: time.Millisecond},
+	})
+	testWantData(t, gothreatmatrix.PREFLIGHT_SKIP, canary.Check().Status)
+	received := 0
+	for result := range canary.Results() {
+		if !result.Healthy {
+			t.Errorf("Unexpected failure of the canary %s: %v", result.Observable, result.Err)
+		}
+		// * two rounds of the two default observables
+		if received++; received == 4 {
+			canary.Stop()
+		}
+	}
+	if err := canary.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the canary to be canceled, got: %v", err)
+	}
+	metrics := canary.Metrics()
+	testWantData(t, 4, metrics.Successes)
+	testWantData(t, 0, metrics.Failures)
+	testWantData(t, gothreatmatrix.PREFLIGHT_PASS, canary.Check().Status)
+	if _, ok := canary.Last(gothreatmatrix.CANARY_DOMAIN); !ok {
+		t.Errorf("Expected a result for %s", gothreatmatrix.CANARY_DOMAIN)
+	}
+}
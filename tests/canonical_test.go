@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestDefaultCanonicalizer(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["ipv6"] = TestData{Input: []string{"", "2001:DB8:0:0:0:0:0:1"}, Want: "2001:db8::1"}
+	testCases["domain"] = TestData{Input: []string{"domain", " Dns.Google. "}, Want: "dns.google"}
+	testCases["hash"] = TestData{Input: []string{"hash", "2329AB183AD74DD65E0519FA8B977F3B"}, Want: "2329ab183ad74dd65e0519fa8b977f3b"}
+	testCases["url"] = TestData{Input: []string{"url", "HTTPS://Evil.Example:443#top"}, Want: "https://evil.example/"}
+	testCases["urlPort"] = TestData{Input: []string{"url", "http://evil.example:8080/Login?next=a"}, Want: "http://evil.example:8080/Login?next=a"}
+	testCases["generic"] = TestData{Input: []string{"generic", "John Doe"}, Want: "John Doe"}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			input := testCase.Input.([]string)
+			canonical, err := gothreatmatrix.DefaultCanonicalizer{}.Canonicalize(context.Background(), input[0], input[1])
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.Want, canonical)
+		})
+	}
+}
+
+func TestReuseRecentResultsCanonicalizer(t *testing.T) {
+	redirects := map[string]string{"http://short.example/a": "https://final.example/"}
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		ReuseRecentResults: true,
+		DedupWindowMinutes: 30,
+		Canonicalizer: gothreatmatrix.CanonicalizerFunc(func(ctx context.Context, classification string, observable string) (string, error) {
+			if target, ok := redirects[observable]; ok {
+				return target, nil
+			}
+			return gothreatmatrix.DefaultCanonicalizer{}.Canonicalize(ctx, classification, observable)
+		}),
+	})
+	defer closeServer()
+	ctx := context.Background()
+	submissions := 0
+	apiHandler.HandleFunc(constants.ASK_ANALYSIS_AVAILABILITY_URL, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "not_available"}`))
+	})
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		submissions++
+		w.Write([]byte(`{"job_id": 43, "status": "accepted"}`))
+	})
+	for _, observable := range []string{"https://final.example/", "http://short.example/a", "HTTPS://FINAL.example"} {
+		analysisResponse, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+			BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{AnalyzersRequested: []string{"UrlScan_Search"}},
+			ObservableName:      observable,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testWantData(t, 43, analysisResponse.JobID)
+	}
+	testWantData(t, 1, submissions)
+
+	// * other analyzers are another analysis
+	if _, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+		BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{AnalyzersRequested: []string{"Classic_DNS"}},
+		ObservableName:      "http://short.example/a",
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 2, submissions)
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/sirupsen/logrus"
+)
+
+func TestCheckLeastPrivilege(t *testing.T) {
+	// * table test cases
+	testCases := map[string]struct {
+		canSubmit   bool
+		required    []gothreatmatrix.Capability
+		wantGranted []gothreatmatrix.Capability
+		wantWarned  []gothreatmatrix.Capability
+	}{
+		"readOnlyEnrichmentWithAdminKey": {
+			canSubmit:   true,
+			required:    []gothreatmatrix.Capability{gothreatmatrix.CAPABILITY_READ},
+			wantGranted: []gothreatmatrix.Capability{gothreatmatrix.CAPABILITY_READ, gothreatmatrix.CAPABILITY_SUBMIT, gothreatmatrix.CAPABILITY_MANAGE_ORGANIZATION},
+			wantWarned:  []gothreatmatrix.Capability{gothreatmatrix.CAPABILITY_SUBMIT, gothreatmatrix.CAPABILITY_MANAGE_ORGANIZATION},
+		},
+		"submitterWithoutSubmit": {
+			canSubmit:   false,
+			required:    []gothreatmatrix.Capability{gothreatmatrix.CAPABILITY_READ, gothreatmatrix.CAPABILITY_SUBMIT},
+			wantGranted: []gothreatmatrix.Capability{gothreatmatrix.CAPABILITY_READ, gothreatmatrix.CAPABILITY_MANAGE_ORGANIZATION},
+			wantWarned:  []gothreatmatrix.Capability{gothreatmatrix.CAPABILITY_MANAGE_ORGANIZATION},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			testServer := httptest.NewServer(preflightHandler(t, http.StatusOK, testCase.canSubmit))
+			defer testServer.Close()
+			client := gothreatmatrix.NewThreatMatrixClient(&gothreatmatrix.ThreatMatrixClientOptions{
+				Url:                  testServer.URL,
+				Token:                "test-token",
+				RequiredCapabilities: testCase.required,
+			}, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+			report, err := client.Capabilities(context.Background())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, "analyst", report.Username)
+			testWantData(t, testCase.wantGranted, report.Granted)
+			warnings, err := client.CheckLeastPrivilege(context.Background())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			warned := []gothreatmatrix.Capability{}
+			for _, warning := range warnings {
+				warned = append(warned, warning.Capability)
+			}
+			testWantData(t, testCase.wantWarned, warned)
+		})
+	}
+}
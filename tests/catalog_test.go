@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestCaptureCatalogSnapshot(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	ctx := context.Background()
+	apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, TestData{Data: `{"Yara": {"name": "Yara", "external_service": false}}`}, "GET"))
+	apiHandler.Handle(constants.CONNECTOR_CONFIG_URL, serverHandler(t, TestData{Data: `{"MISP": {"name": "MISP", "maximum_tlp": "AMBER"}}`}, "GET"))
+	apiHandler.Handle(constants.PLAYBOOK_CONFIG_URL, serverHandler(t, TestData{Data: `{"FREE_TO_USE_ANALYZERS": {"name": "FREE_TO_USE_ANALYZERS", "analyzers": {"Yara": {}}, "supports": ["file"]}}`}, "GET"))
+
+	snapshot, err := client.CaptureCatalogSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	snapshotPath := path.Join(t.TempDir(), "catalog.json")
+	if err := snapshot.Save(snapshotPath); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loaded, err := gothreatmatrix.LoadCatalogSnapshot(snapshotPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, snapshot.Analyzers, loaded.Analyzers)
+	testWantData(t, snapshot.Connectors, loaded.Connectors)
+	testWantData(t, snapshot.Playbooks, loaded.Playbooks)
+	testWantData(t, gothreatmatrix.AMBER, loaded.Connectors["MISP"].MaximumTlp)
+	testWantData(t, []string{"file"}, loaded.Playbooks["FREE_TO_USE_ANALYZERS"].Supports)
+}
+
+func TestCatalogSnapshotPath(t *testing.T) {
+	snapshotPath := path.Join(t.TempDir(), "catalog.json")
+	snapshot := gothreatmatrix.CatalogSnapshot{
+		Analyzers: map[string]gothreatmatrix.AnalyzerConfig{
+			"Yara":       {BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "Yara"}},
+			"VirusTotal": {BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "VirusTotal"}, ExternalService: true},
+		},
+		Connectors: map[string]gothreatmatrix.ConnectorConfig{
+			"MISP": {BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "MISP"}},
+		},
+	}
+	if err := snapshot.Save(snapshotPath); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		CatalogSnapshotPath: snapshotPath,
+	})
+	defer closeServer()
+	ctx := context.Background()
+	restricted := func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request to the restricted endpoint %s", r.URL.Path)
+		w.WriteHeader(http.StatusForbidden)
+	}
+	apiHandler.HandleFunc(constants.ANALYZER_CONFIG_URL, restricted)
+	apiHandler.HandleFunc(constants.CONNECTOR_CONFIG_URL, restricted)
+
+	analyzerNames, err := client.AnalyzerService.FreeLocalOnly(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"Yara"}, analyzerNames)
+	connectorConfigs, err := client.ConnectorService.GetConfigs(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 1, len(*connectorConfigs))
+
+	//* refreshing the snapshot out-of-band
+	snapshot.Analyzers["Strings_Info"] = gothreatmatrix.AnalyzerConfig{BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "Strings_Info"}}
+	if err := snapshot.Save(snapshotPath); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	analyzerNames, err = client.AnalyzerService.FreeLocalOnly(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"Strings_Info", "Yara"}, analyzerNames)
+
+	//* a missing snapshot is an error, not a silent fallback to the network
+	os.Remove(snapshotPath)
+	if _, err := client.AnalyzerService.GetConfigs(ctx); err == nil {
+		t.Errorf("Expected an error for a missing snapshot")
+	}
+}
+
+func TestDiffCatalogSnapshots(t *testing.T) {
+	older := &gothreatmatrix.CatalogSnapshot{
+		CapturedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Analyzers: map[string]gothreatmatrix.AnalyzerConfig{
+			"Classic_DNS": {BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "Classic_DNS"}, ObservableSupported: []string{"domain"}},
+			"Shodan_Search": {BaseConfigurationType: gothreatmatrix.BaseConfigurationType{
+				Name:   "Shodan_Search",
+				Params: map[string]gothreatmatrix.Parameter{"shodan_analysis": {Value: "search"}, "timeout": {Value: float64(10)}},
+			}},
+			"Old_Analyzer": {BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "Old_Analyzer"}},
+		},
+		Playbooks: map[string]gothreatmatrix.PlaybookConfig{"FREE_TO_USE_ANALYZERS": {Name: "FREE_TO_USE_ANALYZERS"}},
+	}
+	newer := &gothreatmatrix.CatalogSnapshot{
+		CapturedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		Analyzers: map[string]gothreatmatrix.AnalyzerConfig{
+			"Classic_DNS": {BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "Classic_DNS"}, ObservableSupported: []string{"domain"}},
+			"Shodan_Search": {BaseConfigurationType: gothreatmatrix.BaseConfigurationType{
+				Name:     "Shodan_Search",
+				Disabled: true,
+				Params:   map[string]gothreatmatrix.Parameter{"shodan_analysis": {Value: "search"}, "timeout": {Value: float64(30)}, "page": {Value: float64(1)}},
+			}},
+			"New_Analyzer": {BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "New_Analyzer"}},
+		},
+		Connectors: map[string]gothreatmatrix.ConnectorConfig{"MISP": {BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "MISP"}}},
+		Playbooks:  map[string]gothreatmatrix.PlaybookConfig{"FREE_TO_USE_ANALYZERS": {Name: "FREE_TO_USE_ANALYZERS"}},
+	}
+	diff, err := gothreatmatrix.DiffCatalogSnapshots(older, newer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, &gothreatmatrix.CatalogDiff{
+		Added: []gothreatmatrix.CatalogChange{
+			{Type: gothreatmatrix.ANALYZER_PLUGIN, Name: "New_Analyzer"},
+			{Type: gothreatmatrix.CONNECTOR_PLUGIN, Name: "MISP"},
+		},
+		Removed: []gothreatmatrix.CatalogChange{{Type: gothreatmatrix.ANALYZER_PLUGIN, Name: "Old_Analyzer"}},
+		Changed: []gothreatmatrix.CatalogChange{{
+			Type:   gothreatmatrix.ANALYZER_PLUGIN,
+			Name:   "Shodan_Search",
+			Fields: []string{"disabled", "params"},
+			Params: []string{"page", "timeout"},
+		}},
+	}, diff)
+	testWantData(t, false, diff.IsEmpty())
+
+	same, err := gothreatmatrix.DiffCatalogSnapshots(newer, newer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, true, same.IsEmpty())
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var mutex sync.Mutex
+	changes := []string{}
+	options := &gothreatmatrix.ThreatMatrixClientOptions{
+		CircuitBreaker: &gothreatmatrix.CircuitBreaker{
+			FailureThreshold: 3,
+			Cooldown:         50 * time.Millisecond,
+			OnStateChange: func(host string, from gothreatmatrix.CircuitState, to gothreatmatrix.CircuitState) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				changes = append(changes, from.String()+">"+to.String())
+			},
+		},
+	}
+	client, apiHandler, closeServer := setupWithOptions(options)
+	defer closeServer()
+	var requests, healthy int32
+	apiHandler.HandleFunc("/api/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"id": 1}`))
+	})
+	apiHandler.HandleFunc("/api/jobs/2", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"detail": "Not found."}`))
+	})
+	serverUrl, _ := url.Parse(options.Url)
+	host := serverUrl.Host
+	ctx := context.Background()
+
+	// * a 4xx response tells the host is up, it resets the failures
+	client.JobService.Get(ctx, 1)
+	client.JobService.Get(ctx, 1)
+	client.JobService.Get(ctx, 2)
+	client.JobService.Get(ctx, 1)
+	client.JobService.Get(ctx, 1)
+	testWantData(t, gothreatmatrix.CIRCUIT_CLOSED, client.CircuitState(host))
+	client.JobService.Get(ctx, 1)
+	testWantData(t, gothreatmatrix.CIRCUIT_OPEN, client.CircuitState(host))
+
+	// * the open circuit fails fast
+	if _, err := client.JobService.Get(ctx, 1); !errors.Is(err, gothreatmatrix.ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	testWantData(t, int32(5), atomic.LoadInt32(&requests))
+
+	// * the failed probe opens the circuit again
+	time.Sleep(60 * time.Millisecond)
+	client.JobService.Get(ctx, 1)
+	testWantData(t, gothreatmatrix.CIRCUIT_OPEN, client.CircuitState(host))
+
+	time.Sleep(60 * time.Millisecond)
+	atomic.StoreInt32(&healthy, 1)
+	if _, err := client.JobService.Get(ctx, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, gothreatmatrix.CIRCUIT_CLOSED, client.CircuitState(host))
+	mutex.Lock()
+	defer mutex.Unlock()
+	testWantData(t, []string{"CLOSED>OPEN", "OPEN>HALF_OPEN", "HALF_OPEN>OPEN", "OPEN>HALF_OPEN", "HALF_OPEN>CLOSED"}, changes)
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func newClassificationOptions() *gothreatmatrix.ThreatMatrixClientOptions {
+	cve := gothreatmatrix.CVE_CLASSIFICATION
+	cve.Analyzers = []string{"NVD_CVE"}
+	cve.Tags = []string{"vulnerability"}
+	email := gothreatmatrix.EMAIL_CLASSIFICATION
+	phone := gothreatmatrix.PHONE_CLASSIFICATION
+	phone.Playbook = "Phone_Lookup"
+	return &gothreatmatrix.ThreatMatrixClientOptions{
+		CustomClassifications: []gothreatmatrix.CustomClassification{cve, email, phone},
+		DefaultPlaybooks:      map[string]string{"email": "Email_Reputation"},
+	}
+}
+
+func TestClientClassifyObservable(t *testing.T) {
+	client, _, closeServer := setupWithOptions(newClassificationOptions())
+	defer closeServer()
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["cve"] = TestData{Input: "CVE-2021-44228", Want: "cve"}
+	testCases["email"] = TestData{Input: "alice@example.com", Want: "email"}
+	testCases["phone"] = TestData{Input: "+1 (555) 010-0199", Want: "phone"}
+	testCases["domain"] = TestData{Input: "example.com", Want: "domain"}
+	testCases["generic"] = TestData{Input: "some value", Want: gothreatmatrix.GENERIC_CLASSIFICATION}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			testWantData(t, testCase.Want, client.ClassifyObservable(testCase.Input.(string)))
+		})
+	}
+}
+
+func TestCustomClassificationAnalysis(t *testing.T) {
+	type submission struct {
+		Classification string
+		Analyzers      []string
+		Playbook       string
+		Tags           []string
+	}
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["detected"] = TestData{
+		Input: gothreatmatrix.ObservableAnalysisParams{ObservableName: "CVE-2021-44228"},
+		Want:  submission{Classification: "generic", Analyzers: []string{"NVD_CVE"}, Tags: []string{"vulnerability"}},
+	}
+	testCases["explicit"] = TestData{
+		Input: gothreatmatrix.ObservableAnalysisParams{ObservableName: "+44 20 7946 0000", ObservableClassification: "phone"},
+		Want:  submission{Classification: "generic", Playbook: "Phone_Lookup"},
+	}
+	testCases["defaultPlaybook"] = TestData{
+		Input: gothreatmatrix.ObservableAnalysisParams{ObservableName: "alice@example.com"},
+		Want:  submission{Classification: "generic", Playbook: "Email_Reputation"},
+	}
+	testCases["requestedAnalyzers"] = TestData{
+		Input: gothreatmatrix.ObservableAnalysisParams{
+			BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{AnalyzersRequested: []string{"CVE_Search"}},
+			ObservableName:      "CVE-2021-44228",
+		},
+		Want: submission{Classification: "generic", Analyzers: []string{"CVE_Search"}, Tags: []string{"vulnerability"}},
+	}
+	testCases["builtin"] = TestData{
+		Input: gothreatmatrix.ObservableAnalysisParams{ObservableName: "8.8.8.8", ObservableClassification: "ip"},
+		Want:  submission{Classification: "ip"},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setupWithOptions(newClassificationOptions())
+			defer closeServer()
+			apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+				params := gothreatmatrix.ObservableAnalysisParams{}
+				if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+					t.Errorf("Could not parse request body: %v", err)
+				}
+				testWantData(t, testCase.Want, submission{
+					Classification: params.ObservableClassification,
+					Analyzers:      params.AnalyzersRequested,
+					Playbook:       params.PlaybookRequested,
+					Tags:           params.TagsLabels,
+				})
+				w.Write([]byte(`{"job_id":1,"status":"accepted"}`))
+			})
+			input := testCase.Input.(gothreatmatrix.ObservableAnalysisParams)
+			if _, err := client.CreateObservableAnalysis(context.Background(), &input); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCustomClassificationMultipleObservables(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(newClassificationOptions())
+	defer closeServer()
+	apiHandler.HandleFunc(constants.ANALYZE_MULTIPLE_OBSERVABLES_URL, func(w http.ResponseWriter, r *http.Request) {
+		params := gothreatmatrix.MultipleObservableAnalysisParams{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("Could not parse request body: %v", err)
+		}
+		testWantData(t, [][]string{{"generic", "CVE-2021-44228"}, {"generic", "CVE-2022-22965"}}, params.Observables)
+		testWantData(t, []string{"NVD_CVE"}, params.AnalyzersRequested)
+		w.Write([]byte(`{"count":2,"results":[]}`))
+	})
+	params := gothreatmatrix.MultipleObservableAnalysisParams{
+		Observables: [][]string{{"cve", "CVE-2021-44228"}, {"cve", "CVE-2022-22965"}},
+	}
+	if _, err := client.CreateMultipleObservableAnalysis(context.Background(), &params); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// the caller's observables are left untouched
+	testWantData(t, "cve", params.Observables[0][0])
+}
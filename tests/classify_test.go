@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/classify"
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestClassifyPackageObservable(t *testing.T) {
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["ipv4"] = TestData{Input: "8.8.8.8", Want: classify.IP}
+	testCases["ipv6"] = TestData{Input: "2001:4860:4860::8888", Want: classify.IP}
+	testCases["url"] = TestData{Input: "https://Evil.example.com/login?user=1", Want: classify.URL}
+	testCases["domain"] = TestData{Input: " Evil.Example.com ", Want: classify.DOMAIN}
+	testCases["md5"] = TestData{Input: "D41D8CD98F00B204E9800998ECF8427E", Want: classify.HASH}
+	testCases["sha256"] = TestData{Input: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Want: classify.HASH}
+	testCases["generic"] = TestData{Input: "CVE-2021-44228", Want: classify.GENERIC}
+	testCases["email"] = TestData{Input: "analyst@example.com", Want: classify.GENERIC}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			testWantData(t, testCase.Want, classify.Observable(testCase.Input.(string)))
+		})
+	}
+}
+
+func TestClassifyHash(t *testing.T) {
+	testWantData(t, classify.MD5, classify.Hash("d41d8cd98f00b204e9800998ecf8427e"))
+	testWantData(t, classify.SHA1, classify.Hash("da39a3ee5e6b4b0d3255bfef95601890afd80709"))
+	testWantData(t, classify.SHA256, classify.Hash("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"))
+	testWantData(t, "", classify.Hash("not a hash"))
+}
+
+func TestObservableAnalysisAutoClassification(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		CustomClassifications: []gothreatmatrix.CustomClassification{gothreatmatrix.CVE_CLASSIFICATION},
+	})
+	defer closeServer()
+	classifications := []string{}
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		params := gothreatmatrix.ObservableAnalysisParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		classifications = append(classifications, params.ObservableClassification)
+		w.Write([]byte(`{"job_id": 1, "status": "accepted"}`))
+	})
+	for _, observable := range []string{"1.1.1.1", "dns.google", "CVE-2021-44228"} {
+		if _, err := client.CreateObservableAnalysis(context.Background(), &gothreatmatrix.ObservableAnalysisParams{ObservableName: observable}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// * custom classifications are sent as generic ones
+	testWantData(t, []string{classify.IP, classify.DOMAIN, classify.GENERIC}, classifications)
+}
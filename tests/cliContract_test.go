@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/internal/cli"
+)
+
+func TestCLIContractSchemaIsPublished(t *testing.T) {
+	data, err := cli.MarshalCLIContractSchema()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	published, err := os.ReadFile("../docs/cli-contract.schema.json")
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if string(published) != string(data) {
+		t.Errorf("docs/cli-contract.schema.json is stale, regenerate it with go run ./cmd/threatmatrix schema > docs/cli-contract.schema.json")
+	}
+}
+
+func TestCLIContractSchema(t *testing.T) {
+	schema := cli.CLIContractSchema()
+	properties := schema["properties"].(map[string]interface{})
+	testWantData(t, map[string]interface{}{"const": cli.CLI_CONTRACT_VERSION}, properties["contract_version"])
+	testWantData(t, []string{"contract_version", "command", "ok"}, schema["required"])
+	definitions := schema["$defs"].(map[string]interface{})
+	waitResult := definitions["CLIWaitResult"].(map[string]interface{})
+	testWantData(t, map[string]interface{}{"$ref": "#/$defs/JobSummary"}, waitResult["properties"].(map[string]interface{})["summary"])
+	scanResult := definitions["CLIScanResult"].(map[string]interface{})
+	// * omitempty fields are optional
+	testWantData(t, []string{"analysis"}, scanResult["required"])
+}
+
+func TestCLIContractSchemaGroupedCommands(t *testing.T) {
+	schema := cli.CLIContractSchema()
+	properties := schema["properties"].(map[string]interface{})
+	commands := properties["command"].(map[string]interface{})["enum"].([]string)
+	for _, command := range []string{"analyze observable", "analyze file", "jobs list", "analyzers health", "connectors health"} {
+		if !containsString(commands, command) {
+			t.Errorf("%q is not a command of the contract: %v", command, commands)
+		}
+	}
+	// * the commands sharing a result type share its schema
+	refs := map[string]int{}
+	for _, result := range properties["result"].(map[string]interface{})["oneOf"].([]interface{}) {
+		refs[result.(map[string]interface{})["$ref"].(string)]++
+	}
+	testWantData(t, 1, refs["#/$defs/CLIScanResult"])
+	testWantData(t, 1, refs["#/$defs/CLIHealthResult"])
+	testWantData(t, 1, refs["#/$defs/JobListResponse"])
+}
+
+func TestCLIHealthResultOK(t *testing.T) {
+	testCases := map[string]struct {
+		plugins []cli.CLIPluginHealth
+		want    bool
+	}{
+		"healthy": {
+			plugins: []cli.CLIPluginHealth{{Name: "VirusTotal_v3", Healthy: true}, {Name: "Shodan_Search", Healthy: true}},
+			want:    true,
+		},
+		"one unhealthy": {
+			plugins: []cli.CLIPluginHealth{{Name: "VirusTotal_v3", Healthy: true}, {Name: "Shodan_Search"}},
+			want:    false,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result := cli.CLIHealthResult{PluginType: "analyzer", Plugins: testCase.plugins}
+			testWantData(t, testCase.want, result.OK())
+		})
+	}
+}
+
+func TestNewCLIError(t *testing.T) {
+	apiError := func(statusCode int) error {
+		client, apiHandler, closeServer := setup()
+		defer closeServer()
+		apiHandler.Handle("/api/jobs/1", serverHandler(t, TestData{StatusCode: statusCode, Data: `{"detail": "failed"}`}, "GET"))
+		_, err := client.JobService.Get(context.Background(), 1)
+		return fmt.Errorf("waiting: %w", err)
+	}
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["notFound"] = TestData{Input: apiError(http.StatusNotFound), Want: &cli.CLIError{Kind: cli.CLI_ERROR_NOT_FOUND, StatusCode: http.StatusNotFound}}
+	testCases["unauthorized"] = TestData{Input: apiError(http.StatusUnauthorized), Want: &cli.CLIError{Kind: cli.CLI_ERROR_UNAUTHORIZED, StatusCode: http.StatusUnauthorized}}
+	testCases["rateLimited"] = TestData{Input: apiError(http.StatusTooManyRequests), Want: &cli.CLIError{Kind: cli.CLI_ERROR_RATE_LIMITED, StatusCode: http.StatusTooManyRequests}}
+	testCases["api"] = TestData{Input: apiError(http.StatusBadRequest), Want: &cli.CLIError{Kind: cli.CLI_ERROR_API, StatusCode: http.StatusBadRequest}}
+	testCases["timeout"] = TestData{Input: fmt.Errorf("waiting: %w", context.DeadlineExceeded), Want: &cli.CLIError{Kind: cli.CLI_ERROR_TIMEOUT}}
+	testCases["network"] = TestData{Input: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, Want: &cli.CLIError{Kind: cli.CLI_ERROR_NETWORK}}
+	testCases["internal"] = TestData{Input: errors.New("broken"), Want: &cli.CLIError{Kind: cli.CLI_ERROR_INTERNAL}}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			err := testCase.Input.(error)
+			cliError := cli.NewCLIError(err)
+			want := testCase.Want.(*cli.CLIError)
+			want.Message = err.Error()
+			testWantData(t, want, cliError)
+		})
+	}
+}
+
+func TestCLIResponseJSON(t *testing.T) {
+	response := cli.CLIResponse{
+		ContractVersion: cli.CLI_CONTRACT_VERSION,
+		Command:         "export",
+		OK:              true,
+		Result:          &cli.CLIExportResult{JobID: 42, Format: "sigma", Content: "title: x\n"},
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, `{"contract_version":1,"command":"export","ok":true,"result":{"job_id":42,"format":"sigma","content":"title: x\n"}}`, string(data))
+}
@@ -0,0 +1,179 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/sirupsen/logrus"
+)
+
+// testConfiguredClient checks the client reaches its instance and refuses to submit Shodan_Search.
+func testConfiguredClient(t *testing.T, client *gothreatmatrix.ThreatMatrixClient) {
+	t.Helper()
+	if _, err := client.TagService.List(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, err := client.CreateObservableAnalysis(context.Background(), &gothreatmatrix.ObservableAnalysisParams{
+		BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{AnalyzersRequested: []string{"Shodan_Search"}},
+		ObservableName:      "8.8.8.8",
+	})
+	if !errors.Is(err, gothreatmatrix.ErrNoAnalyzerAllowed) {
+		t.Errorf("Expected ErrNoAnalyzerAllowed, got %v", err)
+	}
+}
+
+// newConfigServer returns a server answering the tags endpoint to the requests authenticated with the token.
+func newConfigServer(t *testing.T, token string) *httptest.Server {
+	apiHandler := http.NewServeMux()
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		testWantData(t, "token "+token, r.Header.Get("Authorization"))
+		w.Write([]byte(`[]`))
+	})
+	return httptest.NewServer(apiHandler)
+}
+
+func TestNewThreatMatrixClientFromFile(t *testing.T) {
+	testCases := map[string]struct {
+		fileName string
+		content  string
+	}{
+		"yaml": {
+			fileName: "threatmatrix.yaml",
+			content: `---
+# the instance
+url: {{URL}}
+token: "file-token" # quoted
+timeout: 30
+verify_uploads: true
+analyzers_denied:
+  - Shodan_Search
+  - 'VirusTotal_v3'
+`,
+		},
+		"yamlInlineList": {
+			fileName: "threatmatrix.yml",
+			content:  "url: {{URL}}\ntoken: file-token\nanalyzers_denied: [Shodan_Search, \"VirusTotal_v3\"]\n",
+		},
+		"toml": {
+			fileName: "threatmatrix.toml",
+			content:  "url = \"{{URL}}\"\ntoken = 'file-token'\ntimeout = 30\nanalyzers_denied = [\"Shodan_Search\"]\n",
+		},
+		"json": {
+			fileName: "threatmatrix.json",
+			content:  `{"url": "{{URL}}", "token": "file-token", "analyzers_denied": ["Shodan_Search"]}`,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			server := newConfigServer(t, "file-token")
+			defer server.Close()
+			filePath := filepath.Join(t.TempDir(), testCase.fileName)
+			os.WriteFile(filePath, []byte(strings.ReplaceAll(testCase.content, "{{URL}}", server.URL)), 0600)
+			client, err := gothreatmatrix.NewThreatMatrixClientFromFile(filePath, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testConfiguredClient(t, client)
+		})
+	}
+}
+
+func TestNewThreatMatrixClientFromFileNestedOptions(t *testing.T) {
+	requests := 0
+	apiHandler := http.NewServeMux()
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		// * only the retry_options of the file gets the request through
+		if requests++; requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+	server := httptest.NewServer(apiHandler)
+	defer server.Close()
+	content := `url: ` + server.URL + `
+token: file-token
+retry_options:
+  max_retries: 1
+  base_delay: 1ms
+rate_limit:
+  requests_per_second: 100
+  burst: 10
+proxy_rules:
+  "*.internal.example.com": direct
+`
+	filePath := filepath.Join(t.TempDir(), "threatmatrix.yaml")
+	os.WriteFile(filePath, []byte(content), 0600)
+	client, err := gothreatmatrix.NewThreatMatrixClientFromFile(filePath, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.TagService.List(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 2, requests)
+}
+
+func TestNewThreatMatrixClientFromFileErrors(t *testing.T) {
+	testCases := map[string]struct {
+		fileName string
+		content  string
+		want     string
+	}{
+		"unknownOption": {fileName: "threatmatrix.yaml", content: "url: http://localhost\ntokn: x\n", want: `unknown field "tokn"`},
+		"notAnInteger":  {fileName: "threatmatrix.yaml", content: "url: http://localhost\ntimeout: soon\n", want: "timeout"},
+		"unknownNested": {fileName: "threatmatrix.yaml", content: "url: http://localhost\nretry_options:\n  max_retry: 3\n", want: `unknown field "max_retry"`},
+		"tomlUnknown":   {fileName: "threatmatrix.toml", content: "url = \"http://localhost\"\ntokn = \"x\"\n", want: "unknown option tokn"},
+		"tomlInteger":   {fileName: "threatmatrix.toml", content: "url = \"http://localhost\"\ntimeout = \"soon\"\n", want: "timeout is not a positive integer"},
+		"table":         {fileName: "threatmatrix.toml", content: "url = \"http://localhost\"\n[retry_options]\n", want: "line 2: tables are not supported"},
+		"notScalar":     {fileName: "threatmatrix.toml", content: "url = \"http://localhost\"\nproxy_rules = \"x\"\n", want: "proxy_rules can only be set in a JSON or YAML configuration file"},
+		"missingUrl":    {fileName: "threatmatrix.toml", content: "token = \"x\"\n", want: "sets no URL"},
+		"format":        {fileName: "threatmatrix.ini", content: "url=x\n", want: "unknown configuration format"},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), testCase.fileName)
+			os.WriteFile(filePath, []byte(testCase.content), 0600)
+			_, err := gothreatmatrix.NewThreatMatrixClientFromFile(filePath, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+			if err == nil || !strings.Contains(err.Error(), testCase.want) {
+				t.Errorf("Expected an error containing %q, got %v", testCase.want, err)
+			}
+		})
+	}
+}
+
+func TestNewThreatMatrixClientFromEnv(t *testing.T) {
+	server := newConfigServer(t, "env-token")
+	defer server.Close()
+	t.Setenv("THREATMATRIX_URL", server.URL)
+	t.Setenv("THREATMATRIX_TOKEN", "env-token")
+	t.Setenv("THREATMATRIX_TIMEOUT", "30")
+	t.Setenv("THREATMATRIX_ANALYZERS_DENIED", "Shodan_Search, VirusTotal_v3")
+	client, err := gothreatmatrix.NewThreatMatrixClientFromEnv(nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testConfiguredClient(t, client)
+
+	// * the environment overrides the file
+	filePath := filepath.Join(t.TempDir(), "threatmatrix.yaml")
+	os.WriteFile(filePath, []byte("url: http://localhost\ntoken: file-token\n"), 0600)
+	client, err = gothreatmatrix.NewThreatMatrixClientFromFile(filePath, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testConfiguredClient(t, client)
+
+	t.Setenv("THREATMATRIX_TIMEOUT", "-1")
+	if _, err := gothreatmatrix.NewThreatMatrixClientFromEnv(nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel}); err == nil || !strings.Contains(err.Error(), "THREATMATRIX_TIMEOUT") {
+		t.Errorf("Expected an error of THREATMATRIX_TIMEOUT, got %v", err)
+	}
+}
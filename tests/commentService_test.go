@@ -0,0 +1,291 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestCommentServiceListForInvestigation(t *testing.T) {
+	commentListJson := `[{"id":1,"user":{"username":"playbook-bot"},"content":"Seeded by the phishing playbook","created_at":"2022-07-15T20:25:44.041286Z","updated_at":"2022-07-15T20:25:44.041286Z"}]`
+	commentList := []gothreatmatrix.Comment{}
+	if unmarshalError := json.Unmarshal([]byte(commentListJson), &commentList); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      1,
+		Data:       commentListJson,
+		StatusCode: http.StatusOK,
+		Want:       commentList,
+	}
+	testCases["cantFind"] = TestData{
+		Input:      9000,
+		Data:       `{"detail":"Not found."}`,
+		StatusCode: http.StatusNotFound,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusNotFound,
+			Message:    `{"detail":"Not found."}`,
+			Detail:     "Not found.",
+		},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			id, ok := testCase.Input.(int)
+			if ok {
+				investigationId := uint64(id)
+				testUrl := fmt.Sprintf(constants.INVESTIGATION_COMMENTS_URL, investigationId)
+				apiHandler.Handle(testUrl, serverHandler(t, testCase, "GET"))
+				gottenCommentList, err := client.CommentService.ListForInvestigation(ctx, investigationId)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, testCase.Want, *gottenCommentList)
+				}
+			} else {
+				t.Fatalf("Casting failed!")
+			}
+		})
+	}
+}
+
+func TestCommentServiceCreateForInvestigation(t *testing.T) {
+	commentJson := `{"id":2,"user":{"username":"playbook-bot"},"content":"Sandbox run finished","created_at":"2022-07-15T20:25:44.041286Z","updated_at":"2022-07-15T20:25:44.041286Z"}`
+	comment := gothreatmatrix.Comment{}
+	if unmarshalError := json.Unmarshal([]byte(commentJson), &comment); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input: gothreatmatrix.CommentParams{
+			Content: "Sandbox run finished",
+		},
+		Data:       commentJson,
+		StatusCode: http.StatusCreated,
+		Want:       &comment,
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			commentParams, ok := testCase.Input.(gothreatmatrix.CommentParams)
+			if ok {
+				testUrl := fmt.Sprintf(constants.INVESTIGATION_COMMENTS_URL, 1)
+				apiHandler.Handle(testUrl, serverHandler(t, testCase, "POST"))
+				gottenComment, err := client.CommentService.CreateForInvestigation(ctx, 1, &commentParams)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, testCase.Want, gottenComment)
+				}
+			} else {
+				t.Fatalf("Casting failed!")
+			}
+		})
+	}
+}
+
+func TestCommentServiceUpdateForInvestigation(t *testing.T) {
+	commentJson := `{"id":2,"user":{"username":"playbook-bot"},"content":"Sandbox run finished: malicious","created_at":"2022-07-15T20:25:44.041286Z","updated_at":"2022-07-15T20:30:44.041286Z"}`
+	comment := gothreatmatrix.Comment{}
+	if unmarshalError := json.Unmarshal([]byte(commentJson), &comment); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input: gothreatmatrix.CommentParams{
+			Content: "Sandbox run finished: malicious",
+		},
+		Data:       commentJson,
+		StatusCode: http.StatusOK,
+		Want:       &comment,
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			commentParams, ok := testCase.Input.(gothreatmatrix.CommentParams)
+			if ok {
+				testUrl := fmt.Sprintf(constants.SPECIFIC_INVESTIGATION_COMMENT_URL, 1, 2)
+				apiHandler.Handle(testUrl, serverHandler(t, testCase, "PATCH"))
+				gottenComment, err := client.CommentService.UpdateForInvestigation(ctx, 1, 2, &commentParams)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, testCase.Want, gottenComment)
+				}
+			} else {
+				t.Fatalf("Casting failed!")
+			}
+		})
+	}
+}
+
+func TestCommentServiceDeleteForInvestigation(t *testing.T) {
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      2,
+		Data:       "",
+		StatusCode: http.StatusNoContent,
+		Want:       true,
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			id, ok := testCase.Input.(int)
+			if ok {
+				commentId := uint64(id)
+				testUrl := fmt.Sprintf(constants.SPECIFIC_INVESTIGATION_COMMENT_URL, 1, commentId)
+				apiHandler.Handle(testUrl, serverHandler(t, testCase, "DELETE"))
+				isDeleted, err := client.CommentService.DeleteForInvestigation(ctx, 1, commentId)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, testCase.Want, isDeleted)
+				}
+			} else {
+				t.Fatalf("Casting failed!")
+			}
+		})
+	}
+}
+
+func TestCommentServiceListForJob(t *testing.T) {
+	commentListJson := `[{"id":3,"user":{"username":"analyst"},"content":"False positive, internal resolver","created_at":"2022-07-15T20:25:44.041286Z","updated_at":"2022-07-15T20:25:44.041286Z"}]`
+	commentList := []gothreatmatrix.Comment{}
+	if unmarshalError := json.Unmarshal([]byte(commentListJson), &commentList); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      1,
+		Data:       commentListJson,
+		StatusCode: http.StatusOK,
+		Want:       commentList,
+	}
+	testCases["cantFind"] = TestData{
+		Input:      9000,
+		Data:       `{"detail":"Not found."}`,
+		StatusCode: http.StatusNotFound,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusNotFound,
+			Message:    `{"detail":"Not found."}`,
+			Detail:     "Not found.",
+		},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			id, ok := testCase.Input.(int)
+			if ok {
+				jobId := uint64(id)
+				testUrl := fmt.Sprintf(constants.JOB_COMMENTS_URL, jobId)
+				apiHandler.Handle(testUrl, serverHandler(t, testCase, "GET"))
+				gottenCommentList, err := client.CommentService.ListForJob(ctx, jobId)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, testCase.Want, *gottenCommentList)
+				}
+			} else {
+				t.Fatalf("Casting failed!")
+			}
+		})
+	}
+}
+
+func TestCommentServiceCreateForJob(t *testing.T) {
+	commentJson := `{"id":4,"user":{"username":"analyst"},"content":"Escalated to IR","created_at":"2022-07-15T20:25:44.041286Z","updated_at":"2022-07-15T20:25:44.041286Z"}`
+	comment := gothreatmatrix.Comment{}
+	if unmarshalError := json.Unmarshal([]byte(commentJson), &comment); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input: gothreatmatrix.CommentParams{
+			Content: "Escalated to IR",
+		},
+		Data:       commentJson,
+		StatusCode: http.StatusCreated,
+		Want:       &comment,
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			commentParams, ok := testCase.Input.(gothreatmatrix.CommentParams)
+			if ok {
+				testUrl := fmt.Sprintf(constants.JOB_COMMENTS_URL, 1)
+				apiHandler.Handle(testUrl, serverHandler(t, testCase, "POST"))
+				gottenComment, err := client.CommentService.CreateForJob(ctx, 1, &commentParams)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, testCase.Want, gottenComment)
+				}
+			} else {
+				t.Fatalf("Casting failed!")
+			}
+		})
+	}
+}
+
+func TestCommentServiceDeleteForJob(t *testing.T) {
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      4,
+		Data:       "",
+		StatusCode: http.StatusNoContent,
+		Want:       true,
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			id, ok := testCase.Input.(int)
+			if ok {
+				commentId := uint64(id)
+				testUrl := fmt.Sprintf(constants.SPECIFIC_JOB_COMMENT_URL, 1, commentId)
+				apiHandler.Handle(testUrl, serverHandler(t, testCase, "DELETE"))
+				isDeleted, err := client.CommentService.DeleteForJob(ctx, 1, commentId)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, testCase.Want, isDeleted)
+				}
+			} else {
+				t.Fatalf("Casting failed!")
+			}
+		})
+	}
+}
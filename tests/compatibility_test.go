@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/sirupsen/logrus"
+)
+
+func TestNegotiateCompatibility(t *testing.T) {
+	testCases := map[string]struct {
+		currentStatus int
+		legacyStatus  int
+		want          gothreatmatrix.CompatibilityMode
+		wantErr       error
+	}{
+		"current": {
+			currentStatus: http.StatusMethodNotAllowed,
+			want:          gothreatmatrix.CURRENT_API,
+		},
+		"legacy": {
+			currentStatus: http.StatusNotFound,
+			legacyStatus:  http.StatusMethodNotAllowed,
+			want:          gothreatmatrix.LEGACY_API,
+		},
+		// * e.g. a wrong Url, or a proxy answering 404 for everything
+		"undetected": {
+			currentStatus: http.StatusNotFound,
+			legacyStatus:  http.StatusNotFound,
+			wantErr:       gothreatmatrix.ErrCompatibilityUndetected,
+		},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			apiHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, serverHandler(t, TestData{StatusCode: testCase.currentStatus}, "GET"))
+			apiHandler.HandleFunc(constants.LEGACY_ANALYSIS_REQUEST_URL, func(w http.ResponseWriter, r *http.Request) {
+				if testCase.legacyStatus == 0 {
+					t.Errorf("Unexpected probe of %s", r.URL.Path)
+				}
+				w.WriteHeader(testCase.legacyStatus)
+			})
+			mode, err := client.NegotiateCompatibility(ctx)
+			if testCase.wantErr != nil {
+				if !errors.Is(err, testCase.wantErr) {
+					t.Fatalf("Expected %v, got: %v", testCase.wantErr, err)
+				}
+				testWantData(t, gothreatmatrix.CURRENT_API, client.CompatibilityMode())
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.want, mode)
+		})
+	}
+}
+
+func TestLegacyObservableAnalysis(t *testing.T) {
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input: gothreatmatrix.ObservableAnalysisParams{
+			BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{
+				RuntimeConfiguration: map[string]interface{}{"Classic_DNS": map[string]interface{}{"classification": "dns"}},
+			},
+			ObservableName:           "8.8.8.8",
+			ObservableClassification: "ip",
+		},
+		Data:       `{"report_id":12,"status":"accepted","warnings":[],"analyzers_running":["Classic_DNS"],"connectors_running":[]}`,
+		StatusCode: http.StatusOK,
+		Want: &gothreatmatrix.AnalysisResponse{
+			JobID:             12,
+			Status:            "accepted",
+			Warnings:          []string{},
+			AnalyzersRunning:  []string{"Classic_DNS"},
+			ConnectorsRunning: []string{},
+		},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			apiHandler.HandleFunc(constants.LEGACY_ANALYSIS_REQUEST_URL, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" {
+					// * the probe of NegotiateCompatibility
+					w.WriteHeader(http.StatusMethodNotAllowed)
+					return
+				}
+				testMethod(t, r, "POST")
+				bodyBytes, _ := ioutil.ReadAll(r.Body)
+				body := map[string]interface{}{}
+				if err := json.Unmarshal(bodyBytes, &body); err != nil {
+					t.Fatalf("Could not parse request body: %v", err)
+				}
+				testWantData(t, "ip", body["observable_classification"])
+				// * the fields nested in the runtime configuration are not renamed
+				testWantData(t, map[string]interface{}{"Classic_DNS": map[string]interface{}{"classification": "dns"}}, body["runtime_configuration"])
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(testCase.Data))
+			})
+			if _, err := client.NegotiateCompatibility(ctx); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			observableParams, ok := testCase.Input.(gothreatmatrix.ObservableAnalysisParams)
+			if ok {
+				gottenAnalysisResponse, err := client.CreateObservableAnalysis(ctx, &observableParams)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, testCase.Want, gottenAnalysisResponse)
+				}
+			} else {
+				t.Fatalf("Casting failed!")
+			}
+		})
+	}
+}
+
+func TestAutoNegotiate(t *testing.T) {
+	// * the instance is not answering yet when the client is created, the probe runs again on its first call
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		AutoNegotiate: true,
+		ServerVersion: "v4.0.0",
+	})
+	defer closeServer()
+	probes := 0
+	apiHandler.HandleFunc(constants.LEGACY_ANALYSIS_REQUEST_URL, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"report_id": 12, "status": "accepted"}`))
+	})
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		probes++
+		w.Header().Set(gothreatmatrix.SERVER_VERSION_HEADER, "v3.4.1")
+		http.NotFound(w, r)
+	})
+	testWantData(t, "v4.0.0", client.ServerVersion())
+	ctx := context.Background()
+	for attempt := 0; attempt < 2; attempt++ {
+		response, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{ObservableName: "8.8.8.8", ObservableClassification: "ip"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testWantData(t, 12, response.JobID)
+	}
+	testWantData(t, 1, probes)
+	testWantData(t, gothreatmatrix.LEGACY_API, client.CompatibilityMode())
+	testWantData(t, "v3.4.1", client.ServerVersion())
+}
+
+func TestAutoNegotiateOnCreation(t *testing.T) {
+	apiHandler := http.NewServeMux()
+	server := httptest.NewServer(apiHandler)
+	defer server.Close()
+	probes := 0
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		probes++
+		w.Header().Set(gothreatmatrix.SERVER_VERSION_HEADER, "v6.0.2")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	client := gothreatmatrix.NewThreatMatrixClient(&gothreatmatrix.ThreatMatrixClientOptions{
+		Url:               server.URL,
+		Token:             "test-token",
+		AutoNegotiate:     true,
+		CompatibilityMode: gothreatmatrix.LEGACY_API,
+	}, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+	// * known before any call of the client
+	testWantData(t, "v6.0.2", client.ServerVersion())
+	testWantData(t, gothreatmatrix.CURRENT_API, client.CompatibilityMode())
+	if _, err := client.TagService.List(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 1, probes)
+}
+
+func TestLegacyJobResponse(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{CompatibilityMode: gothreatmatrix.LEGACY_API})
+	defer closeServer()
+	// * the report of the analyzer holds fields named like the renamed ones, which must be kept
+	jobJson := `{"id": 12, "analysis_reports": [{"name": "Yara", "report": {"report_id": "r-1", "analysis_reports": ["a"]}}]}`
+	apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 12), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(jobJson))
+	})
+	wantReport := map[string]interface{}{"report_id": "r-1", "analysis_reports": []interface{}{"a"}}
+	job, err := client.JobService.Get(context.Background(), 12)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 1, len(job.AnalyzerReports))
+	testWantData(t, wantReport, job.AnalyzerReports[0].Report)
+}
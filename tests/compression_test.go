@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestCompression(t *testing.T) {
+	tagsJson := `[{"id": 1, "label": "TEST1", "color": "#1c71d8"}]`
+	largeTag := &gothreatmatrix.TagParams{Label: strings.Repeat("a", 2048), Color: "#1c71d8"}
+	testCases := map[string]struct {
+		options      *gothreatmatrix.ThreatMatrixClientOptions
+		tag          *gothreatmatrix.TagParams
+		wantGzip     bool
+		wantGzipBody bool
+	}{
+		"default": {
+			options:  &gothreatmatrix.ThreatMatrixClientOptions{},
+			tag:      largeTag,
+			wantGzip: true,
+		},
+		"largeRequest": {
+			options:      &gothreatmatrix.ThreatMatrixClientOptions{GzipRequestsAbove: 1024},
+			tag:          largeTag,
+			wantGzip:     true,
+			wantGzipBody: true,
+		},
+		"smallRequest": {
+			options:  &gothreatmatrix.ThreatMatrixClientOptions{GzipRequestsAbove: 1024},
+			tag:      &gothreatmatrix.TagParams{Label: "TEST1", Color: "#1c71d8"},
+			wantGzip: true,
+		},
+		"disabled": {
+			options: &gothreatmatrix.ThreatMatrixClientOptions{DisableCompression: true, GzipRequestsAbove: 1024},
+			tag:     largeTag,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setupWithOptions(testCase.options)
+			defer closeServer()
+			apiHandler.HandleFunc("/api/tags", func(w http.ResponseWriter, r *http.Request) {
+				gzipped := r.Header.Get("Accept-Encoding") == "gzip"
+				testWantData(t, testCase.wantGzip, gzipped)
+				if r.Method == "POST" {
+					testWantData(t, testCase.wantGzipBody, r.Header.Get("Content-Encoding") == "gzip")
+					var body io.Reader = r.Body
+					if testCase.wantGzipBody {
+						gzipReader, err := gzip.NewReader(r.Body)
+						if err != nil {
+							t.Fatalf("Could not read the gzip body: %v", err)
+						}
+						body = gzipReader
+					}
+					data, _ := io.ReadAll(body)
+					if !strings.Contains(string(data), testCase.tag.Label) {
+						t.Errorf("Unexpected body: %s", data)
+					}
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(`{"id": 1, "label": "TEST1", "color": "#1c71d8"}`))
+					return
+				}
+				if !gzipped {
+					w.Write([]byte(tagsJson))
+					return
+				}
+				w.Header().Set("Content-Encoding", "gzip")
+				writer := gzip.NewWriter(w)
+				writer.Write([]byte(tagsJson))
+				writer.Close()
+			})
+			ctx := context.Background()
+			tags, err := client.TagService.List(ctx)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, "TEST1", (*tags)[0].Label)
+			if _, err := client.TagService.Create(ctx, testCase.tag); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCompressionRangeRequests(t *testing.T) {
+	sample := strings.Repeat("memory dump ", 1000)
+	sampleHash := md5.Sum([]byte(sample))
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	gottenEncodings := map[string]string{}
+	apiHandler.HandleFunc(fmt.Sprintf(constants.DOWNLOAD_SAMPLE_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		gottenEncodings[r.Header.Get("Range")] = r.Header.Get("Accept-Encoding")
+		// * a server gzipping whatever it can, ranges included
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			http.ServeContent(w, r, "sample", time.Time{}, strings.NewReader(sample))
+			return
+		}
+		compressed := &bytes.Buffer{}
+		writer := gzip.NewWriter(compressed)
+		writer.Write([]byte(sample))
+		writer.Close()
+		w.Header().Set("Content-Encoding", "gzip")
+		http.ServeContent(w, r, "sample", time.Time{}, bytes.NewReader(compressed.Bytes()))
+	})
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), serverHandler(t, TestData{Data: fmt.Sprintf(`{"id": 1, "md5": "%s"}`, hex.EncodeToString(sampleHash[:]))}, "GET"))
+	filePath := filepath.Join(t.TempDir(), "sample.bin")
+	if err := os.WriteFile(filePath+".part", []byte(sample[:5000]), 0600); err != nil {
+		t.Fatalf("Could not write the partial download: %v", err)
+	}
+	if err := client.JobService.DownloadSampleToFile(context.Background(), 1, filePath, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, map[string]string{"bytes=5000-": ""}, gottenEncodings)
+	downloaded, _ := os.ReadFile(filePath)
+	testWantData(t, sample, string(downloaded))
+}
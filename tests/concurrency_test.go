@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// TestClientConcurrentUse exercises the state shared by the goroutines using a client, run it with -race.
+func TestClientConcurrentUse(t *testing.T) {
+	snapshotPath := filepath.Join(t.TempDir(), "catalog.json")
+	snapshot := &gothreatmatrix.CatalogSnapshot{
+		CapturedAt: time.Now(),
+		Analyzers:  map[string]gothreatmatrix.AnalyzerConfig{"Classic_DNS": {BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "Classic_DNS"}}},
+	}
+	if err := snapshot.Save(snapshotPath); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	metrics := gothreatmatrix.NewRequestMetrics()
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		CatalogSnapshotPath: snapshotPath,
+		ReuseRecentResults:  true,
+		DedupWindowMinutes:  10,
+		RetryOptions:        &gothreatmatrix.RetryOptions{MaxRetries: 1, BaseDelay: time.Millisecond},
+		EnableTelemetry:     true,
+		Telemetry:           metrics,
+	})
+	defer closeServer()
+	apiHandler.Handle("/api/jobs/1", serverHandler(t, TestData{Data: `{"id": 1, "status": "reported_without_fails"}`}, "GET"))
+	apiHandler.Handle(constants.ASK_ANALYSIS_AVAILABILITY_URL, serverHandler(t, TestData{Data: `{"status": "not_available"}`}, "POST"))
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte(`{"job_id": 1, "status": "accepted"}`))
+	})
+
+	var middlewareCalls int64
+	ctx := context.Background()
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		waitGroup.Add(1)
+		go func(worker int) {
+			defer waitGroup.Done()
+			// * copies of the client share its state
+			workerClient := client
+			for iteration := 0; iteration < 20; iteration++ {
+				var err error
+				switch (worker + iteration) % 5 {
+				case 0:
+					_, err = workerClient.JobService.Get(ctx, 1)
+				case 1:
+					_, err = workerClient.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{ObservableName: "8.8.8.8"})
+				case 2:
+					_, err = workerClient.AnalyzerService.GetConfigs(ctx)
+				case 3:
+					_, err = workerClient.NegotiateCompatibility(ctx)
+				case 4:
+					workerClient.Use(func(next gothreatmatrix.RoundTripFunc) gothreatmatrix.RoundTripFunc {
+						return func(request *http.Request) (*http.Response, error) {
+							atomic.AddInt64(&middlewareCalls, 1)
+							return next(request)
+						}
+					})
+				}
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+			}
+		}(worker)
+	}
+	waitGroup.Wait()
+	testWantData(t, gothreatmatrix.CURRENT_API, client.CompatibilityMode())
+	if atomic.LoadInt64(&middlewareCalls) == 0 {
+		t.Errorf("Expected the middlewares registered concurrently to run")
+	}
+	if routes := metrics.Routes(); len(routes) == 0 {
+		t.Errorf("Expected the calls to be recorded")
+	}
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestConfigCache(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{ConfigCacheTTL: 1})
+	defer closeServer()
+	requests, notModified := 0, 0
+	apiHandler.HandleFunc(constants.CONNECTOR_CONFIG_URL, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`{"MISP": {"name": "MISP"}}`))
+	})
+	ctx := context.Background()
+	getConfigs := func(ctx context.Context) {
+		t.Helper()
+		connectorConfigs, err := client.ConnectorService.GetConfigs(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testWantData(t, "MISP", (*connectorConfigs)[0].Name)
+	}
+
+	getConfigs(ctx)
+	getConfigs(ctx)
+	testWantData(t, 1, requests)
+
+	getConfigs(gothreatmatrix.WithCallOptions(ctx, gothreatmatrix.ForceRefresh()))
+	testWantData(t, 2, requests)
+	testWantData(t, 0, notModified)
+
+	time.Sleep(1100 * time.Millisecond)
+	getConfigs(ctx)
+	getConfigs(ctx)
+	testWantData(t, 3, requests)
+	testWantData(t, 1, notModified)
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestConnectionBudgetShared(t *testing.T) {
+	budget := gothreatmatrix.NewConnectionBudget(2)
+	var inFlight, maxInFlight int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		w.Write([]byte(`{"id": 1}`))
+	}
+	clients := []gothreatmatrix.ThreatMatrixClient{}
+	for index := 0; index < 2; index++ {
+		client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{ConnectionBudget: budget})
+		defer closeServer()
+		apiHandler.HandleFunc("/api/jobs/1", handler)
+		clients = append(clients, client)
+	}
+	var waitGroup sync.WaitGroup
+	for index := 0; index < 8; index++ {
+		waitGroup.Add(1)
+		go func(client gothreatmatrix.ThreatMatrixClient) {
+			defer waitGroup.Done()
+			if _, err := client.JobService.Get(context.Background(), 1); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}(clients[index%2])
+	}
+	waitGroup.Wait()
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 requests in flight, got %d", maxInFlight)
+	}
+	testWantData(t, 0, budget.InUse())
+}
+
+func TestConnectionBudgetStream(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{MaxConcurrentRequests: 1})
+	defer closeServer()
+	apiHandler.HandleFunc("/api/jobs/1/download_sample", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("This is the sample"))
+	})
+	apiHandler.HandleFunc("/api/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 1}`))
+	})
+	stream, err := client.JobService.StreamSample(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// * the open stream holds the only slot
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.JobService.Get(timeoutCtx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait for a slot to end with the context, got %v", err)
+	}
+	io.ReadAll(stream)
+	stream.Close()
+	if _, err := client.JobService.Get(context.Background(), 1); err != nil {
+		t.Errorf("Unexpected error once the stream was closed: %v", err)
+	}
+}
@@ -71,6 +71,7 @@ func TestConnectorServiceHealthCheck(t *testing.T) {
 		Want: &gothreatmatrix.ThreatMatrixError{
 			StatusCode: http.StatusBadRequest,
 			Message:    `{"errors": {"detail": "Connector doesn't exist"}}`,
+			Errors:     map[string]interface{}{"detail": "Connector doesn't exist"},
 		},
 	}
 	for name, testCase := range testCases {
@@ -93,3 +94,23 @@ func TestConnectorServiceHealthCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestConnectorServiceHealthCheckAll(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.CONNECTOR_CONFIG_URL, serverHandler(t, TestData{
+		Data: `{"MISP": {"name": "MISP", "disabled": false}, "OpenCTI": {"name": "OpenCTI", "disabled": false}, "Slack": {"name": "Slack", "disabled": true}}`,
+	}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.CONNECTOR_HEALTHCHECK_URL, "MISP"), serverHandler(t, TestData{Data: `{"status": true}`}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.CONNECTOR_HEALTHCHECK_URL, "OpenCTI"), serverHandler(t, TestData{Data: `{"status": false}`}, "GET"))
+	health, err := client.ConnectorService.HealthCheckAll(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	healthy := map[string]bool{}
+	for name, pluginHealth := range health {
+		testWantData(t, gothreatmatrix.CONNECTOR_PLUGIN, pluginHealth.Type)
+		healthy[name] = pluginHealth.Healthy
+	}
+	testWantData(t, map[string]bool{"MISP": true, "OpenCTI": false}, healthy)
+}
@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestTagServiceCreateVerified(t *testing.T) {
+	// * the tag as it is read back on each attempt
+	readBacks := []TestData{
+		{Data: `{"detail": "Not found."}`, StatusCode: http.StatusNotFound},
+		{Data: `{"id": 5, "label": "phishing", "color": "#FFFFFF"}`},
+		{Data: `{"id": 5, "label": "phishing", "color": "#1D8EE5"}`},
+	}
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["eventuallyConsistent"] = TestData{
+		Input: 3,
+		Want:  &gothreatmatrix.Tag{ID: 5, Label: "phishing", Color: "#1D8EE5"},
+	}
+	testCases["mismatch"] = TestData{
+		Input: 2,
+		Want:  &gothreatmatrix.MismatchError{Resource: "tag", ID: 5, Field: "color", Want: "#1d8ee5", Got: "#FFFFFF"},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.Handle(constants.BASE_TAG_URL, serverHandler(t, TestData{Data: `{"id": 5, "label": "phishing", "color": "#1d8ee5"}`, StatusCode: http.StatusCreated}, "POST"))
+			var mutex sync.Mutex
+			reads := 0
+			apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_TAG_URL, 5), func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				mutex.Lock()
+				readBack := readBacks[reads]
+				reads++
+				mutex.Unlock()
+				if readBack.StatusCode != 0 {
+					w.WriteHeader(readBack.StatusCode)
+				}
+				w.Write([]byte(readBack.Data))
+			})
+			tag, err := client.TagService.CreateVerified(context.Background(), &gothreatmatrix.TagParams{Label: "phishing", Color: "#1d8ee5"}, &gothreatmatrix.ConsistencyOptions{
+				Attempts: testCase.Input.(int),
+				Interval: time.Millisecond,
+			})
+			if err != nil {
+				testWantData(t, testCase.Want, err)
+				return
+			}
+			testWantData(t, testCase.Want, tag)
+		})
+	}
+}
+
+func TestTagServiceUpdateVerifiedError(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_TAG_URL, 5), func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			w.Write([]byte(`{"id": 5, "label": "c2", "color": "#000000"}`))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"detail": "Forbidden."}`))
+	})
+	_, err := client.TagService.UpdateVerified(context.Background(), 5, &gothreatmatrix.TagParams{Label: "c2", Color: "#000000"}, nil)
+	testError(t, TestData{StatusCode: http.StatusForbidden, Want: &gothreatmatrix.ThreatMatrixError{StatusCode: http.StatusForbidden, Message: `{"detail": "Forbidden."}`, Detail: "Forbidden."}}, err)
+}
+
+func TestCommentServiceCreateForInvestigationVerified(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	reads := 0
+	apiHandler.HandleFunc(fmt.Sprintf(constants.INVESTIGATION_COMMENTS_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 9, "content": "escalated to IR"}`))
+			return
+		}
+		reads++
+		if reads == 1 {
+			// * the new comment is not listed yet
+			w.Write([]byte(`[{"id": 8, "content": "triaged"}]`))
+			return
+		}
+		w.Write([]byte(`[{"id": 8, "content": "triaged"}, {"id": 9, "content": "escalated to IR"}]`))
+	})
+	comment, err := client.CommentService.CreateForInvestigationVerified(context.Background(), 1, &gothreatmatrix.CommentParams{Content: "escalated to IR"}, &gothreatmatrix.ConsistencyOptions{Interval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(&gothreatmatrix.Comment{ID: 9, Content: "escalated to IR"}, comment); diff != "" {
+		t.Fatalf(diff)
+	}
+	testWantData(t, 2, reads)
+}
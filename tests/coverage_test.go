@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestPlanCoverage(t *testing.T) {
+	analyzerConfigsJson := `{
+		"Classic_DNS": {"name": "Classic_DNS", "type": "observable", "observable_supported": ["domain", "url"]},
+		"Shodan": {"name": "Shodan", "type": "observable", "external_service": true, "observable_supported": ["ip"], "secrets": {"api_key_name": {"required": true}}},
+		"AbuseIPDB": {"name": "AbuseIPDB", "type": "observable", "external_service": true, "leaks_info": true, "observable_supported": ["ip"]},
+		"TorProject": {"name": "TorProject", "type": "observable", "observable_supported": ["ip"]},
+		"PDF_Info": {"name": "PDF_Info", "type": "file", "supported_filetypes": ["application/pdf"]},
+		"File_Info": {"name": "File_Info", "type": "file", "not_supported_filetypes": ["text/plain"]},
+		"VirusTotal_v3_Get_File": {"name": "VirusTotal_v3_Get_File", "type": "file", "external_service": true, "run_hash": true},
+		"Old_DNS": {"name": "Old_DNS", "type": "observable", "disabled": true, "observable_supported": ["domain"]}
+	}`
+	playbookConfigsJson := `{
+		"FREE_TO_USE_ANALYZERS": {"name": "FREE_TO_USE_ANALYZERS", "supports": ["ip", "domain", "file"], "analyzers": {"Classic_DNS": {}, "TorProject": {}, "File_Info": {}}},
+		"IP_REPUTATION": {"name": "IP_REPUTATION", "supports": ["ip"], "analyzers": {"Shodan": {}, "AbuseIPDB": {}, "TorProject": {}}},
+		"DISABLED": {"name": "DISABLED", "disabled": true, "supports": ["ip"], "analyzers": {"TorProject": {}}}
+	}`
+	indicatorTypes := []string{"ip", "domain", "hash", "application/pdf", "text/plain", "generic"}
+	testCases := map[string]struct {
+		constraints *gothreatmatrix.CoverageConstraints
+		want        *gothreatmatrix.CoveragePlan
+	}{
+		"unconstrained": {
+			want: &gothreatmatrix.CoveragePlan{
+				Analyzers: map[string][]string{
+					"ip":              {"AbuseIPDB", "Shodan", "TorProject"},
+					"domain":          {"Classic_DNS"},
+					"hash":            {"VirusTotal_v3_Get_File"},
+					"application/pdf": {"File_Info", "PDF_Info", "VirusTotal_v3_Get_File"},
+					"text/plain":      {"VirusTotal_v3_Get_File"},
+					"generic":         {},
+				},
+				Uncovered: []string{"generic"},
+				Playbooks: map[string][]gothreatmatrix.PlaybookSuggestion{
+					"ip": {
+						{Name: "IP_REPUTATION", Analyzers: []string{"AbuseIPDB", "Shodan", "TorProject"}},
+						{Name: "FREE_TO_USE_ANALYZERS", Analyzers: []string{"TorProject"}},
+					},
+					"domain":          {{Name: "FREE_TO_USE_ANALYZERS", Analyzers: []string{"Classic_DNS"}}},
+					"hash":            {},
+					"application/pdf": {{Name: "FREE_TO_USE_ANALYZERS", Analyzers: []string{"File_Info"}}},
+					"text/plain":      {},
+					"generic":         {},
+				},
+			},
+		},
+		"redNoSecrets": {
+			constraints: &gothreatmatrix.CoverageConstraints{Tlp: gothreatmatrix.RED, NoSecrets: true},
+			want: &gothreatmatrix.CoveragePlan{
+				Analyzers: map[string][]string{
+					"ip":              {"TorProject"},
+					"domain":          {"Classic_DNS"},
+					"hash":            {},
+					"application/pdf": {"File_Info", "PDF_Info"},
+					"text/plain":      {},
+					"generic":         {},
+				},
+				Uncovered: []string{"hash", "text/plain", "generic"},
+				Playbooks: map[string][]gothreatmatrix.PlaybookSuggestion{
+					"ip": {
+						{Name: "FREE_TO_USE_ANALYZERS", Analyzers: []string{"TorProject"}},
+						{Name: "IP_REPUTATION", Analyzers: []string{"TorProject"}},
+					},
+					"domain":          {{Name: "FREE_TO_USE_ANALYZERS", Analyzers: []string{"Classic_DNS"}}},
+					"hash":            {},
+					"application/pdf": {{Name: "FREE_TO_USE_ANALYZERS", Analyzers: []string{"File_Info"}}},
+					"text/plain":      {},
+					"generic":         {},
+				},
+			},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, TestData{Data: analyzerConfigsJson}, "GET"))
+			apiHandler.Handle(constants.PLAYBOOK_CONFIG_URL, serverHandler(t, TestData{Data: playbookConfigsJson}, "GET"))
+			plan, err := client.PlanCoverage(context.Background(), indicatorTypes, testCase.constraints)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.want, plan)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestDataModelServiceListDomains(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["page"] = TestData{
+		Data:       `{"count": 1, "total_pages": 1, "results": [{"id": 3, "evaluation": "malicious", "reliability": 7, "kill_chain_phase": "delivery", "tags": ["phishing"], "malware_family": "Emotet", "date": "2024-03-01T12:00:00Z", "rank": 0, "resolutions": ["1.2.3.4"]}]}`,
+		StatusCode: http.StatusOK,
+		Want: []gothreatmatrix.DomainDataModel{
+			{
+				BaseDataModel: gothreatmatrix.BaseDataModel{
+					ID:             3,
+					Evaluation:     gothreatmatrix.EVALUATION_MALICIOUS,
+					Reliability:    7,
+					KillChainPhase: "delivery",
+					Tags:           []string{"phishing"},
+					MalwareFamily:  "Emotet",
+					Date:           &date,
+				},
+				Resolutions: []string{"1.2.3.4"},
+			},
+		},
+	}
+	testCases["unavailable"] = TestData{
+		Data:       `<h1>Not Found</h1>`,
+		StatusCode: http.StatusNotFound,
+		Want:       gothreatmatrix.ErrFeatureUnavailable,
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.Handle(constants.DOMAIN_DATA_MODEL_URL, serverHandler(t, testCase, "GET"))
+			domains, err := client.DataModelService.ListDomains(context.Background())
+			if err != nil {
+				if !errors.Is(err, testCase.Want.(error)) {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			} else {
+				testWantData(t, testCase.Want, domains)
+			}
+		})
+	}
+}
+
+func TestDataModelServiceGet(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	ctx := context.Background()
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_IP_DATA_MODEL_URL, 4), serverHandler(t, TestData{
+		Data: `{"id": 4, "evaluation": "trusted", "reliability": 9, "asn": 15169, "org_name": "Google LLC", "country_code": "US", "isp": "Google"}`,
+	}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_FILE_DATA_MODEL_URL, 5), serverHandler(t, TestData{
+		Data: `{"id": 5, "evaluation": "malicious", "comments": ["dropper"], "stats": {"positives": 40}}`,
+	}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_DOMAIN_DATA_MODEL_URL, 6), serverHandler(t, TestData{
+		Data:       `{"detail": "Not found."}`,
+		StatusCode: http.StatusNotFound,
+	}, "GET"))
+
+	ip, err := client.DataModelService.GetIP(ctx, 4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, &gothreatmatrix.IPDataModel{
+		BaseDataModel: gothreatmatrix.BaseDataModel{ID: 4, Evaluation: gothreatmatrix.EVALUATION_TRUSTED, Reliability: 9},
+		Asn:           15169,
+		OrgName:       "Google LLC",
+		CountryCode:   "US",
+		Isp:           "Google",
+	}, ip)
+	file, err := client.DataModelService.GetFile(ctx, 5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"dropper"}, file.Comments)
+	testWantData(t, float64(40), file.Stats["positives"])
+	if _, err := client.DataModelService.GetDomain(ctx, 6); !gothreatmatrix.IsNotFound(err) || errors.Is(err, gothreatmatrix.ErrFeatureUnavailable) {
+		t.Errorf("Expected a not found error, got: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestDeprecationNotices(t *testing.T) {
+	notices := []string{}
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		OnDeprecation: func(notice gothreatmatrix.DeprecationNotice) {
+			notices = append(notices, notice.String())
+		},
+	})
+	defer closeServer()
+	catalogs := []string{
+		`{"Classic_DNS": {"name": "Classic_DNS"}, "Shodan_Search": {"name": "Shodan_Search", "deprecated": true}, "Tor": {"name": "Tor"}}`,
+		`{"Classic_DNS": {"name": "Classic_DNS"}, "Shodan_Search": {"name": "Shodan_Search", "deprecated": true}}`,
+	}
+	calls := 0
+	apiHandler.HandleFunc(constants.ANALYZER_CONFIG_URL, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(catalogs[calls]))
+		if calls < len(catalogs)-1 {
+			calls++
+		}
+	})
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"job_id": 1, "status": "accepted"}`))
+	})
+	ctx := context.Background()
+	for range catalogs {
+		if _, err := client.AnalyzerService.GetConfigs(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// * a notice is only raised once per plugin
+	if _, err := client.AnalyzerService.GetConfigs(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"analyzer Shodan_Search is deprecated", "analyzer Tor was removed"}, notices)
+
+	analysisResponse, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+		BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{
+			AnalyzersRequested: []string{"Classic_DNS", "Shodan_Search", "Tor"},
+		},
+		ObservableName: "example.com",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	deprecations := []string{}
+	for _, notice := range analysisResponse.Deprecations {
+		deprecations = append(deprecations, notice.Name+" "+notice.Reason)
+	}
+	testWantData(t, []string{"Shodan_Search deprecated", "Tor removed"}, deprecations)
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestCompareDNSResolutions(t *testing.T) {
+	jobJson := `{"id": 9, "status": "reported_with_fails", "observable_name": "blocked.example", "analyzer_reports": [
+		{"name": "Classic_DNS", "status": "SUCCESS", "report": {"resolutions": ["0.0.0.0"]}},
+		{"name": "CloudFlare_DNS", "status": "SUCCESS", "report": {"resolutions": [{"name": "blocked.example.", "type": 1, "TTL": 300, "data": "93.184.216.34"}]}},
+		{"name": "Google_DNS", "status": "SUCCESS", "report": {"resolutions": [{"name": "blocked.example.", "type": 1, "TTL": 300, "data": "93.184.216.34"}]}},
+		{"name": "Quad9_DNS", "status": "SUCCESS", "report": {"resolutions": [{"name": "blocked.example.", "type": 1, "TTL": 300, "data": "93.184.216.34"}]}},
+		{"name": "DNS0_EU", "status": "FAILED", "errors": ["timeout"]}
+	]}`
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		params := gothreatmatrix.ObservableAnalysisParams{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("Could not parse request body: %v", err)
+		}
+		testWantData(t, gothreatmatrix.DEFAULT_DNS_RESOLVER_ANALYZERS, params.AnalyzersRequested)
+		w.Write([]byte(`{"job_id": 9, "status": "accepted"}`))
+	})
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 9), serverHandler(t, TestData{Data: jobJson}, "GET"))
+
+	comparison, err := client.CompareDNSResolutions(context.Background(), "blocked.example", &gothreatmatrix.DNSComparisonOptions{
+		WaitOptions: &gothreatmatrix.WaitOptions{PollInterval: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"93.184.216.34"}, comparison.Consensus)
+	testWantData(t, []string{"DNS0_EU"}, comparison.Failed)
+	testWantData(t, true, comparison.Divergent())
+	testWantData(t, []gothreatmatrix.DNSDifference{
+		{Analyzer: "Classic_DNS", Missing: []string{"93.184.216.34"}, Extra: []string{"0.0.0.0"}},
+	}, comparison.Differences)
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestDryRun(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{DryRun: true, GzipRequestsAbove: 16})
+	defer closeServer()
+	ctx := context.Background()
+	apiHandler.HandleFunc(constants.BASE_TAG_URL, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			t.Errorf("Unexpected %s request sent in dry run", r.Method)
+		}
+		w.Write([]byte(`[{"id": 1, "label": "TEST1", "color": "#1c71d8"}]`))
+	})
+	for _, route := range []string{constants.ANALYZE_OBSERVABLE_URL, fmt.Sprintf(constants.SPECIFIC_JOB_URL, 7), fmt.Sprintf(constants.KILL_JOB_URL, 8)} {
+		apiHandler.HandleFunc(route, func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("Unexpected %s %s request sent in dry run", r.Method, r.URL.Path)
+		})
+	}
+
+	tags, err := client.TagService.List(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 1, len(*tags))
+	if _, err := client.TagService.Create(ctx, &gothreatmatrix.TagParams{Label: "phishing-campaign", Color: "#ff0000"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	analysis, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+		BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{AnalyzersRequested: []string{"Classic_DNS"}},
+		ObservableName:      "dns.google",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 0, analysis.JobID)
+	deleted, err := client.JobService.Delete(ctx, 7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, true, deleted)
+	killed, err := client.JobService.Kill(ctx, 8)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, true, killed)
+
+	requests := client.DryRunLog().Requests()
+	methods := []string{}
+	for _, request := range requests {
+		requestUrl, err := url.Parse(request.Url)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		methods = append(methods, request.Method+" "+requestUrl.Path)
+	}
+	testWantData(t, []string{"POST /api/tags", "POST /api/analyze_observable", "DELETE /api/jobs/7", "PATCH /api/jobs/8/kill"}, methods)
+	if !strings.Contains(requests[0].Body, "phishing-campaign") || !strings.Contains(requests[1].Body, "dns.google") {
+		t.Errorf("Unexpected bodies: %q, %q", requests[0].Body, requests[1].Body)
+	}
+	testWantData(t, "application/json", requests[0].ContentType)
+	client.DryRunLog().Reset()
+	testWantData(t, 0, len(client.DryRunLog().Requests()))
+}
+
+func TestDryRunOff(t *testing.T) {
+	client, _, closeServer := setup()
+	defer closeServer()
+	if client.DryRunLog() != nil {
+		t.Errorf("Unexpected dry run log")
+	}
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestEventSchemasArePublished(t *testing.T) {
+	files, err := gothreatmatrix.MarshalEventSchemas()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 3, len(files))
+	for fileName, data := range files {
+		published, err := os.ReadFile(filepath.Join("..", "docs", "schemas", fileName))
+		if err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		if string(published) != string(data) {
+			t.Errorf("docs/schemas/%s is stale, regenerate it with go generate ./gothreatmatrix", fileName)
+		}
+	}
+}
+
+func TestEventSchemas(t *testing.T) {
+	schemas := gothreatmatrix.EventSchemas()
+	jobEvent := schemas["job_event"]
+	testWantData(t, "https://github.com/khulnasoft/go-threatmatrix/schemas/v1/job_event.schema.json", jobEvent["$id"])
+	testWantData(t, gothreatmatrix.EVENT_SCHEMA_VERSION, jobEvent["version"])
+	properties := jobEvent["properties"].(map[string]interface{})
+	testWantData(t, map[string]interface{}{"const": gothreatmatrix.EVENT_SCHEMA_VERSION}, properties["schema_version"])
+	testWantData(t, map[string]interface{}{"$ref": "#/$defs/Job"}, properties["job"])
+	artifact := schemas["artifact"]
+	testWantData(t, []string{"value", "classification", "sources"}, artifact["required"])
+
+	// * the payloads of the sinks carry the version
+	buffer := &bytes.Buffer{}
+	if err := gothreatmatrix.NewJSONLinesSink(buffer).WriteJob(context.Background(), &gothreatmatrix.Job{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	event := gothreatmatrix.JobEvent{}
+	if err := json.Unmarshal(buffer.Bytes(), &event); err != nil {
+		t.Fatalf("Could not parse the event: %v", err)
+	}
+	testWantData(t, gothreatmatrix.EVENT_SCHEMA_VERSION, event.SchemaVersion)
+}
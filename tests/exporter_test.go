@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestExporter(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	failPage := 3
+	apiHandler.HandleFunc(constants.BASE_JOB_URL, func(w http.ResponseWriter, r *http.Request) {
+		testWantData(t, "id", r.URL.Query().Get("ordering"))
+		testWantData(t, "reported_without_fails", r.URL.Query().Get("status"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == failPage {
+			failPage = 0
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		results := []string{}
+		for id := (page-1)*4 + 1; id <= page*4 && id <= 18; id++ {
+			results = append(results, fmt.Sprintf(`{"id": %d, "status": "reported_without_fails"}`, id))
+		}
+		fmt.Fprintf(w, `{"count": 18, "total_pages": 5, "results": [%s]}`, strings.Join(results, ", "))
+	})
+	dir := t.TempDir()
+	exporter := client.JobService.NewExporter(&gothreatmatrix.ExportOptions{
+		Dir:          dir,
+		Filter:       gothreatmatrix.NewJobFilter().Status(gothreatmatrix.REPORTED_WITHOUT_FAILS),
+		MaxFileBytes: 1000,
+		PageSize:     4,
+	})
+	ctx := context.Background()
+	checkpoint, err := exporter.Run(ctx)
+	if err == nil {
+		t.Fatalf("Expected the failed page to stop the export")
+	}
+	testWantData(t, 2, checkpoint.Page)
+	testWantData(t, 8, checkpoint.Exported)
+
+	checkpoint, err = exporter.Run(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, true, checkpoint.Done)
+	testWantData(t, 18, checkpoint.Exported)
+	testWantData(t, 18, checkpoint.LastJobID)
+	if checkpoint.File < 2 {
+		t.Errorf("Expected the files to be rotated, got %d file", checkpoint.File)
+	}
+	ids := []int{}
+	for file := 1; file <= checkpoint.File; file++ {
+		exportFile, err := os.Open(exporter.FilePath(file))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if info, _ := exportFile.Stat(); info.Size() > 1000 {
+			t.Errorf("Expected the file %d to be rotated at 1000 bytes, it has %d", file, info.Size())
+		}
+		scanner := bufio.NewScanner(exportFile)
+		for scanner.Scan() {
+			job := gothreatmatrix.JobList{}
+			if err := json.Unmarshal(scanner.Bytes(), &job); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			ids = append(ids, job.ID)
+		}
+		exportFile.Close()
+	}
+	want := []int{}
+	for id := 1; id <= 18; id++ {
+		want = append(want, id)
+	}
+	testWantData(t, want, ids)
+}
+
+func TestJobServiceExport(t *testing.T) {
+	jobsJson := `{"count": 2, "total_pages": 1, "results": [
+		{"id": 1, "observable_name": "dns.google", "observable_classification": "domain", "status": "reported_without_fails", "tlp": "AMBER",
+			"tags": [{"label": "dns"}, {"label": "benign"}], "analyzers_to_execute": ["Classic_DNS"], "received_request_time": "2024-03-01T12:00:00Z", "finished_analysis_time": "2024-03-01T12:01:00Z"},
+		{"id": 2, "is_sample": true, "file_name": "invoice.pdf", "file_mimetype": "application/pdf", "status": "running", "tlp": "RED", "received_request_time": "2024-03-01T12:05:00Z"}
+	]}`
+	testCases := map[string]struct {
+		format  *gothreatmatrix.JobExportFormat
+		want    string
+		wantErr error
+	}{
+		"jsonl": {
+			format: &gothreatmatrix.JobExportFormat{Columns: []string{gothreatmatrix.EXPORT_COLUMN_ID, gothreatmatrix.EXPORT_COLUMN_OBSERVABLE, gothreatmatrix.EXPORT_COLUMN_TAGS, gothreatmatrix.EXPORT_COLUMN_FINISHED}},
+			want: `{"finished_analysis_time":"2024-03-01T12:01:00Z","id":1,"observable":"dns.google","tags":["dns","benign"]}
+{"finished_analysis_time":null,"id":2,"observable":"invoice.pdf","tags":[]}
+`,
+		},
+		"csv": {
+			format: &gothreatmatrix.JobExportFormat{Encoding: gothreatmatrix.EXPORT_CSV},
+			want: `id,observable,classification,status,tlp,tags,received_request_time,finished_analysis_time,analyzers
+1,dns.google,domain,reported_without_fails,AMBER,dns;benign,2024-03-01T12:00:00Z,2024-03-01T12:01:00Z,Classic_DNS
+2,invoice.pdf,application/pdf,running,RED,,2024-03-01T12:05:00Z,,
+`,
+		},
+		"verdict": {
+			format: &gothreatmatrix.JobExportFormat{
+				Encoding: gothreatmatrix.EXPORT_CSV,
+				Columns:  []string{gothreatmatrix.EXPORT_COLUMN_ID, gothreatmatrix.EXPORT_COLUMN_VERDICT},
+				Verdict: func(job *gothreatmatrix.Job) gothreatmatrix.Verdict {
+					if len(job.AnalyzerReports) > 0 {
+						return gothreatmatrix.MALICIOUS
+					}
+					return gothreatmatrix.BENIGN
+				},
+			},
+			want: "id,verdict\n1,malicious\n2,benign\n",
+		},
+		"unknownColumn": {
+			format:  &gothreatmatrix.JobExportFormat{Columns: []string{"score"}},
+			wantErr: gothreatmatrix.ErrInvalidExportFormat,
+		},
+		"unknownEncoding": {
+			format:  &gothreatmatrix.JobExportFormat{Encoding: "xml"},
+			wantErr: gothreatmatrix.ErrInvalidExportFormat,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.HandleFunc(constants.BASE_JOB_URL, func(w http.ResponseWriter, r *http.Request) {
+				testWantData(t, "AMBER", r.URL.Query().Get("tlp"))
+				w.Write([]byte(jobsJson))
+			})
+			apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), serverHandler(t, TestData{Data: `{"id": 1, "analyzer_reports": [{"name": "Classic_DNS", "status": "SUCCESS"}]}`}, "GET"))
+			apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 2), serverHandler(t, TestData{Data: `{"id": 2}`}, "GET"))
+			output := &strings.Builder{}
+			exported, err := client.JobService.Export(context.Background(), gothreatmatrix.NewJobFilter().Tlp(gothreatmatrix.AMBER), testCase.format, output)
+			if testCase.wantErr != nil {
+				if !errors.Is(err, testCase.wantErr) {
+					t.Fatalf("Expected %v, got %v", testCase.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, 2, exported)
+			testWantData(t, testCase.want, output.String())
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+type featureTestInput struct {
+	StatusCode    int
+	Body          string
+	Header        string
+	ServerVersion string
+}
+
+func TestFeatureUnavailable(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["routerNotFound"] = TestData{
+		Input: featureTestInput{StatusCode: http.StatusNotFound, Body: "<h1>Not Found</h1>", Header: "v4.2.0"},
+		Want:  &gothreatmatrix.FeatureUnavailableError{Feature: gothreatmatrix.FEATURE_VISUALIZERS, ServerVersion: "v4.2.0"},
+	}
+	testCases["notImplemented"] = TestData{
+		Input: featureTestInput{StatusCode: http.StatusNotImplemented, Body: `{"detail": "not implemented"}`},
+		Want:  &gothreatmatrix.FeatureUnavailableError{Feature: gothreatmatrix.FEATURE_VISUALIZERS},
+	}
+	testCases["configuredVersion"] = TestData{
+		Input: featureTestInput{StatusCode: http.StatusNotFound, Body: "Not Found", Header: "v4.2.0", ServerVersion: "v4.0.0"},
+		Want:  &gothreatmatrix.FeatureUnavailableError{Feature: gothreatmatrix.FEATURE_VISUALIZERS, ServerVersion: "v4.0.0"},
+	}
+	testCases["objectNotFound"] = TestData{
+		Input: featureTestInput{StatusCode: http.StatusNotFound, Body: `{"detail": "Not found."}`},
+		Want:  nil,
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			input := testCase.Input.(featureTestInput)
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{ServerVersion: input.ServerVersion})
+			defer closeServer()
+			apiHandler.Handle(constants.VISUALIZER_CONFIG_URL, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if input.Header != "" {
+					w.Header().Set(gothreatmatrix.SERVER_VERSION_HEADER, input.Header)
+				}
+				w.WriteHeader(input.StatusCode)
+				w.Write([]byte(input.Body))
+			}))
+			_, err := client.VisualizerService.GetConfigs(context.Background())
+			if err == nil {
+				t.Fatalf("Expected an error")
+			}
+			want, _ := testCase.Want.(*gothreatmatrix.FeatureUnavailableError)
+			if want == nil {
+				if errors.Is(err, gothreatmatrix.ErrFeatureUnavailable) {
+					t.Fatalf("Unexpected ErrFeatureUnavailable: %v", err)
+				}
+				if !gothreatmatrix.IsNotFound(err) {
+					t.Errorf("Expected a not found error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, gothreatmatrix.ErrFeatureUnavailable) {
+				t.Fatalf("Expected ErrFeatureUnavailable, got %v", err)
+			}
+			var featureError *gothreatmatrix.FeatureUnavailableError
+			errors.As(err, &featureError)
+			testWantData(t, want.Feature, featureError.Feature)
+			testWantData(t, want.ServerVersion, featureError.ServerVersion)
+			var threatMatrixError *gothreatmatrix.ThreatMatrixError
+			if !errors.As(err, &threatMatrixError) || threatMatrixError.StatusCode != input.StatusCode {
+				t.Errorf("Expected the ThreatMatrixError to be wrapped, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFeatureUnavailableInvestigations(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.BASE_INVESTIGATION_URL, serverHandler(t, TestData{StatusCode: http.StatusNotFound, Data: "Not Found"}, "GET"))
+	_, err := client.InvestigationService.Paginate(10).All(context.Background())
+	if !errors.Is(err, gothreatmatrix.ErrFeatureUnavailable) {
+		t.Errorf("Expected ErrFeatureUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+const applicabilityAnalyzerConfigsJson = `{
+	"PDF_Info": {"name": "PDF_Info", "type": "file", "supported_filetypes": ["application/pdf"]},
+	"File_Info": {"name": "File_Info", "type": "file"},
+	"Strings_Info": {"name": "Strings_Info", "type": "file", "not_supported_filetypes": ["application/pdf"]},
+	"Yara_Scan": {"name": "Yara_Scan", "type": "file", "disabled": true},
+	"VirusTotal_v3_Get_File": {"name": "VirusTotal_v3_Get_File", "type": "file"},
+	"Classic_DNS": {"name": "Classic_DNS", "type": "observable", "observable_supported": ["domain"]}
+}`
+
+func TestFileApplicability(t *testing.T) {
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["pdf"] = TestData{
+		Input: "%PDF-1.7\n%âãÏÓ\n",
+		Want: gothreatmatrix.FileApplicability{
+			FileName:  "sample",
+			MimeType:  "application/pdf",
+			FileType:  gothreatmatrix.FILE_TYPE_PDF,
+			Analyzers: []string{"File_Info", "PDF_Info"},
+			Rejected: map[string]string{
+				"Strings_Info":           gothreatmatrix.REJECTED_UNSUPPORTED_FILE_TYPE,
+				"Yara_Scan":              gothreatmatrix.REJECTED_DISABLED,
+				"VirusTotal_v3_Get_File": gothreatmatrix.REJECTED_DENIED,
+			},
+		},
+	}
+	testCases["text"] = TestData{
+		Input: "just some text\n",
+		Want: gothreatmatrix.FileApplicability{
+			FileName:  "sample",
+			MimeType:  "text/plain",
+			FileType:  gothreatmatrix.FILE_TYPE_UNKNOWN,
+			Analyzers: []string{"File_Info", "Strings_Info"},
+			Rejected: map[string]string{
+				"PDF_Info":               gothreatmatrix.REJECTED_UNSUPPORTED_FILE_TYPE,
+				"Yara_Scan":              gothreatmatrix.REJECTED_DISABLED,
+				"VirusTotal_v3_Get_File": gothreatmatrix.REJECTED_DENIED,
+			},
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+				AnalyzersDenied: []string{"VirusTotal_v3_Get_File"},
+			})
+			defer closeServer()
+			apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, TestData{Data: applicabilityAnalyzerConfigsJson}, "GET"))
+			path := filepath.Join(t.TempDir(), "sample")
+			if err := os.WriteFile(path, []byte(testCase.Input.(string)), 0o644); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer file.Close()
+			applicability, err := client.FileApplicability(context.Background(), file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.Want, *applicability)
+		})
+	}
+}
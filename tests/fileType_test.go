@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// zipContent returns a zip archive holding empty entries of these names.
+func zipContent(t *testing.T, names ...string) []byte {
+	buffer := bytes.Buffer{}
+	writer := zip.NewWriter(&buffer)
+	for _, name := range names {
+		if _, err := writer.Create(name); err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	return buffer.Bytes()
+}
+
+// peContent returns the headers of a Windows executable.
+func peContent() []byte {
+	content := make([]byte, 0x100)
+	copy(content, "MZ")
+	content[0x3c] = 0x80
+	copy(content[0x80:], "PE\x00\x00")
+	return content
+}
+
+// openTestFile writes the content in a temporary file and opens it.
+func openTestFile(t *testing.T, name string, content []byte) *os.File {
+	filePath := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filePath, content, 0600); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+func TestDetectFileType(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["pe"] = TestData{Input: peContent(), Want: gothreatmatrix.FILE_TYPE_PE}
+	testCases["elf"] = TestData{Input: []byte("\x7fELF\x02\x01\x01"), Want: gothreatmatrix.FILE_TYPE_ELF}
+	testCases["pdf"] = TestData{Input: []byte("%PDF-1.7\n"), Want: gothreatmatrix.FILE_TYPE_PDF}
+	testCases["legacyOffice"] = TestData{Input: []byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1, 0x00}, Want: gothreatmatrix.FILE_TYPE_OFFICE}
+	testCases["docx"] = TestData{Input: zipContent(t, "[Content_Types].xml", "word/document.xml"), Want: gothreatmatrix.FILE_TYPE_OFFICE}
+	testCases["apk"] = TestData{Input: zipContent(t, "META-INF/MANIFEST.MF", "AndroidManifest.xml", "classes.dex"), Want: gothreatmatrix.FILE_TYPE_APK}
+	testCases["zip"] = TestData{Input: zipContent(t, "invoice.pdf.exe"), Want: gothreatmatrix.FILE_TYPE_ARCHIVE}
+	testCases["text"] = TestData{Input: []byte("hello world"), Want: gothreatmatrix.FILE_TYPE_UNKNOWN}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			file := openTestFile(t, name, testCase.Input.([]byte))
+			fileType, err := gothreatmatrix.DetectFileType(file)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.Want, fileType)
+			// * the file is rewound for the upload
+			header := make([]byte, 2)
+			file.Read(header)
+			testWantData(t, testCase.Input.([]byte)[:2], header)
+		})
+	}
+}
+
+func TestDetectFileTypeFromOffset(t *testing.T) {
+	// * a file read before: its type is the one of the part from its current offset, the part an upload sends
+	content := append([]byte("HEADER"), zipContent(t, "AndroidManifest.xml")...)
+	file := openTestFile(t, "fromOffset", content)
+	file.Seek(6, io.SeekStart)
+	fileType, err := gothreatmatrix.DetectFileType(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, gothreatmatrix.FILE_TYPE_APK, fileType)
+	offset, _ := file.Seek(0, io.SeekCurrent)
+	testWantData(t, int64(6), offset)
+}
+
+func TestFileTypePlaybooks(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["pe"] = TestData{Input: peContent(), Want: "Sandbox_Heavy"}
+	testCases["docx"] = TestData{Input: zipContent(t, "[Content_Types].xml", "word/vbaProject.bin"), Want: "Macro_Analysis"}
+	testCases["apk"] = TestData{Input: zipContent(t, "AndroidManifest.xml"), Want: "Mobile_Analysis"}
+	testCases["fallback"] = TestData{Input: []byte("hello world"), Want: "Sample_Static_Analysis"}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+				FileTypePlaybooks: map[string]string{
+					gothreatmatrix.FILE_TYPE_PE:     "Sandbox_Heavy",
+					gothreatmatrix.FILE_TYPE_OFFICE: "Macro_Analysis",
+					gothreatmatrix.FILE_TYPE_APK:    "Mobile_Analysis",
+				},
+				DefaultPlaybooks: map[string]string{gothreatmatrix.DEFAULT_FILE_PLAYBOOK_KEY: "Sample_Static_Analysis"},
+			})
+			defer closeServer()
+			apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					t.Errorf("Could not parse the form: %v", err)
+				}
+				testWantData(t, testCase.Want, r.FormValue("playbook_requested"))
+				uploaded, _, err := r.FormFile("file")
+				if err != nil {
+					t.Fatalf("Error: %s", err)
+				}
+				defer uploaded.Close()
+				content := bytes.Buffer{}
+				content.ReadFrom(uploaded)
+				testWantData(t, testCase.Input.([]byte), content.Bytes())
+				w.Write([]byte(`{"job_id":2,"status":"accepted"}`))
+			})
+			file := openTestFile(t, name, testCase.Input.([]byte))
+			if _, err := client.CreateFileAnalysis(context.Background(), &gothreatmatrix.FileAnalysisParams{File: file}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// fixtureTypes maps the type names used in the fixture manifests (see cmd/fixturegen) to the structs they decode into.
+var fixtureTypes = map[string]func() interface{}{
+	"map[string]AnalyzerConfig":  func() interface{} { return &map[string]gothreatmatrix.AnalyzerConfig{} },
+	"map[string]ConnectorConfig": func() interface{} { return &map[string]gothreatmatrix.ConnectorConfig{} },
+	"JobListResponse":            func() interface{} { return &gothreatmatrix.JobListResponse{} },
+	"Job":                        func() interface{} { return &gothreatmatrix.Job{} },
+	"[]Tag":                      func() interface{} { return &[]gothreatmatrix.Tag{} },
+	"User":                       func() interface{} { return &gothreatmatrix.User{} },
+}
+
+type fixtureManifest struct {
+	Version  string `json:"version"`
+	Fixtures []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"fixtures"`
+}
+
+// Helper test
+// Testing that every field the struct knows about is present in the captured response,
+// a missing one means the server renamed or dropped it.
+func testKnownFields(t *testing.T, location string, want interface{}, got interface{}) {
+	t.Helper()
+	switch gotValue := got.(type) {
+	case map[string]interface{}:
+		wantValue, ok := want.(map[string]interface{})
+		if !ok {
+			t.Errorf("%s: expected an object in the fixture", location)
+			return
+		}
+		for key, gotField := range gotValue {
+			wantField, ok := wantValue[key]
+			if !ok {
+				t.Errorf("%s.%s: field missing from the fixture", location, key)
+				continue
+			}
+			testKnownFields(t, location+"."+key, wantField, gotField)
+		}
+	case []interface{}:
+		wantValue, ok := want.([]interface{})
+		if !ok {
+			t.Errorf("%s: expected an array in the fixture", location)
+			return
+		}
+		for index := 0; index < len(gotValue) && index < len(wantValue); index++ {
+			testKnownFields(t, location, wantValue[index], gotValue[index])
+		}
+	}
+}
+
+func TestFixturesDecoding(t *testing.T) {
+	manifestPaths, err := filepath.Glob(filepath.Join("testFiles", "fixtures", "*", "manifest.json"))
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if len(manifestPaths) == 0 {
+		t.Fatalf("No fixtures found")
+	}
+	for _, manifestPath := range manifestPaths {
+		manifestBytes, err := ioutil.ReadFile(manifestPath)
+		if err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		manifest := fixtureManifest{}
+		if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		for _, fixture := range manifest.Fixtures {
+			fixture := fixture
+			fixturePath := filepath.Join(filepath.Dir(manifestPath), fixture.Name+".json")
+			//* Subtest
+			t.Run(manifest.Version+"/"+fixture.Name, func(t *testing.T) {
+				newValue, ok := fixtureTypes[fixture.Type]
+				if !ok {
+					t.Fatalf("Unknown fixture type %s", fixture.Type)
+				}
+				data, err := ioutil.ReadFile(fixturePath)
+				if err != nil {
+					t.Fatalf("Error: %s", err)
+				}
+				decoded := newValue()
+				if err := json.Unmarshal(data, decoded); err != nil {
+					t.Fatalf("Could not decode into %s: %v", fixture.Type, err)
+				}
+				reencoded, err := json.Marshal(decoded)
+				if err != nil {
+					t.Fatalf("Error: %s", err)
+				}
+				var want, got interface{}
+				json.Unmarshal(data, &want)
+				json.Unmarshal(reencoded, &got)
+				testKnownFields(t, fixture.Name, want, got)
+			})
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// malformedSeeds are hostile inputs every fuzz target starts from.
+var malformedSeeds = []string{
+	``,
+	`null`,
+	`{"report": {"score": 1e400}}`,
+	`{"analyzer_reports": [{"name": "x", "report": {"score": -1e999999}}]}`,
+	`{"id": 99999999999999999999999999}`,
+	"{\"observable_name\": \"\xff\xfe\xfd\"}",
+	`{"analyzer_reports": [{"report": ` + strings.Repeat(`{"a":`, 20000) + `1` + strings.Repeat(`}`, 20000) + `}]}`,
+	`{"analyzer_reports": [{"report": ` + strings.Repeat(`[`, 5000) + strings.Repeat(`]`, 5000) + `}]}`,
+	`{"analyzer_reports": [{"report": [1, 2]}]}`,
+	`{"params": {"x": {"value": 1e400, "type": "int"}}}`,
+	`{"tlp": 4, "tags": [null]}`,
+}
+
+func addFixtureSeeds(f *testing.F, name string) {
+	paths, _ := filepath.Glob(filepath.Join("testFiles", "fixtures", "*", name))
+	for _, path := range paths {
+		if data, err := ioutil.ReadFile(path); err == nil {
+			f.Add(data)
+		}
+	}
+	for _, seed := range malformedSeeds {
+		f.Add([]byte(seed))
+	}
+}
+
+func FuzzJobUnmarshal(f *testing.F) {
+	addFixtureSeeds(f, "jobs.json")
+	f.Add([]byte(`{"id": 1, "analyzer_reports": [{"name": "Classic_DNS", "report": {"resolutions": []}}]}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		job := gothreatmatrix.Job{}
+		if err := json.Unmarshal(data, &job); err != nil {
+			return
+		}
+		if _, err := json.Marshal(job); err != nil {
+			t.Errorf("Could not marshal a decoded job: %v", err)
+		}
+		for index := range job.AnalyzerReports {
+			// only errors are acceptable here, the fuzzer reports panics
+			job.AnalyzerReports[index].Decode()
+		}
+	})
+}
+
+func FuzzAnalyzerConfigUnmarshal(f *testing.F) {
+	addFixtureSeeds(f, "analyzer_configs.json")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		analyzerConfigs := map[string]gothreatmatrix.AnalyzerConfig{}
+		if err := json.Unmarshal(data, &analyzerConfigs); err != nil {
+			return
+		}
+		if _, err := json.Marshal(analyzerConfigs); err != nil {
+			t.Errorf("Could not marshal decoded analyzer configs: %v", err)
+		}
+	})
+}
+
+func TestUntrustedReportNumbers(t *testing.T) {
+	// *a number that overflows float64 must not fail the whole job
+	data := []byte(`{"id": 1, "analyzer_reports": [{"name": "Classic_DNS", "report": {"score": 1e400, "count": 3}}]}`)
+	job := gothreatmatrix.Job{}
+	if err := json.Unmarshal(data, &job); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	report := job.AnalyzerReports[0].Report
+	if score, ok := report["score"].(json.Number); !ok || score.String() != "1e400" {
+		t.Errorf("Expected the out of range score to be kept as json.Number, got %#v", report["score"])
+	}
+	if count, ok := report["count"].(float64); !ok || count != 3 {
+		t.Errorf("Expected the count to be decoded as float64, got %#v", report["count"])
+	}
+
+	analyzerConfigs := map[string]gothreatmatrix.AnalyzerConfig{}
+	configData := []byte(`{"x": {"name": "x", "params": {"limit": {"value": 1e400, "type": "int", "description": "d"}}}}`)
+	if err := json.Unmarshal(configData, &analyzerConfigs); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	if parameter := analyzerConfigs["x"].Params["limit"]; parameter.Description != "d" || parameter.Type != "int" {
+		t.Errorf("Unexpected parameter %#v", parameter)
+	}
+}
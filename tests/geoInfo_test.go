@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestExtractGeoInfo(t *testing.T) {
+	maxMindReport := `{"name": "MaxMindGeoIP", "status": "SUCCESS", "report": {
+		"GeoLite2-City": {"country": {"iso_code": "US", "names": {"en": "United States"}}, "subdivisions": [{"names": {"en": "California"}}], "city": {"names": {"en": "Mountain View"}}, "location": {"latitude": 37.386, "longitude": -122.0838}},
+		"GeoLite2-ASN": {"autonomous_system_number": 15169, "autonomous_system_organization": "GOOGLE"}
+	}}`
+	ipInfoReport := `{"name": "IPInfo", "status": "SUCCESS", "report": {"ip": "8.8.8.8", "city": "Palo Alto", "region": "California", "country": "us", "loc": "37.4056,-122.0775", "org": "AS15169 Google LLC"}}`
+	greyNoiseReport := `{"name": "GreyNoise", "status": "SUCCESS", "report": {"metadata": {"asn": "AS15169", "city": "Los Angeles", "country": "United States", "country_code": "US", "organization": "Google LLC"}}}`
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["priority"] = TestData{
+		Input: `{"analyzer_reports": [` + greyNoiseReport + `, ` + ipInfoReport + `, ` + maxMindReport + `]}`,
+		Want: &gothreatmatrix.GeoInfo{
+			CountryCode:    "US",
+			Country:        "United States",
+			Region:         "California",
+			City:           "Mountain View",
+			Location:       &gothreatmatrix.GeoLocation{Latitude: 37.386, Longitude: -122.0838},
+			ASN:            15169,
+			ASOrganization: "GOOGLE",
+			Sources: map[string]string{
+				"country_code": "MaxMindGeoIP", "country": "MaxMindGeoIP", "region": "MaxMindGeoIP", "city": "MaxMindGeoIP",
+				"location": "MaxMindGeoIP", "asn": "MaxMindGeoIP", "as_organization": "MaxMindGeoIP",
+			},
+		},
+	}
+	testCases["complementary"] = TestData{
+		Input: `{"analyzer_reports": [` + greyNoiseReport + `, ` + ipInfoReport + `]}`,
+		Want: &gothreatmatrix.GeoInfo{
+			CountryCode:    "US",
+			Country:        "United States",
+			Region:         "California",
+			City:           "Palo Alto",
+			Location:       &gothreatmatrix.GeoLocation{Latitude: 37.4056, Longitude: -122.0775},
+			ASN:            15169,
+			ASOrganization: "Google LLC",
+			Sources: map[string]string{
+				"country_code": "IPInfo", "country": "GreyNoise", "region": "IPInfo", "city": "IPInfo",
+				"location": "IPInfo", "asn": "IPInfo", "as_organization": "IPInfo",
+			},
+		},
+	}
+	testCases["noGeoData"] = TestData{
+		Input: `{"analyzer_reports": [{"name": "GreyNoiseCommunity", "report": {"noise": false, "riot": true}}, {"name": "Classic_DNS", "report": {"resolutions": []}}]}`,
+		Want:  (*gothreatmatrix.GeoInfo)(nil),
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			job := gothreatmatrix.Job{}
+			if err := json.Unmarshal([]byte(testCase.Input.(string)), &job); err != nil {
+				t.Fatalf("Error: %s", err)
+			}
+			testWantData(t, testCase.Want, gothreatmatrix.ExtractGeoInfo(&job))
+		})
+	}
+}
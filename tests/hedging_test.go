@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// slowFirstHandler answers with data, the first request only once its client gave up on it.
+func slowFirstHandler(data string, requests *int, mutex *sync.Mutex) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		*requests++
+		first := *requests == 1
+		mutex.Unlock()
+		if first {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(300 * time.Millisecond):
+			}
+		}
+		w.Write([]byte(data))
+	}
+}
+
+func TestHedging(t *testing.T) {
+	hedging := &gothreatmatrix.HedgingOptions{Delay: 20 * time.Millisecond}
+	testCases := map[string]struct {
+		ctx          context.Context
+		method       string
+		wantRequests int
+		wantFast     bool
+	}{
+		"hedged":         {context.Background(), "GET", 2, true},
+		"withoutHedging": {gothreatmatrix.WithCallOptions(context.Background(), gothreatmatrix.WithoutHedging()), "GET", 1, false},
+		"notReadOnly":    {context.Background(), "POST", 1, false},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{Hedging: hedging})
+			defer closeServer()
+			var mutex sync.Mutex
+			requests := 0
+			apiHandler.Handle(constants.BASE_TAG_URL, slowFirstHandler(`[{"id": 1, "label": "phishing", "color": "#ff0000"}]`, &requests, &mutex))
+			apiHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, slowFirstHandler(`{"job_id": 1, "status": "accepted"}`, &requests, &mutex))
+			start := time.Now()
+			var err error
+			if testCase.method == "GET" {
+				var tags *[]gothreatmatrix.Tag
+				tags, err = client.TagService.List(testCase.ctx)
+				if err == nil {
+					testWantData(t, "phishing", (*tags)[0].Label)
+				}
+			} else {
+				_, err = client.CreateObservableAnalysis(testCase.ctx, &gothreatmatrix.ObservableAnalysisParams{
+					ObservableName:           "dns.google",
+					ObservableClassification: "domain",
+				})
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			mutex.Lock()
+			testWantData(t, testCase.wantRequests, requests)
+			mutex.Unlock()
+			testWantData(t, testCase.wantFast, time.Since(start) < 200*time.Millisecond)
+		})
+	}
+}
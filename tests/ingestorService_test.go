@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestIngestorServiceGetConfigs(t *testing.T) {
+	ingestorConfigJsonString := `{"ThreatFox":{"name":"ThreatFox","python_module":"threatfox.ThreatFox","disabled":true,"description":"Ingest the ThreatFox IOCs","playbook_to_execute":"FREE_TO_USE_ANALYZERS","schedule":"*/10 * * * *"}}`
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      nil,
+		Data:       ingestorConfigJsonString,
+		StatusCode: http.StatusOK,
+		Want: []gothreatmatrix.IngestorConfig{
+			{
+				BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "ThreatFox", PythonModule: "threatfox.ThreatFox", Disabled: true, Description: "Ingest the ThreatFox IOCs"},
+				PlaybookToExecute:     "FREE_TO_USE_ANALYZERS",
+				Schedule:              "*/10 * * * *",
+			},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			apiHandler.Handle(constants.INGESTOR_CONFIG_URL, serverHandler(t, testCase, "GET"))
+			gottenIngestorConfigList, err := client.IngestorService.GetConfigs(ctx)
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, *gottenIngestorConfigList)
+			}
+		})
+	}
+}
+
+func TestIngestorServiceHealthCheck(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      "ThreatFox",
+		Data:       `{"status": true}`,
+		StatusCode: http.StatusOK,
+		Want:       true,
+	}
+	testCases["ingestorDoesntExist"] = TestData{
+		Input:      "notAIngestor",
+		Data:       `{"errors": {"detail": "Ingestor doesn't exist"}}`,
+		StatusCode: http.StatusBadRequest,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusBadRequest,
+			Message:    `{"errors": {"detail": "Ingestor doesn't exist"}}`,
+			Errors:     map[string]interface{}{"detail": "Ingestor doesn't exist"},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			input, ok := testCase.Input.(string)
+			if ok {
+				testUrl := fmt.Sprintf(constants.INGESTOR_HEALTHCHECK_URL, input)
+				apiHandler.Handle(testUrl, serverHandler(t, testCase, "GET"))
+				status, err := client.IngestorService.HealthCheck(ctx, input)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, testCase.Want, status)
+				}
+			}
+		})
+	}
+}
+
+func TestIngestorServicePull(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      "ThreatFox",
+		Data:       `{"status": true}`,
+		StatusCode: http.StatusOK,
+		Want:       true,
+	}
+	testCases["ingestorDoesntExist"] = TestData{
+		Input:      "notAIngestor",
+		Data:       `{"errors": {"detail": "Ingestor doesn't exist"}}`,
+		StatusCode: http.StatusBadRequest,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusBadRequest,
+			Message:    `{"errors": {"detail": "Ingestor doesn't exist"}}`,
+			Errors:     map[string]interface{}{"detail": "Ingestor doesn't exist"},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			input := testCase.Input.(string)
+			apiHandler.Handle(fmt.Sprintf(constants.INGESTOR_PULL_URL, input), serverHandler(t, testCase, "POST"))
+			status, err := client.IngestorService.Pull(context.Background(), input)
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestVerifySample(t *testing.T) {
+	sample := []byte("This is the sample")
+	md5Hash := md5.Sum(sample)
+	sha256Hash := sha256.Sum256(sample)
+	testCases := map[string]struct {
+		md5     string
+		sha256  string
+		wantErr *gothreatmatrix.IntegrityError
+	}{
+		"match": {
+			md5:    hex.EncodeToString(md5Hash[:]),
+			sha256: strings.ToUpper(hex.EncodeToString(sha256Hash[:])),
+		},
+		"noHashes": {},
+		"md5Mismatch": {
+			md5: "1872746d244c489367a7b543c484e60b",
+			wantErr: &gothreatmatrix.IntegrityError{
+				Subject:   "sample",
+				Algorithm: "md5",
+				Expected:  "1872746d244c489367a7b543c484e60b",
+				Actual:    hex.EncodeToString(md5Hash[:]),
+				Err:       gothreatmatrix.ErrSampleChecksumMismatch,
+			},
+		},
+		"sha256Mismatch": {
+			md5:    hex.EncodeToString(md5Hash[:]),
+			sha256: strings.Repeat("0", 64),
+			wantErr: &gothreatmatrix.IntegrityError{
+				Subject:   "sample",
+				Algorithm: "sha256",
+				Expected:  strings.Repeat("0", 64),
+				Actual:    hex.EncodeToString(sha256Hash[:]),
+				Err:       gothreatmatrix.ErrSampleChecksumMismatch,
+			},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			job := &gothreatmatrix.Job{}
+			job.Md5 = testCase.md5
+			if testCase.sha256 != "" {
+				job.AnalyzerReports = []gothreatmatrix.Report{{Name: "File_Info", Status: "SUCCESS", Report: map[string]interface{}{"sha256": testCase.sha256}}}
+			}
+			err := gothreatmatrix.VerifySample(job, bytes.NewReader(sample))
+			if testCase.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			integrityError := &gothreatmatrix.IntegrityError{}
+			if !errors.As(err, &integrityError) {
+				t.Fatalf("Expected an IntegrityError, got: %v", err)
+			}
+			if !errors.Is(err, gothreatmatrix.ErrSampleChecksumMismatch) {
+				t.Fatalf("Expected ErrSampleChecksumMismatch, got: %v", err)
+			}
+			want, got := *testCase.wantErr, *integrityError
+			want.Err, got.Err = nil, nil
+			testWantData(t, want, got)
+		})
+	}
+}
+
+// rewriteBundle copies the bundle, letting edit change, drop (nil) or add files.
+func rewriteBundle(t *testing.T, bundle []byte, edit func(files map[string][]byte)) []byte {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil {
+		t.Fatalf("Could not read the bundle: %v", err)
+	}
+	files := map[string][]byte{}
+	names := []string{}
+	for _, file := range archive.File {
+		reader, _ := file.Open()
+		files[file.Name], _ = ioutil.ReadAll(reader)
+		reader.Close()
+		names = append(names, file.Name)
+	}
+	edit(files)
+	for name := range files {
+		if !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	rewritten := &bytes.Buffer{}
+	writer := zip.NewWriter(rewritten)
+	for _, name := range names {
+		if content, ok := files[name]; ok && content != nil {
+			fileWriter, _ := writer.Create(name)
+			fileWriter.Write(content)
+		}
+	}
+	writer.Close()
+	return rewritten.Bytes()
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestJobBundle(t *testing.T) {
+	sample := []byte("This is the sample")
+	md5Hash := md5.Sum(sample)
+	jobJson := fmt.Sprintf(`{"id": 1, "is_sample": true, "file_name": "sample.exe", "md5": "%s", "status": "reported_without_fails"}`, hex.EncodeToString(md5Hash[:]))
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), serverHandler(t, TestData{Data: jobJson}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.DOWNLOAD_SAMPLE_JOB_URL, 1), serverHandler(t, TestData{Data: string(sample)}, "GET"))
+	bundleBuffer := &bytes.Buffer{}
+	if err := client.JobService.ExportBundle(context.Background(), 1, bundleBuffer, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	bundle := bundleBuffer.Bytes()
+
+	testCases := map[string]struct {
+		edit    func(files map[string][]byte)
+		wantErr error
+	}{
+		"intact": {
+			edit: func(files map[string][]byte) {},
+		},
+		"tampered": {
+			edit:    func(files map[string][]byte) { files["sample/sample.exe"] = []byte("This is not the sample") },
+			wantErr: gothreatmatrix.ErrBundleChecksumMismatch,
+		},
+		"missingFile": {
+			edit:    func(files map[string][]byte) { files["report.html"] = nil },
+			wantErr: gothreatmatrix.ErrBundleFileMissing,
+		},
+		"extraFile": {
+			edit:    func(files map[string][]byte) { files["notes.txt"] = []byte("added later") },
+			wantErr: gothreatmatrix.ErrBundleFileNotInManifest,
+		},
+		"missingManifest": {
+			edit:    func(files map[string][]byte) { files[gothreatmatrix.BUNDLE_MANIFEST_NAME] = nil },
+			wantErr: gothreatmatrix.ErrBundleManifestMissing,
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			edited := rewriteBundle(t, bundle, testCase.edit)
+			err := gothreatmatrix.VerifyJobBundle(bytes.NewReader(edited), int64(len(edited)))
+			if testCase.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, testCase.wantErr) {
+				t.Fatalf("Expected %v, got: %v", testCase.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestJobBundleSampleMismatch(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), serverHandler(t, TestData{Data: `{"id": 1, "is_sample": true, "md5": "1872746d244c489367a7b543c484e60b"}`}, "GET"))
+	apiHandler.HandleFunc(fmt.Sprintf(constants.DOWNLOAD_SAMPLE_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("This is the sample"))
+	})
+	err := client.JobService.ExportBundle(context.Background(), 1, &bytes.Buffer{}, true)
+	if !errors.Is(err, gothreatmatrix.ErrSampleChecksumMismatch) {
+		t.Fatalf("Expected ErrSampleChecksumMismatch, got: %v", err)
+	}
+}
+
+func TestCreateFileAnalysisVerifyUploads(t *testing.T) {
+	content, err := os.ReadFile("./testFiles/fileForAnalysis.txt")
+	if err != nil {
+		t.Fatalf("Could not read the file: %v", err)
+	}
+	md5Hash := md5.Sum(content)
+	goodMd5 := hex.EncodeToString(md5Hash[:])
+	offsetMd5Hash := md5.Sum(content[5:])
+	offsetMd5 := hex.EncodeToString(offsetMd5Hash[:])
+	badMd5 := "1872746d244c489367a7b543c484e60b"
+	testCases := map[string]struct {
+		resubmit    bool
+		offset      int64
+		md5s        []string
+		wantJobID   int
+		wantUploads int
+		wantErr     error
+	}{
+		"match": {
+			md5s:        []string{goodMd5},
+			wantJobID:   1,
+			wantUploads: 1,
+		},
+		"mismatch": {
+			md5s:        []string{badMd5},
+			wantJobID:   1,
+			wantUploads: 1,
+			wantErr:     gothreatmatrix.ErrUploadChecksumMismatch,
+		},
+		"resubmitted": {
+			resubmit:    true,
+			md5s:        []string{badMd5, goodMd5},
+			wantJobID:   2,
+			wantUploads: 2,
+		},
+		"resubmittedMismatch": {
+			resubmit:    true,
+			md5s:        []string{badMd5, badMd5},
+			wantJobID:   2,
+			wantUploads: 2,
+			wantErr:     gothreatmatrix.ErrUploadChecksumMismatch,
+		},
+		// * the part of the file from its offset is uploaded, verified and resubmitted
+		"resubmittedFromOffset": {
+			resubmit:    true,
+			offset:      5,
+			md5s:        []string{badMd5, offsetMd5},
+			wantJobID:   2,
+			wantUploads: 2,
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+				VerifyUploads:          true,
+				ResubmitCorruptUploads: testCase.resubmit,
+			})
+			defer closeServer()
+			uploads := 0
+			apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+				uploads++
+				uploadedFile, _, err := r.FormFile("file")
+				if err != nil {
+					t.Errorf("Missing file: %v", err)
+					return
+				}
+				if uploadedContent, _ := io.ReadAll(uploadedFile); !bytes.Equal(uploadedContent, content[testCase.offset:]) {
+					t.Errorf("Uploaded %q, want %q", uploadedContent, content[testCase.offset:])
+				}
+				fmt.Fprintf(w, `{"job_id": %d, "status": "accepted"}`, uploads)
+			})
+			for index, md5 := range testCase.md5s {
+				jobJson := fmt.Sprintf(`{"id": %d, "is_sample": true, "file_name": "fileForAnalysis.txt", "md5": %q}`, index+1, md5)
+				apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, index+1), serverHandler(t, TestData{Data: jobJson}, "GET"))
+			}
+			file, err := os.Open("./testFiles/fileForAnalysis.txt")
+			if err != nil {
+				t.Fatalf("Could not open the file: %v", err)
+			}
+			defer file.Close()
+			file.Seek(testCase.offset, io.SeekStart)
+			analysisResponse, err := client.CreateFileAnalysis(context.Background(), &gothreatmatrix.FileAnalysisParams{File: file})
+			if testCase.wantErr != nil {
+				var integrityError *gothreatmatrix.IntegrityError
+				if !errors.Is(err, testCase.wantErr) || !errors.As(err, &integrityError) {
+					t.Fatalf("Expected an IntegrityError wrapping %v, got: %v", testCase.wantErr, err)
+				}
+				if integrityError.Subject != "fileForAnalysis.txt" || integrityError.Actual != goodMd5 {
+					t.Errorf("Unexpected integrity error: %+v", integrityError)
+				}
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if analysisResponse == nil || analysisResponse.JobID != testCase.wantJobID {
+				t.Errorf("Expected job %d, got: %+v", testCase.wantJobID, analysisResponse)
+			}
+			if uploads != testCase.wantUploads {
+				t.Errorf("Expected %d uploads, got %d", testCase.wantUploads, uploads)
+			}
+		})
+	}
+}
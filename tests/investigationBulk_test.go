@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// investigationBulkTreeJson has a job that stopped, a running pivoted one, a failed one and one reported with fails.
+const investigationBulkTreeJson = `{"name": "case 42", "jobs": [
+	{"pk": 1, "status": "reported_without_fails", "children": [{"pk": 2, "status": "analyzers_running"}]},
+	{"pk": 3, "status": "failed"},
+	{"pk": 4, "status": "reported_with_fails"}
+]}`
+
+// setupInvestigationBulk serves the tree of investigation 1 and records the job actions it receives,
+// refusing the ones on the failing path.
+func setupInvestigationBulk(t *testing.T, failingPath string) (gothreatmatrix.ThreatMatrixClient, *[]string, func()) {
+	client, apiHandler, closeServer := setup()
+	apiHandler.Handle(fmt.Sprintf(constants.INVESTIGATION_TREE_URL, 1), serverHandler(t, TestData{Data: investigationBulkTreeJson}, "GET"))
+	var mutex sync.Mutex
+	actions := []string{}
+	record := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == failingPath {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors": {"detail": "Job is not running"}}`))
+			return
+		}
+		mutex.Lock()
+		actions = append(actions, r.Method+" "+r.URL.Path)
+		mutex.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}
+	for jobId := 1; jobId <= 4; jobId++ {
+		apiHandler.HandleFunc(fmt.Sprintf(constants.KILL_JOB_URL, jobId), record)
+		apiHandler.HandleFunc(fmt.Sprintf(constants.RETRY_ANALYZER_JOB_URL, jobId, "Classic_DNS"), record)
+		jobJson := fmt.Sprintf(`{"id": %d, "analyzer_reports": [{"name": "Classic_DNS", "status": "FAILED"}]}`, jobId)
+		apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, jobId), serverHandler(t, TestData{Data: jobJson}, "GET"))
+	}
+	return client, &actions, closeServer
+}
+
+func TestInvestigationServiceKillRunning(t *testing.T) {
+	client, actions, closeServer := setupInvestigationBulk(t, fmt.Sprintf(constants.KILL_JOB_URL, 2))
+	defer closeServer()
+	results, err := client.InvestigationService.KillRunning(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].JobID != 2 || results[0].Err == nil {
+		t.Fatalf("Expected the kill of job 2 to fail, got: %+v", results)
+	}
+	testWantData(t, []string{}, *actions)
+}
+
+func TestInvestigationServiceRetryFailed(t *testing.T) {
+	client, actions, closeServer := setupInvestigationBulk(t, "")
+	defer closeServer()
+	results, err := client.InvestigationService.RetryFailed(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []gothreatmatrix.InvestigationJobResult{
+		{JobID: 3, Retry: &gothreatmatrix.JobRetry{JobID: 3, Analyzers: []string{"Classic_DNS"}, Connectors: []string{}}},
+		{JobID: 4, Retry: &gothreatmatrix.JobRetry{JobID: 4, Analyzers: []string{"Classic_DNS"}, Connectors: []string{}}},
+	}, results)
+	testWantData(t, []string{"PATCH /api/jobs/3/analyzer/Classic_DNS/retry", "PATCH /api/jobs/4/analyzer/Classic_DNS/retry"}, *actions)
+}
+
+func TestInvestigationServiceExportAll(t *testing.T) {
+	client, _, closeServer := setupInvestigationBulk(t, "")
+	defer closeServer()
+	exported := []int{}
+	sink := gothreatmatrix.JobSinkFunc(func(ctx context.Context, job *gothreatmatrix.Job) error {
+		exported = append(exported, job.ID)
+		return nil
+	})
+	results, err := client.InvestigationService.ExportAll(context.Background(), 1, sink)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 4, len(results))
+	testWantData(t, []int{1, 2, 3, 4}, exported)
+}
+
+func TestInvestigationServiceRetag(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_INVESTIGATION_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PATCH")
+		params := map[string][]string{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("Could not parse request body: %v", err)
+		}
+		testWantData(t, []string{"closed", "false-positive"}, params["tags"])
+		fmt.Fprintf(w, `{"id": 1, "name": "case 42", "tags": ["closed", "false-positive"]}`)
+	})
+	investigation, err := client.InvestigationService.Retag(context.Background(), 1, []string{"closed", "false-positive"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"closed", "false-positive"}, investigation.Tags)
+}
@@ -0,0 +1,316 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestInvestigationServiceCreate(t *testing.T) {
+	investigationJsonString := `{"id": 1, "name": "phishing campaign", "description": "wave of 2022-07-15", "owner": {"username": "hussain"}, "status": "created", "tags": [], "jobs": [], "total_jobs": 0, "start_time": null, "end_time": null}`
+	investigation := gothreatmatrix.Investigation{}
+	if unmarshalError := json.Unmarshal([]byte(investigationJsonString), &investigation); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input: gothreatmatrix.InvestigationParams{
+			Name:        "phishing campaign",
+			Description: "wave of 2022-07-15",
+		},
+		Data:       investigationJsonString,
+		StatusCode: http.StatusCreated,
+		Want:       &investigation,
+	}
+	testCases["badRequest"] = TestData{
+		Input:      gothreatmatrix.InvestigationParams{},
+		Data:       `{"name": ["This field may not be blank."]}`,
+		StatusCode: http.StatusBadRequest,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusBadRequest,
+			Message:    `{"name": ["This field may not be blank."]}`,
+			Errors:     map[string]interface{}{"name": []interface{}{"This field may not be blank."}},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			apiHandler.Handle(constants.BASE_INVESTIGATION_URL, serverHandler(t, testCase, "POST"))
+			investigationParams := testCase.Input.(gothreatmatrix.InvestigationParams)
+			gottenInvestigation, err := client.InvestigationService.Create(ctx, &investigationParams)
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, gottenInvestigation)
+			}
+		})
+	}
+}
+
+func TestInvestigationServiceAddJob(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      uint64(10),
+		StatusCode: http.StatusOK,
+		Want:       true,
+	}
+	testCases["jobNotFound"] = TestData{
+		Input:      uint64(11),
+		Data:       `{"detail": "Not found."}`,
+		StatusCode: http.StatusNotFound,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusNotFound,
+			Message:    `{"detail": "Not found."}`,
+			Detail:     "Not found.",
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			jobId := testCase.Input.(uint64)
+			apiHandler.HandleFunc(fmt.Sprintf(constants.ADD_JOB_TO_INVESTIGATION_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				params := map[string]uint64{}
+				if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+					t.Errorf("Could not parse request body: %v", err)
+				}
+				testWantData(t, jobId, params["job"])
+				w.WriteHeader(testCase.StatusCode)
+				w.Write([]byte(testCase.Data))
+			})
+			added, err := client.InvestigationService.AddJob(ctx, 1, jobId)
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, added)
+			}
+		})
+	}
+}
+
+func TestInvestigationServiceCreateFromTemplate(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.BASE_INVESTIGATION_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		params := gothreatmatrix.InvestigationParams{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("Could not parse request body: %v", err)
+		}
+		testWantData(t, gothreatmatrix.InvestigationParams{Name: "Phishing email: INC-42", Description: gothreatmatrix.PHISHING_EMAIL_TEMPLATE.Description, Tags: []string{"phishing"}}, params)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 7, "name": "Phishing email: INC-42", "tags": ["phishing"]}`))
+	})
+	submissions := []string{}
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		params := gothreatmatrix.ObservableAnalysisParams{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("Could not parse request body: %v", err)
+		}
+		testWantData(t, []string{"phishing"}, params.TagsLabels)
+		testWantData(t, gothreatmatrix.AMBER, params.Tlp)
+		submissions = append(submissions, params.ObservableName+" "+params.PlaybookRequested)
+		w.Write([]byte(fmt.Sprintf(`{"job_id": %d, "status": "accepted"}`, len(submissions))))
+	})
+	apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Could not parse the form: %v", err)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Could not read the file: %v", err)
+		}
+		content, _ := io.ReadAll(file)
+		if len(content) == 0 {
+			t.Errorf("The file was uploaded empty")
+		}
+		submissions = append(submissions, header.Filename+" "+r.FormValue("playbook_requested"))
+		w.Write([]byte(fmt.Sprintf(`{"job_id": %d, "status": "accepted"}`, len(submissions))))
+	})
+	added := []string{}
+	apiHandler.HandleFunc(fmt.Sprintf(constants.ADD_JOB_TO_INVESTIGATION_URL, 7), func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		added = append(added, string(body))
+		w.WriteHeader(http.StatusOK)
+	})
+	file, err := os.Open("testFiles/fileForAnalysis.txt")
+	if err != nil {
+		t.Fatalf("Could not open the file: %v", err)
+	}
+	defer file.Close()
+	templated, err := client.InvestigationService.CreateFromTemplate(context.Background(), &gothreatmatrix.PHISHING_EMAIL_TEMPLATE, &gothreatmatrix.InvestigationSeed{
+		Name:        "INC-42",
+		Observables: []string{"http://login.example.com/reset", "example.com", "unclassified value"},
+		Files:       []*os.File{file},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{
+		"http://login.example.com/reset Popular_URL_Reputation_Services",
+		"example.com Dns",
+		"example.com Popular_URL_Reputation_Services",
+		"fileForAnalysis.txt Sample_Static_Analysis",
+	}, submissions)
+	testWantData(t, []uint64{1, 2, 3, 4}, templated.Jobs)
+	testWantData(t, []string{"unclassified value"}, templated.Skipped)
+	testWantData(t, uint64(7), templated.Investigation.ID)
+	testWantData(t, []string{`{"job":1}`, `{"job":2}`, `{"job":3}`, `{"job":4}`}, added)
+}
+
+func TestInvestigationServiceGet(t *testing.T) {
+	investigationJsonString := `{"id": 1, "name": "phishing campaign", "description": "", "owner": {"username": "hussain"}, "status": "running", "tags": [], "jobs": [4, 5], "total_jobs": 2, "start_time": null, "end_time": null}`
+	investigation := gothreatmatrix.Investigation{}
+	if unmarshalError := json.Unmarshal([]byte(investigationJsonString), &investigation); unmarshalError != nil {
+		t.Fatalf("Error: %s", unmarshalError)
+	}
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      uint64(1),
+		Data:       investigationJsonString,
+		StatusCode: http.StatusOK,
+		Want:       &investigation,
+	}
+	testCases["notFound"] = TestData{
+		Input:      uint64(2),
+		Data:       `{"detail": "Not found."}`,
+		StatusCode: http.StatusNotFound,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusNotFound,
+			Message:    `{"detail": "Not found."}`,
+			Detail:     "Not found.",
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			investigationId := testCase.Input.(uint64)
+			apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_INVESTIGATION_URL, investigationId), serverHandler(t, testCase, "GET"))
+			gottenInvestigation, err := client.InvestigationService.Get(ctx, investigationId)
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, gottenInvestigation)
+			}
+		})
+	}
+}
+
+func TestInvestigationServiceList(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.BASE_INVESTIGATION_URL, serverHandler(t, TestData{
+		Data: `{"count": 1, "total_pages": 1, "results": [{"id": 1, "name": "phishing campaign", "jobs": [4]}]}`,
+	}, "GET"))
+	investigationList, err := client.InvestigationService.List(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, &gothreatmatrix.InvestigationListResponse{
+		Count:      1,
+		TotalPages: 1,
+		Results:    []gothreatmatrix.Investigation{{ID: 1, Name: "phishing campaign", Jobs: []uint64{4}}},
+	}, investigationList)
+}
+
+func TestInvestigationServiceTree(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(fmt.Sprintf(constants.INVESTIGATION_TREE_URL, 1), serverHandler(t, TestData{
+		Data: `{"name": "phishing campaign", "jobs": [{"pk": 4, "analyzed_object_name": "evil.example.com", "playbook": "Dns", "status": "reported_without_fails", "is_sample": false, "children": [{"pk": 5, "analyzed_object_name": "10.1.2.3", "playbook": "Popular_IP_Reputation_Services", "status": "running", "is_sample": false}]}]}`,
+	}, "GET"))
+	investigationTree, err := client.InvestigationService.Tree(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, &gothreatmatrix.InvestigationTree{
+		Name: "phishing campaign",
+		Jobs: []gothreatmatrix.InvestigationTreeJob{{
+			ID:                 4,
+			AnalyzedObjectName: "evil.example.com",
+			Playbook:           "Dns",
+			Status:             "reported_without_fails",
+			Children: []gothreatmatrix.InvestigationTreeJob{{
+				ID:                 5,
+				AnalyzedObjectName: "10.1.2.3",
+				Playbook:           "Popular_IP_Reputation_Services",
+				Status:             "running",
+			}},
+		}},
+	}, investigationTree)
+}
+
+func TestInvestigationServiceRemoveJob(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(fmt.Sprintf(constants.REMOVE_JOB_FROM_INVESTIGATION_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		params := map[string]uint64{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("Could not parse request body: %v", err)
+		}
+		testWantData(t, uint64(4), params["job"])
+		w.WriteHeader(http.StatusOK)
+	})
+	removed, err := client.InvestigationService.RemoveJob(context.Background(), 1, 4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, true, removed)
+}
+
+func TestInvestigationServiceDelete(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      uint64(1),
+		Data:       "",
+		StatusCode: http.StatusNoContent,
+		Want:       true,
+	}
+	testCases["notFound"] = TestData{
+		Input:      uint64(2),
+		Data:       `{"detail": "Not found."}`,
+		StatusCode: http.StatusNotFound,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusNotFound,
+			Message:    `{"detail": "Not found."}`,
+			Detail:     "Not found.",
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			investigationId := testCase.Input.(uint64)
+			apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_INVESTIGATION_URL, investigationId), serverHandler(t, testCase, "DELETE"))
+			deleted, err := client.InvestigationService.Delete(ctx, investigationId)
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, deleted)
+			}
+		})
+	}
+}
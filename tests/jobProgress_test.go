@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestJobProgress(t *testing.T) {
+	softTimeLimits := map[string]time.Duration{
+		"Cuckoo_Scan": 20 * time.Minute,
+		"File_Info":   time.Minute,
+	}
+	now := time.Date(2023, 1, 1, 10, 10, 0, 0, time.UTC)
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["sandboxHalfway"] = TestData{
+		Input: `{"status": "running", "received_request_time": "2023-01-01T10:00:00Z", "analyzers_to_execute": ["Cuckoo_Scan", "File_Info"],
+			"analyzer_reports": [{"name": "File_Info", "status": "SUCCESS"}, {"name": "Cuckoo_Scan", "status": "RUNNING", "start_time": "2023-01-01T10:00:00Z"}]}`,
+		Want: gothreatmatrix.JobProgress{AnalyzersCompleted: 1, AnalyzersTotal: 2, Elapsed: 10 * time.Minute, Expected: 20 * time.Minute, Fraction: 0.75},
+	}
+	testCases["overTheLimit"] = TestData{
+		Input: `{"status": "running", "received_request_time": "2023-01-01T09:00:00Z", "analyzers_to_execute": ["Cuckoo_Scan"]}`,
+		Want:  gothreatmatrix.JobProgress{AnalyzersTotal: 1, Elapsed: 70 * time.Minute, Expected: 20 * time.Minute, Fraction: 0.95},
+	}
+	testCases["unknownLimit"] = TestData{
+		Input: `{"status": "running", "received_request_time": "2023-01-01T10:00:00Z", "analyzers_to_execute": ["Yara", "File_Info"],
+			"analyzer_reports": [{"name": "File_Info", "status": "SUCCESS"}]}`,
+		Want: gothreatmatrix.JobProgress{AnalyzersCompleted: 1, AnalyzersTotal: 2, Elapsed: 10 * time.Minute, Expected: time.Minute, Fraction: 0.5},
+	}
+	testCases["finished"] = TestData{
+		Input: `{"status": "reported_with_fails", "received_request_time": "2023-01-01T10:00:00Z", "finished_analysis_time": "2023-01-01T10:03:00Z", "analyzers_to_execute": ["File_Info"]}`,
+		Want:  gothreatmatrix.JobProgress{AnalyzersCompleted: 1, AnalyzersTotal: 1, Elapsed: 3 * time.Minute, Expected: time.Minute, Fraction: 1},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			job := gothreatmatrix.Job{}
+			if err := json.Unmarshal([]byte(testCase.Input.(string)), &job); err != nil {
+				t.Fatalf("Error: %s", err)
+			}
+			testWantData(t, testCase.Want, job.Progress(softTimeLimits, now))
+		})
+	}
+}
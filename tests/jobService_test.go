@@ -2,11 +2,22 @@ package tests
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/khulnasoft/go-threatmatrix/constants"
 	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
 )
@@ -67,6 +78,7 @@ func TestJobServiceGet(t *testing.T) {
 			Want: &gothreatmatrix.ThreatMatrixError{
 				StatusCode: http.StatusNotFound,
 				Message:    `{"detail":"Not found."}`,
+				Detail:     "Not found.",
 			},
 		}
 		for name, testCase := range testCases {
@@ -111,6 +123,7 @@ func TestJobServiceDownloadSample(t *testing.T) {
 		Want: &gothreatmatrix.ThreatMatrixError{
 			StatusCode: http.StatusBadRequest,
 			Message:    doesNotHaveASampleResponseJsonString,
+			Errors:     map[string]interface{}{"detail": "Requested job does not have a sample associated with it."},
 		},
 	}
 	for name, testCase := range testCases {
@@ -154,6 +167,7 @@ func TestJobServiceDelete(t *testing.T) {
 		Want: &gothreatmatrix.ThreatMatrixError{
 			StatusCode: http.StatusNotFound,
 			Message:    notFoundJson,
+			Detail:     "Not found.",
 		},
 	}
 	for name, testCase := range testCases {
@@ -194,6 +208,7 @@ func TestJobServiceKill(t *testing.T) {
 		Want: &gothreatmatrix.ThreatMatrixError{
 			StatusCode: http.StatusNotFound,
 			Message:    `{"detail":"Not found."}`,
+			Detail:     "Not found.",
 		},
 	}
 	testCases["jobNotRunning"] = TestData{
@@ -203,6 +218,7 @@ func TestJobServiceKill(t *testing.T) {
 		Want: &gothreatmatrix.ThreatMatrixError{
 			StatusCode: http.StatusBadRequest,
 			Message:    `{"errors":{"detail":"Job is not running"}}`,
+			Errors:     map[string]interface{}{"detail": "Job is not running"},
 		},
 	}
 	for name, testCase := range testCases {
@@ -254,6 +270,7 @@ func TestJobServiceKillAnalyzer(t *testing.T) {
 		Want: &gothreatmatrix.ThreatMatrixError{
 			StatusCode: http.StatusNotFound,
 			Message:    `{"errors":{"analyzer report":"Not found."}}`,
+			Errors:     map[string]interface{}{"analyzer report": "Not found."},
 		},
 	}
 	testCases["analyzerNotRunning"] = TestData{
@@ -266,6 +283,7 @@ func TestJobServiceKillAnalyzer(t *testing.T) {
 		Want: &gothreatmatrix.ThreatMatrixError{
 			StatusCode: http.StatusBadRequest,
 			Message:    `{"errors":{"detail":"Plugin call is not running or pending"}}`,
+			Errors:     map[string]interface{}{"detail": "Plugin call is not running or pending"},
 		},
 	}
 	for name, testCase := range testCases {
@@ -387,3 +405,694 @@ func TestJobServiceRetryConnector(t *testing.T) {
 		})
 	}
 }
+
+func TestJobServiceRetry(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 3), serverHandler(t, TestData{Data: `{"id": 3, "status": "reported_with_fails",
+		"analyzer_reports": [{"name": "Classic_DNS", "status": "SUCCESS"}, {"name": "Shodan", "status": "FAILED"}, {"name": "Cuckoo_Scan", "status": "KILLED"}],
+		"connector_reports": [{"name": "YETI", "status": "FAILED"}, {"name": "MISP", "status": "SUCCESS"}]}`}, "GET"))
+	retried := []string{}
+	for _, path := range []string{
+		fmt.Sprintf(constants.RETRY_ANALYZER_JOB_URL, 3, "Shodan"),
+		fmt.Sprintf(constants.RETRY_ANALYZER_JOB_URL, 3, "Cuckoo_Scan"),
+		fmt.Sprintf(constants.RETRY_CONNECTOR_JOB_URL, 3, "YETI"),
+	} {
+		apiHandler.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			testMethod(t, r, "PATCH")
+			retried = append(retried, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+	retry, err := client.JobService.Retry(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, &gothreatmatrix.JobRetry{JobID: 3, Analyzers: []string{"Shodan", "Cuckoo_Scan"}, Connectors: []string{"YETI"}}, retry)
+	testWantData(t, 3, len(retried))
+}
+
+func TestJobServiceGetMany(t *testing.T) {
+	notFoundJsonString := `{"detail":"Not found."}`
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      []uint64{1, 9000, 2},
+		StatusCode: http.StatusOK,
+		Want: []gothreatmatrix.JobResult{
+			{
+				ID:  1,
+				Job: &gothreatmatrix.Job{BaseJob: gothreatmatrix.BaseJob{ID: 1, Status: "reported_without_fails"}},
+			},
+			{
+				ID: 9000,
+				Err: &gothreatmatrix.ThreatMatrixError{
+					StatusCode: http.StatusNotFound,
+					Message:    notFoundJsonString,
+					Detail:     "Not found.",
+				},
+			},
+			{
+				ID:  2,
+				Job: &gothreatmatrix.Job{BaseJob: gothreatmatrix.BaseJob{ID: 2, Status: "running"}},
+			},
+		},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			jobIds, ok := testCase.Input.([]uint64)
+			if ok {
+				apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), serverHandler(t, TestData{StatusCode: http.StatusOK, Data: `{"id":1,"status":"reported_without_fails"}`}, "GET"))
+				apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 2), serverHandler(t, TestData{StatusCode: http.StatusOK, Data: `{"id":2,"status":"running"}`}, "GET"))
+				apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 9000), serverHandler(t, TestData{StatusCode: http.StatusNotFound, Data: notFoundJsonString}, "GET"))
+				gottenResults := client.JobService.GetMany(ctx, jobIds, 2)
+				diff := cmp.Diff(testCase.Want, gottenResults, cmpopts.IgnoreFields(gothreatmatrix.ThreatMatrixError{}, "Response"))
+				if diff != "" {
+					t.Fatalf(diff)
+				}
+			} else {
+				t.Fatalf("Casting failed!")
+			}
+		})
+	}
+}
+
+func TestJobServiceListWithFilter(t *testing.T) {
+	receivedAfter := time.Date(2022, time.July, 15, 20, 0, 0, 0, time.UTC)
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      gothreatmatrix.NewJobFilter().Status(gothreatmatrix.RUNNING).TagIn("phishing", "malware").ReceivedAfter(receivedAfter),
+		Data:       `{"count":1,"total_pages":1,"results":[{"id":74,"status":"running"}]}`,
+		StatusCode: http.StatusOK,
+		Want: url.Values{
+			"status":                     []string{"running"},
+			"tags":                       []string{"phishing,malware"},
+			"received_request_time__gte": []string{"2022-07-15T20:00:00Z"},
+		},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			jobFilter, ok := testCase.Input.(*gothreatmatrix.JobFilter)
+			if ok {
+				apiHandler.HandleFunc(constants.BASE_JOB_URL, func(w http.ResponseWriter, r *http.Request) {
+					testMethod(t, r, "GET")
+					testWantData(t, testCase.Want, r.URL.Query())
+					w.Write([]byte(testCase.Data))
+				})
+				gottenJobList, err := client.JobService.ListWithFilter(ctx, jobFilter)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, 1, gottenJobList.Count)
+				}
+			} else {
+				t.Fatalf("Casting failed!")
+			}
+		})
+	}
+}
+
+func TestJobServiceWaitForCompletion(t *testing.T) {
+	// * the job as it is seen on each poll
+	jobPolls := []string{
+		`{"id": 1, "status": "running", "analyzers_to_execute": ["Classic_DNS", "Cuckoo_Scan"], "analyzer_reports": [{"name": "Classic_DNS", "status": "RUNNING"}]}`,
+		`{"id": 1, "status": "running", "analyzers_to_execute": ["Classic_DNS", "Cuckoo_Scan"], "analyzer_reports": [{"name": "Classic_DNS", "status": "SUCCESS"}]}`,
+		`{"id": 1, "status": "reported_without_fails", "analyzers_to_execute": ["Classic_DNS", "Cuckoo_Scan"], "analyzer_reports": [{"name": "Classic_DNS", "status": "SUCCESS"}, {"name": "Cuckoo_Scan", "status": "SUCCESS"}]}`,
+	}
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["complete"] = TestData{
+		Input: &gothreatmatrix.WaitOptions{},
+		Want:  gothreatmatrix.WaitResult{Complete: true, PendingAnalyzers: []string{}},
+	}
+	testCases["mustHave"] = TestData{
+		Input: &gothreatmatrix.WaitOptions{MustHaveAnalyzers: []string{"Classic_DNS"}},
+		Want:  gothreatmatrix.WaitResult{PendingAnalyzers: []string{"Cuckoo_Scan"}},
+	}
+	testCases["timeout"] = TestData{
+		Input: &gothreatmatrix.WaitOptions{AnalyzerTimeout: 25 * time.Millisecond, KillOnTimeout: true},
+		Want:  gothreatmatrix.WaitResult{PendingAnalyzers: []string{"Classic_DNS", "Cuckoo_Scan"}, TimedOut: true},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			polls := 0
+			killed := []string{}
+			apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				poll := polls
+				if name == "timeout" {
+					//* the job never moves on
+					poll = 0
+				}
+				if poll >= len(jobPolls) {
+					poll = len(jobPolls) - 1
+				}
+				polls++
+				w.Write([]byte(jobPolls[poll]))
+			})
+			apiHandler.HandleFunc("/api/jobs/1/analyzer/", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "PATCH")
+				killed = append(killed, r.URL.Path)
+				w.WriteHeader(http.StatusNoContent)
+			})
+			options := testCase.Input.(*gothreatmatrix.WaitOptions)
+			options.PollInterval = time.Millisecond
+			result, err := client.JobService.WaitForCompletion(ctx, 1, options)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			diff := cmp.Diff(testCase.Want, *result, cmpopts.IgnoreFields(gothreatmatrix.WaitResult{}, "Job"))
+			if diff != "" {
+				t.Fatalf(diff)
+			}
+			if options.KillOnTimeout {
+				testWantData(t, []string{"/api/jobs/1/analyzer/Classic_DNS/kill", "/api/jobs/1/analyzer/Cuckoo_Scan/kill"}, killed)
+			}
+		})
+	}
+}
+
+func TestJobServiceWaitForCompletionBackoff(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	polls := 0
+	apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 5 {
+			w.Write([]byte(`{"id": 1, "status": "running"}`))
+			return
+		}
+		w.Write([]byte(`{"id": 1, "status": "reported_without_fails"}`))
+	})
+	start := time.Now()
+	result, err := client.JobService.WaitForCompletion(context.Background(), 1, &gothreatmatrix.WaitOptions{
+		PollInterval:    5 * time.Millisecond,
+		MaxPollInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, true, result.Complete)
+	// * the waits between the 5 polls back off: 5ms, 10ms, 20ms, then 20ms
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Errorf("Expected the polling to back off, waited %v", elapsed)
+	}
+}
+
+func TestJobServiceWaitForCompletionInvalidTransition(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	// * the job goes back to pending after it started running
+	jobPolls := []string{`{"id": 1, "status": "running"}`, `{"id": 1, "status": "pending"}`}
+	polls := 0
+	apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(jobPolls[polls%len(jobPolls)]))
+		polls++
+	})
+	_, err := client.JobService.WaitForCompletion(context.Background(), 1, &gothreatmatrix.WaitOptions{PollInterval: time.Millisecond})
+	transitionError, ok := err.(*gothreatmatrix.InvalidTransitionError)
+	if !ok {
+		t.Fatalf("Expected an InvalidTransitionError, got: %v", err)
+	}
+	testWantData(t, gothreatmatrix.InvalidTransitionError{JobID: 1, From: gothreatmatrix.RUNNING, To: gothreatmatrix.PENDING}, *transitionError)
+}
+
+func TestJobServiceWaitForCompletionHeartbeat(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	polls := 0
+	apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 4 {
+			w.Write([]byte(`{"id": 1, "status": "running", "analyzers_to_execute": ["Cuckoo_Scan"]}`))
+			return
+		}
+		w.Write([]byte(`{"id": 1, "status": "reported_without_fails"}`))
+	})
+	errAbort := errors.New("aborted by the caller")
+	testCases := map[string]struct {
+		heartbeat func(heartbeats *[]gothreatmatrix.Heartbeat) gothreatmatrix.HeartbeatFunc
+		// timeout is reached before the job completes, unless the heartbeat extends it
+		timeout   time.Duration
+		wantPolls int
+		wantErr   error
+	}{
+		"everyPoll": {
+			heartbeat: func(heartbeats *[]gothreatmatrix.Heartbeat) gothreatmatrix.HeartbeatFunc {
+				return func(heartbeat *gothreatmatrix.Heartbeat) error {
+					*heartbeats = append(*heartbeats, *heartbeat)
+					return nil
+				}
+			},
+			wantPolls: 4,
+		},
+		"abort": {
+			heartbeat: func(heartbeats *[]gothreatmatrix.Heartbeat) gothreatmatrix.HeartbeatFunc {
+				return func(heartbeat *gothreatmatrix.Heartbeat) error {
+					*heartbeats = append(*heartbeats, *heartbeat)
+					if heartbeat.Poll == 2 {
+						return errAbort
+					}
+					return nil
+				}
+			},
+			wantPolls: 2,
+			wantErr:   errAbort,
+		},
+		"extendTimeout": {
+			heartbeat: func(heartbeats *[]gothreatmatrix.Heartbeat) gothreatmatrix.HeartbeatFunc {
+				return func(heartbeat *gothreatmatrix.Heartbeat) error {
+					*heartbeats = append(*heartbeats, *heartbeat)
+					heartbeat.ExtendTimeout(time.Second)
+					return nil
+				}
+			},
+			timeout:   15 * time.Millisecond,
+			wantPolls: 4,
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			polls = 0
+			heartbeats := []gothreatmatrix.Heartbeat{}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			result, err := client.JobService.WaitForCompletion(ctx, 1, &gothreatmatrix.WaitOptions{
+				PollInterval:    10 * time.Millisecond,
+				AnalyzerTimeout: testCase.timeout,
+				Heartbeat:       testCase.heartbeat(&heartbeats),
+			})
+			if !errors.Is(err, testCase.wantErr) {
+				t.Fatalf("Expected %v, got: %v", testCase.wantErr, err)
+			}
+			testWantData(t, testCase.wantPolls, len(heartbeats))
+			for index, heartbeat := range heartbeats {
+				testWantData(t, index+1, heartbeat.Poll)
+				testWantData(t, uint64(1), heartbeat.JobID)
+				if heartbeat.Deadline.IsZero() {
+					t.Errorf("Expected the deadline of the context in the heartbeat")
+				}
+			}
+			testWantData(t, gothreatmatrix.RUNNING, heartbeats[0].Status)
+			testWantData(t, []string{"Cuckoo_Scan"}, heartbeats[0].PendingAnalyzers)
+			if testCase.wantErr == nil {
+				testWantData(t, true, result.Complete)
+				testWantData(t, gothreatmatrix.REPORTED_WITHOUT_FAILS, heartbeats[3].Status)
+			}
+		})
+	}
+}
+
+func TestJobServiceWatchManyHeartbeat(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 1, "status": "running"}`))
+	})
+	errAbort := errors.New("aborted by the caller")
+	watch := client.JobService.WatchMany(context.Background(), []uint64{1}, &gothreatmatrix.WaitOptions{
+		PollInterval: time.Millisecond,
+		Heartbeat: func(heartbeat *gothreatmatrix.Heartbeat) error {
+			if heartbeat.Poll == 3 {
+				return errAbort
+			}
+			return nil
+		},
+	})
+	for range watch.Updates() {
+	}
+	if err := watch.Wait(); !errors.Is(err, errAbort) {
+		t.Fatalf("Expected the heartbeat error, got: %v", err)
+	}
+}
+
+func TestJobServiceGetPartialReports(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["running"] = TestData{
+		Input:      uint64(1),
+		Data:       `{"id": 1, "status": "running", "analyzers_to_execute": ["Classic_DNS", "Cuckoo_Scan", "Shodan"], "analyzer_reports": [{"name": "Classic_DNS", "status": "SUCCESS", "report": {"resolutions": []}}, {"name": "Cuckoo_Scan", "status": "RUNNING"}, {"name": "Shodan", "status": "FAILED", "errors": ["no api key"]}]}`,
+		StatusCode: http.StatusOK,
+		Want: &gothreatmatrix.PartialReports{
+			Status: "running",
+			Reports: []gothreatmatrix.Report{
+				{Name: "Classic_DNS", Status: "SUCCESS", Report: map[string]interface{}{"resolutions": []interface{}{}}},
+				{Name: "Shodan", Status: "FAILED", Errors: []string{"no api key"}},
+			},
+			PendingAnalyzers: []string{"Cuckoo_Scan"},
+		},
+	}
+	testCases["notFound"] = TestData{
+		Input:      uint64(2),
+		Data:       `{"detail": "Not found."}`,
+		StatusCode: http.StatusNotFound,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusNotFound,
+			Message:    `{"detail": "Not found."}`,
+			Detail:     "Not found.",
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			jobId := testCase.Input.(uint64)
+			apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, jobId), serverHandler(t, testCase, "GET"))
+			partialReports, err := client.JobService.GetPartialReports(ctx, jobId)
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, partialReports)
+			}
+		})
+	}
+}
+
+func TestJobServiceExportJob(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 5), serverHandler(t, TestData{Data: `{"id": 5, "observable_name": "dns.google"}`}, "GET"))
+	exported := []string{}
+	sink := gothreatmatrix.JobSinkFunc(func(ctx context.Context, job *gothreatmatrix.Job) error {
+		exported = append(exported, job.ObservableName)
+		return nil
+	})
+	if err := client.JobService.ExportJob(context.Background(), 5, sink, sink); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"dns.google", "dns.google"}, exported)
+}
+
+func TestJobServiceWatchMany(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	polls := 0
+	apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 3 {
+			w.Write([]byte(`{"id": 1, "status": "running", "analyzers_to_execute": ["Classic_DNS"]}`))
+			return
+		}
+		w.Write([]byte(`{"id": 1, "status": "reported_without_fails", "analyzers_to_execute": ["Classic_DNS"], "analyzer_reports": [{"name": "Classic_DNS", "status": "SUCCESS"}]}`))
+	})
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 2), serverHandler(t, TestData{Data: `{"id": 2, "status": "reported_with_fails"}`}, "GET"))
+	watch := client.JobService.WatchMany(context.Background(), []uint64{1, 2}, &gothreatmatrix.WaitOptions{PollInterval: time.Millisecond})
+	updates := map[uint64][]string{}
+	for update := range watch.Updates() {
+		updates[update.JobID] = append(updates[update.JobID], fmt.Sprintf("%s %v", update.Job.Status, update.Done))
+	}
+	if err := watch.Wait(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, map[uint64][]string{
+		1: {"running false", "reported_without_fails true"},
+		2: {"reported_with_fails true"},
+	}, updates)
+}
+
+func TestJobServiceWatchManyProgressSnapshots(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, TestData{Data: `{"Cuckoo_Scan": {"name": "Cuckoo_Scan", "type": "file", "config": {"soft_time_limit": 1200}}}`}, "GET"))
+	polls := 0
+	apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 20 {
+			w.Write([]byte(`{"id": 1, "status": "running", "analyzers_to_execute": ["Cuckoo_Scan"]}`))
+			return
+		}
+		w.Write([]byte(`{"id": 1, "status": "reported_without_fails", "analyzers_to_execute": ["Cuckoo_Scan"], "analyzer_reports": [{"name": "Cuckoo_Scan", "status": "SUCCESS"}]}`))
+	})
+	watch := client.JobService.WatchMany(context.Background(), []uint64{1}, &gothreatmatrix.WaitOptions{PollInterval: time.Millisecond, ProgressSnapshots: true})
+	snapshots := 0
+	var last gothreatmatrix.JobUpdate
+	for update := range watch.Updates() {
+		if update.Snapshot {
+			snapshots++
+		}
+		last = update
+	}
+	if err := watch.Wait(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// * the job showed no change for 18 polls: snapshots after 1, 2, 4 and 8 polls at most, not one per poll
+	if snapshots == 0 || snapshots > 5 {
+		t.Fatalf("Expected a few exponentially spaced snapshots, got %d", snapshots)
+	}
+	testWantData(t, gothreatmatrix.JobProgress{AnalyzersCompleted: 1, AnalyzersTotal: 1, Expected: 20 * time.Minute, Fraction: 1}, last.Progress)
+}
+
+func TestJobServiceWatchManyFatalError(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), serverHandler(t, TestData{Data: `{"id": 1, "status": "running"}`}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 2), serverHandler(t, TestData{Data: `{"detail": "Not found."}`, StatusCode: http.StatusNotFound}, "GET"))
+	watch := client.JobService.WatchMany(context.Background(), []uint64{1, 2}, &gothreatmatrix.WaitOptions{PollInterval: time.Millisecond})
+	for range watch.Updates() {
+	}
+	err := watch.Wait()
+	threatMatrixError, ok := err.(*gothreatmatrix.ThreatMatrixError)
+	if !ok || threatMatrixError.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected the not found error, got: %v", err)
+	}
+}
+
+func TestJobServiceStreamSample(t *testing.T) {
+	sample := strings.Repeat("memory dump ", 100000)
+	doesNotHaveASampleResponseJsonString := `{"errors":{"detail":"Requested job does not have a sample associated with it."}}`
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      1,
+		Data:       sample,
+		StatusCode: http.StatusOK,
+		Want:       sample,
+	}
+	testCases["doesNotHaveASample"] = TestData{
+		Input:      2,
+		Data:       doesNotHaveASampleResponseJsonString,
+		StatusCode: http.StatusBadRequest,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusBadRequest,
+			Message:    doesNotHaveASampleResponseJsonString,
+			Errors:     map[string]interface{}{"detail": "Requested job does not have a sample associated with it."},
+		},
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			jobId := uint64(testCase.Input.(int))
+			apiHandler.Handle(fmt.Sprintf(constants.DOWNLOAD_SAMPLE_JOB_URL, jobId), serverHandler(t, testCase, "GET"))
+			stream, err := client.JobService.StreamSample(context.Background(), jobId)
+			if err != nil {
+				testError(t, testCase, err)
+				return
+			}
+			defer stream.Close()
+			gottenSample, err := io.ReadAll(stream)
+			if err != nil {
+				t.Fatalf("Could not read the stream: %v", err)
+			}
+			testWantData(t, testCase.Want, string(gottenSample))
+		})
+	}
+}
+
+func TestJobServiceStreamSampleSlowConsumer(t *testing.T) {
+	sample := strings.Repeat("memory dump ", 1000)
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{Timeout: 1})
+	defer closeServer()
+	apiHandler.Handle(fmt.Sprintf(constants.DOWNLOAD_SAMPLE_JOB_URL, 1), serverHandler(t, TestData{Data: sample}, "GET"))
+	stream, err := client.JobService.StreamSample(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer stream.Close()
+	head := make([]byte, 12)
+	if _, err := io.ReadFull(stream, head); err != nil {
+		t.Fatalf("Could not read the stream: %v", err)
+	}
+	// * the consumer reads the rest after the Timeout of the client
+	time.Sleep(1500 * time.Millisecond)
+	rest, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("Could not read the stream: %v", err)
+	}
+	testWantData(t, sample, string(head)+string(rest))
+}
+
+func TestJobServiceDownloadSampleToFile(t *testing.T) {
+	sample := strings.Repeat("memory dump ", 1000)
+	sampleHash := md5.Sum([]byte(sample))
+	testCases := map[string]struct {
+		partial   string
+		md5       string
+		wantRange string
+		wantErr   error
+	}{
+		"fresh": {
+			md5: hex.EncodeToString(sampleHash[:]),
+		},
+		"resumed": {
+			partial:   sample[:5000],
+			md5:       hex.EncodeToString(sampleHash[:]),
+			wantRange: "bytes=5000-",
+		},
+		"alreadyComplete": {
+			partial:   sample,
+			md5:       hex.EncodeToString(sampleHash[:]),
+			wantRange: fmt.Sprintf("bytes=%d-", len(sample)),
+		},
+		"checksumMismatch": {
+			partial:   "corrupted" + sample[9:5000],
+			md5:       hex.EncodeToString(sampleHash[:]),
+			wantRange: "bytes=5000-",
+			wantErr:   gothreatmatrix.ErrSampleChecksumMismatch,
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			gottenRange := ""
+			apiHandler.HandleFunc(fmt.Sprintf(constants.DOWNLOAD_SAMPLE_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+				gottenRange = r.Header.Get("Range")
+				http.ServeContent(w, r, "sample", time.Time{}, strings.NewReader(sample))
+			})
+			apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), serverHandler(t, TestData{Data: fmt.Sprintf(`{"id": 1, "md5": "%s"}`, testCase.md5)}, "GET"))
+			filePath := filepath.Join(t.TempDir(), "sample.bin")
+			if testCase.partial != "" {
+				if err := os.WriteFile(filePath+".part", []byte(testCase.partial), 0600); err != nil {
+					t.Fatalf("Could not write the partial download: %v", err)
+				}
+			}
+			err := client.JobService.DownloadSampleToFile(context.Background(), 1, filePath, &gothreatmatrix.DownloadOptions{BytesPerSecond: 1 << 20})
+			testWantData(t, testCase.wantRange, gottenRange)
+			if testCase.wantErr != nil {
+				if !errors.Is(err, testCase.wantErr) {
+					t.Fatalf("Expected %v, got: %v", testCase.wantErr, err)
+				}
+				if _, statError := os.Stat(filePath + ".part"); !os.IsNotExist(statError) {
+					t.Fatalf("The corrupted download was kept")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			downloaded, _ := os.ReadFile(filePath)
+			testWantData(t, sample, string(downloaded))
+		})
+	}
+}
+
+func TestJobServiceDownloadSampleToFileSlow(t *testing.T) {
+	sample := strings.Repeat("memory dump ", 1000)
+	sampleHash := md5.Sum([]byte(sample))
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{Timeout: 1})
+	defer closeServer()
+	apiHandler.HandleFunc(fmt.Sprintf(constants.DOWNLOAD_SAMPLE_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		// * the body takes longer than the Timeout of the client to be sent
+		for index := 0; index < 3; index++ {
+			w.Write([]byte(sample[index*len(sample)/3 : (index+1)*len(sample)/3]))
+			w.(http.Flusher).Flush()
+			select {
+			case <-time.After(500 * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), serverHandler(t, TestData{Data: fmt.Sprintf(`{"id": 1, "md5": "%s"}`, hex.EncodeToString(sampleHash[:]))}, "GET"))
+	filePath := filepath.Join(t.TempDir(), "sample.bin")
+	if err := client.JobService.DownloadSampleToFile(context.Background(), 1, filePath, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	downloaded, _ := os.ReadFile(filePath)
+	testWantData(t, sample, string(downloaded))
+
+	// * the download is still bounded by the context of the caller
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := client.JobService.DownloadSampleToFile(ctx, 1, filepath.Join(t.TempDir(), "canceled.bin"), nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %v, got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestJobServiceRetryAnalyzerOrRescan(t *testing.T) {
+	testCases := map[string]struct {
+		retryStatus int
+		jobJson     string
+		want        *gothreatmatrix.AnalyzerRetry
+	}{
+		"supported": {
+			retryStatus: http.StatusNoContent,
+			want:        &gothreatmatrix.AnalyzerRetry{JobID: 1, AnalyzerName: "Classic_DNS"},
+		},
+		"emulatedObservable": {
+			retryStatus: http.StatusNotFound,
+			jobJson:     `{"id": 1, "observable_name": "dns.google", "observable_classification": "domain", "tlp": "AMBER", "tags": [{"id": 1, "label": "phishing"}]}`,
+			want:        &gothreatmatrix.AnalyzerRetry{JobID: 1, AnalyzerName: "Classic_DNS", Emulated: true, RescanJobID: 2},
+		},
+		"emulatedSample": {
+			retryStatus: http.StatusMethodNotAllowed,
+			jobJson:     `{"id": 1, "is_sample": true, "file_name": "sample.exe", "tlp": "AMBER"}`,
+			want:        &gothreatmatrix.AnalyzerRetry{JobID: 1, AnalyzerName: "Classic_DNS", Emulated: true, RescanJobID: 2},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.HandleFunc(fmt.Sprintf(constants.RETRY_ANALYZER_JOB_URL, 1, "Classic_DNS"), func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "PATCH")
+				w.WriteHeader(testCase.retryStatus)
+			})
+			apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), serverHandler(t, TestData{Data: testCase.jobJson}, "GET"))
+			apiHandler.Handle(fmt.Sprintf(constants.DOWNLOAD_SAMPLE_JOB_URL, 1), serverHandler(t, TestData{Data: "This is the sample"}, "GET"))
+			apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+				params := gothreatmatrix.ObservableAnalysisParams{}
+				if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+					t.Errorf("Could not parse request body: %v", err)
+				}
+				testWantData(t, []string{"Classic_DNS"}, params.AnalyzersRequested)
+				testWantData(t, []string{"phishing"}, params.TagsLabels)
+				testWantData(t, gothreatmatrix.AMBER, params.Tlp)
+				w.Write([]byte(`{"job_id": 2, "status": "accepted"}`))
+			})
+			apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					t.Errorf("Could not parse the form: %v", err)
+				}
+				testWantData(t, []string{"Classic_DNS"}, r.MultipartForm.Value["analyzers_requested"])
+				testWantData(t, "sample.exe", r.MultipartForm.File["file"][0].Filename)
+				w.Write([]byte(`{"job_id": 2, "status": "accepted"}`))
+			})
+			retry, err := client.JobService.RetryAnalyzerOrRescan(context.Background(), 1, "Classic_DNS")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.want, retry)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestJobStatus(t *testing.T) {
+	type statusTraits struct {
+		Terminal bool
+		CanKill  bool
+	}
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["pending"] = TestData{
+		Input: gothreatmatrix.PENDING,
+		Want:  statusTraits{CanKill: true},
+	}
+	testCases["phase"] = TestData{
+		Input: gothreatmatrix.CONNECTORS_RUNNING,
+		Want:  statusTraits{CanKill: true},
+	}
+	testCases["reported"] = TestData{
+		Input: gothreatmatrix.REPORTED_WITH_FAILS,
+		Want:  statusTraits{Terminal: true},
+	}
+	testCases["killed"] = TestData{
+		Input: gothreatmatrix.KILLED,
+		Want:  statusTraits{Terminal: true},
+	}
+	testCases["unknown"] = TestData{
+		Input: gothreatmatrix.JobStatus("plugins_warming_up"),
+		Want:  statusTraits{CanKill: true},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			status := testCase.Input.(gothreatmatrix.JobStatus)
+			testWantData(t, testCase.Want, statusTraits{Terminal: status.IsTerminal(), CanKill: status.CanKill()})
+		})
+	}
+}
+
+func TestJobStatusCanTransitionTo(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["start"] = TestData{
+		Input: []gothreatmatrix.JobStatus{gothreatmatrix.PENDING, gothreatmatrix.RUNNING},
+		Want:  true,
+	}
+	testCases["pendingToReported"] = TestData{
+		Input: []gothreatmatrix.JobStatus{gothreatmatrix.PENDING, gothreatmatrix.REPORTED_WITHOUT_FAILS},
+		Want:  true,
+	}
+	testCases["nextPhase"] = TestData{
+		Input: []gothreatmatrix.JobStatus{gothreatmatrix.ANALYZERS_COMPLETED, gothreatmatrix.CONNECTORS_RUNNING},
+		Want:  true,
+	}
+	testCases["backToPending"] = TestData{
+		Input: []gothreatmatrix.JobStatus{gothreatmatrix.RUNNING, gothreatmatrix.PENDING},
+		Want:  false,
+	}
+	testCases["retry"] = TestData{
+		Input: []gothreatmatrix.JobStatus{gothreatmatrix.REPORTED_WITH_FAILS, gothreatmatrix.RUNNING},
+		Want:  true,
+	}
+	testCases["terminalToTerminal"] = TestData{
+		Input: []gothreatmatrix.JobStatus{gothreatmatrix.KILLED, gothreatmatrix.REPORTED_WITHOUT_FAILS},
+		Want:  false,
+	}
+	testCases["terminalToPhase"] = TestData{
+		Input: []gothreatmatrix.JobStatus{gothreatmatrix.FAILED, gothreatmatrix.ANALYZERS_RUNNING},
+		Want:  false,
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			statuses := testCase.Input.([]gothreatmatrix.JobStatus)
+			testWantData(t, testCase.Want, statuses[0].CanTransitionTo(statuses[1]))
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// Helper test
+// Accepting the websocket handshake of the request and returning the hijacked connection
+func acceptWebsocket(t *testing.T, w http.ResponseWriter, r *http.Request) (net.Conn, *bufio.ReadWriter) {
+	t.Helper()
+	if r.Header.Get("Upgrade") != "websocket" || r.Header.Get("Authorization") != "token test-token" {
+		t.Errorf("Unexpected handshake headers: %v", r.Header)
+	}
+	acceptSum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	conn, buffer, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(buffer, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(acceptSum[:]))
+	buffer.Flush()
+	return conn, buffer
+}
+
+// Helper test
+// Writing the job as an unmasked text frame, the way a server does
+func writeJobFrame(t *testing.T, buffer *bufio.ReadWriter, job string) {
+	t.Helper()
+	frame := []byte{0x81}
+	if len(job) < 126 {
+		frame = append(frame, byte(len(job)))
+	} else {
+		frame = append(frame, 126, byte(len(job)>>8), byte(len(job)))
+	}
+	buffer.Write(append(frame, job...))
+	buffer.Flush()
+}
+
+func TestJobServiceWatch(t *testing.T) {
+	running := `{"id": 1, "status": "running", "analyzer_reports": [{"name": "Classic_DNS", "type": "analyzer", "status": "PENDING"}, {"name": "Shodan_Search", "type": "analyzer", "status": "SUCCESS"}]}`
+	analyzed := `{"id": 1, "status": "running", "analyzer_reports": [{"name": "Classic_DNS", "type": "analyzer", "status": "SUCCESS"}, {"name": "Shodan_Search", "type": "analyzer", "status": "SUCCESS"}]}`
+	reported := `{"id": 1, "status": "reported_without_fails", "analyzer_reports": [{"name": "Classic_DNS", "type": "analyzer", "status": "SUCCESS"}, {"name": "Shodan_Search", "type": "analyzer", "status": "SUCCESS"}]}`
+	testCases := map[string]struct {
+		// frames are the jobs sent on every connection, the connection being dropped after them
+		frames [][]string
+		want   []string
+	}{
+		"single connection": {
+			frames: [][]string{{running, `{"type": "keepalive"}`, analyzed, reported}},
+			want:   []string{"report_completed Shodan_Search", "report_completed Classic_DNS", "job_completed"},
+		},
+		"reconnection": {
+			frames: [][]string{{running}, {reported}},
+			want:   []string{"report_completed Shodan_Search", "report_completed Classic_DNS", "job_completed"},
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			connections := 0
+			apiHandler.HandleFunc(fmt.Sprintf(constants.JOB_WEBSOCKET_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+				if connections >= len(testCase.frames) {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				frames := testCase.frames[connections]
+				connections++
+				conn, buffer := acceptWebsocket(t, w, r)
+				defer conn.Close()
+				for _, frame := range frames {
+					writeJobFrame(t, buffer, frame)
+				}
+			})
+			// * the job fetched after a reconnection, all caught up
+			apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(reported))
+			})
+			events, err := client.JobService.Watch(context.Background(), 1)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := []string{}
+			for event := range events {
+				if event.Report != nil {
+					got = append(got, event.Event+" "+event.Report.Name)
+				} else {
+					got = append(got, event.Event)
+				}
+			}
+			testWantData(t, testCase.want, got)
+		})
+	}
+}
+
+func TestJobServiceWatchUnavailable(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(fmt.Sprintf(constants.JOB_WEBSOCKET_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"detail": "Not found."})
+	})
+	_, err := client.JobService.Watch(context.Background(), 1)
+	if !gothreatmatrix.IsNotFound(err) {
+		t.Fatalf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestJobServiceWatchConnectionBudget(t *testing.T) {
+	running := `{"id": 1, "status": "running", "analyzer_reports": [{"name": "Classic_DNS", "type": "analyzer", "status": "SUCCESS"}]}`
+	reported := `{"id": 1, "status": "reported_without_fails", "analyzer_reports": [{"name": "Classic_DNS", "type": "analyzer", "status": "SUCCESS"}]}`
+	budget := gothreatmatrix.NewConnectionBudget(2)
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{ConnectionBudget: budget})
+	defer closeServer()
+	watched := 0
+	client.Use(func(next gothreatmatrix.RoundTripFunc) gothreatmatrix.RoundTripFunc {
+		return func(request *http.Request) (*http.Response, error) {
+			if request.Header.Get("Upgrade") == "websocket" {
+				watched++
+			}
+			return next(request)
+		}
+	})
+	finish := make(chan struct{})
+	apiHandler.HandleFunc(fmt.Sprintf(constants.JOB_WEBSOCKET_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+		conn, buffer := acceptWebsocket(t, w, r)
+		defer conn.Close()
+		writeJobFrame(t, buffer, running)
+		<-finish
+		writeJobFrame(t, buffer, reported)
+	})
+	events, err := client.JobService.Watch(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	<-events
+	// * the open websocket holds its slot of the budget
+	testWantData(t, 1, budget.InUse())
+	testWantData(t, 1, watched)
+	close(finish)
+	for range events {
+	}
+	testWantData(t, 0, budget.InUse())
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/khulnasoft/go-threatmatrix/metrics"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	collector := metrics.NewCollector(&metrics.CollectorOptions{Buckets: []float64{1, 0.5}})
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		EnableTelemetry: true,
+		Telemetry:       collector,
+		RetryOptions:    &gothreatmatrix.RetryOptions{MaxRetries: 1, BaseDelay: time.Millisecond},
+	})
+	defer closeServer()
+	tagCalls := 0
+	apiHandler.HandleFunc(constants.BASE_TAG_URL+"/1", func(w http.ResponseWriter, r *http.Request) {
+		tagCalls++
+		if tagCalls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id": 1, "label": "phishing", "color": "#1c71d8"}`))
+	})
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"job_id": 1, "status": "accepted"}`))
+	})
+	apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"detail": "invalid file"}`))
+	})
+	ctx := context.Background()
+	if _, err := client.TagService.Get(ctx, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{ObservableName: "8.8.8.8", ObservableClassification: "ip"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.CreateFileAnalysis(ctx, &gothreatmatrix.FileAnalysisParams{File: openTestFile(t, "sample.txt", []byte("sample"))}); err == nil {
+		t.Fatalf("Expected the file submission to fail")
+	}
+
+	exposition := &bytes.Buffer{}
+	if err := collector.Write(exposition); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, line := range []string{
+		"# TYPE threatmatrix_requests_total counter",
+		`threatmatrix_requests_total{method="GET",route="/api/tags/{id}",status="200"} 1`,
+		`threatmatrix_requests_total{method="POST",route="/api/analyze_file",status="400"} 1`,
+		"# TYPE threatmatrix_request_duration_seconds histogram",
+		`threatmatrix_request_duration_seconds_bucket{method="GET",route="/api/tags/{id}",le="0.5"} 1`,
+		`threatmatrix_request_duration_seconds_bucket{method="GET",route="/api/tags/{id}",le="+Inf"} 1`,
+		`threatmatrix_request_duration_seconds_count{method="GET",route="/api/tags/{id}"} 1`,
+		`threatmatrix_jobs_submitted_total{route="/api/analyze_observable"} 1`,
+		`threatmatrix_jobs_failed_total{route="/api/analyze_file"} 1`,
+		`threatmatrix_retries_total{method="GET",route="/api/tags/{id}"} 1`,
+	} {
+		if !strings.Contains(exposition.String(), line+"\n") {
+			t.Errorf("Expected the metrics to contain %q, got:\n%s", line, exposition.String())
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestMiddleware(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc("/api/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		testWantData(t, "soc-eu", r.Header.Get("X-Tenant"))
+		w.Write([]byte(`{"id": 1}`))
+	})
+	calls := []string{}
+	recorder := func(name string) gothreatmatrix.Middleware {
+		return func(next gothreatmatrix.RoundTripFunc) gothreatmatrix.RoundTripFunc {
+			return func(request *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" before")
+				if name == "outer" {
+					request.Header.Set("X-Tenant", "soc-eu")
+				}
+				response, err := next(request)
+				if err == nil {
+					calls = append(calls, name+" after "+http.StatusText(response.StatusCode))
+				}
+				return response, err
+			}
+		}
+	}
+	client.Use(recorder("outer"), recorder("inner"))
+	if _, err := client.JobService.Get(context.Background(), 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"outer before", "inner before", "inner after OK", "outer after OK"}, calls)
+}
+
+func TestTransportOption(t *testing.T) {
+	routed := 0
+	transport := gothreatmatrix.RoundTripFunc(func(request *http.Request) (*http.Response, error) {
+		routed++
+		return http.DefaultTransport.RoundTrip(request)
+	})
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{Transport: transport})
+	defer closeServer()
+	apiHandler.Handle("/api/jobs/1", serverHandler(t, TestData{Data: `{"id": 1}`}, "GET"))
+	if _, err := client.JobService.Get(context.Background(), 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 1, routed)
+}
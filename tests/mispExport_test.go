@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/export/misp"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestMispNewEvent(t *testing.T) {
+	job := stixObservableJob()
+	event := misp.NewEvent(job, &misp.Options{Verdict: gothreatmatrix.MALICIOUS, Distribution: misp.DISTRIBUTION_COMMUNITY})
+	testWantData(t, event, misp.NewEvent(job, &misp.Options{Verdict: gothreatmatrix.MALICIOUS, Distribution: misp.DISTRIBUTION_COMMUNITY}))
+	testWantData(t, "ThreatMatrix job 7: evil.example.com", event.Info)
+	testWantData(t, "2024-03-01", event.Date)
+	testWantData(t, misp.THREAT_LEVEL_HIGH, event.ThreatLevelId)
+	testWantData(t, misp.ANALYSIS_COMPLETED, event.Analysis)
+	testWantData(t, []misp.Tag{
+		{Name: "tlp:amber"},
+		{Name: "phishing"},
+		{Name: `malware_classification:malware-family="emotet"`},
+	}, event.Tags)
+
+	values := map[string]misp.Attribute{}
+	for _, attribute := range event.Attributes {
+		values[attribute.Type+"|"+attribute.Value] = attribute
+	}
+	testWantData(t, 2, len(values))
+	if subject, ok := values["domain|evil.example.com"]; !ok || !subject.ToIds || subject.Category != misp.CATEGORY_NETWORK_ACTIVITY {
+		t.Errorf("Expected the analyzed domain flagged for the IDS, got %+v", event.Attributes)
+	}
+	if artifact, ok := values["ip-dst|8.8.8.8"]; !ok || artifact.ToIds || artifact.Comment != "found by Classic_DNS" {
+		t.Errorf("Expected the resolved IP as an artifact, got %+v", event.Attributes)
+	}
+
+	document, err := event.Document()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	decoded := map[string]map[string]interface{}{}
+	if err := json.Unmarshal(document, &decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, "1", decoded["Event"]["threat_level_id"])
+	testWantData(t, "1", decoded["Event"]["distribution"])
+}
+
+func TestMispNewEventFile(t *testing.T) {
+	job := &gothreatmatrix.Job{
+		AnalyzerReports: []gothreatmatrix.Report{
+			{Name: "File_Info", Status: "SUCCESS", Report: map[string]interface{}{"sha256": "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855"}},
+		},
+	}
+	received := time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC)
+	job.ID = 8
+	job.IsSample = true
+	job.FileName = "invoice.pdf"
+	job.Md5 = "D41D8CD98F00B204E9800998ECF8427E"
+	job.ReceivedRequestTime = &received
+	event := misp.NewEvent(job, nil)
+	testWantData(t, misp.THREAT_LEVEL_UNDEFINED, event.ThreatLevelId)
+	testWantData(t, misp.ANALYSIS_ONGOING, event.Analysis)
+	types := []string{}
+	for _, attribute := range event.Attributes {
+		types = append(types, attribute.Type+"|"+attribute.Value)
+		if attribute.ToIds {
+			t.Errorf("Expected no attribute flagged for the IDS without a verdict, got %+v", attribute)
+		}
+	}
+	testWantData(t, []string{
+		"filename|invoice.pdf",
+		"md5|d41d8cd98f00b204e9800998ecf8427e",
+		"sha256|e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}, types)
+}
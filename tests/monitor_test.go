@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestMonitorPluginHealth(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	var mutex sync.Mutex
+	checks := 0
+	// * the analyzer goes down on the third check and never comes back
+	apiHandler.HandleFunc(fmt.Sprintf(constants.ANALYZER_HEALTHCHECK_URL, "Yara"), func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		mutex.Lock()
+		checks++
+		healthy := checks < 3
+		mutex.Unlock()
+		w.Write([]byte(fmt.Sprintf(`{"status": %t}`, healthy)))
+	})
+	apiHandler.Handle(fmt.Sprintf(constants.CONNECTOR_HEALTHCHECK_URL, "MISP"), serverHandler(t, TestData{Data: `{"detail": "Not found."}`, StatusCode: http.StatusNotFound}, "GET"))
+	monitor := client.MonitorPluginHealth(context.Background(), &gothreatmatrix.MonitorOptions{
+		Analyzers:   []string{"Yara"},
+		Connectors:  []string{"MISP"},
+		MinInterval: time.Millisecond,
+		MaxInterval: 4 * time.Millisecond,
+	})
+	type healthState struct {
+		Type    string
+		Name    string
+		Healthy bool
+		Failed  bool
+	}
+	states := []healthState{}
+	for health := range monitor.Updates() {
+		states = append(states, healthState{Type: health.Type, Name: health.Name, Healthy: health.Healthy, Failed: health.Err != nil})
+		if len(states) == 3 {
+			monitor.Stop()
+		}
+	}
+	testWantData(t, []healthState{
+		{Type: gothreatmatrix.ANALYZER_PLUGIN, Name: "Yara", Healthy: true},
+		{Type: gothreatmatrix.CONNECTOR_PLUGIN, Name: "MISP", Failed: true},
+		{Type: gothreatmatrix.ANALYZER_PLUGIN, Name: "Yara"},
+	}, states)
+	if err := monitor.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the monitor to be canceled, got: %v", err)
+	}
+}
+
+func TestMonitorPluginHealthDefaultPlugins(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, TestData{Data: `{
+		"Yara": {"name": "Yara", "type": "file", "docker_based": true},
+		"Classic_DNS": {"name": "Classic_DNS", "type": "observable"}
+	}`}, "GET"))
+	apiHandler.Handle(constants.CONNECTOR_CONFIG_URL, serverHandler(t, TestData{Data: `{"MISP": {"name": "MISP"}, "OpenCTI": {"name": "OpenCTI", "disabled": true}}`}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.ANALYZER_HEALTHCHECK_URL, "Yara"), serverHandler(t, TestData{Data: `{"status": true}`}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.CONNECTOR_HEALTHCHECK_URL, "MISP"), serverHandler(t, TestData{Data: `{"status": true}`}, "GET"))
+	monitor := client.MonitorPluginHealth(context.Background(), &gothreatmatrix.MonitorOptions{MinInterval: time.Millisecond})
+	names := []string{}
+	for health := range monitor.Updates() {
+		names = append(names, health.Name)
+		if len(names) == 2 {
+			monitor.Stop()
+		}
+	}
+	testWantData(t, []string{"Yara", "MISP"}, names)
+}
+
+func TestMonitorPluginHealthFailureBackoff(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	var mutex sync.Mutex
+	checks := 0
+	apiHandler.HandleFunc(fmt.Sprintf(constants.ANALYZER_HEALTHCHECK_URL, "Yara"), func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		checks++
+		mutex.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"detail": "the container is down"}`))
+	})
+	monitor := client.MonitorPluginHealth(context.Background(), &gothreatmatrix.MonitorOptions{
+		Analyzers:          []string{"Yara"},
+		MinInterval:        time.Millisecond,
+		FailureMaxInterval: 8 * time.Millisecond,
+	})
+	go func() {
+		time.Sleep(60 * time.Millisecond)
+		monitor.Stop()
+	}()
+	updates := 0
+	for range monitor.Updates() {
+		updates++
+	}
+	monitor.Wait()
+	// * checks at 0, 1, 3, 7, 15, 23... ms instead of every millisecond, and the failure is reported once
+	mutex.Lock()
+	defer mutex.Unlock()
+	if checks < 3 || checks > 15 {
+		t.Fatalf("Expected the failing analyzer to be checked with backoff, got %d checks", checks)
+	}
+	testWantData(t, 1, updates)
+	health, ok := monitor.Health(gothreatmatrix.ANALYZER_PLUGIN, "Yara")
+	if !ok || health.Healthy || health.Err == nil {
+		t.Fatalf("Expected the cached failure, got: %+v", health)
+	}
+}
@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/sirupsen/logrus"
+)
+
+// countingHandler answers with the status code and data, counting the requests.
+func countingHandler(statusCode int, data string, requests *int, mutex *sync.Mutex) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		*requests++
+		mutex.Unlock()
+		w.WriteHeader(statusCode)
+		w.Write([]byte(data))
+	}
+}
+
+func TestMultiClientFailover(t *testing.T) {
+	analysisJson := `{"job_id": 7, "status": "accepted"}`
+	testCases := map[string]struct {
+		primaryStatusCode int
+		write             bool
+		wantPrimary       int
+		wantSecondary     int
+		wantErr           bool
+		wantHealthy       bool
+	}{
+		"readHealthy":         {http.StatusOK, false, 1, 0, false, true},
+		"readUnavailable":     {http.StatusServiceUnavailable, false, 1, 1, false, false},
+		"readGatewayTimeout":  {http.StatusGatewayTimeout, false, 1, 1, false, false},
+		"readNotFound":        {http.StatusNotFound, false, 1, 0, true, true},
+		"writeUnavailable":    {http.StatusServiceUnavailable, true, 1, 1, false, false},
+		"writeGatewayTimeout": {http.StatusGatewayTimeout, true, 1, 0, true, false},
+		"writeServerError":    {http.StatusInternalServerError, true, 1, 0, true, true},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			var mutex sync.Mutex
+			primaryRequests, secondaryRequests := 0, 0
+			primaryHandler, secondaryHandler := http.NewServeMux(), http.NewServeMux()
+			primaryHandler.Handle(constants.BASE_TAG_URL, countingHandler(testCase.primaryStatusCode, `[]`, &primaryRequests, &mutex))
+			primaryHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, countingHandler(testCase.primaryStatusCode, analysisJson, &primaryRequests, &mutex))
+			secondaryHandler.Handle(constants.BASE_TAG_URL, countingHandler(http.StatusOK, `[]`, &secondaryRequests, &mutex))
+			secondaryHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, countingHandler(http.StatusOK, analysisJson, &secondaryRequests, &mutex))
+			primary, secondary := httptest.NewServer(primaryHandler), httptest.NewServer(secondaryHandler)
+			defer primary.Close()
+			defer secondary.Close()
+			multiClient, err := gothreatmatrix.NewMultiClient(&gothreatmatrix.MultiClientOptions{
+				Urls: []string{primary.URL, secondary.URL},
+			}, &gothreatmatrix.ThreatMatrixClientOptions{Token: "test-token"}, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			ctx := context.Background()
+			if testCase.write {
+				_, err = multiClient.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+					ObservableName:           "dns.google",
+					ObservableClassification: "domain",
+				})
+			} else {
+				err = multiClient.Read(ctx, func(client *gothreatmatrix.ThreatMatrixClient) error {
+					_, err := client.TagService.List(ctx)
+					return err
+				})
+			}
+			testWantData(t, testCase.wantErr, err != nil)
+			testWantData(t, testCase.wantPrimary, primaryRequests)
+			testWantData(t, testCase.wantSecondary, secondaryRequests)
+			testWantData(t, testCase.wantHealthy, multiClient.Health()[0].Healthy)
+		})
+	}
+}
+
+func TestMultiClientRouting(t *testing.T) {
+	var mutex sync.Mutex
+	primaryRequests, secondaryRequests := 0, 0
+	primaryHandler, secondaryHandler := http.NewServeMux(), http.NewServeMux()
+	secondaryHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, countingHandler(http.StatusOK, `{"job_id": 7, "status": "accepted"}`, &secondaryRequests, &mutex))
+	secondaryHandler.Handle("/api/jobs/7", countingHandler(http.StatusOK, `{"id": 7, "status": "running"}`, &secondaryRequests, &mutex))
+	primaryHandler.Handle("/api/jobs/7", countingHandler(http.StatusOK, `{"id": 7, "status": "reported_without_fails"}`, &primaryRequests, &mutex))
+	primary, secondary := httptest.NewServer(primaryHandler), httptest.NewServer(secondaryHandler)
+	defer secondary.Close()
+	// * the primary is unreachable while the job is created
+	primaryUrl := primary.URL
+	primary.Close()
+	multiClient, err := gothreatmatrix.NewMultiClient(&gothreatmatrix.MultiClientOptions{
+		Urls:       []string{primaryUrl, secondary.URL},
+		StickyJobs: true,
+	}, &gothreatmatrix.ThreatMatrixClientOptions{Token: "test-token"}, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx := context.Background()
+	analysisResponse, err := multiClient.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+		ObservableName:           "dns.google",
+		ObservableClassification: "domain",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, false, multiClient.Health()[0].Healthy)
+	testWantData(t, true, multiClient.Health()[1].Healthy)
+	var job *gothreatmatrix.Job
+	err = multiClient.ForJob(ctx, analysisResponse.JobID, func(client *gothreatmatrix.ThreatMatrixClient) error {
+		job, err = client.JobService.Get(ctx, uint64(analysisResponse.JobID))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, "running", job.Status)
+	testWantData(t, 2, secondaryRequests)
+	testWantData(t, 0, primaryRequests)
+
+	if _, err := gothreatmatrix.NewMultiClient(&gothreatmatrix.MultiClientOptions{}, &gothreatmatrix.ThreatMatrixClientOptions{}, nil, nil); !errors.Is(err, gothreatmatrix.ErrNoInstances) {
+		t.Errorf("Expected ErrNoInstances, got: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestNotificationServiceListUnread(t *testing.T) {
+	createdAt := time.Date(2024, 5, 2, 9, 30, 0, 0, time.UTC)
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["page"] = TestData{
+		Data:       `{"count": 1, "total_pages": 1, "results": [{"id": 4, "title": "Upgrade", "description": "<p>v6.0 is out</p>", "read": false, "created_at": "2024-05-02T09:30:00Z"}]}`,
+		StatusCode: http.StatusOK,
+		Want: []gothreatmatrix.Notification{
+			{ID: 4, Title: "Upgrade", Description: "<p>v6.0 is out</p>", CreatedAt: &createdAt},
+		},
+	}
+	testCases["readFilterIgnored"] = TestData{
+		Data:       `[{"id": 4, "title": "Upgrade", "read": false, "created_at": "2024-05-02T09:30:00Z"}, {"id": 2, "title": "Maintenance", "read": true}]`,
+		StatusCode: http.StatusOK,
+		Want: []gothreatmatrix.Notification{
+			{ID: 4, Title: "Upgrade", CreatedAt: &createdAt},
+		},
+	}
+	testCases["unauthorized"] = TestData{
+		Data:       `{"detail": "Invalid token."}`,
+		StatusCode: http.StatusUnauthorized,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusUnauthorized,
+			Message:    `{"detail": "Invalid token."}`,
+			Detail:     "Invalid token.",
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.Handle(constants.BASE_NOTIFICATION_URL, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				testWantData(t, "false", r.URL.Query().Get("read"))
+				serverHandler(t, testCase, "GET").ServeHTTP(w, r)
+			}))
+			notifications, err := client.NotificationService.ListUnread(context.Background())
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, notifications)
+			}
+		})
+	}
+}
+
+func TestNotificationServiceMarkAsRead(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      4,
+		StatusCode: http.StatusNoContent,
+		Want:       true,
+	}
+	testCases["notFound"] = TestData{
+		Input:      9,
+		Data:       `{"detail": "Not found."}`,
+		StatusCode: http.StatusNotFound,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusNotFound,
+			Message:    `{"detail": "Not found."}`,
+			Detail:     "Not found.",
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			notificationId := testCase.Input.(int)
+			apiHandler.Handle(fmt.Sprintf(constants.MARK_NOTIFICATION_AS_READ_URL, notificationId), serverHandler(t, testCase, "POST"))
+			marked, err := client.NotificationService.MarkAsRead(context.Background(), notificationId)
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, marked)
+			}
+		})
+	}
+}
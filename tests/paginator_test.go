@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// pagedJobsHandler serves totalJobs jobs page by page, recording the queries it received.
+func pagedJobsHandler(t *testing.T, totalJobs int, queries *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		*queries = append(*queries, r.URL.RawQuery)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+		totalPages := (totalJobs + pageSize - 1) / pageSize
+		results := ""
+		for id := (page-1)*pageSize + 1; id <= page*pageSize && id <= totalJobs; id++ {
+			if results != "" {
+				results += ","
+			}
+			results += fmt.Sprintf(`{"id": %d}`, id)
+		}
+		fmt.Fprintf(w, `{"count": %d, "total_pages": %d, "results": [%s]}`, totalJobs, totalPages, results)
+	}
+}
+
+func TestPaginatorNext(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	queries := []string{}
+	apiHandler.Handle(constants.BASE_JOB_URL, pagedJobsHandler(t, 5, &queries))
+	paginator := client.JobService.Paginate(gothreatmatrix.NewJobFilter().Status(gothreatmatrix.RUNNING), 2)
+	pages := [][]int{}
+	for paginator.More() {
+		jobs, err := paginator.Next(context.Background())
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		ids := []int{}
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+		}
+		pages = append(pages, ids)
+	}
+	testWantData(t, [][]int{{1, 2}, {3, 4}, {5}}, pages)
+	testWantData(t, gothreatmatrix.PageInfo{Page: 3, PageSize: 2, Count: 5, TotalPages: 3}, paginator.PageInfo())
+	testWantData(t, []string{"page=1&page_size=2&status=running", "page=2&page_size=2&status=running", "page=3&page_size=2&status=running"}, queries)
+	if _, err := paginator.Next(context.Background()); !errors.Is(err, gothreatmatrix.ErrNoMorePages) {
+		t.Errorf("Expected ErrNoMorePages, got %v", err)
+	}
+}
+
+func TestPaginatorAll(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["severalPages"] = TestData{Input: 7, Want: 7}
+	testCases["empty"] = TestData{Input: 0, Want: 0}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			queries := []string{}
+			apiHandler.Handle(constants.BASE_JOB_URL, pagedJobsHandler(t, testCase.Input.(int), &queries))
+			jobs, err := client.JobService.Paginate(nil, 3).All(context.Background())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.Want, len(jobs))
+		})
+	}
+}
+
+func TestPaginatorError(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	failed := false
+	apiHandler.Handle(constants.BASE_INVESTIGATION_URL, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" && !failed {
+			failed = true
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"count": 2, "total_pages": 2, "results": [{"id": %s, "name": "case"}]}`, r.URL.Query().Get("page"))
+	}))
+	paginator := client.InvestigationService.Paginate(1)
+	investigations, err := paginator.All(context.Background())
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	testWantData(t, 1, len(investigations))
+	// * the failed page is fetched again
+	investigations, err = paginator.All(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 1, len(investigations))
+	testWantData(t, 2, paginator.PageInfo().Page)
+}
+
+func TestPaginatorPlainList(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.BASE_TAG_URL, serverHandler(t, TestData{Data: `[{"id": 1, "label": "phishing", "color": "#ff0000"}]`}, "GET"))
+	paginator := client.TagService.Paginate(0)
+	tags, err := paginator.All(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []gothreatmatrix.Tag{{ID: 1, Label: "phishing", Color: "#ff0000"}}, tags)
+	testWantData(t, gothreatmatrix.PageInfo{Page: 1, Count: 1, TotalPages: 1}, paginator.PageInfo())
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestPIIGuardScan(t *testing.T) {
+	guard := gothreatmatrix.PIIGuard{
+		InternalDomains: []string{"acme.com"},
+	}
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["employeeEmail"] = TestData{
+		Input: "alice.smith@acme.com",
+		Want: []gothreatmatrix.PIIFinding{
+			{Kind: gothreatmatrix.PII_EMAIL, Value: "alice.smith@acme.com", Match: "alice.smith@acme.com"},
+		},
+	}
+	testCases["externalEmail"] = TestData{
+		Input: "phisher@evil.example",
+		Want:  []gothreatmatrix.PIIFinding{},
+	}
+	testCases["internalHostname"] = TestData{
+		Input: "fileserver01.corp",
+		Want: []gothreatmatrix.PIIFinding{
+			{Kind: gothreatmatrix.PII_INTERNAL_HOSTNAME, Value: "fileserver01.corp", Match: "fileserver01.corp"},
+		},
+	}
+	testCases["internalDomainHostname"] = TestData{
+		Input: "vpn.eu.acme.com",
+		Want: []gothreatmatrix.PIIFinding{
+			{Kind: gothreatmatrix.PII_INTERNAL_HOSTNAME, Value: "vpn.eu.acme.com", Match: "vpn.eu.acme.com"},
+		},
+	}
+	testCases["creditCard"] = TestData{
+		Input: "invoice 4111 1111 1111 1111.pdf",
+		Want: []gothreatmatrix.PIIFinding{
+			{Kind: gothreatmatrix.PII_CREDIT_CARD, Value: "invoice 4111 1111 1111 1111.pdf", Match: "4111 1111 1111 1111"},
+		},
+	}
+	testCases["notLuhn"] = TestData{
+		Input: "4111111111111112",
+		Want:  []gothreatmatrix.PIIFinding{},
+	}
+	testCases["publicIndicators"] = TestData{
+		Input: "dns.google",
+		Want:  []gothreatmatrix.PIIFinding{},
+	}
+	testCases["hash"] = TestData{
+		Input: "2329ab183ad74dd65e0519fa8b977f3b",
+		Want:  []gothreatmatrix.PIIFinding{},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			testWantData(t, testCase.Want, guard.Scan(testCase.Input.(string)))
+		})
+	}
+}
+
+func TestPIIGuardSubmission(t *testing.T) {
+	analyzerConfigs := `{
+		"Classic_DNS": {"name": "Classic_DNS", "external_service": false},
+		"VirusTotal_v3_Get_Observable": {"name": "VirusTotal_v3_Get_Observable", "external_service": true}
+	}`
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases[gothreatmatrix.PII_BLOCK.String()] = TestData{
+		Input: gothreatmatrix.PII_BLOCK,
+		Want:  nil,
+	}
+	testCases[gothreatmatrix.PII_FORCE_RED.String()] = TestData{
+		Input: gothreatmatrix.PII_FORCE_RED,
+		Want: gothreatmatrix.BasicAnalysisParams{
+			Tlp:                gothreatmatrix.RED,
+			AnalyzersRequested: []string{"Classic_DNS", "VirusTotal_v3_Get_Observable"},
+		},
+	}
+	testCases[gothreatmatrix.PII_LOCAL_ONLY.String()] = TestData{
+		Input: gothreatmatrix.PII_LOCAL_ONLY,
+		Want: gothreatmatrix.BasicAnalysisParams{
+			Tlp:                gothreatmatrix.RED,
+			AnalyzersRequested: []string{"Classic_DNS"},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			detected := 0
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+				PIIGuard: &gothreatmatrix.PIIGuard{
+					InternalDomains: []string{"acme.com"},
+					Action:          testCase.Input.(gothreatmatrix.PIIAction),
+					OnDetect: func(findings []gothreatmatrix.PIIFinding) {
+						detected += len(findings)
+					},
+				},
+			})
+			defer closeServer()
+			ctx := context.Background()
+			apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, TestData{Data: analyzerConfigs}, "GET"))
+			apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+				if testCase.Want == nil {
+					t.Errorf("Blocked submission sent")
+				}
+				params := gothreatmatrix.ObservableAnalysisParams{}
+				if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+					t.Errorf("Could not parse request body: %v", err)
+				}
+				testWantData(t, testCase.Want, params.BasicAnalysisParams)
+				w.Write([]byte(`{"job_id": 1, "status": "accepted"}`))
+			})
+			_, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+				BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{
+					Tlp:                gothreatmatrix.GREEN,
+					AnalyzersRequested: []string{"Classic_DNS", "VirusTotal_v3_Get_Observable"},
+				},
+				ObservableName: "bob@acme.com",
+			})
+			piiError := &gothreatmatrix.PIIError{}
+			if testCase.Want == nil {
+				if !errors.As(err, &piiError) || len(piiError.Findings) != 1 {
+					t.Errorf("Expected a PIIError, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, 1, detected)
+		})
+	}
+}
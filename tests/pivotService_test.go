@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestPivotServiceGetConfigs(t *testing.T) {
+	pivotConfigJsonString := `{"AbuseIpToSubmission":{"name":"AbuseIpToSubmission","python_module":"compare.Compare","disabled":false,"description":"Analyze the IPs reported by AbuseIPDB","related_analyzer_configs":["AbuseIPDB"],"related_connector_configs":[],"playbook_to_execute":"FREE_TO_USE_ANALYZERS"}}`
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      nil,
+		Data:       pivotConfigJsonString,
+		StatusCode: http.StatusOK,
+		Want: []gothreatmatrix.PivotConfig{
+			{
+				BaseConfigurationType:   gothreatmatrix.BaseConfigurationType{Name: "AbuseIpToSubmission", PythonModule: "compare.Compare", Description: "Analyze the IPs reported by AbuseIPDB"},
+				RelatedAnalyzerConfigs:  []string{"AbuseIPDB"},
+				RelatedConnectorConfigs: []string{},
+				PlaybookToExecute:       "FREE_TO_USE_ANALYZERS",
+			},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			apiHandler.Handle(constants.PIVOT_CONFIG_URL, serverHandler(t, testCase, "GET"))
+			gottenPivotConfigList, err := client.PivotService.GetConfigs(ctx)
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, *gottenPivotConfigList)
+			}
+		})
+	}
+}
+
+func TestPivotServiceHealthCheck(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      "AbuseIpToSubmission",
+		Data:       `{"status": true}`,
+		StatusCode: http.StatusOK,
+		Want:       true,
+	}
+	testCases["pivotDoesntExist"] = TestData{
+		Input:      "notAPivot",
+		Data:       `{"errors": {"detail": "Pivot doesn't exist"}}`,
+		StatusCode: http.StatusBadRequest,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusBadRequest,
+			Message:    `{"errors": {"detail": "Pivot doesn't exist"}}`,
+			Errors:     map[string]interface{}{"detail": "Pivot doesn't exist"},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			input, ok := testCase.Input.(string)
+			if ok {
+				testUrl := fmt.Sprintf(constants.PIVOT_HEALTHCHECK_URL, input)
+				apiHandler.Handle(testUrl, serverHandler(t, testCase, "GET"))
+				status, err := client.PivotService.HealthCheck(ctx, input)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, testCase.Want, status)
+				}
+			}
+		})
+	}
+}
+
+func TestPivotServiceChain(t *testing.T) {
+	// * job 1 spawned jobs 2 and 3, job 2 spawned job 4, job 4 spawned job 1 again
+	pivotMaps := []gothreatmatrix.PivotMap{
+		{ID: 1, StartingJob: 1, PivotConfig: "Resolve_Domain", EndingJob: 2},
+		{ID: 2, StartingJob: 1, PivotConfig: "Download_Payload", EndingJob: 3},
+		{ID: 3, StartingJob: 2, PivotConfig: "Resolve_Domain", EndingJob: 4},
+		{ID: 4, StartingJob: 4, PivotConfig: "Loop", EndingJob: 1},
+	}
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.PIVOT_MAP_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		found := []gothreatmatrix.PivotMap{}
+		for _, pivotMap := range pivotMaps {
+			if fmt.Sprint(pivotMap.StartingJob) == r.URL.Query().Get("starting_job") || fmt.Sprint(pivotMap.EndingJob) == r.URL.Query().Get("ending_job") {
+				found = append(found, pivotMap)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"count": len(found), "total_pages": 1, "results": found})
+	})
+	ctx := context.Background()
+	children, err := client.PivotService.Children(ctx, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, pivotMaps[:2], children)
+
+	chain, err := client.PivotService.Descendants(ctx, 1, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []uint64{1, 2, 3, 4}, chain.JobIDs())
+	chain, err = client.PivotService.Descendants(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []uint64{1, 2, 3}, chain.JobIDs())
+
+	root, err := client.PivotService.Root(ctx, 4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, uint64(1), root)
+}
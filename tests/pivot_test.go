@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestClassifyObservable(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["ipv4"] = TestData{Input: "8.8.8.8", Want: "ip"}
+	testCases["ipv6"] = TestData{Input: "2001:4860:4860::8888", Want: "ip"}
+	testCases["url"] = TestData{Input: "https://evil.example/login.php?id=1", Want: "url"}
+	testCases["domain"] = TestData{Input: "dns.google", Want: "domain"}
+	testCases["md5"] = TestData{Input: "2329ab183ad74dd65e0519fa8b977f3b", Want: "hash"}
+	testCases["sentence"] = TestData{Input: "no resolution found", Want: ""}
+	testCases["number"] = TestData{Input: "1234", Want: ""}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			testWantData(t, testCase.Want, gothreatmatrix.ClassifyObservable(testCase.Input.(string)))
+		})
+	}
+}
+
+func TestExtractArtifacts(t *testing.T) {
+	job := gothreatmatrix.Job{
+		BaseJob: gothreatmatrix.BaseJob{ObservableName: "dns.google"},
+		AnalyzerReports: []gothreatmatrix.Report{
+			{Name: "Classic_DNS", Report: map[string]interface{}{"observable": "dns.google", "resolutions": []interface{}{"8.8.8.8", "8.8.4.4"}}},
+			{Name: "Robtex", Report: map[string]interface{}{"pdns": []interface{}{map[string]interface{}{"ip": "8.8.8.8", "note": "seen twice"}}}},
+		},
+	}
+	testWantData(t, []gothreatmatrix.Artifact{
+		{Value: "8.8.4.4", Classification: "ip", Sources: []string{"Classic_DNS"}},
+		{Value: "8.8.8.8", Classification: "ip", Sources: []string{"Classic_DNS", "Robtex"}},
+	}, gothreatmatrix.ExtractArtifacts(&job))
+}
+
+func TestExtractIndicators(t *testing.T) {
+	job := gothreatmatrix.Job{
+		BaseJob: gothreatmatrix.BaseJob{ObservableName: "Evil.Example"},
+		AnalyzerReports: []gothreatmatrix.Report{
+			{Name: "VirusTotal_v3_Get_Observable", Report: map[string]interface{}{
+				"domain":  "evil.example",
+				"subject": "CDN.Evil.Example",
+				"hashes":  []interface{}{"44D88612FEA8A8F36DE82E1278ABB02F"},
+				"comment": "Dropper fetched from https://evil.example/payload.exe, exploiting cve-2021-44228 (see 10.0.0.1).",
+			}},
+			{Name: "Shodan_Search", Report: map[string]interface{}{
+				"ips":   []interface{}{"10.0.0.1", "2001:0db8::0001"},
+				"vulns": []interface{}{"CVE-2021-44228"},
+				"file":  "payload.exe",
+			}},
+		},
+	}
+	testWantData(t, []gothreatmatrix.Indicator{
+		{Value: "CVE-2021-44228", Type: "cve", Sources: []string{"VirusTotal_v3_Get_Observable", "Shodan_Search"}},
+		{Value: "cdn.evil.example", Type: "domain", Sources: []string{"VirusTotal_v3_Get_Observable"}},
+		{Value: "44d88612fea8a8f36de82e1278abb02f", Type: "hash", Sources: []string{"VirusTotal_v3_Get_Observable"}},
+		{Value: "10.0.0.1", Type: "ip", Sources: []string{"VirusTotal_v3_Get_Observable", "Shodan_Search"}},
+		{Value: "2001:db8::1", Type: "ip", Sources: []string{"Shodan_Search"}},
+		{Value: "https://evil.example/payload.exe", Type: "url", Sources: []string{"VirusTotal_v3_Get_Observable"}},
+	}, gothreatmatrix.ExtractIndicators(&job))
+}
+
+func TestPivotHunt(t *testing.T) {
+	// * what each observable's reports point to
+	pivots := map[string][]string{
+		"evil.example":     {"10.0.0.1", "https://evil.example/payload.exe", "cdn.evil.example"},
+		"10.0.0.1":         {"ns1.evil.example"},
+		"cdn.evil.example": {"10.0.0.2"},
+	}
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	var mutex sync.Mutex
+	jobIds := map[string]int{}
+	observables := map[int]string{}
+	investigationJobs := []uint64{}
+	apiHandler.HandleFunc(constants.BASE_INVESTIGATION_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		w.Write([]byte(`{"id": 7, "name": "Pivot hunt: evil.example"}`))
+	})
+	apiHandler.HandleFunc(fmt.Sprintf(constants.ADD_JOB_TO_INVESTIGATION_URL, 7), func(w http.ResponseWriter, r *http.Request) {
+		params := map[string]uint64{}
+		json.NewDecoder(r.Body).Decode(&params)
+		mutex.Lock()
+		investigationJobs = append(investigationJobs, params["job"])
+		mutex.Unlock()
+	})
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		params := gothreatmatrix.ObservableAnalysisParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		mutex.Lock()
+		jobId := len(jobIds) + 1
+		jobIds[params.ObservableName] = jobId
+		observables[jobId] = params.ObservableName
+		mutex.Unlock()
+		fmt.Fprintf(w, `{"job_id": %d, "status": "accepted"}`, jobId)
+	})
+	apiHandler.HandleFunc(constants.BASE_JOB_URL+"/", func(w http.ResponseWriter, r *http.Request) {
+		var jobId int
+		fmt.Sscanf(r.URL.Path, constants.SPECIFIC_JOB_URL, &jobId)
+		mutex.Lock()
+		observable := observables[jobId]
+		mutex.Unlock()
+		reportValues := []interface{}{}
+		for _, value := range pivots[observable] {
+			reportValues = append(reportValues, value)
+		}
+		job := map[string]interface{}{
+			"id":              jobId,
+			"status":          "reported_without_fails",
+			"observable_name": observable,
+			"analyzer_reports": []interface{}{
+				map[string]interface{}{"name": "Passive_DNS", "status": "SUCCESS", "report": map[string]interface{}{"values": reportValues}},
+			},
+		}
+		json.NewEncoder(w).Encode(job)
+	})
+
+	result, err := client.PivotHunt(context.Background(), "evil.example", "domain", &gothreatmatrix.PivotOptions{
+		MaxDepth:        2,
+		MaxWidth:        2,
+		Classifications: []string{"ip", "domain"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, uint64(7), result.Investigation.ID)
+	analyzed := []string{}
+	depths := []int{}
+	for _, pivotJob := range result.Jobs {
+		if pivotJob.Err != nil {
+			t.Errorf("Unexpected error for %s: %v", pivotJob.ObservableName, pivotJob.Err)
+		}
+		analyzed = append(analyzed, pivotJob.ObservableName)
+		depths = append(depths, pivotJob.Depth)
+	}
+	// * the url is not pivoted on, the width limits the first level to 2 indicators
+	testWantData(t, []string{"evil.example", "10.0.0.1", "cdn.evil.example", "ns1.evil.example", "10.0.0.2"}, analyzed)
+	testWantData(t, []int{0, 1, 1, 2, 2}, depths)
+	testWantData(t, result.Jobs[0].JobID, result.Jobs[1].ParentJobID)
+	testWantData(t, []uint64{1, 2, 3, 4, 5}, investigationJobs)
+}
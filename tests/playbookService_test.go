@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestParseScanCheckTime(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["djangoDuration"] = TestData{Input: "1 00:00:00", Want: 24 * time.Hour}
+	testCases["hoursOnly"] = TestData{Input: "02:30:00", Want: 2*time.Hour + 30*time.Minute}
+	testCases["fractionalSeconds"] = TestData{Input: "00:00:01.500000", Want: 1500 * time.Millisecond}
+	testCases["colonDays"] = TestData{Input: "2:00:00:00", Want: 48 * time.Hour}
+	testCases["invalid"] = TestData{Input: "yesterday", Want: nil}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			duration, err := gothreatmatrix.ParseScanCheckTime(testCase.Input.(string))
+			if testCase.Want == nil {
+				if err == nil {
+					t.Errorf("Expected an error, got %v", duration)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.Want, duration)
+		})
+	}
+}
+
+func TestFormatScanCheckTime(t *testing.T) {
+	testWantData(t, "1 00:00:00", gothreatmatrix.FormatScanCheckTime(24*time.Hour))
+	testWantData(t, "0 02:30:05", gothreatmatrix.FormatScanCheckTime(2*time.Hour+30*time.Minute+5*time.Second))
+	duration, err := gothreatmatrix.ParseScanCheckTime(gothreatmatrix.FormatScanCheckTime(50 * time.Hour))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 50*time.Hour, duration)
+}
+
+func TestPlaybookScanCheckWindow(t *testing.T) {
+	forceNew := gothreatmatrix.PlaybookConfig{ScanMode: gothreatmatrix.SCAN_MODE_FORCE_NEW, ScanCheckTime: "1 00:00:00"}
+	checkPrevious := gothreatmatrix.PlaybookConfig{ScanMode: gothreatmatrix.SCAN_MODE_CHECK_PREVIOUS, ScanCheckTime: "1 00:00:00"}
+	window, err := forceNew.ScanCheckWindow()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, time.Duration(0), window)
+	window, err = checkPrevious.ScanCheckWindow()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 24*time.Hour, window)
+}
+
+const playbookServiceConfigsJson = `{
+	"FREE_TO_USE_ANALYZERS": {"name": "FREE_TO_USE_ANALYZERS", "description": "free analyzers", "disabled": false, "analyzers": {"Classic_DNS": {}}, "connectors": {}, "supports": ["ip", "domain", "url"], "scan_mode": 2, "scan_check_time": "1 00:00:00"},
+	"DISABLED": {"name": "DISABLED", "disabled": true, "analyzers": {}, "connectors": {}, "supports": []}
+}`
+
+func TestPlaybookServiceGetConfigs(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.PLAYBOOK_CONFIG_URL, serverHandler(t, TestData{Data: playbookServiceConfigsJson}, "GET"))
+	playbookConfigs, err := client.PlaybookService.GetConfigs(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	names := []string{}
+	for _, playbookConfig := range *playbookConfigs {
+		names = append(names, playbookConfig.Name)
+	}
+	testWantData(t, []string{"DISABLED", "FREE_TO_USE_ANALYZERS"}, names)
+}
+
+func TestPlaybookServiceCreatePlaybook(t *testing.T) {
+	playbookJson := `{"name": "DNS", "description": "dns resolutions", "disabled": false, "analyzers": {"Classic_DNS": {}}, "connectors": {}, "supports": ["domain"]}`
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.BASE_PLAYBOOK_URL, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		playbookParams := gothreatmatrix.PlaybookParams{}
+		if err := json.NewDecoder(r.Body).Decode(&playbookParams); err != nil {
+			t.Fatalf("Error: %s", err)
+		}
+		testWantData(t, "DNS", playbookParams.Name)
+		testWantData(t, []string{"domain"}, playbookParams.Supports)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(playbookJson))
+	}))
+	playbookConfig, err := client.PlaybookService.CreatePlaybook(context.Background(), &gothreatmatrix.PlaybookParams{
+		Name:        "DNS",
+		Description: "dns resolutions",
+		Analyzers:   map[string]interface{}{"Classic_DNS": map[string]interface{}{}},
+		Connectors:  map[string]interface{}{},
+		Supports:    []string{"domain"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, &gothreatmatrix.PlaybookConfig{
+		Name:        "DNS",
+		Description: "dns resolutions",
+		Analyzers:   map[string]interface{}{"Classic_DNS": map[string]interface{}{}},
+		Connectors:  map[string]interface{}{},
+		Supports:    []string{"domain"},
+	}, playbookConfig)
+}
+
+func TestPlaybookServiceRunPlaybookOnObservable(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["supported"] = TestData{Input: []string{"FREE_TO_USE_ANALYZERS", "8.8.8.8"}, Want: nil}
+	testCases["unsupportedClassification"] = TestData{Input: []string{"FREE_TO_USE_ANALYZERS", "2329ab183ad74dd65e0519fa8b977f3b"}, Want: gothreatmatrix.ErrPlaybookNotRunnable}
+	testCases["disabled"] = TestData{Input: []string{"DISABLED", "8.8.8.8"}, Want: gothreatmatrix.ErrPlaybookNotRunnable}
+	testCases["unknown"] = TestData{Input: []string{"MISSING", "8.8.8.8"}, Want: gothreatmatrix.ErrPlaybookNotRunnable}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			input := testCase.Input.([]string)
+			submitted := false
+			apiHandler.Handle(constants.PLAYBOOK_CONFIG_URL, serverHandler(t, TestData{Data: playbookServiceConfigsJson}, "GET"))
+			apiHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				submitted = true
+				params := map[string]interface{}{}
+				if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+					t.Fatalf("Error: %s", err)
+				}
+				testWantData(t, input[0], params["playbook_requested"])
+				testWantData(t, nil, params["analyzers_requested"])
+				w.Write([]byte(`{"job_id": 7, "status": "accepted"}`))
+			}))
+			analysisResponse, err := client.PlaybookService.RunPlaybookOnObservable(context.Background(), input[0], &gothreatmatrix.ObservableAnalysisParams{
+				BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{AnalyzersRequested: []string{"VirusTotal_v3_Get_Observable"}},
+				ObservableName:      input[1],
+			})
+			if testCase.Want != nil {
+				if !errors.Is(err, testCase.Want.(error)) {
+					t.Fatalf("Expected %v, got %v", testCase.Want, err)
+				}
+				testWantData(t, false, submitted)
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, 7, analysisResponse.JobID)
+		})
+	}
+}
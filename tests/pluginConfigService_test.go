@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+const pluginConfigListJson = `[
+	{"id": 1, "type": "analyzer", "plugin_name": "VirusTotal_v3_Get_File", "attribute": "api_key_name", "value": "********", "is_secret": true, "for_organization": true, "owner": "admin"},
+	{"id": 2, "type": "analyzer", "plugin_name": "VirusTotal_v3_Get_File", "attribute": "max_tries", "value": 10, "is_secret": false, "for_organization": false, "owner": "analyst"},
+	{"id": 3, "type": "connector", "plugin_name": "MISP", "attribute": "url_key_name", "value": "https://misp.example.com", "is_secret": false, "for_organization": true, "owner": "admin"}
+]`
+
+func TestPluginConfigServiceListForPlugin(t *testing.T) {
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      "VirusTotal_v3_Get_File",
+		Data:       pluginConfigListJson,
+		StatusCode: http.StatusOK,
+		Want: []gothreatmatrix.PluginConfigEntry{
+			{ID: 1, Type: "analyzer", PluginName: "VirusTotal_v3_Get_File", Attribute: "api_key_name", Value: "********", IsSecret: true, ForOrganization: true, Owner: "admin"},
+			{ID: 2, Type: "analyzer", PluginName: "VirusTotal_v3_Get_File", Attribute: "max_tries", Value: float64(10), Owner: "analyst"},
+		},
+	}
+	testCases["unknownPlugin"] = TestData{
+		Input:      "Shodan_Search",
+		Data:       pluginConfigListJson,
+		StatusCode: http.StatusOK,
+		Want:       []gothreatmatrix.PluginConfigEntry{},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.Handle(constants.PLUGIN_CONFIG_URL, serverHandler(t, testCase, "GET"))
+			entries, err := client.PluginConfigService.ListForPlugin(context.Background(), gothreatmatrix.ANALYZER_PLUGIN, testCase.Input.(string))
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, entries)
+			}
+		})
+	}
+}
+
+func TestPluginConfigServiceSet(t *testing.T) {
+	testCases := map[string]struct {
+		forOrganization bool
+		wantMethod      string
+	}{
+		// * the organization secret exists, it is updated
+		"update": {forOrganization: true, wantMethod: "PATCH"},
+		"create": {forOrganization: false, wantMethod: "POST"},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			methods := []string{}
+			apiHandler.HandleFunc(constants.PLUGIN_CONFIG_URL, func(w http.ResponseWriter, r *http.Request) {
+				methods = append(methods, r.Method)
+				if r.Method == "GET" {
+					w.Write([]byte(pluginConfigListJson))
+					return
+				}
+				entries := []gothreatmatrix.PluginConfigEntry{}
+				json.NewDecoder(r.Body).Decode(&entries)
+				testWantData(t, []gothreatmatrix.PluginConfigEntry{{
+					Type: "analyzer", PluginName: "VirusTotal_v3_Get_File", Attribute: "api_key_name", Value: "new-key", IsSecret: true,
+				}}, entries)
+				entries[0].ID = 4
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(entries)
+			})
+			apiHandler.HandleFunc(fmt.Sprintf(constants.SPECIFIC_PLUGIN_CONFIG_URL, 1), func(w http.ResponseWriter, r *http.Request) {
+				methods = append(methods, r.Method)
+				update := map[string]interface{}{}
+				json.NewDecoder(r.Body).Decode(&update)
+				testWantData(t, map[string]interface{}{"value": "new-key"}, update)
+				w.Write([]byte(`{"id": 1, "type": "analyzer", "plugin_name": "VirusTotal_v3_Get_File", "attribute": "api_key_name", "value": "new-key", "is_secret": true, "for_organization": true}`))
+			})
+			entry, err := client.PluginConfigService.SetSecret(context.Background(), gothreatmatrix.ANALYZER_PLUGIN, "VirusTotal_v3_Get_File", "api_key_name", "new-key", testCase.forOrganization)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, []string{"GET", testCase.wantMethod}, methods)
+			testWantData(t, "new-key", entry.Value)
+			testWantData(t, testCase.forOrganization, entry.ForOrganization)
+		})
+	}
+}
+
+func TestPluginConfigServiceDelete(t *testing.T) {
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      uint64(2),
+		StatusCode: http.StatusNoContent,
+		Want:       true,
+	}
+	testCases["notFound"] = TestData{
+		Input:      uint64(9),
+		Data:       `{"detail": "Not found."}`,
+		StatusCode: http.StatusNotFound,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusNotFound,
+			Message:    `{"detail": "Not found."}`,
+			Detail:     "Not found.",
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			id := testCase.Input.(uint64)
+			apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_PLUGIN_CONFIG_URL, id), serverHandler(t, testCase, "DELETE"))
+			deleted, err := client.PluginConfigService.Delete(context.Background(), id)
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, deleted)
+			}
+		})
+	}
+}
+
+func TestPluginConfigEntryMasking(t *testing.T) {
+	secret := gothreatmatrix.PluginConfigEntry{Type: "analyzer", PluginName: "Shodan_Search", Attribute: "api_key_name", Value: "s3cr3t", IsSecret: true}
+	parameter := gothreatmatrix.PluginConfigEntry{Type: "analyzer", PluginName: "Shodan_Search", Attribute: "shodan_analysis", Value: "search", ForOrganization: true}
+	for _, formatted := range []string{secret.String(), fmt.Sprintf("%v", secret), fmt.Sprintf("%#v", secret), fmt.Sprint([]gothreatmatrix.PluginConfigEntry{secret})} {
+		if strings.Contains(formatted, "s3cr3t") || !strings.Contains(formatted, gothreatmatrix.SECRET_MASK) {
+			t.Errorf("The secret is not masked: %s", formatted)
+		}
+	}
+	testWantData(t, "analyzer Shodan_Search shodan_analysis=search (organization)", parameter.String())
+	// * the JSON holds the value, for the writes
+	data, _ := json.Marshal(secret)
+	if !strings.Contains(string(data), "s3cr3t") {
+		t.Errorf("The JSON of the entry lost its value: %s", data)
+	}
+	testWantData(t, "s3cr3t", secret.Value)
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// sandboxConfig is the configuration of a plugin type the SDK does not wrap.
+type sandboxConfig struct {
+	gothreatmatrix.BaseConfigurationType
+	Queue string `json:"queue"`
+}
+
+func TestFetchPluginConfigs(t *testing.T) {
+	want := []sandboxConfig{
+		{BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "Cuckoo"}, Queue: "long"},
+		{BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "Triage"}, Queue: "default"},
+	}
+	testCases := make(map[string]TestData)
+	testCases["map"] = TestData{
+		Data:       `{"Triage": {"name": "Triage", "queue": "default"}, "Cuckoo": {"name": "Cuckoo", "queue": "long"}}`,
+		StatusCode: http.StatusOK,
+		Want:       want,
+	}
+	testCases["list"] = TestData{
+		Data:       `[{"name": "Triage", "queue": "default"}, {"name": "Cuckoo", "queue": "long"}]`,
+		StatusCode: http.StatusOK,
+		Want:       want,
+	}
+	testCases["page"] = TestData{
+		Data:       `{"count": 2, "next": null, "results": [{"name": "Triage", "queue": "default"}, {"name": "Cuckoo", "queue": "long"}]}`,
+		StatusCode: http.StatusOK,
+		Want:       want,
+	}
+	testCases["error"] = TestData{
+		Data:       `{"detail": "Not found."}`,
+		StatusCode: http.StatusNotFound,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusNotFound,
+			Message:    `{"detail": "Not found."}`,
+			Detail:     "Not found.",
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.Handle("/api/sandbox", serverHandler(t, testCase, "GET"))
+			configs, err := gothreatmatrix.FetchPluginConfigs[sandboxConfig](context.Background(), &client, "/api/sandbox")
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, configs)
+			}
+		})
+	}
+}
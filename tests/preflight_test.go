@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/sirupsen/logrus"
+)
+
+// preflightHandler serves the endpoints probed by Preflight, answering the token check with accessStatus.
+func preflightHandler(t *testing.T, accessStatus int, canSubmit bool) *http.ServeMux {
+	apiHandler := http.NewServeMux()
+	apiHandler.HandleFunc(constants.USER_DETAILS_URL, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(accessStatus)
+		w.Write([]byte(`{"user": {"username": "analyst"}, "access": {"total_submissions": 3}}`))
+	})
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "OPTIONS":
+			if canSubmit {
+				w.Write([]byte(`{"name": "Analyze Observable", "actions": {"POST": {}}}`))
+			} else {
+				w.Write([]byte(`{"name": "Analyze Observable", "actions": {}}`))
+			}
+		default:
+			w.Header().Set(gothreatmatrix.SERVER_VERSION_HEADER, "v5.2.0")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	apiHandler.Handle(constants.BASE_JOB_URL, serverHandler(t, TestData{Data: `{"count": 1, "total_pages": 1, "results": [{"id": 1}]}`}, "GET"))
+	apiHandler.Handle(constants.ORGANIZATION_URL, serverHandler(t, TestData{Data: `{"name": "soc", "is_user_owner": true}`}, "GET"))
+	return apiHandler
+}
+
+func TestPreflight(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["ready"] = TestData{
+		Input: []interface{}{http.StatusOK, true},
+		Want: map[string]gothreatmatrix.PreflightStatus{
+			gothreatmatrix.PREFLIGHT_REACHABILITY: gothreatmatrix.PREFLIGHT_PASS,
+			gothreatmatrix.PREFLIGHT_TLS:          gothreatmatrix.PREFLIGHT_WARN,
+			gothreatmatrix.PREFLIGHT_AUTH:         gothreatmatrix.PREFLIGHT_PASS,
+			gothreatmatrix.PREFLIGHT_API_VERSION:  gothreatmatrix.PREFLIGHT_PASS,
+			gothreatmatrix.PREFLIGHT_READ:         gothreatmatrix.PREFLIGHT_PASS,
+			gothreatmatrix.PREFLIGHT_SUBMIT:       gothreatmatrix.PREFLIGHT_PASS,
+			gothreatmatrix.PREFLIGHT_ADMIN:        gothreatmatrix.PREFLIGHT_PASS,
+		},
+	}
+	testCases["readOnly"] = TestData{
+		Input: []interface{}{http.StatusOK, false},
+		Want: map[string]gothreatmatrix.PreflightStatus{
+			gothreatmatrix.PREFLIGHT_REACHABILITY: gothreatmatrix.PREFLIGHT_PASS,
+			gothreatmatrix.PREFLIGHT_TLS:          gothreatmatrix.PREFLIGHT_WARN,
+			gothreatmatrix.PREFLIGHT_AUTH:         gothreatmatrix.PREFLIGHT_PASS,
+			gothreatmatrix.PREFLIGHT_API_VERSION:  gothreatmatrix.PREFLIGHT_PASS,
+			gothreatmatrix.PREFLIGHT_READ:         gothreatmatrix.PREFLIGHT_PASS,
+			gothreatmatrix.PREFLIGHT_SUBMIT:       gothreatmatrix.PREFLIGHT_FAIL,
+			gothreatmatrix.PREFLIGHT_ADMIN:        gothreatmatrix.PREFLIGHT_PASS,
+		},
+	}
+	testCases["badToken"] = TestData{
+		Input: []interface{}{http.StatusUnauthorized, true},
+		Want: map[string]gothreatmatrix.PreflightStatus{
+			gothreatmatrix.PREFLIGHT_REACHABILITY: gothreatmatrix.PREFLIGHT_PASS,
+			gothreatmatrix.PREFLIGHT_TLS:          gothreatmatrix.PREFLIGHT_WARN,
+			gothreatmatrix.PREFLIGHT_AUTH:         gothreatmatrix.PREFLIGHT_FAIL,
+			gothreatmatrix.PREFLIGHT_API_VERSION:  gothreatmatrix.PREFLIGHT_SKIP,
+			gothreatmatrix.PREFLIGHT_READ:         gothreatmatrix.PREFLIGHT_SKIP,
+			gothreatmatrix.PREFLIGHT_SUBMIT:       gothreatmatrix.PREFLIGHT_SKIP,
+			gothreatmatrix.PREFLIGHT_ADMIN:        gothreatmatrix.PREFLIGHT_SKIP,
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			input := testCase.Input.([]interface{})
+			testServer := httptest.NewServer(preflightHandler(t, input[0].(int), input[1].(bool)))
+			defer testServer.Close()
+			client := gothreatmatrix.NewThreatMatrixClient(&gothreatmatrix.ThreatMatrixClientOptions{
+				Url:   testServer.URL,
+				Token: "test-token",
+			}, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+			report := client.Preflight(context.Background())
+			statuses := map[string]gothreatmatrix.PreflightStatus{}
+			for _, check := range report.Checks {
+				statuses[check.Name] = check.Status
+			}
+			testWantData(t, testCase.Want, statuses)
+			want := testCase.Want.(map[string]gothreatmatrix.PreflightStatus)
+			testWantData(t, want[gothreatmatrix.PREFLIGHT_AUTH] == gothreatmatrix.PREFLIGHT_PASS && want[gothreatmatrix.PREFLIGHT_SUBMIT] == gothreatmatrix.PREFLIGHT_PASS, report.OK())
+			if want[gothreatmatrix.PREFLIGHT_API_VERSION] == gothreatmatrix.PREFLIGHT_PASS {
+				testWantData(t, "v5.2.0", report.ServerVersion)
+			}
+		})
+	}
+}
+
+func TestPreflightConnection(t *testing.T) {
+	closedServer := httptest.NewServer(http.NotFoundHandler())
+	closedServer.Close()
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["unreachable"] = TestData{
+		Input: closedServer.URL,
+		Want:  []gothreatmatrix.PreflightStatus{gothreatmatrix.PREFLIGHT_FAIL, gothreatmatrix.PREFLIGHT_SKIP},
+	}
+	testCases["untrustedCertificate"] = TestData{
+		Input: tlsServer.URL,
+		Want:  []gothreatmatrix.PreflightStatus{gothreatmatrix.PREFLIGHT_PASS, gothreatmatrix.PREFLIGHT_FAIL},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client := gothreatmatrix.NewThreatMatrixClient(&gothreatmatrix.ThreatMatrixClientOptions{
+				Url:   testCase.Input.(string),
+				Token: "test-token",
+			}, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+			report := client.Preflight(context.Background())
+			reachability, _ := report.Check(gothreatmatrix.PREFLIGHT_REACHABILITY)
+			tls, _ := report.Check(gothreatmatrix.PREFLIGHT_TLS)
+			testWantData(t, testCase.Want, []gothreatmatrix.PreflightStatus{reachability.Status, tls.Status})
+			auth, _ := report.Check(gothreatmatrix.PREFLIGHT_AUTH)
+			testWantData(t, gothreatmatrix.PREFLIGHT_SKIP, auth.Status)
+			testWantData(t, false, report.OK())
+		})
+	}
+}
+
+func TestPreflightInsecureSkipVerify(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	testCases := map[string]struct {
+		pins []string
+		want gothreatmatrix.PreflightStatus
+	}{
+		"unpinned": {want: gothreatmatrix.PREFLIGHT_WARN},
+		"pinned":   {pins: []string{gothreatmatrix.SPKIHash(tlsServer.Certificate())}, want: gothreatmatrix.PREFLIGHT_PASS},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client := gothreatmatrix.NewThreatMatrixClient(&gothreatmatrix.ThreatMatrixClientOptions{
+				Url:                tlsServer.URL,
+				Token:              "test-token",
+				InsecureSkipVerify: true,
+				PinnedSPKIHashes:   testCase.pins,
+			}, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+			report := client.Preflight(context.Background())
+			tls, _ := report.Check(gothreatmatrix.PREFLIGHT_TLS)
+			testWantData(t, testCase.want, tls.Status)
+		})
+	}
+}
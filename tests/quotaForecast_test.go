@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestForecastQuotas(t *testing.T) {
+	observables := func(count int) []string {
+		return make([]string, count)
+	}
+	records := []gothreatmatrix.AuditRecord{
+		{Time: time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC), Observables: observables(40), AnalyzersRequested: []string{"Censys_Search"}},
+		{Time: time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC), Observables: observables(10), AnalyzersRequested: []string{"VirusTotal_v3_Get_Observable"}},
+		{Time: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), Observables: observables(20), AnalyzersRequested: []string{"VirusTotal_v3_Get_Observable", "Classic_DNS"}},
+		{Time: time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC), Observables: observables(30), PlaybookRequested: "FREE_TO_USE_ANALYZERS"},
+	}
+	forecasts := gothreatmatrix.ForecastQuotas(records, &gothreatmatrix.QuotaForecastOptions{
+		Quotas: map[string]int{"VirusTotal_v3_Get_Observable": 100, "Shodan_Search": 25, "Censys_Search": 50},
+		Playbooks: map[string]gothreatmatrix.PlaybookConfig{
+			"FREE_TO_USE_ANALYZERS": {Name: "FREE_TO_USE_ANALYZERS", Analyzers: map[string]interface{}{"Shodan_Search": map[string]interface{}{}}},
+		},
+		Now: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+	})
+	if len(forecasts) != 3 {
+		t.Fatalf("Expected 3 forecasts, got %+v", forecasts)
+	}
+	resetsAt := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	censys := forecasts[0]
+	testWantData(t, "Censys_Search", censys.Analyzer)
+	testWantData(t, 40, censys.Used)
+	testWantData(t, 10, censys.Remaining)
+	// * 40 analyses over the 24 days of history, the 10 remaining last 6 days
+	wantExhaustedAt := time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC)
+	if censys.ExhaustedAt == nil || censys.ExhaustedAt.Before(wantExhaustedAt.Add(-time.Second)) || censys.ExhaustedAt.After(wantExhaustedAt.Add(time.Second)) {
+		t.Errorf("Expected the quota to be exhausted around %v, got %v", wantExhaustedAt, censys.ExhaustedAt)
+	}
+
+	shodan := forecasts[1]
+	testWantData(t, "Shodan_Search", shodan.Analyzer)
+	testWantData(t, 30, shodan.Used)
+	testWantData(t, 0, shodan.Remaining)
+	testWantData(t, time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC), *shodan.ExhaustedAt)
+
+	virusTotal := forecasts[2]
+	testWantData(t, "VirusTotal_v3_Get_Observable", virusTotal.Analyzer)
+	testWantData(t, 20, virusTotal.Used)
+	testWantData(t, 1.25, virusTotal.DailyRate)
+	testWantData(t, 41, virusTotal.ProjectedUsage)
+	testWantData(t, resetsAt, virusTotal.ResetsAt)
+	if virusTotal.ExhaustedAt != nil {
+		t.Errorf("Expected the quota to last the month, got %v", virusTotal.ExhaustedAt)
+	}
+
+	output := bytes.Buffer{}
+	if err := gothreatmatrix.WriteQuotaMetrics(&output, forecasts); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	metrics := output.String()
+	for _, line := range []string{
+		`threatmatrix_analyzer_quota_used{analyzer="Shodan_Search"} 30`,
+		`threatmatrix_analyzer_quota_daily_rate{analyzer="VirusTotal_v3_Get_Observable"} 1.25`,
+		`threatmatrix_analyzer_quota_exhaustion_timestamp_seconds{analyzer="Shodan_Search"} 1710201600`,
+	} {
+		if !strings.Contains(metrics, line+"\n") {
+			t.Errorf("Expected the metric %s in %s", line, metrics)
+		}
+	}
+	if strings.Contains(metrics, `threatmatrix_analyzer_quota_exhaustion_timestamp_seconds{analyzer="VirusTotal_v3_Get_Observable"}`) {
+		t.Errorf("Expected no exhaustion metric for a quota lasting the month")
+	}
+}
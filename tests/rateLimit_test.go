@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestRateLimit(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		RateLimit: &gothreatmatrix.RateLimit{RequestsPerSecond: 20, Burst: 2},
+	})
+	defer closeServer()
+	apiHandler.Handle("/api/jobs/1", serverHandler(t, TestData{Data: `{"id": 1}`}, "GET"))
+	apiHandler.Handle("/api/tags/1", serverHandler(t, TestData{Data: `{"id": 1}`}, "GET"))
+	ctx := context.Background()
+	start := time.Now()
+	// * the burst is shared by the services, then one request every 50ms
+	for index := 0; index < 6; index++ {
+		var err error
+		if index%2 == 0 {
+			_, err = client.JobService.Get(ctx, 1)
+		} else {
+			_, err = client.TagService.Get(ctx, 1)
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("Expected the requests to be limited, they took %v", elapsed)
+	}
+
+	cancelCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	client.JobService.Get(ctx, 1)
+	client.JobService.Get(ctx, 1)
+	if _, err := client.JobService.Get(cancelCtx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the wait to end with the context, got %v", err)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		RateLimit: &gothreatmatrix.RateLimit{},
+	})
+	defer closeServer()
+	apiHandler.HandleFunc("/api/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": 1}`))
+	})
+	start := time.Now()
+	for index := 0; index < 20; index++ {
+		if _, err := client.JobService.Get(context.Background(), 1); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected no limit, the requests took %v", elapsed)
+	}
+}
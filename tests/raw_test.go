@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestClientDo(t *testing.T) {
+	type pluginState struct {
+		Name     string `json:"name"`
+		Disabled bool   `json:"disabled"`
+	}
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["get"] = TestData{
+		Input:      nil,
+		Data:       `{"name": "Classic_DNS", "disabled": false}`,
+		StatusCode: http.StatusOK,
+		Want:       &pluginState{Name: "Classic_DNS"},
+	}
+	testCases["jsonBody"] = TestData{
+		Input:      pluginState{Name: "Classic_DNS", Disabled: true},
+		Data:       `{"name": "Classic_DNS", "disabled": true}`,
+		StatusCode: http.StatusCreated,
+		Want:       &pluginState{Name: "Classic_DNS", Disabled: true},
+	}
+	testCases["noContent"] = TestData{
+		Input:      []byte(`{"name": "Classic_DNS"}`),
+		StatusCode: http.StatusNoContent,
+		Want:       &pluginState{},
+	}
+	testCases["error"] = TestData{
+		Input:      nil,
+		Data:       `{"detail": "Not found."}`,
+		StatusCode: http.StatusNotFound,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusNotFound,
+			Message:    `{"detail": "Not found."}`,
+			Detail:     "Not found.",
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			method := "GET"
+			if testCase.Input != nil {
+				method = "POST"
+			}
+			apiHandler.HandleFunc("/api/plugin-config/Classic_DNS", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, method)
+				testWantData(t, "token test-token", r.Header.Get("Authorization"))
+				testWantData(t, "analyzer", r.URL.Query().Get("type"))
+				if testCase.Input != nil {
+					sent := pluginState{}
+					if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+						t.Errorf("Could not parse request body: %v", err)
+					}
+					testWantData(t, "Classic_DNS", sent.Name)
+				}
+				w.Header().Set("X-Total-Count", "1")
+				w.WriteHeader(testCase.StatusCode)
+				w.Write([]byte(testCase.Data))
+			})
+			out := &pluginState{}
+			response, err := client.Do(context.Background(), method, "api/plugin-config/Classic_DNS?type=analyzer", testCase.Input, out)
+			if err != nil {
+				testError(t, testCase, err)
+				return
+			}
+			testWantData(t, testCase.StatusCode, response.StatusCode)
+			testWantData(t, "1", response.Header.Get("X-Total-Count"))
+			testWantData(t, testCase.Data, string(response.Data))
+			testWantData(t, testCase.Want, out)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestParseExternalReference(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["siem"] = TestData{
+		Input: "ref:siem:ALERT-4821",
+		Want:  gothreatmatrix.ExternalReference{System: "siem", ID: "ALERT-4821"},
+	}
+	testCases["idWithColon"] = TestData{
+		Input: "ref:soar:case:17",
+		Want:  gothreatmatrix.ExternalReference{System: "soar", ID: "case:17"},
+	}
+	testCases["plainTag"] = TestData{
+		Input: "phishing",
+		Want:  gothreatmatrix.ExternalReference{},
+	}
+	testCases["noId"] = TestData{
+		Input: "ref:jira:",
+		Want:  gothreatmatrix.ExternalReference{},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			reference, ok := gothreatmatrix.ParseExternalReference(testCase.Input.(string))
+			testWantData(t, testCase.Want, reference)
+			testWantData(t, testCase.Want != gothreatmatrix.ExternalReference{}, ok)
+		})
+	}
+}
+
+func TestAddReferences(t *testing.T) {
+	params := gothreatmatrix.BasicAnalysisParams{TagsLabels: []string{"phishing"}}
+	if err := params.AddReferences(gothreatmatrix.ExternalReference{System: "SIEM", ID: "ALERT-4821"}, gothreatmatrix.ExternalReference{System: "jira", ID: "SOC-123"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"phishing", "ref:siem:ALERT-4821", "ref:jira:SOC-123"}, params.TagsLabels)
+	invalid := []gothreatmatrix.ExternalReference{
+		{System: "siem"},
+		{System: "a:b", ID: "1"},
+		{System: "ticket", ID: strings.Repeat("x", gothreatmatrix.MAX_TAG_LABEL_LENGTH)},
+	}
+	for _, reference := range invalid {
+		if err := params.AddReferences(reference); !errors.Is(err, gothreatmatrix.ErrInvalidReference) {
+			t.Fatalf("Expected ErrInvalidReference for %v, got: %v", reference, err)
+		}
+	}
+}
+
+func TestJobServiceFindByReference(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.BASE_JOB_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testWantData(t, "ref:soar:case-17", r.URL.Query().Get("tags"))
+		w.Write([]byte(`{"count": 1, "total_pages": 1, "results": [{"id": 3, "tags": [{"id": 1, "label": "c2"}, {"id": 2, "label": "ref:soar:case-17"}]}]}`))
+	})
+	jobList, err := client.JobService.FindByReference(context.Background(), gothreatmatrix.ExternalReference{System: "soar", ID: "case-17"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []gothreatmatrix.ExternalReference{{System: "soar", ID: "case-17"}}, jobList.Results[0].References())
+	// * the references are sent along with the analysis
+	params := gothreatmatrix.ObservableAnalysisParams{ObservableName: "8.8.8.8", ObservableClassification: "ip"}
+	params.AddReferences(gothreatmatrix.ExternalReference{System: "siem", ID: "ALERT-4821"})
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		sent := gothreatmatrix.ObservableAnalysisParams{}
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Errorf("Could not parse request body: %v", err)
+		}
+		testWantData(t, []string{"ref:siem:ALERT-4821"}, sent.TagsLabels)
+		w.Write([]byte(`{"job_id": 4, "status": "accepted"}`))
+	})
+	if _, err := client.CreateObservableAnalysis(context.Background(), &params); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestJobServiceReplay(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	pages := map[string]string{
+		"1": `{"count": 3, "total_pages": 2, "results": [{"id": 1, "status": "reported_without_fails"}, {"id": 2, "status": "running"}]}`,
+		"2": `{"count": 3, "total_pages": 2, "results": [{"id": 3, "status": "reported_with_fails"}]}`,
+	}
+	apiHandler.HandleFunc(constants.BASE_JOB_URL, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		testWantData(t, "2023-01-01T00:00:00Z", query.Get("received_request_time__gte"))
+		testWantData(t, "received_request_time,id", query.Get("ordering"))
+		testWantData(t, "2", query.Get("page_size"))
+		testWantData(t, "true", query.Get("is_sample"))
+		w.Write([]byte(pages[query.Get("page")]))
+	})
+	for _, jobId := range []int{1, 3} {
+		jobJson := fmt.Sprintf(`{"id": %d, "status": "reported_without_fails", "received_request_time": "2023-01-0%dT10:00:00Z"}`, jobId, jobId)
+		apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, jobId), serverHandler(t, TestData{Data: jobJson}, "GET"))
+	}
+	written := []int{}
+	sink := gothreatmatrix.JobSinkFunc(func(ctx context.Context, job *gothreatmatrix.Job) error {
+		if job.ID == 3 {
+			return errors.New("consumer unavailable")
+		}
+		written = append(written, job.ID)
+		return nil
+	})
+	result, err := client.JobService.Replay(context.Background(), &gothreatmatrix.ReplayOptions{
+		From:     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Filter:   gothreatmatrix.NewJobFilter().IsSample(true),
+		PageSize: 2,
+	}, sink)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []int{1}, written)
+	testWantData(t, 1, result.Replayed)
+	testWantData(t, 1, result.Skipped)
+	testWantData(t, 1, len(result.Failures))
+	testWantData(t, uint64(3), result.Failures[0].JobID)
+	testWantData(t, time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC), result.LastReceived)
+}
+
+func TestWebhookSink(t *testing.T) {
+	var gotEvent gothreatmatrix.JobEvent
+	var gotSignature, gotTimestamp, gotAuthorization string
+	var gotBody []byte
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		gotSignature = r.Header.Get("X-ThreatMatrix-Signature")
+		gotTimestamp = r.Header.Get("X-ThreatMatrix-Timestamp")
+		gotAuthorization = r.Header.Get("Authorization")
+		json.Unmarshal(gotBody, &gotEvent)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer webhook.Close()
+	sink := &gothreatmatrix.WebhookSink{Url: webhook.URL, Secret: "secret", Headers: map[string]string{"Authorization": "Bearer consumer"}}
+	job := &gothreatmatrix.Job{}
+	job.ID = 4
+	if err := sink.WriteJob(context.Background(), job); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// * the signature covers the timestamp, so that the event cannot be replayed later
+	signature := hmac.New(sha256.New, []byte("secret"))
+	signature.Write([]byte(gotTimestamp + "."))
+	signature.Write(gotBody)
+	testWantData(t, hex.EncodeToString(signature.Sum(nil)), gotSignature)
+	if signedAt, err := strconv.ParseInt(gotTimestamp, 10, 64); err != nil || time.Since(time.Unix(signedAt, 0)) > time.Minute {
+		t.Errorf("Unexpected timestamp %q", gotTimestamp)
+	}
+	testWantData(t, "Bearer consumer", gotAuthorization)
+	testWantData(t, gothreatmatrix.JOB_COMPLETED_EVENT, gotEvent.Event)
+	testWantData(t, 4, gotEvent.Job.ID)
+}
+
+func TestJSONLinesSink(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	sink := gothreatmatrix.NewJSONLinesSink(buffer)
+	for _, jobId := range []int{1, 2} {
+		job := &gothreatmatrix.Job{}
+		job.ID = jobId
+		if err := sink.WriteJob(context.Background(), job); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+	testWantData(t, 2, len(lines))
+	event := gothreatmatrix.JobEvent{}
+	if err := json.Unmarshal(lines[1], &event); err != nil {
+		t.Fatalf("Could not parse the line: %v", err)
+	}
+	testWantData(t, 2, event.Job.ID)
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// readArchive returns the files of a reports archive in the format, by name.
+func readArchive(t *testing.T, data []byte, format string) map[string][]byte {
+	t.Helper()
+	files := map[string][]byte{}
+	if format == gothreatmatrix.ARCHIVE_ZIP {
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("Could not read the archive: %v", err)
+		}
+		for _, file := range archive.File {
+			reader, err := file.Open()
+			if err != nil {
+				t.Fatalf("Could not open %s: %v", file.Name, err)
+			}
+			files[file.Name], _ = ioutil.ReadAll(reader)
+			reader.Close()
+		}
+		return files
+	}
+	var reader io.Reader = bytes.NewReader(data)
+	if format == gothreatmatrix.ARCHIVE_TAR_GZ {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			t.Fatalf("Could not read the archive: %v", err)
+		}
+		reader = gzipReader
+	}
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatalf("Could not read the archive: %v", err)
+		}
+		files[header.Name], _ = ioutil.ReadAll(archive)
+	}
+}
+
+func TestJobServiceDownloadReports(t *testing.T) {
+	jobJson := `{"id": 1, "status": "reported_without_fails", "finished_analysis_time": "2024-05-02T09:30:00Z",
+		"analyzer_reports": [{"name": "Classic_DNS", "status": "SUCCESS", "report": {"resolutions": ["8.8.8.8"]}, "end_time": "2024-05-02T09:29:00Z"}],
+		"connector_reports": [{"name": "MISP", "status": "FAILED", "errors": ["not configured"]}]}`
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), serverHandler(t, TestData{Data: jobJson}, "GET"))
+	for _, format := range []string{gothreatmatrix.ARCHIVE_ZIP, gothreatmatrix.ARCHIVE_TAR, gothreatmatrix.ARCHIVE_TAR_GZ} {
+		// *Subtest
+		t.Run(format, func(t *testing.T) {
+			archive := &bytes.Buffer{}
+			if err := client.JobService.DownloadReports(context.Background(), 1, format, archive); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			files := readArchive(t, archive.Bytes(), format)
+			testWantData(t, 3, len(files))
+			report := gothreatmatrix.Report{}
+			if err := json.Unmarshal(files["analyzers/Classic_DNS.json"], &report); err != nil {
+				t.Fatalf("Could not decode the report: %v", err)
+			}
+			testWantData(t, []interface{}{"8.8.8.8"}, report.Report["resolutions"])
+			if err := json.Unmarshal(files["connectors/MISP.json"], &report); err != nil {
+				t.Fatalf("Could not decode the report: %v", err)
+			}
+			testWantData(t, []string{"not configured"}, report.Errors)
+			if _, ok := files[gothreatmatrix.BUNDLE_MANIFEST_NAME]; !ok {
+				t.Errorf("Expected a manifest")
+			}
+			if format == gothreatmatrix.ARCHIVE_ZIP {
+				if err := gothreatmatrix.VerifyJobBundle(bytes.NewReader(archive.Bytes()), int64(archive.Len())); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			}
+		})
+	}
+	if err := client.JobService.DownloadReports(context.Background(), 1, "rar", &bytes.Buffer{}); !errors.Is(err, gothreatmatrix.ErrInvalidArchiveFormat) {
+		t.Errorf("Expected ErrInvalidArchiveFormat, got: %v", err)
+	}
+}
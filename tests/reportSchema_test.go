@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+type classicDnsReport struct {
+	Observable  string   `json:"observable"`
+	Resolutions []string `json:"resolutions"`
+}
+
+func (report *classicDnsReport) Validate() error {
+	if report.Observable == "" {
+		return errors.New("missing observable")
+	}
+	return nil
+}
+
+func TestReportSchemaRegistryDecode(t *testing.T) {
+	registry := gothreatmatrix.NewReportSchemaRegistry()
+	registry.Register("Classic_DNS", classicDnsReport{})
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["registered"] = TestData{
+		Input: gothreatmatrix.Report{
+			Name:   "Classic_DNS",
+			Report: map[string]interface{}{"observable": "8.8.8.8", "resolutions": []interface{}{"dns.google"}},
+		},
+		Want: &classicDnsReport{
+			Observable:  "8.8.8.8",
+			Resolutions: []string{"dns.google"},
+		},
+	}
+	testCases["unknown"] = TestData{
+		Input: gothreatmatrix.Report{
+			Name:   "Shodan",
+			Report: map[string]interface{}{"ports": []interface{}{float64(53)}},
+		},
+		Want: json.RawMessage(`{"ports":[53]}`),
+	}
+	testCases["invalid"] = TestData{
+		Input: gothreatmatrix.Report{
+			Name:   "Classic_DNS",
+			Report: map[string]interface{}{"resolutions": []interface{}{}},
+		},
+		Want: nil,
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			report, ok := testCase.Input.(gothreatmatrix.Report)
+			if !ok {
+				t.Fatalf("Casting failed!")
+			}
+			decoded, err := registry.Decode(&report)
+			if testCase.Want == nil {
+				if err == nil {
+					t.Fatalf("Expected a validation error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.Want, decoded)
+		})
+	}
+}
+
+func TestReportDecodeKnown(t *testing.T) {
+	// *table test case
+	testCases := make(map[string]TestData)
+	testCases["virustotal"] = TestData{
+		Input: gothreatmatrix.Report{
+			Name: "VirusTotal_v3_Get_Observable",
+			Report: map[string]interface{}{"data": map[string]interface{}{
+				"id":         "8.8.8.8",
+				"attributes": map[string]interface{}{"last_analysis_stats": map[string]interface{}{"malicious": float64(2), "harmless": float64(60)}},
+			}},
+		},
+		Want: 2,
+	}
+	testCases["abuseipdb"] = TestData{
+		Input: gothreatmatrix.Report{
+			Name:   "AbuseIPDB",
+			Report: map[string]interface{}{"data": map[string]interface{}{"ipAddress": "1.2.3.4", "abuseConfidenceScore": float64(87)}},
+		},
+		Want: 87,
+	}
+	testCases["unknown"] = TestData{
+		Input: gothreatmatrix.Report{
+			Name:   "Classic_DNS",
+			Report: map[string]interface{}{"observable": "dns.google"},
+		},
+		Want: nil,
+	}
+	for name, testCase := range testCases {
+		//* Subtest
+		t.Run(name, func(t *testing.T) {
+			report, ok := testCase.Input.(gothreatmatrix.Report)
+			if !ok {
+				t.Fatalf("Casting failed!")
+			}
+			decoded, known, err := report.DecodeKnown()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.Want != nil, known)
+			switch decodedReport := decoded.(type) {
+			case *gothreatmatrix.VirusTotalReport:
+				testWantData(t, testCase.Want, decodedReport.Data.Attributes.LastAnalysisStats.Malicious)
+			case *gothreatmatrix.AbuseIPDBReport:
+				testWantData(t, testCase.Want, decodedReport.Data.AbuseConfidenceScore)
+			}
+		})
+	}
+}
+
+func TestReportDecodeInto(t *testing.T) {
+	report := gothreatmatrix.Report{
+		Name:   "Classic_DNS",
+		Report: map[string]interface{}{"observable": "dns.google", "resolutions": []interface{}{"8.8.8.8"}},
+	}
+	decoded := classicDnsReport{}
+	if err := report.DecodeInto(&decoded); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, classicDnsReport{Observable: "dns.google", Resolutions: []string{"8.8.8.8"}}, decoded)
+	report.Report = map[string]interface{}{"resolutions": []interface{}{}}
+	if err := report.DecodeInto(&classicDnsReport{}); err == nil {
+		t.Errorf("Expected a validation error")
+	}
+}
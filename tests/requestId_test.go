@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestRequestIDs(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	// *table test cases
+	testCases := make(map[string]TestData)
+	testCases["fromContext"] = TestData{
+		Input: gothreatmatrix.WithRequestID(context.Background(), "incoming-42"),
+		Want:  "incoming-42",
+	}
+	testCases["echoedByProxy"] = TestData{
+		Input: context.Background(),
+		Data:  "proxy-7",
+		Want:  "proxy-7",
+	}
+	testCases["generated"] = TestData{
+		Input: context.Background(),
+		Want:  uuidPattern,
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+				GenerateRequestIDs: true,
+				CorrelationHeaders: map[string]string{"X-Tenant": "acme"},
+			})
+			defer closeServer()
+			sent := ""
+			apiHandler.HandleFunc(constants.BASE_TAG_URL+"/1", func(w http.ResponseWriter, r *http.Request) {
+				sent = r.Header.Get(gothreatmatrix.REQUEST_ID_HEADER)
+				testWantData(t, "acme", r.Header.Get("X-Tenant"))
+				if testCase.Data != "" {
+					w.Header().Set(gothreatmatrix.REQUEST_ID_HEADER, testCase.Data)
+				}
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"detail": "Not found."}`))
+			})
+			info := &gothreatmatrix.ResponseInfo{}
+			ctx := gothreatmatrix.WithResponseInfo(testCase.Input.(context.Context), info)
+			_, err := client.TagService.Get(ctx, 1)
+			var threatMatrixError *gothreatmatrix.ThreatMatrixError
+			if !errors.As(err, &threatMatrixError) {
+				t.Fatalf("Expected a ThreatMatrixError, got %v", err)
+			}
+			switch want := testCase.Want.(type) {
+			case string:
+				testWantData(t, want, threatMatrixError.RequestID)
+			case *regexp.Regexp:
+				if !want.MatchString(threatMatrixError.RequestID) || threatMatrixError.RequestID != sent {
+					t.Errorf("Expected the generated request ID %q to be a UUID, got %q", sent, threatMatrixError.RequestID)
+				}
+			}
+			testWantData(t, threatMatrixError.RequestID, info.RequestID)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// recordingLogger is a gothreatmatrix.Logger keeping the messages it is given.
+type recordingLogger struct {
+	mutex    sync.Mutex
+	messages []string
+	logs     []string
+}
+
+func (logger *recordingLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.messages = append(logger.messages, msg)
+	logger.logs = append(logger.logs, fmt.Sprint(append([]interface{}{msg}, args...)...))
+}
+
+func TestRequestLogger(t *testing.T) {
+	logger := &recordingLogger{}
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		Logger:       logger,
+		RetryOptions: &gothreatmatrix.RetryOptions{MaxRetries: 1, BaseDelay: time.Millisecond},
+	})
+	defer closeServer()
+	attempts := 0
+	apiHandler.HandleFunc("/api/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"id": 1}`))
+	})
+	if _, err := client.JobService.Get(context.Background(), 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{
+		"threatmatrix request",
+		"threatmatrix response",
+		"threatmatrix request retried",
+		"threatmatrix request",
+		"threatmatrix response",
+	}, logger.messages)
+	logs := strings.Join(logger.logs, "\n")
+	if strings.Contains(logs, "test-token") {
+		t.Errorf("Expected the token to be redacted, got %s", logs)
+	}
+	for _, want := range []string{"token " + gothreatmatrix.REDACTED, "status503", "status200", "response_bytes9"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("Expected the logs to contain %q, got %s", want, logs)
+		}
+	}
+}
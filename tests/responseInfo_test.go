@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestResponseInfo(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		RetryOptions: &gothreatmatrix.RetryOptions{MaxRetries: 1, BaseDelay: time.Millisecond},
+	})
+	defer closeServer()
+	attempts := 0
+	apiHandler.HandleFunc("/api/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Deprecation", "true")
+		w.Write([]byte(`{"id": 1}`))
+	})
+	apiHandler.Handle("/api/jobs/2", serverHandler(t, TestData{StatusCode: http.StatusNotFound, Data: `{"detail": "Not found."}`}, "GET"))
+
+	info := &gothreatmatrix.ResponseInfo{}
+	ctx := gothreatmatrix.WithResponseInfo(context.Background(), info)
+	if _, err := client.JobService.Get(ctx, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, http.StatusOK, info.StatusCode)
+	testWantData(t, "true", info.Header.Get("Deprecation"))
+	testWantData(t, 2, info.Attempts)
+
+	info = &gothreatmatrix.ResponseInfo{}
+	if _, err := client.JobService.Get(gothreatmatrix.WithResponseInfo(context.Background(), info), 2); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	testWantData(t, http.StatusNotFound, info.StatusCode)
+	testWantData(t, 1, info.Attempts)
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestResultViewer(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 1), serverHandler(t, TestData{
+		Data: `{"id": 1, "status": "reported_without_fails", "observable_name": "<script>alert(1)</script>.example.com", "observable_classification": "domain", "analyzer_reports": [{"name": "Classic_DNS", "status": "SUCCESS"}]}`,
+	}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 9), serverHandler(t, TestData{
+		Data:       `{"detail": "Not found."}`,
+		StatusCode: http.StatusNotFound,
+	}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 2), serverHandler(t, TestData{
+		Data:       `{"detail": "server error"}`,
+		StatusCode: http.StatusInternalServerError,
+	}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_INVESTIGATION_URL, 3), serverHandler(t, TestData{
+		Data: `{"id": 3, "name": "phishing campaign", "status": "running", "tags": ["phishing"], "jobs": [1]}`,
+	}, "GET"))
+	viewer := http.StripPrefix("/viewer", client.NewResultViewer(&gothreatmatrix.ResultViewerOptions{
+		Authorize: func(r *http.Request) bool {
+			return r.Header.Get("X-User") != ""
+		},
+	}))
+
+	// *table test case
+	testCases := map[string]struct {
+		method       string
+		path         string
+		anonymous    bool
+		wantStatus   int
+		wantContains []string
+	}{
+		"job": {
+			path:         "/viewer/jobs/1",
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"<h1>Job 1</h1>", "Classic_DNS", "&lt;script&gt;alert(1)&lt;/script&gt;.example.com"},
+		},
+		"investigation": {
+			path:         "/viewer/investigations/3",
+			wantStatus:   http.StatusOK,
+			wantContains: []string{"Investigation: phishing campaign", `<a href="../jobs/1">1</a>`, "phishing"},
+		},
+		"jobNotFound":   {path: "/viewer/jobs/9", wantStatus: http.StatusNotFound},
+		"serverError":   {path: "/viewer/jobs/2", wantStatus: http.StatusBadGateway},
+		"unknownPath":   {path: "/viewer/tags/1", wantStatus: http.StatusNotFound},
+		"invalidID":     {path: "/viewer/jobs/one", wantStatus: http.StatusNotFound},
+		"notAllowed":    {method: "POST", path: "/viewer/jobs/1", wantStatus: http.StatusMethodNotAllowed},
+		"notAuthorized": {path: "/viewer/jobs/1", anonymous: true, wantStatus: http.StatusForbidden},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			method := testCase.method
+			if method == "" {
+				method = "GET"
+			}
+			request := httptest.NewRequest(method, testCase.path, nil)
+			if !testCase.anonymous {
+				request.Header.Set("X-User", "analyst")
+			}
+			recorder := httptest.NewRecorder()
+			viewer.ServeHTTP(recorder, request)
+			testWantData(t, testCase.wantStatus, recorder.Code)
+			if testCase.wantStatus != http.StatusOK {
+				return
+			}
+			testWantData(t, gothreatmatrix.VIEWER_CONTENT_SECURITY_POLICY, recorder.Header().Get("Content-Security-Policy"))
+			body := recorder.Body.String()
+			for _, want := range testCase.wantContains {
+				if !strings.Contains(body, want) {
+					t.Errorf("Expected the page to contain %q, got:\n%s", want, body)
+				}
+			}
+		})
+	}
+}
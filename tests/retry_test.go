@@ -0,0 +1,362 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestIsIdempotent(t *testing.T) {
+	testCases := map[string]struct {
+		method string
+		path   string
+		want   bool
+	}{
+		"get":          {"GET", "/api/jobs/1", true},
+		"delete":       {"DELETE", "/api/jobs/1", true},
+		"availability": {"POST", constants.ASK_ANALYSIS_AVAILABILITY_URL, true},
+		"submission":   {"POST", constants.ANALYZE_OBSERVABLE_URL, false},
+		"fileUpload":   {"POST", constants.ANALYZE_FILE_URL, false},
+		"killAnalyzer": {"PATCH", "/api/jobs/1/analyzer/Shodan/kill", false},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			testWantData(t, testCase.want, gothreatmatrix.IsIdempotent(testCase.method, testCase.path))
+		})
+	}
+}
+
+// flakyHandler fails with a 503 the first failures times, then answers with data.
+func flakyHandler(failures int, data string, requests *[]*http.Request, bodies *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		*requests = append(*requests, r)
+		*bodies = append(*bodies, string(body))
+		if len(*requests) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(data))
+	}
+}
+
+func TestRetryOptions(t *testing.T) {
+	retryOptions := &gothreatmatrix.RetryOptions{MaxRetries: 2, BaseDelay: time.Millisecond}
+	jobJson := `{"id": 1, "status": "running"}`
+	analysisJson := `{"job_id": 1, "status": "accepted"}`
+
+	t.Run("idempotent", func(t *testing.T) {
+		client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{RetryOptions: retryOptions})
+		defer closeServer()
+		requests, bodies := []*http.Request{}, []string{}
+		apiHandler.Handle("/api/jobs/1", flakyHandler(2, jobJson, &requests, &bodies))
+		job, err := client.JobService.Get(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testWantData(t, 1, job.ID)
+		testWantData(t, 3, len(requests))
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{RetryOptions: retryOptions})
+		defer closeServer()
+		requests, bodies := []*http.Request{}, []string{}
+		apiHandler.Handle("/api/jobs/1", flakyHandler(5, jobJson, &requests, &bodies))
+		if _, err := client.JobService.Get(context.Background(), 1); err == nil {
+			t.Fatalf("Expected an error")
+		}
+		testWantData(t, 3, len(requests))
+	})
+
+	t.Run("submissionWithoutKey", func(t *testing.T) {
+		client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{RetryOptions: retryOptions})
+		defer closeServer()
+		requests, bodies := []*http.Request{}, []string{}
+		apiHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, flakyHandler(1, analysisJson, &requests, &bodies))
+		_, err := client.CreateObservableAnalysis(context.Background(), &gothreatmatrix.ObservableAnalysisParams{
+			ObservableName:           "dns.google",
+			ObservableClassification: "domain",
+		})
+		if err == nil {
+			t.Fatalf("Expected an error")
+		}
+		testWantData(t, 1, len(requests))
+	})
+
+	t.Run("submissionWithKey", func(t *testing.T) {
+		client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{RetryOptions: retryOptions})
+		defer closeServer()
+		requests, bodies := []*http.Request{}, []string{}
+		apiHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, flakyHandler(1, analysisJson, &requests, &bodies))
+		_, err := client.CreateObservableAnalysis(context.Background(), &gothreatmatrix.ObservableAnalysisParams{
+			BasicAnalysisParams:      gothreatmatrix.BasicAnalysisParams{IdempotencyKey: "submission-1"},
+			ObservableName:           "dns.google",
+			ObservableClassification: "domain",
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testWantData(t, 2, len(requests))
+		testWantData(t, "submission-1", requests[1].Header.Get(gothreatmatrix.IDEMPOTENCY_KEY_HEADER))
+		testWantData(t, bodies[0], bodies[1])
+	})
+
+	t.Run("submissionWithGeneratedKey", func(t *testing.T) {
+		client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+			RetryOptions:            retryOptions,
+			GenerateIdempotencyKeys: true,
+		})
+		defer closeServer()
+		requests, bodies := []*http.Request{}, []string{}
+		apiHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, flakyHandler(1, analysisJson, &requests, &bodies))
+		params := &gothreatmatrix.ObservableAnalysisParams{
+			ObservableName:           "dns.google",
+			ObservableClassification: "domain",
+		}
+		if _, err := client.CreateObservableAnalysis(context.Background(), params); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := client.CreateObservableAnalysis(context.Background(), params); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testWantData(t, 3, len(requests))
+		key := requests[0].Header.Get(gothreatmatrix.IDEMPOTENCY_KEY_HEADER)
+		testWantData(t, 32, len(key))
+		testWantData(t, key, requests[1].Header.Get(gothreatmatrix.IDEMPOTENCY_KEY_HEADER))
+		if requests[2].Header.Get(gothreatmatrix.IDEMPOTENCY_KEY_HEADER) == key {
+			t.Errorf("Expected a new key for the second submission")
+		}
+		testWantData(t, "", params.IdempotencyKey)
+	})
+
+	t.Run("fileUploadWithKey", func(t *testing.T) {
+		client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+			RetryOptions:         retryOptions,
+			UploadBytesPerSecond: 1 << 20,
+		})
+		defer closeServer()
+		requests, bodies := []*http.Request{}, []string{}
+		apiHandler.Handle(constants.ANALYZE_FILE_URL, flakyHandler(1, analysisJson, &requests, &bodies))
+		file, err := os.Open("./testFiles/fileForAnalysis.txt")
+		if err != nil {
+			t.Fatalf("Could not open the file: %v", err)
+		}
+		defer file.Close()
+		_, err = client.CreateFileAnalysis(context.Background(), &gothreatmatrix.FileAnalysisParams{
+			BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{IdempotencyKey: "upload-1"},
+			File:                file,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testWantData(t, 2, len(requests))
+		testWantData(t, bodies[0], bodies[1])
+		testWantData(t, true, strings.Contains(bodies[1], "fileForAnalysis.txt"))
+	})
+}
+
+// statusHandler fails with the status code and headers the first failures times, then answers with data.
+func statusHandler(failures int, statusCode int, headers map[string]string, data string, requests *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if *requests <= failures {
+			for key, value := range headers {
+				w.Header().Set(key, value)
+			}
+			w.WriteHeader(statusCode)
+			return
+		}
+		w.Write([]byte(data))
+	}
+}
+
+func TestDedupeInFlightSubmissions(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{DedupeInFlightSubmissions: true})
+	defer closeServer()
+	var mutex sync.Mutex
+	requests := 0
+	arrived, release := make(chan struct{}), make(chan struct{})
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		first := requests == 1
+		mutex.Unlock()
+		if first {
+			close(arrived)
+			<-release
+		}
+		w.Write([]byte(`{"job_id": 1, "status": "accepted"}`))
+	})
+	params := &gothreatmatrix.ObservableAnalysisParams{
+		ObservableName:           "dns.google",
+		ObservableClassification: "domain",
+	}
+	responses := make(chan *gothreatmatrix.AnalysisResponse, 4)
+	errs := make(chan error, 4)
+	submit := func(params *gothreatmatrix.ObservableAnalysisParams) {
+		response, err := client.CreateObservableAnalysis(context.Background(), params)
+		responses <- response
+		errs <- err
+	}
+	go submit(params)
+	<-arrived
+	for index := 0; index < 3; index++ {
+		go submit(params)
+	}
+	// * letting the identical submissions join the one in flight
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	for index := 0; index < 4; index++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testWantData(t, 1, (<-responses).JobID)
+	}
+	testWantData(t, 1, requests)
+
+	if _, err := client.CreateObservableAnalysis(context.Background(), &gothreatmatrix.ObservableAnalysisParams{
+		ObservableName:           "8.8.8.8",
+		ObservableClassification: "ip",
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.CreateObservableAnalysis(context.Background(), params); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 3, requests)
+}
+
+func TestDedupeInFlightSubmissionsLeaderCanceled(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{DedupeInFlightSubmissions: true})
+	defer closeServer()
+	var mutex sync.Mutex
+	requests := 0
+	arrived := make(chan struct{})
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		first := requests == 1
+		mutex.Unlock()
+		if first {
+			// * the body is read for the disconnect of the canceled client to be noticed
+			ioutil.ReadAll(r.Body)
+			close(arrived)
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(`{"job_id": 2, "status": "accepted"}`))
+	})
+	params := &gothreatmatrix.ObservableAnalysisParams{
+		ObservableName:           "dns.google",
+		ObservableClassification: "domain",
+	}
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := client.CreateObservableAnalysis(leaderCtx, params)
+		leaderErr <- err
+	}()
+	<-arrived
+	responses := make(chan *gothreatmatrix.AnalysisResponse, 2)
+	errs := make(chan error, 2)
+	for index := 0; index < 2; index++ {
+		go func() {
+			response, err := client.CreateObservableAnalysis(context.Background(), params)
+			responses <- response
+			errs <- err
+		}()
+	}
+	// * letting the identical submissions join the one in flight
+	time.Sleep(50 * time.Millisecond)
+	cancelLeader()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	// * the waiting callers are not handed the cancellation of the leader: one of them submits again
+	for index := 0; index < 2; index++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testWantData(t, 2, (<-responses).JobID)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	testWantData(t, 2, requests)
+}
+
+func TestRetryOptionsDelays(t *testing.T) {
+	jobJson := `{"id": 1, "status": "running"}`
+	// * table test cases
+	testCases := map[string]struct {
+		retryOptions gothreatmatrix.RetryOptions
+		statusCode   int
+		headers      map[string]string
+		wantRequests int
+		wantError    bool
+	}{
+		"serverError":           {gothreatmatrix.RetryOptions{MaxRetries: 2, BaseDelay: time.Millisecond}, http.StatusInternalServerError, nil, 3, false},
+		"notImplemented":        {gothreatmatrix.RetryOptions{MaxRetries: 2, BaseDelay: time.Millisecond}, http.StatusNotImplemented, nil, 1, true},
+		"maxDelayCapsBaseDelay": {gothreatmatrix.RetryOptions{MaxRetries: 2, BaseDelay: time.Hour, MaxDelay: time.Millisecond}, http.StatusServiceUnavailable, nil, 3, false},
+		"retryAfterSeconds":     {gothreatmatrix.RetryOptions{MaxRetries: 2, BaseDelay: time.Hour}, http.StatusTooManyRequests, map[string]string{"Retry-After": "0"}, 3, false},
+		"retryAfterPastDate":    {gothreatmatrix.RetryOptions{MaxRetries: 2, BaseDelay: time.Hour}, http.StatusTooManyRequests, map[string]string{"Retry-After": "Wed, 21 Oct 2015 07:28:00 GMT"}, 3, false},
+		"retryAfterAboveLimit":  {gothreatmatrix.RetryOptions{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Second}, http.StatusTooManyRequests, map[string]string{"Retry-After": "120"}, 1, true},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			retryOptions := testCase.retryOptions
+			client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{RetryOptions: &retryOptions})
+			defer closeServer()
+			requests := 0
+			apiHandler.Handle("/api/jobs/1", statusHandler(2, testCase.statusCode, testCase.headers, jobJson, &requests))
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, err := client.JobService.Get(ctx, 1)
+			testWantData(t, testCase.wantError, err != nil)
+			testWantData(t, testCase.wantRequests, requests)
+		})
+	}
+}
+
+func TestRetryOptionsUnmarshalJSON(t *testing.T) {
+	testCases := map[string]struct {
+		data      string
+		want      gothreatmatrix.RetryOptions
+		wantError bool
+	}{
+		"durationStrings": {data: `{"max_retries": 3, "base_delay": "250ms", "max_delay": "2s"}`, want: gothreatmatrix.RetryOptions{MaxRetries: 3, BaseDelay: 250 * time.Millisecond, MaxDelay: 2 * time.Second}},
+		"nanoseconds":     {data: `{"max_retries": 1, "base_delay": 1000000}`, want: gothreatmatrix.RetryOptions{MaxRetries: 1, BaseDelay: time.Millisecond}},
+		"invalidDuration": {data: `{"base_delay": "soon"}`, wantError: true},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var retryOptions gothreatmatrix.RetryOptions
+			err := json.Unmarshal([]byte(testCase.data), &retryOptions)
+			testWantData(t, testCase.wantError, err != nil)
+			if !testCase.wantError {
+				testWantData(t, testCase.want, retryOptions)
+			}
+		})
+	}
+
+	// * the other options with a duration decode it the same way
+	var options gothreatmatrix.ThreatMatrixClientOptions
+	data := `{"circuit_breaker": {"cooldown": "30s"}, "hedging": {"delay": "200ms"}}`
+	if err := json.Unmarshal([]byte(data), &options); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 30*time.Second, options.CircuitBreaker.Cooldown)
+	testWantData(t, 200*time.Millisecond, options.Hedging.Delay)
+}
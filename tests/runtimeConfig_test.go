@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestBuildRuntimeConfig(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, TestData{
+		Data: `{"VirusTotal_v3_Get_File": {"name": "VirusTotal_v3_Get_File", "params": {
+			"max_tries": {"value": 10, "type": "int"},
+			"include_behaviour_summary": {"value": false, "type": "bool"},
+			"relationships_to_request": {"value": [], "type": "list"}}}}`,
+	}, "GET"))
+	apiHandler.Handle(constants.CONNECTOR_CONFIG_URL, serverHandler(t, TestData{
+		Data: `{"MISP": {"name": "MISP", "params": {"tlp": {"value": "white", "type": "str"}}}}`,
+	}, "GET"))
+	// * table test cases
+	testCases := map[string]struct {
+		build        func(runtimeConfig *gothreatmatrix.RuntimeConfig)
+		wantProblems []string
+		want         map[string]interface{}
+	}{
+		"valid": {
+			build: func(runtimeConfig *gothreatmatrix.RuntimeConfig) {
+				runtimeConfig.Analyzer("VirusTotal_v3_Get_File").Set("max_tries", 3).Set("relationships_to_request", []string{"contacted_urls"})
+				runtimeConfig.Connector("MISP").Set("tlp", "green")
+			},
+			want: map[string]interface{}{
+				"analyzers":  map[string]interface{}{"VirusTotal_v3_Get_File": map[string]interface{}{"max_tries": 3, "relationships_to_request": []string{"contacted_urls"}}},
+				"connectors": map[string]interface{}{"MISP": map[string]interface{}{"tlp": "green"}},
+			},
+		},
+		"invalid": {
+			build: func(runtimeConfig *gothreatmatrix.RuntimeConfig) {
+				runtimeConfig.Analyzer("VirusTotal_v3_Get_File").Set("max_tries", "3").Set("max_trys", 3).Set("include_behaviour_summary", true)
+				runtimeConfig.Analyzer("VirusTotal_v4").Set("max_tries", 3)
+				runtimeConfig.Connector("MISP").Set("tlp", 1)
+			},
+			wantProblems: []string{
+				"analyzers.VirusTotal_v3_Get_File.max_tries: expected int, got string",
+				"analyzers.VirusTotal_v3_Get_File.max_trys: unknown param",
+				"analyzers.VirusTotal_v4: unknown plugin",
+				"connectors.MISP.tlp: expected str, got int",
+			},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			runtimeConfig := gothreatmatrix.NewRuntimeConfig()
+			testCase.build(runtimeConfig)
+			runtimeConfiguration, err := client.BuildRuntimeConfig(context.Background(), runtimeConfig)
+			if testCase.wantProblems == nil {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				testWantData(t, testCase.want, runtimeConfiguration)
+				return
+			}
+			var runtimeConfigError *gothreatmatrix.RuntimeConfigError
+			if !errors.As(err, &runtimeConfigError) || !errors.Is(err, gothreatmatrix.ErrInvalidRuntimeConfig) {
+				t.Fatalf("Expected a RuntimeConfigError, got: %v", err)
+			}
+			problems := []string{}
+			for _, problem := range runtimeConfigError.Problems {
+				problems = append(problems, problem.String())
+			}
+			testWantData(t, testCase.wantProblems, problems)
+		})
+	}
+}
+
+func TestPluginConfigValidate(t *testing.T) {
+	analyzerConfigs := map[string]gothreatmatrix.AnalyzerConfig{}
+	configData := []byte(`{"Shodan_Search": {"name": "Shodan_Search", "secrets": {"api_key_name": {"env_var_key": "SHODAN_KEY", "description": "API key", "required": true, "type": "str"}}, "params": {
+		"shodan_analysis": {"type": "str", "description": "search or honeyscore", "required": true},
+		"timeout": {"type": "int", "description": "request timeout", "required": true, "default": 30},
+		"verbose": {"value": false, "type": "bool", "description": "verbose output"}}}}`)
+	if err := json.Unmarshal(configData, &analyzerConfigs); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	analyzerConfig := analyzerConfigs["Shodan_Search"]
+	testWantData(t, gothreatmatrix.Parameter{Type: "int", Description: "request timeout", Required: true, Default: float64(30)}, analyzerConfig.Params["timeout"])
+	testWantData(t, "str", analyzerConfig.Params["shodan_analysis"].TypeName())
+	testWantData(t, "str", analyzerConfig.Secrets["api_key_name"].Type)
+	// * table test cases
+	testCases := map[string]struct {
+		runtimeConfig map[string]interface{}
+		wantProblems  []string
+	}{
+		"valid": {
+			runtimeConfig: map[string]interface{}{"shodan_analysis": "search", "verbose": true},
+		},
+		"requiredNotSet": {
+			runtimeConfig: map[string]interface{}{"verbose": true},
+			wantProblems:  []string{"Shodan_Search.shodan_analysis: required param not set"},
+		},
+		"invalid": {
+			runtimeConfig: map[string]interface{}{"shodan_analysis": "search", "timeout": 2.5, "verbos": true},
+			wantProblems: []string{
+				"Shodan_Search.timeout: expected int, got float64",
+				"Shodan_Search.verbos: unknown param",
+			},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			err := analyzerConfig.Validate(testCase.runtimeConfig)
+			if testCase.wantProblems == nil {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			var runtimeConfigError *gothreatmatrix.RuntimeConfigError
+			if !errors.As(err, &runtimeConfigError) {
+				t.Fatalf("Expected a RuntimeConfigError, got: %v", err)
+			}
+			problems := []string{}
+			for _, problem := range runtimeConfigError.Problems {
+				problems = append(problems, problem.String())
+			}
+			testWantData(t, testCase.wantProblems, problems)
+		})
+	}
+}
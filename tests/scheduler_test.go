@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestSchedulerPersistence(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	submitted := []string{}
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		params := gothreatmatrix.ObservableAnalysisParams{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("Could not parse request body: %v", err)
+		}
+		if r.Header.Get("Idempotency-Key") == "" {
+			t.Errorf("Expected an Idempotency-Key header")
+		}
+		submitted = append(submitted, params.ObservableName)
+		w.Write([]byte(`{"job_id":7,"status":"accepted"}`))
+	})
+	schedulePath := filepath.Join(t.TempDir(), "schedule.json")
+	scheduler, err := client.NewScheduler(&gothreatmatrix.SchedulerOptions{Path: schedulePath})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	for observable, at := range map[string]time.Time{"8.8.8.8": now.Add(time.Hour), "1.1.1.1": now.Add(-time.Minute), "9.9.9.9": now.Add(2 * time.Hour)} {
+		params := &gothreatmatrix.ObservableAnalysisParams{ObservableName: observable}
+		params.Tlp = gothreatmatrix.AMBER
+		if _, err := scheduler.Schedule(params, at); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	cancelled := scheduler.Pending()[2]
+	if ok, err := scheduler.Cancel(cancelled.ID); !ok || err != nil {
+		t.Fatalf("Expected the analysis to be cancelled, got %v %v", ok, err)
+	}
+
+	// * a new process finds the pending analyses
+	scheduler, err = client.NewScheduler(&gothreatmatrix.SchedulerOptions{Path: schedulePath})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	pending := scheduler.Pending()
+	testWantData(t, 2, len(pending))
+	testWantData(t, gothreatmatrix.AMBER, pending[0].Params.Tlp)
+	due, err := scheduler.SubmitDue(context.Background(), now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 1, len(due))
+	testWantData(t, 7, due[0].JobID)
+	testWantData(t, []string{"1.1.1.1"}, submitted)
+	testWantData(t, "8.8.8.8", scheduler.Pending()[0].Params.ObservableName)
+}
+
+func TestSchedulerSubmitDue(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["refused"] = TestData{StatusCode: http.StatusBadRequest, Data: `{"errors": {"observable_name": ["invalid"]}}`, Want: 0}
+	testCases["serverError"] = TestData{StatusCode: http.StatusInternalServerError, Data: `{"detail": "failed"}`, Want: 1}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, serverHandler(t, testCase, "POST"))
+			scheduler, err := client.NewScheduler(nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, err := scheduler.Schedule(&gothreatmatrix.ObservableAnalysisParams{ObservableName: "8.8.8.8"}, time.Now()); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			due, err := scheduler.SubmitDue(context.Background(), time.Now())
+			if testCase.Want.(int) == 0 {
+				if err != nil || len(due) != 1 || due[0].Err == nil {
+					t.Errorf("Expected the refused analysis to be returned with its error, got %v %v", due, err)
+				}
+			} else if err == nil {
+				t.Errorf("Expected an error")
+			}
+			testWantData(t, testCase.Want, len(scheduler.Pending()))
+		})
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.ANALYZE_OBSERVABLE_URL, serverHandler(t, TestData{Data: `{"job_id":3,"status":"accepted"}`}, "POST"))
+	scheduler, err := client.NewScheduler(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	submitted := make(chan gothreatmatrix.ScheduledAnalysis, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- scheduler.Run(ctx, func(scheduled gothreatmatrix.ScheduledAnalysis) {
+			submitted <- scheduled
+		})
+	}()
+	if _, err := scheduler.Schedule(&gothreatmatrix.ObservableAnalysisParams{ObservableName: "8.8.8.8"}, time.Now().Add(50*time.Millisecond)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	select {
+	case scheduled := <-submitted:
+		testWantData(t, 3, scheduled.JobID)
+	case <-ctx.Done():
+		t.Fatalf("The scheduled analysis was not submitted")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSchedulerRunCircuitOpen(t *testing.T) {
+	client, apiHandler, closeServer := setupWithOptions(&gothreatmatrix.ThreatMatrixClientOptions{
+		CircuitBreaker: &gothreatmatrix.CircuitBreaker{FailureThreshold: 1, Cooldown: 30 * time.Millisecond},
+	})
+	defer closeServer()
+	// * the outage opens the circuit, which Run waits out like the outage itself
+	var requests int32
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"job_id":3,"status":"accepted"}`))
+	})
+	scheduler, err := client.NewScheduler(&gothreatmatrix.SchedulerOptions{RetryInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := scheduler.Schedule(&gothreatmatrix.ObservableAnalysisParams{ObservableName: "8.8.8.8"}, time.Now()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	submitted := make(chan gothreatmatrix.ScheduledAnalysis, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- scheduler.Run(ctx, func(scheduled gothreatmatrix.ScheduledAnalysis) {
+			submitted <- scheduled
+		})
+	}()
+	select {
+	case scheduled := <-submitted:
+		testWantData(t, 3, scheduled.JobID)
+	case err := <-done:
+		t.Fatalf("Run returned before the submission: %v", err)
+	case <-ctx.Done():
+		t.Fatalf("The scheduled analysis was not submitted")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	testWantData(t, 0, len(scheduler.Pending()))
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func testPng(t *testing.T) []byte {
+	t.Helper()
+	buffer := bytes.Buffer{}
+	if err := png.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	return buffer.Bytes()
+}
+
+func screenshotJob(t *testing.T) gothreatmatrix.Job {
+	encoded := base64.StdEncoding.EncodeToString(testPng(t))
+	return gothreatmatrix.Job{
+		BaseJob: gothreatmatrix.BaseJob{ID: 3, ObservableName: "https://evil.example/<login>", ObservableClassification: "url"},
+		AnalyzerReports: []gothreatmatrix.Report{
+			{Name: "UrlScan_Submit_Result", Status: "SUCCESS", Report: map[string]interface{}{
+				// * a link to the screenshot, not the image itself
+				"screenshot": "https://urlscan.io/screenshots/1.png",
+			}},
+			{Name: "Browser_Screenshot", Status: "SUCCESS", Report: map[string]interface{}{
+				"result": map[string]interface{}{"screenshot": encoded, "title": encoded},
+				"pages":  []interface{}{map[string]interface{}{"screenshot_full": "data:image/png;base64," + encoded}},
+			}},
+		},
+	}
+}
+
+func TestExtractScreenshots(t *testing.T) {
+	job := screenshotJob(t)
+	screenshots := gothreatmatrix.ExtractScreenshots(&job)
+	if len(screenshots) != 2 {
+		t.Fatalf("Expected 2 screenshots, got %d", len(screenshots))
+	}
+	testWantData(t, "pages[0].screenshot_full", screenshots[0].Field)
+	testWantData(t, "result.screenshot", screenshots[1].Field)
+	testWantData(t, "image/png", screenshots[1].MimeType)
+	testWantData(t, testPng(t), screenshots[1].Data)
+
+	paths, err := gothreatmatrix.SaveScreenshots(screenshots, t.TempDir())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 2, len(paths))
+	if !strings.HasSuffix(paths[0], "Browser_Screenshot_1.png") {
+		t.Errorf("Unexpected path %s", paths[0])
+	}
+	data, err := os.ReadFile(paths[1])
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, testPng(t), data)
+}
+
+func TestWriteHTMLReport(t *testing.T) {
+	job := screenshotJob(t)
+	output := bytes.Buffer{}
+	if err := gothreatmatrix.WriteHTMLReport(&output, &job); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	page := output.String()
+	if !strings.Contains(page, `src="data:image/png;base64,`) {
+		t.Errorf("Expected the screenshots to be embedded")
+	}
+	if strings.Contains(page, "<login>") || !strings.Contains(page, "&lt;login&gt;") {
+		t.Errorf("Expected the observable to be escaped")
+	}
+	if strings.Contains(page, "urlscan.io") {
+		t.Errorf("Expected no remote resource")
+	}
+}
+
+func TestJobServiceGetScreenshots(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	job := screenshotJob(t)
+	jobJson, err := json.Marshal(job)
+	if err != nil {
+		t.Fatalf("Error: %s", err)
+	}
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_JOB_URL, 3), serverHandler(t, TestData{Data: string(jobJson)}, "GET"))
+	screenshots, err := client.JobService.GetScreenshots(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 2, len(screenshots))
+}
+
+func TestDefangIndicator(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["url"] = TestData{Input: "https://evil.com/login", Want: "hxxps://evil[.]com/login"}
+	testCases["ip"] = TestData{Input: "8.8.8.8", Want: "8[.]8[.]8[.]8"}
+	testCases["email"] = TestData{Input: "attacker@evil.com", Want: "attacker[@]evil[.]com"}
+	testCases["hash"] = TestData{Input: "2329ab183ad74dd65e0519fa8b977f3b", Want: "2329ab183ad74dd65e0519fa8b977f3b"}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			testWantData(t, testCase.Want, gothreatmatrix.DefangIndicator(testCase.Input.(string)))
+		})
+	}
+}
+
+func TestWriteInvestigationEmail(t *testing.T) {
+	investigation := gothreatmatrix.Investigation{ID: 2, Name: "Phishing <campaign>", Status: "concluded", Tags: []string{"phishing"}}
+	jobs := []gothreatmatrix.Job{screenshotJob(t), {BaseJob: gothreatmatrix.BaseJob{ID: 4, Status: "reported_without_fails", FileName: "invoice.pdf"}}}
+	output := bytes.Buffer{}
+	if err := gothreatmatrix.WriteInvestigationEmail(&output, &investigation, jobs); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	page := output.String()
+	if strings.Contains(page, "<style") || strings.Contains(page, "<img") || strings.Contains(page, "<link") {
+		t.Errorf("Expected inline styles and no remote or embedded resource")
+	}
+	if strings.Contains(page, "urlscan.io") || strings.Contains(page, "evil.example") || !strings.Contains(page, "hxxps://evil[.]example/&lt;login&gt;") {
+		t.Errorf("Expected the indicators to be defanged")
+	}
+	if !strings.Contains(page, "invoice[.]pdf") {
+		t.Errorf("Expected the file name as indicator")
+	}
+	if strings.Contains(page, "<campaign>") {
+		t.Errorf("Expected the investigation to be escaped")
+	}
+}
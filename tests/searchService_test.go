@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	query := gothreatmatrix.ParseSearchQuery("tag:phishing login Tag:urgent analyzer:Classic_DNS https://example.com")
+	want := &gothreatmatrix.SearchQuery{
+		Text:     "login https://example.com",
+		Tags:     []string{"phishing", "urgent"},
+		Analyzer: "Classic_DNS",
+	}
+	if diff := cmp.Diff(want, query); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestSearchServiceJobs(t *testing.T) {
+	// *table test cases
+	testCases := make(map[string]TestData)
+	testCases["jobs"] = TestData{
+		Input: &gothreatmatrix.SearchQuery{Text: "login", Tags: []string{"phishing"}, Analyzer: "Classic_DNS", Page: 2},
+		Data:  `{"count": 3, "total_pages": 2, "results": [{"id": 3, "observable_name": "login.example.com", "status": "running"}]}`,
+		Want: []interface{}{
+			constants.BASE_JOB_URL,
+			"analyzers_requested=Classic_DNS&page=2&search=login&tags=phishing",
+			[]gothreatmatrix.SearchHit{{JobID: 3, Job: &gothreatmatrix.JobList{BaseJob: gothreatmatrix.BaseJob{ID: 3, ObservableName: "login.example.com", Status: "running"}}}},
+		},
+	}
+	testCases["elastic"] = TestData{
+		Input: &gothreatmatrix.SearchQuery{Text: "malicious", Analyzer: "Classic_DNS", Elastic: true},
+		Data:  `[{"job": {"id": 7}, "config": {"name": "Classic_DNS", "plugin_name": "analyzer"}, "status": "SUCCESS", "errors": [], "report": {"verdict": "malicious"}}]`,
+		Want: []interface{}{
+			constants.PLUGIN_REPORT_SEARCH_URL,
+			"name=Classic_DNS&page=1&report=malicious&type=analyzer",
+			[]gothreatmatrix.SearchHit{{JobID: 7, PluginReport: &gothreatmatrix.PluginReportHit{
+				JobID:      7,
+				PluginType: "analyzer",
+				Name:       "Classic_DNS",
+				Status:     "SUCCESS",
+				Errors:     []string{},
+				Report:     map[string]interface{}{"verdict": "malicious"},
+			}}},
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			want := testCase.Want.([]interface{})
+			apiHandler.HandleFunc(want[0].(string), func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				testWantData(t, want[1], r.URL.RawQuery)
+				w.Write([]byte(testCase.Data))
+			})
+			results, err := client.SearchService.Jobs(context.Background(), testCase.Input.(*gothreatmatrix.SearchQuery))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, want[2], results.Hits)
+		})
+	}
+}
+
+func TestSearchServiceElasticUnavailable(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.PLUGIN_REPORT_SEARCH_URL, func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	_, err := client.SearchService.Jobs(context.Background(), &gothreatmatrix.SearchQuery{Text: "malicious", Elastic: true})
+	var featureError *gothreatmatrix.FeatureUnavailableError
+	if !errors.As(err, &featureError) || featureError.Feature != gothreatmatrix.FEATURE_REPORT_SEARCH {
+		t.Errorf("Expected the Elasticsearch search to be unavailable, got %v", err)
+	}
+	_, err = client.SearchService.Jobs(context.Background(), &gothreatmatrix.SearchQuery{Tags: []string{"phishing"}, Elastic: true})
+	if !errors.Is(err, gothreatmatrix.ErrInvalidSearchQuery) {
+		t.Errorf("Expected ErrInvalidSearchQuery, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func sigmaTestJob() *gothreatmatrix.Job {
+	job := &gothreatmatrix.Job{}
+	job.ID = 42
+	job.IsSample = true
+	job.FileName = "invoice.exe"
+	job.Md5 = "2329ab183ad74dd65e0519fa8b977f3b"
+	job.Tlp = "AMBER"
+	job.AnalyzerReports = []gothreatmatrix.Report{
+		{Name: "Triage_Scan", Report: map[string]interface{}{
+			"network": map[string]interface{}{
+				"requests": []interface{}{
+					map[string]interface{}{"domain": "C2.Evil.example", "dst": "203.0.113.7"},
+					map[string]interface{}{"dst": "10.0.2.15", "url": "http://c2.evil.example/gate.php"},
+				},
+			},
+			"processes": []interface{}{
+				map[string]interface{}{"image": `C:\Users\admin\AppData\Local\Temp\invoice.exe`, "cmdline": `"invoice.exe" /install`},
+				map[string]interface{}{"image": `C:\Windows\System32\schtasks.exe`, "cmdline": `schtasks /create /tn updater /tr invoice.exe`},
+			},
+		}},
+		// * not a sandbox, its hosts are not activity of the sample
+		{Name: "Classic_DNS", Report: map[string]interface{}{"host": "dns.google"}},
+	}
+	return job
+}
+
+func TestExtractSandboxIndicators(t *testing.T) {
+	indicators := gothreatmatrix.ExtractSandboxIndicators(sigmaTestJob())
+	testWantData(t, &gothreatmatrix.SandboxIndicators{
+		Domains:      []string{"c2.evil.example"},
+		IPs:          []string{"203.0.113.7"},
+		URLs:         []string{"http://c2.evil.example/gate.php"},
+		Processes:    []string{`C:\Users\admin\AppData\Local\Temp\invoice.exe`, `C:\Windows\System32\schtasks.exe`},
+		CommandLines: []string{`"invoice.exe" /install`, `schtasks /create /tn updater /tr invoice.exe`},
+	}, indicators)
+}
+
+func TestSigmaRules(t *testing.T) {
+	options := &gothreatmatrix.SigmaExportOptions{Date: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)}
+	rules := gothreatmatrix.SigmaRules(sigmaTestJob(), options)
+	categories := []string{}
+	for _, rule := range rules {
+		categories = append(categories, rule.LogSource.Category)
+	}
+	testWantData(t, []string{"dns_query", "network_connection", "proxy", "process_creation"}, categories)
+	processRule := rules[3]
+	testWantData(t, "1 of selection_*", processRule.Detection["condition"])
+	testWantData(t, map[string]interface{}{"Image|endswith": []string{`\invoice.exe`, `\schtasks.exe`}}, processRule.Detection["selection_image"])
+	testWantData(t, []string{"tlp.amber"}, processRule.Tags)
+	testWantData(t, "2023-03-01", processRule.Date)
+	// * exporting the job again gives the same rules
+	testWantData(t, rules, gothreatmatrix.SigmaRules(sigmaTestJob(), options))
+
+	empty := gothreatmatrix.SigmaRules(&gothreatmatrix.Job{}, nil)
+	testWantData(t, 0, len(empty))
+}
+
+func TestWriteSigmaRules(t *testing.T) {
+	rules := gothreatmatrix.SigmaRules(sigmaTestJob(), &gothreatmatrix.SigmaExportOptions{Date: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)})
+	buffer := &bytes.Buffer{}
+	if err := gothreatmatrix.WriteSigmaRules(buffer, rules[:1]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, `title: DNS queries of invoice.exe
+id: `+rules[0].ID+`
+status: experimental
+description: Detects the DNS queries observed by the sandbox analyzers of ThreatMatrix job 42 on invoice.exe.
+author: ThreatMatrix
+date: 2023-03-01
+tags:
+  - tlp.amber
+logsource:
+  category: dns_query
+detection:
+  condition: selection
+  selection:
+    QueryName:
+      - c2.evil.example
+falsepositives:
+  - Unknown
+level: medium
+`, buffer.String())
+
+	buffer.Reset()
+	if err := gothreatmatrix.WriteSigmaRules(buffer, rules); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, len(rules)-1, strings.Count(buffer.String(), "---\n"))
+}
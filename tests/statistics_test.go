@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestStatisticsServiceJobsPerStatus(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.HandleFunc(constants.JOB_AGGREGATE_STATUS_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testWantData(t, gothreatmatrix.STATISTICS_RANGE_WEEK, r.URL.Query().Get("range"))
+		w.Write([]byte(`[
+			{"date": "2023-01-02T00:00:00Z", "reported_without_fails": 6, "failed": 2},
+			{"date": "2023-01-01T00:00:00Z", "reported_without_fails": 1, "failed": 1}
+		]`))
+	})
+	series, err := client.StatisticsService.JobsPerStatus(context.Background(), &gothreatmatrix.StatisticsOptions{Range: gothreatmatrix.STATISTICS_RANGE_WEEK})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"failed", "reported_without_fails"}, series.Keys)
+	testWantData(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), series.Points[0].Date)
+	testWantData(t, 8, series.Points[1].Total())
+	testWantData(t, 0.7, series.Rate(string(gothreatmatrix.REPORTED_WITHOUT_FAILS)))
+}
+
+func TestStatisticsServiceTopPlaybooks(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.JOB_AGGREGATE_TOP_PLAYBOOK_URL, serverHandler(t, TestData{
+		Data: `{"values": ["Dns", "FREE_TO_USE_ANALYZERS"], "aggregation": [{"date": "2023-01-01T00:00:00Z", "Dns": 4, "FREE_TO_USE_ANALYZERS": 3}]}`,
+	}, "GET"))
+	series, err := client.StatisticsService.TopPlaybooks(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"Dns", "FREE_TO_USE_ANALYZERS"}, series.Keys)
+	testWantData(t, map[string]int{"Dns": 4, "FREE_TO_USE_ANALYZERS": 3}, series.Totals())
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/export/stix"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// stixObservableJob is a domain job that resolved to an IP and was attributed to a malware family.
+func stixObservableJob() *gothreatmatrix.Job {
+	finished := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	job := &gothreatmatrix.Job{
+		AnalyzerReports: []gothreatmatrix.Report{
+			{Name: "Classic_DNS", Status: "SUCCESS", Report: map[string]interface{}{"resolutions": []interface{}{map[string]interface{}{"data": "8.8.8.8"}}}},
+			{Name: "Malpedia", Status: "SUCCESS", Report: map[string]interface{}{"family": "Emotet"}},
+			{Name: "Shodan", Status: "FAILED"},
+		},
+	}
+	job.ID = 7
+	job.ObservableName = "evil.example.com"
+	job.ObservableClassification = "domain"
+	job.Tlp = "AMBER"
+	job.Tags = []gothreatmatrix.Tag{{Label: "phishing"}}
+	job.FinishedAnalysisTime = &finished
+	return job
+}
+
+func TestStixNewBundle(t *testing.T) {
+	job := stixObservableJob()
+	bundle := stix.NewBundle(job, &stix.Options{Verdict: gothreatmatrix.MALICIOUS})
+	testWantData(t, bundle, stix.NewBundle(job, &stix.Options{Verdict: gothreatmatrix.MALICIOUS}))
+	testWantData(t, "bundle", bundle.Type)
+
+	ids := map[string]string{}
+	for _, objectType := range []string{"identity", "marking-definition", "domain-name", "ipv4-addr", "malware", "indicator", "relationship", "report"} {
+		objects := bundle.ObjectsOfType(objectType)
+		if len(objects) != 1 {
+			t.Fatalf("Expected one %s, got %d", objectType, len(objects))
+		}
+		ids[objectType] = objects[0].ID()
+	}
+	// * the observables have the UUIDv5 of the specification
+	testWantData(t, "domain-name--6262c8a9-ac28-50fe-a7df-f5923bb2ee14", ids["domain-name"])
+	testWantData(t, "ipv4-addr--2f689bf9-0ff2-545f-aa61-e495eb8cecc7", ids["ipv4-addr"])
+	testWantData(t, "marking-definition--f88d31f6-486f-44da-b317-01333bde0b82", ids["marking-definition"])
+
+	indicator := bundle.ObjectsOfType("indicator")[0]
+	testWantData(t, "[domain-name:value = 'evil.example.com']", indicator["pattern"])
+	testWantData(t, []string{"malicious-activity"}, indicator["indicator_types"])
+	testWantData(t, "2024-03-01T12:30:00.000Z", indicator["created"])
+	testWantData(t, ids["identity"], indicator["created_by_ref"])
+	testWantData(t, []string{ids["marking-definition"]}, indicator["object_marking_refs"])
+
+	relationship := bundle.ObjectsOfType("relationship")[0]
+	testWantData(t, ids["indicator"], relationship["source_ref"])
+	testWantData(t, ids["malware"], relationship["target_ref"])
+
+	results := map[string]interface{}{}
+	for _, analysis := range bundle.ObjectsOfType("malware-analysis") {
+		results[analysis["product"].(string)] = analysis["result"]
+	}
+	testWantData(t, map[string]interface{}{"Classic_DNS": "unknown", "Malpedia": "malicious"}, results)
+
+	report := bundle.ObjectsOfType("report")[0]
+	testWantData(t, "ThreatMatrix job 7: evil.example.com", report["name"])
+	testWantData(t, []string{"phishing"}, report["labels"])
+	if refs := report["object_refs"].([]string); len(refs) != 7 {
+		t.Errorf("Expected the report to reference 7 objects, got %v", refs)
+	}
+	if _, err := json.Marshal(bundle); err != nil {
+		t.Fatalf("Could not marshal the bundle: %v", err)
+	}
+}
+
+func TestStixNewBundleSample(t *testing.T) {
+	testCases := map[string]struct {
+		verdict       gothreatmatrix.Verdict
+		wantIndicator bool
+	}{
+		"suspicious": {verdict: gothreatmatrix.SUSPICIOUS, wantIndicator: true},
+		"unknown":    {verdict: gothreatmatrix.UNKNOWN},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			job := &gothreatmatrix.Job{
+				AnalyzerReports: []gothreatmatrix.Report{
+					{Name: "File_Info", Status: "SUCCESS", Report: map[string]interface{}{"sha256": "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855"}},
+				},
+			}
+			job.ID = 8
+			job.IsSample = true
+			job.FileName = "invoice.pdf"
+			job.FileMimetype = "application/pdf"
+			job.Md5 = "d41d8cd98f00b204e9800998ecf8427e"
+			job.Tlp = "CLEAR"
+			bundle := stix.NewBundle(job, &stix.Options{Verdict: testCase.verdict, Time: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)})
+			files := bundle.ObjectsOfType("file")
+			if len(files) != 1 {
+				t.Fatalf("Expected one file, got %d", len(files))
+			}
+			testWantData(t, map[string]string{
+				"MD5":     "d41d8cd98f00b204e9800998ecf8427e",
+				"SHA-256": "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			}, files[0]["hashes"])
+			testWantData(t, "application/pdf", files[0]["mime_type"])
+			testWantData(t, "TLP:WHITE", bundle.ObjectsOfType("marking-definition")[0]["name"])
+			testWantData(t, files[0].ID(), bundle.ObjectsOfType("malware-analysis")[0]["sample_ref"])
+			indicators := bundle.ObjectsOfType("indicator")
+			if !testCase.wantIndicator {
+				testWantData(t, 0, len(indicators))
+				return
+			}
+			testWantData(t, "[file:hashes.'MD5' = 'd41d8cd98f00b204e9800998ecf8427e' OR file:hashes.'SHA-256' = 'e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855']", indicators[0]["pattern"])
+			testWantData(t, []string{"anomalous-activity"}, indicators[0]["indicator_types"])
+		})
+	}
+}
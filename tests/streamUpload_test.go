@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/khulnasoft/go-threatmatrix/threatmatrixtest"
+	"github.com/sirupsen/logrus"
+)
+
+func TestCreateFileAnalysisFromReader(t *testing.T) {
+	content := strings.Repeat("threatmatrix", 100000)
+	contentSum := md5.Sum([]byte(content))
+	testCases := map[string]struct {
+		size      int64
+		wantError error
+	}{
+		"known size":    {size: int64(len(content))},
+		"chunked":       {size: 0},
+		"size mismatch": {size: int64(len(content)) + 1, wantError: gothreatmatrix.ErrUploadSizeMismatch},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			server := threatmatrixtest.NewServer()
+			defer server.Close()
+			server.AddAnalyzer(threatmatrixtest.FileAnalyzer("File_Info"))
+			client := server.Client()
+			var progress []gothreatmatrix.UploadProgress
+			// * hiding the strings.Reader, so nothing but the stream is known of the file
+			reader := struct{ io.Reader }{strings.NewReader(content)}
+			analysisResponse, err := client.CreateFileAnalysisFromReader(context.Background(), &gothreatmatrix.StreamFileAnalysisParams{
+				Reader:   reader,
+				FileName: "/samples/sample.bin",
+				Size:     testCase.size,
+				OnProgress: func(uploadProgress gothreatmatrix.UploadProgress) {
+					progress = append(progress, uploadProgress)
+				},
+			})
+			if testCase.wantError != nil {
+				if !errors.Is(err, testCase.wantError) {
+					t.Fatalf("Expected %v, got %v", testCase.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			submission := server.Submissions()[0]
+			testWantData(t, analysisResponse.JobID, submission.JobID)
+			testWantData(t, "sample.bin", submission.FileName)
+			testWantData(t, hex.EncodeToString(contentSum[:]), submission.ObservableName)
+			last := progress[len(progress)-1]
+			testWantData(t, int64(len(content)), last.Sent)
+			wantTotal := testCase.size
+			if wantTotal == 0 {
+				wantTotal = -1
+			}
+			testWantData(t, wantTotal, last.Total)
+		})
+	}
+}
+
+// slowReader returns its content a chunk at a time, waiting before every chunk.
+type slowReader struct {
+	content []byte
+	chunk   int
+	wait    time.Duration
+}
+
+func (reader *slowReader) Read(p []byte) (int, error) {
+	if len(reader.content) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(reader.wait)
+	if len(p) > reader.chunk {
+		p = p[:reader.chunk]
+	}
+	n := copy(p, reader.content)
+	reader.content = reader.content[n:]
+	return n, nil
+}
+
+func TestCreateFileAnalysisFromReaderSlow(t *testing.T) {
+	content := strings.Repeat("threatmatrix", 1000)
+	server := threatmatrixtest.NewServer()
+	defer server.Close()
+	server.AddAnalyzer(threatmatrixtest.FileAnalyzer("File_Info"))
+	client := gothreatmatrix.NewThreatMatrixClient(&gothreatmatrix.ThreatMatrixClientOptions{
+		Url:     server.URL,
+		Token:   threatmatrixtest.TOKEN,
+		Timeout: 1,
+	}, nil, &gothreatmatrix.LoggerParams{Level: logrus.WarnLevel})
+	// * the upload takes longer than the Timeout of the client
+	reader := &slowReader{content: []byte(content), chunk: len(content) / 3, wait: 500 * time.Millisecond}
+	analysisResponse, err := client.CreateFileAnalysisFromReader(context.Background(), &gothreatmatrix.StreamFileAnalysisParams{
+		Reader:   reader,
+		FileName: "sample.bin",
+		Size:     int64(len(content)),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, server.Submissions()[0].JobID, analysisResponse.JobID)
+}
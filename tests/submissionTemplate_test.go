@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+const templateYaml = `---
+# triage of reported phishing
+name: phishing-triage
+tlp: AMBER
+tags: [phishing, "needs review"]
+playbook: FREE_TO_USE_ANALYZERS
+runtime_configuration:
+  analyzers:
+    Doc_Info:
+      additional_passwords_to_check: ['infected', "1234"]
+      timeout: 30
+  connectors: {}
+analyzers_denied:
+- VirusTotal_v3_Get_File   # costs money
+- name_with_#hash
+`
+
+func TestLoadSubmissionTemplate(t *testing.T) {
+	testCases := map[string]struct {
+		content string
+		want    *gothreatmatrix.SubmissionTemplate
+		wantErr bool
+	}{
+		"yaml": {
+			content: templateYaml,
+			want: &gothreatmatrix.SubmissionTemplate{
+				Name:     "phishing-triage",
+				Tlp:      gothreatmatrix.AMBER,
+				Tags:     []string{"phishing", "needs review"},
+				Playbook: "FREE_TO_USE_ANALYZERS",
+				RuntimeConfiguration: map[string]interface{}{
+					"analyzers": map[string]interface{}{
+						"Doc_Info": map[string]interface{}{
+							"additional_passwords_to_check": []interface{}{"infected", "1234"},
+							"timeout":                       float64(30),
+						},
+					},
+					"connectors": map[string]interface{}{},
+				},
+				AnalyzersDenied: []string{"VirusTotal_v3_Get_File", "name_with_#hash"},
+			},
+		},
+		"sequenceOfMappings": {
+			content: "name: nested\nruntime_configuration:\n  steps:\n    - name: first\n      enabled: true\n    -\n      name: second\n",
+			want: &gothreatmatrix.SubmissionTemplate{
+				Name: "nested",
+				RuntimeConfiguration: map[string]interface{}{
+					"steps": []interface{}{
+						map[string]interface{}{"name": "first", "enabled": true},
+						map[string]interface{}{"name": "second"},
+					},
+				},
+			},
+		},
+		// * an apostrophe inside a plain scalar does not quote the comment after it
+		"apostrophes": {
+			content: `name: analyst's pick # shared template
+playbook: "Free # not a comment" # a comment
+tags: [it's, 'quoted # kept', "with \" escape # kept"]
+analyzers_denied:
+- O'Brien_Lookup # the analyst's favorite
+- 'it''s quoted' # quoted
+`,
+			want: &gothreatmatrix.SubmissionTemplate{
+				Name:            "analyst's pick",
+				Playbook:        "Free # not a comment",
+				Tags:            []string{"it's", "quoted # kept", `with " escape # kept`},
+				AnalyzersDenied: []string{"O'Brien_Lookup", "it's quoted"},
+			},
+		},
+		"json": {
+			content: `{"name": "json", "tlp": "RED", "analyzers_allowed": ["Classic_DNS"]}`,
+			want: &gothreatmatrix.SubmissionTemplate{
+				Name:             "json",
+				Tlp:              gothreatmatrix.RED,
+				AnalyzersAllowed: []string{"Classic_DNS"},
+			},
+		},
+		"badIndentation": {
+			content: "name: bad\ntags:\n  - a\n   - b\n",
+			wantErr: true,
+		},
+		"unsupportedAnchor": {
+			content: "name: &anchor bad\n",
+			wantErr: true,
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "template.yaml")
+			if err := os.WriteFile(path, []byte(testCase.content), 0600); err != nil {
+				t.Fatalf("Could not write the template: %v", err)
+			}
+			template, err := gothreatmatrix.LoadSubmissionTemplate(path)
+			if testCase.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got: %+v", template)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.want, template)
+		})
+	}
+}
+
+func TestSubmissionTemplateSave(t *testing.T) {
+	template := &gothreatmatrix.SubmissionTemplate{
+		Name:     "round trip",
+		Tlp:      gothreatmatrix.GREEN,
+		Tags:     []string{"true", "-dash", "with: colon", "it's"},
+		Playbook: "",
+		RuntimeConfiguration: map[string]interface{}{
+			"analyzers": map[string]interface{}{
+				"Yara": map[string]interface{}{"rules": []interface{}{"a", map[string]interface{}{"b": nil}}, "empty": []interface{}{}},
+			},
+			"threshold": 0.5,
+		},
+		AnalyzersAllowed: []string{"Classic_DNS", "Yara"},
+	}
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	if err := template.Save(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loaded, err := gothreatmatrix.LoadSubmissionTemplate(path)
+	if err != nil {
+		data, _ := os.ReadFile(path)
+		t.Fatalf("Could not load the saved template: %v\n%s", err, data)
+	}
+	testWantData(t, template, loaded)
+}
+
+func TestApplyTemplate(t *testing.T) {
+	template := &gothreatmatrix.SubmissionTemplate{
+		Tlp:      gothreatmatrix.AMBER,
+		Tags:     []string{"phishing"},
+		Playbook: "FREE_TO_USE_ANALYZERS",
+		RuntimeConfiguration: map[string]interface{}{
+			"analyzers": map[string]interface{}{
+				"Doc_Info": map[string]interface{}{"timeout": 30},
+				"Shodan":   map[string]interface{}{"include_honeyscore": true},
+			},
+		},
+		AnalyzersDenied: []string{"Shodan"},
+	}
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, TestData{Data: `{"Doc_Info": {"name": "Doc_Info"}, "Shodan": {"name": "Shodan"}, "Old": {"name": "Old", "disabled": true}}`}, "GET"))
+	testCases := map[string]struct {
+		input gothreatmatrix.BasicAnalysisParams
+		want  gothreatmatrix.BasicAnalysisParams
+	}{
+		"defaults": {
+			input: gothreatmatrix.BasicAnalysisParams{},
+			want: gothreatmatrix.BasicAnalysisParams{
+				Tlp:                gothreatmatrix.AMBER,
+				TagsLabels:         []string{"phishing"},
+				PlaybookRequested:  "FREE_TO_USE_ANALYZERS",
+				AnalyzersRequested: []string{"Doc_Info"},
+				RuntimeConfiguration: map[string]interface{}{
+					"analyzers": map[string]interface{}{"Doc_Info": map[string]interface{}{"timeout": 30}},
+				},
+			},
+		},
+		"overrides": {
+			input: gothreatmatrix.BasicAnalysisParams{
+				Tlp:                gothreatmatrix.RED,
+				TagsLabels:         []string{"phishing", "urgent"},
+				AnalyzersRequested: []string{"Doc_Info", "Shodan"},
+				RuntimeConfiguration: map[string]interface{}{
+					"analyzers": map[string]interface{}{"Doc_Info": map[string]interface{}{"timeout": 60}},
+				},
+			},
+			want: gothreatmatrix.BasicAnalysisParams{
+				Tlp:                gothreatmatrix.RED,
+				TagsLabels:         []string{"phishing", "urgent"},
+				AnalyzersRequested: []string{"Doc_Info"},
+				RuntimeConfiguration: map[string]interface{}{
+					"analyzers": map[string]interface{}{"Doc_Info": map[string]interface{}{"timeout": 60}},
+				},
+			},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			params := testCase.input
+			if err := client.ApplyTemplate(context.Background(), template, &params); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			params.AnalyzersRequested = sortedStrings(params.AnalyzersRequested)
+			wantJson, _ := json.Marshal(testCase.want)
+			gotJson, _ := json.Marshal(params)
+			testWantData(t, string(wantJson), string(gotJson))
+		})
+	}
+}
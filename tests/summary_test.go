@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestNormalizeMalwareFamily(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["alias"] = TestData{Input: "Geodo", Want: "emotet"}
+	testCases["punctuation"] = TestData{Input: "Agent-Tesla", Want: "agenttesla"}
+	testCases["unknown"] = TestData{Input: "Some_Family", Want: "somefamily"}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			testWantData(t, testCase.Want, gothreatmatrix.NormalizeMalwareFamily(testCase.Input.(string)))
+		})
+	}
+}
+
+func TestJobSummaryMalwareFamilies(t *testing.T) {
+	job := gothreatmatrix.Job{
+		BaseJob: gothreatmatrix.BaseJob{
+			ID:     5,
+			Status: "reported_with_fails",
+			Md5:    "2329ab183ad74dd65e0519fa8b977f3b",
+			Tlp:    "AMBER",
+			Tags:   []gothreatmatrix.Tag{{ID: 1, Label: "phishing"}},
+		},
+		AnalyzerReports: []gothreatmatrix.Report{
+			{Name: "VirusTotal_v3_Get_File", Status: "SUCCESS", Report: map[string]interface{}{
+				"data": map[string]interface{}{"attributes": map[string]interface{}{"last_analysis_results": map[string]interface{}{
+					"Kaspersky":  map[string]interface{}{"category": "malicious", "result": "HEUR:Trojan-Banker.Win32.Emotet.gen"},
+					"Microsoft":  map[string]interface{}{"category": "malicious", "result": "Trojan:Win32/Geodo.AX!MTB"},
+					"ESET-NOD32": map[string]interface{}{"category": "malicious", "result": "Win32/Kryptik.HNDZ"},
+					"Avast":      map[string]interface{}{"category": "malicious", "result": "Win32:Malware-gen"},
+					"Sophos":     map[string]interface{}{"category": "malicious", "result": "Troj/Zumanek-A"},
+					"McAfee":     map[string]interface{}{"category": "malicious", "result": "Zumanek!1A2B3C4D5E6F"},
+					"ClamAV":     map[string]interface{}{"category": "undetected", "result": nil},
+				}}},
+			}},
+			{Name: "MalwareBazaar_Get_File", Status: "SUCCESS", Report: map[string]interface{}{
+				"data": []interface{}{map[string]interface{}{"signature": "Heodo", "tags": []interface{}{"exe"}}},
+			}},
+			{Name: "Triage_Scan", Status: "FAILED"},
+		},
+	}
+	summary := job.Summary()
+	testWantData(t, []string{"phishing"}, summary.Tags)
+	testWantData(t, []string{"VirusTotal_v3_Get_File", "MalwareBazaar_Get_File"}, summary.AnalyzersSucceeded)
+	testWantData(t, []string{"Triage_Scan"}, summary.AnalyzersFailed)
+
+	families := summary.MalwareFamilies
+	if len(families) != 2 {
+		t.Fatalf("Expected 2 families, got %+v", families)
+	}
+	testWantData(t, "emotet", families[0].Name)
+	testWantData(t, 3, families[0].Detections)
+	testWantData(t, []string{"MalwareBazaar_Get_File", "VirusTotal_v3_Get_File"}, sortedStrings(families[0].Sources))
+	// * an unknown family is kept when two engines agree on it
+	testWantData(t, "zumanek", families[1].Name)
+	testWantData(t, 2, families[1].Detections)
+}
+
+func sortedStrings(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
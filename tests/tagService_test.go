@@ -76,6 +76,7 @@ func TestTagServiceGet(t *testing.T) {
 		Want: &gothreatmatrix.ThreatMatrixError{
 			StatusCode: http.StatusNotFound,
 			Message:    `{"detail": "Not found."}`,
+			Detail:     "Not found.",
 		},
 	}
 
@@ -130,6 +131,7 @@ func TestTagServiceCreate(t *testing.T) {
 		Want: &gothreatmatrix.ThreatMatrixError{
 			StatusCode: http.StatusBadRequest,
 			Message:    `{"label":["tag with this label already exists."]}`,
+			Errors:     map[string]interface{}{"label": []interface{}{"tag with this label already exists."}},
 		},
 	}
 	for name, testCase := range testCases {
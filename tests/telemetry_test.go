@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+type recordedSpan struct {
+	name       string
+	attributes map[string]interface{}
+	err        error
+}
+
+// recordingTelemetry records the spans it starts and the requests of a RequestMetrics.
+type recordingTelemetry struct {
+	*gothreatmatrix.RequestMetrics
+	spans []*recordedSpan
+}
+
+func (telemetry *recordingTelemetry) StartSpan(ctx context.Context, name string, attributes map[string]interface{}) (context.Context, gothreatmatrix.TelemetrySpan) {
+	span := &recordedSpan{name: name, attributes: map[string]interface{}{}}
+	for key, value := range attributes {
+		span.attributes[key] = value
+	}
+	telemetry.spans = append(telemetry.spans, span)
+	return ctx, span
+}
+
+func (span *recordedSpan) End(attributes map[string]interface{}, err error) {
+	for key, value := range attributes {
+		span.attributes[key] = value
+	}
+	span.err = err
+}
+
+func TestTelemetry(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["killAnalyzer"] = TestData{
+		Input:      gothreatmatrix.ThreatMatrixClientOptions{EnableTelemetry: true},
+		StatusCode: http.StatusNoContent,
+		Want: &recordedSpan{
+			name: "PATCH /api/jobs/{id}/analyzer/{name}/kill",
+			attributes: map[string]interface{}{
+				gothreatmatrix.TELEMETRY_HTTP_METHOD:      "PATCH",
+				gothreatmatrix.TELEMETRY_HTTP_ROUTE:       "/api/jobs/{id}/analyzer/{name}/kill",
+				gothreatmatrix.TELEMETRY_HTTP_STATUS_CODE: http.StatusNoContent,
+				gothreatmatrix.TELEMETRY_JOB_ID:           "42",
+				"threatmatrix.analyzer":                   "Classic_DNS",
+			},
+		},
+	}
+	testCases["failed"] = TestData{
+		Input:      gothreatmatrix.ThreatMatrixClientOptions{EnableTelemetry: true},
+		StatusCode: http.StatusNotFound,
+		Data:       `{"detail": "Not found."}`,
+		Want: &recordedSpan{
+			name: "PATCH /api/jobs/{id}/analyzer/{name}/kill",
+			attributes: map[string]interface{}{
+				gothreatmatrix.TELEMETRY_HTTP_METHOD:      "PATCH",
+				gothreatmatrix.TELEMETRY_HTTP_ROUTE:       "/api/jobs/{id}/analyzer/{name}/kill",
+				gothreatmatrix.TELEMETRY_HTTP_STATUS_CODE: http.StatusNotFound,
+				gothreatmatrix.TELEMETRY_JOB_ID:           "42",
+				"threatmatrix.analyzer":                   "Classic_DNS",
+			},
+		},
+	}
+	testCases["disabled"] = TestData{
+		Input:      gothreatmatrix.ThreatMatrixClientOptions{},
+		StatusCode: http.StatusNoContent,
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			telemetry := &recordingTelemetry{RequestMetrics: gothreatmatrix.NewRequestMetrics()}
+			options := testCase.Input.(gothreatmatrix.ThreatMatrixClientOptions)
+			options.Telemetry = telemetry
+			client, apiHandler, closeServer := setupWithOptions(&options)
+			defer closeServer()
+			apiHandler.Handle(fmt.Sprintf(constants.KILL_ANALYZER_JOB_URL, 42, "Classic_DNS"), serverHandler(t, testCase, "PATCH"))
+			_, err := client.JobService.KillAnalyzer(context.Background(), 42, "Classic_DNS")
+			if testCase.Want == nil {
+				testWantData(t, 0, len(telemetry.spans))
+				testWantData(t, []string{}, telemetry.Routes())
+				return
+			}
+			want := testCase.Want.(*recordedSpan)
+			if len(telemetry.spans) != 1 {
+				t.Fatalf("Expected one span, got %d", len(telemetry.spans))
+			}
+			span := telemetry.spans[0]
+			testWantData(t, want.name, span.name)
+			testWantData(t, want.attributes, span.attributes)
+			testWantData(t, err != nil, span.err != nil)
+			routeMetrics, ok := telemetry.Route(want.name)
+			if !ok {
+				t.Fatalf("Expected the call to be recorded")
+			}
+			wantErrors := 0
+			if err != nil {
+				wantErrors = 1
+			}
+			testWantData(t, 1, routeMetrics.Count)
+			testWantData(t, wantErrors, routeMetrics.Errors)
+			testWantData(t, map[int]int{testCase.StatusCode: 1}, routeMetrics.StatusCodeCount)
+		})
+	}
+}
@@ -33,6 +33,24 @@ func setup() (testClient gothreatmatrix.ThreatMatrixClient, apiHandler *http.Ser
 
 }
 
+// Setting up the router, test server, and a client configured with the given options
+// (the Url and Token are filled in for you)
+func setupWithOptions(options *gothreatmatrix.ThreatMatrixClientOptions) (testClient gothreatmatrix.ThreatMatrixClient, apiHandler *http.ServeMux, closeServer func()) {
+
+	apiHandler = http.NewServeMux()
+
+	testServer := httptest.NewServer(apiHandler)
+
+	options.Url = testServer.URL
+	options.Token = "test-token"
+	testClient = gothreatmatrix.NewThreatMatrixClient(options, nil, &gothreatmatrix.LoggerParams{
+		Level: logrus.DebugLevel,
+	})
+
+	return testClient, apiHandler, testServer.Close
+
+}
+
 // Helper test
 // Testing the request method is as expected
 func testMethod(t *testing.T, request *http.Request, wantedMethod string) {
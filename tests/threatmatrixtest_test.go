@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/khulnasoft/go-threatmatrix/threatmatrixtest"
+)
+
+func TestThreatMatrixTestServerObservableAnalysis(t *testing.T) {
+	server := threatmatrixtest.NewServer()
+	defer server.Close()
+	server.AddAnalyzer(threatmatrixtest.Analyzer("Classic_DNS", "domain"))
+	server.AddAnalyzer(threatmatrixtest.Analyzer("Shodan_Search", "ip"))
+	server.AddPlaybook(threatmatrixtest.Playbook("Dns", "Classic_DNS"))
+	client := server.Client()
+	ctx := context.Background()
+
+	analyzerConfigs, err := client.AnalyzerService.GetConfigs(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 2, len(*analyzerConfigs))
+
+	analysisResponse, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+		BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{PlaybookRequested: "Dns", TagsLabels: []string{"phishing"}},
+		ObservableName:      "example.com",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, []string{"Classic_DNS"}, analysisResponse.AnalyzersRunning)
+
+	waitResult, err := client.JobService.WaitForCompletion(ctx, uint64(analysisResponse.JobID), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, gothreatmatrix.REPORTED_WITHOUT_FAILS, waitResult.Job.State())
+	testWantData(t, "domain", waitResult.Job.ObservableClassification)
+	testWantData(t, threatmatrixtest.REPORT_SUCCESS, waitResult.Job.AnalyzerReports[0].Status)
+
+	submissions := server.Submissions()
+	testWantData(t, 1, len(submissions))
+	testWantData(t, constants.ANALYZE_OBSERVABLE_URL, submissions[0].Route)
+	testWantData(t, []string{"phishing"}, submissions[0].Params.TagsLabels)
+
+	if _, err := client.CreateObservableAnalysis(ctx, &gothreatmatrix.ObservableAnalysisParams{
+		BasicAnalysisParams: gothreatmatrix.BasicAnalysisParams{AnalyzersRequested: []string{"Unknown"}},
+		ObservableName:      "8.8.8.8",
+	}); err == nil {
+		t.Fatalf("Expected an error for an unknown analyzer")
+	}
+}
+
+func TestThreatMatrixTestServerJobs(t *testing.T) {
+	server := threatmatrixtest.NewServer()
+	defer server.Close()
+	server.AddAnalyzer(threatmatrixtest.FileAnalyzer("File_Info"))
+	server.OnSubmit = func(job *gothreatmatrix.Job) {
+		job.Status = string(gothreatmatrix.RUNNING)
+	}
+	failedJob := threatmatrixtest.ObservableJob(0, "8.8.8.8", gothreatmatrix.REPORTED_WITH_FAILS)
+	failedJob.AnalyzerReports = append(failedJob.AnalyzerReports, threatmatrixtest.FailedReport("Shodan_Search", "quota exceeded"))
+	failedJobID := server.AddJob(failedJob)
+	client := server.Client()
+	ctx := context.Background()
+
+	fileName := filepath.Join(t.TempDir(), "sample.txt")
+	if err := os.WriteFile(fileName, []byte("threatmatrixtest"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	analysisResponse, err := client.CreateFileAnalysis(ctx, &gothreatmatrix.FileAnalysisParams{File: file})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, failedJobID+1, analysisResponse.JobID)
+	testWantData(t, "sample.txt", server.Submissions()[0].FileName)
+
+	running, err := client.JobService.ListWithFilter(ctx, gothreatmatrix.NewJobFilter().Status(gothreatmatrix.RUNNING))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 1, running.Count)
+	testWantData(t, "sample.txt", running.Results[0].FileName)
+
+	killed, err := client.JobService.Kill(ctx, uint64(analysisResponse.JobID))
+	if err != nil || !killed {
+		t.Fatalf("Expected the job to be killed, got %v, %v", killed, err)
+	}
+	job, _ := server.Job(analysisResponse.JobID)
+	testWantData(t, string(gothreatmatrix.KILLED), job.Status)
+
+	deleted, err := client.JobService.Delete(ctx, uint64(failedJobID))
+	if err != nil || !deleted {
+		t.Fatalf("Expected the job to be deleted, got %v, %v", deleted, err)
+	}
+	_, err = client.JobService.Get(ctx, uint64(failedJobID))
+	if !gothreatmatrix.IsNotFound(err) {
+		t.Fatalf("Expected a not found error, got %v", err)
+	}
+
+	unauthenticated := NewTestThreatMatrixClient(server.URL)
+	if _, err := unauthenticated.JobService.List(ctx); err == nil {
+		t.Fatalf("Expected the server to reject an unknown token")
+	}
+}
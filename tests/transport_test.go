@@ -0,0 +1,282 @@
+package tests
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/sirupsen/logrus"
+)
+
+func TestTLSOptions(t *testing.T) {
+	testServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"user": {"username": "hussain"}}`))
+	}))
+	testServer.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	testServer.StartTLS()
+	defer testServer.Close()
+	serverCertificate := testServer.Certificate()
+	certificatePath := filepath.Join(t.TempDir(), "ca.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverCertificate.Raw})
+	if err := os.WriteFile(certificatePath, pemBytes, 0600); err != nil {
+		t.Fatalf("Could not write the certificate: %v", err)
+	}
+	testCases := map[string]struct {
+		options gothreatmatrix.ThreatMatrixClientOptions
+		wantErr bool
+	}{
+		"caBundle": {
+			options: gothreatmatrix.ThreatMatrixClientOptions{Certificate: certificatePath},
+		},
+		"untrusted": {
+			options: gothreatmatrix.ThreatMatrixClientOptions{},
+			wantErr: true,
+		},
+		"pinned": {
+			options: gothreatmatrix.ThreatMatrixClientOptions{
+				Certificate:      certificatePath,
+				MinTLSVersion:    "1.2",
+				CipherSuites:     []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+				PinnedSPKIHashes: []string{gothreatmatrix.SPKIHash(serverCertificate)},
+			},
+		},
+		"insecureSkipVerify": {
+			options: gothreatmatrix.ThreatMatrixClientOptions{InsecureSkipVerify: true},
+		},
+		"insecureSkipVerifyPinned": {
+			options: gothreatmatrix.ThreatMatrixClientOptions{
+				InsecureSkipVerify: true,
+				PinnedSPKIHashes:   []string{gothreatmatrix.SPKIHash(serverCertificate)},
+			},
+		},
+		"insecureSkipVerifyPinMismatch": {
+			options: gothreatmatrix.ThreatMatrixClientOptions{
+				InsecureSkipVerify: true,
+				PinnedSPKIHashes:   []string{"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="},
+			},
+			wantErr: true,
+		},
+		"pinMismatch": {
+			options: gothreatmatrix.ThreatMatrixClientOptions{
+				Certificate:      certificatePath,
+				PinnedSPKIHashes: []string{"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="},
+			},
+			wantErr: true,
+		},
+		"minVersionTooHigh": {
+			options: gothreatmatrix.ThreatMatrixClientOptions{Certificate: certificatePath, MinTLSVersion: "1.3"},
+			wantErr: true,
+		},
+		"invalidCipherSuite": {
+			options: gothreatmatrix.ThreatMatrixClientOptions{Certificate: certificatePath, CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+			wantErr: true,
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			options := testCase.options
+			options.Url = testServer.URL
+			options.Token = "test-token"
+			client := gothreatmatrix.NewThreatMatrixClient(&options, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+			_, err := client.UserService.Access(context.Background())
+			if testCase.wantErr != (err != nil) {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if name == "pinMismatch" && !errors.Is(err, gothreatmatrix.ErrCertificatePinMismatch) {
+				t.Fatalf("Expected ErrCertificatePinMismatch, got: %v", err)
+			}
+		})
+	}
+}
+
+// startSocks5Proxy starts a minimal SOCKS5 proxy (no authentication, CONNECT only) that sends every connection
+// to target, whatever the requested host, and records the requested hosts.
+func startSocks5Proxy(t *testing.T, target string) (address string, requestedHosts chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	requestedHosts = make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				header := make([]byte, 2)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				methods := make([]byte, header[1])
+				io.ReadFull(conn, methods)
+				conn.Write([]byte{5, 0})
+				request := make([]byte, 4)
+				if _, err := io.ReadFull(conn, request); err != nil {
+					return
+				}
+				host := ""
+				switch request[3] {
+				case 1:
+					ip := make([]byte, 4)
+					io.ReadFull(conn, ip)
+					host = net.IP(ip).String()
+				case 3:
+					length := make([]byte, 1)
+					io.ReadFull(conn, length)
+					name := make([]byte, length[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				default:
+					return
+				}
+				port := make([]byte, 2)
+				io.ReadFull(conn, port)
+				requestedHosts <- host
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer upstream.Close()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}(conn)
+		}
+	}()
+	return listener.Addr().String(), requestedHosts
+}
+
+func TestProxyOptions(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"user": {"username": "hussain"}}`))
+	}))
+	defer testServer.Close()
+	socksAddress, requestedHosts := startSocks5Proxy(t, testServer.Listener.Addr().String())
+	_, port, _ := net.SplitHostPort(testServer.Listener.Addr().String())
+	testCases := map[string]struct {
+		url     string
+		options gothreatmatrix.ThreatMatrixClientOptions
+		// wantHost is the host the proxy is asked for, empty when the proxy must not be used.
+		wantHost string
+		wantErr  bool
+	}{
+		"socks5h": {
+			url:      "http://threatmatrix.internal:" + port,
+			options:  gothreatmatrix.ThreatMatrixClientOptions{ProxyUrl: "socks5h://" + socksAddress},
+			wantHost: "threatmatrix.internal",
+		},
+		"rule": {
+			url: "http://threatmatrix.internal:" + port,
+			options: gothreatmatrix.ThreatMatrixClientOptions{ProxyRules: map[string]string{
+				"*.internal": "socks5h://" + socksAddress,
+			}},
+			wantHost: "threatmatrix.internal",
+		},
+		"direct": {
+			url: testServer.URL,
+			options: gothreatmatrix.ThreatMatrixClientOptions{
+				ProxyUrl:   "socks5h://" + socksAddress,
+				ProxyRules: map[string]string{"127.0.0.1": gothreatmatrix.PROXY_DIRECT},
+			},
+		},
+		"invalidProxy": {
+			url:     testServer.URL,
+			options: gothreatmatrix.ThreatMatrixClientOptions{ProxyUrl: "ftp://" + socksAddress},
+			wantErr: true,
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			options := testCase.options
+			options.Url = testCase.url
+			options.Token = "test-token"
+			client := gothreatmatrix.NewThreatMatrixClient(&options, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+			_, err := client.UserService.Access(context.Background())
+			if testCase.wantErr != (err != nil) {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			select {
+			case host := <-requestedHosts:
+				testWantData(t, testCase.wantHost, host)
+			default:
+				testWantData(t, testCase.wantHost, "")
+			}
+		})
+	}
+}
+
+func TestConnectionPoolOptions(t *testing.T) {
+	testCases := map[string]struct {
+		options         gothreatmatrix.ThreatMatrixClientOptions
+		wantConnections int64
+	}{
+		"maxConnsPerHost": {
+			options:         gothreatmatrix.ThreatMatrixClientOptions{MaxConnsPerHost: 1},
+			wantConnections: 1,
+		},
+		"pooled": {
+			options:         gothreatmatrix.ThreatMatrixClientOptions{MaxIdleConnsPerHost: 4, MaxConnsPerHost: 4, IdleConnTimeout: 30},
+			wantConnections: 4,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var connections, inFlight int64
+			release := make(chan struct{})
+			testServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt64(&inFlight, 1)
+				<-release
+				w.Write([]byte(`{"id": 1, "label": "TEST1", "color": "#1c71d8"}`))
+			}))
+			testServer.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt64(&connections, 1)
+				}
+			}
+			testServer.Start()
+			defer testServer.Close()
+			options := testCase.options
+			options.Url = testServer.URL
+			options.Token = "test-token"
+			client := gothreatmatrix.NewThreatMatrixClient(&options, nil, &gothreatmatrix.LoggerParams{Level: logrus.DebugLevel})
+			// * the client is not affected by the options changed once it is built
+			options.Url = "http://localhost:1"
+			var waitGroup sync.WaitGroup
+			for request := 0; request < 8; request++ {
+				waitGroup.Add(1)
+				go func() {
+					defer waitGroup.Done()
+					if _, err := client.TagService.Get(context.Background(), 1); err != nil {
+						t.Errorf("Unexpected error: %v", err)
+					}
+				}()
+			}
+			for deadline := time.Now().Add(2 * time.Second); atomic.LoadInt64(&inFlight) < testCase.wantConnections && time.Now().Before(deadline); {
+				time.Sleep(time.Millisecond)
+			}
+			// * leaving the time for requests beyond the cap to open connections they must not open
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+			waitGroup.Wait()
+			testWantData(t, testCase.wantConnections, atomic.LoadInt64(&connections))
+		})
+	}
+}
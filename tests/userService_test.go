@@ -3,8 +3,10 @@ package tests
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/khulnasoft/go-threatmatrix/constants"
 	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
@@ -169,3 +171,150 @@ func TestUserServiceInviteToOrganization(t *testing.T) {
 		})
 	}
 }
+
+func TestUserServiceOrganizationActivity(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	apiHandler.Handle(constants.ANALYZER_CONFIG_URL, serverHandler(t, TestData{Data: `{
+		"Classic_DNS": {"name": "Classic_DNS", "type": "observable"},
+		"VirusTotal_v3_Get_File": {"name": "VirusTotal_v3_Get_File", "type": "file", "external_service": true}
+	}`}, "GET"))
+	jobPages := []string{
+		`{"count": 3, "total_pages": 2, "results": [
+			{"id": 1, "user": {"username": "alice"}, "status": "reported_without_fails", "analyzers_to_execute": ["Classic_DNS"]},
+			{"id": 2, "user": {"username": "bob"}, "is_sample": true, "status": "reported_with_fails", "analyzers_to_execute": ["VirusTotal_v3_Get_File"]}
+		]}`,
+		`{"count": 3, "total_pages": 2, "results": [
+			{"id": 3, "user": {"username": "alice"}, "is_sample": true, "status": "failed", "analyzers_to_execute": ["Classic_DNS", "VirusTotal_v3_Get_File"]}
+		]}`,
+	}
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	apiHandler.HandleFunc(constants.BASE_JOB_URL, func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		testWantData(t, "2023-01-01T00:00:00Z", r.URL.Query().Get("received_request_time__gte"))
+		page := 1
+		if r.URL.Query().Get("page") == "2" {
+			page = 2
+		}
+		w.Write([]byte(jobPages[page-1]))
+	})
+	report, err := client.UserService.OrganizationActivity(context.Background(), &gothreatmatrix.ActivityOptions{From: from})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := &gothreatmatrix.ActivityReport{
+		From: from,
+		Members: []gothreatmatrix.MemberActivity{
+			{Username: "alice", Jobs: 2, Observables: 1, Samples: 1, AnalyzerRuns: 3, ExternalAnalyzerRuns: 1, Outcomes: map[gothreatmatrix.JobStatus]int{gothreatmatrix.REPORTED_WITHOUT_FAILS: 1, gothreatmatrix.FAILED: 1}},
+			{Username: "bob", Jobs: 1, Samples: 1, AnalyzerRuns: 1, ExternalAnalyzerRuns: 1, Outcomes: map[gothreatmatrix.JobStatus]int{gothreatmatrix.REPORTED_WITH_FAILS: 1}},
+		},
+		Total: gothreatmatrix.MemberActivity{Jobs: 3, Observables: 1, Samples: 2, AnalyzerRuns: 4, ExternalAnalyzerRuns: 2, Outcomes: map[gothreatmatrix.JobStatus]int{gothreatmatrix.REPORTED_WITHOUT_FAILS: 1, gothreatmatrix.REPORTED_WITH_FAILS: 1, gothreatmatrix.FAILED: 1}},
+	}
+	testWantData(t, want, report)
+	testWantData(t, 0.5, report.Members[0].OutcomeRatio(gothreatmatrix.FAILED))
+}
+
+func TestUserServiceMembers(t *testing.T) {
+	joined := time.Date(2022, 7, 24, 18, 43, 42, 0, time.UTC)
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["members"] = TestData{
+		Data: `{"members_count": 2, "name": "soc", "owner": {"username": "owner", "full_name": "The Owner", "joined": "2022-07-24T18:43:42Z"},
+			"members": [{"username": "owner", "full_name": "The Owner", "joined": "2022-07-24T18:43:42Z", "is_owner": true, "is_admin": true},
+			{"username": "analyst", "full_name": "An Analyst", "joined": "2022-07-24T18:43:42Z"}]}`,
+		StatusCode: http.StatusOK,
+		Want: []gothreatmatrix.Member{
+			{Username: "owner", FullName: "The Owner", Joined: joined, IsOwner: true, IsAdmin: true},
+			{Username: "analyst", FullName: "An Analyst", Joined: joined},
+		},
+	}
+	testCases["ownerOnly"] = TestData{
+		Data:       `{"members_count": 1, "name": "soc", "owner": {"username": "owner", "full_name": "The Owner", "joined": "2022-07-24T18:43:42Z"}}`,
+		StatusCode: http.StatusOK,
+		Want: []gothreatmatrix.Member{
+			{Username: "owner", FullName: "The Owner", Joined: joined, IsOwner: true, IsAdmin: true},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			apiHandler.Handle(constants.ORGANIZATION_URL, serverHandler(t, testCase, "GET"))
+			members, err := client.UserService.Members(context.Background())
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, members)
+			}
+		})
+	}
+}
+
+func TestUserServiceInvitations(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	ctx := context.Background()
+	apiHandler.Handle(constants.INVITATIONS_URL, serverHandler(t, TestData{
+		Data:       `[{"id": 12, "created_at": "2022-07-24T18:43:42Z", "status": "pending", "organization": {"name": "soc", "members_count": 3}}]`,
+		StatusCode: http.StatusOK,
+	}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.ACCEPT_INVITATION_URL, 12), serverHandler(t, TestData{StatusCode: http.StatusOK, Data: `{}`}, "POST"))
+	apiHandler.Handle(fmt.Sprintf(constants.DECLINE_INVITATION_URL, 13), serverHandler(t, TestData{
+		StatusCode: http.StatusNotFound,
+		Data:       `{"detail": "Not found."}`,
+	}, "POST"))
+	invitations, err := client.UserService.Invitations(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 1, len(invitations))
+	testWantData(t, 12, invitations[0].Id)
+	testWantData(t, "soc", invitations[0].Organization.Name)
+	if err := client.UserService.AcceptInvitation(ctx, 12); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := client.UserService.DeclineInvitation(ctx, 13); !gothreatmatrix.IsNotFound(err) {
+		t.Errorf("Expected a not found error, got: %v", err)
+	}
+}
+
+func TestUserServiceAPITokens(t *testing.T) {
+	client, apiHandler, closeServer := setup()
+	defer closeServer()
+	ctx := context.Background()
+	apiHandler.Handle(constants.AUTH_API_ACCESS_URL, serverHandler(t, TestData{
+		Data:       `{"id": 7, "token": "4f2e9a", "client": "gothreatmatrix", "created": "2022-07-24T18:43:42Z", "expiry": "2023-07-24T18:43:42Z"}`,
+		StatusCode: http.StatusCreated,
+	}, "POST"))
+	apiHandler.Handle(constants.AUTH_SESSIONS_URL, serverHandler(t, TestData{
+		Data:       `[{"id": 3, "client": "web", "has_expired": false, "is_current": true}, {"id": 7, "client": "gothreatmatrix", "has_expired": false, "is_current": false}]`,
+		StatusCode: http.StatusOK,
+	}, "GET"))
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_AUTH_SESSION_URL, 7), serverHandler(t, TestData{StatusCode: http.StatusNoContent}, "DELETE"))
+	apiHandler.Handle(fmt.Sprintf(constants.SPECIFIC_AUTH_SESSION_URL, 9), serverHandler(t, TestData{
+		StatusCode: http.StatusNotFound,
+		Data:       `{"detail": "Not found."}`,
+	}, "DELETE"))
+	apiToken, err := client.UserService.CreateAPIToken(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, "4f2e9a", apiToken.Token)
+	testWantData(t, time.Date(2023, 7, 24, 18, 43, 42, 0, time.UTC), *apiToken.Expiry)
+	apiTokens, err := client.UserService.ListTokens(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 2, len(apiTokens))
+	testWantData(t, true, apiTokens[0].IsCurrent)
+	testWantData(t, "", apiTokens[1].Token)
+	revoked, err := client.UserService.RevokeToken(ctx, 7)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, true, revoked)
+	if _, err := client.UserService.RevokeToken(ctx, 9); !gothreatmatrix.IsNotFound(err) {
+		t.Errorf("Expected a not found error, got: %v", err)
+	}
+}
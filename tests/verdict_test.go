@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestBenignNoiseRule(t *testing.T) {
+	type verdictInput struct {
+		Job     string
+		Verdict gothreatmatrix.Verdict
+	}
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["benignScanner"] = TestData{
+		Input: verdictInput{
+			Job:     `{"observable_classification": "ip", "analyzer_reports": [{"name": "GreyNoiseCommunity", "report": {"noise": true, "riot": false, "classification": "benign", "name": "Shodan.io"}}]}`,
+			Verdict: gothreatmatrix.MALICIOUS,
+		},
+		Want: gothreatmatrix.SUSPICIOUS,
+	}
+	testCases["riot"] = TestData{
+		Input: verdictInput{
+			Job:     `{"observable_classification": "ip", "analyzer_reports": [{"name": "GreyNoise", "report": {"riot": true, "name": "Google Public DNS"}}]}`,
+			Verdict: gothreatmatrix.SUSPICIOUS,
+		},
+		Want: gothreatmatrix.BENIGN,
+	}
+	testCases["maliciousScanner"] = TestData{
+		Input: verdictInput{
+			Job:     `{"observable_classification": "ip", "analyzer_reports": [{"name": "GreyNoiseCommunity", "report": {"noise": true, "riot": false, "classification": "malicious"}}]}`,
+			Verdict: gothreatmatrix.MALICIOUS,
+		},
+		Want: gothreatmatrix.MALICIOUS,
+	}
+	testCases["notAnIp"] = TestData{
+		Input: verdictInput{
+			Job:     `{"observable_classification": "domain", "analyzer_reports": [{"name": "GreyNoise", "report": {"riot": true}}]}`,
+			Verdict: gothreatmatrix.MALICIOUS,
+		},
+		Want: gothreatmatrix.MALICIOUS,
+	}
+	testCases["noNoiseClassification"] = TestData{
+		Input: verdictInput{
+			Job:     `{"observable_classification": "ip", "analyzer_reports": [{"name": "Classic_DNS", "report": {"resolutions": []}}]}`,
+			Verdict: gothreatmatrix.SUSPICIOUS,
+		},
+		Want: gothreatmatrix.SUSPICIOUS,
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			input := testCase.Input.(verdictInput)
+			job := gothreatmatrix.Job{}
+			if err := json.Unmarshal([]byte(input.Job), &job); err != nil {
+				t.Fatalf("Error: %s", err)
+			}
+			verdict := gothreatmatrix.ApplyVerdictRules(&job, input.Verdict, gothreatmatrix.BENIGN_NOISE_RULE)
+			testWantData(t, testCase.Want, verdict)
+		})
+	}
+}
+
+func TestJobVerdict(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["malicious"] = TestData{
+		Input: `{"id": 1, "data_model": {"id": 3, "evaluation": "malicious", "reliability": 8, "kill_chain_phase": "c2"}}`,
+		Want:  gothreatmatrix.MALICIOUS,
+	}
+	testCases["suspicious"] = TestData{
+		Input: `{"id": 1, "data_model": {"evaluation": "suspicious"}}`,
+		Want:  gothreatmatrix.SUSPICIOUS,
+	}
+	testCases["trusted"] = TestData{
+		Input: `{"id": 1, "data_model": {"evaluation": "trusted", "reliability": 10}}`,
+		Want:  gothreatmatrix.BENIGN,
+	}
+	testCases["notConcluded"] = TestData{
+		Input: `{"id": 1, "data_model": {"evaluation": null}}`,
+		Want:  gothreatmatrix.UNKNOWN,
+	}
+	testCases["noDataModel"] = TestData{
+		Input: `{"id": 1}`,
+		Want:  gothreatmatrix.UNKNOWN,
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			job := gothreatmatrix.Job{}
+			if err := json.Unmarshal([]byte(testCase.Input.(string)), &job); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			testWantData(t, testCase.Want, job.Verdict())
+		})
+	}
+	job := gothreatmatrix.Job{}
+	json.Unmarshal([]byte(testCases["malicious"].Input.(string)), &job)
+	testWantData(t, 8, job.DataModel.Reliability)
+	testWantData(t, "c2", job.DataModel.KillChainPhase)
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+func TestVisualizerServiceGetConfigs(t *testing.T) {
+	visualizerConfigJsonString := `{"Yara":{"name":"Yara","python_module":"yara.Yara","disabled":false,"description":"Visualize the Yara matches","playbooks":["FREE_TO_USE_ANALYZERS"]},"DNS":{"name":"DNS","python_module":"dns.DNS","disabled":false,"description":"Visualize the DNS resolutions","playbooks":["DNS"]}}`
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      nil,
+		Data:       visualizerConfigJsonString,
+		StatusCode: http.StatusOK,
+		Want: []gothreatmatrix.VisualizerConfig{
+			{BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "DNS", PythonModule: "dns.DNS", Description: "Visualize the DNS resolutions"}, Playbooks: []string{"DNS"}},
+			{BaseConfigurationType: gothreatmatrix.BaseConfigurationType{Name: "Yara", PythonModule: "yara.Yara", Description: "Visualize the Yara matches"}, Playbooks: []string{"FREE_TO_USE_ANALYZERS"}},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			apiHandler.Handle(constants.VISUALIZER_CONFIG_URL, serverHandler(t, testCase, "GET"))
+			gottenVisualizerConfigList, err := client.VisualizerService.GetConfigs(ctx)
+			if err != nil {
+				testError(t, testCase, err)
+			} else {
+				testWantData(t, testCase.Want, *gottenVisualizerConfigList)
+			}
+		})
+	}
+}
+
+func TestVisualizerServiceHealthCheck(t *testing.T) {
+	// * table test cases
+	testCases := make(map[string]TestData)
+	testCases["simple"] = TestData{
+		Input:      "DNS",
+		Data:       `{"status": true}`,
+		StatusCode: http.StatusOK,
+		Want:       true,
+	}
+	testCases["visualizerDoesntExist"] = TestData{
+		Input:      "notAVisualizer",
+		Data:       `{"errors": {"detail": "Visualizer doesn't exist"}}`,
+		StatusCode: http.StatusBadRequest,
+		Want: &gothreatmatrix.ThreatMatrixError{
+			StatusCode: http.StatusBadRequest,
+			Message:    `{"errors": {"detail": "Visualizer doesn't exist"}}`,
+			Errors:     map[string]interface{}{"detail": "Visualizer doesn't exist"},
+		},
+	}
+	for name, testCase := range testCases {
+		// *Subtest
+		t.Run(name, func(t *testing.T) {
+			client, apiHandler, closeServer := setup()
+			defer closeServer()
+			ctx := context.Background()
+			input, ok := testCase.Input.(string)
+			if ok {
+				testUrl := fmt.Sprintf(constants.VISUALIZER_HEALTHCHECK_URL, input)
+				apiHandler.Handle(testUrl, serverHandler(t, testCase, "GET"))
+				status, err := client.VisualizerService.HealthCheck(ctx, input)
+				if err != nil {
+					testError(t, testCase, err)
+				} else {
+					testWantData(t, testCase.Want, status)
+				}
+			}
+		})
+	}
+}
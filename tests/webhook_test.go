@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/khulnasoft/go-threatmatrix/webhook"
+)
+
+func TestWebhookReceiver(t *testing.T) {
+	completedEvent := `{"schema_version": 1, "event": "job_completed", "job": {"id": 42, "status": "reported_without_fails"}}`
+	now := time.Now().Unix()
+	testCases := map[string]struct {
+		method    string
+		body      string
+		signature string
+		// timestamp is the TIMESTAMP_HEADER sent, none when empty
+		timestamp  string
+		failing    bool
+		wantStatus int
+		wantEvents []string
+	}{
+		"signed": {
+			body:       completedEvent,
+			signature:  webhook.Sign([]byte(completedEvent), now, "secret"),
+			timestamp:  fmt.Sprint(now),
+			wantStatus: http.StatusNoContent,
+			wantEvents: []string{"completed 42", "any job_completed"},
+		},
+		"otherEvent": {
+			body:       `{"event": "report_completed", "job": {"id": 42}, "report": {"name": "Classic_DNS"}}`,
+			signature:  webhook.Sign([]byte(`{"event": "report_completed", "job": {"id": 42}, "report": {"name": "Classic_DNS"}}`), now, "secret"),
+			timestamp:  fmt.Sprint(now),
+			wantStatus: http.StatusNoContent,
+			wantEvents: []string{"any report_completed"},
+		},
+		"wrongSignature": {
+			body:       completedEvent,
+			signature:  webhook.Sign([]byte(completedEvent), now, "other"),
+			timestamp:  fmt.Sprint(now),
+			wantStatus: http.StatusUnauthorized,
+			wantEvents: []string{},
+		},
+		"unsigned": {
+			body:       completedEvent,
+			timestamp:  fmt.Sprint(now),
+			wantStatus: http.StatusUnauthorized,
+			wantEvents: []string{},
+		},
+		"withinTolerance": {
+			body:       completedEvent,
+			signature:  webhook.Sign([]byte(completedEvent), now-240, "secret"),
+			timestamp:  fmt.Sprint(now - 240),
+			wantStatus: http.StatusNoContent,
+			wantEvents: []string{"completed 42", "any job_completed"},
+		},
+		"replayed": {
+			body:       completedEvent,
+			signature:  webhook.Sign([]byte(completedEvent), now-600, "secret"),
+			timestamp:  fmt.Sprint(now - 600),
+			wantStatus: http.StatusUnauthorized,
+			wantEvents: []string{},
+		},
+		"future": {
+			body:       completedEvent,
+			signature:  webhook.Sign([]byte(completedEvent), now+600, "secret"),
+			timestamp:  fmt.Sprint(now + 600),
+			wantStatus: http.StatusUnauthorized,
+			wantEvents: []string{},
+		},
+		// * a replayed event with a fresh timestamp does not match its signature
+		"timestampNotSigned": {
+			body:       completedEvent,
+			signature:  webhook.Sign([]byte(completedEvent), now-600, "secret"),
+			timestamp:  fmt.Sprint(now),
+			wantStatus: http.StatusUnauthorized,
+			wantEvents: []string{},
+		},
+		"missingTimestamp": {
+			body:       completedEvent,
+			signature:  webhook.Sign([]byte(completedEvent), now, "secret"),
+			wantStatus: http.StatusUnauthorized,
+			wantEvents: []string{},
+		},
+		"notAnEvent": {
+			body:       `{"id": 42}`,
+			signature:  webhook.Sign([]byte(`{"id": 42}`), now, "secret"),
+			timestamp:  fmt.Sprint(now),
+			wantStatus: http.StatusBadRequest,
+			wantEvents: []string{},
+		},
+		"handlerError": {
+			body:       completedEvent,
+			signature:  webhook.Sign([]byte(completedEvent), now, "secret"),
+			timestamp:  fmt.Sprint(now),
+			failing:    true,
+			wantStatus: http.StatusInternalServerError,
+			wantEvents: []string{"completed 42"},
+		},
+		"get": {
+			method:     "GET",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantEvents: []string{},
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			events := []string{}
+			receiver := webhook.NewReceiver(&webhook.Options{Secret: "secret"})
+			receiver.Handle(gothreatmatrix.JOB_COMPLETED_EVENT, func(ctx context.Context, event *gothreatmatrix.JobEvent) error {
+				events = append(events, fmt.Sprintf("completed %d", event.Job.ID))
+				if testCase.failing {
+					return errors.New("pipeline down")
+				}
+				return nil
+			})
+			receiver.Handle(webhook.ANY_EVENT, func(ctx context.Context, event *gothreatmatrix.JobEvent) error {
+				events = append(events, "any "+event.Event)
+				return nil
+			})
+			method := testCase.method
+			if method == "" {
+				method = "POST"
+			}
+			request := httptest.NewRequest(method, "/webhook", bytes.NewBufferString(testCase.body))
+			if testCase.signature != "" {
+				request.Header.Set(gothreatmatrix.SIGNATURE_HEADER, testCase.signature)
+			}
+			if testCase.timestamp != "" {
+				request.Header.Set(gothreatmatrix.TIMESTAMP_HEADER, testCase.timestamp)
+			}
+			recorder := httptest.NewRecorder()
+			receiver.ServeHTTP(recorder, request)
+			testWantData(t, testCase.wantStatus, recorder.Code)
+			testWantData(t, testCase.wantEvents, events)
+		})
+	}
+}
+
+func TestWebhookReceiverTolerance(t *testing.T) {
+	receiver := webhook.NewReceiver(&webhook.Options{Secret: "secret", Tolerance: 30 * time.Second})
+	receiver.Handle(webhook.ANY_EVENT, func(ctx context.Context, event *gothreatmatrix.JobEvent) error {
+		return nil
+	})
+	body := `{"event": "job_completed", "job": {"id": 42}}`
+	for age, wantStatus := range map[int64]int{10: http.StatusNoContent, 60: http.StatusUnauthorized} {
+		timestamp := time.Now().Unix() - age
+		request := httptest.NewRequest("POST", "/webhook", bytes.NewBufferString(body))
+		request.Header.Set(gothreatmatrix.SIGNATURE_HEADER, webhook.Sign([]byte(body), timestamp, "secret"))
+		request.Header.Set(gothreatmatrix.TIMESTAMP_HEADER, fmt.Sprint(timestamp))
+		recorder := httptest.NewRecorder()
+		receiver.ServeHTTP(recorder, request)
+		if recorder.Code != wantStatus {
+			t.Errorf("Signed %ds ago: %d, want %d", age, recorder.Code, wantStatus)
+		}
+	}
+}
+
+func TestWebhookSinkToReceiver(t *testing.T) {
+	received := make(chan int, 1)
+	receiver := webhook.NewReceiver(&webhook.Options{Secret: "secret"})
+	receiver.Handle(gothreatmatrix.JOB_COMPLETED_EVENT, func(ctx context.Context, event *gothreatmatrix.JobEvent) error {
+		received <- event.Job.ID
+		return nil
+	})
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	job := &gothreatmatrix.Job{}
+	job.ID = 7
+	sink := &gothreatmatrix.WebhookSink{Url: server.URL, Secret: "secret"}
+	if err := sink.WriteJob(context.Background(), job); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	testWantData(t, 7, <-received)
+}
@@ -0,0 +1,144 @@
+package threatmatrixtest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// Values of the Status of the reports the fixtures build.
+const (
+	REPORT_SUCCESS = "SUCCESS"
+	REPORT_FAILED  = "FAILED"
+)
+
+// FIXTURE_USERNAME is the user of the jobs the fixtures build.
+const FIXTURE_USERNAME = "threatmatrixtest"
+
+// Analyzer returns the configuration of an enabled observable analyzer supporting the classifications.
+func Analyzer(name string, observableSupported ...string) gothreatmatrix.AnalyzerConfig {
+	return gothreatmatrix.AnalyzerConfig{
+		BaseConfigurationType: gothreatmatrix.BaseConfigurationType{
+			Name:         name,
+			PythonModule: "threatmatrixtest.Analyzer",
+			Secrets:      map[string]gothreatmatrix.Secret{},
+			Params:       map[string]gothreatmatrix.Parameter{},
+			Verification: gothreatmatrix.VerificationType{Configured: true},
+		},
+		Type:                  "observable",
+		ObservableSupported:   observableSupported,
+		SupportedFiletypes:    []string{},
+		NotSupportedFiletypes: []string{},
+	}
+}
+
+// FileAnalyzer returns the configuration of an enabled file analyzer supporting the mime types, every one when none is given.
+func FileAnalyzer(name string, supportedFiletypes ...string) gothreatmatrix.AnalyzerConfig {
+	analyzerConfig := Analyzer(name)
+	analyzerConfig.Type = "file"
+	analyzerConfig.ObservableSupported = []string{}
+	if supportedFiletypes != nil {
+		analyzerConfig.SupportedFiletypes = supportedFiletypes
+	}
+	return analyzerConfig
+}
+
+// Connector returns the configuration of an enabled connector.
+func Connector(name string) gothreatmatrix.ConnectorConfig {
+	return gothreatmatrix.ConnectorConfig{
+		BaseConfigurationType: gothreatmatrix.BaseConfigurationType{
+			Name:         name,
+			PythonModule: "threatmatrixtest.Connector",
+			Secrets:      map[string]gothreatmatrix.Secret{},
+			Params:       map[string]gothreatmatrix.Parameter{},
+			Verification: gothreatmatrix.VerificationType{Configured: true},
+		},
+		MaximumTlp: gothreatmatrix.AMBER,
+	}
+}
+
+// Playbook returns the configuration of an enabled playbook running the analyzers on observables and files.
+func Playbook(name string, analyzers ...string) gothreatmatrix.PlaybookConfig {
+	playbookConfig := gothreatmatrix.PlaybookConfig{
+		Name:       name,
+		Analyzers:  map[string]interface{}{},
+		Connectors: map[string]interface{}{},
+		Supports:   []string{"ip", "url", "domain", "hash", "generic", "file"},
+		ScanMode:   gothreatmatrix.SCAN_MODE_FORCE_NEW,
+	}
+	for _, analyzerName := range analyzers {
+		playbookConfig.Analyzers[analyzerName] = map[string]interface{}{}
+	}
+	return playbookConfig
+}
+
+// ObservableJob returns a job of the analysis of the observable, without reports.
+// Its finished analysis time is set when the status is terminal.
+func ObservableJob(id int, observableName string, status gothreatmatrix.JobStatus) gothreatmatrix.Job {
+	hash := md5.Sum([]byte(observableName))
+	job := baseJob(id, status)
+	job.ObservableName = observableName
+	job.Md5 = hex.EncodeToString(hash[:])
+	return job
+}
+
+// FileJob returns a job of the analysis of the file of the md5, without reports.
+// Its finished analysis time is set when the status is terminal.
+func FileJob(id int, fileName string, md5 string, status gothreatmatrix.JobStatus) gothreatmatrix.Job {
+	job := baseJob(id, status)
+	job.IsSample = true
+	job.FileName = fileName
+	job.Md5 = md5
+	return job
+}
+
+// baseJob returns the fields shared by the jobs of the fixtures.
+func baseJob(id int, status gothreatmatrix.JobStatus) gothreatmatrix.Job {
+	received := time.Now().UTC()
+	job := gothreatmatrix.Job{
+		BaseJob: gothreatmatrix.BaseJob{
+			ID:                  id,
+			User:                gothreatmatrix.UserDetails{Username: FIXTURE_USERNAME},
+			Tags:                []gothreatmatrix.Tag{},
+			Status:              string(status),
+			AnalyzersRequested:  []string{},
+			ConnectorsRequested: []string{},
+			AnalyzersToExecute:  []string{},
+			ConnectorsToExecute: []string{},
+			ReceivedRequestTime: &received,
+			Tlp:                 gothreatmatrix.WHITE.String(),
+			Errors:              []string{},
+		},
+		AnalyzerReports:  []gothreatmatrix.Report{},
+		ConnectorReports: []gothreatmatrix.Report{},
+		Permission:       map[string]interface{}{},
+	}
+	if status.IsTerminal() {
+		job.FinishedAnalysisTime = &received
+	}
+	return job
+}
+
+// SuccessReport returns a successful report of the plugin.
+func SuccessReport(name string, report map[string]interface{}) gothreatmatrix.Report {
+	now := time.Now().UTC()
+	return gothreatmatrix.Report{
+		Name:                 name,
+		Status:               REPORT_SUCCESS,
+		Report:               report,
+		Errors:               []string{},
+		StartTime:            now,
+		EndTime:              now,
+		RuntimeConfiguration: map[string]interface{}{},
+	}
+}
+
+// FailedReport returns a failed report of the plugin with the errors.
+func FailedReport(name string, errors ...string) gothreatmatrix.Report {
+	report := SuccessReport(name, map[string]interface{}{})
+	report.Status = REPORT_FAILED
+	report.Errors = append(report.Errors, errors...)
+	return report
+}
@@ -0,0 +1,478 @@
+// Package threatmatrixtest provides an in-memory fake ThreatMatrix instance and fixture builders,
+// so the integrations of gothreatmatrix can be unit tested without a live instance.
+//
+//	server := threatmatrixtest.NewServer()
+//	defer server.Close()
+//	server.AddAnalyzer(threatmatrixtest.Analyzer("Classic_DNS", "domain"))
+//	client := server.Client()
+//	analysisResponse, err := client.CreateObservableAnalysis(ctx, params)
+package threatmatrixtest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/constants"
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+	"github.com/sirupsen/logrus"
+)
+
+// TOKEN is the API key the Server accepts, the one of the Client it returns.
+const TOKEN = "threatmatrixtest-token"
+
+// Submission represents an analysis the Server received.
+type Submission struct {
+	// Route is the endpoint of the submission, e.g. constants.ANALYZE_OBSERVABLE_URL.
+	Route                    string
+	Params                   gothreatmatrix.BasicAnalysisParams
+	ObservableName           string
+	ObservableClassification string
+	FileName                 string
+	JobID                    int
+}
+
+// Server is a fake ThreatMatrix instance serving, from memory, the plugin configurations, the analyze endpoints
+// and the job endpoints. Every submission creates a job, completed right away with a successful report
+// of every analyzer to execute unless OnSubmit changes it.
+type Server struct {
+	*httptest.Server
+
+	// OnSubmit, when set, is called with every job the submissions create, before it is saved:
+	// it can change its status or reports, e.g. to leave it running.
+	OnSubmit func(job *gothreatmatrix.Job)
+
+	mutex       sync.Mutex
+	analyzers   map[string]gothreatmatrix.AnalyzerConfig
+	connectors  map[string]gothreatmatrix.ConnectorConfig
+	playbooks   map[string]gothreatmatrix.PlaybookConfig
+	jobs        map[int]*gothreatmatrix.Job
+	nextJobID   int
+	submissions []Submission
+}
+
+// NewServer starts a Server without any plugins nor jobs. It must be closed once the test is over.
+func NewServer() *Server {
+	server := &Server{
+		analyzers:  map[string]gothreatmatrix.AnalyzerConfig{},
+		connectors: map[string]gothreatmatrix.ConnectorConfig{},
+		playbooks:  map[string]gothreatmatrix.PlaybookConfig{},
+		jobs:       map[int]*gothreatmatrix.Job{},
+		nextJobID:  1,
+	}
+	apiHandler := http.NewServeMux()
+	apiHandler.HandleFunc(constants.ANALYZER_CONFIG_URL, func(w http.ResponseWriter, r *http.Request) {
+		server.serveConfigs(w, r, server.analyzers)
+	})
+	apiHandler.HandleFunc(constants.CONNECTOR_CONFIG_URL, func(w http.ResponseWriter, r *http.Request) {
+		server.serveConfigs(w, r, server.connectors)
+	})
+	apiHandler.HandleFunc(constants.PLAYBOOK_CONFIG_URL, func(w http.ResponseWriter, r *http.Request) {
+		server.serveConfigs(w, r, server.playbooks)
+	})
+	apiHandler.HandleFunc(constants.ANALYZE_OBSERVABLE_URL, server.serveAnalyzeObservable)
+	apiHandler.HandleFunc(constants.ANALYZE_MULTIPLE_OBSERVABLES_URL, server.serveAnalyzeMultipleObservables)
+	apiHandler.HandleFunc(constants.ANALYZE_FILE_URL, server.serveAnalyzeFile)
+	apiHandler.HandleFunc(constants.ANALYZE_MULTIPLE_FILES_URL, server.serveAnalyzeFile)
+	apiHandler.HandleFunc(constants.BASE_JOB_URL, server.serveJobs)
+	apiHandler.HandleFunc(constants.BASE_JOB_URL+"/", server.serveJob)
+	server.Server = httptest.NewServer(server.authenticate(apiHandler))
+	return server
+}
+
+// Client returns a client of the Server, authenticated with TOKEN.
+func (server *Server) Client() gothreatmatrix.ThreatMatrixClient {
+	return gothreatmatrix.NewThreatMatrixClient(
+		&gothreatmatrix.ThreatMatrixClientOptions{
+			Url:   server.URL,
+			Token: TOKEN,
+		},
+		nil,
+		&gothreatmatrix.LoggerParams{
+			Level: logrus.WarnLevel,
+		},
+	)
+}
+
+// AddAnalyzer adds the analyzer to the configurations of the Server, replacing the one of the same name.
+func (server *Server) AddAnalyzer(analyzerConfig gothreatmatrix.AnalyzerConfig) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.analyzers[analyzerConfig.Name] = analyzerConfig
+}
+
+// AddConnector adds the connector to the configurations of the Server, replacing the one of the same name.
+func (server *Server) AddConnector(connectorConfig gothreatmatrix.ConnectorConfig) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.connectors[connectorConfig.Name] = connectorConfig
+}
+
+// AddPlaybook adds the playbook to the configurations of the Server, replacing the one of the same name.
+func (server *Server) AddPlaybook(playbookConfig gothreatmatrix.PlaybookConfig) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.playbooks[playbookConfig.Name] = playbookConfig
+}
+
+// AddJob saves the job, replacing the one of the same ID. A job without an ID is given the next one,
+// which is returned.
+func (server *Server) AddJob(job gothreatmatrix.Job) int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if job.ID == 0 {
+		job.ID = server.nextJobID
+	}
+	if job.ID >= server.nextJobID {
+		server.nextJobID = job.ID + 1
+	}
+	server.jobs[job.ID] = &job
+	return job.ID
+}
+
+// Job returns a copy of the job, ok is false when it does not exist.
+func (server *Server) Job(id int) (job gothreatmatrix.Job, ok bool) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	saved, ok := server.jobs[id]
+	if !ok {
+		return job, false
+	}
+	return *saved, true
+}
+
+// SetJobStatus changes the status of the job, e.g. to complete a job OnSubmit left running.
+// The finished analysis time is set when the status is terminal.
+func (server *Server) SetJobStatus(id int, status gothreatmatrix.JobStatus) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	job, ok := server.jobs[id]
+	if !ok {
+		return false
+	}
+	job.Status = string(status)
+	if status.IsTerminal() {
+		finished := time.Now().UTC()
+		job.FinishedAnalysisTime = &finished
+	}
+	return true
+}
+
+// Submissions returns the analyses the Server received, in order.
+func (server *Server) Submissions() []Submission {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return append([]Submission{}, server.submissions...)
+}
+
+// authenticate rejects the requests that are not authenticated with TOKEN, as ThreatMatrix does.
+func (server *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Authorization"), "token "+TOKEN) {
+			writeDetail(w, http.StatusUnauthorized, "Invalid token.")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveConfigs serves the plugin configurations, keyed by plugin name.
+func (server *Server) serveConfigs(w http.ResponseWriter, r *http.Request, configs interface{}) {
+	if r.Method != http.MethodGet {
+		writeDetail(w, http.StatusMethodNotAllowed, fmt.Sprintf("Method \"%s\" not allowed.", r.Method))
+		return
+	}
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	writeJson(w, http.StatusOK, configs)
+}
+
+// serveAnalyzeObservable serves POST /api/analyze_observable.
+func (server *Server) serveAnalyzeObservable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeDetail(w, http.StatusMethodNotAllowed, fmt.Sprintf("Method \"%s\" not allowed.", r.Method))
+		return
+	}
+	params := gothreatmatrix.ObservableAnalysisParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeJson(w, http.StatusBadRequest, map[string][]string{"non_field_errors": {err.Error()}})
+		return
+	}
+	if params.ObservableName == "" {
+		writeJson(w, http.StatusBadRequest, map[string][]string{"observable_name": {"This field is required."}})
+		return
+	}
+	analysisResponse, err := server.submit(Submission{
+		Route:                    constants.ANALYZE_OBSERVABLE_URL,
+		Params:                   params.BasicAnalysisParams,
+		ObservableName:           params.ObservableName,
+		ObservableClassification: params.ObservableClassification,
+	})
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, map[string][]string{"non_field_errors": {err.Error()}})
+		return
+	}
+	writeJson(w, http.StatusOK, analysisResponse)
+}
+
+// serveAnalyzeMultipleObservables serves POST /api/analyze_multiple_observables.
+func (server *Server) serveAnalyzeMultipleObservables(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeDetail(w, http.StatusMethodNotAllowed, fmt.Sprintf("Method \"%s\" not allowed.", r.Method))
+		return
+	}
+	params := gothreatmatrix.MultipleObservableAnalysisParams{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeJson(w, http.StatusBadRequest, map[string][]string{"non_field_errors": {err.Error()}})
+		return
+	}
+	multipleAnalysisResponse := gothreatmatrix.MultipleAnalysisResponse{Results: []gothreatmatrix.AnalysisResponse{}}
+	for _, observable := range params.Observables {
+		// * every observable is a [classification, name] pair
+		if len(observable) != 2 {
+			writeJson(w, http.StatusBadRequest, map[string][]string{"observables": {"Every observable must be a [classification, name] pair."}})
+			return
+		}
+		analysisResponse, err := server.submit(Submission{
+			Route:                    constants.ANALYZE_MULTIPLE_OBSERVABLES_URL,
+			Params:                   params.BasicAnalysisParams,
+			ObservableName:           observable[1],
+			ObservableClassification: observable[0],
+		})
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string][]string{"non_field_errors": {err.Error()}})
+			return
+		}
+		multipleAnalysisResponse.Results = append(multipleAnalysisResponse.Results, *analysisResponse)
+	}
+	multipleAnalysisResponse.Count = len(multipleAnalysisResponse.Results)
+	writeJson(w, http.StatusOK, multipleAnalysisResponse)
+}
+
+// serveAnalyzeFile serves POST /api/analyze_file and POST /api/analyze_multiple_files.
+func (server *Server) serveAnalyzeFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeDetail(w, http.StatusMethodNotAllowed, fmt.Sprintf("Method \"%s\" not allowed.", r.Method))
+		return
+	}
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeJson(w, http.StatusBadRequest, map[string][]string{"non_field_errors": {err.Error()}})
+		return
+	}
+	form := r.MultipartForm
+	params := gothreatmatrix.BasicAnalysisParams{
+		Tlp:                 gothreatmatrix.ParseTLP(r.FormValue("tlp")),
+		AnalyzersRequested:  form.Value["analyzers_requested"],
+		ConnectorsRequested: form.Value["connectors_requested"],
+		TagsLabels:          form.Value["tags_labels"],
+		PlaybookRequested:   r.FormValue("playbook_requested"),
+	}
+	if runtimeConfiguration := r.FormValue("runtime_configuration"); runtimeConfiguration != "" {
+		if err := json.Unmarshal([]byte(runtimeConfiguration), &params.RuntimeConfiguration); err != nil {
+			writeJson(w, http.StatusBadRequest, map[string][]string{"runtime_configuration": {err.Error()}})
+			return
+		}
+	}
+	field := "file"
+	if r.URL.Path == constants.ANALYZE_MULTIPLE_FILES_URL {
+		field = "files"
+	}
+	files := form.File[field]
+	if len(files) == 0 {
+		writeJson(w, http.StatusBadRequest, map[string][]string{field: {"No file was submitted."}})
+		return
+	}
+	results := []gothreatmatrix.AnalysisResponse{}
+	for _, fileHeader := range files {
+		file, err := fileHeader.Open()
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string][]string{field: {err.Error()}})
+			return
+		}
+		hash := md5.New()
+		_, err = io.Copy(hash, file)
+		file.Close()
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string][]string{field: {err.Error()}})
+			return
+		}
+		analysisResponse, err := server.submit(Submission{
+			Route:          r.URL.Path,
+			Params:         params,
+			ObservableName: hex.EncodeToString(hash.Sum(nil)),
+			FileName:       fileHeader.Filename,
+		})
+		if err != nil {
+			writeJson(w, http.StatusBadRequest, map[string][]string{"non_field_errors": {err.Error()}})
+			return
+		}
+		results = append(results, *analysisResponse)
+	}
+	if field == "file" {
+		writeJson(w, http.StatusOK, results[0])
+		return
+	}
+	writeJson(w, http.StatusOK, gothreatmatrix.MultipleAnalysisResponse{Count: len(results), Results: results})
+}
+
+// submit records the submission and creates its job. The ObservableName of a file submission is the md5 of the file.
+func (server *Server) submit(submission Submission) (*gothreatmatrix.AnalysisResponse, error) {
+	server.mutex.Lock()
+	analyzers := submission.Params.AnalyzersRequested
+	connectors := submission.Params.ConnectorsRequested
+	if playbookName := submission.Params.PlaybookRequested; playbookName != "" {
+		playbookConfig, ok := server.playbooks[playbookName]
+		if !ok {
+			server.mutex.Unlock()
+			return nil, fmt.Errorf("playbook %s does not exist", playbookName)
+		}
+		if len(analyzers) == 0 {
+			analyzers = sortedKeys(playbookConfig.Analyzers)
+		}
+		if len(connectors) == 0 {
+			connectors = sortedKeys(playbookConfig.Connectors)
+		}
+	}
+	if len(analyzers) == 0 {
+		analyzers = sortedKeys(server.analyzers)
+	}
+	for _, analyzerName := range analyzers {
+		if _, ok := server.analyzers[analyzerName]; !ok {
+			server.mutex.Unlock()
+			return nil, fmt.Errorf("analyzer %s does not exist", analyzerName)
+		}
+	}
+	var job gothreatmatrix.Job
+	if submission.FileName != "" {
+		job = FileJob(server.nextJobID, submission.FileName, submission.ObservableName, gothreatmatrix.REPORTED_WITHOUT_FAILS)
+	} else {
+		job = ObservableJob(server.nextJobID, submission.ObservableName, gothreatmatrix.REPORTED_WITHOUT_FAILS)
+		job.ObservableClassification = submission.ObservableClassification
+	}
+	server.nextJobID++
+	job.Tlp = submission.Params.Tlp.String()
+	job.AnalyzersRequested = submission.Params.AnalyzersRequested
+	job.ConnectorsRequested = submission.Params.ConnectorsRequested
+	job.AnalyzersToExecute = analyzers
+	job.ConnectorsToExecute = connectors
+	for _, label := range submission.Params.TagsLabels {
+		job.Tags = append(job.Tags, gothreatmatrix.Tag{Label: label})
+	}
+	for _, analyzerName := range analyzers {
+		job.AnalyzerReports = append(job.AnalyzerReports, SuccessReport(analyzerName, map[string]interface{}{}))
+	}
+	for _, connectorName := range connectors {
+		job.ConnectorReports = append(job.ConnectorReports, SuccessReport(connectorName, map[string]interface{}{}))
+	}
+	onSubmit := server.OnSubmit
+	server.mutex.Unlock()
+	// * the hook may call the other methods of the server
+	if onSubmit != nil {
+		onSubmit(&job)
+	}
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.jobs[job.ID] = &job
+	submission.JobID = job.ID
+	server.submissions = append(server.submissions, submission)
+	return &gothreatmatrix.AnalysisResponse{
+		JobID:             job.ID,
+		Status:            "accepted",
+		Warnings:          []string{},
+		AnalyzersRunning:  analyzers,
+		ConnectorsRunning: connectors,
+	}, nil
+}
+
+// serveJobs serves GET /api/jobs, the jobs being listed from the newest and filtered by status and observable name.
+func (server *Server) serveJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeDetail(w, http.StatusMethodNotAllowed, fmt.Sprintf("Method \"%s\" not allowed.", r.Method))
+		return
+	}
+	query := r.URL.Query()
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	jobListResponse := gothreatmatrix.JobListResponse{Results: []gothreatmatrix.JobList{}}
+	for _, job := range server.jobs {
+		if status := query.Get("status"); status != "" && job.Status != status {
+			continue
+		}
+		if observableName := query.Get("observable_name"); observableName != "" && job.ObservableName != observableName {
+			continue
+		}
+		jobListResponse.Results = append(jobListResponse.Results, gothreatmatrix.JobList{BaseJob: job.BaseJob})
+	}
+	sort.Slice(jobListResponse.Results, func(i, j int) bool {
+		return jobListResponse.Results[i].ID > jobListResponse.Results[j].ID
+	})
+	jobListResponse.Count = len(jobListResponse.Results)
+	jobListResponse.TotalPages = 1
+	writeJson(w, http.StatusOK, jobListResponse)
+}
+
+// serveJob serves GET and DELETE /api/jobs/{id} and PATCH /api/jobs/{id}/kill.
+func (server *Server) serveJob(w http.ResponseWriter, r *http.Request) {
+	rawID, route, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, constants.BASE_JOB_URL+"/"), "/")
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		writeDetail(w, http.StatusNotFound, "Not found.")
+		return
+	}
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	job, ok := server.jobs[id]
+	if !ok {
+		writeDetail(w, http.StatusNotFound, "Not found.")
+		return
+	}
+	switch {
+	case route == "" && r.Method == http.MethodGet:
+		writeJson(w, http.StatusOK, job)
+	case route == "" && r.Method == http.MethodDelete:
+		delete(server.jobs, id)
+		w.WriteHeader(http.StatusNoContent)
+	case route == "kill" && r.Method == http.MethodPatch:
+		if gothreatmatrix.JobStatus(job.Status).IsTerminal() {
+			writeJson(w, http.StatusBadRequest, map[string]string{"detail": "Job is not running"})
+			return
+		}
+		job.Status = string(gothreatmatrix.KILLED)
+		w.WriteHeader(http.StatusNoContent)
+	case route == "" || route == "kill":
+		writeDetail(w, http.StatusMethodNotAllowed, fmt.Sprintf("Method \"%s\" not allowed.", r.Method))
+	default:
+		writeDetail(w, http.StatusNotFound, "Not found.")
+	}
+}
+
+// sortedKeys returns the keys of the map, sorted.
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeDetail writes an error body the way ThreatMatrix does, e.g. {"detail": "Not found."}.
+func writeDetail(w http.ResponseWriter, statusCode int, detail string) {
+	writeJson(w, statusCode, map[string]string{"detail": detail})
+}
+
+// writeJson writes the value as a JSON body.
+func writeJson(w http.ResponseWriter, statusCode int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(value)
+}
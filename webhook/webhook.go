@@ -0,0 +1,182 @@
+// Package webhook receives the job events posted by ThreatMatrix callbacks and by gothreatmatrix.WebhookSink,
+// verifies their signature and dispatches them to the handlers registered for them, so pipelines can react to
+// finished jobs instead of polling for them.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/khulnasoft/go-threatmatrix/gothreatmatrix"
+)
+
+// DEFAULT_MAX_BYTES is the largest event a Receiver accepts when Options leaves MaxBytes at 0.
+const DEFAULT_MAX_BYTES = 8 << 20
+
+// ANY_EVENT registers a handler for every event, whatever its name.
+const ANY_EVENT = ""
+
+// DEFAULT_TOLERANCE is how far the timestamp of a signed event may be from the clock of a Receiver when Options
+// leaves Tolerance at 0.
+const DEFAULT_TOLERANCE = 5 * time.Minute
+
+// ErrNotAnEvent is returned by ParseEvent for a JSON body with no event name or no job.
+var ErrNotAnEvent = errors.New("webhook: not a job event")
+
+// HandlerFunc handles an event. An error makes the Receiver answer 500, so that the sender retries the event.
+type HandlerFunc func(ctx context.Context, event *gothreatmatrix.JobEvent) error
+
+// Options represents the fields used to configure NewReceiver.
+type Options struct {
+	// Secret, when set, is the key the events are signed with in the gothreatmatrix.SIGNATURE_HEADER header
+	// (HMAC-SHA256, hex encoded) along with their gothreatmatrix.TIMESTAMP_HEADER, see Sign. The unsigned events
+	// are rejected, and so are the ones without a timestamp or signed more than Tolerance away from now,
+	// so that a captured event cannot be replayed later.
+	Secret string
+	// Tolerance is how far the timestamp of a signed event may be from the clock of the Receiver,
+	// DEFAULT_TOLERANCE when 0.
+	Tolerance time.Duration
+	// MaxBytes is the largest event accepted, DEFAULT_MAX_BYTES when 0.
+	MaxBytes int64
+}
+
+// Receiver is an http.Handler parsing the posted job events and dispatching them to the handlers registered for
+// their name, e.g. gothreatmatrix.JOB_COMPLETED_EVENT, then to the ones registered for ANY_EVENT.
+//
+// It answers 204 once every handler returned, 400 for bodies that are not events, 401 for a missing or wrong
+// signature or timestamp, 413 for bodies larger than MaxBytes and 500 when a handler failed.
+type Receiver struct {
+	options  Options
+	mutex    sync.RWMutex
+	handlers map[string][]HandlerFunc
+}
+
+// NewReceiver returns a Receiver with no handler. options can be nil.
+func NewReceiver(options *Options) *Receiver {
+	receiver := &Receiver{
+		handlers: map[string][]HandlerFunc{},
+	}
+	if options != nil {
+		receiver.options = *options
+	}
+	if receiver.options.MaxBytes <= 0 {
+		receiver.options.MaxBytes = DEFAULT_MAX_BYTES
+	}
+	if receiver.options.Tolerance <= 0 {
+		receiver.options.Tolerance = DEFAULT_TOLERANCE
+	}
+	return receiver
+}
+
+// Handle registers the handler for the events named event, or for every event with ANY_EVENT.
+// The handlers of an event run in the order they were registered, stopping at the first error.
+func (receiver *Receiver) Handle(event string, handler HandlerFunc) {
+	receiver.mutex.Lock()
+	defer receiver.mutex.Unlock()
+	receiver.handlers[event] = append(receiver.handlers[event], handler)
+}
+
+// Sign returns the signature of the body signed at the timestamp, in Unix seconds, with the secret, as sent in the
+// gothreatmatrix.SIGNATURE_HEADER header: the HMAC-SHA256 of the timestamp, a "." and the body.
+func Sign(body []byte, timestamp int64, secret string) string {
+	return hex.EncodeToString(signatureOf(body, timestamp, secret))
+}
+
+// Verify reports whether the signature is the one of the body signed at the timestamp with the secret,
+// in constant time.
+func Verify(body []byte, timestamp int64, signature string, secret string) bool {
+	received, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(received, signatureOf(body, timestamp, secret))
+}
+
+// signatureOf returns the HMAC-SHA256 of the timestamp and the body with the secret.
+func signatureOf(body []byte, timestamp int64, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// verifyRequest reports whether the request carries the signature of the body, signed within the Tolerance.
+func (receiver *Receiver) verifyRequest(r *http.Request, body []byte) bool {
+	timestamp, err := strconv.ParseInt(r.Header.Get(gothreatmatrix.TIMESTAMP_HEADER), 10, 64)
+	if err != nil {
+		return false
+	}
+	age := time.Since(time.Unix(timestamp, 0))
+	if age > receiver.options.Tolerance || age < -receiver.options.Tolerance {
+		return false
+	}
+	return Verify(body, timestamp, r.Header.Get(gothreatmatrix.SIGNATURE_HEADER), receiver.options.Secret)
+}
+
+// ParseEvent decodes a job event, failing when the body is not one.
+func ParseEvent(body []byte) (*gothreatmatrix.JobEvent, error) {
+	event := &gothreatmatrix.JobEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, err
+	}
+	if event.Event == "" || event.Job == nil {
+		return nil, ErrNotAnEvent
+	}
+	return event, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (receiver *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, receiver.options.MaxBytes+1))
+	if err != nil {
+		http.Error(w, "could not read the event", http.StatusBadRequest)
+		return
+	}
+	if int64(len(body)) > receiver.options.MaxBytes {
+		http.Error(w, "the event is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if receiver.options.Secret != "" && !receiver.verifyRequest(r, body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	event, err := ParseEvent(body)
+	if err != nil {
+		http.Error(w, "the body is not a job event", http.StatusBadRequest)
+		return
+	}
+	if err := receiver.dispatch(r.Context(), event); err != nil {
+		http.Error(w, "could not handle the event: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dispatch runs the handlers of the event, then the ones of every event.
+func (receiver *Receiver) dispatch(ctx context.Context, event *gothreatmatrix.JobEvent) error {
+	receiver.mutex.RLock()
+	handlers := append([]HandlerFunc{}, receiver.handlers[event.Event]...)
+	handlers = append(handlers, receiver.handlers[ANY_EVENT]...)
+	receiver.mutex.RUnlock()
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}